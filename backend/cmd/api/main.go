@@ -45,6 +45,17 @@ func main() {
 	dashboardRepo := repository.NewDashboardRepository(db)
 	notificationRepo := repository.NewNotificationRepository(db)
 	inventoryRepo := repository.NewInventoryRepository(db)
+	productionRepo := repository.NewProductionRepository(db)
+	salesRepo := repository.NewSalesRepository(db)
+	salesOrderRepo := repository.NewSalesOrderRepository(db)
+	purchaseRepo := repository.NewPurchaseRepository(db)
+	procurementRepo := repository.NewProcurementRepository(db)
+	dunningRepo := repository.NewDunningRepository(db)
+	fixedAssetRepo := repository.NewFixedAssetRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	expenseClaimRepo := repository.NewExpenseClaimRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
+	deferralRepo := repository.NewDeferralRepository(db)
 	auditLogRepo := repository.NewAuditLogRepository(db)
 	backupRepo := repository.NewBackupRepository(db)
 
@@ -57,6 +68,14 @@ func main() {
 		DBName:   cfg.DBName,
 	}
 
+	// Outgoing email: SMTP when configured, otherwise only logged
+	var emailSender services.EmailSender
+	if cfg.SMTPHost != "" {
+		emailSender = services.NewSMTPEmailSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.SMTPFrom)
+	} else {
+		emailSender = services.NewLogEmailSender()
+	}
+
 	// Initialize services
 	authService := services.NewAuthService(userRepo, cfg)
 	userService := services.NewUserService(userRepo)
@@ -66,14 +85,31 @@ func main() {
 	ledgerService := services.NewLedgerService(ledgerRepo)
 	reportService := services.NewReportService(reportRepo)
 	cashBankService := services.NewCashBankService(cashBankRepo, journalRepo, ledgerRepo, accountRepo)
-	taxService := services.NewTaxService(taxRepo)
+	taxService := services.NewTaxService(taxRepo, payrollRepo)
 	dashboardService := services.NewDashboardService(dashboardRepo)
-	notificationService := services.NewNotificationService(notificationRepo, taxRepo, userRepo)
-	inventoryService := services.NewInventoryService(inventoryRepo, journalRepo, accountRepo)
+	notificationService := services.NewNotificationService(notificationRepo, taxRepo, userRepo, inventoryRepo, loanRepo)
+	inventoryService := services.NewInventoryService(db, inventoryRepo, accountRepo, ledgerRepo, journalService, notificationService)
+	productionService := services.NewProductionService(db, productionRepo, inventoryRepo, accountRepo, inventoryService, journalService)
+	salesService := services.NewSalesService(db, salesRepo, salesOrderRepo, inventoryRepo, accountRepo, ledgerRepo, inventoryService, journalService, cashBankService)
+	salesOrderService := services.NewSalesOrderService(salesOrderRepo, salesRepo, inventoryRepo, inventoryService, salesService)
+	purchaseService := services.NewPurchaseService(db, purchaseRepo, procurementRepo, inventoryRepo, accountRepo, ledgerRepo, inventoryService, journalService, cashBankService)
+	procurementService := services.NewProcurementService(procurementRepo, purchaseRepo, inventoryRepo, accountRepo, ledgerRepo, inventoryService)
 	auditLogService := services.NewAuditLogService(auditLogRepo)
 	exportService := services.NewExportService()
+	dunningService := services.NewDunningService(dunningRepo, salesRepo, notificationService, exportService, emailSender)
+	fixedAssetService := services.NewFixedAssetService(db, fixedAssetRepo, accountRepo, journalService)
+	payrollService := services.NewPayrollService(db, payrollRepo, accountRepo, journalService, cashBankService, taxService)
+	expenseClaimService := services.NewExpenseClaimService(db, expenseClaimRepo, payrollRepo, accountRepo, journalService, cashBankService)
+	loanService := services.NewLoanService(db, loanRepo, accountRepo, journalService, cashBankService)
+	deferralService := services.NewDeferralService(db, deferralRepo, accountRepo, journalService)
 	backupService := services.NewBackupService(backupRepo, dbConfig)
 
+	// Add default accounts introduced since each company's chart was set up;
+	// a chart that needs fixing by hand is logged and does not stop the server
+	if err := accountService.BackfillDefaultAccounts(); err != nil {
+		log.Printf("Failed to backfill default accounts: %v", err)
+	}
+
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
 	userHandler := handlers.NewUserHandler(userService)
@@ -87,10 +123,45 @@ func main() {
 	dashboardHandler := handlers.NewDashboardHandler(dashboardService)
 	notificationHandler := handlers.NewNotificationHandler(notificationService)
 	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
+	productionHandler := handlers.NewProductionHandler(productionService)
+	salesHandler := handlers.NewSalesHandler(salesService)
+	salesOrderHandler := handlers.NewSalesOrderHandler(salesOrderService)
+	purchaseHandler := handlers.NewPurchaseHandler(purchaseService)
+	procurementHandler := handlers.NewProcurementHandler(procurementService)
+	dunningHandler := handlers.NewDunningHandler(dunningService)
+	fixedAssetHandler := handlers.NewFixedAssetHandler(fixedAssetService)
+	payrollHandler := handlers.NewPayrollHandler(payrollService)
+	expenseClaimHandler := handlers.NewExpenseClaimHandler(expenseClaimService)
+	loanHandler := handlers.NewLoanHandler(loanService)
+	deferralHandler := handlers.NewDeferralHandler(deferralService)
 	auditLogHandler := handlers.NewAuditLogHandler(auditLogService)
-	exportHandler := handlers.NewExportHandler(exportService, journalService, ledgerService, reportService)
+	exportHandler := handlers.NewExportHandler(exportService, journalService, ledgerService, reportService, inventoryService, salesService, purchaseService, dunningService)
 	backupHandler := handlers.NewBackupHandler(backupService)
 
+	// Scheduled postings are recorded as the system user
+	systemUser, err := userService.GetSystemUser()
+	if err != nil {
+		log.Fatalf("Failed to load system user: %v", err)
+	}
+
+	// Background checks (daily)
+	scheduler := services.NewScheduler(companyRepo, 24*time.Hour)
+	scheduler.Register("lot expiry notifications", notificationService.CheckAndCreateLotExpiryNotifications)
+	scheduler.Register("low stock notifications", notificationService.CheckAndCreateLowStockNotifications)
+	scheduler.Register("stock reservation expiry", inventoryService.ExpireReservations)
+	scheduler.Register("dunning reminders", dunningService.ProcessDunning)
+	scheduler.Register("monthly depreciation", func(companyID uint) error {
+		return fixedAssetService.ProcessDepreciation(companyID, systemUser.ID)
+	})
+	scheduler.Register("loan installment reminders", notificationService.CheckAndCreateLoanDueNotifications)
+	scheduler.Register("loan current portion", func(companyID uint) error {
+		return loanService.ProcessReclassification(companyID, systemUser.ID)
+	})
+	scheduler.Register("deferral amortization", func(companyID uint) error {
+		return deferralService.ProcessAmortization(companyID, systemUser.ID)
+	})
+	scheduler.Start()
+
 	// Setup Gin router
 	r := gin.Default()
 
@@ -218,6 +289,7 @@ func main() {
 				taxes.DELETE("/:id", taxHandler.DeleteTax)
 				taxes.POST("/:id/report", taxHandler.MarkAsReported)
 				taxes.POST("/:id/pay", taxHandler.MarkAsPaid)
+				taxes.POST("/pph21/calculate", taxHandler.CalculatePPh21)
 			}
 
 			// Dashboard & Analytics
@@ -251,24 +323,380 @@ func main() {
 				inventory.GET("/products/:id", inventoryHandler.GetProductByID)
 				inventory.PUT("/products/:id", inventoryHandler.UpdateProduct)
 				inventory.DELETE("/products/:id", inventoryHandler.DeleteProduct)
+				inventory.POST("/products/:id/units", inventoryHandler.SetProductUnit)
+				inventory.DELETE("/products/:id/units/:unit_id", inventoryHandler.DeleteProductUnit)
+
+				// Units of Measure
+				inventory.POST("/units", inventoryHandler.CreateUnitOfMeasure)
+				inventory.GET("/units", inventoryHandler.GetUnitsOfMeasure)
+				inventory.PUT("/units/:id", inventoryHandler.UpdateUnitOfMeasure)
+				inventory.DELETE("/units/:id", inventoryHandler.DeleteUnitOfMeasure)
 
 				// Stock Movements
 				inventory.POST("/movements", inventoryHandler.CreateStockMovement)
 				inventory.GET("/movements", inventoryHandler.GetStockMovements)
 
 				// Stock Balances
-				inventory.GET("/balances", inventoryHandler.GetStockBalances)
+				inventory.GET("/balances", inventoryHandler.GetStockBalances) // ?warehouse_id=&unit=purchase|sales|<code>
 				inventory.GET("/balances/:product_id", inventoryHandler.GetStockBalance)
 
+				// Cost Layers
+				inventory.GET("/cost-layers", inventoryHandler.GetCostLayers) // ?product_id=&warehouse_id=&open_only=true
+
+				// Serial Numbers
+				inventory.GET("/serials", inventoryHandler.GetSerialNumbers) // ?product_id=&warehouse_id=&status=
+				inventory.GET("/serials/:serial_number/history", inventoryHandler.GetSerialHistory)
+
+				// Lots / Batches
+				inventory.GET("/lots", inventoryHandler.GetStockLots) // ?product_id=&warehouse_id=&expiring_within_days=
+
+				// Warehouses
+				inventory.POST("/warehouses", inventoryHandler.CreateWarehouse)
+				inventory.GET("/warehouses", inventoryHandler.GetWarehouses)
+				inventory.PUT("/warehouses/:id", inventoryHandler.UpdateWarehouse)
+				inventory.DELETE("/warehouses/:id", inventoryHandler.DeleteWarehouse)
+
+				// Stock Transfers
+				inventory.POST("/transfers", inventoryHandler.CreateStockTransfer)
+				inventory.GET("/transfers", inventoryHandler.GetStockTransfers) // ?status=
+				inventory.GET("/transfers/:id", inventoryHandler.GetStockTransferByID)
+				inventory.POST("/transfers/:id/dispatch", inventoryHandler.DispatchStockTransfer)
+				inventory.POST("/transfers/:id/receive", inventoryHandler.ReceiveStockTransfer)
+				inventory.POST("/transfers/:id/cancel", inventoryHandler.CancelStockTransfer)
+
+				// Stock Reservations
+				inventory.POST("/reservations", inventoryHandler.CreateReservation)
+				inventory.GET("/reservations", inventoryHandler.GetReservations) // ?product_id=&warehouse_id=&status=
+				inventory.GET("/reservations/:id", inventoryHandler.GetReservationByID)
+				inventory.POST("/reservations/:id/release", inventoryHandler.ReleaseReservation)
+				inventory.GET("/atp", inventoryHandler.GetAvailableToPromise) // ?product_ids=1,2,3&warehouse_id=
+
+				// Landed Costs
+				inventory.POST("/landed-costs", inventoryHandler.CreateLandedCost)
+				inventory.GET("/landed-costs", inventoryHandler.GetLandedCosts) // ?status=
+				inventory.GET("/landed-costs/:id", inventoryHandler.GetLandedCostByID)
+				inventory.POST("/landed-costs/:id/post", inventoryHandler.PostLandedCost)
+				inventory.POST("/landed-costs/:id/cancel", inventoryHandler.CancelLandedCost)
+
+				// GL Account Mapping & Reconciliation
+				inventory.POST("/account-mappings", inventoryHandler.CreateAccountMapping)
+				inventory.GET("/account-mappings", inventoryHandler.GetAccountMappings)
+				inventory.PUT("/account-mappings/:id", inventoryHandler.UpdateAccountMapping)
+				inventory.DELETE("/account-mappings/:id", inventoryHandler.DeleteAccountMapping)
+				inventory.GET("/gl-reconciliation", inventoryHandler.GetGLReconciliation) // ?as_of_date=
+
 				// Stock Opname
 				inventory.POST("/opname", inventoryHandler.CreateStockOpname)
-				inventory.GET("/opname", inventoryHandler.GetStockOpnames)
+				inventory.GET("/opname", inventoryHandler.GetStockOpnames) // ?status=
+				inventory.GET("/opname/:id", inventoryHandler.GetStockOpnameByID)
+				inventory.POST("/opname/:id/counts", inventoryHandler.SubmitOpnameCounts)
+				inventory.GET("/opname/:id/counts", inventoryHandler.GetOpnameCounts) // ?round=
+				inventory.POST("/opname/:id/review", inventoryHandler.ReviewStockOpname)
 				inventory.POST("/opname/:id/approve", inventoryHandler.ApproveStockOpname)
+				inventory.POST("/opname/:id/cancel", inventoryHandler.CancelStockOpname)
+
+				// Reorder Suggestions
+				inventory.GET("/reorder-suggestions", inventoryHandler.GetReorderSuggestions) // ?lookback_days=90&only_needed=true
+
+				// Inventory Reports
+				inventory.GET("/stock-card/:product_id", inventoryHandler.GetStockCard) // ?start_date=&end_date=&warehouse_id=
+				inventory.GET("/valuation", inventoryHandler.GetInventoryValuation)     // ?as_of_date=&warehouse_id=
+				inventory.GET("/aging", inventoryHandler.GetInventoryAging)             // ?slow_days=90&dead_days=180&warehouse_id=
 
 				// HPP Calculation
 				inventory.GET("/hpp", inventoryHandler.CalculateHPP)
 			}
 
+			// Production / Assembly
+			production := protected.Group("/production")
+			{
+				// Bill of Materials
+				production.GET("/boms/:product_id", productionHandler.GetBOM)
+				production.PUT("/boms/:product_id", productionHandler.SetBOM)
+				production.GET("/boms/:product_id/cost", productionHandler.GetBOMCost) // ?quantity=
+
+				// Production Orders
+				production.POST("/orders", productionHandler.CreateProductionOrder)
+				production.GET("/orders", productionHandler.GetProductionOrders) // ?status=
+				production.GET("/orders/:id", productionHandler.GetProductionOrderByID)
+				production.POST("/orders/:id/start", productionHandler.StartProductionOrder)
+				production.POST("/orders/:id/complete", productionHandler.CompleteProductionOrder)
+				production.POST("/orders/:id/cancel", productionHandler.CancelProductionOrder)
+			}
+
+			// Customers
+			customers := protected.Group("/customers")
+			{
+				customers.POST("", salesHandler.CreateCustomer)
+				customers.GET("", salesHandler.GetCustomers) // ?active=true
+				customers.GET("/:id", salesHandler.GetCustomerByID)
+				customers.PUT("/:id", salesHandler.UpdateCustomer)
+				customers.DELETE("/:id", salesHandler.DeleteCustomer)
+			}
+
+			// Sales / Accounts Receivable
+			sales := protected.Group("/sales")
+			{
+				// Sales Quotations
+				sales.POST("/quotations", salesOrderHandler.CreateSalesQuotation)
+				sales.GET("/quotations", salesOrderHandler.GetSalesQuotations) // ?customer_id=&status=
+				sales.GET("/quotations/:id", salesOrderHandler.GetSalesQuotationByID)
+				sales.POST("/quotations/:id/send", salesOrderHandler.SendSalesQuotation)
+				sales.POST("/quotations/:id/accept", salesOrderHandler.AcceptSalesQuotation)
+				sales.POST("/quotations/:id/reject", salesOrderHandler.RejectSalesQuotation)
+				sales.POST("/quotations/:id/cancel", salesOrderHandler.CancelSalesQuotation)
+				sales.POST("/quotations/:id/convert", salesOrderHandler.ConvertSalesQuotation) // ?order_date=
+
+				// Sales Orders
+				sales.POST("/orders", salesOrderHandler.CreateSalesOrder)
+				sales.GET("/orders", salesOrderHandler.GetSalesOrders) // ?customer_id=&status=
+				sales.GET("/orders/:id", salesOrderHandler.GetSalesOrderByID)
+				sales.POST("/orders/:id/confirm", salesOrderHandler.ConfirmSalesOrder)
+				sales.POST("/orders/:id/close", salesOrderHandler.CloseSalesOrder)
+				sales.POST("/orders/:id/cancel", salesOrderHandler.CancelSalesOrder)
+
+				// Delivery Orders
+				sales.POST("/deliveries", salesOrderHandler.CreateDeliveryOrder)
+				sales.GET("/deliveries", salesOrderHandler.GetDeliveryOrders) // ?customer_id=&sales_order_id=&status=
+				sales.GET("/deliveries/:id", salesOrderHandler.GetDeliveryOrderByID)
+				sales.POST("/deliveries/:id/post", salesOrderHandler.PostDeliveryOrder)
+				sales.POST("/deliveries/:id/cancel", salesOrderHandler.CancelDeliveryOrder)
+
+				// Sales Invoices
+				sales.POST("/invoices/from-deliveries", salesOrderHandler.CreateSalesInvoiceFromDeliveries)
+				sales.POST("/invoices", salesHandler.CreateSalesInvoice)
+				sales.GET("/invoices", salesHandler.GetSalesInvoices) // ?customer_id=&status=
+				sales.GET("/invoices/:id", salesHandler.GetSalesInvoiceByID)
+				sales.POST("/invoices/:id/post", salesHandler.PostSalesInvoice)
+				sales.POST("/invoices/:id/cancel", salesHandler.CancelSalesInvoice)
+
+				// Customer Payments
+				sales.POST("/payments", salesHandler.CreateCustomerPayment)
+				sales.GET("/payments", salesHandler.GetCustomerPayments) // ?customer_id=
+				sales.GET("/payments/:id", salesHandler.GetCustomerPaymentByID)
+
+				// Credit Notes
+				sales.POST("/credit-notes", salesHandler.CreateSalesCreditNote)
+				sales.GET("/credit-notes", salesHandler.GetSalesCreditNotes) // ?customer_id=&invoice_id=&status=
+				sales.GET("/credit-notes/:id", salesHandler.GetSalesCreditNoteByID)
+				sales.POST("/credit-notes/:id/post", salesHandler.PostSalesCreditNote)
+				sales.POST("/credit-notes/:id/cancel", salesHandler.CancelSalesCreditNote)
+				sales.POST("/credit-notes/:id/apply", salesHandler.ApplySalesCreditNote)   // customer credit to another open invoice
+				sales.POST("/credit-notes/:id/refund", salesHandler.RefundSalesCreditNote) // customer credit paid back
+
+				// Receivable Aging
+				sales.GET("/aging", salesHandler.GetReceivableAging) // ?as_of_date=&buckets=30,60,90&customer_id=
+
+				// Backorders
+				sales.GET("/reports/backorders", salesOrderHandler.GetBackorderReport) // ?customer_id=
+
+				// Customer Statements
+				sales.GET("/statements/:customer_id", salesHandler.GetCustomerStatement) // ?start_date=&end_date=
+
+				// Dunning
+				sales.GET("/dunning/levels", dunningHandler.GetDunningLevels)
+				sales.PUT("/dunning/levels", dunningHandler.SetDunningLevels)
+				sales.POST("/dunning/run", dunningHandler.RunDunning)               // ?as_of_date=
+				sales.GET("/dunning/reminders", dunningHandler.GetDunningReminders) // ?customer_id=
+				sales.GET("/dunning/reminders/:id", dunningHandler.GetDunningReminderByID)
+				sales.POST("/dunning/reminders/:id/resend", dunningHandler.ResendDunningReminder)
+			}
+
+			// Vendors
+			vendors := protected.Group("/vendors")
+			{
+				vendors.POST("", purchaseHandler.CreateVendor)
+				vendors.GET("", purchaseHandler.GetVendors) // ?active=true
+				vendors.GET("/:id", purchaseHandler.GetVendorByID)
+				vendors.PUT("/:id", purchaseHandler.UpdateVendor)
+				vendors.DELETE("/:id", purchaseHandler.DeleteVendor)
+			}
+
+			// Purchasing / Accounts Payable
+			purchases := protected.Group("/purchases")
+			{
+				// Purchase Requisitions
+				purchases.POST("/requisitions", procurementHandler.CreatePurchaseRequisition)
+				purchases.GET("/requisitions", procurementHandler.GetPurchaseRequisitions) // ?status=
+				purchases.GET("/requisitions/:id", procurementHandler.GetPurchaseRequisitionByID)
+				purchases.POST("/requisitions/:id/approve", procurementHandler.ApprovePurchaseRequisition)
+				purchases.POST("/requisitions/:id/reject", procurementHandler.RejectPurchaseRequisition)
+				purchases.POST("/requisitions/:id/cancel", procurementHandler.CancelPurchaseRequisition)
+
+				// Purchase Orders
+				purchases.POST("/orders", procurementHandler.CreatePurchaseOrder)
+				purchases.GET("/orders", procurementHandler.GetPurchaseOrders) // ?vendor_id=&status=
+				purchases.GET("/orders/:id", procurementHandler.GetPurchaseOrderByID)
+				purchases.POST("/orders/:id/approve", procurementHandler.ApprovePurchaseOrder)
+				purchases.POST("/orders/:id/close", procurementHandler.ClosePurchaseOrder)
+				purchases.POST("/orders/:id/cancel", procurementHandler.CancelPurchaseOrder)
+
+				// Goods Receipts
+				purchases.POST("/receipts", procurementHandler.CreateGoodsReceipt)
+				purchases.GET("/receipts", procurementHandler.GetGoodsReceipts) // ?vendor_id=&purchase_order_id=&status=
+				purchases.GET("/receipts/:id", procurementHandler.GetGoodsReceiptByID)
+				purchases.POST("/receipts/:id/post", procurementHandler.PostGoodsReceipt)
+				purchases.POST("/receipts/:id/cancel", procurementHandler.CancelGoodsReceipt)
+
+				// Three-way Match Tolerance
+				purchases.GET("/match-tolerance", procurementHandler.GetMatchTolerance)
+				purchases.PUT("/match-tolerance", middleware.RoleMiddleware("admin"), procurementHandler.SetMatchTolerance)
+
+				// Purchase Bills
+				purchases.POST("/bills", purchaseHandler.CreatePurchaseBill)
+				purchases.GET("/bills", purchaseHandler.GetPurchaseBills) // ?vendor_id=&status=
+				purchases.GET("/bills/:id", purchaseHandler.GetPurchaseBillByID)
+				purchases.POST("/bills/:id/post", purchaseHandler.PostPurchaseBill)
+				purchases.POST("/bills/:id/cancel", purchaseHandler.CancelPurchaseBill)
+				purchases.POST("/bills/:id/approve-match", middleware.RoleMiddleware("admin"), purchaseHandler.ApprovePurchaseBillMatch)
+
+				// Vendor Payments
+				purchases.POST("/payments", purchaseHandler.CreateVendorPayment)
+				purchases.GET("/payments", purchaseHandler.GetVendorPayments) // ?vendor_id=
+				purchases.GET("/payments/:id", purchaseHandler.GetVendorPaymentByID)
+
+				// Debit Notes
+				purchases.POST("/debit-notes", purchaseHandler.CreatePurchaseDebitNote)
+				purchases.GET("/debit-notes", purchaseHandler.GetPurchaseDebitNotes) // ?vendor_id=&bill_id=&status=
+				purchases.GET("/debit-notes/:id", purchaseHandler.GetPurchaseDebitNoteByID)
+				purchases.POST("/debit-notes/:id/post", purchaseHandler.PostPurchaseDebitNote)
+				purchases.POST("/debit-notes/:id/cancel", purchaseHandler.CancelPurchaseDebitNote)
+				purchases.POST("/debit-notes/:id/apply", purchaseHandler.ApplyPurchaseDebitNote)   // vendor credit to another open bill
+				purchases.POST("/debit-notes/:id/refund", purchaseHandler.RefundPurchaseDebitNote) // vendor credit paid back
+
+				// Payment Runs
+				purchases.POST("/payment-runs", purchaseHandler.CreatePaymentRun)
+				purchases.GET("/payment-runs", purchaseHandler.GetPaymentRuns) // ?status=
+				purchases.GET("/payment-runs/:id", purchaseHandler.GetPaymentRunByID)
+				purchases.PUT("/payment-runs/:id/lines/:line_id", purchaseHandler.UpdatePaymentRunLine)
+				purchases.POST("/payment-runs/:id/process", purchaseHandler.ProcessPaymentRun)
+				purchases.POST("/payment-runs/:id/cancel", purchaseHandler.CancelPaymentRun)
+
+				// Payable Aging
+				purchases.GET("/aging", purchaseHandler.GetPayableAging) // ?as_of_date=&buckets=30,60,90&vendor_id=
+
+				// Procurement Reports
+				purchases.GET("/reports/open-orders", procurementHandler.GetOpenPurchaseOrders) // ?vendor_id=
+				purchases.GET("/reports/grni", procurementHandler.GetGRNIReport)                // ?as_of_date=&vendor_id=
+			}
+
+			fixedAssets := protected.Group("/fixed-assets")
+			{
+				// Asset Register
+				fixedAssets.POST("", fixedAssetHandler.CreateFixedAsset)
+				fixedAssets.GET("", fixedAssetHandler.GetFixedAssets) // ?status=&fiscal_group=
+				fixedAssets.GET("/:id", fixedAssetHandler.GetFixedAssetByID)
+				fixedAssets.PUT("/:id", fixedAssetHandler.UpdateFixedAsset)
+				fixedAssets.DELETE("/:id", fixedAssetHandler.DeleteFixedAsset)
+				fixedAssets.GET("/:id/schedule", fixedAssetHandler.GetDepreciationSchedule)
+				fixedAssets.GET("/:id/history", fixedAssetHandler.GetFixedAssetHistory)
+
+				// Asset Events
+				fixedAssets.POST("/:id/transfer", fixedAssetHandler.TransferFixedAsset)
+				fixedAssets.POST("/:id/revalue", fixedAssetHandler.RevalueFixedAsset)
+				fixedAssets.POST("/:id/impair", fixedAssetHandler.ImpairFixedAsset)
+				fixedAssets.POST("/:id/dispose", fixedAssetHandler.DisposeFixedAsset)
+
+				// Depreciation Runs
+				fixedAssets.POST("/depreciation-runs", fixedAssetHandler.RunDepreciation)
+				fixedAssets.GET("/depreciation-runs", fixedAssetHandler.GetDepreciationRuns)
+				fixedAssets.GET("/depreciation-runs/:id", fixedAssetHandler.GetDepreciationRunByID)
+
+				// Fixed Asset Reports
+				fixedAssets.GET("/reports/depreciation", fixedAssetHandler.GetDepreciationReport) // ?year=
+			}
+
+			payroll := protected.Group("/payroll")
+			{
+				// Salary Components
+				payroll.POST("/components", payrollHandler.CreateSalaryComponent)
+				payroll.GET("/components", payrollHandler.GetSalaryComponents) // ?active=true
+				payroll.PUT("/components/:id", payrollHandler.UpdateSalaryComponent)
+
+				// Employees
+				payroll.POST("/employees", payrollHandler.CreateEmployee)
+				payroll.GET("/employees", payrollHandler.GetEmployees) // ?department=&active=true
+				payroll.GET("/employees/:id", payrollHandler.GetEmployeeByID)
+				payroll.PUT("/employees/:id", payrollHandler.UpdateEmployee)
+				payroll.DELETE("/employees/:id", payrollHandler.DeleteEmployee)
+				payroll.GET("/employees/:id/payslips", payrollHandler.GetEmployeePayslips) // ?year=
+
+				// BPJS Settings
+				payroll.GET("/settings", payrollHandler.GetPayrollSetting)
+				payroll.PUT("/settings", payrollHandler.SetPayrollSetting)
+
+				// Payroll Runs
+				payroll.POST("/runs", payrollHandler.CreatePayrollRun)
+				payroll.GET("/runs", payrollHandler.GetPayrollRuns) // ?year=
+				payroll.GET("/runs/:id", payrollHandler.GetPayrollRunByID)
+				payroll.DELETE("/runs/:id", payrollHandler.DeletePayrollRun)
+				payroll.POST("/runs/:id/post", payrollHandler.PostPayrollRun)
+				payroll.POST("/runs/:id/pay", payrollHandler.PayPayrollRun)
+				payroll.GET("/payslips/:id", payrollHandler.GetPayslipByID)
+			}
+
+			expenses := protected.Group("/expenses")
+			{
+				// Cash Advances
+				expenses.POST("/advances", expenseClaimHandler.CreateCashAdvance)
+				expenses.GET("/advances", expenseClaimHandler.GetCashAdvances) // ?employee_id=&status=
+				expenses.GET("/advances/:id", expenseClaimHandler.GetCashAdvanceByID)
+				expenses.POST("/advances/:id/approve", expenseClaimHandler.ApproveCashAdvance)
+				expenses.POST("/advances/:id/reject", expenseClaimHandler.RejectCashAdvance)
+				expenses.POST("/advances/:id/cancel", expenseClaimHandler.CancelCashAdvance)
+				expenses.POST("/advances/:id/disburse", expenseClaimHandler.DisburseCashAdvance)
+
+				// Expense Claims
+				expenses.POST("/claims", expenseClaimHandler.CreateExpenseClaim)
+				expenses.GET("/claims", expenseClaimHandler.GetExpenseClaims) // ?employee_id=&status=
+				expenses.GET("/claims/:id", expenseClaimHandler.GetExpenseClaimByID)
+				expenses.POST("/claims/:id/approve", expenseClaimHandler.ApproveExpenseClaim)
+				expenses.POST("/claims/:id/reject", expenseClaimHandler.RejectExpenseClaim)
+				expenses.POST("/claims/:id/cancel", expenseClaimHandler.CancelExpenseClaim)
+				expenses.POST("/claims/:id/settle", expenseClaimHandler.SettleExpenseClaim)
+
+				// Receipt Attachments
+				expenses.POST("/claim-items/:item_id/attachments", expenseClaimHandler.UploadAttachment) // multipart file=
+				expenses.GET("/attachments/:id", expenseClaimHandler.DownloadAttachment)
+				expenses.DELETE("/attachments/:id", expenseClaimHandler.DeleteAttachment)
+
+				// Expense Reports
+				expenses.GET("/reports/outstanding-advances", expenseClaimHandler.GetOutstandingAdvances) // ?as_of_date=&employee_id=
+			}
+
+			loans := protected.Group("/loans")
+			{
+				loans.POST("", loanHandler.CreateLoan)
+				loans.GET("", loanHandler.GetLoans) // ?status=
+				loans.GET("/:id", loanHandler.GetLoanByID)
+				loans.POST("/:id/pay", loanHandler.PayLoanInstallment)
+
+				// Floating Rate Changes
+				loans.POST("/:id/rate-changes", loanHandler.ChangeLoanRate)
+				loans.GET("/:id/rate-changes", loanHandler.GetLoanRateChanges)
+
+				// Current Portion Reclassifications
+				loans.POST("/reclassifications", loanHandler.RunReclassification)
+				loans.GET("/reclassifications", loanHandler.GetReclassifications)
+				loans.GET("/reclassifications/:id", loanHandler.GetReclassificationByID)
+			}
+
+			deferrals := protected.Group("/deferrals")
+			{
+				deferrals.POST("", deferralHandler.CreateDeferralSchedule)
+				deferrals.GET("", deferralHandler.GetDeferralSchedules) // ?type=&status=
+				deferrals.GET("/:id", deferralHandler.GetDeferralScheduleByID)
+				deferrals.DELETE("/:id", deferralHandler.DeleteDeferralSchedule)
+
+				// Period-End Amortization Runs
+				deferrals.POST("/runs", deferralHandler.RunAmortization)
+				deferrals.GET("/runs", deferralHandler.GetDeferralRuns)
+				deferrals.GET("/runs/:id", deferralHandler.GetDeferralRunByID)
+
+				// Deferral Reports
+				deferrals.GET("/reports/roll-forward", deferralHandler.GetRollForwardReport) // ?start_date=&end_date=&type=
+			}
+
 			// Audit Logs (NEW - FASE 5)
 			auditLogs := protected.Group("/audit-logs")
 			auditLogs.Use(middleware.RoleMiddleware("admin")) // Only admin can view audit logs
@@ -286,6 +714,18 @@ func main() {
 				exports.GET("/trial-balance", exportHandler.ExportTrialBalance)       // ?format=csv/excel&end_date=
 				exports.GET("/income-statement", exportHandler.ExportIncomeStatement) // ?format=csv/excel&start_date=&end_date=
 				exports.GET("/balance-sheet", exportHandler.ExportBalanceSheet)       // ?format=csv/excel&as_of_date=
+
+				// Inventory Reports
+				exports.GET("/stock-card", exportHandler.ExportStockCard)                   // ?format=csv/excel&product_id=&start_date=&end_date=&warehouse_id=
+				exports.GET("/inventory-valuation", exportHandler.ExportInventoryValuation) // ?format=csv/excel&as_of_date=&warehouse_id=
+				exports.GET("/inventory-aging", exportHandler.ExportInventoryAging)         // ?format=csv/excel&slow_days=&dead_days=&warehouse_id=
+				exports.GET("/opname-count-sheet", exportHandler.ExportOpnameCountSheet)    // ?format=csv/excel&opname_id=&show_system=false&recount_only=false
+
+				// Receivable / Payable Reports
+				exports.GET("/receivable-aging", exportHandler.ExportReceivableAging)     // ?format=csv/excel&as_of_date=&buckets=30,60,90&customer_id=&detail=true
+				exports.GET("/payable-aging", exportHandler.ExportPayableAging)           // ?format=csv/excel&as_of_date=&buckets=30,60,90&vendor_id=&detail=true
+				exports.GET("/customer-statement", exportHandler.ExportCustomerStatement) // ?format=pdf/csv/excel&customer_id=&start_date=&end_date=
+				exports.GET("/dunning-reminder", exportHandler.ExportDunningReminder)     // ?reminder_id=
 			}
 
 			// Backup & Restore (NEW - FASE 5)
@@ -25,6 +25,11 @@ type Config struct {
 	JWTSecret     string
 	JWTExpiration time.Duration
 	CORSOrigins   []string
+	SMTPHost      string // empty = emails are logged, not sent
+	SMTPPort      string
+	SMTPUsername  string
+	SMTPPassword  string
+	SMTPFrom      string
 }
 
 func LoadConfig() *Config {
@@ -49,6 +54,11 @@ func LoadConfig() *Config {
 		DBLoc:         getEnv("DB_LOC", "Local"),
 		JWTSecret:     getEnv("JWT_SECRET", "your-secret-key"),
 		JWTExpiration: 24 * time.Hour,
+		SMTPHost:      getEnv("SMTP_HOST", ""),
+		SMTPPort:      getEnv("SMTP_PORT", "25"),
+		SMTPUsername:  getEnv("SMTP_USERNAME", ""),
+		SMTPPassword:  getEnv("SMTP_PASSWORD", ""),
+		SMTPFrom:      getEnv("SMTP_FROM", "noreply@finara.local"),
 	}
 }
 
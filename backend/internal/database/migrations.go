@@ -21,11 +21,90 @@ func RunMigrations(db *gorm.DB) error {
 		&models.BankReconciliation{},
 		&models.Tax{},
 		&models.Notification{},
+		&models.UnitOfMeasure{},
 		&models.Product{},
+		&models.ProductUnit{},
+		&models.BOMItem{},
+		&models.Warehouse{},
 		&models.StockMovement{},
 		&models.StockBalance{},
+		&models.StockCostLayer{},
+		&models.StockLayerConsumption{},
+		&models.StockLot{},
+		&models.StockLotAllocation{},
+		&models.SerialNumber{},
+		&models.SerialNumberEvent{},
+		&models.InventoryAccountMapping{},
+		&models.StockTransfer{},
+		&models.StockTransferItem{},
+		&models.StockReservation{},
+		&models.LandedCost{},
+		&models.LandedCostCharge{},
+		&models.LandedCostItem{},
 		&models.StockOpname{},
 		&models.StockOpnameItem{},
+		&models.StockOpnameCount{},
+		&models.ProductionOrder{},
+		&models.ProductionOrderItem{},
+		&models.Customer{},
+		&models.SalesQuotation{},
+		&models.SalesQuotationItem{},
+		&models.SalesOrder{},
+		&models.SalesOrderItem{},
+		&models.DeliveryOrder{},
+		&models.DeliveryOrderItem{},
+		&models.SalesInvoice{},
+		&models.SalesInvoiceItem{},
+		&models.CustomerPayment{},
+		&models.CustomerPaymentAllocation{},
+		&models.SalesCreditNote{},
+		&models.SalesCreditNoteItem{},
+		&models.SalesCreditNoteApplication{},
+		&models.DunningLevel{},
+		&models.DunningReminder{},
+		&models.DunningReminderItem{},
+		&models.Vendor{},
+		&models.PurchaseRequisition{},
+		&models.PurchaseRequisitionItem{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+		&models.PurchaseMatchTolerance{},
+		&models.PurchaseBill{},
+		&models.PurchaseBillItem{},
+		&models.VendorPayment{},
+		&models.VendorPaymentAllocation{},
+		&models.PurchaseDebitNote{},
+		&models.PurchaseDebitNoteItem{},
+		&models.PurchaseDebitNoteApplication{},
+		&models.PaymentRun{},
+		&models.PaymentRunLine{},
+		&models.FixedAsset{},
+		&models.DepreciationScheduleLine{},
+		&models.FixedAssetEvent{},
+		&models.DepreciationRun{},
+		&models.DepreciationRunLine{},
+		&models.SalaryComponent{},
+		&models.Employee{},
+		&models.EmployeeSalaryComponent{},
+		&models.PayrollSetting{},
+		&models.PayrollRun{},
+		&models.Payslip{},
+		&models.PayslipLine{},
+		&models.CashAdvance{},
+		&models.ExpenseClaim{},
+		&models.ExpenseClaimItem{},
+		&models.ExpenseClaimAttachment{},
+		&models.Loan{},
+		&models.LoanInstallment{},
+		&models.LoanRateChange{},
+		&models.LoanReclassification{},
+		&models.LoanReclassificationLine{},
+		&models.DeferralSchedule{},
+		&models.DeferralScheduleLine{},
+		&models.DeferralRun{},
+		&models.DeferralRunLine{},
 		&models.AuditLog{},
 		&models.Backup{},
 	)
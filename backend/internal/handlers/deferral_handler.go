@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DeferralHandler struct {
+	deferralService services.DeferralService
+}
+
+func NewDeferralHandler(deferralService services.DeferralService) *DeferralHandler {
+	return &DeferralHandler{deferralService: deferralService}
+}
+
+// Deferral Schedule Handlers
+type CreateDeferralScheduleRequest struct {
+	Type                  string  `json:"type" binding:"required"` // prepaid_expense, deferred_revenue
+	Description           string  `json:"description" binding:"required"`
+	Reference             string  `json:"reference"`
+	BalanceSheetAccountID uint    `json:"balance_sheet_account_id"` // omitted = 1-1800 Biaya Dibayar di Muka or 2-1900 Pendapatan Diterima di Muka
+	PLAccountID           uint    `json:"pl_account_id" binding:"required"`
+	Amount                float64 `json:"amount" binding:"required,gt=0"`
+	StartDate             string  `json:"start_date" binding:"required"`
+	EndDate               string  `json:"end_date" binding:"required"`
+	Method                string  `json:"method"` // daily, monthly; omitted = monthly
+	Notes                 string  `json:"notes"`
+}
+
+func (h *DeferralHandler) CreateDeferralSchedule(c *gin.Context) {
+	var req CreateDeferralScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	schedule := &models.DeferralSchedule{
+		CompanyID:             companyID.(uint),
+		Type:                  models.DeferralType(req.Type),
+		Description:           req.Description,
+		Reference:             req.Reference,
+		BalanceSheetAccountID: req.BalanceSheetAccountID,
+		PLAccountID:           req.PLAccountID,
+		Amount:                req.Amount,
+		StartDate:             startDate,
+		EndDate:               endDate,
+		Method:                models.DeferralMethod(req.Method),
+		Notes:                 req.Notes,
+		CreatedBy:             userID.(uint),
+	}
+
+	if err := h.deferralService.CreateDeferralSchedule(schedule); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create deferral schedule", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Deferral schedule created successfully", schedule)
+}
+
+func (h *DeferralHandler) GetDeferralSchedules(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	schedules, err := h.deferralService.GetDeferralSchedules(companyID.(uint), c.Query("type"), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve deferral schedules", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Deferral schedules retrieved successfully", schedules)
+}
+
+func (h *DeferralHandler) GetDeferralScheduleByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid deferral schedule ID", err)
+		return
+	}
+
+	schedule, err := h.deferralService.GetDeferralScheduleByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Deferral schedule not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Deferral schedule retrieved successfully", schedule)
+}
+
+func (h *DeferralHandler) DeleteDeferralSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid deferral schedule ID", err)
+		return
+	}
+
+	if err := h.deferralService.DeleteDeferralSchedule(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete deferral schedule", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Deferral schedule deleted successfully", nil)
+}
+
+// Deferral Run Handlers
+type RunDeferralAmortizationRequest struct {
+	Period string `json:"period" binding:"required"` // YYYY-MM
+}
+
+func (h *DeferralHandler) RunAmortization(c *gin.Context) {
+	var req RunDeferralAmortizationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	run, err := h.deferralService.RunAmortization(companyID.(uint), req.Period, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to run amortization", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Amortization posted successfully", run)
+}
+
+func (h *DeferralHandler) GetDeferralRuns(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	runs, err := h.deferralService.GetDeferralRuns(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve amortization runs", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Amortization runs retrieved successfully", runs)
+}
+
+func (h *DeferralHandler) GetDeferralRunByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid amortization run ID", err)
+		return
+	}
+
+	run, err := h.deferralService.GetDeferralRunByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Amortization run not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Amortization run retrieved successfully", run)
+}
+
+// Report Handlers
+func (h *DeferralHandler) GetRollForwardReport(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	startDateStr := c.Query("start_date")
+	endDateStr := c.Query("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "start_date and end_date are required", nil)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid start_date format", err)
+		return
+	}
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid end_date format", err)
+		return
+	}
+
+	report, err := h.deferralService.GetRollForwardReport(companyID.(uint), c.Query("type"), startDate, endDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to generate roll-forward report", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Roll-forward report generated successfully", report)
+}
\ No newline at end of file
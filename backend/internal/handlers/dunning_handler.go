@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DunningHandler struct {
+	dunningService services.DunningService
+}
+
+func NewDunningHandler(dunningService services.DunningService) *DunningHandler {
+	return &DunningHandler{dunningService: dunningService}
+}
+
+// Dunning Level Handlers
+type DunningLevelRequest struct {
+	Level       int    `json:"level" binding:"required"`
+	DaysOverdue int    `json:"days_overdue" binding:"required"`
+	Name        string `json:"name" binding:"required"`
+	Subject     string `json:"subject"`
+	Message     string `json:"message"`
+}
+
+type SetDunningLevelsRequest struct {
+	Levels []DunningLevelRequest `json:"levels" binding:"required,dive"`
+}
+
+func (h *DunningHandler) GetDunningLevels(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	levels, err := h.dunningService.GetDunningLevels(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve dunning levels", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dunning levels retrieved successfully", levels)
+}
+
+// SetDunningLevels replaces the company's dunning levels
+func (h *DunningHandler) SetDunningLevels(c *gin.Context) {
+	var req SetDunningLevelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	levels := make([]models.DunningLevel, len(req.Levels))
+	for i, level := range req.Levels {
+		levels[i] = models.DunningLevel{
+			Level:       level.Level,
+			DaysOverdue: level.DaysOverdue,
+			Name:        level.Name,
+			Subject:     level.Subject,
+			Message:     level.Message,
+		}
+	}
+
+	levels, err := h.dunningService.SetDunningLevels(companyID.(uint), levels)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to save dunning levels", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dunning levels saved successfully", levels)
+}
+
+// Dunning Run Handlers
+func (h *DunningHandler) RunDunning(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	asOfDateStr := c.Query("as_of_date")
+	if asOfDateStr == "" {
+		asOfDateStr = time.Now().Format("2006-01-02")
+	}
+	asOfDate, err := time.Parse("2006-01-02", asOfDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid as_of_date format", err)
+		return
+	}
+
+	reminders, err := h.dunningService.RunDunning(companyID.(uint), asOfDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to run dunning", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dunning run completed successfully", reminders)
+}
+
+// Dunning Reminder Handlers
+func (h *DunningHandler) GetDunningReminders(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	reminders, err := h.dunningService.GetDunningReminders(companyID.(uint), customerID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve dunning reminders", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dunning reminders retrieved successfully", reminders)
+}
+
+func (h *DunningHandler) GetDunningReminderByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid dunning reminder ID", err)
+		return
+	}
+
+	reminder, err := h.dunningService.GetDunningReminderByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Dunning reminder not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dunning reminder retrieved successfully", reminder)
+}
+
+func (h *DunningHandler) ResendDunningReminder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid dunning reminder ID", err)
+		return
+	}
+
+	reminder, err := h.dunningService.ResendDunningReminder(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to resend dunning reminder", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Dunning reminder resent successfully", reminder)
+}
\ No newline at end of file
@@ -0,0 +1,477 @@
+package handlers
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const maxAttachmentSize = 10 << 20 // 10 MB
+
+type ExpenseClaimHandler struct {
+	expenseClaimService services.ExpenseClaimService
+}
+
+func NewExpenseClaimHandler(expenseClaimService services.ExpenseClaimService) *ExpenseClaimHandler {
+	return &ExpenseClaimHandler{expenseClaimService: expenseClaimService}
+}
+
+// Cash Advance Handlers
+type CreateCashAdvanceRequest struct {
+	EmployeeID  uint    `json:"employee_id" binding:"required"`
+	RequestDate string  `json:"request_date" binding:"required"`
+	Purpose     string  `json:"purpose" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	Notes       string  `json:"notes"`
+}
+
+type SettleExpenseRequest struct {
+	AccountID uint   `json:"account_id" binding:"required"` // cash or bank account
+	Date      string `json:"date" binding:"required"`
+	Reference string `json:"reference"`
+}
+
+func (h *ExpenseClaimHandler) CreateCashAdvance(c *gin.Context) {
+	var req CreateCashAdvanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	requestDate, err := time.Parse("2006-01-02", req.RequestDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	advance := &models.CashAdvance{
+		CompanyID:   companyID.(uint),
+		EmployeeID:  req.EmployeeID,
+		RequestDate: requestDate,
+		Purpose:     req.Purpose,
+		Amount:      req.Amount,
+		Notes:       req.Notes,
+		CreatedBy:   userID.(uint),
+	}
+
+	if err := h.expenseClaimService.CreateCashAdvance(advance); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create cash advance", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Cash advance created successfully", advance)
+}
+
+func (h *ExpenseClaimHandler) GetCashAdvances(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	employeeID, err := queryEmployeeID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid employee ID", err)
+		return
+	}
+
+	advances, err := h.expenseClaimService.GetCashAdvances(companyID.(uint), employeeID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve cash advances", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cash advances retrieved successfully", advances)
+}
+
+func (h *ExpenseClaimHandler) GetCashAdvanceByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid cash advance ID", err)
+		return
+	}
+
+	advance, err := h.expenseClaimService.GetCashAdvanceByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Cash advance not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cash advance retrieved successfully", advance)
+}
+
+func (h *ExpenseClaimHandler) ApproveCashAdvance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid cash advance ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.expenseClaimService.ApproveCashAdvance(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to approve cash advance", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cash advance approved successfully", nil)
+}
+
+func (h *ExpenseClaimHandler) RejectCashAdvance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid cash advance ID", err)
+		return
+	}
+
+	if err := h.expenseClaimService.RejectCashAdvance(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to reject cash advance", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cash advance rejected successfully", nil)
+}
+
+func (h *ExpenseClaimHandler) CancelCashAdvance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid cash advance ID", err)
+		return
+	}
+
+	if err := h.expenseClaimService.CancelCashAdvance(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel cash advance", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cash advance cancelled successfully", nil)
+}
+
+func (h *ExpenseClaimHandler) DisburseCashAdvance(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid cash advance ID", err)
+		return
+	}
+
+	var req SettleExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	disbursementDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	if err := h.expenseClaimService.DisburseCashAdvance(uint(id), req.AccountID, disbursementDate, req.Reference, userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to disburse cash advance", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cash advance disbursed successfully", nil)
+}
+
+// Expense Claim Handlers
+type CreateExpenseClaimRequest struct {
+	EmployeeID  uint                      `json:"employee_id" binding:"required"`
+	AdvanceID   *uint                     `json:"advance_id"` // omitted = out-of-pocket claim
+	ClaimDate   string                    `json:"claim_date" binding:"required"`
+	Description string                    `json:"description"`
+	Notes       string                    `json:"notes"`
+	Items       []ExpenseClaimItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type ExpenseClaimItemRequest struct {
+	ExpenseDate   string  `json:"expense_date" binding:"required"`
+	AccountID     uint    `json:"account_id" binding:"required"`
+	Description   string  `json:"description" binding:"required"`
+	ReceiptNumber string  `json:"receipt_number"`
+	Amount        float64 `json:"amount" binding:"required,gt=0"`
+}
+
+func (h *ExpenseClaimHandler) CreateExpenseClaim(c *gin.Context) {
+	var req CreateExpenseClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	claimDate, err := time.Parse("2006-01-02", req.ClaimDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	items := make([]models.ExpenseClaimItem, len(req.Items))
+	for i, item := range req.Items {
+		expenseDate, err := time.Parse("2006-01-02", item.ExpenseDate)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+			return
+		}
+		items[i] = models.ExpenseClaimItem{
+			ExpenseDate:   expenseDate,
+			AccountID:     item.AccountID,
+			Description:   item.Description,
+			ReceiptNumber: item.ReceiptNumber,
+			Amount:        item.Amount,
+		}
+	}
+
+	claim := &models.ExpenseClaim{
+		CompanyID:   companyID.(uint),
+		EmployeeID:  req.EmployeeID,
+		AdvanceID:   req.AdvanceID,
+		ClaimDate:   claimDate,
+		Description: req.Description,
+		Notes:       req.Notes,
+		CreatedBy:   userID.(uint),
+		Items:       items,
+	}
+
+	if err := h.expenseClaimService.CreateExpenseClaim(claim); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create expense claim", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Expense claim created successfully", claim)
+}
+
+func (h *ExpenseClaimHandler) GetExpenseClaims(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	employeeID, err := queryEmployeeID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid employee ID", err)
+		return
+	}
+
+	claims, err := h.expenseClaimService.GetExpenseClaims(companyID.(uint), employeeID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve expense claims", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Expense claims retrieved successfully", claims)
+}
+
+func (h *ExpenseClaimHandler) GetExpenseClaimByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expense claim ID", err)
+		return
+	}
+
+	claim, err := h.expenseClaimService.GetExpenseClaimByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Expense claim not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Expense claim retrieved successfully", claim)
+}
+
+func (h *ExpenseClaimHandler) ApproveExpenseClaim(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expense claim ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.expenseClaimService.ApproveExpenseClaim(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to approve expense claim", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Expense claim approved successfully", nil)
+}
+
+func (h *ExpenseClaimHandler) RejectExpenseClaim(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expense claim ID", err)
+		return
+	}
+
+	if err := h.expenseClaimService.RejectExpenseClaim(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to reject expense claim", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Expense claim rejected successfully", nil)
+}
+
+func (h *ExpenseClaimHandler) CancelExpenseClaim(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expense claim ID", err)
+		return
+	}
+
+	if err := h.expenseClaimService.CancelExpenseClaim(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel expense claim", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Expense claim cancelled successfully", nil)
+}
+
+func (h *ExpenseClaimHandler) SettleExpenseClaim(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expense claim ID", err)
+		return
+	}
+
+	var req SettleExpenseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	settlementDate, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	if err := h.expenseClaimService.SettleExpenseClaim(uint(id), req.AccountID, settlementDate, req.Reference, userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to settle expense claim", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Expense claim settled successfully", nil)
+}
+
+// Attachment Handlers
+func (h *ExpenseClaimHandler) UploadAttachment(c *gin.Context) {
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expense claim item ID", err)
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File is required", err)
+		return
+	}
+	if header.Size > maxAttachmentSize {
+		utils.ErrorResponse(c, http.StatusBadRequest, "File is too large", errors.New("attachments are limited to 10 MB"))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read file", err)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to read file", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	attachment, err := h.expenseClaimService.AddExpenseClaimAttachment(uint(itemID), header.Filename, header.Header.Get("Content-Type"), data, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to upload attachment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Attachment uploaded successfully", attachment)
+}
+
+func (h *ExpenseClaimHandler) DownloadAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attachment ID", err)
+		return
+	}
+
+	attachment, err := h.expenseClaimService.GetExpenseClaimAttachment(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Attachment not found", err)
+		return
+	}
+
+	c.FileAttachment(attachment.FilePath, attachment.FileName)
+}
+
+func (h *ExpenseClaimHandler) DeleteAttachment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid attachment ID", err)
+		return
+	}
+
+	if err := h.expenseClaimService.DeleteExpenseClaimAttachment(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete attachment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Attachment deleted successfully", nil)
+}
+
+// Report Handlers
+func (h *ExpenseClaimHandler) GetOutstandingAdvances(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	employeeID, err := queryEmployeeID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid employee ID", err)
+		return
+	}
+
+	asOfDateStr := c.Query("as_of_date")
+	if asOfDateStr == "" {
+		asOfDateStr = time.Now().Format("2006-01-02")
+	}
+	asOfDate, err := time.Parse("2006-01-02", asOfDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	report, err := h.expenseClaimService.GetOutstandingAdvances(companyID.(uint), employeeID, asOfDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve outstanding advances", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Outstanding advances retrieved successfully", report)
+}
+
+func queryEmployeeID(c *gin.Context) (uint, error) {
+	employeeIDStr := c.Query("employee_id")
+	if employeeIDStr == "" {
+		return 0, nil
+	}
+
+	employeeID, err := strconv.ParseUint(employeeIDStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(employeeID), nil
+}
\ No newline at end of file
@@ -1,20 +1,27 @@
 package handlers
 
 import (
+	"finara-backend/internal/models"
 	"finara-backend/internal/services"
 	"finara-backend/internal/utils"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
 type ExportHandler struct {
-	exportService  services.ExportService
-	journalService services.JournalService
-	ledgerService  services.LedgerService
-	reportService  services.ReportService
+	exportService    services.ExportService
+	journalService   services.JournalService
+	ledgerService    services.LedgerService
+	reportService    services.ReportService
+	inventoryService services.InventoryService
+	salesService     services.SalesService
+	purchaseService  services.PurchaseService
+	dunningService   services.DunningService
 }
 
 func NewExportHandler(
@@ -22,12 +29,20 @@ func NewExportHandler(
 	journalService services.JournalService,
 	ledgerService services.LedgerService,
 	reportService services.ReportService,
+	inventoryService services.InventoryService,
+	salesService services.SalesService,
+	purchaseService services.PurchaseService,
+	dunningService services.DunningService,
 ) *ExportHandler {
 	return &ExportHandler{
-		exportService:  exportService,
-		journalService: journalService,
-		ledgerService:  ledgerService,
-		reportService:  reportService,
+		exportService:    exportService,
+		journalService:   journalService,
+		ledgerService:    ledgerService,
+		reportService:    reportService,
+		inventoryService: inventoryService,
+		salesService:     salesService,
+		purchaseService:  purchaseService,
+		dunningService:   dunningService,
 	}
 }
 
@@ -221,6 +236,351 @@ func (h *ExportHandler) ExportBalanceSheet(c *gin.Context) {
 		return
 	}
 
+	// Return file
+	c.FileAttachment(filepath, filepath)
+}
+
+func (h *ExportHandler) ExportStockCard(c *gin.Context) {
+	format := c.Query("format") // csv or excel
+
+	productID, err := strconv.ParseUint(c.Query("product_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "product_id is required", err)
+		return
+	}
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	startDate, endDate, err := queryPeriod(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	// Get stock card
+	card, err := h.inventoryService.GetStockCard(uint(productID), warehouseID, startDate, endDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to retrieve stock card", err)
+		return
+	}
+
+	// Generate filename
+	timestamp := time.Now().Format("20060102_150405")
+	var filepath string
+	var exportErr error
+
+	if format == "excel" || format == "xlsx" {
+		filename := fmt.Sprintf("stock_card_%s_%s.xlsx", card.ProductCode, timestamp)
+		filepath, exportErr = h.exportService.ExportStockCardToExcel(card, filename)
+	} else {
+		filename := fmt.Sprintf("stock_card_%s_%s.csv", card.ProductCode, timestamp)
+		filepath, exportErr = h.exportService.ExportStockCardToCSV(card, filename)
+	}
+
+	if exportErr != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export stock card", exportErr)
+		return
+	}
+
+	// Return file
+	c.FileAttachment(filepath, filepath)
+}
+
+func (h *ExportHandler) ExportInventoryValuation(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+	format := c.Query("format") // csv or excel
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	asOfDateStr := c.Query("as_of_date")
+	if asOfDateStr == "" {
+		asOfDateStr = time.Now().Format("2006-01-02")
+	}
+
+	asOfDate, err := time.Parse("2006-01-02", asOfDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid as_of_date format", err)
+		return
+	}
+
+	// Get inventory valuation
+	valuation, err := h.inventoryService.GetInventoryValuation(companyID.(uint), warehouseID, asOfDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve inventory valuation", err)
+		return
+	}
+
+	// Generate filename
+	timestamp := time.Now().Format("20060102_150405")
+	var filepath string
+	var exportErr error
+
+	if format == "excel" || format == "xlsx" {
+		filename := fmt.Sprintf("inventory_valuation_%s.xlsx", timestamp)
+		filepath, exportErr = h.exportService.ExportInventoryValuationToExcel(valuation, filename)
+	} else {
+		filename := fmt.Sprintf("inventory_valuation_%s.csv", timestamp)
+		filepath, exportErr = h.exportService.ExportInventoryValuationToCSV(valuation, filename)
+	}
+
+	if exportErr != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export inventory valuation", exportErr)
+		return
+	}
+
+	// Return file
+	c.FileAttachment(filepath, filepath)
+}
+
+func (h *ExportHandler) ExportInventoryAging(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+	format := c.Query("format") // csv or excel
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	slowDays, _ := strconv.Atoi(c.DefaultQuery("slow_days", "90"))
+	deadDays, _ := strconv.Atoi(c.DefaultQuery("dead_days", "180"))
+
+	// Get inventory aging
+	report, err := h.inventoryService.GetInventoryAging(companyID.(uint), warehouseID, slowDays, deadDays)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve inventory aging", err)
+		return
+	}
+
+	// Generate filename
+	timestamp := time.Now().Format("20060102_150405")
+	var filepath string
+	var exportErr error
+
+	if format == "excel" || format == "xlsx" {
+		filename := fmt.Sprintf("inventory_aging_%s.xlsx", timestamp)
+		filepath, exportErr = h.exportService.ExportInventoryAgingToExcel(report, filename)
+	} else {
+		filename := fmt.Sprintf("inventory_aging_%s.csv", timestamp)
+		filepath, exportErr = h.exportService.ExportInventoryAgingToCSV(report, filename)
+	}
+
+	if exportErr != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export inventory aging", exportErr)
+		return
+	}
+
+	// Return file
+	c.FileAttachment(filepath, filepath)
+}
+
+func (h *ExportHandler) ExportOpnameCountSheet(c *gin.Context) {
+	format := c.Query("format") // csv or excel
+
+	opnameID, err := strconv.ParseUint(c.Query("opname_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "opname_id is required", err)
+		return
+	}
+
+	showSystem := c.Query("show_system") == "true"
+	recountOnly := c.Query("recount_only") == "true"
+
+	// Get stock opname
+	opname, err := h.inventoryService.GetStockOpnameByID(uint(opnameID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Stock opname not found", err)
+		return
+	}
+
+	if recountOnly {
+		items := opname.Items[:0]
+		for _, item := range opname.Items {
+			if item.Status == models.OpnameItemRecount {
+				items = append(items, item)
+			}
+		}
+		opname.Items = items
+	}
+
+	// Generate filename
+	timestamp := time.Now().Format("20060102_150405")
+	var filepath string
+	var exportErr error
+
+	if format == "excel" || format == "xlsx" {
+		filename := fmt.Sprintf("count_sheet_%s.xlsx", timestamp)
+		filepath, exportErr = h.exportService.ExportCountSheetToExcel(opname, showSystem, filename)
+	} else {
+		filename := fmt.Sprintf("count_sheet_%s.csv", timestamp)
+		filepath, exportErr = h.exportService.ExportCountSheetToCSV(opname, showSystem, filename)
+	}
+
+	if exportErr != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export count sheet", exportErr)
+		return
+	}
+
+	// Return file
+	c.FileAttachment(filepath, filepath)
+}
+
+func (h *ExportHandler) ExportReceivableAging(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+	format := c.Query("format") // csv or excel
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	asOfDate, limits, err := queryAgingParams(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid aging parameters", err)
+		return
+	}
+
+	// Get receivable aging
+	report, err := h.salesService.GetReceivableAging(companyID.(uint), customerID, asOfDate, limits)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve receivable aging", err)
+		return
+	}
+
+	h.exportAging(c, report, "receivable_aging", format)
+}
+
+func (h *ExportHandler) ExportPayableAging(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+	format := c.Query("format") // csv or excel
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	asOfDate, limits, err := queryAgingParams(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid aging parameters", err)
+		return
+	}
+
+	// Get payable aging
+	report, err := h.purchaseService.GetPayableAging(companyID.(uint), vendorID, asOfDate, limits)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve payable aging", err)
+		return
+	}
+
+	h.exportAging(c, report, "payable_aging", format)
+}
+
+// exportAging writes the aging file; ?detail=true lists the open documents
+// under each customer or vendor
+func (h *ExportHandler) exportAging(c *gin.Context, report *models.AgingReport, name string, format string) {
+	showDocuments := c.Query("detail") == "true"
+
+	// Generate filename
+	timestamp := time.Now().Format("20060102_150405")
+	var filepath string
+	var exportErr error
+
+	if format == "excel" || format == "xlsx" {
+		filename := fmt.Sprintf("%s_%s.xlsx", name, timestamp)
+		filepath, exportErr = h.exportService.ExportAgingToExcel(report, showDocuments, filename)
+	} else {
+		filename := fmt.Sprintf("%s_%s.csv", name, timestamp)
+		filepath, exportErr = h.exportService.ExportAgingToCSV(report, showDocuments, filename)
+	}
+
+	if exportErr != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export aging", exportErr)
+		return
+	}
+
+	// Return file
+	c.FileAttachment(filepath, filepath)
+}
+
+func (h *ExportHandler) ExportCustomerStatement(c *gin.Context) {
+	format := c.Query("format") // pdf, csv or excel
+
+	customerID, err := strconv.ParseUint(c.Query("customer_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "customer_id is required", err)
+		return
+	}
+
+	startDate, endDate, err := queryPeriod(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	// Get customer statement
+	statement, err := h.salesService.GetCustomerStatement(uint(customerID), startDate, endDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to retrieve customer statement", err)
+		return
+	}
+
+	// Generate filename
+	timestamp := time.Now().Format("20060102_150405")
+	var filepath string
+	var exportErr error
+
+	switch format {
+	case "pdf":
+		filename := fmt.Sprintf("statement_%s_%s.pdf", statement.CustomerCode, timestamp)
+		filepath, exportErr = h.exportService.ExportCustomerStatementToPDF(statement, filename)
+	case "excel", "xlsx":
+		filename := fmt.Sprintf("statement_%s_%s.xlsx", statement.CustomerCode, timestamp)
+		filepath, exportErr = h.exportService.ExportCustomerStatementToExcel(statement, filename)
+	default:
+		filename := fmt.Sprintf("statement_%s_%s.csv", statement.CustomerCode, timestamp)
+		filepath, exportErr = h.exportService.ExportCustomerStatementToCSV(statement, filename)
+	}
+
+	if exportErr != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export customer statement", exportErr)
+		return
+	}
+
+	// Return file
+	c.FileAttachment(filepath, filepath)
+}
+
+// ExportDunningReminder returns the reminder document as PDF
+func (h *ExportHandler) ExportDunningReminder(c *gin.Context) {
+	reminderID, err := strconv.ParseUint(c.Query("reminder_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "reminder_id is required", err)
+		return
+	}
+
+	reminder, err := h.dunningService.GetDunningReminderByID(uint(reminderID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Dunning reminder not found", err)
+		return
+	}
+
+	filename := strings.ReplaceAll(reminder.ReminderNumber, "/", "_") + ".pdf"
+	filepath, err := h.exportService.ExportDunningReminderToPDF(reminder, filename)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to export dunning reminder", err)
+		return
+	}
+
 	// Return file
 	c.FileAttachment(filepath, filepath)
 }
\ No newline at end of file
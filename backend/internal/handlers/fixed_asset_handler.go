@@ -0,0 +1,452 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FixedAssetHandler struct {
+	fixedAssetService services.FixedAssetService
+}
+
+func NewFixedAssetHandler(fixedAssetService services.FixedAssetService) *FixedAssetHandler {
+	return &FixedAssetHandler{fixedAssetService: fixedAssetService}
+}
+
+// Fixed Asset Handlers
+type CreateFixedAssetRequest struct {
+	Name                 string  `json:"name" binding:"required"`
+	Description          string  `json:"description"`
+	Location             string  `json:"location"`
+	CostCenter           string  `json:"cost_center"`
+	AssetAccountID       uint    `json:"asset_account_id" binding:"required"`
+	AccumulatedAccountID uint    `json:"accumulated_account_id"` // 0 = 1-2900 Akumulasi Penyusutan
+	ExpenseAccountID     uint    `json:"expense_account_id"`     // 0 = 5-1500 Beban Penyusutan
+	AcquisitionDate      string  `json:"acquisition_date" binding:"required"`
+	AcquisitionCost      float64 `json:"acquisition_cost" binding:"required,gt=0"`
+	SalvageValue         float64 `json:"salvage_value"`
+	UsefulLifeMonths     int     `json:"useful_life_months" binding:"required,gt=0"`
+	Method               string  `json:"method" binding:"required"`
+	FiscalGroup          string  `json:"fiscal_group" binding:"required"`
+	FiscalMethod         string  `json:"fiscal_method"`       // empty = straight line
+	DepreciatedThrough   string  `json:"depreciated_through"` // depreciation already booked before the register
+	Notes                string  `json:"notes"`
+}
+
+type UpdateFixedAssetRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+	Location    string `json:"location"`
+	Notes       string `json:"notes"`
+}
+
+func (h *FixedAssetHandler) CreateFixedAsset(c *gin.Context) {
+	var req CreateFixedAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	acquisitionDate, err := time.Parse("2006-01-02", req.AcquisitionDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	depreciatedThrough, err := parseOptionalDate(req.DepreciatedThrough)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid depreciated_through format", err)
+		return
+	}
+
+	asset := &models.FixedAsset{
+		CompanyID:            companyID.(uint),
+		Name:                 req.Name,
+		Description:          req.Description,
+		Location:             req.Location,
+		CostCenter:           req.CostCenter,
+		AssetAccountID:       req.AssetAccountID,
+		AccumulatedAccountID: req.AccumulatedAccountID,
+		ExpenseAccountID:     req.ExpenseAccountID,
+		AcquisitionDate:      acquisitionDate,
+		AcquisitionCost:      req.AcquisitionCost,
+		SalvageValue:         req.SalvageValue,
+		UsefulLifeMonths:     req.UsefulLifeMonths,
+		Method:               models.DepreciationMethod(req.Method),
+		FiscalGroup:          models.FiscalAssetGroup(req.FiscalGroup),
+		FiscalMethod:         models.DepreciationMethod(req.FiscalMethod),
+		DepreciatedThrough:   depreciatedThrough,
+		Notes:                req.Notes,
+		CreatedBy:            userID.(uint),
+	}
+
+	if err := h.fixedAssetService.CreateFixedAsset(asset); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create fixed asset", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Fixed asset created successfully", asset)
+}
+
+func (h *FixedAssetHandler) GetFixedAssets(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	assets, err := h.fixedAssetService.GetFixedAssets(companyID.(uint), c.Query("status"), c.Query("fiscal_group"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve fixed assets", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed assets retrieved successfully", assets)
+}
+
+func (h *FixedAssetHandler) GetFixedAssetByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	asset, err := h.fixedAssetService.GetFixedAssetByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Fixed asset not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed asset retrieved successfully", asset)
+}
+
+func (h *FixedAssetHandler) UpdateFixedAsset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	var req UpdateFixedAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	asset := &models.FixedAsset{
+		Name:        req.Name,
+		Description: req.Description,
+		Location:    req.Location,
+		Notes:       req.Notes,
+	}
+
+	if err := h.fixedAssetService.UpdateFixedAsset(uint(id), asset); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update fixed asset", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed asset updated successfully", nil)
+}
+
+func (h *FixedAssetHandler) DeleteFixedAsset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	if err := h.fixedAssetService.DeleteFixedAsset(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete fixed asset", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed asset deleted successfully", nil)
+}
+
+func (h *FixedAssetHandler) GetDepreciationSchedule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	schedule, err := h.fixedAssetService.GetDepreciationSchedule(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Fixed asset not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Depreciation schedule retrieved successfully", schedule)
+}
+
+func (h *FixedAssetHandler) GetFixedAssetHistory(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	events, err := h.fixedAssetService.GetFixedAssetHistory(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Fixed asset not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed asset history retrieved successfully", events)
+}
+
+// Asset Event Handlers
+type TransferFixedAssetRequest struct {
+	TransferDate string `json:"transfer_date" binding:"required"`
+	Location     string `json:"location"`    // empty = unchanged
+	CostCenter   string `json:"cost_center"` // empty = unchanged
+	Notes        string `json:"notes"`
+}
+
+type RevalueFixedAssetRequest struct {
+	RevaluationDate     string   `json:"revaluation_date" binding:"required"`
+	RevaluedAmount      float64  `json:"revalued_amount" binding:"required,gt=0"`
+	RemainingLifeMonths int      `json:"remaining_life_months"` // 0 = what is left of the useful life
+	SalvageValue        *float64 `json:"salvage_value"`         // omitted = unchanged
+	Notes               string   `json:"notes"`
+}
+
+type ImpairFixedAssetRequest struct {
+	ImpairmentDate string  `json:"impairment_date" binding:"required"`
+	Amount         float64 `json:"amount" binding:"required,gt=0"`
+	Notes          string  `json:"notes"`
+}
+
+type DisposeFixedAssetRequest struct {
+	DisposalDate      string  `json:"disposal_date" binding:"required"`
+	Percent           float64 `json:"percent"`  // share disposed of, 0 = all of it
+	Proceeds          float64 `json:"proceeds"` // 0 = scrapped
+	ProceedsAccountID uint    `json:"proceeds_account_id"`
+	Notes             string  `json:"notes"`
+}
+
+func (h *FixedAssetHandler) TransferFixedAsset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	var req TransferFixedAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	transferDate, err := time.Parse("2006-01-02", req.TransferDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	event := &models.FixedAssetEvent{
+		EventDate:    transferDate,
+		ToLocation:   req.Location,
+		ToCostCenter: req.CostCenter,
+		Notes:        req.Notes,
+		CreatedBy:    userID.(uint),
+	}
+
+	if err := h.fixedAssetService.TransferFixedAsset(uint(id), event); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to transfer fixed asset", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed asset transferred successfully", event)
+}
+
+func (h *FixedAssetHandler) RevalueFixedAsset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	var req RevalueFixedAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	revaluationDate, err := time.Parse("2006-01-02", req.RevaluationDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	event := &models.FixedAssetEvent{
+		EventDate: revaluationDate,
+		Notes:     req.Notes,
+		CreatedBy: userID.(uint),
+	}
+
+	if err := h.fixedAssetService.RevalueFixedAsset(uint(id), event, req.RevaluedAmount, req.RemainingLifeMonths, req.SalvageValue); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to revalue fixed asset", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed asset revalued successfully", event)
+}
+
+func (h *FixedAssetHandler) ImpairFixedAsset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	var req ImpairFixedAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	impairmentDate, err := time.Parse("2006-01-02", req.ImpairmentDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	event := &models.FixedAssetEvent{
+		EventDate: impairmentDate,
+		Amount:    req.Amount,
+		Notes:     req.Notes,
+		CreatedBy: userID.(uint),
+	}
+
+	if err := h.fixedAssetService.ImpairFixedAsset(uint(id), event); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to impair fixed asset", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed asset written down successfully", event)
+}
+
+func (h *FixedAssetHandler) DisposeFixedAsset(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid fixed asset ID", err)
+		return
+	}
+
+	var req DisposeFixedAssetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	disposalDate, err := time.Parse("2006-01-02", req.DisposalDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	event := &models.FixedAssetEvent{
+		EventDate: disposalDate,
+		Amount:    req.Proceeds,
+		Percent:   req.Percent,
+		Notes:     req.Notes,
+		CreatedBy: userID.(uint),
+	}
+
+	if err := h.fixedAssetService.DisposeFixedAsset(uint(id), event, req.ProceedsAccountID); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to dispose of fixed asset", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Fixed asset disposed of successfully", event)
+}
+
+// Depreciation Run Handlers
+type RunDepreciationRequest struct {
+	Period string `json:"period" binding:"required"` // YYYY-MM
+}
+
+func (h *FixedAssetHandler) RunDepreciation(c *gin.Context) {
+	var req RunDepreciationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	run, err := h.fixedAssetService.RunDepreciation(companyID.(uint), req.Period, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to run depreciation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Depreciation posted successfully", run)
+}
+
+func (h *FixedAssetHandler) GetDepreciationRuns(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	runs, err := h.fixedAssetService.GetDepreciationRuns(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve depreciation runs", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Depreciation runs retrieved successfully", runs)
+}
+
+func (h *FixedAssetHandler) GetDepreciationRunByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid depreciation run ID", err)
+		return
+	}
+
+	run, err := h.fixedAssetService.GetDepreciationRunByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Depreciation run not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Depreciation run retrieved successfully", run)
+}
+
+// Report Handlers
+func (h *FixedAssetHandler) GetDepreciationReport(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	yearStr := c.Query("year")
+	if yearStr == "" {
+		yearStr = strconv.Itoa(time.Now().Year())
+	}
+
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid year format", err)
+		return
+	}
+
+	report, err := h.fixedAssetService.GetDepreciationReport(companyID.(uint), year)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve depreciation report", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Depreciation report retrieved successfully", report)
+}
\ No newline at end of file
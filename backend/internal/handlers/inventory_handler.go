@@ -6,6 +6,7 @@ import (
 	"finara-backend/internal/utils"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -21,13 +22,21 @@ func NewInventoryHandler(inventoryService services.InventoryService) *InventoryH
 
 // Product Handlers
 type CreateProductRequest struct {
-	Code        string              `json:"code" binding:"required"`
-	Name        string              `json:"name" binding:"required"`
-	Description string              `json:"description"`
-	Category    string              `json:"category"`
-	Unit        string              `json:"unit" binding:"required"`
-	CostMethod  models.CostMethod   `json:"cost_method"`
-	MinStock    float64             `json:"min_stock"`
+	Code              string            `json:"code" binding:"required"`
+	Name              string            `json:"name" binding:"required"`
+	Description       string            `json:"description"`
+	Category          string            `json:"category"`
+	Unit              string            `json:"unit" binding:"required"`
+	CostMethod        models.CostMethod `json:"cost_method"`
+	MinStock          float64           `json:"min_stock"`
+	ReorderPoint      float64           `json:"reorder_point"`
+	ReorderQuantity   float64           `json:"reorder_quantity"`
+	LeadTimeDays      int               `json:"lead_time_days"`
+	SafetyStock       float64           `json:"safety_stock"`
+	Weight            float64           `json:"weight"`
+	IsLotTracked      bool              `json:"is_lot_tracked"`
+	IsSerialTracked   bool              `json:"is_serial_tracked"`
+	ExpiryWarningDays int               `json:"expiry_warning_days"`
 }
 
 func (h *InventoryHandler) CreateProduct(c *gin.Context) {
@@ -52,6 +61,14 @@ func (h *InventoryHandler) CreateProduct(c *gin.Context) {
 		Unit:        req.Unit,
 		CostMethod:  req.CostMethod,
 		MinStock:    req.MinStock,
+		ReorderPoint:      req.ReorderPoint,
+		ReorderQuantity:   req.ReorderQuantity,
+		LeadTimeDays:      req.LeadTimeDays,
+		SafetyStock:       req.SafetyStock,
+		Weight:            req.Weight,
+		IsLotTracked:      req.IsLotTracked,
+		IsSerialTracked:   req.IsSerialTracked,
+		ExpiryWarningDays: req.ExpiryWarningDays,
 		IsActive:    true,
 	}
 
@@ -115,6 +132,14 @@ func (h *InventoryHandler) UpdateProduct(c *gin.Context) {
 		Unit:        req.Unit,
 		CostMethod:  req.CostMethod,
 		MinStock:    req.MinStock,
+		ReorderPoint:      req.ReorderPoint,
+		ReorderQuantity:   req.ReorderQuantity,
+		LeadTimeDays:      req.LeadTimeDays,
+		SafetyStock:       req.SafetyStock,
+		Weight:            req.Weight,
+		IsLotTracked:      req.IsLotTracked,
+		IsSerialTracked:   req.IsSerialTracked,
+		ExpiryWarningDays: req.ExpiryWarningDays,
 	}
 
 	if err := h.inventoryService.UpdateProduct(uint(id), product); err != nil {
@@ -140,15 +165,180 @@ func (h *InventoryHandler) DeleteProduct(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Product deleted successfully", nil)
 }
 
+// Unit of Measure Handlers
+type UnitOfMeasureRequest struct {
+	Code string `json:"code" binding:"required"`
+	Name string `json:"name" binding:"required"`
+}
+
+func (h *InventoryHandler) CreateUnitOfMeasure(c *gin.Context) {
+	var req UnitOfMeasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	unit := &models.UnitOfMeasure{
+		CompanyID: companyID.(uint),
+		Code:      req.Code,
+		Name:      req.Name,
+	}
+
+	if err := h.inventoryService.CreateUnitOfMeasure(unit); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create unit of measure", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Unit of measure created successfully", unit)
+}
+
+func (h *InventoryHandler) GetUnitsOfMeasure(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	units, err := h.inventoryService.GetUnitsOfMeasure(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve units of measure", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Units of measure retrieved successfully", units)
+}
+
+func (h *InventoryHandler) UpdateUnitOfMeasure(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid unit of measure ID", err)
+		return
+	}
+
+	var req UnitOfMeasureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	unit := &models.UnitOfMeasure{
+		Code: req.Code,
+		Name: req.Name,
+	}
+
+	if err := h.inventoryService.UpdateUnitOfMeasure(uint(id), unit); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update unit of measure", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Unit of measure updated successfully", unit)
+}
+
+func (h *InventoryHandler) DeleteUnitOfMeasure(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid unit of measure ID", err)
+		return
+	}
+
+	if err := h.inventoryService.DeleteUnitOfMeasure(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete unit of measure", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Unit of measure deleted successfully", nil)
+}
+
+type ProductUnitRequest struct {
+	UnitID         uint    `json:"unit_id" binding:"required"`
+	Factor         float64 `json:"factor" binding:"required,gt=0"` // base units per one of this unit
+	IsPurchaseUnit bool    `json:"is_purchase_unit"`
+	IsSalesUnit    bool    `json:"is_sales_unit"`
+}
+
+func (h *InventoryHandler) SetProductUnit(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req ProductUnitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	productUnit := &models.ProductUnit{
+		ProductID:      uint(productID),
+		UnitID:         req.UnitID,
+		Factor:         req.Factor,
+		IsPurchaseUnit: req.IsPurchaseUnit,
+		IsSalesUnit:    req.IsSalesUnit,
+	}
+
+	if err := h.inventoryService.SetProductUnit(productUnit); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to set product unit", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product unit saved successfully", productUnit)
+}
+
+func (h *InventoryHandler) DeleteProductUnit(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	unitID, err := strconv.ParseUint(c.Param("unit_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product unit ID", err)
+		return
+	}
+
+	if err := h.inventoryService.DeleteProductUnit(uint(productID), uint(unitID)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete product unit", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Product unit deleted successfully", nil)
+}
+
 // Stock Movement Handlers
 type CreateStockMovementRequest struct {
-	ProductID    uint    `json:"product_id" binding:"required"`
-	MovementDate string  `json:"movement_date" binding:"required"`
-	Type         string  `json:"type" binding:"required"` // in, out, adjustment
-	Quantity     float64 `json:"quantity" binding:"required,gt=0"`
-	UnitCost     float64 `json:"unit_cost" binding:"required,gte=0"`
-	Reference    string  `json:"reference"`
-	Notes        string  `json:"notes"`
+	ProductID     uint                       `json:"product_id" binding:"required"`
+	WarehouseID   uint                       `json:"warehouse_id"`            // 0 = default warehouse
+	MovementDate  string                     `json:"movement_date" binding:"required"`
+	Type          string                     `json:"type" binding:"required,oneof=in out adjustment"`
+	Quantity      float64                    `json:"quantity" binding:"required,gt=0"`
+	UnitCost      float64                    `json:"unit_cost" binding:"required,gte=0"`
+	Unit          string                     `json:"unit"`                    // alternate unit of quantity & unit_cost, default base unit
+	Reference     string                     `json:"reference"`
+	Party         string                     `json:"party"`                   // customer / supplier
+	Notes         string                     `json:"notes"`
+	LotNumber     string                     `json:"lot_number"`              // lot-tracked stock-in
+	ExpiryDate    string                     `json:"expiry_date"`             // YYYY-MM-DD
+	Lots          []StockLotSelectionRequest `json:"lots"`                    // explicit lots for stock-out, default FEFO
+	SerialNumbers []string                   `json:"serial_numbers"`          // serial-tracked products
+	ReservationID *uint                      `json:"reservation_id"`          // stock-out fulfilling a reservation
+}
+
+type StockLotSelectionRequest struct {
+	LotID    uint    `json:"lot_id" binding:"required"`
+	Quantity float64 `json:"quantity" binding:"required,gt=0"`
+}
+
+// parseOptionalDate parses a YYYY-MM-DD value, empty means no date
+func parseOptionalDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, err
+	}
+	return &date, nil
 }
 
 func (h *InventoryHandler) CreateStockMovement(c *gin.Context) {
@@ -167,16 +357,38 @@ func (h *InventoryHandler) CreateStockMovement(c *gin.Context) {
 		return
 	}
 
+	expiryDate, err := parseOptionalDate(req.ExpiryDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expiry_date format", err)
+		return
+	}
+
+	lots := make([]models.StockLotAllocation, len(req.Lots))
+	for i, lot := range req.Lots {
+		lots[i] = models.StockLotAllocation{
+			LotID:    lot.LotID,
+			Quantity: lot.Quantity,
+		}
+	}
+
 	movement := &models.StockMovement{
-		CompanyID:    companyID.(uint),
-		ProductID:    req.ProductID,
-		MovementDate: movementDate,
-		Type:         req.Type,
-		Quantity:     req.Quantity,
-		UnitCost:     req.UnitCost,
-		Reference:    req.Reference,
-		Notes:        req.Notes,
-		CreatedBy:    userID.(uint),
+		CompanyID:      companyID.(uint),
+		ProductID:      req.ProductID,
+		WarehouseID:    req.WarehouseID,
+		MovementDate:   movementDate,
+		Type:           req.Type,
+		Quantity:       req.Quantity,
+		UnitCost:       req.UnitCost,
+		Unit:           req.Unit,
+		Reference:      req.Reference,
+		Party:          req.Party,
+		Notes:          req.Notes,
+		LotNumber:      req.LotNumber,
+		ExpiryDate:     expiryDate,
+		LotAllocations: lots,
+		SerialNumbers:  req.SerialNumbers,
+		ReservationID:  req.ReservationID,
+		CreatedBy:      userID.(uint),
 	}
 
 	var createErr error
@@ -219,7 +431,13 @@ func (h *InventoryHandler) GetStockMovements(c *gin.Context) {
 		return
 	}
 
-	movements, err := h.inventoryService.GetStockMovementsByCompany(companyID.(uint), startDate, endDate)
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	movements, err := h.inventoryService.GetStockMovementsByCompany(companyID.(uint), warehouseID, startDate, endDate)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve stock movements", err)
 		return
@@ -231,7 +449,13 @@ func (h *InventoryHandler) GetStockMovements(c *gin.Context) {
 func (h *InventoryHandler) GetStockBalances(c *gin.Context) {
 	companyID, _ := c.Get("company_id")
 
-	balances, err := h.inventoryService.GetAllStockBalances(companyID.(uint))
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	balances, err := h.inventoryService.GetAllStockBalances(companyID.(uint), warehouseID, c.Query("unit"))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve stock balances", err)
 		return
@@ -247,7 +471,13 @@ func (h *InventoryHandler) GetStockBalance(c *gin.Context) {
 		return
 	}
 
-	balance, err := h.inventoryService.GetStockBalance(uint(productID))
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	balance, err := h.inventoryService.GetStockBalance(uint(productID), warehouseID, c.Query("unit"))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve stock balance", err)
 		return
@@ -256,18 +486,196 @@ func (h *InventoryHandler) GetStockBalance(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Stock balance retrieved successfully", balance)
 }
 
+// queryWarehouseID reads the optional warehouse_id filter (0 = all warehouses)
+func queryWarehouseID(c *gin.Context) (uint, error) {
+	warehouseIDStr := c.Query("warehouse_id")
+	if warehouseIDStr == "" {
+		return 0, nil
+	}
+
+	warehouseID, err := strconv.ParseUint(warehouseIDStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(warehouseID), nil
+}
+
+// Cost Layer Report
+func (h *InventoryHandler) GetCostLayers(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	var productID uint64
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		parsed, err := strconv.ParseUint(productIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+			return
+		}
+		productID = parsed
+	}
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	openOnly := c.DefaultQuery("open_only", "true") == "true"
+
+	layers, err := h.inventoryService.GetCostLayerReport(companyID.(uint), uint(productID), warehouseID, openOnly)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve cost layers", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cost layers retrieved successfully", layers)
+}
+
+// Account Mapping Handlers
+type InventoryAccountMappingRequest struct {
+	ProductID                 *uint  `json:"product_id"`
+	Category                  string `json:"category"`
+	InventoryAccountID        *uint  `json:"inventory_account_id"`
+	COGSAccountID             *uint  `json:"cogs_account_id"`
+	PurchaseClearingAccountID *uint  `json:"purchase_clearing_account_id"`
+	VarianceAccountID         *uint  `json:"variance_account_id"`
+}
+
+func (h *InventoryHandler) CreateAccountMapping(c *gin.Context) {
+	var req InventoryAccountMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	mapping := &models.InventoryAccountMapping{
+		CompanyID:                 companyID.(uint),
+		ProductID:                 req.ProductID,
+		Category:                  req.Category,
+		InventoryAccountID:        req.InventoryAccountID,
+		COGSAccountID:             req.COGSAccountID,
+		PurchaseClearingAccountID: req.PurchaseClearingAccountID,
+		VarianceAccountID:         req.VarianceAccountID,
+	}
+
+	if err := h.inventoryService.CreateAccountMapping(mapping); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create account mapping", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Account mapping created successfully", mapping)
+}
+
+func (h *InventoryHandler) GetAccountMappings(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	mappings, err := h.inventoryService.GetAccountMappingsByCompany(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve account mappings", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account mappings retrieved successfully", mappings)
+}
+
+func (h *InventoryHandler) UpdateAccountMapping(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid account mapping ID", err)
+		return
+	}
+
+	var req InventoryAccountMappingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	mapping := &models.InventoryAccountMapping{
+		CompanyID:                 companyID.(uint),
+		ProductID:                 req.ProductID,
+		Category:                  req.Category,
+		InventoryAccountID:        req.InventoryAccountID,
+		COGSAccountID:             req.COGSAccountID,
+		PurchaseClearingAccountID: req.PurchaseClearingAccountID,
+		VarianceAccountID:         req.VarianceAccountID,
+	}
+
+	if err := h.inventoryService.UpdateAccountMapping(uint(id), mapping); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update account mapping", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account mapping updated successfully", mapping)
+}
+
+func (h *InventoryHandler) DeleteAccountMapping(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid account mapping ID", err)
+		return
+	}
+
+	if err := h.inventoryService.DeleteAccountMapping(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete account mapping", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Account mapping deleted successfully", nil)
+}
+
+func (h *InventoryHandler) GetGLReconciliation(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	asOfDateStr := c.Query("as_of_date")
+	if asOfDateStr == "" {
+		asOfDateStr = time.Now().Format("2006-01-02")
+	}
+
+	asOfDate, err := time.Parse("2006-01-02", asOfDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid as_of_date format", err)
+		return
+	}
+
+	report, err := h.inventoryService.GetGLReconciliation(companyID.(uint), asOfDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to reconcile inventory to GL", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Inventory reconciliation retrieved successfully", report)
+}
+
 // Stock Opname Handlers
 type CreateStockOpnameRequest struct {
-	OpnameDate string                     `json:"opname_date" binding:"required"`
-	Notes      string                     `json:"notes"`
-	Items      []StockOpnameItemRequest   `json:"items" binding:"required,min=1"`
+	WarehouseID       uint    `json:"warehouse_id"`
+	OpnameDate        string  `json:"opname_date" binding:"required"`
+	Category          string  `json:"category"`    // count only this category
+	ProductIDs        []uint  `json:"product_ids"` // count only these products
+	TolerancePercent  float64 `json:"tolerance_percent"`
+	ToleranceQuantity float64 `json:"tolerance_quantity"`
+	Notes             string  `json:"notes"`
 }
 
-type StockOpnameItemRequest struct {
-	ProductID        uint    `json:"product_id" binding:"required"`
-	SystemQuantity   float64 `json:"system_quantity" binding:"required"`
-	PhysicalQuantity float64 `json:"physical_quantity" binding:"required"`
-	Notes            string  `json:"notes"`
+
+type SubmitOpnameCountsRequest struct {
+	Batch  string                    `json:"batch"` // counter's sheet, zone or rack
+	Counts []StockOpnameCountRequest `json:"counts" binding:"required,min=1,dive"`
+}
+
+type StockOpnameCountRequest struct {
+	ProductID      uint     `json:"product_id" binding:"required"`
+	Quantity       float64  `json:"quantity"`
+	Batch          string   `json:"batch"`
+	LotNumber      string   `json:"lot_number"`      // lot for a count surplus of lot-tracked products
+	ExpiryDate     string   `json:"expiry_date"`     // YYYY-MM-DD
+	ScannedSerials []string `json:"scanned_serials"` // serial-tracked products: units scanned
+	Notes          string   `json:"notes"`
 }
 
 func (h *InventoryHandler) CreateStockOpname(c *gin.Context) {
@@ -286,26 +694,18 @@ func (h *InventoryHandler) CreateStockOpname(c *gin.Context) {
 		return
 	}
 
-	// Convert items
-	items := make([]models.StockOpnameItem, len(req.Items))
-	for i, item := range req.Items {
-		items[i] = models.StockOpnameItem{
-			ProductID:        item.ProductID,
-			SystemQuantity:   item.SystemQuantity,
-			PhysicalQuantity: item.PhysicalQuantity,
-			Notes:            item.Notes,
-		}
-	}
-
 	opname := &models.StockOpname{
-		CompanyID:  companyID.(uint),
-		OpnameDate: opnameDate,
-		Notes:      req.Notes,
-		CreatedBy:  userID.(uint),
-		Items:      items,
+		CompanyID:         companyID.(uint),
+		WarehouseID:       req.WarehouseID,
+		OpnameDate:        opnameDate,
+		Category:          req.Category,
+		TolerancePercent:  req.TolerancePercent,
+		ToleranceQuantity: req.ToleranceQuantity,
+		Notes:             req.Notes,
+		CreatedBy:         userID.(uint),
 	}
 
-	if err := h.inventoryService.CreateStockOpname(opname); err != nil {
+	if err := h.inventoryService.CreateStockOpname(opname, req.ProductIDs); err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create stock opname", err)
 		return
 	}
@@ -316,7 +716,7 @@ func (h *InventoryHandler) CreateStockOpname(c *gin.Context) {
 func (h *InventoryHandler) GetStockOpnames(c *gin.Context) {
 	companyID, _ := c.Get("company_id")
 
-	opnames, err := h.inventoryService.GetStockOpnamesByCompany(companyID.(uint))
+	opnames, err := h.inventoryService.GetStockOpnamesByCompany(companyID.(uint), c.Query("status"))
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve stock opnames", err)
 		return
@@ -325,52 +725,890 @@ func (h *InventoryHandler) GetStockOpnames(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusOK, "Stock opnames retrieved successfully", opnames)
 }
 
-func (h *InventoryHandler) ApproveStockOpname(c *gin.Context) {
+func (h *InventoryHandler) GetStockOpnameByID(c *gin.Context) {
 	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
 		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock opname ID", err)
 		return
 	}
 
-	userID, _ := c.Get("user_id")
-
-	if err := h.inventoryService.ApproveStockOpname(uint(id), userID.(uint)); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to approve stock opname", err)
+	opname, err := h.inventoryService.GetStockOpnameByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Stock opname not found", err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Stock opname approved successfully", nil)
+	utils.SuccessResponse(c, http.StatusOK, "Stock opname retrieved successfully", opname)
 }
 
-// HPP Calculation
-func (h *InventoryHandler) CalculateHPP(c *gin.Context) {
-	companyID, _ := c.Get("company_id")
+func (h *InventoryHandler) SubmitOpnameCounts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock opname ID", err)
+		return
+	}
 
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
+	var req SubmitOpnameCountsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
 
-	if startDateStr == "" || endDateStr == "" {
-		utils.ErrorResponse(c, http.StatusBadRequest, "start_date and end_date are required", nil)
+	userID, _ := c.Get("user_id")
+
+	counts := make([]models.StockOpnameCount, len(req.Counts))
+	for i, count := range req.Counts {
+		expiryDate, err := parseOptionalDate(count.ExpiryDate)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expiry_date format", err)
+			return
+		}
+
+		counts[i] = models.StockOpnameCount{
+			ProductID:      count.ProductID,
+			Quantity:       count.Quantity,
+			Batch:          count.Batch,
+			LotNumber:      count.LotNumber,
+			ExpiryDate:     expiryDate,
+			ScannedSerials: count.ScannedSerials,
+			Notes:          count.Notes,
+		}
+	}
+
+	if err := h.inventoryService.SubmitOpnameCounts(uint(id), req.Batch, counts, userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to submit counts", err)
 		return
 	}
 
-	startDate, err := time.Parse("2006-01-02", startDateStr)
+	opname, err := h.inventoryService.GetStockOpnameByID(uint(id))
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid start_date format", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve stock opname", err)
 		return
 	}
 
-	endDate, err := time.Parse("2006-01-02", endDateStr)
+	utils.SuccessResponse(c, http.StatusOK, "Counts submitted successfully", opname)
+}
+
+func (h *InventoryHandler) GetOpnameCounts(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid end_date format", err)
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock opname ID", err)
 		return
 	}
 
-	hpp, err := h.inventoryService.CalculateHPP(companyID.(uint), startDate, endDate)
+	round, _ := strconv.Atoi(c.Query("round"))
+
+	counts, err := h.inventoryService.GetOpnameCounts(uint(id), round)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate HPP", err)
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve counts", err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "HPP calculated successfully", hpp)
+	utils.SuccessResponse(c, http.StatusOK, "Counts retrieved successfully", counts)
+}
+
+func (h *InventoryHandler) ReviewStockOpname(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock opname ID", err)
+		return
+	}
+
+	opname, err := h.inventoryService.ReviewStockOpname(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to review stock opname", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock opname reviewed successfully", opname)
+}
+
+func (h *InventoryHandler) ApproveStockOpname(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock opname ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.inventoryService.ApproveStockOpname(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to approve stock opname", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock opname approved successfully", nil)
+}
+
+func (h *InventoryHandler) CancelStockOpname(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock opname ID", err)
+		return
+	}
+
+	if err := h.inventoryService.CancelStockOpname(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel stock opname", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock opname cancelled successfully", nil)
+}
+
+// Reorder Suggestions
+func (h *InventoryHandler) GetReorderSuggestions(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	lookbackDays := 90
+	if daysStr := c.Query("lookback_days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil || days <= 0 {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid lookback_days", err)
+			return
+		}
+		lookbackDays = days
+	}
+
+	onlyNeeded := c.DefaultQuery("only_needed", "true") == "true"
+
+	suggestions, err := h.inventoryService.GetReorderSuggestions(companyID.(uint), lookbackDays, onlyNeeded)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve reorder suggestions", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reorder suggestions retrieved successfully", suggestions)
+}
+
+// HPP Calculation
+func (h *InventoryHandler) CalculateHPP(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	startDateStr := c.Query("start_date")
+	endDateStr := c.Query("end_date")
+
+	if startDateStr == "" || endDateStr == "" {
+		utils.ErrorResponse(c, http.StatusBadRequest, "start_date and end_date are required", nil)
+		return
+	}
+
+	startDate, err := time.Parse("2006-01-02", startDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid start_date format", err)
+		return
+	}
+
+	endDate, err := time.Parse("2006-01-02", endDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid end_date format", err)
+		return
+	}
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	hpp, err := h.inventoryService.CalculateHPP(companyID.(uint), warehouseID, startDate, endDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to calculate HPP", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "HPP calculated successfully", hpp)
+}
+
+// Serial Number Handlers
+func (h *InventoryHandler) GetSerialNumbers(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	var productID uint64
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		parsed, err := strconv.ParseUint(productIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+			return
+		}
+		productID = parsed
+	}
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	serials, err := h.inventoryService.GetSerialNumbers(companyID.(uint), uint(productID), warehouseID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve serial numbers", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Serial numbers retrieved successfully", serials)
+}
+
+func (h *InventoryHandler) GetSerialHistory(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	history, err := h.inventoryService.GetSerialHistory(companyID.(uint), c.Param("serial_number"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Serial number not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Serial history retrieved successfully", history)
+}
+
+// Stock Lot Handlers
+func (h *InventoryHandler) GetStockLots(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	var productID uint64
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		parsed, err := strconv.ParseUint(productIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+			return
+		}
+		productID = parsed
+	}
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	var expiringBefore *time.Time
+	if daysStr := c.Query("expiring_within_days"); daysStr != "" {
+		days, err := strconv.Atoi(daysStr)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expiring_within_days", err)
+			return
+		}
+		date := time.Now().AddDate(0, 0, days)
+		expiringBefore = &date
+	}
+
+	lots, err := h.inventoryService.GetStockLots(companyID.(uint), uint(productID), warehouseID, expiringBefore)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve stock lots", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock lots retrieved successfully", lots)
+}
+
+// Warehouse Handlers
+type WarehouseRequest struct {
+	Code      string `json:"code" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+	Address   string `json:"address"`
+	IsDefault bool   `json:"is_default"`
+	IsActive  *bool  `json:"is_active"`
+}
+
+func (h *InventoryHandler) CreateWarehouse(c *gin.Context) {
+	var req WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	warehouse := &models.Warehouse{
+		CompanyID: companyID.(uint),
+		Code:      req.Code,
+		Name:      req.Name,
+		Address:   req.Address,
+		IsDefault: req.IsDefault,
+		IsActive:  true,
+	}
+
+	if err := h.inventoryService.CreateWarehouse(warehouse); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create warehouse", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Warehouse created successfully", warehouse)
+}
+
+func (h *InventoryHandler) GetWarehouses(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	warehouses, err := h.inventoryService.GetWarehousesByCompany(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve warehouses", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Warehouses retrieved successfully", warehouses)
+}
+
+func (h *InventoryHandler) UpdateWarehouse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	var req WarehouseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	warehouse := &models.Warehouse{
+		Code:     req.Code,
+		Name:     req.Name,
+		Address:  req.Address,
+		IsActive: req.IsActive == nil || *req.IsActive,
+	}
+
+	if err := h.inventoryService.UpdateWarehouse(uint(id), warehouse); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update warehouse", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Warehouse updated successfully", warehouse)
+}
+
+func (h *InventoryHandler) DeleteWarehouse(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	if err := h.inventoryService.DeleteWarehouse(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete warehouse", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Warehouse deleted successfully", nil)
+}
+
+// Stock Transfer Handlers
+type CreateStockTransferRequest struct {
+	TransferDate    string                       `json:"transfer_date" binding:"required"`
+	FromWarehouseID uint                         `json:"from_warehouse_id" binding:"required"`
+	ToWarehouseID   uint                         `json:"to_warehouse_id" binding:"required"`
+	Notes           string                       `json:"notes"`
+	Items           []StockTransferItemRequest   `json:"items" binding:"required,min=1,dive"`
+}
+
+type StockTransferItemRequest struct {
+	ProductID     uint     `json:"product_id" binding:"required"`
+	Quantity      float64  `json:"quantity" binding:"required,gt=0"`
+	SerialNumbers []string `json:"serial_numbers"`
+	Notes         string   `json:"notes"`
+}
+
+func (h *InventoryHandler) CreateStockTransfer(c *gin.Context) {
+	var req CreateStockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	transferDate, err := time.Parse("2006-01-02", req.TransferDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	items := make([]models.StockTransferItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.StockTransferItem{
+			ProductID:     item.ProductID,
+			Quantity:      item.Quantity,
+			SerialNumbers: item.SerialNumbers,
+			Notes:         item.Notes,
+		}
+	}
+
+	transfer := &models.StockTransfer{
+		CompanyID:       companyID.(uint),
+		TransferDate:    transferDate,
+		FromWarehouseID: req.FromWarehouseID,
+		ToWarehouseID:   req.ToWarehouseID,
+		Notes:           req.Notes,
+		CreatedBy:       userID.(uint),
+		Items:           items,
+	}
+
+	if err := h.inventoryService.CreateStockTransfer(transfer); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create stock transfer", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Stock transfer created successfully", transfer)
+}
+
+func (h *InventoryHandler) GetStockTransfers(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	transfers, err := h.inventoryService.GetStockTransfersByCompany(companyID.(uint), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve stock transfers", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock transfers retrieved successfully", transfers)
+}
+
+func (h *InventoryHandler) GetStockTransferByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock transfer ID", err)
+		return
+	}
+
+	transfer, err := h.inventoryService.GetStockTransferByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Stock transfer not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock transfer retrieved successfully", transfer)
+}
+
+func (h *InventoryHandler) DispatchStockTransfer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock transfer ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.inventoryService.DispatchStockTransfer(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to dispatch stock transfer", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock transfer dispatched successfully", nil)
+}
+
+func (h *InventoryHandler) ReceiveStockTransfer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock transfer ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.inventoryService.ReceiveStockTransfer(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to receive stock transfer", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock transfer received successfully", nil)
+}
+
+func (h *InventoryHandler) CancelStockTransfer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid stock transfer ID", err)
+		return
+	}
+
+	if err := h.inventoryService.CancelStockTransfer(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel stock transfer", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock transfer cancelled successfully", nil)
+}
+
+// Stock Reservation Handlers
+type CreateReservationRequest struct {
+	ProductID     uint    `json:"product_id" binding:"required"`
+	WarehouseID   uint    `json:"warehouse_id"` // 0 = default warehouse
+	Quantity      float64 `json:"quantity" binding:"required,gt=0"`
+	ExpiresAt     string  `json:"expires_at"`     // YYYY-MM-DD (held through that day) or RFC 3339
+	ReferenceType string  `json:"reference_type"` // sales_order, production_order, ...
+	Reference     string  `json:"reference"`
+	Notes         string  `json:"notes"`
+}
+
+func (h *InventoryHandler) CreateReservation(c *gin.Context) {
+	var req CreateReservationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	var expiresAt *time.Time
+	if req.ExpiresAt != "" {
+		parsed, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil {
+			date, dateErr := time.Parse("2006-01-02", req.ExpiresAt)
+			if dateErr != nil {
+				utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expires_at format", dateErr)
+				return
+			}
+			parsed = date.AddDate(0, 0, 1)
+		}
+		expiresAt = &parsed
+	}
+
+	reservation := &models.StockReservation{
+		CompanyID:     companyID.(uint),
+		ProductID:     req.ProductID,
+		WarehouseID:   req.WarehouseID,
+		Quantity:      req.Quantity,
+		ExpiresAt:     expiresAt,
+		ReferenceType: req.ReferenceType,
+		Reference:     req.Reference,
+		Notes:         req.Notes,
+		CreatedBy:     userID.(uint),
+	}
+
+	if err := h.inventoryService.CreateReservation(reservation); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create reservation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Reservation created successfully", reservation)
+}
+
+func (h *InventoryHandler) GetReservations(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	var productID uint64
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		parsed, err := strconv.ParseUint(productIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+			return
+		}
+		productID = parsed
+	}
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	reservations, err := h.inventoryService.GetReservations(companyID.(uint), uint(productID), warehouseID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve reservations", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservations retrieved successfully", reservations)
+}
+
+func (h *InventoryHandler) GetReservationByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid reservation ID", err)
+		return
+	}
+
+	reservation, err := h.inventoryService.GetReservationByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Reservation not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservation retrieved successfully", reservation)
+}
+
+func (h *InventoryHandler) ReleaseReservation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid reservation ID", err)
+		return
+	}
+
+	if err := h.inventoryService.ReleaseReservation(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to release reservation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservation released successfully", nil)
+}
+
+func (h *InventoryHandler) GetAvailableToPromise(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	var productIDs []uint
+	for _, idStr := range strings.Split(c.Query("product_ids"), ",") {
+		idStr = strings.TrimSpace(idStr)
+		if idStr == "" {
+			continue
+		}
+		id, err := strconv.ParseUint(idStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+			return
+		}
+		productIDs = append(productIDs, uint(id))
+	}
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	atp, err := h.inventoryService.GetAvailableToPromise(companyID.(uint), productIDs, warehouseID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to calculate available to promise", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Available to promise retrieved successfully", atp)
+}
+
+// Landed Cost Handlers
+type CreateLandedCostRequest struct {
+	DocumentDate string                    `json:"document_date" binding:"required"`
+	Method       models.LandedCostMethod   `json:"method"` // quantity, value (default), weight
+	Reference    string                    `json:"reference"`
+	Notes        string                    `json:"notes"`
+	Charges      []LandedCostChargeRequest `json:"charges" binding:"required,min=1,dive"`
+	Items        []LandedCostItemRequest   `json:"items" binding:"required,min=1,dive"`
+}
+
+type LandedCostChargeRequest struct {
+	ChargeType  string  `json:"charge_type" binding:"required"` // freight, customs_duty, insurance, other
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+	AccountID   *uint   `json:"account_id"`
+}
+
+type LandedCostItemRequest struct {
+	MovementID uint    `json:"movement_id" binding:"required"`
+	Weight     float64 `json:"weight"` // total weight of the receipt; 0 = product weight x quantity
+}
+
+func (h *InventoryHandler) CreateLandedCost(c *gin.Context) {
+	var req CreateLandedCostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	documentDate, err := time.Parse("2006-01-02", req.DocumentDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	charges := make([]models.LandedCostCharge, len(req.Charges))
+	for i, charge := range req.Charges {
+		charges[i] = models.LandedCostCharge{
+			ChargeType:  charge.ChargeType,
+			Description: charge.Description,
+			Amount:      charge.Amount,
+			AccountID:   charge.AccountID,
+		}
+	}
+
+	items := make([]models.LandedCostItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.LandedCostItem{
+			MovementID: item.MovementID,
+			Weight:     item.Weight,
+		}
+	}
+
+	landedCost := &models.LandedCost{
+		CompanyID:    companyID.(uint),
+		DocumentDate: documentDate,
+		Method:       req.Method,
+		Reference:    req.Reference,
+		Notes:        req.Notes,
+		CreatedBy:    userID.(uint),
+		Charges:      charges,
+		Items:        items,
+	}
+
+	if err := h.inventoryService.CreateLandedCost(landedCost); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create landed cost", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Landed cost created successfully", landedCost)
+}
+
+func (h *InventoryHandler) GetLandedCosts(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	landedCosts, err := h.inventoryService.GetLandedCostsByCompany(companyID.(uint), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve landed costs", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Landed costs retrieved successfully", landedCosts)
+}
+
+func (h *InventoryHandler) GetLandedCostByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid landed cost ID", err)
+		return
+	}
+
+	landedCost, err := h.inventoryService.GetLandedCostByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Landed cost not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Landed cost retrieved successfully", landedCost)
+}
+
+func (h *InventoryHandler) PostLandedCost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid landed cost ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.inventoryService.PostLandedCost(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to post landed cost", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Landed cost posted successfully", nil)
+}
+
+func (h *InventoryHandler) CancelLandedCost(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid landed cost ID", err)
+		return
+	}
+
+	if err := h.inventoryService.CancelLandedCost(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel landed cost", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Landed cost cancelled successfully", nil)
+}
+
+// Inventory Report Handlers
+func (h *InventoryHandler) GetStockCard(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	startDate, endDate, err := queryPeriod(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	card, err := h.inventoryService.GetStockCard(uint(productID), warehouseID, startDate, endDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to retrieve stock card", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Stock card retrieved successfully", card)
+}
+
+func (h *InventoryHandler) GetInventoryValuation(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	asOfDateStr := c.Query("as_of_date")
+	if asOfDateStr == "" {
+		asOfDateStr = time.Now().Format("2006-01-02")
+	}
+
+	asOfDate, err := time.Parse("2006-01-02", asOfDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid as_of_date format", err)
+		return
+	}
+
+	valuation, err := h.inventoryService.GetInventoryValuation(companyID.(uint), warehouseID, asOfDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve inventory valuation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Inventory valuation retrieved successfully", valuation)
+}
+
+func (h *InventoryHandler) GetInventoryAging(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	warehouseID, err := queryWarehouseID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid warehouse ID", err)
+		return
+	}
+
+	slowDays, _ := strconv.Atoi(c.DefaultQuery("slow_days", "90"))
+	deadDays, _ := strconv.Atoi(c.DefaultQuery("dead_days", "180"))
+
+	report, err := h.inventoryService.GetInventoryAging(companyID.(uint), warehouseID, slowDays, deadDays)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve inventory aging", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Inventory aging retrieved successfully", report)
+}
+
+// queryPeriod reads start_date and end_date, defaulting to the current month
+// up to today
+func queryPeriod(c *gin.Context) (time.Time, time.Time, error) {
+	now := time.Now()
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	endDate := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		parsed, err := time.Parse("2006-01-02", startDateStr)
+		if err != nil {
+			return startDate, endDate, err
+		}
+		startDate = parsed
+	}
+
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		parsed, err := time.Parse("2006-01-02", endDateStr)
+		if err != nil {
+			return startDate, endDate, err
+		}
+		endDate = parsed
+	}
+
+	return startDate, endDate, nil
 }
\ No newline at end of file
@@ -0,0 +1,267 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoanHandler struct {
+	loanService services.LoanService
+}
+
+func NewLoanHandler(loanService services.LoanService) *LoanHandler {
+	return &LoanHandler{loanService: loanService}
+}
+
+// Loan Handlers
+type CreateLoanRequest struct {
+	Lender                string  `json:"lender" binding:"required"`
+	AgreementNumber       string  `json:"agreement_number"`
+	Description           string  `json:"description"`
+	Principal             float64 `json:"principal" binding:"required,gt=0"`
+	InterestRate          float64 `json:"interest_rate" binding:"gte=0"` // annual %
+	RateType              string  `json:"rate_type"`                     // fixed, floating; omitted = fixed
+	Method                string  `json:"method" binding:"required"`     // annuity, flat, effective
+	TenorMonths           int     `json:"tenor_months" binding:"required,gt=0"`
+	StartDate             string  `json:"start_date" binding:"required"`
+	FirstDueDate          string  `json:"first_due_date"`          // omitted = a month after the start date
+	AccountID             uint    `json:"account_id"`              // omitted = 2-2100 Utang Bank Jangka Panjang
+	CurrentAccountID      uint    `json:"current_account_id"`      // omitted = 2-1800 Bagian Lancar Utang Bank Jangka Panjang
+	InterestAccountID     uint    `json:"interest_account_id"`     // omitted = 5-6000 Beban Bunga
+	ReminderDays          int     `json:"reminder_days"`           // omitted = 7
+	PaidInstallments      int     `json:"paid_installments"`       // already paid when taken over from an earlier register
+	DisbursementAccountID uint    `json:"disbursement_account_id"` // cash or bank account receiving the loan, omitted = already booked
+	Notes                 string  `json:"notes"`
+}
+
+type PayLoanInstallmentRequest struct {
+	AccountID   uint   `json:"account_id" binding:"required"`
+	PaymentDate string `json:"payment_date" binding:"required"`
+	Reference   string `json:"reference"`
+}
+
+type ChangeLoanRateRequest struct {
+	NewRate       float64 `json:"new_rate" binding:"gte=0"`
+	EffectiveDate string  `json:"effective_date" binding:"required"`
+	Notes         string  `json:"notes"`
+}
+
+func (h *LoanHandler) CreateLoan(c *gin.Context) {
+	var req CreateLoanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+	firstDueDate, err := parseOptionalDate(req.FirstDueDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	loan := &models.Loan{
+		CompanyID:         companyID.(uint),
+		Lender:            req.Lender,
+		AgreementNumber:   req.AgreementNumber,
+		Description:       req.Description,
+		Principal:         req.Principal,
+		InterestRate:      req.InterestRate,
+		RateType:          models.LoanRateType(req.RateType),
+		Method:            models.LoanMethod(req.Method),
+		TenorMonths:       req.TenorMonths,
+		StartDate:         startDate,
+		AccountID:         req.AccountID,
+		CurrentAccountID:  req.CurrentAccountID,
+		InterestAccountID: req.InterestAccountID,
+		ReminderDays:      req.ReminderDays,
+		Notes:             req.Notes,
+		CreatedBy:         userID.(uint),
+	}
+	if firstDueDate != nil {
+		loan.FirstDueDate = *firstDueDate
+	}
+
+	if err := h.loanService.CreateLoan(loan, req.PaidInstallments, req.DisbursementAccountID); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create loan", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Loan created successfully", loan)
+}
+
+func (h *LoanHandler) GetLoans(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	loans, err := h.loanService.GetLoans(companyID.(uint), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve loans", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Loans retrieved successfully", loans)
+}
+
+func (h *LoanHandler) GetLoanByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid loan ID", err)
+		return
+	}
+
+	loan, err := h.loanService.GetLoanByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Loan not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Loan retrieved successfully", loan)
+}
+
+func (h *LoanHandler) PayLoanInstallment(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid loan ID", err)
+		return
+	}
+
+	var req PayLoanInstallmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	installment, err := h.loanService.PayLoanInstallment(uint(id), req.AccountID, paymentDate, req.Reference, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to pay loan installment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Loan installment paid successfully", installment)
+}
+
+// Rate Change Handlers
+func (h *LoanHandler) ChangeLoanRate(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid loan ID", err)
+		return
+	}
+
+	var req ChangeLoanRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	effectiveDate, err := time.Parse("2006-01-02", req.EffectiveDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	change := &models.LoanRateChange{
+		EffectiveDate: effectiveDate,
+		NewRate:       req.NewRate,
+		Notes:         req.Notes,
+		CreatedBy:     userID.(uint),
+	}
+
+	if err := h.loanService.ChangeLoanRate(uint(id), change); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to change loan rate", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Loan rate changed successfully", change)
+}
+
+func (h *LoanHandler) GetLoanRateChanges(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid loan ID", err)
+		return
+	}
+
+	changes, err := h.loanService.GetLoanRateChanges(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve rate changes", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Rate changes retrieved successfully", changes)
+}
+
+// Reclassification Handlers
+type RunReclassificationRequest struct {
+	Period string `json:"period" binding:"required"` // YYYY-MM
+}
+
+func (h *LoanHandler) RunReclassification(c *gin.Context) {
+	var req RunReclassificationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	reclassification, err := h.loanService.RunReclassification(companyID.(uint), req.Period, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to reclassify current portion", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Current portion reclassified successfully", reclassification)
+}
+
+func (h *LoanHandler) GetReclassifications(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	reclassifications, err := h.loanService.GetReclassifications(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve reclassifications", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reclassifications retrieved successfully", reclassifications)
+}
+
+func (h *LoanHandler) GetReclassificationByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid reclassification ID", err)
+		return
+	}
+
+	reclassification, err := h.loanService.GetReclassificationByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Reclassification not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reclassification retrieved successfully", reclassification)
+}
\ No newline at end of file
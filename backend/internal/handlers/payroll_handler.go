@@ -0,0 +1,509 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PayrollHandler struct {
+	payrollService services.PayrollService
+}
+
+func NewPayrollHandler(payrollService services.PayrollService) *PayrollHandler {
+	return &PayrollHandler{payrollService: payrollService}
+}
+
+// Salary Component Handlers
+type SalaryComponentRequest struct {
+	Code      string `json:"code" binding:"required"`
+	Name      string `json:"name" binding:"required"`
+	Type      string `json:"type" binding:"required"` // allowance, deduction
+	IsTaxable *bool  `json:"is_taxable"`              // omitted = taxable allowance
+	AccountID *uint  `json:"account_id"`              // omitted = 5-1100 Beban Gaji
+	IsActive  *bool  `json:"is_active"`               // omitted = active
+}
+
+func (req *SalaryComponentRequest) toSalaryComponent(companyID uint) *models.SalaryComponent {
+	component := &models.SalaryComponent{
+		CompanyID: companyID,
+		Code:      req.Code,
+		Name:      req.Name,
+		Type:      models.SalaryComponentType(req.Type),
+		IsTaxable: true,
+		AccountID: req.AccountID,
+		IsActive:  true,
+	}
+	if req.IsTaxable != nil {
+		component.IsTaxable = *req.IsTaxable
+	}
+	if req.IsActive != nil {
+		component.IsActive = *req.IsActive
+	}
+	return component
+}
+
+func (h *PayrollHandler) CreateSalaryComponent(c *gin.Context) {
+	var req SalaryComponentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	component := req.toSalaryComponent(companyID.(uint))
+	if err := h.payrollService.CreateSalaryComponent(component); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create salary component", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Salary component created successfully", component)
+}
+
+func (h *PayrollHandler) GetSalaryComponents(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	components, err := h.payrollService.GetSalaryComponents(companyID.(uint), c.Query("active") == "true")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve salary components", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Salary components retrieved successfully", components)
+}
+
+func (h *PayrollHandler) UpdateSalaryComponent(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid salary component ID", err)
+		return
+	}
+
+	var req SalaryComponentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	if err := h.payrollService.UpdateSalaryComponent(uint(id), req.toSalaryComponent(companyID.(uint))); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update salary component", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Salary component updated successfully", nil)
+}
+
+// Employee Handlers
+type EmployeeComponentRequest struct {
+	ComponentID uint    `json:"component_id" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required,gt=0"`
+}
+
+type EmployeeRequest struct {
+	Code              string                     `json:"code" binding:"required"`
+	Name              string                     `json:"name" binding:"required"`
+	NIK               string                     `json:"nik"`
+	NPWP              string                     `json:"npwp"`
+	Position          string                     `json:"position"`
+	Department        string                     `json:"department"`
+	JoinDate          string                     `json:"join_date" binding:"required"`
+	EndDate           string                     `json:"end_date"`
+	PTKPStatus        string                     `json:"ptkp_status" binding:"required"` // TK/0 .. K/3
+	BaseSalary        float64                    `json:"base_salary" binding:"required,gt=0"`
+	HealthInsured     *bool                      `json:"health_insured"`     // omitted = enrolled
+	EmploymentInsured *bool                      `json:"employment_insured"` // omitted = enrolled
+	PensionInsured    *bool                      `json:"pension_insured"`    // omitted = enrolled
+	BankName          string                     `json:"bank_name"`
+	BankAccountNumber string                     `json:"bank_account_number"`
+	BankAccountName   string                     `json:"bank_account_name"`
+	IsActive          *bool                      `json:"is_active"` // omitted = active
+	Notes             string                     `json:"notes"`
+	Components        []EmployeeComponentRequest `json:"components" binding:"dive"`
+}
+
+func (req *EmployeeRequest) toEmployee(companyID uint) (*models.Employee, error) {
+	joinDate, err := time.Parse("2006-01-02", req.JoinDate)
+	if err != nil {
+		return nil, err
+	}
+	endDate, err := parseOptionalDate(req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	components := make([]models.EmployeeSalaryComponent, len(req.Components))
+	for i, component := range req.Components {
+		components[i] = models.EmployeeSalaryComponent{
+			ComponentID: component.ComponentID,
+			Amount:      component.Amount,
+		}
+	}
+
+	employee := &models.Employee{
+		CompanyID:         companyID,
+		Code:              req.Code,
+		Name:              req.Name,
+		NIK:               req.NIK,
+		NPWP:              req.NPWP,
+		Position:          req.Position,
+		Department:        req.Department,
+		JoinDate:          joinDate,
+		EndDate:           endDate,
+		PTKPStatus:        models.PTKPStatus(req.PTKPStatus),
+		BaseSalary:        req.BaseSalary,
+		HealthInsured:     true,
+		EmploymentInsured: true,
+		PensionInsured:    true,
+		BankName:          req.BankName,
+		BankAccountNumber: req.BankAccountNumber,
+		BankAccountName:   req.BankAccountName,
+		IsActive:          true,
+		Notes:             req.Notes,
+		Components:        components,
+	}
+	if req.HealthInsured != nil {
+		employee.HealthInsured = *req.HealthInsured
+	}
+	if req.EmploymentInsured != nil {
+		employee.EmploymentInsured = *req.EmploymentInsured
+	}
+	if req.PensionInsured != nil {
+		employee.PensionInsured = *req.PensionInsured
+	}
+	if req.IsActive != nil {
+		employee.IsActive = *req.IsActive
+	}
+	return employee, nil
+}
+
+func (h *PayrollHandler) CreateEmployee(c *gin.Context) {
+	var req EmployeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	employee, err := req.toEmployee(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	if err := h.payrollService.CreateEmployee(employee); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create employee", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Employee created successfully", employee)
+}
+
+func (h *PayrollHandler) GetEmployees(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	employees, err := h.payrollService.GetEmployees(companyID.(uint), c.Query("department"), c.Query("active") == "true")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve employees", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Employees retrieved successfully", employees)
+}
+
+func (h *PayrollHandler) GetEmployeeByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid employee ID", err)
+		return
+	}
+
+	employee, err := h.payrollService.GetEmployeeByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Employee not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Employee retrieved successfully", employee)
+}
+
+func (h *PayrollHandler) UpdateEmployee(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid employee ID", err)
+		return
+	}
+
+	var req EmployeeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	employee, err := req.toEmployee(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	if err := h.payrollService.UpdateEmployee(uint(id), employee); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update employee", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Employee updated successfully", nil)
+}
+
+func (h *PayrollHandler) DeleteEmployee(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid employee ID", err)
+		return
+	}
+
+	if err := h.payrollService.DeleteEmployee(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete employee", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Employee deleted successfully", nil)
+}
+
+func (h *PayrollHandler) GetEmployeePayslips(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid employee ID", err)
+		return
+	}
+
+	yearStr := c.Query("year")
+	if yearStr == "" {
+		yearStr = strconv.Itoa(time.Now().Year())
+	}
+
+	year, err := strconv.Atoi(yearStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid year format", err)
+		return
+	}
+
+	payslips, err := h.payrollService.GetEmployeePayslips(uint(id), year)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve payslips", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payslips retrieved successfully", payslips)
+}
+
+// Payroll Setting Handlers
+type PayrollSettingRequest struct {
+	JKKRate        float64 `json:"jkk_rate" binding:"required,gt=0"`
+	HealthWageCap  float64 `json:"health_wage_cap" binding:"required,gt=0"`
+	PensionWageCap float64 `json:"pension_wage_cap" binding:"required,gt=0"`
+}
+
+func (h *PayrollHandler) GetPayrollSetting(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	setting, err := h.payrollService.GetPayrollSetting(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve payroll settings", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payroll settings retrieved successfully", setting)
+}
+
+func (h *PayrollHandler) SetPayrollSetting(c *gin.Context) {
+	var req PayrollSettingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	setting, err := h.payrollService.SetPayrollSetting(&models.PayrollSetting{
+		CompanyID:      companyID.(uint),
+		JKKRate:        req.JKKRate,
+		HealthWageCap:  req.HealthWageCap,
+		PensionWageCap: req.PensionWageCap,
+	})
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update payroll settings", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payroll settings updated successfully", setting)
+}
+
+// Payroll Run Handlers
+type CreatePayrollRunRequest struct {
+	Period  string `json:"period" binding:"required"` // YYYY-MM
+	PayDate string `json:"pay_date" binding:"required"`
+	Notes   string `json:"notes"`
+}
+
+type PayPayrollRunRequest struct {
+	AccountID   uint   `json:"account_id" binding:"required"`
+	PaymentDate string `json:"payment_date" binding:"required"`
+	Reference   string `json:"reference"`
+}
+
+func (h *PayrollHandler) CreatePayrollRun(c *gin.Context) {
+	var req CreatePayrollRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	payDate, err := time.Parse("2006-01-02", req.PayDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	run := &models.PayrollRun{
+		CompanyID: companyID.(uint),
+		Period:    req.Period,
+		PayDate:   payDate,
+		Notes:     req.Notes,
+		CreatedBy: userID.(uint),
+	}
+
+	if err := h.payrollService.CreatePayrollRun(run); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create payroll run", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Payroll run created successfully", run)
+}
+
+func (h *PayrollHandler) GetPayrollRuns(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	year := 0
+	if yearStr := c.Query("year"); yearStr != "" {
+		var err error
+		year, err = strconv.Atoi(yearStr)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid year format", err)
+			return
+		}
+	}
+
+	runs, err := h.payrollService.GetPayrollRuns(companyID.(uint), year)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve payroll runs", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payroll runs retrieved successfully", runs)
+}
+
+func (h *PayrollHandler) GetPayrollRunByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payroll run ID", err)
+		return
+	}
+
+	run, err := h.payrollService.GetPayrollRunByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Payroll run not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payroll run retrieved successfully", run)
+}
+
+func (h *PayrollHandler) DeletePayrollRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payroll run ID", err)
+		return
+	}
+
+	if err := h.payrollService.DeletePayrollRun(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete payroll run", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payroll run deleted successfully", nil)
+}
+
+func (h *PayrollHandler) PostPayrollRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payroll run ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.payrollService.PostPayrollRun(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to post payroll run", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payroll run posted successfully", nil)
+}
+
+func (h *PayrollHandler) PayPayrollRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payroll run ID", err)
+		return
+	}
+
+	var req PayPayrollRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	if err := h.payrollService.PayPayrollRun(uint(id), req.AccountID, paymentDate, req.Reference, userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to pay payroll run", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payroll run paid successfully", nil)
+}
+
+func (h *PayrollHandler) GetPayslipByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payslip ID", err)
+		return
+	}
+
+	payslip, err := h.payrollService.GetPayslipByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Payslip not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payslip retrieved successfully", payslip)
+}
\ No newline at end of file
@@ -0,0 +1,552 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProcurementHandler struct {
+	procurementService services.ProcurementService
+}
+
+func NewProcurementHandler(procurementService services.ProcurementService) *ProcurementHandler {
+	return &ProcurementHandler{procurementService: procurementService}
+}
+
+// Purchase Requisition Handlers
+type CreatePurchaseRequisitionRequest struct {
+	RequestDate  string                           `json:"request_date" binding:"required"`
+	RequiredDate string                           `json:"required_date"`
+	Department   string                           `json:"department"`
+	Notes        string                           `json:"notes"`
+	Items        []PurchaseRequisitionItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type PurchaseRequisitionItemRequest struct {
+	ProductID   uint    `json:"product_id" binding:"required"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity" binding:"required,gt=0"`
+	Unit        string  `json:"unit"`
+}
+
+func (h *ProcurementHandler) CreatePurchaseRequisition(c *gin.Context) {
+	var req CreatePurchaseRequisitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	requestDate, err := time.Parse("2006-01-02", req.RequestDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	requiredDate, err := parseOptionalDate(req.RequiredDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid required_date format", err)
+		return
+	}
+
+	items := make([]models.PurchaseRequisitionItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.PurchaseRequisitionItem{
+			ProductID:   item.ProductID,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			Unit:        item.Unit,
+		}
+	}
+
+	requisition := &models.PurchaseRequisition{
+		CompanyID:    companyID.(uint),
+		RequestDate:  requestDate,
+		RequiredDate: requiredDate,
+		Department:   req.Department,
+		Notes:        req.Notes,
+		CreatedBy:    userID.(uint),
+		Items:        items,
+	}
+
+	if err := h.procurementService.CreatePurchaseRequisition(requisition); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create purchase requisition", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Purchase requisition created successfully", requisition)
+}
+
+func (h *ProcurementHandler) GetPurchaseRequisitions(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	requisitions, err := h.procurementService.GetPurchaseRequisitions(companyID.(uint), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve purchase requisitions", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase requisitions retrieved successfully", requisitions)
+}
+
+func (h *ProcurementHandler) GetPurchaseRequisitionByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase requisition ID", err)
+		return
+	}
+
+	requisition, err := h.procurementService.GetPurchaseRequisitionByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Purchase requisition not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase requisition retrieved successfully", requisition)
+}
+
+func (h *ProcurementHandler) ApprovePurchaseRequisition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase requisition ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.procurementService.ApprovePurchaseRequisition(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to approve purchase requisition", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase requisition approved successfully", nil)
+}
+
+func (h *ProcurementHandler) RejectPurchaseRequisition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase requisition ID", err)
+		return
+	}
+
+	if err := h.procurementService.RejectPurchaseRequisition(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to reject purchase requisition", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase requisition rejected successfully", nil)
+}
+
+func (h *ProcurementHandler) CancelPurchaseRequisition(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase requisition ID", err)
+		return
+	}
+
+	if err := h.procurementService.CancelPurchaseRequisition(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel purchase requisition", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase requisition cancelled successfully", nil)
+}
+
+// Purchase Order Handlers
+type CreatePurchaseOrderRequest struct {
+	OrderDate    string                     `json:"order_date" binding:"required"`
+	ExpectedDate string                     `json:"expected_date"`
+	VendorID     uint                       `json:"vendor_id" binding:"required"`
+	WarehouseID  uint                       `json:"warehouse_id"`
+	TaxRate      *float64                   `json:"tax_rate"` // omitted = standard PPN rate
+	Reference    string                     `json:"reference"`
+	Notes        string                     `json:"notes"`
+	Items        []PurchaseOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type PurchaseOrderItemRequest struct {
+	ProductID         uint    `json:"product_id" binding:"required"`
+	RequisitionItemID *uint   `json:"requisition_item_id"`
+	Description       string  `json:"description"`
+	Quantity          float64 `json:"quantity" binding:"required,gt=0"`
+	Unit              string  `json:"unit"`
+	UnitPrice         float64 `json:"unit_price" binding:"gte=0"`
+	DiscountPercent   float64 `json:"discount_percent"`
+}
+
+func (h *ProcurementHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req CreatePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	orderDate, err := time.Parse("2006-01-02", req.OrderDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	expectedDate, err := parseOptionalDate(req.ExpectedDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expected_date format", err)
+		return
+	}
+
+	items := make([]models.PurchaseOrderItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.PurchaseOrderItem{
+			ProductID:         item.ProductID,
+			RequisitionItemID: item.RequisitionItemID,
+			Description:       item.Description,
+			Quantity:          item.Quantity,
+			Unit:              item.Unit,
+			UnitPrice:         item.UnitPrice,
+			DiscountPercent:   item.DiscountPercent,
+		}
+	}
+
+	order := &models.PurchaseOrder{
+		CompanyID:    companyID.(uint),
+		OrderDate:    orderDate,
+		ExpectedDate: expectedDate,
+		VendorID:     req.VendorID,
+		WarehouseID:  req.WarehouseID,
+		TaxRate:      services.DefaultPPNRate,
+		Reference:    req.Reference,
+		Notes:        req.Notes,
+		CreatedBy:    userID.(uint),
+		Items:        items,
+	}
+	if req.TaxRate != nil {
+		order.TaxRate = *req.TaxRate
+	}
+
+	if err := h.procurementService.CreatePurchaseOrder(order); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create purchase order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Purchase order created successfully", order)
+}
+
+func (h *ProcurementHandler) GetPurchaseOrders(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	orders, err := h.procurementService.GetPurchaseOrders(companyID.(uint), vendorID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve purchase orders", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase orders retrieved successfully", orders)
+}
+
+func (h *ProcurementHandler) GetPurchaseOrderByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase order ID", err)
+		return
+	}
+
+	order, err := h.procurementService.GetPurchaseOrderByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Purchase order not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase order retrieved successfully", order)
+}
+
+func (h *ProcurementHandler) ApprovePurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase order ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.procurementService.ApprovePurchaseOrder(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to approve purchase order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase order approved successfully", nil)
+}
+
+func (h *ProcurementHandler) ClosePurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase order ID", err)
+		return
+	}
+
+	if err := h.procurementService.ClosePurchaseOrder(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to close purchase order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase order closed successfully", nil)
+}
+
+func (h *ProcurementHandler) CancelPurchaseOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase order ID", err)
+		return
+	}
+
+	if err := h.procurementService.CancelPurchaseOrder(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel purchase order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase order cancelled successfully", nil)
+}
+
+// Goods Receipt Handlers
+type CreateGoodsReceiptRequest struct {
+	ReceiptDate     string                    `json:"receipt_date" binding:"required"`
+	PurchaseOrderID uint                      `json:"purchase_order_id" binding:"required"`
+	WarehouseID     uint                      `json:"warehouse_id"` // 0 = the purchase order's warehouse
+	Reference       string                    `json:"reference"`
+	Notes           string                    `json:"notes"`
+	Items           []GoodsReceiptItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type GoodsReceiptItemRequest struct {
+	PurchaseOrderItemID uint     `json:"purchase_order_item_id" binding:"required"`
+	Description         string   `json:"description"`
+	Quantity            float64  `json:"quantity" binding:"required,gt=0"`
+	LotNumber           string   `json:"lot_number"`
+	ExpiryDate          string   `json:"expiry_date"`
+	SerialNumbers       []string `json:"serial_numbers"`
+}
+
+func (h *ProcurementHandler) CreateGoodsReceipt(c *gin.Context) {
+	var req CreateGoodsReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	receiptDate, err := time.Parse("2006-01-02", req.ReceiptDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	items := make([]models.GoodsReceiptItem, len(req.Items))
+	for i, item := range req.Items {
+		expiryDate, err := parseOptionalDate(item.ExpiryDate)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expiry_date format", err)
+			return
+		}
+		items[i] = models.GoodsReceiptItem{
+			PurchaseOrderItemID: item.PurchaseOrderItemID,
+			Description:         item.Description,
+			Quantity:            item.Quantity,
+			LotNumber:           item.LotNumber,
+			ExpiryDate:          expiryDate,
+			SerialNumbers:       item.SerialNumbers,
+		}
+	}
+
+	receipt := &models.GoodsReceipt{
+		CompanyID:       companyID.(uint),
+		ReceiptDate:     receiptDate,
+		PurchaseOrderID: req.PurchaseOrderID,
+		WarehouseID:     req.WarehouseID,
+		Reference:       req.Reference,
+		Notes:           req.Notes,
+		CreatedBy:       userID.(uint),
+		Items:           items,
+	}
+
+	if err := h.procurementService.CreateGoodsReceipt(receipt); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create goods receipt", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Goods receipt created successfully", receipt)
+}
+
+func (h *ProcurementHandler) GetGoodsReceipts(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	var purchaseOrderID uint64
+	if purchaseOrderIDStr := c.Query("purchase_order_id"); purchaseOrderIDStr != "" {
+		purchaseOrderID, err = strconv.ParseUint(purchaseOrderIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase order ID", err)
+			return
+		}
+	}
+
+	receipts, err := h.procurementService.GetGoodsReceipts(companyID.(uint), vendorID, uint(purchaseOrderID), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve goods receipts", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Goods receipts retrieved successfully", receipts)
+}
+
+func (h *ProcurementHandler) GetGoodsReceiptByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid goods receipt ID", err)
+		return
+	}
+
+	receipt, err := h.procurementService.GetGoodsReceiptByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Goods receipt not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Goods receipt retrieved successfully", receipt)
+}
+
+func (h *ProcurementHandler) PostGoodsReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid goods receipt ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.procurementService.PostGoodsReceipt(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to post goods receipt", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Goods receipt posted successfully", nil)
+}
+
+func (h *ProcurementHandler) CancelGoodsReceipt(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid goods receipt ID", err)
+		return
+	}
+
+	if err := h.procurementService.CancelGoodsReceipt(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel goods receipt", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Goods receipt cancelled successfully", nil)
+}
+
+// Match Tolerance Handlers
+type SetMatchToleranceRequest struct {
+	QuantityPercent float64 `json:"quantity_percent" binding:"gte=0"`
+	PricePercent    float64 `json:"price_percent" binding:"gte=0"`
+}
+
+func (h *ProcurementHandler) GetMatchTolerance(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	tolerance, err := h.procurementService.GetMatchTolerance(companyID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve match tolerance", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Match tolerance retrieved successfully", tolerance)
+}
+
+func (h *ProcurementHandler) SetMatchTolerance(c *gin.Context) {
+	var req SetMatchToleranceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	tolerance, err := h.procurementService.SetMatchTolerance(companyID.(uint), req.QuantityPercent, req.PricePercent)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to save match tolerance", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Match tolerance saved successfully", tolerance)
+}
+
+// Procurement Report Handlers
+func (h *ProcurementHandler) GetOpenPurchaseOrders(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	report, err := h.procurementService.GetOpenPurchaseOrders(companyID.(uint), vendorID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve open purchase orders", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Open purchase orders retrieved successfully", report)
+}
+
+func (h *ProcurementHandler) GetGRNIReport(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	asOfDateStr := c.Query("as_of_date")
+	if asOfDateStr == "" {
+		asOfDateStr = time.Now().Format("2006-01-02")
+	}
+	asOfDate, err := time.Parse("2006-01-02", asOfDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	report, err := h.procurementService.GetGRNIReport(companyID.(uint), vendorID, asOfDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve received-not-billed report", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Received-not-billed report retrieved successfully", report)
+}
\ No newline at end of file
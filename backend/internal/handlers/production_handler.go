@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ProductionHandler struct {
+	productionService services.ProductionService
+}
+
+func NewProductionHandler(productionService services.ProductionService) *ProductionHandler {
+	return &ProductionHandler{productionService: productionService}
+}
+
+// Bill of Materials Handlers
+type SetBOMRequest struct {
+	Items []BOMItemRequest `json:"items" binding:"dive"`
+}
+
+type BOMItemRequest struct {
+	ComponentID uint    `json:"component_id" binding:"required"`
+	Quantity    float64 `json:"quantity" binding:"required,gt=0"`
+	Notes       string  `json:"notes"`
+}
+
+func (h *ProductionHandler) GetBOM(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	items, err := h.productionService.GetBOM(uint(productID))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve bill of materials", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bill of materials retrieved successfully", items)
+}
+
+func (h *ProductionHandler) SetBOM(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	var req SetBOMRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	items := make([]models.BOMItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.BOMItem{
+			ComponentID: item.ComponentID,
+			Quantity:    item.Quantity,
+			Notes:       item.Notes,
+		}
+	}
+
+	if err := h.productionService.SetBOM(uint(productID), items); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to save bill of materials", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bill of materials saved successfully", items)
+}
+
+func (h *ProductionHandler) GetBOMCost(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("product_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid product ID", err)
+		return
+	}
+
+	quantity := 1.0
+	if quantityStr := c.Query("quantity"); quantityStr != "" {
+		quantity, err = strconv.ParseFloat(quantityStr, 64)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid quantity", err)
+			return
+		}
+	}
+
+	cost, err := h.productionService.GetBOMCost(uint(productID), quantity)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to calculate bill of materials cost", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Bill of materials cost calculated successfully", cost)
+}
+
+// Production Order Handlers
+type CreateProductionOrderRequest struct {
+	OrderDate     string                       `json:"order_date" binding:"required"`
+	ProductID     uint                         `json:"product_id" binding:"required"`
+	WarehouseID   uint                         `json:"warehouse_id" binding:"required"`
+	Quantity      float64                      `json:"quantity" binding:"required,gt=0"`
+	LaborCost     float64                      `json:"labor_cost"`
+	OverheadCost  float64                      `json:"overhead_cost"`
+	LotNumber     string                       `json:"lot_number"`
+	ExpiryDate    string                       `json:"expiry_date"`
+	SerialNumbers []string                     `json:"serial_numbers"`
+	Notes         string                       `json:"notes"`
+	Items         []ProductionOrderItemRequest `json:"items" binding:"dive"` // empty = explode the product's BOM
+}
+
+type ProductionOrderItemRequest struct {
+	ComponentID   uint     `json:"component_id" binding:"required"`
+	Quantity      float64  `json:"quantity" binding:"required,gt=0"`
+	SerialNumbers []string `json:"serial_numbers"`
+}
+
+type CompleteProductionOrderRequest struct {
+	LaborCost    *float64 `json:"labor_cost"`    // omitted = keep planned
+	OverheadCost *float64 `json:"overhead_cost"` // omitted = keep planned
+}
+
+func (h *ProductionHandler) CreateProductionOrder(c *gin.Context) {
+	var req CreateProductionOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	orderDate, err := time.Parse("2006-01-02", req.OrderDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	expiryDate, err := parseOptionalDate(req.ExpiryDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expiry_date format", err)
+		return
+	}
+
+	items := make([]models.ProductionOrderItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.ProductionOrderItem{
+			ComponentID:   item.ComponentID,
+			Quantity:      item.Quantity,
+			SerialNumbers: item.SerialNumbers,
+		}
+	}
+
+	order := &models.ProductionOrder{
+		CompanyID:     companyID.(uint),
+		OrderDate:     orderDate,
+		ProductID:     req.ProductID,
+		WarehouseID:   req.WarehouseID,
+		Quantity:      req.Quantity,
+		LaborCost:     req.LaborCost,
+		OverheadCost:  req.OverheadCost,
+		LotNumber:     req.LotNumber,
+		ExpiryDate:    expiryDate,
+		SerialNumbers: req.SerialNumbers,
+		Notes:         req.Notes,
+		CreatedBy:     userID.(uint),
+		Items:         items,
+	}
+
+	if err := h.productionService.CreateProductionOrder(order); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create production order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Production order created successfully", order)
+}
+
+func (h *ProductionHandler) GetProductionOrders(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	orders, err := h.productionService.GetProductionOrdersByCompany(companyID.(uint), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve production orders", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Production orders retrieved successfully", orders)
+}
+
+func (h *ProductionHandler) GetProductionOrderByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid production order ID", err)
+		return
+	}
+
+	order, err := h.productionService.GetProductionOrderByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Production order not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Production order retrieved successfully", order)
+}
+
+func (h *ProductionHandler) StartProductionOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid production order ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.productionService.StartProductionOrder(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to start production order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Production order started successfully", nil)
+}
+
+func (h *ProductionHandler) CompleteProductionOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid production order ID", err)
+		return
+	}
+
+	var req CompleteProductionOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+			return
+		}
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.productionService.CompleteProductionOrder(uint(id), req.LaborCost, req.OverheadCost, userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to complete production order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Production order completed successfully", nil)
+}
+
+func (h *ProductionHandler) CancelProductionOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid production order ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.productionService.CancelProductionOrder(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel production order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Production order cancelled successfully", nil)
+}
\ No newline at end of file
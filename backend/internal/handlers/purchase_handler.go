@@ -0,0 +1,842 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseHandler struct {
+	purchaseService services.PurchaseService
+}
+
+func NewPurchaseHandler(purchaseService services.PurchaseService) *PurchaseHandler {
+	return &PurchaseHandler{purchaseService: purchaseService}
+}
+
+// Vendor Handlers
+type VendorRequest struct {
+	Code              string `json:"code" binding:"required"`
+	Name              string `json:"name" binding:"required"`
+	NPWP              string `json:"npwp"`
+	Address           string `json:"address"`
+	Phone             string `json:"phone"`
+	Email             string `json:"email"`
+	ContactPerson     string `json:"contact_person"`
+	PaymentTermDays   *int   `json:"payment_term_days"` // omitted = 30 days
+	BankName          string `json:"bank_name"`
+	BankAccountNumber string `json:"bank_account_number"`
+	BankAccountName   string `json:"bank_account_name"`
+	IsActive          *bool  `json:"is_active"` // omitted = active
+	Notes             string `json:"notes"`
+}
+
+func (req *VendorRequest) toVendor(companyID uint) *models.Vendor {
+	vendor := &models.Vendor{
+		CompanyID:         companyID,
+		Code:              req.Code,
+		Name:              req.Name,
+		NPWP:              req.NPWP,
+		Address:           req.Address,
+		Phone:             req.Phone,
+		Email:             req.Email,
+		ContactPerson:     req.ContactPerson,
+		PaymentTermDays:   30,
+		BankName:          req.BankName,
+		BankAccountNumber: req.BankAccountNumber,
+		BankAccountName:   req.BankAccountName,
+		IsActive:          true,
+		Notes:             req.Notes,
+	}
+	if req.PaymentTermDays != nil {
+		vendor.PaymentTermDays = *req.PaymentTermDays
+	}
+	if req.IsActive != nil {
+		vendor.IsActive = *req.IsActive
+	}
+	return vendor
+}
+
+func (h *PurchaseHandler) CreateVendor(c *gin.Context) {
+	var req VendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	vendor := req.toVendor(companyID.(uint))
+	if err := h.purchaseService.CreateVendor(vendor); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create vendor", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Vendor created successfully", vendor)
+}
+
+func (h *PurchaseHandler) GetVendors(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendors, err := h.purchaseService.GetVendorsByCompany(companyID.(uint), c.Query("active") == "true")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve vendors", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Vendors retrieved successfully", vendors)
+}
+
+func (h *PurchaseHandler) GetVendorByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	vendor, err := h.purchaseService.GetVendorByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Vendor not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Vendor retrieved successfully", vendor)
+}
+
+func (h *PurchaseHandler) UpdateVendor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	var req VendorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	if err := h.purchaseService.UpdateVendor(uint(id), req.toVendor(companyID.(uint))); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update vendor", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Vendor updated successfully", nil)
+}
+
+func (h *PurchaseHandler) DeleteVendor(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	if err := h.purchaseService.DeleteVendor(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete vendor", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Vendor deleted successfully", nil)
+}
+
+// Purchase Bill Handlers
+type CreatePurchaseBillRequest struct {
+	BillDate         string                    `json:"bill_date" binding:"required"`
+	DueDate          string                    `json:"due_date"` // empty = bill date plus the vendor's payment terms
+	VendorID         uint                      `json:"vendor_id" binding:"required"`
+	VendorInvoiceNo  string                    `json:"vendor_invoice_no"`
+	WarehouseID      uint                      `json:"warehouse_id"`
+	TaxRate          *float64                  `json:"tax_rate"` // omitted = standard PPN rate
+	TaxInvoiceNumber string                    `json:"tax_invoice_number"`
+	WithholdingType  string                    `json:"withholding_type"` // pph23, pph4ayat2
+	WithholdingRate  float64                   `json:"withholding_rate"`
+	Notes            string                    `json:"notes"`
+	Items            []PurchaseBillItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type PurchaseBillItemRequest struct {
+	ProductID          *uint    `json:"product_id"`            // stock line
+	AccountID          *uint    `json:"account_id"`            // expense line
+	GoodsReceiptItemID *uint    `json:"goods_receipt_item_id"` // stock line already received against a purchase order
+	Description        string   `json:"description"`
+	Quantity           float64  `json:"quantity" binding:"required,gt=0"`
+	Unit               string   `json:"unit"`
+	UnitPrice          float64  `json:"unit_price" binding:"gte=0"`
+	DiscountPercent    float64  `json:"discount_percent"`
+	IsWithholdable     bool     `json:"is_withholdable"`
+	LotNumber          string   `json:"lot_number"`
+	ExpiryDate         string   `json:"expiry_date"`
+	SerialNumbers      []string `json:"serial_numbers"`
+}
+
+func (h *PurchaseHandler) CreatePurchaseBill(c *gin.Context) {
+	var req CreatePurchaseBillRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	billDate, err := time.Parse("2006-01-02", req.BillDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	dueDate, err := parseOptionalDate(req.DueDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid due_date format", err)
+		return
+	}
+
+	items := make([]models.PurchaseBillItem, len(req.Items))
+	for i, item := range req.Items {
+		expiryDate, err := parseOptionalDate(item.ExpiryDate)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expiry_date format", err)
+			return
+		}
+		items[i] = models.PurchaseBillItem{
+			ProductID:          item.ProductID,
+			AccountID:          item.AccountID,
+			GoodsReceiptItemID: item.GoodsReceiptItemID,
+			Description:        item.Description,
+			Quantity:           item.Quantity,
+			Unit:               item.Unit,
+			UnitPrice:          item.UnitPrice,
+			DiscountPercent:    item.DiscountPercent,
+			IsWithholdable:     item.IsWithholdable,
+			LotNumber:          item.LotNumber,
+			ExpiryDate:         expiryDate,
+			SerialNumbers:      item.SerialNumbers,
+		}
+	}
+
+	bill := &models.PurchaseBill{
+		CompanyID:        companyID.(uint),
+		BillDate:         billDate,
+		VendorID:         req.VendorID,
+		VendorInvoiceNo:  req.VendorInvoiceNo,
+		WarehouseID:      req.WarehouseID,
+		TaxRate:          services.DefaultPPNRate,
+		TaxInvoiceNumber: req.TaxInvoiceNumber,
+		WithholdingType:  models.TaxType(req.WithholdingType),
+		WithholdingRate:  req.WithholdingRate,
+		Notes:            req.Notes,
+		CreatedBy:        userID.(uint),
+		Items:            items,
+	}
+	if dueDate != nil {
+		bill.DueDate = *dueDate
+	}
+	if req.TaxRate != nil {
+		bill.TaxRate = *req.TaxRate
+	}
+
+	if err := h.purchaseService.CreatePurchaseBill(bill); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create purchase bill", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Purchase bill created successfully", bill)
+}
+
+func (h *PurchaseHandler) GetPurchaseBills(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	bills, err := h.purchaseService.GetPurchaseBills(companyID.(uint), vendorID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve purchase bills", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase bills retrieved successfully", bills)
+}
+
+func (h *PurchaseHandler) GetPurchaseBillByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase bill ID", err)
+		return
+	}
+
+	bill, err := h.purchaseService.GetPurchaseBillByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Purchase bill not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase bill retrieved successfully", bill)
+}
+
+func (h *PurchaseHandler) PostPurchaseBill(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase bill ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.purchaseService.PostPurchaseBill(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to post purchase bill", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase bill posted successfully", nil)
+}
+
+func (h *PurchaseHandler) CancelPurchaseBill(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase bill ID", err)
+		return
+	}
+
+	if err := h.purchaseService.CancelPurchaseBill(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel purchase bill", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase bill cancelled successfully", nil)
+}
+
+func (h *PurchaseHandler) ApprovePurchaseBillMatch(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase bill ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.purchaseService.ApprovePurchaseBillMatch(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to approve purchase bill match", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Purchase bill match approved successfully", nil)
+}
+
+// Vendor Payment Handlers
+type CreateVendorPaymentRequest struct {
+	PaymentDate string                           `json:"payment_date" binding:"required"`
+	VendorID    uint                             `json:"vendor_id" binding:"required"`
+	AccountID   uint                             `json:"account_id" binding:"required"`
+	Amount      float64                          `json:"amount" binding:"required,gt=0"`
+	Reference   string                           `json:"reference"`
+	Notes       string                           `json:"notes"`
+	Allocations []VendorPaymentAllocationRequest `json:"allocations" binding:"dive"` // empty = oldest due bills first
+}
+
+type VendorPaymentAllocationRequest struct {
+	BillID uint    `json:"bill_id" binding:"required"`
+	Amount float64 `json:"amount" binding:"required,gt=0"`
+}
+
+func (h *PurchaseHandler) CreateVendorPayment(c *gin.Context) {
+	var req CreateVendorPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	allocations := make([]models.VendorPaymentAllocation, len(req.Allocations))
+	for i, allocation := range req.Allocations {
+		allocations[i] = models.VendorPaymentAllocation{
+			BillID: allocation.BillID,
+			Amount: allocation.Amount,
+		}
+	}
+
+	payment := &models.VendorPayment{
+		CompanyID:   companyID.(uint),
+		PaymentDate: paymentDate,
+		VendorID:    req.VendorID,
+		AccountID:   req.AccountID,
+		Amount:      req.Amount,
+		Reference:   req.Reference,
+		Notes:       req.Notes,
+		CreatedBy:   userID.(uint),
+		Allocations: allocations,
+	}
+
+	if err := h.purchaseService.CreateVendorPayment(payment); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to record vendor payment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Vendor payment recorded successfully", payment)
+}
+
+func (h *PurchaseHandler) GetVendorPayments(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	payments, err := h.purchaseService.GetVendorPayments(companyID.(uint), vendorID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve vendor payments", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Vendor payments retrieved successfully", payments)
+}
+
+func (h *PurchaseHandler) GetVendorPaymentByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor payment ID", err)
+		return
+	}
+
+	payment, err := h.purchaseService.GetVendorPaymentByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Vendor payment not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Vendor payment retrieved successfully", payment)
+}
+
+// Purchase Debit Note Handlers
+type CreatePurchaseDebitNoteRequest struct {
+	DebitNoteDate    string                         `json:"debit_note_date" binding:"required"`
+	BillID           uint                           `json:"bill_id" binding:"required"`
+	Reason           string                         `json:"reason"`
+	Reference        string                         `json:"reference"`
+	TaxInvoiceNumber string                         `json:"tax_invoice_number"`
+	Notes            string                         `json:"notes"`
+	Items            []PurchaseDebitNoteItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type PurchaseDebitNoteItemRequest struct {
+	BillItemID    uint     `json:"bill_item_id" binding:"required"`
+	Description   string   `json:"description"`
+	Quantity      float64  `json:"quantity" binding:"gte=0"` // returned; 0 = price correction only
+	Amount        float64  `json:"amount" binding:"gte=0"`   // 0 = the billed price of the quantity
+	SerialNumbers []string `json:"serial_numbers"`
+}
+
+func (h *PurchaseHandler) CreatePurchaseDebitNote(c *gin.Context) {
+	var req CreatePurchaseDebitNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	debitNoteDate, err := time.Parse("2006-01-02", req.DebitNoteDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	items := make([]models.PurchaseDebitNoteItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.PurchaseDebitNoteItem{
+			BillItemID:    item.BillItemID,
+			Description:   item.Description,
+			Quantity:      item.Quantity,
+			Amount:        item.Amount,
+			SerialNumbers: item.SerialNumbers,
+		}
+	}
+
+	debitNote := &models.PurchaseDebitNote{
+		CompanyID:        companyID.(uint),
+		DebitNoteDate:    debitNoteDate,
+		BillID:           req.BillID,
+		Reason:           req.Reason,
+		Reference:        req.Reference,
+		TaxInvoiceNumber: req.TaxInvoiceNumber,
+		Notes:            req.Notes,
+		CreatedBy:        userID.(uint),
+		Items:            items,
+	}
+
+	if err := h.purchaseService.CreatePurchaseDebitNote(debitNote); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create debit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Debit note created successfully", debitNote)
+}
+
+func (h *PurchaseHandler) GetPurchaseDebitNotes(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	var billID uint64
+	if billIDStr := c.Query("bill_id"); billIDStr != "" {
+		billID, err = strconv.ParseUint(billIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid purchase bill ID", err)
+			return
+		}
+	}
+
+	debitNotes, err := h.purchaseService.GetPurchaseDebitNotes(companyID.(uint), vendorID, uint(billID), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve debit notes", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Debit notes retrieved successfully", debitNotes)
+}
+
+func (h *PurchaseHandler) GetPurchaseDebitNoteByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid debit note ID", err)
+		return
+	}
+
+	debitNote, err := h.purchaseService.GetPurchaseDebitNoteByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Debit note not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Debit note retrieved successfully", debitNote)
+}
+
+func (h *PurchaseHandler) PostPurchaseDebitNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid debit note ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.purchaseService.PostPurchaseDebitNote(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to post debit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Debit note posted successfully", nil)
+}
+
+func (h *PurchaseHandler) CancelPurchaseDebitNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid debit note ID", err)
+		return
+	}
+
+	if err := h.purchaseService.CancelPurchaseDebitNote(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel debit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Debit note cancelled successfully", nil)
+}
+
+type ApplyPurchaseDebitNoteRequest struct {
+	ApplicationDate string  `json:"application_date" binding:"required"`
+	BillID          uint    `json:"bill_id" binding:"required"`
+	Amount          float64 `json:"amount" binding:"required,gt=0"`
+}
+
+func (h *PurchaseHandler) ApplyPurchaseDebitNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid debit note ID", err)
+		return
+	}
+
+	var req ApplyPurchaseDebitNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	applicationDate, err := time.Parse("2006-01-02", req.ApplicationDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	application := &models.PurchaseDebitNoteApplication{
+		CompanyID:       companyID.(uint),
+		DebitNoteID:     uint(id),
+		ApplicationDate: applicationDate,
+		BillID:          &req.BillID,
+		Amount:          req.Amount,
+		CreatedBy:       userID.(uint),
+	}
+
+	if err := h.purchaseService.ApplyPurchaseDebitNote(application); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to apply debit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Debit note applied successfully", application)
+}
+
+type RefundPurchaseDebitNoteRequest struct {
+	RefundDate string  `json:"refund_date" binding:"required"`
+	AccountID  uint    `json:"account_id" binding:"required"` // cash or bank account
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	Reference  string  `json:"reference"`
+}
+
+func (h *PurchaseHandler) RefundPurchaseDebitNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid debit note ID", err)
+		return
+	}
+
+	var req RefundPurchaseDebitNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	refundDate, err := time.Parse("2006-01-02", req.RefundDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	application := &models.PurchaseDebitNoteApplication{
+		CompanyID:       companyID.(uint),
+		DebitNoteID:     uint(id),
+		ApplicationDate: refundDate,
+		AccountID:       &req.AccountID,
+		Amount:          req.Amount,
+		Reference:       req.Reference,
+		CreatedBy:       userID.(uint),
+	}
+
+	if err := h.purchaseService.RefundPurchaseDebitNote(application); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to refund debit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Debit note refunded successfully", application)
+}
+
+// Payment Run Handlers
+type CreatePaymentRunRequest struct {
+	PaymentDate string `json:"payment_date" binding:"required"`
+	DueBefore   string `json:"due_before"` // empty = payment date
+	AccountID   uint   `json:"account_id" binding:"required"`
+	VendorIDs   []uint `json:"vendor_ids"` // empty = all vendors
+	Notes       string `json:"notes"`
+}
+
+type UpdatePaymentRunLineRequest struct {
+	Amount *float64 `json:"amount" binding:"required"` // 0 = drop the bill from the run
+}
+
+func (h *PurchaseHandler) CreatePaymentRun(c *gin.Context) {
+	var req CreatePaymentRunRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	dueBefore, err := parseOptionalDate(req.DueBefore)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid due_before format", err)
+		return
+	}
+	if dueBefore == nil {
+		dueBefore = &paymentDate
+	}
+
+	run := &models.PaymentRun{
+		CompanyID:   companyID.(uint),
+		PaymentDate: paymentDate,
+		DueBefore:   *dueBefore,
+		AccountID:   req.AccountID,
+		Notes:       req.Notes,
+		CreatedBy:   userID.(uint),
+	}
+
+	if err := h.purchaseService.CreatePaymentRun(run, req.VendorIDs); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create payment run", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Payment run created successfully", run)
+}
+
+func (h *PurchaseHandler) GetPaymentRuns(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	runs, err := h.purchaseService.GetPaymentRuns(companyID.(uint), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve payment runs", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment runs retrieved successfully", runs)
+}
+
+func (h *PurchaseHandler) GetPaymentRunByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment run ID", err)
+		return
+	}
+
+	run, err := h.purchaseService.GetPaymentRunByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Payment run not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment run retrieved successfully", run)
+}
+
+func (h *PurchaseHandler) UpdatePaymentRunLine(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment run ID", err)
+		return
+	}
+
+	lineID, err := strconv.ParseUint(c.Param("line_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid line ID", err)
+		return
+	}
+
+	var req UpdatePaymentRunLineRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	if err := h.purchaseService.UpdatePaymentRunLine(uint(id), uint(lineID), *req.Amount); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update payment run line", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment run line updated successfully", nil)
+}
+
+func (h *PurchaseHandler) ProcessPaymentRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment run ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.purchaseService.ProcessPaymentRun(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to process payment run", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment run processed successfully", nil)
+}
+
+func (h *PurchaseHandler) CancelPaymentRun(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid payment run ID", err)
+		return
+	}
+
+	if err := h.purchaseService.CancelPaymentRun(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel payment run", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payment run cancelled successfully", nil)
+}
+
+// Payable Aging Handlers
+func (h *PurchaseHandler) GetPayableAging(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	vendorID, err := queryVendorID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid vendor ID", err)
+		return
+	}
+
+	asOfDate, limits, err := queryAgingParams(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid aging parameters", err)
+		return
+	}
+
+	report, err := h.purchaseService.GetPayableAging(companyID.(uint), vendorID, asOfDate, limits)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve payable aging", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Payable aging retrieved successfully", report)
+}
+
+// queryVendorID reads the optional vendor_id filter; 0 = all vendors
+func queryVendorID(c *gin.Context) (uint, error) {
+	vendorIDStr := c.Query("vendor_id")
+	if vendorIDStr == "" {
+		return 0, nil
+	}
+
+	vendorID, err := strconv.ParseUint(vendorIDStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(vendorID), nil
+}
\ No newline at end of file
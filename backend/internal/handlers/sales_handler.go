@@ -0,0 +1,721 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SalesHandler struct {
+	salesService services.SalesService
+}
+
+func NewSalesHandler(salesService services.SalesService) *SalesHandler {
+	return &SalesHandler{salesService: salesService}
+}
+
+// Customer Handlers
+type CustomerRequest struct {
+	Code            string  `json:"code" binding:"required"`
+	Name            string  `json:"name" binding:"required"`
+	NPWP            string  `json:"npwp"`
+	Address         string  `json:"address"`
+	Phone           string  `json:"phone"`
+	Email           string  `json:"email"`
+	ContactPerson   string  `json:"contact_person"`
+	PaymentTermDays *int    `json:"payment_term_days"` // omitted = 30 days
+	CreditLimit     float64 `json:"credit_limit"`
+	IsActive        *bool   `json:"is_active"` // omitted = active
+	Notes           string  `json:"notes"`
+	AccountOwnerID  *uint   `json:"account_owner_id"` // user notified of dunning reminders
+}
+
+func (req *CustomerRequest) toCustomer(companyID uint) *models.Customer {
+	customer := &models.Customer{
+		CompanyID:       companyID,
+		Code:            req.Code,
+		Name:            req.Name,
+		NPWP:            req.NPWP,
+		Address:         req.Address,
+		Phone:           req.Phone,
+		Email:           req.Email,
+		ContactPerson:   req.ContactPerson,
+		PaymentTermDays: 30,
+		CreditLimit:     req.CreditLimit,
+		IsActive:        true,
+		Notes:           req.Notes,
+		AccountOwnerID:  req.AccountOwnerID,
+	}
+	if req.PaymentTermDays != nil {
+		customer.PaymentTermDays = *req.PaymentTermDays
+	}
+	if req.IsActive != nil {
+		customer.IsActive = *req.IsActive
+	}
+	return customer
+}
+
+func (h *SalesHandler) CreateCustomer(c *gin.Context) {
+	var req CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	customer := req.toCustomer(companyID.(uint))
+	if err := h.salesService.CreateCustomer(customer); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create customer", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Customer created successfully", customer)
+}
+
+func (h *SalesHandler) GetCustomers(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customers, err := h.salesService.GetCustomersByCompany(companyID.(uint), c.Query("active") == "true")
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve customers", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Customers retrieved successfully", customers)
+}
+
+func (h *SalesHandler) GetCustomerByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	customer, err := h.salesService.GetCustomerByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Customer not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Customer retrieved successfully", customer)
+}
+
+func (h *SalesHandler) UpdateCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	var req CustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	if err := h.salesService.UpdateCustomer(uint(id), req.toCustomer(companyID.(uint))); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to update customer", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Customer updated successfully", nil)
+}
+
+func (h *SalesHandler) DeleteCustomer(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	if err := h.salesService.DeleteCustomer(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to delete customer", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Customer deleted successfully", nil)
+}
+
+// Sales Invoice Handlers
+type CreateSalesInvoiceRequest struct {
+	InvoiceDate      string                    `json:"invoice_date" binding:"required"`
+	DueDate          string                    `json:"due_date"` // empty = invoice date plus the customer's payment terms
+	CustomerID       uint                      `json:"customer_id" binding:"required"`
+	WarehouseID      uint                      `json:"warehouse_id"`
+	DiscountAmount   float64                   `json:"discount_amount"`
+	TaxRate          *float64                  `json:"tax_rate"` // omitted = standard PPN rate
+	TaxInvoiceNumber string                    `json:"tax_invoice_number"`
+	Reference        string                    `json:"reference"`
+	Notes            string                    `json:"notes"`
+	Items            []SalesInvoiceItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type SalesInvoiceItemRequest struct {
+	ProductID        uint     `json:"product_id" binding:"required"`
+	Description      string   `json:"description"`
+	Quantity         float64  `json:"quantity" binding:"required,gt=0"`
+	Unit             string   `json:"unit"`
+	UnitPrice        float64  `json:"unit_price" binding:"gte=0"`
+	DiscountPercent  float64  `json:"discount_percent"`
+	RevenueAccountID *uint    `json:"revenue_account_id"`
+	SerialNumbers    []string `json:"serial_numbers"`
+}
+
+func (h *SalesHandler) CreateSalesInvoice(c *gin.Context) {
+	var req CreateSalesInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	invoiceDate, err := time.Parse("2006-01-02", req.InvoiceDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	dueDate, err := parseOptionalDate(req.DueDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid due_date format", err)
+		return
+	}
+
+	items := make([]models.SalesInvoiceItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.SalesInvoiceItem{
+			ProductID:        item.ProductID,
+			Description:      item.Description,
+			Quantity:         item.Quantity,
+			Unit:             item.Unit,
+			UnitPrice:        item.UnitPrice,
+			DiscountPercent:  item.DiscountPercent,
+			RevenueAccountID: item.RevenueAccountID,
+			SerialNumbers:    item.SerialNumbers,
+		}
+	}
+
+	invoice := &models.SalesInvoice{
+		CompanyID:        companyID.(uint),
+		InvoiceDate:      invoiceDate,
+		CustomerID:       req.CustomerID,
+		WarehouseID:      req.WarehouseID,
+		DiscountAmount:   req.DiscountAmount,
+		TaxRate:          services.DefaultPPNRate,
+		TaxInvoiceNumber: req.TaxInvoiceNumber,
+		Reference:        req.Reference,
+		Notes:            req.Notes,
+		CreatedBy:        userID.(uint),
+		Items:            items,
+	}
+	if dueDate != nil {
+		invoice.DueDate = *dueDate
+	}
+	if req.TaxRate != nil {
+		invoice.TaxRate = *req.TaxRate
+	}
+
+	if err := h.salesService.CreateSalesInvoice(invoice); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create sales invoice", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Sales invoice created successfully", invoice)
+}
+
+func (h *SalesHandler) GetSalesInvoices(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	invoices, err := h.salesService.GetSalesInvoices(companyID.(uint), customerID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve sales invoices", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales invoices retrieved successfully", invoices)
+}
+
+func (h *SalesHandler) GetSalesInvoiceByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales invoice ID", err)
+		return
+	}
+
+	invoice, err := h.salesService.GetSalesInvoiceByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Sales invoice not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales invoice retrieved successfully", invoice)
+}
+
+func (h *SalesHandler) PostSalesInvoice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales invoice ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.salesService.PostSalesInvoice(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to post sales invoice", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales invoice posted successfully", nil)
+}
+
+func (h *SalesHandler) CancelSalesInvoice(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales invoice ID", err)
+		return
+	}
+
+	if err := h.salesService.CancelSalesInvoice(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel sales invoice", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales invoice cancelled successfully", nil)
+}
+
+// Customer Payment Handlers
+type CreateCustomerPaymentRequest struct {
+	PaymentDate string                     `json:"payment_date" binding:"required"`
+	CustomerID  uint                       `json:"customer_id" binding:"required"`
+	AccountID   uint                       `json:"account_id" binding:"required"`
+	Amount      float64                    `json:"amount" binding:"required,gt=0"`
+	Reference   string                     `json:"reference"`
+	Notes       string                     `json:"notes"`
+	Allocations []PaymentAllocationRequest `json:"allocations" binding:"dive"` // empty = oldest due invoices first
+}
+
+type PaymentAllocationRequest struct {
+	InvoiceID uint    `json:"invoice_id" binding:"required"`
+	Amount    float64 `json:"amount" binding:"required,gt=0"`
+}
+
+func (h *SalesHandler) CreateCustomerPayment(c *gin.Context) {
+	var req CreateCustomerPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	paymentDate, err := time.Parse("2006-01-02", req.PaymentDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	allocations := make([]models.CustomerPaymentAllocation, len(req.Allocations))
+	for i, allocation := range req.Allocations {
+		allocations[i] = models.CustomerPaymentAllocation{
+			InvoiceID: allocation.InvoiceID,
+			Amount:    allocation.Amount,
+		}
+	}
+
+	payment := &models.CustomerPayment{
+		CompanyID:   companyID.(uint),
+		PaymentDate: paymentDate,
+		CustomerID:  req.CustomerID,
+		AccountID:   req.AccountID,
+		Amount:      req.Amount,
+		Reference:   req.Reference,
+		Notes:       req.Notes,
+		CreatedBy:   userID.(uint),
+		Allocations: allocations,
+	}
+
+	if err := h.salesService.CreateCustomerPayment(payment); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to record customer payment", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Customer payment recorded successfully", payment)
+}
+
+func (h *SalesHandler) GetCustomerPayments(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	payments, err := h.salesService.GetCustomerPayments(companyID.(uint), customerID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve customer payments", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Customer payments retrieved successfully", payments)
+}
+
+func (h *SalesHandler) GetCustomerPaymentByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer payment ID", err)
+		return
+	}
+
+	payment, err := h.salesService.GetCustomerPaymentByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Customer payment not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Customer payment retrieved successfully", payment)
+}
+
+// Sales Credit Note Handlers
+type CreateSalesCreditNoteRequest struct {
+	CreditNoteDate   string                       `json:"credit_note_date" binding:"required"`
+	InvoiceID        uint                         `json:"invoice_id" binding:"required"`
+	WarehouseID      uint                         `json:"warehouse_id"` // returned goods; 0 = the warehouse they were issued from
+	Reason           string                       `json:"reason"`
+	TaxInvoiceNumber string                       `json:"tax_invoice_number"`
+	Notes            string                       `json:"notes"`
+	Items            []SalesCreditNoteItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type SalesCreditNoteItemRequest struct {
+	InvoiceItemID uint     `json:"invoice_item_id" binding:"required"`
+	Description   string   `json:"description"`
+	Quantity      float64  `json:"quantity" binding:"gte=0"` // returned; 0 = price correction only
+	Amount        float64  `json:"amount" binding:"gte=0"`   // 0 = the invoiced net price of the quantity
+	SerialNumbers []string `json:"serial_numbers"`
+}
+
+func (h *SalesHandler) CreateSalesCreditNote(c *gin.Context) {
+	var req CreateSalesCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	creditNoteDate, err := time.Parse("2006-01-02", req.CreditNoteDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	items := make([]models.SalesCreditNoteItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.SalesCreditNoteItem{
+			InvoiceItemID: item.InvoiceItemID,
+			Description:   item.Description,
+			Quantity:      item.Quantity,
+			Amount:        item.Amount,
+			SerialNumbers: item.SerialNumbers,
+		}
+	}
+
+	creditNote := &models.SalesCreditNote{
+		CompanyID:        companyID.(uint),
+		CreditNoteDate:   creditNoteDate,
+		InvoiceID:        req.InvoiceID,
+		WarehouseID:      req.WarehouseID,
+		Reason:           req.Reason,
+		TaxInvoiceNumber: req.TaxInvoiceNumber,
+		Notes:            req.Notes,
+		CreatedBy:        userID.(uint),
+		Items:            items,
+	}
+
+	if err := h.salesService.CreateSalesCreditNote(creditNote); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create credit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Credit note created successfully", creditNote)
+}
+
+func (h *SalesHandler) GetSalesCreditNotes(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	var invoiceID uint64
+	if invoiceIDStr := c.Query("invoice_id"); invoiceIDStr != "" {
+		invoiceID, err = strconv.ParseUint(invoiceIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales invoice ID", err)
+			return
+		}
+	}
+
+	creditNotes, err := h.salesService.GetSalesCreditNotes(companyID.(uint), customerID, uint(invoiceID), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve credit notes", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Credit notes retrieved successfully", creditNotes)
+}
+
+func (h *SalesHandler) GetSalesCreditNoteByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid credit note ID", err)
+		return
+	}
+
+	creditNote, err := h.salesService.GetSalesCreditNoteByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Credit note not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Credit note retrieved successfully", creditNote)
+}
+
+func (h *SalesHandler) PostSalesCreditNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid credit note ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.salesService.PostSalesCreditNote(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to post credit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Credit note posted successfully", nil)
+}
+
+func (h *SalesHandler) CancelSalesCreditNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid credit note ID", err)
+		return
+	}
+
+	if err := h.salesService.CancelSalesCreditNote(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel credit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Credit note cancelled successfully", nil)
+}
+
+type ApplySalesCreditNoteRequest struct {
+	ApplicationDate string  `json:"application_date" binding:"required"`
+	InvoiceID       uint    `json:"invoice_id" binding:"required"`
+	Amount          float64 `json:"amount" binding:"required,gt=0"`
+}
+
+func (h *SalesHandler) ApplySalesCreditNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid credit note ID", err)
+		return
+	}
+
+	var req ApplySalesCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	applicationDate, err := time.Parse("2006-01-02", req.ApplicationDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	application := &models.SalesCreditNoteApplication{
+		CompanyID:       companyID.(uint),
+		CreditNoteID:    uint(id),
+		ApplicationDate: applicationDate,
+		InvoiceID:       &req.InvoiceID,
+		Amount:          req.Amount,
+		CreatedBy:       userID.(uint),
+	}
+
+	if err := h.salesService.ApplySalesCreditNote(application); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to apply credit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Credit note applied successfully", application)
+}
+
+type RefundSalesCreditNoteRequest struct {
+	RefundDate string  `json:"refund_date" binding:"required"`
+	AccountID  uint    `json:"account_id" binding:"required"` // cash or bank account
+	Amount     float64 `json:"amount" binding:"required,gt=0"`
+	Reference  string  `json:"reference"`
+}
+
+func (h *SalesHandler) RefundSalesCreditNote(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid credit note ID", err)
+		return
+	}
+
+	var req RefundSalesCreditNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	refundDate, err := time.Parse("2006-01-02", req.RefundDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	application := &models.SalesCreditNoteApplication{
+		CompanyID:       companyID.(uint),
+		CreditNoteID:    uint(id),
+		ApplicationDate: refundDate,
+		AccountID:       &req.AccountID,
+		Amount:          req.Amount,
+		Reference:       req.Reference,
+		CreatedBy:       userID.(uint),
+	}
+
+	if err := h.salesService.RefundSalesCreditNote(application); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to refund credit note", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Credit note refunded successfully", application)
+}
+
+// Customer Statement Handlers
+func (h *SalesHandler) GetCustomerStatement(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("customer_id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	startDate, endDate, err := queryPeriod(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	statement, err := h.salesService.GetCustomerStatement(uint(customerID), startDate, endDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to retrieve customer statement", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Customer statement retrieved successfully", statement)
+}
+
+// Receivable Aging Handlers
+func (h *SalesHandler) GetReceivableAging(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	asOfDate, limits, err := queryAgingParams(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid aging parameters", err)
+		return
+	}
+
+	report, err := h.salesService.GetReceivableAging(companyID.(uint), customerID, asOfDate, limits)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve receivable aging", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Receivable aging retrieved successfully", report)
+}
+
+// queryAgingParams reads as_of_date (default today) and buckets, the
+// comma-separated upper bounds in days overdue (default 30,60,90)
+func queryAgingParams(c *gin.Context) (time.Time, []int, error) {
+	asOfDateStr := c.Query("as_of_date")
+	if asOfDateStr == "" {
+		asOfDateStr = time.Now().Format("2006-01-02")
+	}
+
+	asOfDate, err := time.Parse("2006-01-02", asOfDateStr)
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+
+	limits := services.DefaultAgingLimits
+	if bucketsStr := c.Query("buckets"); bucketsStr != "" {
+		limits = nil
+		for _, part := range strings.Split(bucketsStr, ",") {
+			limit, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return time.Time{}, nil, err
+			}
+			limits = append(limits, limit)
+		}
+	}
+	if _, err := services.AgingBuckets(limits); err != nil {
+		return time.Time{}, nil, err
+	}
+
+	return asOfDate, limits, nil
+}
+
+// queryCustomerID reads the optional customer_id filter; 0 = all customers
+func queryCustomerID(c *gin.Context) (uint, error) {
+	customerIDStr := c.Query("customer_id")
+	if customerIDStr == "" {
+		return 0, nil
+	}
+
+	customerID, err := strconv.ParseUint(customerIDStr, 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	return uint(customerID), nil
+}
\ No newline at end of file
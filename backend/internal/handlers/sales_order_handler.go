@@ -0,0 +1,560 @@
+package handlers
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"finara-backend/internal/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SalesOrderHandler struct {
+	salesOrderService services.SalesOrderService
+}
+
+func NewSalesOrderHandler(salesOrderService services.SalesOrderService) *SalesOrderHandler {
+	return &SalesOrderHandler{salesOrderService: salesOrderService}
+}
+
+// Sales Quotation Handlers
+type CreateSalesQuotationRequest struct {
+	QuotationDate string                  `json:"quotation_date" binding:"required"`
+	ValidUntil    string                  `json:"valid_until"`
+	CustomerID    uint                    `json:"customer_id" binding:"required"`
+	TaxRate       *float64                `json:"tax_rate"` // omitted = standard PPN rate
+	Reference     string                  `json:"reference"`
+	Notes         string                  `json:"notes"`
+	Items         []SalesOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// SalesOrderItemRequest is a quotation or sales order line
+type SalesOrderItemRequest struct {
+	ProductID       uint    `json:"product_id" binding:"required"`
+	Description     string  `json:"description"`
+	Quantity        float64 `json:"quantity" binding:"required,gt=0"`
+	Unit            string  `json:"unit"`
+	UnitPrice       float64 `json:"unit_price" binding:"gte=0"`
+	DiscountPercent float64 `json:"discount_percent"`
+}
+
+func (h *SalesOrderHandler) CreateSalesQuotation(c *gin.Context) {
+	var req CreateSalesQuotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	quotationDate, err := time.Parse("2006-01-02", req.QuotationDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	validUntil, err := parseOptionalDate(req.ValidUntil)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid valid_until format", err)
+		return
+	}
+
+	items := make([]models.SalesQuotationItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.SalesQuotationItem{
+			ProductID:       item.ProductID,
+			Description:     item.Description,
+			Quantity:        item.Quantity,
+			Unit:            item.Unit,
+			UnitPrice:       item.UnitPrice,
+			DiscountPercent: item.DiscountPercent,
+		}
+	}
+
+	quotation := &models.SalesQuotation{
+		CompanyID:     companyID.(uint),
+		QuotationDate: quotationDate,
+		ValidUntil:    validUntil,
+		CustomerID:    req.CustomerID,
+		TaxRate:       services.DefaultPPNRate,
+		Reference:     req.Reference,
+		Notes:         req.Notes,
+		CreatedBy:     userID.(uint),
+		Items:         items,
+	}
+	if req.TaxRate != nil {
+		quotation.TaxRate = *req.TaxRate
+	}
+
+	if err := h.salesOrderService.CreateSalesQuotation(quotation); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create sales quotation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Sales quotation created successfully", quotation)
+}
+
+func (h *SalesOrderHandler) GetSalesQuotations(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	quotations, err := h.salesOrderService.GetSalesQuotations(companyID.(uint), customerID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve sales quotations", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales quotations retrieved successfully", quotations)
+}
+
+func (h *SalesOrderHandler) GetSalesQuotationByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales quotation ID", err)
+		return
+	}
+
+	quotation, err := h.salesOrderService.GetSalesQuotationByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Sales quotation not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales quotation retrieved successfully", quotation)
+}
+
+func (h *SalesOrderHandler) SendSalesQuotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales quotation ID", err)
+		return
+	}
+
+	if err := h.salesOrderService.SendSalesQuotation(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to send sales quotation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales quotation marked as sent", nil)
+}
+
+func (h *SalesOrderHandler) AcceptSalesQuotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales quotation ID", err)
+		return
+	}
+
+	if err := h.salesOrderService.AcceptSalesQuotation(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to accept sales quotation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales quotation accepted successfully", nil)
+}
+
+func (h *SalesOrderHandler) RejectSalesQuotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales quotation ID", err)
+		return
+	}
+
+	if err := h.salesOrderService.RejectSalesQuotation(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to reject sales quotation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales quotation rejected successfully", nil)
+}
+
+func (h *SalesOrderHandler) CancelSalesQuotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales quotation ID", err)
+		return
+	}
+
+	if err := h.salesOrderService.CancelSalesQuotation(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel sales quotation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales quotation cancelled successfully", nil)
+}
+
+func (h *SalesOrderHandler) ConvertSalesQuotation(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales quotation ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	orderDateStr := c.Query("order_date")
+	if orderDateStr == "" {
+		orderDateStr = time.Now().Format("2006-01-02")
+	}
+	orderDate, err := time.Parse("2006-01-02", orderDateStr)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	order, err := h.salesOrderService.ConvertSalesQuotation(uint(id), orderDate, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to convert sales quotation", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Sales order created from quotation", order)
+}
+
+// Sales Order Handlers
+type CreateSalesOrderRequest struct {
+	OrderDate    string                  `json:"order_date" binding:"required"`
+	ExpectedDate string                  `json:"expected_date"`
+	CustomerID   uint                    `json:"customer_id" binding:"required"`
+	WarehouseID  uint                    `json:"warehouse_id"`
+	TaxRate      *float64                `json:"tax_rate"` // omitted = standard PPN rate
+	Reference    string                  `json:"reference"`
+	Notes        string                  `json:"notes"`
+	Items        []SalesOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+func (h *SalesOrderHandler) CreateSalesOrder(c *gin.Context) {
+	var req CreateSalesOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	orderDate, err := time.Parse("2006-01-02", req.OrderDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	expectedDate, err := parseOptionalDate(req.ExpectedDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid expected_date format", err)
+		return
+	}
+
+	items := make([]models.SalesOrderItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.SalesOrderItem{
+			ProductID:       item.ProductID,
+			Description:     item.Description,
+			Quantity:        item.Quantity,
+			Unit:            item.Unit,
+			UnitPrice:       item.UnitPrice,
+			DiscountPercent: item.DiscountPercent,
+		}
+	}
+
+	order := &models.SalesOrder{
+		CompanyID:    companyID.(uint),
+		OrderDate:    orderDate,
+		ExpectedDate: expectedDate,
+		CustomerID:   req.CustomerID,
+		WarehouseID:  req.WarehouseID,
+		TaxRate:      services.DefaultPPNRate,
+		Reference:    req.Reference,
+		Notes:        req.Notes,
+		CreatedBy:    userID.(uint),
+		Items:        items,
+	}
+	if req.TaxRate != nil {
+		order.TaxRate = *req.TaxRate
+	}
+
+	if err := h.salesOrderService.CreateSalesOrder(order); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create sales order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Sales order created successfully", order)
+}
+
+func (h *SalesOrderHandler) GetSalesOrders(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	orders, err := h.salesOrderService.GetSalesOrders(companyID.(uint), customerID, c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve sales orders", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales orders retrieved successfully", orders)
+}
+
+func (h *SalesOrderHandler) GetSalesOrderByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales order ID", err)
+		return
+	}
+
+	order, err := h.salesOrderService.GetSalesOrderByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Sales order not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales order retrieved successfully", order)
+}
+
+func (h *SalesOrderHandler) ConfirmSalesOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales order ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.salesOrderService.ConfirmSalesOrder(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to confirm sales order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales order confirmed successfully", nil)
+}
+
+func (h *SalesOrderHandler) CloseSalesOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales order ID", err)
+		return
+	}
+
+	if err := h.salesOrderService.CloseSalesOrder(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to close sales order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales order closed successfully", nil)
+}
+
+func (h *SalesOrderHandler) CancelSalesOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales order ID", err)
+		return
+	}
+
+	if err := h.salesOrderService.CancelSalesOrder(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel sales order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Sales order cancelled successfully", nil)
+}
+
+// Delivery Order Handlers
+type CreateDeliveryOrderRequest struct {
+	DeliveryDate string                     `json:"delivery_date" binding:"required"`
+	SalesOrderID uint                       `json:"sales_order_id" binding:"required"`
+	WarehouseID  uint                       `json:"warehouse_id"` // 0 = the sales order's warehouse
+	ShipTo       string                     `json:"ship_to"`      // empty = the customer's address
+	Reference    string                     `json:"reference"`
+	Notes        string                     `json:"notes"`
+	Items        []DeliveryOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type DeliveryOrderItemRequest struct {
+	SalesOrderItemID uint     `json:"sales_order_item_id" binding:"required"`
+	Description      string   `json:"description"`
+	Quantity         float64  `json:"quantity" binding:"required,gt=0"`
+	SerialNumbers    []string `json:"serial_numbers"`
+}
+
+func (h *SalesOrderHandler) CreateDeliveryOrder(c *gin.Context) {
+	var req CreateDeliveryOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	deliveryDate, err := time.Parse("2006-01-02", req.DeliveryDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	items := make([]models.DeliveryOrderItem, len(req.Items))
+	for i, item := range req.Items {
+		items[i] = models.DeliveryOrderItem{
+			SalesOrderItemID: item.SalesOrderItemID,
+			Description:      item.Description,
+			Quantity:         item.Quantity,
+			SerialNumbers:    item.SerialNumbers,
+		}
+	}
+
+	delivery := &models.DeliveryOrder{
+		CompanyID:    companyID.(uint),
+		DeliveryDate: deliveryDate,
+		SalesOrderID: req.SalesOrderID,
+		WarehouseID:  req.WarehouseID,
+		ShipTo:       req.ShipTo,
+		Reference:    req.Reference,
+		Notes:        req.Notes,
+		CreatedBy:    userID.(uint),
+		Items:        items,
+	}
+
+	if err := h.salesOrderService.CreateDeliveryOrder(delivery); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create delivery order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Delivery order created successfully", delivery)
+}
+
+func (h *SalesOrderHandler) GetDeliveryOrders(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	var salesOrderID uint64
+	if salesOrderIDStr := c.Query("sales_order_id"); salesOrderIDStr != "" {
+		salesOrderID, err = strconv.ParseUint(salesOrderIDStr, 10, 32)
+		if err != nil {
+			utils.ErrorResponse(c, http.StatusBadRequest, "Invalid sales order ID", err)
+			return
+		}
+	}
+
+	deliveries, err := h.salesOrderService.GetDeliveryOrders(companyID.(uint), customerID, uint(salesOrderID), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve delivery orders", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery orders retrieved successfully", deliveries)
+}
+
+func (h *SalesOrderHandler) GetDeliveryOrderByID(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery order ID", err)
+		return
+	}
+
+	delivery, err := h.salesOrderService.GetDeliveryOrderByID(uint(id))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusNotFound, "Delivery order not found", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery order retrieved successfully", delivery)
+}
+
+func (h *SalesOrderHandler) PostDeliveryOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery order ID", err)
+		return
+	}
+
+	userID, _ := c.Get("user_id")
+
+	if err := h.salesOrderService.PostDeliveryOrder(uint(id), userID.(uint)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to post delivery order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery order posted successfully", nil)
+}
+
+func (h *SalesOrderHandler) CancelDeliveryOrder(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid delivery order ID", err)
+		return
+	}
+
+	if err := h.salesOrderService.CancelDeliveryOrder(uint(id)); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to cancel delivery order", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Delivery order cancelled successfully", nil)
+}
+
+// Invoicing Handlers
+type CreateInvoiceFromDeliveriesRequest struct {
+	InvoiceDate string `json:"invoice_date" binding:"required"`
+	DeliveryIDs []uint `json:"delivery_ids" binding:"required,min=1"`
+}
+
+func (h *SalesOrderHandler) CreateSalesInvoiceFromDeliveries(c *gin.Context) {
+	var req CreateInvoiceFromDeliveriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+	userID, _ := c.Get("user_id")
+
+	invoiceDate, err := time.Parse("2006-01-02", req.InvoiceDate)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid date format", err)
+		return
+	}
+
+	invoice, err := h.salesOrderService.CreateSalesInvoiceFromDeliveries(companyID.(uint), req.DeliveryIDs, invoiceDate, userID.(uint))
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to create sales invoice", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Sales invoice created from delivery orders", invoice)
+}
+
+// Sales Order Report Handlers
+func (h *SalesOrderHandler) GetBackorderReport(c *gin.Context) {
+	companyID, _ := c.Get("company_id")
+
+	customerID, err := queryCustomerID(c)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid customer ID", err)
+		return
+	}
+
+	report, err := h.salesOrderService.GetBackorderReport(companyID.(uint), customerID)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve backorder report", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Backorder report retrieved successfully", report)
+}
\ No newline at end of file
@@ -235,4 +235,26 @@ func (h *TaxHandler) GetTaxSummary(c *gin.Context) {
 	}
 
 	utils.SuccessResponse(c, http.StatusOK, "Tax summary retrieved successfully", summary)
+}
+
+type CalculatePPh21Request struct {
+	Period string `json:"period" binding:"required"` // YYYY-MM
+}
+
+func (h *TaxHandler) CalculatePPh21(c *gin.Context) {
+	var req CalculatePPh21Request
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request", err)
+		return
+	}
+
+	companyID, _ := c.Get("company_id")
+
+	tax, err := h.taxService.CalculatePPh21(companyID.(uint), req.Period)
+	if err != nil {
+		utils.ErrorResponse(c, http.StatusBadRequest, "Failed to calculate PPh 21", err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "PPh 21 calculated successfully", tax)
 }
\ No newline at end of file
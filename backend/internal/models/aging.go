@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Open item: a posted invoice or bill with what was still unpaid at the
+// report date. Filled by the sales and purchase repositories.
+type AgingOpenItem struct {
+	DocumentID     uint
+	DocumentType   string
+	DocumentNumber string
+	DocumentDate   time.Time
+	DueDate        time.Time
+	PartyID        uint
+	PartyCode      string
+	PartyName      string
+	TotalAmount    float64
+	Outstanding    float64
+}
+
+// AR / AP Aging
+type AgingBucket struct {
+	Label    string `json:"label"`
+	FromDays int    `json:"from_days"` // days overdue, inclusive
+	ToDays   int    `json:"to_days"`   // inclusive, -1 = open-ended
+}
+
+type AgingDocument struct {
+	DocumentID     uint    `json:"document_id"`
+	DocumentType   string  `json:"document_type"`
+	DocumentNumber string  `json:"document_number"`
+	DocumentDate   string  `json:"document_date"`
+	DueDate        string  `json:"due_date"`
+	DaysOverdue    int     `json:"days_overdue"`
+	TotalAmount    float64 `json:"total_amount"`
+	Outstanding    float64 `json:"outstanding"`
+	Bucket         int     `json:"bucket"` // index into the report buckets
+}
+
+type AgingPartyLine struct {
+	PartyID   uint            `json:"party_id"`
+	PartyCode string          `json:"party_code"`
+	PartyName string          `json:"party_name"`
+	Amounts   []float64       `json:"amounts"` // per bucket
+	Total     float64         `json:"total"`
+	Documents []AgingDocument `json:"documents"`
+}
+
+type AgingReport struct {
+	Type               string           `json:"type"` // receivable, payable
+	AsOfDate           string           `json:"as_of_date"`
+	Buckets            []AgingBucket    `json:"buckets"`
+	Lines              []AgingPartyLine `json:"lines"`
+	BucketTotals       []float64        `json:"bucket_totals"`
+	Total              float64          `json:"total"`
+	ControlAccountCode string           `json:"control_account_code"`
+	ControlAccountName string           `json:"control_account_name"`
+	GLBalance          float64          `json:"gl_balance"`
+	Difference         float64          `json:"difference"`    // subledger total less GL balance
+	IsReconciled       bool             `json:"is_reconciled"` // only checked on the unfiltered report
+}
\ No newline at end of file
@@ -6,17 +6,26 @@ type TransactionType string
 type TransactionCategory string
 
 const (
-	TransactionTypeIn  TransactionType = "in"
-	TransactionTypeOut TransactionType = "out"
+	TransactionTypeIn       TransactionType = "in"
+	TransactionTypeOut      TransactionType = "out"
 	TransactionTypeTransfer TransactionType = "transfer"
 
-	CategoryCashSales     TransactionCategory = "cash_sales"
-	CategoryCashPurchase  TransactionCategory = "cash_purchase"
-	CategoryExpense       TransactionCategory = "expense"
-	CategoryWithdrawal    TransactionCategory = "withdrawal"
-	CategoryDeposit       TransactionCategory = "deposit"
-	CategoryTransfer      TransactionCategory = "transfer"
-	CategoryOther         TransactionCategory = "other"
+	CategoryCashSales         TransactionCategory = "cash_sales"
+	CategoryCashPurchase      TransactionCategory = "cash_purchase"
+	CategoryExpense           TransactionCategory = "expense"
+	CategoryWithdrawal        TransactionCategory = "withdrawal"
+	CategoryDeposit           TransactionCategory = "deposit"
+	CategoryTransfer          TransactionCategory = "transfer"
+	CategoryOther             TransactionCategory = "other"
+	CategoryReceivablePayment TransactionCategory = "receivable_payment"
+	CategoryPayablePayment    TransactionCategory = "payable_payment"
+	CategorySalaryPayment     TransactionCategory = "salary_payment"
+	CategoryCashAdvance       TransactionCategory = "cash_advance"
+	CategoryExpenseClaim      TransactionCategory = "expense_claim"
+	CategoryLoanDisbursement  TransactionCategory = "loan_disbursement"
+	CategoryLoanInstallment   TransactionCategory = "loan_installment"
+	CategoryCustomerRefund    TransactionCategory = "customer_refund"
+	CategoryVendorRefund      TransactionCategory = "vendor_refund"
 )
 
 type CashBankTransaction struct {
@@ -0,0 +1,127 @@
+package models
+
+import "time"
+
+type DeferralType string
+
+const (
+	DeferralTypePrepaidExpense  DeferralType = "prepaid_expense"  // biaya dibayar di muka
+	DeferralTypeDeferredRevenue DeferralType = "deferred_revenue" // pendapatan diterima di muka
+)
+
+type DeferralMethod string
+
+const (
+	DeferralMethodDaily   DeferralMethod = "daily"   // by the days of each month in the term
+	DeferralMethodMonthly DeferralMethod = "monthly" // equal amounts for each month in the term
+)
+
+type DeferralStatus string
+
+const (
+	DeferralStatusActive    DeferralStatus = "active"
+	DeferralStatusCompleted DeferralStatus = "completed"
+)
+
+// Deferral Schedule spreads an amount paid or received in advance over its
+// term. The amount is booked to the balance sheet account when it is paid or
+// invoiced; the runs move it to the P&L account month by month.
+type DeferralSchedule struct {
+	BaseModel
+	CompanyID             uint                   `gorm:"not null;index" json:"company_id"`
+	Company               Company                `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	ScheduleNumber        string                 `gorm:"uniqueIndex;size:50;not null" json:"schedule_number"`
+	Type                  DeferralType           `gorm:"type:varchar(20);not null;index" json:"type"`
+	Description           string                 `gorm:"size:255;not null" json:"description"`
+	Reference             string                 `gorm:"size:100" json:"reference"`                      // policy, license or invoice number
+	BalanceSheetAccountID uint                   `gorm:"not null;index" json:"balance_sheet_account_id"` // 1-1800 or 2-1900
+	BalanceSheetAccount   Account                `gorm:"foreignKey:BalanceSheetAccountID" json:"balance_sheet_account,omitempty"`
+	PLAccountID           uint                   `gorm:"not null" json:"pl_account_id"` // expense or revenue recognized
+	PLAccount             Account                `gorm:"foreignKey:PLAccountID" json:"pl_account,omitempty"`
+	Amount                float64                `gorm:"type:decimal(20,2);not null" json:"amount"`
+	StartDate             time.Time              `gorm:"not null;index" json:"start_date"`
+	EndDate               time.Time              `gorm:"not null" json:"end_date"`
+	Method                DeferralMethod         `gorm:"type:varchar(20);not null" json:"method"`
+	AmortizedAmount       float64                `gorm:"type:decimal(20,2);default:0" json:"amortized_amount"`
+	AmortizedThrough      *time.Time             `json:"amortized_through"` // last month-end posted, nil = not yet
+	Status                DeferralStatus         `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	Notes                 string                 `gorm:"type:text" json:"notes"`
+	CreatedBy             uint                   `gorm:"not null" json:"created_by"`
+	User                  User                   `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Lines                 []DeferralScheduleLine `gorm:"foreignKey:ScheduleID" json:"lines,omitempty"`
+}
+
+// DeferralScheduleLine is one month of a schedule's amortization
+type DeferralScheduleLine struct {
+	BaseModel
+	ScheduleID  uint      `gorm:"not null;index" json:"schedule_id"`
+	Period      string    `json:"period"` // YYYY-MM
+	PeriodEnd   time.Time `json:"period_end"`
+	Days        int       `json:"days"` // days of the term falling in the month
+	Amount      float64   `gorm:"type:decimal(20,2);default:0" json:"amount"`
+	Accumulated float64   `gorm:"type:decimal(20,2);default:0" json:"accumulated"`
+	Remaining   float64   `gorm:"type:decimal(20,2);default:0" json:"remaining"`
+	IsPosted    bool      `gorm:"default:false" json:"is_posted"`
+	RunID       *uint     `gorm:"index" json:"run_id"`
+}
+
+// Deferral Run posts one month's amortization of all active schedules in a
+// single journal
+type DeferralRun struct {
+	BaseModel
+	CompanyID            uint              `gorm:"not null;uniqueIndex:idx_deferral_run_period" json:"company_id"`
+	Company              Company           `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Period               string            `gorm:"size:7;not null;uniqueIndex:idx_deferral_run_period" json:"period"` // YYYY-MM
+	PeriodEnd            time.Time         `gorm:"not null" json:"period_end"`
+	TotalPrepaidExpense  float64           `gorm:"type:decimal(20,2);default:0" json:"total_prepaid_expense"`
+	TotalDeferredRevenue float64           `gorm:"type:decimal(20,2);default:0" json:"total_deferred_revenue"`
+	JournalID            *uint             `gorm:"index" json:"journal_id"`
+	Journal              *Journal          `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
+	CreatedBy            uint              `gorm:"not null" json:"created_by"`
+	User                 User              `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Lines                []DeferralRunLine `gorm:"foreignKey:RunID" json:"lines,omitempty"`
+}
+
+// DeferralRunLine is a schedule's amortization in a run; a schedule the
+// previous runs missed catches up on every month since
+type DeferralRunLine struct {
+	BaseModel
+	RunID      uint             `gorm:"not null;index" json:"run_id"`
+	ScheduleID uint             `gorm:"not null;index" json:"schedule_id"`
+	Schedule   DeferralSchedule `gorm:"foreignKey:ScheduleID" json:"schedule,omitempty"`
+	Months     int              `gorm:"not null" json:"months"`
+	Amount     float64          `gorm:"type:decimal(20,2);default:0" json:"amount"`
+	Remaining  float64          `gorm:"type:decimal(20,2);default:0" json:"remaining"`
+}
+
+// DeferralRollForwardLine moves a schedule's deferred balance from the
+// start to the end of the report period
+type DeferralRollForwardLine struct {
+	ScheduleID     uint      `json:"schedule_id"`
+	ScheduleNumber string    `json:"schedule_number"`
+	Description    string    `json:"description"`
+	StartDate      time.Time `json:"start_date"`
+	EndDate        time.Time `json:"end_date"`
+	OpeningBalance float64   `json:"opening_balance"`
+	Additions      float64   `json:"additions"`
+	Amortization   float64   `json:"amortization"`
+	ClosingBalance float64   `json:"closing_balance"`
+}
+
+type DeferralRollForwardGroup struct {
+	Type           DeferralType              `json:"type"`
+	AccountID      uint                      `json:"account_id"`
+	AccountCode    string                    `json:"account_code"`
+	AccountName    string                    `json:"account_name"`
+	Lines          []DeferralRollForwardLine `json:"lines"`
+	OpeningBalance float64                   `json:"opening_balance"`
+	Additions      float64                   `json:"additions"`
+	Amortization   float64                   `json:"amortization"`
+	ClosingBalance float64                   `json:"closing_balance"`
+}
+
+type DeferralRollForwardReport struct {
+	StartDate time.Time                  `json:"start_date"`
+	EndDate   time.Time                  `json:"end_date"`
+	Groups    []DeferralRollForwardGroup `json:"groups"`
+}
\ No newline at end of file
@@ -0,0 +1,57 @@
+package models
+
+import "time"
+
+// Dunning level: reminder sent once an invoice is this many days overdue.
+// Levels escalate, a higher level has a later threshold.
+type DunningLevel struct {
+	BaseModel
+	CompanyID   uint    `gorm:"not null;uniqueIndex:idx_company_dunning_level" json:"company_id"`
+	Company     Company `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Level       int     `gorm:"not null;uniqueIndex:idx_company_dunning_level" json:"level"`
+	DaysOverdue int     `gorm:"not null" json:"days_overdue"`
+	Name        string  `gorm:"size:100;not null" json:"name"`
+	Subject     string  `gorm:"size:255" json:"subject"`
+	Message     string  `gorm:"type:text" json:"message"` // opening paragraph of the reminder
+}
+
+type DunningEmailStatus string
+
+const (
+	DunningEmailStatusSent    DunningEmailStatus = "sent"
+	DunningEmailStatusFailed  DunningEmailStatus = "failed"
+	DunningEmailStatusSkipped DunningEmailStatus = "skipped" // customer has no email address
+)
+
+// Dunning Reminder: the reminder document sent to a customer for its
+// overdue invoices
+type DunningReminder struct {
+	BaseModel
+	CompanyID      uint                  `gorm:"not null;index" json:"company_id"`
+	Company        Company               `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	ReminderNumber string                `gorm:"uniqueIndex;size:50;not null" json:"reminder_number"`
+	ReminderDate   time.Time             `gorm:"not null;index" json:"reminder_date"`
+	CustomerID     uint                  `gorm:"not null;index" json:"customer_id"`
+	Customer       Customer              `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Level          int                   `gorm:"not null" json:"level"`
+	LevelName      string                `gorm:"size:100" json:"level_name"`
+	TotalOverdue   float64               `gorm:"type:decimal(20,2);default:0" json:"total_overdue"`
+	Subject        string                `gorm:"size:255" json:"subject"`
+	Body           string                `gorm:"type:text" json:"body"`
+	EmailTo        string                `gorm:"size:100" json:"email_to"`
+	EmailStatus    DunningEmailStatus    `gorm:"type:varchar(20)" json:"email_status"`
+	EmailError     string                `gorm:"type:text" json:"email_error"`
+	SentAt         *time.Time            `json:"sent_at"`
+	Items          []DunningReminderItem `gorm:"foreignKey:ReminderID" json:"items,omitempty"`
+}
+
+type DunningReminderItem struct {
+	BaseModel
+	ReminderID  uint         `gorm:"not null;index" json:"reminder_id"`
+	InvoiceID   uint         `gorm:"not null;index" json:"invoice_id"`
+	Invoice     SalesInvoice `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	DueDate     time.Time    `json:"due_date"`
+	DaysOverdue int          `json:"days_overdue"`
+	Level       int          `json:"level"` // level reached by this invoice
+	Outstanding float64      `gorm:"type:decimal(20,2);default:0" json:"outstanding"`
+}
\ No newline at end of file
@@ -0,0 +1,129 @@
+package models
+
+import "time"
+
+type CashAdvanceStatus string
+
+const (
+	CashAdvanceStatusDraft     CashAdvanceStatus = "draft"
+	CashAdvanceStatusApproved  CashAdvanceStatus = "approved"
+	CashAdvanceStatusRejected  CashAdvanceStatus = "rejected"
+	CashAdvanceStatusDisbursed CashAdvanceStatus = "disbursed" // paid out, waiting for the expense claim
+	CashAdvanceStatusSettled   CashAdvanceStatus = "settled"
+	CashAdvanceStatusCancelled CashAdvanceStatus = "cancelled"
+)
+
+// Cash Advance (kasbon) an employee takes for a trip or purchase. Once
+// approved it is paid out to the employee advances account and is settled
+// by an expense claim for what was spent.
+type CashAdvance struct {
+	BaseModel
+	CompanyID             uint              `gorm:"not null;index" json:"company_id"`
+	Company               Company           `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	AdvanceNumber         string            `gorm:"uniqueIndex;size:50;not null" json:"advance_number"`
+	EmployeeID            uint              `gorm:"not null;index" json:"employee_id"`
+	Employee              Employee          `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
+	RequestDate           time.Time         `gorm:"not null;index" json:"request_date"`
+	Purpose               string            `gorm:"type:text;not null" json:"purpose"`
+	Amount                float64           `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Status                CashAdvanceStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	ApprovedAt            *time.Time        `json:"approved_at"`
+	ApprovedBy            *uint             `json:"approved_by"`
+	DisbursementDate      *time.Time        `json:"disbursement_date"`
+	CashBankTransactionID *uint             `json:"cash_bank_transaction_id"`
+	JournalID             *uint             `gorm:"index" json:"journal_id"`
+	SettledDate           *time.Time        `json:"settled_date"`
+	Notes                 string            `gorm:"type:text" json:"notes"`
+	CreatedBy             uint              `gorm:"not null" json:"created_by"`
+	User                  User              `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+}
+
+type ExpenseClaimStatus string
+
+const (
+	ExpenseClaimStatusDraft     ExpenseClaimStatus = "draft"
+	ExpenseClaimStatusApproved  ExpenseClaimStatus = "approved"
+	ExpenseClaimStatusRejected  ExpenseClaimStatus = "rejected"
+	ExpenseClaimStatusSettled   ExpenseClaimStatus = "settled"
+	ExpenseClaimStatusCancelled ExpenseClaimStatus = "cancelled"
+)
+
+// Expense Claim itemizes what an employee spent, against a cash advance or
+// out of pocket. Settling it expenses the items; a positive balance is
+// reimbursed to the employee and a negative one is the unspent advance the
+// employee returns.
+type ExpenseClaim struct {
+	BaseModel
+	CompanyID             uint               `gorm:"not null;index" json:"company_id"`
+	Company               Company            `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	ClaimNumber           string             `gorm:"uniqueIndex;size:50;not null" json:"claim_number"`
+	EmployeeID            uint               `gorm:"not null;index" json:"employee_id"`
+	Employee              Employee           `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
+	AdvanceID             *uint              `gorm:"index" json:"advance_id"`
+	Advance               *CashAdvance       `gorm:"foreignKey:AdvanceID" json:"advance,omitempty"`
+	ClaimDate             time.Time          `gorm:"not null;index" json:"claim_date"`
+	Description           string             `gorm:"type:text" json:"description"`
+	TotalAmount           float64            `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	AdvanceAmount         float64            `gorm:"type:decimal(20,2);default:0" json:"advance_amount"`
+	Balance               float64            `gorm:"type:decimal(20,2);default:0" json:"balance"` // total less the advance
+	Status                ExpenseClaimStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	ApprovedAt            *time.Time         `json:"approved_at"`
+	ApprovedBy            *uint              `json:"approved_by"`
+	SettlementDate        *time.Time         `json:"settlement_date"`
+	JournalID             *uint              `gorm:"index" json:"journal_id"`
+	CashBankTransactionID *uint              `json:"cash_bank_transaction_id"`
+	SettlementJournalID   *uint              `json:"settlement_journal_id"`
+	Notes                 string             `gorm:"type:text" json:"notes"`
+	CreatedBy             uint               `gorm:"not null" json:"created_by"`
+	User                  User               `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Items                 []ExpenseClaimItem `gorm:"foreignKey:ClaimID" json:"items,omitempty"`
+}
+
+type ExpenseClaimItem struct {
+	BaseModel
+	ClaimID       uint                     `gorm:"not null;index" json:"claim_id"`
+	ExpenseDate   time.Time                `gorm:"not null" json:"expense_date"`
+	AccountID     uint                     `gorm:"not null" json:"account_id"` // expense account
+	Account       Account                  `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Description   string                   `gorm:"size:255;not null" json:"description"`
+	ReceiptNumber string                   `gorm:"size:100" json:"receipt_number"`
+	Amount        float64                  `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Attachments   []ExpenseClaimAttachment `gorm:"foreignKey:ItemID" json:"attachments,omitempty"`
+}
+
+// ExpenseClaimAttachment is a receipt scan or photo kept with a claim item
+type ExpenseClaimAttachment struct {
+	BaseModel
+	ItemID      uint   `gorm:"not null;index" json:"item_id"`
+	FileName    string `gorm:"size:255;not null" json:"file_name"`
+	ContentType string `gorm:"size:100" json:"content_type"`
+	FileSize    int64  `json:"file_size"`
+	FilePath    string `gorm:"size:500;not null" json:"-"`
+	UploadedBy  uint   `gorm:"not null" json:"uploaded_by"`
+}
+
+// OutstandingAdvance is a disbursed advance not yet settled by a claim
+type OutstandingAdvance struct {
+	AdvanceID        uint      `json:"advance_id"`
+	AdvanceNumber    string    `json:"advance_number"`
+	DisbursementDate time.Time `json:"disbursement_date"`
+	Purpose          string    `json:"purpose"`
+	Amount           float64   `json:"amount"`
+	DaysOutstanding  int       `json:"days_outstanding"`
+	ClaimNumber      string    `json:"claim_number"` // draft or approved claim settling it, if any
+}
+
+type OutstandingAdvanceGroup struct {
+	EmployeeID   uint                 `json:"employee_id"`
+	EmployeeCode string               `json:"employee_code"`
+	EmployeeName string               `json:"employee_name"`
+	Department   string               `json:"department"`
+	Advances     []OutstandingAdvance `json:"advances"`
+	Total        float64              `json:"total"`
+}
+
+type OutstandingAdvanceReport struct {
+	AsOfDate  time.Time                 `json:"as_of_date"`
+	Employees []OutstandingAdvanceGroup `json:"employees"`
+	Total     float64                   `json:"total"`
+}
\ No newline at end of file
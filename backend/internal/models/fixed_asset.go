@@ -0,0 +1,197 @@
+package models
+
+import "time"
+
+type DepreciationMethod string
+
+const (
+	DepreciationMethodStraightLine     DepreciationMethod = "straight_line"     // garis lurus
+	DepreciationMethodDecliningBalance DepreciationMethod = "declining_balance" // saldo menurun
+)
+
+// FiscalAssetGroup is the asset group of PPh Pasal 11 that sets the useful
+// life allowed for tax
+type FiscalAssetGroup string
+
+const (
+	FiscalAssetGroup1                 FiscalAssetGroup = "kelompok_1"              // 4 tahun
+	FiscalAssetGroup2                 FiscalAssetGroup = "kelompok_2"              // 8 tahun
+	FiscalAssetGroup3                 FiscalAssetGroup = "kelompok_3"              // 16 tahun
+	FiscalAssetGroup4                 FiscalAssetGroup = "kelompok_4"              // 20 tahun
+	FiscalAssetGroupBuilding          FiscalAssetGroup = "bangunan_permanen"       // 20 tahun, garis lurus
+	FiscalAssetGroupTemporaryBuilding FiscalAssetGroup = "bangunan_tidak_permanen" // 10 tahun, garis lurus
+)
+
+type FixedAssetStatus string
+
+const (
+	FixedAssetStatusActive           FixedAssetStatus = "active"
+	FixedAssetStatusFullyDepreciated FixedAssetStatus = "fully_depreciated"
+	FixedAssetStatusDisposed         FixedAssetStatus = "disposed"
+)
+
+// Fixed Asset in the register. Commercial depreciation follows the asset's
+// own useful life and method and is posted monthly; fiscal depreciation
+// follows its fiscal group and is only reported. Cost is the gross amount
+// carried after revaluations and partial disposals.
+type FixedAsset struct {
+	BaseModel
+	CompanyID                     uint               `gorm:"not null;index" json:"company_id"`
+	Company                       Company            `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	AssetNumber                   string             `gorm:"uniqueIndex;size:50;not null" json:"asset_number"`
+	Name                          string             `gorm:"size:255;not null" json:"name"`
+	Description                   string             `gorm:"type:text" json:"description"`
+	Location                      string             `gorm:"size:100" json:"location"`
+	CostCenter                    string             `gorm:"size:100;index" json:"cost_center"`
+	AssetAccountID                uint               `gorm:"not null;index" json:"asset_account_id"` // 1-2100 Peralatan, 1-2200 Kendaraan, 1-2300 Gedung
+	AssetAccount                  Account            `gorm:"foreignKey:AssetAccountID" json:"asset_account,omitempty"`
+	AccumulatedAccountID          uint               `gorm:"not null" json:"accumulated_account_id"` // akumulasi penyusutan
+	ExpenseAccountID              uint               `gorm:"not null" json:"expense_account_id"`     // beban penyusutan
+	AcquisitionDate               time.Time          `gorm:"not null;index" json:"acquisition_date"`
+	AcquisitionCost               float64            `gorm:"type:decimal(20,2);not null" json:"acquisition_cost"`
+	Cost                          float64            `gorm:"type:decimal(20,2);not null" json:"cost"`
+	FiscalCost                    float64            `gorm:"type:decimal(20,2);not null" json:"fiscal_cost"` // cost for tax, only reduced by partial disposals
+	SalvageValue                  float64            `gorm:"type:decimal(20,2);default:0" json:"salvage_value"`
+	UsefulLifeMonths              int                `gorm:"not null" json:"useful_life_months"`
+	Method                        DepreciationMethod `gorm:"type:varchar(20);not null" json:"method"`
+	FiscalGroup                   FiscalAssetGroup   `gorm:"type:varchar(30);not null;index" json:"fiscal_group"`
+	FiscalMethod                  DepreciationMethod `gorm:"type:varchar(20);not null" json:"fiscal_method"`
+	AccumulatedDepreciation       float64            `gorm:"type:decimal(20,2);default:0" json:"accumulated_depreciation"`
+	FiscalAccumulatedDepreciation float64            `gorm:"type:decimal(20,2);default:0" json:"fiscal_accumulated_depreciation"`
+	RevaluationSurplus            float64            `gorm:"type:decimal(20,2);default:0" json:"revaluation_surplus"` // surplus still in equity for the asset
+	DepreciatedThrough            *time.Time         `json:"depreciated_through"`                                     // last month-end depreciated, nil = not yet
+	Status                        FixedAssetStatus   `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	DisposalDate                  *time.Time         `json:"disposal_date"`
+	Notes                         string             `gorm:"type:text" json:"notes"`
+	CreatedBy                     uint               `gorm:"not null" json:"created_by"`
+	User                          User               `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+}
+
+// DepreciationScheduleLine is one month of an asset's depreciation, both
+// commercial and fiscal. Posted months are kept as they were; the months
+// ahead are laid out again whenever the asset is revalued, impaired or
+// partly disposed of.
+type DepreciationScheduleLine struct {
+	BaseModel
+	AssetID                       uint      `gorm:"not null;index" json:"asset_id"`
+	Period                        string    `json:"period"` // YYYY-MM
+	PeriodEnd                     time.Time `json:"period_end"`
+	Depreciation                  float64   `json:"depreciation"`
+	AccumulatedDepreciation       float64   `json:"accumulated_depreciation"`
+	BookValue                     float64   `json:"book_value"`
+	FiscalDepreciation            float64   `json:"fiscal_depreciation"`
+	FiscalAccumulatedDepreciation float64   `json:"fiscal_accumulated_depreciation"`
+	FiscalBookValue               float64   `json:"fiscal_book_value"`
+	IsPosted                      bool      `gorm:"default:false" json:"is_posted"`
+	RunID                         *uint     `gorm:"index" json:"run_id"` // nil when posted ahead of an asset event
+}
+
+type DepreciationSchedule struct {
+	Asset FixedAsset                 `json:"asset"`
+	Lines []DepreciationScheduleLine `json:"lines"`
+}
+
+// Depreciation Run posts one month's commercial depreciation of all active
+// assets in a single journal
+type DepreciationRun struct {
+	BaseModel
+	CompanyID               uint                  `gorm:"not null;uniqueIndex:idx_depreciation_run_period" json:"company_id"`
+	Company                 Company               `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Period                  string                `gorm:"size:7;not null;uniqueIndex:idx_depreciation_run_period" json:"period"` // YYYY-MM
+	PeriodEnd               time.Time             `gorm:"not null" json:"period_end"`
+	TotalDepreciation       float64               `gorm:"type:decimal(20,2);default:0" json:"total_depreciation"`
+	TotalFiscalDepreciation float64               `gorm:"type:decimal(20,2);default:0" json:"total_fiscal_depreciation"`
+	JournalID               *uint                 `gorm:"index" json:"journal_id"`
+	Journal                 *Journal              `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
+	CreatedBy               uint                  `gorm:"not null" json:"created_by"`
+	User                    User                  `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Lines                   []DepreciationRunLine `gorm:"foreignKey:RunID" json:"lines,omitempty"`
+}
+
+// DepreciationRunLine is an asset's depreciation in a run; an asset the
+// previous runs missed catches up on every month since
+type DepreciationRunLine struct {
+	BaseModel
+	RunID                         uint       `gorm:"not null;index" json:"run_id"`
+	AssetID                       uint       `gorm:"not null;index" json:"asset_id"`
+	Asset                         FixedAsset `gorm:"foreignKey:AssetID" json:"asset,omitempty"`
+	Months                        int        `gorm:"not null" json:"months"`
+	Depreciation                  float64    `gorm:"type:decimal(20,2);default:0" json:"depreciation"`
+	FiscalDepreciation            float64    `gorm:"type:decimal(20,2);default:0" json:"fiscal_depreciation"`
+	AccumulatedDepreciation       float64    `gorm:"type:decimal(20,2);default:0" json:"accumulated_depreciation"`
+	FiscalAccumulatedDepreciation float64    `gorm:"type:decimal(20,2);default:0" json:"fiscal_accumulated_depreciation"`
+	BookValue                     float64    `gorm:"type:decimal(20,2);default:0" json:"book_value"`
+}
+
+type FixedAssetEventType string
+
+const (
+	FixedAssetEventAcquisition     FixedAssetEventType = "acquisition"
+	FixedAssetEventDepreciation    FixedAssetEventType = "depreciation" // months caught up ahead of another event
+	FixedAssetEventTransfer        FixedAssetEventType = "transfer"
+	FixedAssetEventRevaluation     FixedAssetEventType = "revaluation"
+	FixedAssetEventImpairment      FixedAssetEventType = "impairment"
+	FixedAssetEventPartialDisposal FixedAssetEventType = "partial_disposal"
+	FixedAssetEventDisposal        FixedAssetEventType = "disposal"
+)
+
+// FixedAssetEvent is an entry in the asset's history, with the asset's cost
+// and book value before and after it
+type FixedAssetEvent struct {
+	BaseModel
+	AssetID         uint                `gorm:"not null;index" json:"asset_id"`
+	EventType       FixedAssetEventType `gorm:"type:varchar(20);not null" json:"event_type"`
+	EventDate       time.Time           `gorm:"not null;index" json:"event_date"`
+	Description     string              `gorm:"type:text" json:"description"`
+	CostBefore      float64             `gorm:"type:decimal(20,2);default:0" json:"cost_before"`
+	CostAfter       float64             `gorm:"type:decimal(20,2);default:0" json:"cost_after"`
+	BookValueBefore float64             `gorm:"type:decimal(20,2);default:0" json:"book_value_before"`
+	BookValueAfter  float64             `gorm:"type:decimal(20,2);default:0" json:"book_value_after"`
+	Amount          float64             `gorm:"type:decimal(20,2);default:0" json:"amount"`    // depreciation, surplus, write-down or proceeds
+	Percent         float64             `gorm:"type:decimal(5,2);default:0" json:"percent"`    // share of the asset disposed of
+	GainLoss        float64             `gorm:"type:decimal(20,2);default:0" json:"gain_loss"` // disposals, negative = loss
+	FromLocation    string              `gorm:"size:100" json:"from_location"`
+	ToLocation      string              `gorm:"size:100" json:"to_location"`
+	FromCostCenter  string              `gorm:"size:100" json:"from_cost_center"`
+	ToCostCenter    string              `gorm:"size:100" json:"to_cost_center"`
+	JournalID       *uint               `gorm:"index" json:"journal_id"`
+	Notes           string              `gorm:"type:text" json:"notes"`
+	CreatedBy       uint                `gorm:"not null" json:"created_by"`
+	User            User                `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+}
+
+// FixedAssetDepreciationLine compares an asset's commercial and fiscal
+// depreciation for the year
+type FixedAssetDepreciationLine struct {
+	AssetID                uint             `json:"asset_id"`
+	AssetNumber            string           `json:"asset_number"`
+	Name                   string           `json:"name"`
+	FiscalGroup            FiscalAssetGroup `json:"fiscal_group"`
+	AcquisitionDate        time.Time        `json:"acquisition_date"`
+	AcquisitionCost        float64          `json:"acquisition_cost"`
+	CommercialDepreciation float64          `json:"commercial_depreciation"`
+	FiscalDepreciation     float64          `json:"fiscal_depreciation"`
+	FiscalCorrection       float64          `json:"fiscal_correction"` // commercial - fiscal, positive adds to taxable income
+	CommercialBookValue    float64          `json:"commercial_book_value"`
+	FiscalBookValue        float64          `json:"fiscal_book_value"`
+}
+
+type FixedAssetDepreciationGroup struct {
+	FiscalGroup            FiscalAssetGroup             `json:"fiscal_group"`
+	Lines                  []FixedAssetDepreciationLine `json:"lines"`
+	AcquisitionCost        float64                      `json:"acquisition_cost"`
+	CommercialDepreciation float64                      `json:"commercial_depreciation"`
+	FiscalDepreciation     float64                      `json:"fiscal_depreciation"`
+	FiscalCorrection       float64                      `json:"fiscal_correction"`
+}
+
+// FixedAssetDepreciationReport is the commercial vs fiscal depreciation of
+// the register for a year, by fiscal group
+type FixedAssetDepreciationReport struct {
+	Year                   int                           `json:"year"`
+	Groups                 []FixedAssetDepreciationGroup `json:"groups"`
+	AcquisitionCost        float64                       `json:"acquisition_cost"`
+	CommercialDepreciation float64                       `json:"commercial_depreciation"`
+	FiscalDepreciation     float64                       `json:"fiscal_depreciation"`
+	FiscalCorrection       float64                       `json:"fiscal_correction"`
+}
\ No newline at end of file
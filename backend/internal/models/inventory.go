@@ -13,89 +13,482 @@ const (
 // Product/Item Master
 type Product struct {
 	BaseModel
-	CompanyID   uint       `gorm:"not null;index" json:"company_id"`
-	Company     Company    `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
-	Code        string     `gorm:"uniqueIndex:idx_company_product_code;size:50;not null" json:"code"`
-	Name        string     `gorm:"size:255;not null" json:"name"`
-	Description string     `gorm:"type:text" json:"description"`
-	Category    string     `gorm:"size:100" json:"category"`
-	Unit        string     `gorm:"size:50" json:"unit"` // pcs, kg, liter, etc
-	CostMethod  CostMethod `gorm:"type:varchar(20);not null;default:'fifo'" json:"cost_method"`
-	MinStock    float64    `gorm:"type:decimal(20,2);default:0" json:"min_stock"`
-	IsActive    bool       `gorm:"default:true" json:"is_active"`
+	CompanyID         uint          `gorm:"not null;index" json:"company_id"`
+	Company           Company       `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Code              string        `gorm:"uniqueIndex:idx_company_product_code;size:50;not null" json:"code"`
+	Name              string        `gorm:"size:255;not null" json:"name"`
+	Description       string        `gorm:"type:text" json:"description"`
+	Category          string        `gorm:"size:100" json:"category"`
+	Unit              string        `gorm:"size:50" json:"unit"` // pcs, kg, liter, etc
+	CostMethod        CostMethod    `gorm:"type:varchar(20);not null;default:'fifo'" json:"cost_method"`
+	MinStock          float64       `gorm:"type:decimal(20,2);default:0" json:"min_stock"`
+	ReorderPoint      float64       `gorm:"type:decimal(20,2);default:0" json:"reorder_point"`    // 0 = suggestions derive it from usage, lead time & safety stock
+	ReorderQuantity   float64       `gorm:"type:decimal(20,2);default:0" json:"reorder_quantity"` // minimum order quantity
+	LeadTimeDays      int           `gorm:"default:0" json:"lead_time_days"`
+	SafetyStock       float64       `gorm:"type:decimal(20,2);default:0" json:"safety_stock"`
+	Weight            float64       `gorm:"type:decimal(20,4);default:0" json:"weight"` // kg per base unit, for landed cost by weight
+	IsLotTracked      bool          `gorm:"default:false" json:"is_lot_tracked"`        // stock-in requires a lot number
+	IsSerialTracked   bool          `gorm:"default:false" json:"is_serial_tracked"`     // every unit moves with its serial number
+	ExpiryWarningDays int           `gorm:"default:0" json:"expiry_warning_days"`       // 0 = default warning window
+	IsActive          bool          `gorm:"default:true" json:"is_active"`
+	Units             []ProductUnit `gorm:"foreignKey:ProductID" json:"units,omitempty"`     // alternate units, Unit is the base (stock) unit
+	BOMItems          []BOMItem     `gorm:"foreignKey:ProductID" json:"bom_items,omitempty"` // components of assembled products
+}
+
+// Unit of Measure catalog
+type UnitOfMeasure struct {
+	BaseModel
+	CompanyID uint    `gorm:"uniqueIndex:idx_company_uom_code;not null" json:"company_id"`
+	Company   Company `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Code      string  `gorm:"uniqueIndex:idx_company_uom_code;size:50;not null" json:"code"` // pcs, pack, ctn
+	Name      string  `gorm:"size:100;not null" json:"name"`
+}
+
+// Product Unit conversion (Factor base units make one of this unit)
+type ProductUnit struct {
+	BaseModel
+	ProductID      uint          `gorm:"uniqueIndex:idx_product_uom;not null" json:"product_id"`
+	UnitID         uint          `gorm:"uniqueIndex:idx_product_uom;not null" json:"unit_id"`
+	Unit           UnitOfMeasure `gorm:"foreignKey:UnitID" json:"unit,omitempty"`
+	Factor         float64       `gorm:"type:decimal(20,6);not null" json:"factor"`
+	IsPurchaseUnit bool          `gorm:"default:false" json:"is_purchase_unit"`
+	IsSalesUnit    bool          `gorm:"default:false" json:"is_sales_unit"`
+}
+
+// Inventory Account Mapping (per product, per category, or company default
+// when both ProductID and Category are empty). Empty accounts fall through
+// to the next level and finally to the default chart of accounts.
+type InventoryAccountMapping struct {
+	BaseModel
+	CompanyID                 uint     `gorm:"not null;index" json:"company_id"`
+	Company                   Company  `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	ProductID                 *uint    `gorm:"index" json:"product_id"`
+	Product                   *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Category                  string   `gorm:"size:100;index" json:"category"`
+	InventoryAccountID        *uint    `json:"inventory_account_id"`
+	InventoryAccount          *Account `gorm:"foreignKey:InventoryAccountID" json:"inventory_account,omitempty"`
+	COGSAccountID             *uint    `json:"cogs_account_id"`
+	COGSAccount               *Account `gorm:"foreignKey:COGSAccountID" json:"cogs_account,omitempty"`
+	PurchaseClearingAccountID *uint    `json:"purchase_clearing_account_id"`
+	PurchaseClearingAccount   *Account `gorm:"foreignKey:PurchaseClearingAccountID" json:"purchase_clearing_account,omitempty"`
+	VarianceAccountID         *uint    `json:"variance_account_id"`
+	VarianceAccount           *Account `gorm:"foreignKey:VarianceAccountID" json:"variance_account,omitempty"`
 }
 
 // Stock Movement
 type StockMovement struct {
 	BaseModel
-	CompanyID       uint      `gorm:"not null;index" json:"company_id"`
-	Company         Company   `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
-	ProductID       uint      `gorm:"not null;index" json:"product_id"`
-	Product         Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	MovementNumber  string    `gorm:"uniqueIndex;size:50;not null" json:"movement_number"`
-	MovementDate    time.Time `gorm:"not null;index" json:"movement_date"`
-	Type            string    `gorm:"type:varchar(20);not null" json:"type"` // in, out, adjustment
-	Quantity        float64   `gorm:"type:decimal(20,2);not null" json:"quantity"`
-	UnitCost        float64   `gorm:"type:decimal(20,2);not null" json:"unit_cost"`
-	TotalCost       float64   `gorm:"type:decimal(20,2);not null" json:"total_cost"`
-	Reference       string    `gorm:"size:100" json:"reference"`
-	Notes           string    `gorm:"type:text" json:"notes"`
-	JournalID       *uint     `gorm:"index" json:"journal_id"`
-	Journal         *Journal  `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
-	CreatedBy       uint      `gorm:"not null" json:"created_by"`
-	User            User      `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	CompanyID        uint                 `gorm:"not null;index" json:"company_id"`
+	Company          Company              `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	ProductID        uint                 `gorm:"not null;index" json:"product_id"`
+	Product          Product              `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	MovementNumber   string               `gorm:"uniqueIndex;size:50;not null" json:"movement_number"`
+	WarehouseID      uint                 `gorm:"not null;default:0;index" json:"warehouse_id"`
+	MovementDate     time.Time            `gorm:"not null;index" json:"movement_date"`
+	Type             string               `gorm:"type:varchar(20);not null" json:"type"`             // in, out, adjustment, transfer_out, transfer_in
+	SourceMovementID *uint                `gorm:"index" json:"source_movement_id"`                   // transfer_in: the transfer_out it receives; transfer_out from transit: the dispatch it receives; in/out: the movement returned
+	Quantity         float64              `gorm:"type:decimal(20,2);not null" json:"quantity"`       // base unit
+	UnitCost         float64              `gorm:"type:decimal(20,6);not null" json:"unit_cost"`      // per base unit
+	Unit             string               `gorm:"size:50" json:"unit"`                               // unit the quantity was entered in
+	UnitQuantity     float64              `gorm:"type:decimal(20,4);default:0" json:"unit_quantity"` // quantity in that unit
+	ConversionFactor float64              `gorm:"type:decimal(20,6);default:1" json:"conversion_factor"`
+	TotalCost        float64              `gorm:"type:decimal(20,2);not null" json:"total_cost"`
+	Reference        string               `gorm:"size:100" json:"reference"`
+	Party            string               `gorm:"size:255" json:"party"` // customer / supplier the goods came from or went to
+	Notes            string               `gorm:"type:text" json:"notes"`
+	JournalID        *uint                `gorm:"index" json:"journal_id"`
+	OffsetAccountID  *uint                `gorm:"-" json:"offset_account_id,omitempty"` // in/out: posted against this instead of purchase clearing / COGS
+	Journal          *Journal             `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
+	LotNumber        string               `gorm:"-" json:"lot_number,omitempty"` // stock-in / adjustment up of lot-tracked products
+	ExpiryDate       *time.Time           `gorm:"-" json:"expiry_date,omitempty"`
+	LotAllocations   []StockLotAllocation `gorm:"foreignKey:MovementID" json:"lot_allocations,omitempty"`
+	SerialNumbers    []string             `gorm:"-" json:"serial_numbers,omitempty"` // in/out: units moved; adjustment: full counted set
+	OpnameID         *uint                `gorm:"index" json:"opname_id"`            // adjustment posted by a stock opname
+	ReservationID    *uint                `gorm:"index" json:"reservation_id"`       // stock-out fulfilling a reservation
+	CreatedBy        uint                 `gorm:"not null" json:"created_by"`
+	User             User                 `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
 }
 
 // Stock Balance
 type StockBalance struct {
 	BaseModel
-	CompanyID     uint    `gorm:"not null;index" json:"company_id"`
-	Company       Company `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
-	ProductID     uint    `gorm:"not null;index" json:"product_id"`
-	Product       Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	Quantity      float64 `gorm:"type:decimal(20,2);not null;default:0" json:"quantity"`
-	AverageCost   float64 `gorm:"type:decimal(20,2);not null;default:0" json:"average_cost"`
-	TotalValue    float64 `gorm:"type:decimal(20,2);not null;default:0" json:"total_value"`
+	CompanyID         uint    `gorm:"not null;index" json:"company_id"`
+	Company           Company `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	ProductID         uint    `gorm:"not null;index" json:"product_id"`
+	Product           Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	WarehouseID       uint    `gorm:"not null;default:0;index" json:"warehouse_id"`
+	Quantity          float64 `gorm:"type:decimal(20,2);not null;default:0" json:"quantity"`
+	AverageCost       float64 `gorm:"type:decimal(20,6);not null;default:0" json:"average_cost"`
+	TotalValue        float64 `gorm:"type:decimal(20,2);not null;default:0" json:"total_value"`
+	DisplayUnit       string  `gorm:"-" json:"display_unit,omitempty"` // alternate unit requested by reports
+	DisplayQuantity   float64 `gorm:"-" json:"display_quantity,omitempty"`
+	ReservedQuantity  float64 `gorm:"-" json:"reserved_quantity"`  // held by active reservations
+	AvailableQuantity float64 `gorm:"-" json:"available_quantity"` // on hand less reserved
+}
+
+type ReservationStatus string
+
+const (
+	ReservationStatusActive    ReservationStatus = "active"
+	ReservationStatusFulfilled ReservationStatus = "fulfilled"
+	ReservationStatusReleased  ReservationStatus = "released"
+	ReservationStatusExpired   ReservationStatus = "expired"
+)
+
+// Stock Reservation: stock held for a confirmed order until it ships
+type StockReservation struct {
+	BaseModel
+	CompanyID         uint              `gorm:"not null;index" json:"company_id"`
+	Company           Company           `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	ReservationNumber string            `gorm:"uniqueIndex;size:50;not null" json:"reservation_number"`
+	ProductID         uint              `gorm:"not null;index" json:"product_id"`
+	Product           Product           `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	WarehouseID       uint              `gorm:"not null;index" json:"warehouse_id"`
+	Warehouse         Warehouse         `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	Quantity          float64           `gorm:"type:decimal(20,2);not null" json:"quantity"` // base unit
+	FulfilledQuantity float64           `gorm:"type:decimal(20,2);default:0" json:"fulfilled_quantity"`
+	Status            ReservationStatus `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	ExpiresAt         *time.Time        `gorm:"index" json:"expires_at"`         // nil = held until fulfilled or released
+	ReferenceType     string            `gorm:"size:50" json:"reference_type"`   // sales_order, production_order, ...
+	Reference         string            `gorm:"size:100;index" json:"reference"` // reference document number
+	Notes             string            `gorm:"type:text" json:"notes"`
+	CreatedBy         uint              `gorm:"not null" json:"created_by"`
+	User              User              `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	ReleasedAt        *time.Time        `json:"released_at"`
+}
+
+// Available-to-promise answer for one product
+type AvailableToPromise struct {
+	ProductID   uint    `json:"product_id"`
+	ProductCode string  `json:"product_code"`
+	ProductName string  `json:"product_name"`
+	Unit        string  `json:"unit"`
+	WarehouseID uint    `json:"warehouse_id"` // 0 = all warehouses
+	OnHand      float64 `json:"on_hand"`
+	Reserved    float64 `json:"reserved"`
+	Available   float64 `json:"available"`
+}
+
+// Stock Cost Layer (one per receipt, consumed by stock-out per cost method)
+type StockCostLayer struct {
+	BaseModel
+	CompanyID         uint           `gorm:"not null;index" json:"company_id"`
+	ProductID         uint           `gorm:"not null;index" json:"product_id"`
+	Product           Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	WarehouseID       uint           `gorm:"not null;default:0;index" json:"warehouse_id"`
+	MovementID        *uint          `gorm:"index" json:"movement_id"` // nil for opening layers built from an existing balance
+	Movement          *StockMovement `gorm:"foreignKey:MovementID" json:"movement,omitempty"`
+	ReceivedDate      time.Time      `gorm:"not null;index" json:"received_date"`
+	OriginDate        *time.Time     `json:"origin_date"`                  // first receipt of stock carried in by a transfer, for FIFO order and aging; nil = received date
+	SourceLayerID     *uint          `gorm:"index" json:"source_layer_id"` // transfer receipts: the layer the stock was taken from
+	Quantity          float64        `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	RemainingQuantity float64        `gorm:"type:decimal(20,2);not null" json:"remaining_quantity"`
+	UnitCost          float64        `gorm:"type:decimal(20,6);not null" json:"unit_cost"`
+}
+
+// Stock Layer Consumption (which layers a stock-out drew from)
+type StockLayerConsumption struct {
+	BaseModel
+	CompanyID    uint      `gorm:"not null;index" json:"company_id"`
+	ProductID    uint      `gorm:"not null;index" json:"product_id"`
+	LayerID      uint      `gorm:"not null;index" json:"layer_id"`
+	MovementID   uint      `gorm:"not null;index" json:"movement_id"`
+	ConsumedDate time.Time `gorm:"not null;index" json:"consumed_date"`
+	Quantity     float64   `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	UnitCost     float64   `gorm:"type:decimal(20,6);not null" json:"unit_cost"`
+	TotalCost    float64   `gorm:"type:decimal(20,2);not null" json:"total_cost"`
+}
+
+// Stock Lot / Batch (per product and warehouse)
+type StockLot struct {
+	BaseModel
+	CompanyID   uint       `gorm:"not null;index" json:"company_id"`
+	ProductID   uint       `gorm:"uniqueIndex:idx_product_warehouse_lot;not null" json:"product_id"`
+	Product     Product    `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	WarehouseID uint       `gorm:"uniqueIndex:idx_product_warehouse_lot;not null" json:"warehouse_id"`
+	Warehouse   Warehouse  `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	LotNumber   string     `gorm:"uniqueIndex:idx_product_warehouse_lot;size:100;not null" json:"lot_number"`
+	ExpiryDate  *time.Time `gorm:"index" json:"expiry_date"`
+	Quantity    float64    `gorm:"type:decimal(20,2);not null;default:0" json:"quantity"`
+}
+
+// Stock Lot Allocation (lot quantities moved by a stock movement)
+type StockLotAllocation struct {
+	BaseModel
+	MovementID uint     `gorm:"not null;index" json:"movement_id"`
+	LotID      uint     `gorm:"not null;index" json:"lot_id"`
+	Lot        StockLot `gorm:"foreignKey:LotID" json:"lot,omitempty"`
+	Quantity   float64  `gorm:"type:decimal(20,2);not null" json:"quantity"`
+}
+
+const (
+	SerialStatusInStock = "in_stock"
+	SerialStatusOut     = "out"
+)
+
+// Serial Number of a serial-tracked product unit
+type SerialNumber struct {
+	BaseModel
+	CompanyID      uint    `gorm:"not null;index" json:"company_id"`
+	ProductID      uint    `gorm:"uniqueIndex:idx_product_serial;not null" json:"product_id"`
+	Product        Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	SerialNumber   string  `gorm:"uniqueIndex:idx_product_serial;size:100;not null" json:"serial_number"`
+	WarehouseID    uint    `gorm:"not null;index" json:"warehouse_id"`            // last warehouse holding the unit
+	Status         string  `gorm:"type:varchar(20);not null;index" json:"status"` // in_stock, out
+	LastMovementID uint    `gorm:"not null" json:"last_movement_id"`
 }
 
+// Serial Number Event (history of a unit: received, moved, sold, returned, adjusted)
+type SerialNumberEvent struct {
+	BaseModel
+	SerialID    uint          `gorm:"not null;index" json:"serial_id"`
+	MovementID  uint          `gorm:"not null;index" json:"movement_id"`
+	Movement    StockMovement `gorm:"foreignKey:MovementID" json:"movement,omitempty"`
+	WarehouseID uint          `gorm:"not null" json:"warehouse_id"`
+	Event       string        `gorm:"type:varchar(20);not null" json:"event"` // received, returned, sold, moved_out, moved_in, found, written_off
+	Party       string        `gorm:"size:255" json:"party"`                  // customer / supplier of the movement
+	EventDate   time.Time     `gorm:"not null;index" json:"event_date"`
+}
+
+// Serial History lookup result
+type SerialHistory struct {
+	Serial   SerialNumber        `json:"serial"`
+	Customer string              `json:"customer,omitempty"` // customer holding the unit when it was last sold
+	Events   []SerialNumberEvent `json:"events"`
+}
+
+const (
+	OpnameStatusDraft     = "draft"    // quantities entered by the client, approvable as is
+	OpnameStatusCounting  = "counting" // system quantities snapshotted, movements of its products frozen
+	OpnameStatusApproved  = "approved"
+	OpnameStatusCancelled = "cancelled"
+)
+
+const (
+	OpnameItemPending = "pending" // no count yet in the current round
+	OpnameItemCounted = "counted"
+	OpnameItemRecount = "recount" // variance over tolerance, waiting for the recount
+)
+
 // Stock Opname (Physical Count)
 type StockOpname struct {
 	BaseModel
-	CompanyID      uint      `gorm:"not null;index" json:"company_id"`
-	Company        Company   `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
-	OpnameNumber   string    `gorm:"uniqueIndex;size:50;not null" json:"opname_number"`
-	OpnameDate     time.Time `gorm:"not null" json:"opname_date"`
-	Status         string    `gorm:"type:varchar(20);not null;default:'draft'" json:"status"` // draft, approved
-	Notes          string    `gorm:"type:text" json:"notes"`
-	CreatedBy      uint      `gorm:"not null" json:"created_by"`
-	User           User      `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
-	ApprovedBy     *uint     `json:"approved_by"`
-	ApprovedAt     *time.Time `json:"approved_at"`
-	Items          []StockOpnameItem `gorm:"foreignKey:OpnameID" json:"items,omitempty"`
+	CompanyID         uint              `gorm:"not null;index" json:"company_id"`
+	Company           Company           `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	OpnameNumber      string            `gorm:"uniqueIndex;size:50;not null" json:"opname_number"`
+	WarehouseID       uint              `gorm:"not null;default:0;index" json:"warehouse_id"`
+	Warehouse         Warehouse         `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	OpnameDate        time.Time         `gorm:"not null" json:"opname_date"`
+	Status            string            `gorm:"type:varchar(20);not null;default:'draft'" json:"status"` // draft, counting, approved, cancelled
+	Category          string            `gorm:"size:100" json:"category"`                                // counted product category, empty = all
+	TolerancePercent  float64           `gorm:"type:decimal(10,2);default:0" json:"tolerance_percent"`   // of system quantity
+	ToleranceQuantity float64           `gorm:"type:decimal(20,2);default:0" json:"tolerance_quantity"`  // base unit
+	Notes             string            `gorm:"type:text" json:"notes"`
+	CreatedBy         uint              `gorm:"not null" json:"created_by"`
+	User              User              `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	ApprovedBy        *uint             `json:"approved_by"`
+	ApprovedAt        *time.Time        `json:"approved_at"`
+	Items             []StockOpnameItem `gorm:"foreignKey:OpnameID" json:"items,omitempty"`
 }
 
 type StockOpnameItem struct {
 	BaseModel
-	OpnameID        uint    `gorm:"not null;index" json:"opname_id"`
-	ProductID       uint    `gorm:"not null;index" json:"product_id"`
-	Product         Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
-	SystemQuantity  float64 `gorm:"type:decimal(20,2);not null" json:"system_quantity"`
-	PhysicalQuantity float64 `gorm:"type:decimal(20,2);not null" json:"physical_quantity"`
-	Difference      float64 `gorm:"type:decimal(20,2);not null" json:"difference"`
-	Notes           string  `gorm:"type:text" json:"notes"`
+	OpnameID         uint       `gorm:"not null;index" json:"opname_id"`
+	ProductID        uint       `gorm:"not null;index" json:"product_id"`
+	Product          Product    `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	SystemQuantity   float64    `gorm:"type:decimal(20,2);not null" json:"system_quantity"`
+	PhysicalQuantity float64    `gorm:"type:decimal(20,2);not null" json:"physical_quantity"`
+	Difference       float64    `gorm:"type:decimal(20,2);not null" json:"difference"`
+	Status           string     `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	CountRound       int        `gorm:"not null;default:1" json:"count_round"` // 2 once flagged for recount
+	LotNumber        string     `gorm:"size:100" json:"lot_number"`            // lot for count surplus of lot-tracked products
+	ExpiryDate       *time.Time `json:"expiry_date"`
+	ScannedSerials   []string   `gorm:"type:text;serializer:json" json:"scanned_serials,omitempty"`
+	Notes            string     `gorm:"type:text" json:"notes"`
+	MovementID       *uint      `json:"movement_id"`                       // adjustment posted on approval
+	AdjustmentError  string     `gorm:"type:text" json:"adjustment_error"` // why the adjustment failed on the last approval
+}
+
+// Stock Opname count: one counter's count of a product, submitted in batches
+type StockOpnameCount struct {
+	BaseModel
+	OpnameID       uint       `gorm:"not null;index" json:"opname_id"`
+	ItemID         uint       `gorm:"not null;index" json:"item_id"`
+	ProductID      uint       `gorm:"not null;index" json:"product_id"`
+	Product        Product    `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	CountRound     int        `gorm:"not null;default:1" json:"count_round"`
+	Batch          string     `gorm:"size:100" json:"batch"` // counter's sheet, zone or rack
+	Quantity       float64    `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	LotNumber      string     `gorm:"size:100" json:"lot_number"`
+	ExpiryDate     *time.Time `json:"expiry_date"`
+	ScannedSerials []string   `gorm:"type:text;serializer:json" json:"scanned_serials,omitempty"`
+	Notes          string     `gorm:"type:text" json:"notes"`
+	CountedBy      uint       `gorm:"not null" json:"counted_by"`
+	User           User       `gorm:"foreignKey:CountedBy" json:"user,omitempty"`
+}
+
+// Reorder Suggestion (quantities in base unit unless stated)
+type ReorderSuggestion struct {
+	ProductID                 uint    `json:"product_id"`
+	ProductCode               string  `json:"product_code"`
+	ProductName               string  `json:"product_name"`
+	Unit                      string  `json:"unit"`
+	OnHand                    float64 `json:"on_hand"`
+	MinStock                  float64 `json:"min_stock"`
+	AverageDailyUsage         float64 `json:"average_daily_usage"`
+	LeadTimeDays              int     `json:"lead_time_days"`
+	SafetyStock               float64 `json:"safety_stock"`
+	ReorderPoint              float64 `json:"reorder_point"`
+	NeedsReorder              bool    `json:"needs_reorder"`
+	SuggestedQuantity         float64 `json:"suggested_quantity"`
+	PurchaseUnit              string  `json:"purchase_unit"`
+	SuggestedPurchaseQuantity float64 `json:"suggested_purchase_quantity"` // rounded up to whole purchase units
 }
 
 // HPP Calculation (Cost of Goods Sold)
 type HPPCalculation struct {
-	ProductID       uint    `json:"product_id"`
-	ProductCode     string  `json:"product_code"`
-	ProductName     string  `json:"product_name"`
-	BeginningStock  float64 `json:"beginning_stock"`
-	BeginningValue  float64 `json:"beginning_value"`
-	Purchases       float64 `json:"purchases"`
-	PurchaseValue   float64 `json:"purchase_value"`
-	Sales           float64 `json:"sales"`
-	COGS            float64 `json:"cogs"`
-	EndingStock     float64 `json:"ending_stock"`
-	EndingValue     float64 `json:"ending_value"`
+	ProductID        uint    `json:"product_id"`
+	ProductCode      string  `json:"product_code"`
+	ProductName      string  `json:"product_name"`
+	BeginningStock   float64 `json:"beginning_stock"`
+	BeginningValue   float64 `json:"beginning_value"`
+	Purchases        float64 `json:"purchases"`
+	PurchaseValue    float64 `json:"purchase_value"`
+	Sales            float64 `json:"sales"`
+	COGS             float64 `json:"cogs"`
+	TransferIn       float64 `json:"transfer_in"`
+	TransferInValue  float64 `json:"transfer_in_value"`
+	TransferOut      float64 `json:"transfer_out"`
+	TransferOutValue float64 `json:"transfer_out_value"`
+	EndingStock      float64 `json:"ending_stock"`
+	EndingValue      float64 `json:"ending_value"`
+}
+
+// Cost Layer Report
+type CostLayerReport struct {
+	LayerID           uint    `json:"layer_id"`
+	ProductID         uint    `json:"product_id"`
+	ProductCode       string  `json:"product_code"`
+	ProductName       string  `json:"product_name"`
+	WarehouseID       uint    `json:"warehouse_id"`
+	WarehouseCode     string  `json:"warehouse_code"`
+	CostMethod        string  `json:"cost_method"`
+	MovementNumber    string  `json:"movement_number"`
+	ReceivedDate      string  `json:"received_date"`
+	OriginDate        string  `json:"origin_date"` // first receipt before any transfer
+	Quantity          float64 `json:"quantity"`
+	RemainingQuantity float64 `json:"remaining_quantity"`
+	UnitCost          float64 `json:"unit_cost"`
+	RemainingValue    float64 `json:"remaining_value"`
+}
+
+// Inventory Subledger to GL Reconciliation
+type InventoryReconciliationLine struct {
+	AccountID      uint    `json:"account_id"`
+	AccountCode    string  `json:"account_code"`
+	AccountName    string  `json:"account_name"`
+	SubledgerValue float64 `json:"subledger_value"`
+	GLBalance      float64 `json:"gl_balance"`
+	Difference     float64 `json:"difference"`
+}
+
+type InventoryReconciliation struct {
+	AsOfDate                string                        `json:"as_of_date"`
+	Accounts                []InventoryReconciliationLine `json:"accounts"`
+	TotalSubledgerValue     float64                       `json:"total_subledger_value"`
+	TotalGLBalance          float64                       `json:"total_gl_balance"`
+	TotalDifference         float64                       `json:"total_difference"`
+	MovementsWithoutJournal int64                         `json:"movements_without_journal"`
+	IsReconciled            bool                          `json:"is_reconciled"`
+}
+
+// Stock Card (Kartu Stok)
+type StockCardEntry struct {
+	MovementID      uint    `json:"movement_id"`
+	MovementNumber  string  `json:"movement_number"`
+	Date            string  `json:"date"`
+	Type            string  `json:"type"`
+	WarehouseID     uint    `json:"warehouse_id"`
+	Reference       string  `json:"reference"`
+	Party           string  `json:"party"`
+	Notes           string  `json:"notes"`
+	QuantityIn      float64 `json:"quantity_in"`
+	QuantityOut     float64 `json:"quantity_out"`
+	ValueIn         float64 `json:"value_in"`
+	ValueOut        float64 `json:"value_out"`
+	BalanceQuantity float64 `json:"balance_quantity"`
+	BalanceValue    float64 `json:"balance_value"`
+}
+
+type StockCard struct {
+	ProductID       uint             `json:"product_id"`
+	ProductCode     string           `json:"product_code"`
+	ProductName     string           `json:"product_name"`
+	Unit            string           `json:"unit"`
+	WarehouseID     uint             `json:"warehouse_id"` // 0 = all warehouses
+	StartDate       string           `json:"start_date"`
+	EndDate         string           `json:"end_date"`
+	OpeningQuantity float64          `json:"opening_quantity"`
+	OpeningValue    float64          `json:"opening_value"`
+	Entries         []StockCardEntry `json:"entries"`
+	TotalIn         float64          `json:"total_in"`
+	TotalOut        float64          `json:"total_out"`
+	TotalValueIn    float64          `json:"total_value_in"`
+	TotalValueOut   float64          `json:"total_value_out"`
+	ClosingQuantity float64          `json:"closing_quantity"`
+	ClosingValue    float64          `json:"closing_value"`
+}
+
+// Inventory Valuation as of a date
+type InventoryValuationLine struct {
+	ProductID   uint    `json:"product_id"`
+	ProductCode string  `json:"product_code"`
+	ProductName string  `json:"product_name"`
+	Category    string  `json:"category"`
+	Unit        string  `json:"unit"`
+	CostMethod  string  `json:"cost_method"`
+	Quantity    float64 `json:"quantity"`
+	AverageCost float64 `json:"average_cost"`
+	Value       float64 `json:"value"`
+}
+
+type InventoryValuation struct {
+	AsOfDate    string                   `json:"as_of_date"`
+	WarehouseID uint                     `json:"warehouse_id"`
+	Lines       []InventoryValuationLine `json:"lines"`
+	TotalValue  float64                  `json:"total_value"`
+}
+
+// Inventory Aging (slow-moving / dead stock)
+const (
+	StockAgeActive     = "active"
+	StockAgeSlowMoving = "slow_moving"
+	StockAgeDead       = "dead"
+)
+
+type InventoryAgingLine struct {
+	ProductID          uint    `json:"product_id"`
+	ProductCode        string  `json:"product_code"`
+	ProductName        string  `json:"product_name"`
+	Quantity           float64 `json:"quantity"`
+	Value              float64 `json:"value"`
+	Days0To30          float64 `json:"days_0_30"` // on-hand value by age of the receipt
+	Days31To60         float64 `json:"days_31_60"`
+	Days61To90         float64 `json:"days_61_90"`
+	Days91To180        float64 `json:"days_91_180"`
+	DaysOver180        float64 `json:"days_over_180"`
+	OldestReceiptDate  string  `json:"oldest_receipt_date"`
+	LastIssueDate      string  `json:"last_issue_date"`       // empty = never issued
+	DaysSinceLastIssue int     `json:"days_since_last_issue"` // since oldest receipt when never issued
+	Status             string  `json:"status"`
+}
+
+type InventoryAgingReport struct {
+	AsOfDate        string               `json:"as_of_date"`
+	SlowMovingDays  int                  `json:"slow_moving_days"`
+	DeadStockDays   int                  `json:"dead_stock_days"`
+	Lines           []InventoryAgingLine `json:"lines"`
+	TotalValue      float64              `json:"total_value"`
+	SlowMovingValue float64              `json:"slow_moving_value"`
+	DeadStockValue  float64              `json:"dead_stock_value"`
 }
\ No newline at end of file
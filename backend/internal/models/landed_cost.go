@@ -0,0 +1,67 @@
+package models
+
+import "time"
+
+type LandedCostMethod string
+
+const (
+	LandedCostByQuantity LandedCostMethod = "quantity"
+	LandedCostByValue    LandedCostMethod = "value"
+	LandedCostByWeight   LandedCostMethod = "weight"
+)
+
+type LandedCostStatus string
+
+const (
+	LandedCostStatusDraft     LandedCostStatus = "draft"
+	LandedCostStatusPosted    LandedCostStatus = "posted"
+	LandedCostStatusCancelled LandedCostStatus = "cancelled"
+)
+
+// Landed Cost document: freight, duty, insurance etc. added onto stock receipts
+type LandedCost struct {
+	BaseModel
+	CompanyID      uint               `gorm:"not null;index" json:"company_id"`
+	Company        Company            `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	DocumentNumber string             `gorm:"uniqueIndex;size:50;not null" json:"document_number"`
+	DocumentDate   time.Time          `gorm:"not null;index" json:"document_date"`
+	Method         LandedCostMethod   `gorm:"type:varchar(20);not null;default:'value'" json:"method"`
+	Status         LandedCostStatus   `gorm:"type:varchar(20);not null;default:'draft'" json:"status"`
+	TotalAmount    float64            `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	Reference      string             `gorm:"size:100" json:"reference"` // forwarder invoice, customs declaration (PIB)
+	Notes          string             `gorm:"type:text" json:"notes"`
+	JournalID      *uint              `gorm:"index" json:"journal_id"`
+	Journal        *Journal           `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
+	CreatedBy      uint               `gorm:"not null" json:"created_by"`
+	User           User               `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	PostedAt       *time.Time         `json:"posted_at"`
+	PostedBy       *uint              `json:"posted_by"`
+	Charges        []LandedCostCharge `gorm:"foreignKey:LandedCostID" json:"charges,omitempty"`
+	Items          []LandedCostItem   `gorm:"foreignKey:LandedCostID" json:"items,omitempty"`
+}
+
+type LandedCostCharge struct {
+	BaseModel
+	LandedCostID uint     `gorm:"not null;index" json:"landed_cost_id"`
+	ChargeType   string   `gorm:"size:50;not null" json:"charge_type"` // freight, customs_duty, insurance, other
+	Description  string   `gorm:"size:255" json:"description"`
+	Amount       float64  `gorm:"type:decimal(20,2);not null" json:"amount"`
+	AccountID    *uint    `json:"account_id"` // credited on posting; nil = purchase clearing
+	Account      *Account `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+}
+
+// Landed Cost line: one stock-in movement receiving a share of the charges
+type LandedCostItem struct {
+	BaseModel
+	LandedCostID      uint          `gorm:"not null;index" json:"landed_cost_id"`
+	MovementID        uint          `gorm:"not null;index" json:"movement_id"`
+	Movement          StockMovement `gorm:"foreignKey:MovementID" json:"movement,omitempty"`
+	ProductID         uint          `gorm:"not null;index" json:"product_id"`
+	Product           Product       `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Quantity          float64       `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Value             float64       `gorm:"type:decimal(20,2);not null" json:"value"`
+	Weight            float64       `gorm:"type:decimal(20,4);default:0" json:"weight"`
+	AllocatedAmount   float64       `gorm:"type:decimal(20,2);default:0" json:"allocated_amount"`
+	CapitalizedAmount float64       `gorm:"type:decimal(20,2);default:0" json:"capitalized_amount"` // added to stock still on hand
+	ExpensedAmount    float64       `gorm:"type:decimal(20,2);default:0" json:"expensed_amount"`    // share of units already issued, to COGS
+}
\ No newline at end of file
@@ -0,0 +1,124 @@
+package models
+
+import "time"
+
+type LoanRateType string
+
+const (
+	LoanRateTypeFixed    LoanRateType = "fixed"
+	LoanRateTypeFloating LoanRateType = "floating" // rate changes reschedule the unpaid installments
+)
+
+type LoanMethod string
+
+const (
+	LoanMethodAnnuity   LoanMethod = "annuity"   // anuitas, equal installments
+	LoanMethodFlat      LoanMethod = "flat"      // bunga flat on the original principal
+	LoanMethodEffective LoanMethod = "effective" // bunga efektif, equal principal with interest on the balance
+)
+
+type LoanStatus string
+
+const (
+	LoanStatusActive  LoanStatus = "active"
+	LoanStatusPaidOff LoanStatus = "paid_off"
+)
+
+// Loan is a bank loan in the register. The principal is carried in the long
+// term account, less the current portion that the period-end
+// reclassification moves to the current account.
+type Loan struct {
+	BaseModel
+	CompanyID             uint              `gorm:"not null;index" json:"company_id"`
+	Company               Company           `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	LoanNumber            string            `gorm:"uniqueIndex;size:50;not null" json:"loan_number"`
+	Lender                string            `gorm:"size:255;not null" json:"lender"`
+	AgreementNumber       string            `gorm:"size:100" json:"agreement_number"` // nomor perjanjian kredit
+	Description           string            `gorm:"type:text" json:"description"`
+	Principal             float64           `gorm:"type:decimal(20,2);not null" json:"principal"`
+	InterestRate          float64           `gorm:"type:decimal(8,4);not null" json:"interest_rate"` // annual %, current rate for floating loans
+	RateType              LoanRateType      `gorm:"type:varchar(20);not null" json:"rate_type"`
+	Method                LoanMethod        `gorm:"type:varchar(20);not null" json:"method"`
+	TenorMonths           int               `gorm:"not null" json:"tenor_months"`
+	StartDate             time.Time         `gorm:"not null;index" json:"start_date"`
+	FirstDueDate          time.Time         `gorm:"not null" json:"first_due_date"`
+	AccountID             uint              `gorm:"not null;index" json:"account_id"` // 2-2100 Utang Bank Jangka Panjang
+	Account               Account           `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	CurrentAccountID      uint              `gorm:"not null" json:"current_account_id"`  // bagian lancar
+	InterestAccountID     uint              `gorm:"not null" json:"interest_account_id"` // beban bunga
+	Outstanding           float64           `gorm:"type:decimal(20,2);default:0" json:"outstanding"`
+	CurrentPortion        float64           `gorm:"type:decimal(20,2);default:0" json:"current_portion"` // part of the outstanding held in the current account
+	ReminderDays          int               `gorm:"default:7" json:"reminder_days"`                      // days before a due date to notify
+	Status                LoanStatus        `gorm:"type:varchar(20);not null;default:'active';index" json:"status"`
+	CashBankTransactionID *uint             `json:"cash_bank_transaction_id"` // disbursement, nil when booked before the register
+	JournalID             *uint             `gorm:"index" json:"journal_id"`
+	Notes                 string            `gorm:"type:text" json:"notes"`
+	CreatedBy             uint              `gorm:"not null" json:"created_by"`
+	User                  User              `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Installments          []LoanInstallment `gorm:"foreignKey:LoanID" json:"installments,omitempty"`
+}
+
+// LoanInstallment is one line of the amortization schedule. Paid
+// installments are kept as they were; the unpaid ones are laid out again
+// when a floating rate changes.
+type LoanInstallment struct {
+	BaseModel
+	LoanID                uint       `gorm:"not null;index" json:"loan_id"`
+	Loan                  *Loan      `gorm:"foreignKey:LoanID" json:"loan,omitempty"`
+	InstallmentNumber     int        `gorm:"not null" json:"installment_number"`
+	DueDate               time.Time  `gorm:"not null;index" json:"due_date"`
+	InterestRate          float64    `gorm:"type:decimal(8,4);not null" json:"interest_rate"`
+	OpeningBalance        float64    `gorm:"type:decimal(20,2);default:0" json:"opening_balance"`
+	Principal             float64    `gorm:"type:decimal(20,2);default:0" json:"principal"`
+	Interest              float64    `gorm:"type:decimal(20,2);default:0" json:"interest"`
+	Amount                float64    `gorm:"type:decimal(20,2);default:0" json:"amount"`
+	ClosingBalance        float64    `gorm:"type:decimal(20,2);default:0" json:"closing_balance"`
+	IsPaid                bool       `gorm:"default:false" json:"is_paid"`
+	PaidDate              *time.Time `json:"paid_date"`
+	CashBankTransactionID *uint      `json:"cash_bank_transaction_id"`
+	JournalID             *uint      `gorm:"index" json:"journal_id"`
+}
+
+// LoanRateChange records a new rate of a floating loan from the date it
+// applies
+type LoanRateChange struct {
+	BaseModel
+	LoanID        uint      `gorm:"not null;index" json:"loan_id"`
+	EffectiveDate time.Time `gorm:"not null" json:"effective_date"`
+	PreviousRate  float64   `gorm:"type:decimal(8,4);not null" json:"previous_rate"`
+	NewRate       float64   `gorm:"type:decimal(8,4);not null" json:"new_rate"`
+	Notes         string    `gorm:"type:text" json:"notes"`
+	CreatedBy     uint      `gorm:"not null" json:"created_by"`
+	User          User      `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+}
+
+// Loan Reclassification moves the principal falling due within twelve
+// months of the period end to current liabilities, for all active loans in
+// a single journal
+type LoanReclassification struct {
+	BaseModel
+	CompanyID           uint                       `gorm:"not null;uniqueIndex:idx_loan_reclassification_period" json:"company_id"`
+	Company             Company                    `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Period              string                     `gorm:"size:7;not null;uniqueIndex:idx_loan_reclassification_period" json:"period"` // YYYY-MM
+	PeriodEnd           time.Time                  `gorm:"not null" json:"period_end"`
+	TotalCurrentPortion float64                    `gorm:"type:decimal(20,2);default:0" json:"total_current_portion"`
+	TotalAdjustment     float64                    `gorm:"type:decimal(20,2);default:0" json:"total_adjustment"`
+	JournalID           *uint                      `gorm:"index" json:"journal_id"`
+	Journal             *Journal                   `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
+	CreatedBy           uint                       `gorm:"not null" json:"created_by"`
+	User                User                       `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Lines               []LoanReclassificationLine `gorm:"foreignKey:ReclassificationID" json:"lines,omitempty"`
+}
+
+// LoanReclassificationLine is a loan's current portion before and after the
+// run; a positive amount moves principal to current liabilities
+type LoanReclassificationLine struct {
+	BaseModel
+	ReclassificationID     uint    `gorm:"not null;index" json:"reclassification_id"`
+	LoanID                 uint    `gorm:"not null;index" json:"loan_id"`
+	Loan                   Loan    `gorm:"foreignKey:LoanID" json:"loan,omitempty"`
+	Outstanding            float64 `gorm:"type:decimal(20,2);default:0" json:"outstanding"`
+	PreviousCurrentPortion float64 `gorm:"type:decimal(20,2);default:0" json:"previous_current_portion"`
+	CurrentPortion         float64 `gorm:"type:decimal(20,2);default:0" json:"current_portion"`
+	Amount                 float64 `gorm:"type:decimal(20,2);default:0" json:"amount"`
+}
\ No newline at end of file
@@ -10,6 +10,11 @@ const (
 	NotificationTypeLowCash      NotificationType = "low_cash"
 	NotificationTypeHighExpense  NotificationType = "high_expense"
 	NotificationTypeJournalDraft NotificationType = "journal_draft"
+	NotificationTypeLotExpiry    NotificationType = "lot_expiry"  // expiring within the warning days
+	NotificationTypeLotExpired   NotificationType = "lot_expired" // past its expiry date
+	NotificationTypeLowStock     NotificationType = "low_stock"
+	NotificationTypeDunning      NotificationType = "dunning"
+	NotificationTypeLoanDue      NotificationType = "loan_due"
 
 	NotificationStatusUnread NotificationStatus = "unread"
 	NotificationStatusRead   NotificationStatus = "read"
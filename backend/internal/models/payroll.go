@@ -0,0 +1,187 @@
+package models
+
+import "time"
+
+// PTKPStatus is the employee's marital status and number of dependents for
+// penghasilan tidak kena pajak, which also sets the PPh 21 TER category
+type PTKPStatus string
+
+const (
+	PTKPStatusTK0 PTKPStatus = "TK/0" // tidak kawin, tanpa tanggungan
+	PTKPStatusTK1 PTKPStatus = "TK/1"
+	PTKPStatusTK2 PTKPStatus = "TK/2"
+	PTKPStatusTK3 PTKPStatus = "TK/3"
+	PTKPStatusK0  PTKPStatus = "K/0" // kawin, tanpa tanggungan
+	PTKPStatusK1  PTKPStatus = "K/1"
+	PTKPStatusK2  PTKPStatus = "K/2"
+	PTKPStatusK3  PTKPStatus = "K/3"
+)
+
+type SalaryComponentType string
+
+const (
+	SalaryComponentAllowance SalaryComponentType = "allowance" // tunjangan
+	SalaryComponentDeduction SalaryComponentType = "deduction" // potongan
+)
+
+// SalaryComponent is an allowance or deduction paid to employees each month.
+// Allowances are expensed to the account, deductions are credited to it;
+// without an account both go to 5-1100 Beban Gaji.
+type SalaryComponent struct {
+	BaseModel
+	CompanyID uint                `gorm:"not null;uniqueIndex:idx_company_salary_component_code" json:"company_id"`
+	Company   Company             `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Code      string              `gorm:"uniqueIndex:idx_company_salary_component_code;size:50;not null" json:"code"`
+	Name      string              `gorm:"size:255;not null" json:"name"`
+	Type      SalaryComponentType `gorm:"type:varchar(20);not null" json:"type"`
+	IsTaxable bool                `json:"is_taxable"` // allowance counted in gross income for PPh 21
+	AccountID *uint               `json:"account_id"`
+	Account   *Account            `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	IsActive  bool                `gorm:"default:true" json:"is_active"`
+}
+
+// Employee on the payroll. Base salary and fixed allowances make up the wage
+// BPJS contributions are computed on.
+type Employee struct {
+	BaseModel
+	CompanyID         uint                      `gorm:"not null;uniqueIndex:idx_company_employee_code" json:"company_id"`
+	Company           Company                   `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Code              string                    `gorm:"uniqueIndex:idx_company_employee_code;size:50;not null" json:"code"`
+	Name              string                    `gorm:"size:255;not null" json:"name"`
+	NIK               string                    `gorm:"size:20" json:"nik"`
+	NPWP              string                    `gorm:"size:30" json:"npwp"`
+	Position          string                    `gorm:"size:100" json:"position"`
+	Department        string                    `gorm:"size:100;index" json:"department"`
+	JoinDate          time.Time                 `gorm:"not null" json:"join_date"`
+	EndDate           *time.Time                `json:"end_date"` // last day of employment
+	PTKPStatus        PTKPStatus                `gorm:"type:varchar(10);not null" json:"ptkp_status"`
+	BaseSalary        float64                   `gorm:"type:decimal(20,2);not null" json:"base_salary"`
+	HealthInsured     bool                      `json:"health_insured"`     // BPJS Kesehatan
+	EmploymentInsured bool                      `json:"employment_insured"` // BPJS Ketenagakerjaan JHT, JKK and JKM
+	PensionInsured    bool                      `json:"pension_insured"`    // BPJS Ketenagakerjaan JP
+	BankName          string                    `gorm:"size:100" json:"bank_name"`
+	BankAccountNumber string                    `gorm:"size:50" json:"bank_account_number"`
+	BankAccountName   string                    `gorm:"size:255" json:"bank_account_name"`
+	IsActive          bool                      `gorm:"default:true" json:"is_active"`
+	Notes             string                    `gorm:"type:text" json:"notes"`
+	Components        []EmployeeSalaryComponent `gorm:"foreignKey:EmployeeID" json:"components,omitempty"`
+}
+
+// EmployeeSalaryComponent is the monthly amount of a component for an employee
+type EmployeeSalaryComponent struct {
+	BaseModel
+	EmployeeID  uint            `gorm:"not null;index" json:"employee_id"`
+	ComponentID uint            `gorm:"not null;index" json:"component_id"`
+	Component   SalaryComponent `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+	Amount      float64         `gorm:"type:decimal(20,2);not null" json:"amount"`
+}
+
+// PayrollSetting holds the BPJS parameters that differ between companies or
+// change from year to year. Rates are percentages of the wage.
+type PayrollSetting struct {
+	BaseModel
+	CompanyID      uint    `gorm:"not null;uniqueIndex" json:"company_id"`
+	JKKRate        float64 `gorm:"type:decimal(5,2);not null" json:"jkk_rate"`          // jaminan kecelakaan kerja, 0.24 to 1.74 by work risk
+	HealthWageCap  float64 `gorm:"type:decimal(20,2);not null" json:"health_wage_cap"`  // BPJS Kesehatan wage ceiling
+	PensionWageCap float64 `gorm:"type:decimal(20,2);not null" json:"pension_wage_cap"` // jaminan pensiun wage ceiling
+}
+
+type PayrollRunStatus string
+
+const (
+	PayrollRunStatusDraft  PayrollRunStatus = "draft"
+	PayrollRunStatusPosted PayrollRunStatus = "posted"
+	PayrollRunStatusPaid   PayrollRunStatus = "paid"
+)
+
+// Payroll Run computes a month's payslips for all employees on the payroll.
+// Posting it accrues the salaries, BPJS contributions and PPh 21 in one
+// journal; paying it settles the net pay from a cash or bank account.
+type PayrollRun struct {
+	BaseModel
+	CompanyID                  uint             `gorm:"not null;uniqueIndex:idx_payroll_run_period" json:"company_id"`
+	Company                    Company          `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	RunNumber                  string           `gorm:"uniqueIndex;size:50;not null" json:"run_number"`
+	Period                     string           `gorm:"size:7;not null;uniqueIndex:idx_payroll_run_period" json:"period"` // YYYY-MM
+	PayDate                    time.Time        `gorm:"not null" json:"pay_date"`
+	Status                     PayrollRunStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	TotalEarnings              float64          `gorm:"type:decimal(20,2);default:0" json:"total_earnings"` // base salaries and allowances
+	TotalDeductions            float64          `gorm:"type:decimal(20,2);default:0" json:"total_deductions"`
+	TotalEmployerContributions float64          `gorm:"type:decimal(20,2);default:0" json:"total_employer_contributions"`
+	TotalEmployeeContributions float64          `gorm:"type:decimal(20,2);default:0" json:"total_employee_contributions"`
+	TotalGrossIncome           float64          `gorm:"type:decimal(20,2);default:0" json:"total_gross_income"` // penghasilan bruto for PPh 21
+	TotalPPh21                 float64          `gorm:"type:decimal(20,2);default:0" json:"total_pph21"`
+	TotalNetPay                float64          `gorm:"type:decimal(20,2);default:0" json:"total_net_pay"`
+	JournalID                  *uint            `gorm:"index" json:"journal_id"`
+	Journal                    *Journal         `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
+	CashBankTransactionID      *uint            `json:"cash_bank_transaction_id"`
+	PaymentJournalID           *uint            `json:"payment_journal_id"`
+	Notes                      string           `gorm:"type:text" json:"notes"`
+	CreatedBy                  uint             `gorm:"not null" json:"created_by"`
+	User                       User             `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Payslips                   []Payslip        `gorm:"foreignKey:RunID" json:"payslips,omitempty"`
+}
+
+// Payslip is an employee's pay for the month. Gross income is what PPh 21 is
+// withheld on: earnings that are taxable plus the JKK, JKM and BPJS
+// Kesehatan premiums the employer pays. PPh 21 uses the TER rate of the
+// month, except in December or the last month of employment, which take the
+// Pasal 17 tax on the year less what was withheld before.
+type Payslip struct {
+	BaseModel
+	RunID               uint          `gorm:"not null;index" json:"run_id"`
+	EmployeeID          uint          `gorm:"not null;index" json:"employee_id"`
+	Employee            Employee      `gorm:"foreignKey:EmployeeID" json:"employee,omitempty"`
+	Period              string        `gorm:"size:7;not null;index" json:"period"` // YYYY-MM
+	PTKPStatus          PTKPStatus    `gorm:"type:varchar(10);not null" json:"ptkp_status"`
+	BaseSalary          float64       `gorm:"type:decimal(20,2);default:0" json:"base_salary"`
+	Allowances          float64       `gorm:"type:decimal(20,2);default:0" json:"allowances"`
+	Deductions          float64       `gorm:"type:decimal(20,2);default:0" json:"deductions"`
+	HealthEmployer      float64       `gorm:"type:decimal(20,2);default:0" json:"health_employer"` // BPJS Kesehatan 4%
+	HealthEmployee      float64       `gorm:"type:decimal(20,2);default:0" json:"health_employee"` // BPJS Kesehatan 1%
+	JHTEmployer         float64       `gorm:"type:decimal(20,2);default:0" json:"jht_employer"`    // jaminan hari tua 3.7%
+	JHTEmployee         float64       `gorm:"type:decimal(20,2);default:0" json:"jht_employee"`    // jaminan hari tua 2%
+	JKKEmployer         float64       `gorm:"type:decimal(20,2);default:0" json:"jkk_employer"`    // jaminan kecelakaan kerja
+	JKMEmployer         float64       `gorm:"type:decimal(20,2);default:0" json:"jkm_employer"`    // jaminan kematian 0.3%
+	JPEmployer          float64       `gorm:"type:decimal(20,2);default:0" json:"jp_employer"`     // jaminan pensiun 2%
+	JPEmployee          float64       `gorm:"type:decimal(20,2);default:0" json:"jp_employee"`     // jaminan pensiun 1%
+	GrossIncome         float64       `gorm:"type:decimal(20,2);default:0" json:"gross_income"`
+	TERCategory         string        `gorm:"size:1" json:"ter_category"`
+	TERRate             float64       `gorm:"type:decimal(5,2);default:0" json:"ter_rate"`
+	IsAnnualCalculation bool          `gorm:"default:false" json:"is_annual_calculation"`
+	PPh21               float64       `gorm:"type:decimal(20,2);default:0" json:"pph21"` // negative when the year was over-withheld
+	NetPay              float64       `gorm:"type:decimal(20,2);default:0" json:"net_pay"`
+	Lines               []PayslipLine `gorm:"foreignKey:PayslipID" json:"lines,omitempty"`
+}
+
+// EmployerContributions is the BPJS the company pays on top of the salary
+func (p *Payslip) EmployerContributions() float64 {
+	return p.HealthEmployer + p.JHTEmployer + p.JKKEmployer + p.JKMEmployer + p.JPEmployer
+}
+
+// EmployeeContributions is the BPJS withheld from the employee's pay
+func (p *Payslip) EmployeeContributions() float64 {
+	return p.HealthEmployee + p.JHTEmployee + p.JPEmployee
+}
+
+// PayslipLine is an allowance or deduction as it was paid
+type PayslipLine struct {
+	BaseModel
+	PayslipID   uint                `gorm:"not null;index" json:"payslip_id"`
+	ComponentID uint                `gorm:"not null" json:"component_id"`
+	Code        string              `gorm:"size:50" json:"code"`
+	Name        string              `gorm:"size:255" json:"name"`
+	Type        SalaryComponentType `gorm:"type:varchar(20);not null" json:"type"`
+	IsTaxable   bool                `json:"is_taxable"`
+	AccountID   uint                `gorm:"not null" json:"account_id"`
+	Amount      float64             `gorm:"type:decimal(20,2);not null" json:"amount"`
+}
+
+// PayrollYearToDate is what an employee's posted payslips of the year add up
+// to before the month being computed
+type PayrollYearToDate struct {
+	Months               int     `json:"months"`
+	GrossIncome          float64 `json:"gross_income"`
+	PensionContributions float64 `json:"pension_contributions"` // JHT and JP paid by the employee
+	PPh21                float64 `json:"pph21"`
+}
\ No newline at end of file
@@ -0,0 +1,227 @@
+package models
+
+import "time"
+
+type PurchaseRequisitionStatus string
+
+const (
+	PurchaseRequisitionStatusDraft     PurchaseRequisitionStatus = "draft"
+	PurchaseRequisitionStatusApproved  PurchaseRequisitionStatus = "approved"
+	PurchaseRequisitionStatusRejected  PurchaseRequisitionStatus = "rejected"
+	PurchaseRequisitionStatusOrdered   PurchaseRequisitionStatus = "ordered" // every line fully on purchase orders
+	PurchaseRequisitionStatusCancelled PurchaseRequisitionStatus = "cancelled"
+)
+
+// Purchase Requisition: an internal request for goods, ordered through
+// purchase orders once approved
+type PurchaseRequisition struct {
+	BaseModel
+	CompanyID         uint                      `gorm:"not null;index" json:"company_id"`
+	Company           Company                   `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	RequisitionNumber string                    `gorm:"uniqueIndex;size:50;not null" json:"requisition_number"`
+	RequestDate       time.Time                 `gorm:"not null;index" json:"request_date"`
+	RequiredDate      *time.Time                `json:"required_date"`
+	Department        string                    `gorm:"size:100" json:"department"`
+	Status            PurchaseRequisitionStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Notes             string                    `gorm:"type:text" json:"notes"`
+	CreatedBy         uint                      `gorm:"not null" json:"created_by"`
+	User              User                      `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	ApprovedAt        *time.Time                `json:"approved_at"`
+	ApprovedBy        *uint                     `json:"approved_by"`
+	Items             []PurchaseRequisitionItem `gorm:"foreignKey:RequisitionID" json:"items,omitempty"`
+}
+
+type PurchaseRequisitionItem struct {
+	BaseModel
+	RequisitionID   uint    `gorm:"not null;index" json:"requisition_id"`
+	ProductID       uint    `gorm:"not null;index" json:"product_id"`
+	Product         Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description     string  `gorm:"size:255" json:"description"`
+	Quantity        float64 `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Unit            string  `gorm:"size:50" json:"unit"`
+	OrderedQuantity float64 `gorm:"type:decimal(20,2);default:0" json:"ordered_quantity"` // on purchase orders that are not cancelled
+}
+
+type PurchaseOrderStatus string
+
+const (
+	PurchaseOrderStatusDraft             PurchaseOrderStatus = "draft"
+	PurchaseOrderStatusOpen              PurchaseOrderStatus = "open" // approved and sent to the vendor
+	PurchaseOrderStatusPartiallyReceived PurchaseOrderStatus = "partially_received"
+	PurchaseOrderStatusReceived          PurchaseOrderStatus = "received"
+	PurchaseOrderStatusClosed            PurchaseOrderStatus = "closed" // short-closed, the rest will not be delivered
+	PurchaseOrderStatusCancelled         PurchaseOrderStatus = "cancelled"
+)
+
+// Purchase Order for stock items, received through goods receipts at the
+// ordered price and billed by the vendor against those receipts
+type PurchaseOrder struct {
+	BaseModel
+	CompanyID    uint                `gorm:"not null;index" json:"company_id"`
+	Company      Company             `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	OrderNumber  string              `gorm:"uniqueIndex;size:50;not null" json:"order_number"`
+	OrderDate    time.Time           `gorm:"not null;index" json:"order_date"`
+	ExpectedDate *time.Time          `json:"expected_date"`
+	VendorID     uint                `gorm:"not null;index" json:"vendor_id"`
+	Vendor       Vendor              `gorm:"foreignKey:VendorID" json:"vendor,omitempty"`
+	WarehouseID  uint                `gorm:"not null;default:0" json:"warehouse_id"` // delivered to, 0 = default warehouse
+	Status       PurchaseOrderStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Subtotal     float64             `gorm:"type:decimal(20,2);default:0" json:"subtotal"` // after line discounts
+	TaxRate      float64             `gorm:"type:decimal(5,2);default:0" json:"tax_rate"`  // PPN percentage
+	TaxAmount    float64             `gorm:"type:decimal(20,2);default:0" json:"tax_amount"`
+	TotalAmount  float64             `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	Reference    string              `gorm:"size:100" json:"reference"` // vendor quotation
+	Notes        string              `gorm:"type:text" json:"notes"`
+	CreatedBy    uint                `gorm:"not null" json:"created_by"`
+	User         User                `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	ApprovedAt   *time.Time          `json:"approved_at"`
+	ApprovedBy   *uint               `json:"approved_by"`
+	Items        []PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderID" json:"items,omitempty"`
+}
+
+type PurchaseOrderItem struct {
+	BaseModel
+	PurchaseOrderID   uint    `gorm:"not null;index" json:"purchase_order_id"`
+	RequisitionItemID *uint   `gorm:"index" json:"requisition_item_id"`
+	ProductID         uint    `gorm:"not null;index" json:"product_id"`
+	Product           Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description       string  `gorm:"size:255" json:"description"`
+	Quantity          float64 `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Unit              string  `gorm:"size:50" json:"unit"` // alternate unit of quantity & price
+	UnitPrice         float64 `gorm:"type:decimal(20,2);not null" json:"unit_price"`
+	DiscountPercent   float64 `gorm:"type:decimal(5,2);default:0" json:"discount_percent"`
+	DiscountAmount    float64 `gorm:"type:decimal(20,2);default:0" json:"discount_amount"`
+	Subtotal          float64 `gorm:"type:decimal(20,2);default:0" json:"subtotal"`
+	ReceivedQuantity  float64 `gorm:"type:decimal(20,2);default:0" json:"received_quantity"`
+	BilledQuantity    float64 `gorm:"type:decimal(20,2);default:0" json:"billed_quantity"`
+}
+
+// NetUnitPrice is the ordered price of one unit after the line discount
+func (i *PurchaseOrderItem) NetUnitPrice() float64 {
+	if i.Quantity == 0 {
+		return 0
+	}
+	return i.Subtotal / i.Quantity
+}
+
+type GoodsReceiptStatus string
+
+const (
+	GoodsReceiptStatusDraft     GoodsReceiptStatus = "draft"
+	GoodsReceiptStatusPosted    GoodsReceiptStatus = "posted"
+	GoodsReceiptStatusCancelled GoodsReceiptStatus = "cancelled"
+)
+
+// Goods Receipt (GRN) against a purchase order: posting receives the goods
+// into stock at the ordered price (Dr inventory / Cr purchase clearing). The
+// clearing balance is the received-not-billed accrual until the vendor's
+// bill is matched to the receipt.
+type GoodsReceipt struct {
+	BaseModel
+	CompanyID       uint               `gorm:"not null;index" json:"company_id"`
+	Company         Company            `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	ReceiptNumber   string             `gorm:"uniqueIndex;size:50;not null" json:"receipt_number"`
+	ReceiptDate     time.Time          `gorm:"not null;index" json:"receipt_date"`
+	PurchaseOrderID uint               `gorm:"not null;index" json:"purchase_order_id"`
+	PurchaseOrder   PurchaseOrder      `gorm:"foreignKey:PurchaseOrderID" json:"purchase_order,omitempty"`
+	VendorID        uint               `gorm:"not null;index" json:"vendor_id"`
+	Vendor          Vendor             `gorm:"foreignKey:VendorID" json:"vendor,omitempty"`
+	WarehouseID     uint               `gorm:"not null;default:0" json:"warehouse_id"`
+	Status          GoodsReceiptStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Reference       string             `gorm:"size:100" json:"reference"` // vendor's delivery note (surat jalan)
+	Notes           string             `gorm:"type:text" json:"notes"`
+	CreatedBy       uint               `gorm:"not null" json:"created_by"`
+	User            User               `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	PostedAt        *time.Time         `json:"posted_at"`
+	PostedBy        *uint              `json:"posted_by"`
+	Items           []GoodsReceiptItem `gorm:"foreignKey:ReceiptID" json:"items,omitempty"`
+}
+
+// Goods Receipt line in the unit of the purchase order line
+type GoodsReceiptItem struct {
+	BaseModel
+	ReceiptID           uint              `gorm:"not null;index" json:"receipt_id"`
+	Receipt             *GoodsReceipt     `gorm:"foreignKey:ReceiptID" json:"receipt,omitempty"`
+	PurchaseOrderItemID uint              `gorm:"not null;index" json:"purchase_order_item_id"`
+	PurchaseOrderItem   PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderItemID" json:"purchase_order_item,omitempty"`
+	ProductID           uint              `gorm:"not null;index" json:"product_id"`
+	Product             Product           `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description         string            `gorm:"size:255" json:"description"`
+	Quantity            float64           `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Unit                string            `gorm:"size:50" json:"unit"`
+	UnitPrice           float64           `gorm:"type:decimal(20,2);not null" json:"unit_price"` // net ordered price
+	LotNumber           string            `gorm:"size:100" json:"lot_number"`
+	ExpiryDate          *time.Time        `json:"expiry_date"`
+	SerialNumbers       []string          `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"`
+	MovementID          *uint             `json:"movement_id"` // stock-in posted with the receipt
+	CostAmount          float64           `gorm:"type:decimal(20,2);default:0" json:"cost_amount"`
+	BilledQuantity      float64           `gorm:"type:decimal(20,2);default:0" json:"billed_quantity"`
+}
+
+// Tolerances for matching a bill line to its purchase order and goods
+// receipt; a bill outside them cannot be posted until the mismatch is approved
+type PurchaseMatchTolerance struct {
+	BaseModel
+	CompanyID       uint    `gorm:"not null;uniqueIndex" json:"company_id"`
+	QuantityPercent float64 `gorm:"type:decimal(5,2);default:0" json:"quantity_percent"` // billed over received-not-billed
+	PricePercent    float64 `gorm:"type:decimal(5,2);default:0" json:"price_percent"`    // billed over or under the ordered price
+}
+
+// Open purchase order line: ordered but not yet received
+type OpenPurchaseOrderLine struct {
+	PurchaseOrderID   uint       `json:"purchase_order_id"`
+	OrderNumber       string     `json:"order_number"`
+	OrderDate         time.Time  `json:"order_date"`
+	ExpectedDate      *time.Time `json:"expected_date"`
+	VendorID          uint       `json:"vendor_id"`
+	VendorCode        string     `json:"vendor_code"`
+	VendorName        string     `json:"vendor_name"`
+	ItemID            uint       `json:"item_id"`
+	ProductCode       string     `json:"product_code"`
+	Description       string     `json:"description"`
+	Unit              string     `json:"unit"`
+	OrderedQuantity   float64    `json:"ordered_quantity"`
+	ReceivedQuantity  float64    `json:"received_quantity"`
+	RemainingQuantity float64    `json:"remaining_quantity"`
+	UnitPrice         float64    `json:"unit_price"` // net of the line discount
+	RemainingAmount   float64    `json:"remaining_amount"`
+	IsOverdue         bool       `json:"is_overdue"` // expected date has passed
+}
+
+type OpenPurchaseOrderReport struct {
+	AsOfDate    string                  `json:"as_of_date"`
+	Lines       []OpenPurchaseOrderLine `json:"lines"`
+	TotalAmount float64                 `json:"total_amount"`
+}
+
+// Received-not-billed line: goods receipt quantity with no matched bill
+type GRNILine struct {
+	ReceiptID        uint      `json:"receipt_id"`
+	ReceiptNumber    string    `json:"receipt_number"`
+	ReceiptDate      time.Time `json:"receipt_date"`
+	OrderNumber      string    `json:"order_number"`
+	VendorID         uint      `json:"vendor_id"`
+	VendorCode       string    `json:"vendor_code"`
+	VendorName       string    `json:"vendor_name"`
+	ItemID           uint      `json:"item_id"`
+	ProductCode      string    `json:"product_code"`
+	Description      string    `json:"description"`
+	Unit             string    `json:"unit"`
+	ReceivedQuantity float64   `json:"received_quantity"`
+	BilledQuantity   float64   `json:"billed_quantity"`
+	UnbilledQuantity float64   `json:"unbilled_quantity"`
+	UnitCost         float64   `json:"unit_cost"`
+	ReceivedCost     float64   `json:"received_cost"`
+	AccruedAmount    float64   `json:"accrued_amount"`
+}
+
+// GRNI accrual report reconciled to the purchase clearing account
+type GRNIReport struct {
+	AsOfDate           string     `json:"as_of_date"`
+	Lines              []GRNILine `json:"lines"`
+	Total              float64    `json:"total"`
+	ControlAccountCode string     `json:"control_account_code"`
+	ControlAccountName string     `json:"control_account_name"`
+	GLBalance          float64    `json:"gl_balance"` // credit balance of the clearing account
+	Difference         float64    `json:"difference"` // e.g. landed costs and rounding left in clearing
+}
\ No newline at end of file
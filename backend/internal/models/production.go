@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+type ProductionStatus string
+
+const (
+	ProductionStatusDraft      ProductionStatus = "draft"
+	ProductionStatusInProgress ProductionStatus = "in_progress" // components issued to WIP
+	ProductionStatusCompleted  ProductionStatus = "completed"
+	ProductionStatusCancelled  ProductionStatus = "cancelled"
+)
+
+// Bill of Materials line: component quantity needed for one base unit of
+// the finished product
+type BOMItem struct {
+	BaseModel
+	ProductID   uint    `gorm:"uniqueIndex:idx_bom_product_component;not null" json:"product_id"`
+	ComponentID uint    `gorm:"uniqueIndex:idx_bom_product_component;not null" json:"component_id"`
+	Component   Product `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+	Quantity    float64 `gorm:"type:decimal(20,4);not null" json:"quantity"` // component base unit
+	Notes       string  `gorm:"type:text" json:"notes"`
+}
+
+// BOM cost roll-up at current component average cost
+type BOMCost struct {
+	ProductID    uint          `json:"product_id"`
+	Quantity     float64       `json:"quantity"`
+	Lines        []BOMCostLine `json:"lines"`
+	MaterialCost float64       `json:"material_cost"`
+	UnitCost     float64       `json:"unit_cost"`
+}
+
+type BOMCostLine struct {
+	ComponentID   uint    `json:"component_id"`
+	ComponentCode string  `json:"component_code"`
+	ComponentName string  `json:"component_name"`
+	Quantity      float64 `json:"quantity"`
+	UnitCost      float64 `json:"unit_cost"`
+	TotalCost     float64 `json:"total_cost"`
+	Available     float64 `json:"available"`
+}
+
+// Production / Assembly Order
+type ProductionOrder struct {
+	BaseModel
+	CompanyID        uint                  `gorm:"not null;index" json:"company_id"`
+	Company          Company               `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	OrderNumber      string                `gorm:"uniqueIndex;size:50;not null" json:"order_number"`
+	OrderDate        time.Time             `gorm:"not null;index" json:"order_date"`
+	ProductID        uint                  `gorm:"not null;index" json:"product_id"`
+	Product          Product               `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	WarehouseID      uint                  `gorm:"not null;index" json:"warehouse_id"` // components issued from and output received into
+	Warehouse        Warehouse             `gorm:"foreignKey:WarehouseID" json:"warehouse,omitempty"`
+	Quantity         float64               `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Status           ProductionStatus      `gorm:"type:varchar(20);not null;default:'draft'" json:"status"`
+	MaterialCost     float64               `gorm:"type:decimal(20,2);default:0" json:"material_cost"`
+	LaborCost        float64               `gorm:"type:decimal(20,2);default:0" json:"labor_cost"`
+	OverheadCost     float64               `gorm:"type:decimal(20,2);default:0" json:"overhead_cost"`
+	TotalCost        float64               `gorm:"type:decimal(20,2);default:0" json:"total_cost"`
+	UnitCost         float64               `gorm:"type:decimal(20,6);default:0" json:"unit_cost"`
+	LotNumber        string                `gorm:"size:100" json:"lot_number"` // output lot of lot-tracked products
+	ExpiryDate       *time.Time            `json:"expiry_date"`
+	SerialNumbers    []string              `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"` // output units of serial-tracked products
+	OutputMovementID *uint                 `json:"output_movement_id"`
+	CostJournalID    *uint                 `json:"cost_journal_id"` // labor and overhead applied to WIP
+	Notes            string                `gorm:"type:text" json:"notes"`
+	CreatedBy        uint                  `gorm:"not null" json:"created_by"`
+	User             User                  `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	StartedAt        *time.Time            `json:"started_at"`
+	CompletedAt      *time.Time            `json:"completed_at"`
+	CompletedBy      *uint                 `json:"completed_by"`
+	Items            []ProductionOrderItem `gorm:"foreignKey:OrderID" json:"items,omitempty"`
+}
+
+type ProductionOrderItem struct {
+	BaseModel
+	OrderID       uint     `gorm:"not null;index" json:"order_id"`
+	ComponentID   uint     `gorm:"not null;index" json:"component_id"`
+	Component     Product  `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+	Quantity      float64  `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	TotalCost     float64  `gorm:"type:decimal(20,2);default:0" json:"total_cost"`
+	SerialNumbers []string `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"` // units consumed of serial-tracked components
+	MovementID    *uint    `json:"movement_id"`
+}
\ No newline at end of file
@@ -0,0 +1,272 @@
+package models
+
+import "time"
+
+// Vendor master
+type Vendor struct {
+	BaseModel
+	CompanyID         uint    `gorm:"not null;uniqueIndex:idx_company_vendor_code" json:"company_id"`
+	Company           Company `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Code              string  `gorm:"uniqueIndex:idx_company_vendor_code;size:50;not null" json:"code"`
+	Name              string  `gorm:"size:255;not null" json:"name"`
+	NPWP              string  `gorm:"size:30" json:"npwp"`
+	Address           string  `gorm:"type:text" json:"address"`
+	Phone             string  `gorm:"size:50" json:"phone"`
+	Email             string  `gorm:"size:100" json:"email"`
+	ContactPerson     string  `gorm:"size:100" json:"contact_person"`
+	PaymentTermDays   int     `gorm:"default:30" json:"payment_term_days"`
+	BankName          string  `gorm:"size:100" json:"bank_name"`
+	BankAccountNumber string  `gorm:"size:50" json:"bank_account_number"`
+	BankAccountName   string  `gorm:"size:255" json:"bank_account_name"`
+	IsActive          bool    `gorm:"default:true" json:"is_active"`
+	Notes             string  `gorm:"type:text" json:"notes"`
+}
+
+type PurchaseBillStatus string
+
+const (
+	PurchaseBillStatusDraft         PurchaseBillStatus = "draft"
+	PurchaseBillStatusPosted        PurchaseBillStatus = "posted"
+	PurchaseBillStatusPartiallyPaid PurchaseBillStatus = "partially_paid"
+	PurchaseBillStatusPaid          PurchaseBillStatus = "paid"
+	PurchaseBillStatusCancelled     PurchaseBillStatus = "cancelled"
+)
+
+// PurchaseMatchStatus is the outcome of matching a bill's lines to purchase
+// orders and goods receipts; empty when no line references a receipt
+type PurchaseMatchStatus string
+
+const (
+	PurchaseMatchStatusMatched  PurchaseMatchStatus = "matched"
+	PurchaseMatchStatusMismatch PurchaseMatchStatus = "mismatch" // outside tolerance, blocks posting
+	PurchaseMatchStatusApproved PurchaseMatchStatus = "approved" // mismatch accepted for posting
+)
+
+// Purchase Bill: posting receives the stock lines and records the payable
+type PurchaseBill struct {
+	BaseModel
+	CompanyID         uint                `gorm:"not null;index" json:"company_id"`
+	Company           Company             `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	BillNumber        string              `gorm:"uniqueIndex;size:50;not null" json:"bill_number"`
+	VendorInvoiceNo   string              `gorm:"size:100" json:"vendor_invoice_no"`
+	BillDate          time.Time           `gorm:"not null;index" json:"bill_date"`
+	DueDate           time.Time           `gorm:"not null;index" json:"due_date"`
+	VendorID          uint                `gorm:"not null;index" json:"vendor_id"`
+	Vendor            Vendor              `gorm:"foreignKey:VendorID" json:"vendor,omitempty"`
+	WarehouseID       uint                `gorm:"not null;default:0" json:"warehouse_id"` // stock lines received into, 0 = default warehouse
+	Status            PurchaseBillStatus  `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Subtotal          float64             `gorm:"type:decimal(20,2);default:0" json:"subtotal"` // after line discounts
+	TaxRate           float64             `gorm:"type:decimal(5,2);default:0" json:"tax_rate"`  // PPN percentage
+	TaxAmount         float64             `gorm:"type:decimal(20,2);default:0" json:"tax_amount"`
+	TotalAmount       float64             `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	WithholdingType   TaxType             `gorm:"type:varchar(20)" json:"withholding_type"` // pph23, pph4ayat2; empty = none
+	WithholdingRate   float64             `gorm:"type:decimal(5,2);default:0" json:"withholding_rate"`
+	WithholdingAmount float64             `gorm:"type:decimal(20,2);default:0" json:"withholding_amount"` // on the withholdable lines, before PPN
+	PayableAmount     float64             `gorm:"type:decimal(20,2);default:0" json:"payable_amount"`     // total less withholding, owed to the vendor
+	PaidAmount        float64             `gorm:"type:decimal(20,2);default:0" json:"paid_amount"`
+	DebitedAmount     float64             `gorm:"type:decimal(20,2);default:0" json:"debited_amount"` // payable reduced by posted debit notes
+	TaxInvoiceNumber  string              `gorm:"size:50" json:"tax_invoice_number"`                  // vendor's faktur pajak
+	Notes             string              `gorm:"type:text" json:"notes"`
+	MatchStatus       PurchaseMatchStatus `gorm:"type:varchar(20);index" json:"match_status"`
+	MatchNotes        string              `gorm:"type:text" json:"match_notes"` // discrepancies found by the last match
+	MatchApprovedAt   *time.Time          `json:"match_approved_at"`
+	MatchApprovedBy   *uint               `json:"match_approved_by"`
+	JournalID         *uint               `gorm:"index" json:"journal_id"`
+	Journal           *Journal            `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
+	CreatedBy         uint                `gorm:"not null" json:"created_by"`
+	User              User                `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	PostedAt          *time.Time          `json:"posted_at"`
+	PostedBy          *uint               `json:"posted_by"`
+	Items             []PurchaseBillItem  `gorm:"foreignKey:BillID" json:"items,omitempty"`
+}
+
+// Outstanding is the amount still to be paid to the vendor
+func (b *PurchaseBill) Outstanding() float64 {
+	return b.PayableAmount - b.PaidAmount - b.DebitedAmount
+}
+
+// Purchase Bill line: a stock item (ProductID) or an expense (AccountID).
+// A stock line billing a goods receipt line (GoodsReceiptItemID) was received
+// by the receipt and is matched to it and its purchase order.
+type PurchaseBillItem struct {
+	BaseModel
+	BillID             uint              `gorm:"not null;index" json:"bill_id"`
+	GoodsReceiptItemID *uint             `gorm:"index" json:"goods_receipt_item_id"`
+	GoodsReceiptItem   *GoodsReceiptItem `gorm:"foreignKey:GoodsReceiptItemID" json:"goods_receipt_item,omitempty"`
+	ProductID          *uint             `gorm:"index" json:"product_id"`
+	Product            *Product          `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	AccountID          *uint             `json:"account_id"`
+	Account            *Account          `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Description        string            `gorm:"size:255" json:"description"`
+	Quantity           float64           `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Unit               string            `gorm:"size:50" json:"unit"` // stock lines: alternate unit of quantity & price
+	UnitPrice          float64           `gorm:"type:decimal(20,2);not null" json:"unit_price"`
+	DiscountPercent    float64           `gorm:"type:decimal(5,2);default:0" json:"discount_percent"`
+	DiscountAmount     float64           `gorm:"type:decimal(20,2);default:0" json:"discount_amount"`
+	Subtotal           float64           `gorm:"type:decimal(20,2);default:0" json:"subtotal"`
+	IsWithholdable     bool              `gorm:"default:false" json:"is_withholdable"` // service subject to the bill's withholding
+	LotNumber          string            `gorm:"size:100" json:"lot_number"`
+	ExpiryDate         *time.Time        `json:"expiry_date"`
+	SerialNumbers      []string          `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"`
+	MovementID         *uint             `json:"movement_id"` // stock-in posted with the bill, or of the goods receipt
+	CostAmount         float64           `gorm:"type:decimal(20,2);default:0" json:"cost_amount"`
+}
+
+// Vendor Payment made from cash/bank and applied to bills
+type VendorPayment struct {
+	BaseModel
+	CompanyID             uint                      `gorm:"not null;index" json:"company_id"`
+	Company               Company                   `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	PaymentNumber         string                    `gorm:"uniqueIndex;size:50;not null" json:"payment_number"`
+	PaymentDate           time.Time                 `gorm:"not null;index" json:"payment_date"`
+	VendorID              uint                      `gorm:"not null;index" json:"vendor_id"`
+	Vendor                Vendor                    `gorm:"foreignKey:VendorID" json:"vendor,omitempty"`
+	AccountID             uint                      `gorm:"not null" json:"account_id"` // cash or bank account paid from
+	Account               Account                   `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Amount                float64                   `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Reference             string                    `gorm:"size:100" json:"reference"`
+	Notes                 string                    `gorm:"type:text" json:"notes"`
+	PaymentRunID          *uint                     `gorm:"index" json:"payment_run_id"`
+	CashBankTransactionID *uint                     `json:"cash_bank_transaction_id"`
+	JournalID             *uint                     `gorm:"index" json:"journal_id"`
+	CreatedBy             uint                      `gorm:"not null" json:"created_by"`
+	User                  User                      `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Allocations           []VendorPaymentAllocation `gorm:"foreignKey:PaymentID" json:"allocations,omitempty"`
+}
+
+type VendorPaymentAllocation struct {
+	BaseModel
+	PaymentID uint         `gorm:"not null;index" json:"payment_id"`
+	BillID    uint         `gorm:"not null;index" json:"bill_id"`
+	Bill      PurchaseBill `gorm:"foreignKey:BillID" json:"bill,omitempty"`
+	Amount    float64      `gorm:"type:decimal(20,2);not null" json:"amount"`
+}
+
+type DebitNoteStatus string
+
+const (
+	DebitNoteStatusDraft     DebitNoteStatus = "draft"
+	DebitNoteStatusPosted    DebitNoteStatus = "posted"
+	DebitNoteStatusCancelled DebitNoteStatus = "cancelled"
+)
+
+// Purchase Debit Note: a return or price correction against a posted bill.
+// Posting reverses the expense or stock cost, PPN Masukan and withholding,
+// sends returned goods back out of stock at their original cost and reduces
+// the bill's open balance.
+type PurchaseDebitNote struct {
+	BaseModel
+	CompanyID         uint                           `gorm:"not null;index" json:"company_id"`
+	Company           Company                        `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	DebitNoteNumber   string                         `gorm:"uniqueIndex;size:50;not null" json:"debit_note_number"`
+	DebitNoteDate     time.Time                      `gorm:"not null;index" json:"debit_note_date"`
+	BillID            uint                           `gorm:"not null;index" json:"bill_id"`
+	Bill              PurchaseBill                   `gorm:"foreignKey:BillID" json:"bill,omitempty"`
+	VendorID          uint                           `gorm:"not null;index" json:"vendor_id"`
+	Vendor            Vendor                         `gorm:"foreignKey:VendorID" json:"vendor,omitempty"`
+	Status            DebitNoteStatus                `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Reason            string                         `gorm:"size:255" json:"reason"`
+	Subtotal          float64                        `gorm:"type:decimal(20,2);default:0" json:"subtotal"` // DPP debited
+	TaxRate           float64                        `gorm:"type:decimal(5,2);default:0" json:"tax_rate"`  // PPN percentage of the bill
+	TaxAmount         float64                        `gorm:"type:decimal(20,2);default:0" json:"tax_amount"`
+	TotalAmount       float64                        `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	WithholdingAmount float64                        `gorm:"type:decimal(20,2);default:0" json:"withholding_amount"` // withholding reversed on the withholdable lines
+	PayableAmount     float64                        `gorm:"type:decimal(20,2);default:0" json:"payable_amount"`     // reduction of what is owed to the vendor
+	UnappliedAmount   float64                        `gorm:"type:decimal(20,2);default:0" json:"unapplied_amount"`   // beyond the bill's open balance, held as vendor credit
+	CreditUsedAmount  float64                        `gorm:"type:decimal(20,2);default:0" json:"credit_used_amount"` // vendor credit since applied to other bills or refunded
+	Reference         string                         `gorm:"size:100" json:"reference"`                              // vendor's credit note
+	TaxInvoiceNumber  string                         `gorm:"size:50" json:"tax_invoice_number"`                      // nomor nota retur
+	Notes             string                         `gorm:"type:text" json:"notes"`
+	JournalID         *uint                          `gorm:"index" json:"journal_id"`
+	CreatedBy         uint                           `gorm:"not null" json:"created_by"`
+	User              User                           `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	PostedAt          *time.Time                     `json:"posted_at"`
+	PostedBy          *uint                          `json:"posted_by"`
+	Items             []PurchaseDebitNoteItem        `gorm:"foreignKey:DebitNoteID" json:"items,omitempty"`
+	Applications      []PurchaseDebitNoteApplication `gorm:"foreignKey:DebitNoteID" json:"applications,omitempty"`
+}
+
+// AppliedAmount is the part of the debit note that reduced its own bill
+func (n *PurchaseDebitNote) AppliedAmount() float64 {
+	return n.PayableAmount - n.UnappliedAmount
+}
+
+// AvailableCredit is the vendor credit still to be applied or refunded
+func (n *PurchaseDebitNote) AvailableCredit() float64 {
+	return n.UnappliedAmount - n.CreditUsedAmount
+}
+
+// Quantity is goods returned in the unit of the bill line; 0 debits a price
+// correction only. Expense lines are always price corrections.
+type PurchaseDebitNoteItem struct {
+	BaseModel
+	DebitNoteID   uint             `gorm:"not null;index" json:"debit_note_id"`
+	BillItemID    uint             `gorm:"not null;index" json:"bill_item_id"`
+	BillItem      PurchaseBillItem `gorm:"foreignKey:BillItemID" json:"bill_item,omitempty"`
+	Description   string           `gorm:"size:255" json:"description"`
+	Quantity      float64          `gorm:"type:decimal(20,2);default:0" json:"quantity"`
+	Unit          string           `gorm:"size:50" json:"unit"`
+	Amount        float64          `gorm:"type:decimal(20,2);default:0" json:"amount"` // DPP debited for the line
+	SerialNumbers []string         `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"`
+	MovementID    *uint            `json:"movement_id"`                                     // stock return posted with the debit note
+	CostAmount    float64          `gorm:"type:decimal(20,2);default:0" json:"cost_amount"` // stock value returned
+}
+
+// Purchase Debit Note application: vendor credit held on a posted debit
+// note, either applied to another open bill of the vendor (BillID) or
+// refunded by the vendor into a cash or bank account (AccountID)
+type PurchaseDebitNoteApplication struct {
+	BaseModel
+	CompanyID             uint          `gorm:"not null;index" json:"company_id"`
+	DebitNoteID           uint          `gorm:"not null;index" json:"debit_note_id"`
+	VendorID              uint          `gorm:"not null;index" json:"vendor_id"`
+	ApplicationDate       time.Time     `gorm:"not null;index" json:"application_date"`
+	BillID                *uint         `gorm:"index" json:"bill_id"`
+	Bill                  *PurchaseBill `gorm:"foreignKey:BillID" json:"bill,omitempty"`
+	AccountID             *uint         `json:"account_id"` // cash or bank account refunded into
+	Account               *Account      `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Amount                float64       `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Reference             string        `gorm:"size:100" json:"reference"`
+	CashBankTransactionID *uint         `json:"cash_bank_transaction_id"`
+	JournalID             *uint         `gorm:"index" json:"journal_id"`
+	CreatedBy             uint          `gorm:"not null" json:"created_by"`
+	User                  User          `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+}
+
+type PaymentRunStatus string
+
+const (
+	PaymentRunStatusDraft     PaymentRunStatus = "draft"
+	PaymentRunStatusProcessed PaymentRunStatus = "processed"
+	PaymentRunStatusCancelled PaymentRunStatus = "cancelled"
+)
+
+// Payment Run: a batch of due bills paid together, one payment per vendor
+type PaymentRun struct {
+	BaseModel
+	CompanyID   uint             `gorm:"not null;index" json:"company_id"`
+	Company     Company          `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	RunNumber   string           `gorm:"uniqueIndex;size:50;not null" json:"run_number"`
+	PaymentDate time.Time        `gorm:"not null;index" json:"payment_date"`
+	DueBefore   time.Time        `gorm:"not null" json:"due_before"` // bills due on or before this date were selected
+	AccountID   uint             `gorm:"not null" json:"account_id"` // cash or bank account paid from
+	Account     Account          `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Status      PaymentRunStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	TotalAmount float64          `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	Notes       string           `gorm:"type:text" json:"notes"`
+	CreatedBy   uint             `gorm:"not null" json:"created_by"`
+	User        User             `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	ProcessedAt *time.Time       `json:"processed_at"`
+	ProcessedBy *uint            `json:"processed_by"`
+	Lines       []PaymentRunLine `gorm:"foreignKey:PaymentRunID" json:"lines,omitempty"`
+}
+
+type PaymentRunLine struct {
+	BaseModel
+	PaymentRunID uint         `gorm:"not null;index" json:"payment_run_id"`
+	BillID       uint         `gorm:"not null;index" json:"bill_id"`
+	Bill         PurchaseBill `gorm:"foreignKey:BillID" json:"bill,omitempty"`
+	VendorID     uint         `gorm:"not null;index" json:"vendor_id"`
+	Amount       float64      `gorm:"type:decimal(20,2);not null" json:"amount"`
+	PaymentID    *uint        `json:"payment_id"` // vendor payment created when the run is processed
+}
\ No newline at end of file
@@ -0,0 +1,240 @@
+package models
+
+import "time"
+
+// Customer master
+type Customer struct {
+	BaseModel
+	CompanyID       uint    `gorm:"not null;uniqueIndex:idx_company_customer_code" json:"company_id"`
+	Company         Company `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Code            string  `gorm:"uniqueIndex:idx_company_customer_code;size:50;not null" json:"code"`
+	Name            string  `gorm:"size:255;not null" json:"name"`
+	NPWP            string  `gorm:"size:30" json:"npwp"`
+	Address         string  `gorm:"type:text" json:"address"`
+	Phone           string  `gorm:"size:50" json:"phone"`
+	Email           string  `gorm:"size:100" json:"email"`
+	ContactPerson   string  `gorm:"size:100" json:"contact_person"`
+	PaymentTermDays int     `gorm:"default:30" json:"payment_term_days"`
+	CreditLimit     float64 `gorm:"type:decimal(20,2);default:0" json:"credit_limit"` // 0 = no limit
+	IsActive        bool    `gorm:"default:true" json:"is_active"`
+	Notes           string  `gorm:"type:text" json:"notes"`
+	AccountOwnerID  *uint   `json:"account_owner_id"` // user following up collections, notified on dunning
+	AccountOwner    *User   `gorm:"foreignKey:AccountOwnerID" json:"account_owner,omitempty"`
+}
+
+type SalesInvoiceStatus string
+
+const (
+	SalesInvoiceStatusDraft         SalesInvoiceStatus = "draft"
+	SalesInvoiceStatusPosted        SalesInvoiceStatus = "posted"
+	SalesInvoiceStatusPartiallyPaid SalesInvoiceStatus = "partially_paid"
+	SalesInvoiceStatusPaid          SalesInvoiceStatus = "paid"
+	SalesInvoiceStatusCancelled     SalesInvoiceStatus = "cancelled"
+)
+
+// Sales Invoice: posting issues the goods and records the receivable
+type SalesInvoice struct {
+	BaseModel
+	CompanyID        uint               `gorm:"not null;index" json:"company_id"`
+	Company          Company            `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	InvoiceNumber    string             `gorm:"uniqueIndex;size:50;not null" json:"invoice_number"`
+	InvoiceDate      time.Time          `gorm:"not null;index" json:"invoice_date"`
+	DueDate          time.Time          `gorm:"not null;index" json:"due_date"`
+	CustomerID       uint               `gorm:"not null;index" json:"customer_id"`
+	Customer         Customer           `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	WarehouseID      uint               `gorm:"not null;default:0" json:"warehouse_id"` // goods issued from, 0 = default warehouse
+	Status           SalesInvoiceStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Subtotal         float64            `gorm:"type:decimal(20,2);default:0" json:"subtotal"` // after line discounts
+	DiscountAmount   float64            `gorm:"type:decimal(20,2);default:0" json:"discount_amount"`
+	TaxRate          float64            `gorm:"type:decimal(5,2);default:0" json:"tax_rate"` // PPN percentage
+	TaxAmount        float64            `gorm:"type:decimal(20,2);default:0" json:"tax_amount"`
+	TotalAmount      float64            `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	PaidAmount       float64            `gorm:"type:decimal(20,2);default:0" json:"paid_amount"`
+	CreditedAmount   float64            `gorm:"type:decimal(20,2);default:0" json:"credited_amount"` // posted credit notes
+	TaxInvoiceNumber string             `gorm:"size:50" json:"tax_invoice_number"`                   // nomor faktur pajak
+	Reference        string             `gorm:"size:100" json:"reference"`                           // customer PO
+	Notes            string             `gorm:"type:text" json:"notes"`
+	JournalID        *uint              `gorm:"index" json:"journal_id"` // revenue, PPN and receivable
+	Journal          *Journal           `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
+	CreatedBy        uint               `gorm:"not null" json:"created_by"`
+	User             User               `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	PostedAt         *time.Time         `json:"posted_at"`
+	PostedBy         *uint              `json:"posted_by"`
+	DunningLevel     int                `gorm:"default:0" json:"dunning_level"` // highest reminder level sent
+	LastDunnedAt     *time.Time         `json:"last_dunned_at"`
+	Items            []SalesInvoiceItem `gorm:"foreignKey:InvoiceID" json:"items,omitempty"`
+}
+
+// Outstanding is the amount still to be received
+func (i *SalesInvoice) Outstanding() float64 {
+	return i.TotalAmount - i.PaidAmount - i.CreditedAmount
+}
+
+type SalesInvoiceItem struct {
+	BaseModel
+	InvoiceID        uint               `gorm:"not null;index" json:"invoice_id"`
+	ProductID        uint               `gorm:"not null;index" json:"product_id"`
+	Product          Product            `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description      string             `gorm:"size:255" json:"description"`
+	Quantity         float64            `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Unit             string             `gorm:"size:50" json:"unit"` // alternate unit of quantity & price, default base unit
+	UnitPrice        float64            `gorm:"type:decimal(20,2);not null" json:"unit_price"`
+	DiscountPercent  float64            `gorm:"type:decimal(5,2);default:0" json:"discount_percent"`
+	DiscountAmount   float64            `gorm:"type:decimal(20,2);default:0" json:"discount_amount"`
+	Subtotal         float64            `gorm:"type:decimal(20,2);default:0" json:"subtotal"`
+	RevenueAccountID *uint              `json:"revenue_account_id"` // nil = Pendapatan Usaha
+	RevenueAccount   *Account           `gorm:"foreignKey:RevenueAccountID" json:"revenue_account,omitempty"`
+	SerialNumbers    []string           `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"` // units sold of serial-tracked products
+	DeliveryItemID   *uint              `gorm:"index" json:"delivery_item_id"`                             // invoiced from a delivery order, which issued the goods
+	DeliveryItem     *DeliveryOrderItem `gorm:"foreignKey:DeliveryItemID" json:"delivery_item,omitempty"`
+	MovementID       *uint              `json:"movement_id"`                                     // stock-out posted with the invoice, or of the delivery
+	CostAmount       float64            `gorm:"type:decimal(20,2);default:0" json:"cost_amount"` // COGS of the goods issued
+}
+
+// Customer Payment received through cash/bank and applied to invoices
+type CustomerPayment struct {
+	BaseModel
+	CompanyID             uint                        `gorm:"not null;index" json:"company_id"`
+	Company               Company                     `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	PaymentNumber         string                      `gorm:"uniqueIndex;size:50;not null" json:"payment_number"`
+	PaymentDate           time.Time                   `gorm:"not null;index" json:"payment_date"`
+	CustomerID            uint                        `gorm:"not null;index" json:"customer_id"`
+	Customer              Customer                    `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	AccountID             uint                        `gorm:"not null" json:"account_id"` // cash or bank account received into
+	Account               Account                     `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Amount                float64                     `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Reference             string                      `gorm:"size:100" json:"reference"` // transfer / giro reference
+	Notes                 string                      `gorm:"type:text" json:"notes"`
+	CashBankTransactionID *uint                       `json:"cash_bank_transaction_id"`
+	JournalID             *uint                       `gorm:"index" json:"journal_id"`
+	CreatedBy             uint                        `gorm:"not null" json:"created_by"`
+	User                  User                        `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Allocations           []CustomerPaymentAllocation `gorm:"foreignKey:PaymentID" json:"allocations,omitempty"`
+}
+
+type CustomerPaymentAllocation struct {
+	BaseModel
+	PaymentID uint         `gorm:"not null;index" json:"payment_id"`
+	InvoiceID uint         `gorm:"not null;index" json:"invoice_id"`
+	Invoice   SalesInvoice `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	Amount    float64      `gorm:"type:decimal(20,2);not null" json:"amount"`
+}
+
+type CreditNoteStatus string
+
+const (
+	CreditNoteStatusDraft     CreditNoteStatus = "draft"
+	CreditNoteStatusPosted    CreditNoteStatus = "posted"
+	CreditNoteStatusCancelled CreditNoteStatus = "cancelled"
+)
+
+// Sales Credit Note: a return or price correction against a posted invoice.
+// Posting reverses revenue and PPN Keluaran, takes returned goods back into
+// stock at their original cost and reduces the invoice's open balance.
+type SalesCreditNote struct {
+	BaseModel
+	CompanyID        uint                         `gorm:"not null;index" json:"company_id"`
+	Company          Company                      `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	CreditNoteNumber string                       `gorm:"uniqueIndex;size:50;not null" json:"credit_note_number"`
+	CreditNoteDate   time.Time                    `gorm:"not null;index" json:"credit_note_date"`
+	InvoiceID        uint                         `gorm:"not null;index" json:"invoice_id"`
+	Invoice          SalesInvoice                 `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	CustomerID       uint                         `gorm:"not null;index" json:"customer_id"`
+	Customer         Customer                     `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	WarehouseID      uint                         `gorm:"not null;default:0" json:"warehouse_id"` // returned goods received into, 0 = where they were issued from
+	Status           CreditNoteStatus             `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Reason           string                       `gorm:"size:255" json:"reason"`
+	Subtotal         float64                      `gorm:"type:decimal(20,2);default:0" json:"subtotal"` // DPP credited
+	TaxRate          float64                      `gorm:"type:decimal(5,2);default:0" json:"tax_rate"`  // PPN percentage of the invoice
+	TaxAmount        float64                      `gorm:"type:decimal(20,2);default:0" json:"tax_amount"`
+	TotalAmount      float64                      `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	UnappliedAmount  float64                      `gorm:"type:decimal(20,2);default:0" json:"unapplied_amount"`   // beyond the invoice's open balance, held as customer credit
+	CreditUsedAmount float64                      `gorm:"type:decimal(20,2);default:0" json:"credit_used_amount"` // customer credit since applied to other invoices or refunded
+	TaxInvoiceNumber string                       `gorm:"size:50" json:"tax_invoice_number"`                      // nomor nota retur
+	Notes            string                       `gorm:"type:text" json:"notes"`
+	JournalID        *uint                        `gorm:"index" json:"journal_id"`
+	CreatedBy        uint                         `gorm:"not null" json:"created_by"`
+	User             User                         `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	PostedAt         *time.Time                   `json:"posted_at"`
+	PostedBy         *uint                        `json:"posted_by"`
+	Items            []SalesCreditNoteItem        `gorm:"foreignKey:CreditNoteID" json:"items,omitempty"`
+	Applications     []SalesCreditNoteApplication `gorm:"foreignKey:CreditNoteID" json:"applications,omitempty"`
+}
+
+// AppliedAmount is the part of the credit note that reduced its own invoice
+func (n *SalesCreditNote) AppliedAmount() float64 {
+	return n.TotalAmount - n.UnappliedAmount
+}
+
+// AvailableCredit is the customer credit still to be applied or refunded
+func (n *SalesCreditNote) AvailableCredit() float64 {
+	return n.UnappliedAmount - n.CreditUsedAmount
+}
+
+// Quantity is returned goods in the unit of the invoice line; 0 credits a
+// price correction only
+type SalesCreditNoteItem struct {
+	BaseModel
+	CreditNoteID  uint             `gorm:"not null;index" json:"credit_note_id"`
+	InvoiceItemID uint             `gorm:"not null;index" json:"invoice_item_id"`
+	InvoiceItem   SalesInvoiceItem `gorm:"foreignKey:InvoiceItemID" json:"invoice_item,omitempty"`
+	ProductID     uint             `gorm:"not null;index" json:"product_id"`
+	Product       Product          `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description   string           `gorm:"size:255" json:"description"`
+	Quantity      float64          `gorm:"type:decimal(20,2);default:0" json:"quantity"`
+	Unit          string           `gorm:"size:50" json:"unit"`
+	Amount        float64          `gorm:"type:decimal(20,2);default:0" json:"amount"`                // DPP credited for the line
+	SerialNumbers []string         `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"` // units returned of serial-tracked products
+	MovementID    *uint            `json:"movement_id"`                                               // stock return posted with the credit note
+	CostAmount    float64          `gorm:"type:decimal(20,2);default:0" json:"cost_amount"`           // COGS reversed
+}
+
+// Sales Credit Note application: customer credit held on a posted credit
+// note, either applied to another open invoice of the customer (InvoiceID)
+// or refunded from a cash or bank account (AccountID)
+type SalesCreditNoteApplication struct {
+	BaseModel
+	CompanyID             uint          `gorm:"not null;index" json:"company_id"`
+	CreditNoteID          uint          `gorm:"not null;index" json:"credit_note_id"`
+	CustomerID            uint          `gorm:"not null;index" json:"customer_id"`
+	ApplicationDate       time.Time     `gorm:"not null;index" json:"application_date"`
+	InvoiceID             *uint         `gorm:"index" json:"invoice_id"`
+	Invoice               *SalesInvoice `gorm:"foreignKey:InvoiceID" json:"invoice,omitempty"`
+	AccountID             *uint         `json:"account_id"` // cash or bank account refunded from
+	Account               *Account      `gorm:"foreignKey:AccountID" json:"account,omitempty"`
+	Amount                float64       `gorm:"type:decimal(20,2);not null" json:"amount"`
+	Reference             string        `gorm:"size:100" json:"reference"`
+	CashBankTransactionID *uint         `json:"cash_bank_transaction_id"`
+	JournalID             *uint         `gorm:"index" json:"journal_id"`
+	CreatedBy             uint          `gorm:"not null" json:"created_by"`
+	User                  User          `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+}
+
+// Customer Statement of account for a period
+type CustomerStatementEntry struct {
+	Date           string  `json:"date"`
+	Type           string  `json:"type"` // invoice, payment, credit_note or refund
+	DocumentID     uint    `json:"document_id"`
+	DocumentNumber string  `json:"document_number"`
+	Reference      string  `json:"reference"`
+	DueDate        string  `json:"due_date,omitempty"`
+	Debit          float64 `json:"debit"`
+	Credit         float64 `json:"credit"`
+	Balance        float64 `json:"balance"`
+}
+
+type CustomerStatement struct {
+	CustomerID     uint                     `json:"customer_id"`
+	CustomerCode   string                   `json:"customer_code"`
+	CustomerName   string                   `json:"customer_name"`
+	Address        string                   `json:"address"`
+	NPWP           string                   `json:"npwp"`
+	StartDate      string                   `json:"start_date"`
+	EndDate        string                   `json:"end_date"`
+	OpeningBalance float64                  `json:"opening_balance"`
+	Entries        []CustomerStatementEntry `json:"entries"`
+	TotalDebit     float64                  `json:"total_debit"`
+	TotalCredit    float64                  `json:"total_credit"`
+	ClosingBalance float64                  `json:"closing_balance"`
+	OverdueAmount  float64                  `json:"overdue_amount"` // part of the closing balance past due at the end date
+}
\ No newline at end of file
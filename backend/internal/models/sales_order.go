@@ -0,0 +1,191 @@
+package models
+
+import "time"
+
+type SalesQuotationStatus string
+
+const (
+	SalesQuotationStatusDraft     SalesQuotationStatus = "draft"
+	SalesQuotationStatusSent      SalesQuotationStatus = "sent"
+	SalesQuotationStatusAccepted  SalesQuotationStatus = "accepted"
+	SalesQuotationStatusRejected  SalesQuotationStatus = "rejected"
+	SalesQuotationStatusOrdered   SalesQuotationStatus = "ordered" // converted to a sales order
+	SalesQuotationStatusCancelled SalesQuotationStatus = "cancelled"
+)
+
+// Sales Quotation offered to a customer; accepting it carries its lines
+// forward to a sales order
+type SalesQuotation struct {
+	BaseModel
+	CompanyID       uint                 `gorm:"not null;index" json:"company_id"`
+	Company         Company              `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	QuotationNumber string               `gorm:"uniqueIndex;size:50;not null" json:"quotation_number"`
+	QuotationDate   time.Time            `gorm:"not null;index" json:"quotation_date"`
+	ValidUntil      *time.Time           `json:"valid_until"` // nil = no expiry
+	CustomerID      uint                 `gorm:"not null;index" json:"customer_id"`
+	Customer        Customer             `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	Status          SalesQuotationStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Subtotal        float64              `gorm:"type:decimal(20,2);default:0" json:"subtotal"` // after line discounts
+	TaxRate         float64              `gorm:"type:decimal(5,2);default:0" json:"tax_rate"`  // PPN percentage
+	TaxAmount       float64              `gorm:"type:decimal(20,2);default:0" json:"tax_amount"`
+	TotalAmount     float64              `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	Reference       string               `gorm:"size:100" json:"reference"` // customer enquiry
+	Notes           string               `gorm:"type:text" json:"notes"`
+	SalesOrderID    *uint                `gorm:"index" json:"sales_order_id"`
+	CreatedBy       uint                 `gorm:"not null" json:"created_by"`
+	User            User                 `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	Items           []SalesQuotationItem `gorm:"foreignKey:QuotationID" json:"items,omitempty"`
+}
+
+type SalesQuotationItem struct {
+	BaseModel
+	QuotationID     uint    `gorm:"not null;index" json:"quotation_id"`
+	ProductID       uint    `gorm:"not null;index" json:"product_id"`
+	Product         Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description     string  `gorm:"size:255" json:"description"`
+	Quantity        float64 `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Unit            string  `gorm:"size:50" json:"unit"` // alternate unit of quantity & price
+	UnitPrice       float64 `gorm:"type:decimal(20,2);not null" json:"unit_price"`
+	DiscountPercent float64 `gorm:"type:decimal(5,2);default:0" json:"discount_percent"`
+	DiscountAmount  float64 `gorm:"type:decimal(20,2);default:0" json:"discount_amount"`
+	Subtotal        float64 `gorm:"type:decimal(20,2);default:0" json:"subtotal"`
+}
+
+type SalesOrderStatus string
+
+const (
+	SalesOrderStatusDraft              SalesOrderStatus = "draft"
+	SalesOrderStatusConfirmed          SalesOrderStatus = "confirmed" // stock reserved, awaiting delivery
+	SalesOrderStatusPartiallyDelivered SalesOrderStatus = "partially_delivered"
+	SalesOrderStatusDelivered          SalesOrderStatus = "delivered"
+	SalesOrderStatusClosed             SalesOrderStatus = "closed" // short-closed, the rest will not be delivered
+	SalesOrderStatusCancelled          SalesOrderStatus = "cancelled"
+)
+
+// Sales Order: confirming it reserves the stock, which is then issued by
+// delivery orders and invoiced from them
+type SalesOrder struct {
+	BaseModel
+	CompanyID    uint             `gorm:"not null;index" json:"company_id"`
+	Company      Company          `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	OrderNumber  string           `gorm:"uniqueIndex;size:50;not null" json:"order_number"`
+	OrderDate    time.Time        `gorm:"not null;index" json:"order_date"`
+	ExpectedDate *time.Time       `json:"expected_date"` // promised delivery date
+	CustomerID   uint             `gorm:"not null;index" json:"customer_id"`
+	Customer     Customer         `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	WarehouseID  uint             `gorm:"not null;default:0" json:"warehouse_id"` // shipped from, 0 = default warehouse
+	QuotationID  *uint            `gorm:"index" json:"quotation_id"`
+	Status       SalesOrderStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	Subtotal     float64          `gorm:"type:decimal(20,2);default:0" json:"subtotal"` // after line discounts
+	TaxRate      float64          `gorm:"type:decimal(5,2);default:0" json:"tax_rate"`  // PPN percentage
+	TaxAmount    float64          `gorm:"type:decimal(20,2);default:0" json:"tax_amount"`
+	TotalAmount  float64          `gorm:"type:decimal(20,2);default:0" json:"total_amount"`
+	Reference    string           `gorm:"size:100" json:"reference"` // customer PO
+	Notes        string           `gorm:"type:text" json:"notes"`
+	CreatedBy    uint             `gorm:"not null" json:"created_by"`
+	User         User             `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	ConfirmedAt  *time.Time       `json:"confirmed_at"`
+	ConfirmedBy  *uint            `json:"confirmed_by"`
+	Items        []SalesOrderItem `gorm:"foreignKey:SalesOrderID" json:"items,omitempty"`
+}
+
+type SalesOrderItem struct {
+	BaseModel
+	SalesOrderID      uint              `gorm:"not null;index" json:"sales_order_id"`
+	QuotationItemID   *uint             `gorm:"index" json:"quotation_item_id"`
+	ProductID         uint              `gorm:"not null;index" json:"product_id"`
+	Product           Product           `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description       string            `gorm:"size:255" json:"description"`
+	Quantity          float64           `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Unit              string            `gorm:"size:50" json:"unit"` // alternate unit of quantity & price
+	UnitPrice         float64           `gorm:"type:decimal(20,2);not null" json:"unit_price"`
+	DiscountPercent   float64           `gorm:"type:decimal(5,2);default:0" json:"discount_percent"`
+	DiscountAmount    float64           `gorm:"type:decimal(20,2);default:0" json:"discount_amount"`
+	Subtotal          float64           `gorm:"type:decimal(20,2);default:0" json:"subtotal"`
+	ReservationID     *uint             `json:"reservation_id"` // stock held for the line on confirmation
+	Reservation       *StockReservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+	ReservedQuantity  float64           `gorm:"type:decimal(20,2);default:0" json:"reserved_quantity"` // in the line's unit; the rest is backordered
+	DeliveredQuantity float64           `gorm:"type:decimal(20,2);default:0" json:"delivered_quantity"`
+}
+
+type DeliveryOrderStatus string
+
+const (
+	DeliveryOrderStatusDraft     DeliveryOrderStatus = "draft"
+	DeliveryOrderStatusPosted    DeliveryOrderStatus = "posted"
+	DeliveryOrderStatusInvoiced  DeliveryOrderStatus = "invoiced"
+	DeliveryOrderStatusCancelled DeliveryOrderStatus = "cancelled"
+)
+
+// Delivery Order (surat jalan) against a sales order: posting issues the
+// goods (Dr COGS / Cr inventory through the stock-out). The invoice raised
+// from it records the revenue without issuing the goods again.
+type DeliveryOrder struct {
+	BaseModel
+	CompanyID      uint                `gorm:"not null;index" json:"company_id"`
+	Company        Company             `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	DeliveryNumber string              `gorm:"uniqueIndex;size:50;not null" json:"delivery_number"`
+	DeliveryDate   time.Time           `gorm:"not null;index" json:"delivery_date"`
+	SalesOrderID   uint                `gorm:"not null;index" json:"sales_order_id"`
+	SalesOrder     SalesOrder          `gorm:"foreignKey:SalesOrderID" json:"sales_order,omitempty"`
+	CustomerID     uint                `gorm:"not null;index" json:"customer_id"`
+	Customer       Customer            `gorm:"foreignKey:CustomerID" json:"customer,omitempty"`
+	WarehouseID    uint                `gorm:"not null;default:0" json:"warehouse_id"`
+	Status         DeliveryOrderStatus `gorm:"type:varchar(20);not null;default:'draft';index" json:"status"`
+	ShipTo         string              `gorm:"type:text" json:"ship_to"`
+	Reference      string              `gorm:"size:100" json:"reference"` // carrier / vehicle
+	Notes          string              `gorm:"type:text" json:"notes"`
+	InvoiceID      *uint               `gorm:"index" json:"invoice_id"` // posted invoice raised from the delivery
+	CreatedBy      uint                `gorm:"not null" json:"created_by"`
+	User           User                `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	PostedAt       *time.Time          `json:"posted_at"`
+	PostedBy       *uint               `json:"posted_by"`
+	Items          []DeliveryOrderItem `gorm:"foreignKey:DeliveryID" json:"items,omitempty"`
+}
+
+// Delivery Order line in the unit of the sales order line
+type DeliveryOrderItem struct {
+	BaseModel
+	DeliveryID       uint           `gorm:"not null;index" json:"delivery_id"`
+	Delivery         *DeliveryOrder `gorm:"foreignKey:DeliveryID" json:"delivery,omitempty"`
+	SalesOrderItemID uint           `gorm:"not null;index" json:"sales_order_item_id"`
+	SalesOrderItem   SalesOrderItem `gorm:"foreignKey:SalesOrderItemID" json:"sales_order_item,omitempty"`
+	ProductID        uint           `gorm:"not null;index" json:"product_id"`
+	Product          Product        `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Description      string         `gorm:"size:255" json:"description"`
+	Quantity         float64        `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	Unit             string         `gorm:"size:50" json:"unit"`
+	SerialNumbers    []string       `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"` // units shipped of serial-tracked products
+	MovementID       *uint          `json:"movement_id"`                                               // stock-out posted with the delivery
+	CostAmount       float64        `gorm:"type:decimal(20,2);default:0" json:"cost_amount"`           // COGS of the goods issued
+}
+
+// Backorder line: ordered on a confirmed sales order but not yet delivered
+type BackorderLine struct {
+	SalesOrderID        uint       `json:"sales_order_id"`
+	OrderNumber         string     `json:"order_number"`
+	OrderDate           time.Time  `json:"order_date"`
+	ExpectedDate        *time.Time `json:"expected_date"`
+	CustomerID          uint       `json:"customer_id"`
+	CustomerCode        string     `json:"customer_code"`
+	CustomerName        string     `json:"customer_name"`
+	ItemID              uint       `json:"item_id"`
+	ProductID           uint       `json:"product_id"`
+	ProductCode         string     `json:"product_code"`
+	Description         string     `json:"description"`
+	Unit                string     `json:"unit"`
+	OrderedQuantity     float64    `json:"ordered_quantity"`
+	DeliveredQuantity   float64    `json:"delivered_quantity"`
+	BackorderedQuantity float64    `json:"backordered_quantity"`
+	ReservedQuantity    float64    `json:"reserved_quantity"` // of the backorder, still held in stock
+	ShortQuantity       float64    `json:"short_quantity"`    // of the backorder, not covered by stock
+	UnitPrice           float64    `json:"unit_price"`        // net of the line discount
+	BackorderAmount     float64    `json:"backorder_amount"`
+	IsOverdue           bool       `json:"is_overdue"` // promised date has passed
+}
+
+type BackorderReport struct {
+	AsOfDate    string          `json:"as_of_date"`
+	Lines       []BackorderLine `json:"lines"`
+	TotalAmount float64         `json:"total_amount"`
+}
\ No newline at end of file
@@ -33,6 +33,7 @@ type Tax struct {
 	ReportedDate  *time.Time `json:"reported_date"`
 	PaidDate      *time.Time `json:"paid_date"`
 	Description   string    `gorm:"type:text" json:"description"`
+	PayrollRunID  *uint     `gorm:"index" json:"payroll_run_id"` // PPh 21 withheld by a payroll run, amounts kept as calculated
 	JournalID     *uint     `gorm:"index" json:"journal_id"`
 	Journal       *Journal  `gorm:"foreignKey:JournalID" json:"journal,omitempty"`
 	CreatedBy     uint      `gorm:"not null" json:"created_by"`
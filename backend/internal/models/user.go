@@ -8,6 +8,10 @@ const (
 	RoleViewer     UserRole = "viewer"
 )
 
+// SystemUserEmail identifies the user that scheduled postings are
+// recorded as
+const SystemUserEmail = "system@finara.local"
+
 type User struct {
 	BaseModel
 	Email     string   `gorm:"uniqueIndex;not null;size:255" json:"email"`
@@ -0,0 +1,62 @@
+package models
+
+import "time"
+
+type TransferStatus string
+
+const (
+	TransferStatusDraft     TransferStatus = "draft"
+	TransferStatusInTransit TransferStatus = "in_transit"
+	TransferStatusReceived  TransferStatus = "received"
+	TransferStatusCancelled TransferStatus = "cancelled"
+)
+
+// Warehouse / Stock Location
+type Warehouse struct {
+	BaseModel
+	CompanyID uint    `gorm:"uniqueIndex:idx_company_warehouse_code;not null" json:"company_id"`
+	Company   Company `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	Code      string  `gorm:"uniqueIndex:idx_company_warehouse_code;size:50;not null" json:"code"`
+	Name      string  `gorm:"size:255;not null" json:"name"`
+	Address   string  `gorm:"type:text" json:"address"`
+	IsDefault bool    `gorm:"default:false" json:"is_default"`
+	IsTransit bool    `gorm:"default:false" json:"is_transit"` // holds stock dispatched but not yet received
+	IsActive  bool    `gorm:"default:true" json:"is_active"`
+}
+
+// Stock Transfer between warehouses
+type StockTransfer struct {
+	BaseModel
+	CompanyID       uint                `gorm:"not null;index" json:"company_id"`
+	Company         Company             `gorm:"foreignKey:CompanyID" json:"company,omitempty"`
+	TransferNumber  string              `gorm:"uniqueIndex;size:50;not null" json:"transfer_number"`
+	TransferDate    time.Time           `gorm:"not null;index" json:"transfer_date"`
+	FromWarehouseID uint                `gorm:"not null;index" json:"from_warehouse_id"`
+	FromWarehouse   Warehouse           `gorm:"foreignKey:FromWarehouseID" json:"from_warehouse,omitempty"`
+	ToWarehouseID   uint                `gorm:"not null;index" json:"to_warehouse_id"`
+	ToWarehouse     Warehouse           `gorm:"foreignKey:ToWarehouseID" json:"to_warehouse,omitempty"`
+	Status          TransferStatus      `gorm:"type:varchar(20);not null;default:'draft'" json:"status"`
+	Notes           string              `gorm:"type:text" json:"notes"`
+	CreatedBy       uint                `gorm:"not null" json:"created_by"`
+	User            User                `gorm:"foreignKey:CreatedBy" json:"user,omitempty"`
+	DispatchedAt    *time.Time          `json:"dispatched_at"`
+	DispatchedBy    *uint               `json:"dispatched_by"`
+	ReceivedAt      *time.Time          `json:"received_at"`
+	ReceivedBy      *uint               `json:"received_by"`
+	Items           []StockTransferItem `gorm:"foreignKey:TransferID" json:"items,omitempty"`
+}
+
+type StockTransferItem struct {
+	BaseModel
+	TransferID           uint     `gorm:"not null;index" json:"transfer_id"`
+	ProductID            uint     `gorm:"not null;index" json:"product_id"`
+	Product              Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Quantity             float64  `gorm:"type:decimal(20,2);not null" json:"quantity"`
+	TotalCost            float64  `gorm:"type:decimal(20,2);default:0" json:"total_cost"`
+	OutMovementID        *uint    `json:"out_movement_id"`         // source -> transit
+	TransitInMovementID  *uint    `json:"transit_in_movement_id"`  // received into transit on dispatch
+	TransitOutMovementID *uint    `json:"transit_out_movement_id"` // issued from transit on receipt
+	InMovementID         *uint    `json:"in_movement_id"`          // transit -> destination
+	SerialNumbers        []string `gorm:"type:text;serializer:json" json:"serial_numbers,omitempty"`
+	Notes                string   `gorm:"type:text" json:"notes"`
+}
@@ -15,6 +15,8 @@ type AccountRepository interface {
 	Update(account *models.Account) error
 	Delete(id uint) error
 	GetActiveAccounts(companyID uint) ([]models.Account, error)
+	FindWithDeletedByCompanyID(companyID uint) ([]models.Account, error)
+	FindCompanyIDsWithAccounts() ([]uint, error)
 }
 
 type accountRepository struct {
@@ -72,4 +74,22 @@ func (r *accountRepository) GetActiveAccounts(companyID uint) ([]models.Account,
 		Order("code ASC").
 		Find(&accounts).Error
 	return accounts, err
+}
+
+// FindWithDeletedByCompanyID returns every account the company has had,
+// deleted accounts included since their codes stay taken
+func (r *accountRepository) FindWithDeletedByCompanyID(companyID uint) ([]models.Account, error) {
+	var accounts []models.Account
+	err := r.db.Unscoped().
+		Where("company_id = ?", companyID).
+		Find(&accounts).Error
+	return accounts, err
+}
+
+func (r *accountRepository) FindCompanyIDsWithAccounts() ([]uint, error) {
+	var companyIDs []uint
+	err := r.db.Model(&models.Account{}).
+		Distinct("company_id").
+		Pluck("company_id", &companyIDs).Error
+	return companyIDs, err
 }
\ No newline at end of file
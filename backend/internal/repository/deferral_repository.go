@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DeferralRepository interface {
+	// Deferral Schedules
+	CreateDeferralSchedule(schedule *models.DeferralSchedule) error
+	FindDeferralScheduleByID(id uint) (*models.DeferralSchedule, error)
+	FindDeferralSchedules(companyID uint, deferralType string, status string) ([]models.DeferralSchedule, error)
+	FindAmortizableSchedules(companyID uint, periodEnd time.Time) ([]models.DeferralSchedule, error)
+	FindSchedulesStartedBy(companyID uint, deferralType string, date time.Time) ([]models.DeferralSchedule, error)
+	UpdateDeferralSchedule(schedule *models.DeferralSchedule) error
+	DeleteDeferralSchedule(id uint) error
+	GenerateDeferralScheduleNumber(companyID uint, date time.Time) (string, error)
+
+	// Schedule Lines
+	FindUnpostedDeferralLines(scheduleID uint) ([]models.DeferralScheduleLine, error)
+	FindPostedDeferralLines(scheduleID uint) ([]models.DeferralScheduleLine, error)
+	MarkDeferralLinesPosted(ids []uint, runID uint) error
+
+	// Deferral Runs
+	CreateDeferralRun(run *models.DeferralRun) error
+	FindDeferralRunByID(id uint) (*models.DeferralRun, error)
+	FindDeferralRuns(companyID uint) ([]models.DeferralRun, error)
+	FindLatestDeferralRun(companyID uint) (*models.DeferralRun, error)
+}
+
+type deferralRepository struct {
+	db *gorm.DB
+}
+
+func NewDeferralRepository(db *gorm.DB) DeferralRepository {
+	return &deferralRepository{db: db}
+}
+
+// Deferral Schedule methods
+func (r *deferralRepository) CreateDeferralSchedule(schedule *models.DeferralSchedule) error {
+	return r.db.Omit("BalanceSheetAccount", "PLAccount").Create(schedule).Error
+}
+
+func (r *deferralRepository) FindDeferralScheduleByID(id uint) (*models.DeferralSchedule, error) {
+	var schedule models.DeferralSchedule
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("period ASC")
+	}).
+		Preload("BalanceSheetAccount").
+		Preload("PLAccount").
+		Preload("User").
+		First(&schedule, id).Error
+	return &schedule, err
+}
+
+func (r *deferralRepository) FindDeferralSchedules(companyID uint, deferralType string, status string) ([]models.DeferralSchedule, error) {
+	var schedules []models.DeferralSchedule
+	query := r.db.Where("company_id = ?", companyID)
+	if deferralType != "" {
+		query = query.Where("type = ?", deferralType)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("start_date ASC, id ASC").
+		Preload("BalanceSheetAccount").
+		Preload("PLAccount").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// FindAmortizableSchedules returns the active schedules starting by the end
+// of the period
+func (r *deferralRepository) FindAmortizableSchedules(companyID uint, periodEnd time.Time) ([]models.DeferralSchedule, error) {
+	var schedules []models.DeferralSchedule
+	err := r.db.Where("company_id = ? AND status = ? AND start_date <= ?", companyID, models.DeferralStatusActive, periodEnd).
+		Order("start_date ASC, id ASC").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+// FindSchedulesStartedBy returns every schedule starting by the date, with
+// its balance sheet account
+func (r *deferralRepository) FindSchedulesStartedBy(companyID uint, deferralType string, date time.Time) ([]models.DeferralSchedule, error) {
+	var schedules []models.DeferralSchedule
+	query := r.db.Where("company_id = ? AND start_date <= ?", companyID, date)
+	if deferralType != "" {
+		query = query.Where("type = ?", deferralType)
+	}
+	err := query.Order("start_date ASC, id ASC").
+		Preload("BalanceSheetAccount").
+		Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *deferralRepository) UpdateDeferralSchedule(schedule *models.DeferralSchedule) error {
+	return r.db.Omit("Lines", "BalanceSheetAccount", "PLAccount", "User").Save(schedule).Error
+}
+
+// DeleteDeferralSchedule removes the schedule with its lines
+func (r *deferralRepository) DeleteDeferralSchedule(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("schedule_id = ?", id).Delete(&models.DeferralScheduleLine{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.DeferralSchedule{}, id).Error
+	})
+}
+
+func (r *deferralRepository) GenerateDeferralScheduleNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "DEF/" + date.Format("200601/")
+
+	err := r.db.Unscoped().Model(&models.DeferralSchedule{}).
+		Where("company_id = ? AND schedule_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Schedule Line methods
+func (r *deferralRepository) FindUnpostedDeferralLines(scheduleID uint) ([]models.DeferralScheduleLine, error) {
+	var lines []models.DeferralScheduleLine
+	err := r.db.Where("schedule_id = ? AND is_posted = ?", scheduleID, false).
+		Order("period ASC").
+		Find(&lines).Error
+	return lines, err
+}
+
+func (r *deferralRepository) FindPostedDeferralLines(scheduleID uint) ([]models.DeferralScheduleLine, error) {
+	var lines []models.DeferralScheduleLine
+	err := r.db.Where("schedule_id = ? AND is_posted = ?", scheduleID, true).
+		Order("period ASC").
+		Find(&lines).Error
+	return lines, err
+}
+
+func (r *deferralRepository) MarkDeferralLinesPosted(ids []uint, runID uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.DeferralScheduleLine{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"is_posted": true, "run_id": runID}).Error
+}
+
+// Deferral Run methods
+func (r *deferralRepository) CreateDeferralRun(run *models.DeferralRun) error {
+	return r.db.Omit("Lines.Schedule").Create(run).Error
+}
+
+func (r *deferralRepository) FindDeferralRunByID(id uint) (*models.DeferralRun, error) {
+	var run models.DeferralRun
+	err := r.db.Preload("Lines.Schedule").
+		Preload("Journal").
+		Preload("User").
+		First(&run, id).Error
+	return &run, err
+}
+
+func (r *deferralRepository) FindDeferralRuns(companyID uint) ([]models.DeferralRun, error) {
+	var runs []models.DeferralRun
+	err := r.db.Where("company_id = ?", companyID).
+		Order("period DESC").
+		Find(&runs).Error
+	return runs, err
+}
+
+func (r *deferralRepository) FindLatestDeferralRun(companyID uint) (*models.DeferralRun, error) {
+	var run models.DeferralRun
+	err := r.db.Where("company_id = ?", companyID).
+		Order("period DESC").
+		First(&run).Error
+	return &run, err
+}
\ No newline at end of file
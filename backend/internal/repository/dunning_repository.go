@@ -0,0 +1,113 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DunningRepository interface {
+	// Dunning Levels
+	FindDunningLevels(companyID uint) ([]models.DunningLevel, error)
+	ReplaceDunningLevels(companyID uint, levels []models.DunningLevel) error
+
+	// Overdue invoices, by customer and oldest due first
+	FindOverdueSalesInvoices(companyID uint, before time.Time) ([]models.SalesInvoice, error)
+
+	// Dunning Reminders
+	CreateDunningReminder(reminder *models.DunningReminder) error
+	FindDunningReminderByID(id uint) (*models.DunningReminder, error)
+	FindDunningReminders(companyID uint, customerID uint) ([]models.DunningReminder, error)
+	UpdateDunningReminder(reminder *models.DunningReminder) error
+	GenerateDunningReminderNumber(companyID uint, date time.Time) (string, error)
+}
+
+type dunningRepository struct {
+	db *gorm.DB
+}
+
+func NewDunningRepository(db *gorm.DB) DunningRepository {
+	return &dunningRepository{db: db}
+}
+
+// Dunning Level methods
+func (r *dunningRepository) FindDunningLevels(companyID uint) ([]models.DunningLevel, error) {
+	var levels []models.DunningLevel
+	err := r.db.Where("company_id = ?", companyID).
+		Order("level ASC").
+		Find(&levels).Error
+	return levels, err
+}
+
+func (r *dunningRepository) ReplaceDunningLevels(companyID uint, levels []models.DunningLevel) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("company_id = ?", companyID).Delete(&models.DunningLevel{}).Error; err != nil {
+			return err
+		}
+		for i := range levels {
+			levels[i].CompanyID = companyID
+			if err := tx.Omit("Company").Create(&levels[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *dunningRepository) FindOverdueSalesInvoices(companyID uint, before time.Time) ([]models.SalesInvoice, error) {
+	var invoices []models.SalesInvoice
+	err := r.db.Where("company_id = ? AND due_date < ? AND status IN ?", companyID, before, []models.SalesInvoiceStatus{
+		models.SalesInvoiceStatusPosted,
+		models.SalesInvoiceStatusPartiallyPaid,
+	}).
+		Order("customer_id ASC, due_date ASC, id ASC").
+		Preload("Customer").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// Dunning Reminder methods
+func (r *dunningRepository) CreateDunningReminder(reminder *models.DunningReminder) error {
+	return r.db.Omit("Customer", "Items.Invoice").Create(reminder).Error
+}
+
+func (r *dunningRepository) FindDunningReminderByID(id uint) (*models.DunningReminder, error) {
+	var reminder models.DunningReminder
+	err := r.db.Preload("Items.Invoice").
+		Preload("Customer").
+		First(&reminder, id).Error
+	return &reminder, err
+}
+
+func (r *dunningRepository) FindDunningReminders(companyID uint, customerID uint) ([]models.DunningReminder, error) {
+	var reminders []models.DunningReminder
+	query := r.db.Where("company_id = ?", companyID)
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	err := query.Order("reminder_date DESC, id DESC").
+		Preload("Customer").
+		Find(&reminders).Error
+	return reminders, err
+}
+
+func (r *dunningRepository) UpdateDunningReminder(reminder *models.DunningReminder) error {
+	return r.db.Omit("Items", "Customer").Save(reminder).Error
+}
+
+func (r *dunningRepository) GenerateDunningReminderNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "DUN/" + date.Format("200601/")
+
+	err := r.db.Model(&models.DunningReminder{}).
+		Where("company_id = ? AND reminder_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
\ No newline at end of file
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExpenseClaimRepository interface {
+	// Cash Advances
+	CreateCashAdvance(advance *models.CashAdvance) error
+	FindCashAdvanceByID(id uint) (*models.CashAdvance, error)
+	FindCashAdvances(companyID uint, employeeID uint, status string) ([]models.CashAdvance, error)
+	FindOutstandingAdvances(companyID uint, employeeID uint, asOfDate time.Time) ([]models.CashAdvance, error)
+	UpdateCashAdvance(advance *models.CashAdvance) error
+	GenerateCashAdvanceNumber(companyID uint, date time.Time) (string, error)
+
+	// Expense Claims
+	CreateExpenseClaim(claim *models.ExpenseClaim) error
+	FindExpenseClaimByID(id uint) (*models.ExpenseClaim, error)
+	FindExpenseClaims(companyID uint, employeeID uint, status string) ([]models.ExpenseClaim, error)
+	FindOpenClaimByAdvance(advanceID uint) (*models.ExpenseClaim, error)
+	UpdateExpenseClaim(claim *models.ExpenseClaim) error
+	GenerateExpenseClaimNumber(companyID uint, date time.Time) (string, error)
+
+	// Attachments
+	FindExpenseClaimItemByID(id uint) (*models.ExpenseClaimItem, error)
+	CreateExpenseClaimAttachment(attachment *models.ExpenseClaimAttachment) error
+	FindExpenseClaimAttachmentByID(id uint) (*models.ExpenseClaimAttachment, error)
+	DeleteExpenseClaimAttachment(id uint) error
+}
+
+type expenseClaimRepository struct {
+	db *gorm.DB
+}
+
+func NewExpenseClaimRepository(db *gorm.DB) ExpenseClaimRepository {
+	return &expenseClaimRepository{db: db}
+}
+
+// Cash Advance methods
+func (r *expenseClaimRepository) CreateCashAdvance(advance *models.CashAdvance) error {
+	return r.db.Omit("Employee").Create(advance).Error
+}
+
+func (r *expenseClaimRepository) FindCashAdvanceByID(id uint) (*models.CashAdvance, error) {
+	var advance models.CashAdvance
+	err := r.db.Preload("Employee").
+		Preload("User").
+		First(&advance, id).Error
+	return &advance, err
+}
+
+func (r *expenseClaimRepository) FindCashAdvances(companyID uint, employeeID uint, status string) ([]models.CashAdvance, error) {
+	var advances []models.CashAdvance
+	query := r.db.Where("company_id = ?", companyID)
+	if employeeID > 0 {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("request_date DESC, id DESC").
+		Preload("Employee").
+		Find(&advances).Error
+	return advances, err
+}
+
+// FindOutstandingAdvances returns the advances paid out by the date and not
+// settled by then, oldest first
+func (r *expenseClaimRepository) FindOutstandingAdvances(companyID uint, employeeID uint, asOfDate time.Time) ([]models.CashAdvance, error) {
+	var advances []models.CashAdvance
+	query := r.db.Where("company_id = ? AND disbursement_date <= ?", companyID, asOfDate).
+		Where("status = ? OR (status = ? AND settled_date > ?)", models.CashAdvanceStatusDisbursed, models.CashAdvanceStatusSettled, asOfDate)
+	if employeeID > 0 {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+	err := query.Order("disbursement_date ASC, id ASC").
+		Preload("Employee").
+		Find(&advances).Error
+	return advances, err
+}
+
+func (r *expenseClaimRepository) UpdateCashAdvance(advance *models.CashAdvance) error {
+	return r.db.Omit("Employee", "User").Save(advance).Error
+}
+
+func (r *expenseClaimRepository) GenerateCashAdvanceNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "ADV/" + date.Format("200601/")
+
+	err := r.db.Model(&models.CashAdvance{}).
+		Where("company_id = ? AND advance_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Expense Claim methods
+func (r *expenseClaimRepository) CreateExpenseClaim(claim *models.ExpenseClaim) error {
+	return r.db.Omit("Employee", "Advance", "Items.Account").Create(claim).Error
+}
+
+func (r *expenseClaimRepository) FindExpenseClaimByID(id uint) (*models.ExpenseClaim, error) {
+	var claim models.ExpenseClaim
+	err := r.db.Preload("Items.Account").
+		Preload("Items.Attachments").
+		Preload("Employee").
+		Preload("Advance").
+		Preload("User").
+		First(&claim, id).Error
+	return &claim, err
+}
+
+func (r *expenseClaimRepository) FindExpenseClaims(companyID uint, employeeID uint, status string) ([]models.ExpenseClaim, error) {
+	var claims []models.ExpenseClaim
+	query := r.db.Where("company_id = ?", companyID)
+	if employeeID > 0 {
+		query = query.Where("employee_id = ?", employeeID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("claim_date DESC, id DESC").
+		Preload("Employee").
+		Find(&claims).Error
+	return claims, err
+}
+
+// FindOpenClaimByAdvance returns the draft or approved claim settling the
+// advance
+func (r *expenseClaimRepository) FindOpenClaimByAdvance(advanceID uint) (*models.ExpenseClaim, error) {
+	var claim models.ExpenseClaim
+	err := r.db.Where("advance_id = ? AND status IN ?", advanceID, []models.ExpenseClaimStatus{
+		models.ExpenseClaimStatusDraft,
+		models.ExpenseClaimStatusApproved,
+	}).First(&claim).Error
+	return &claim, err
+}
+
+func (r *expenseClaimRepository) UpdateExpenseClaim(claim *models.ExpenseClaim) error {
+	return r.db.Omit("Items", "Employee", "Advance", "User").Save(claim).Error
+}
+
+func (r *expenseClaimRepository) GenerateExpenseClaimNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "EXP/" + date.Format("200601/")
+
+	err := r.db.Model(&models.ExpenseClaim{}).
+		Where("company_id = ? AND claim_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Attachment methods
+func (r *expenseClaimRepository) FindExpenseClaimItemByID(id uint) (*models.ExpenseClaimItem, error) {
+	var item models.ExpenseClaimItem
+	err := r.db.First(&item, id).Error
+	return &item, err
+}
+
+func (r *expenseClaimRepository) CreateExpenseClaimAttachment(attachment *models.ExpenseClaimAttachment) error {
+	return r.db.Create(attachment).Error
+}
+
+func (r *expenseClaimRepository) FindExpenseClaimAttachmentByID(id uint) (*models.ExpenseClaimAttachment, error) {
+	var attachment models.ExpenseClaimAttachment
+	err := r.db.First(&attachment, id).Error
+	return &attachment, err
+}
+
+func (r *expenseClaimRepository) DeleteExpenseClaimAttachment(id uint) error {
+	return r.db.Delete(&models.ExpenseClaimAttachment{}, id).Error
+}
\ No newline at end of file
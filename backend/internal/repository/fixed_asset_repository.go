@@ -0,0 +1,226 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FixedAssetRepository interface {
+	// Fixed Assets
+	CreateFixedAsset(asset *models.FixedAsset) error
+	FindFixedAssetByID(id uint) (*models.FixedAsset, error)
+	FindFixedAssets(companyID uint, status string, fiscalGroup string) ([]models.FixedAsset, error)
+	FindDepreciableAssets(companyID uint, periodEnd time.Time) ([]models.FixedAsset, error)
+	UpdateFixedAsset(asset *models.FixedAsset) error
+	DeleteFixedAsset(id uint) error
+	CountPostedFixedAssetEvents(assetID uint) (int64, error)
+	GenerateFixedAssetNumber(companyID uint, date time.Time) (string, error)
+
+	// Depreciation Schedules
+	CreateScheduleLines(lines []models.DepreciationScheduleLine) error
+	FindScheduleLines(assetID uint) ([]models.DepreciationScheduleLine, error)
+	FindUnpostedScheduleLines(assetID uint) ([]models.DepreciationScheduleLine, error)
+	MarkScheduleLinesPosted(ids []uint, runID *uint) error
+	ReplaceUnpostedScheduleLines(assetID uint, lines []models.DepreciationScheduleLine) error
+
+	// Asset History
+	CreateFixedAssetEvent(event *models.FixedAssetEvent) error
+	FindFixedAssetEvents(assetID uint) ([]models.FixedAssetEvent, error)
+
+	// Depreciation Runs
+	CreateDepreciationRun(run *models.DepreciationRun) error
+	FindDepreciationRunByID(id uint) (*models.DepreciationRun, error)
+	FindDepreciationRuns(companyID uint) ([]models.DepreciationRun, error)
+	FindLatestDepreciationRun(companyID uint) (*models.DepreciationRun, error)
+	UpdateDepreciationRun(run *models.DepreciationRun) error
+}
+
+type fixedAssetRepository struct {
+	db *gorm.DB
+}
+
+func NewFixedAssetRepository(db *gorm.DB) FixedAssetRepository {
+	return &fixedAssetRepository{db: db}
+}
+
+// Fixed Asset methods
+func (r *fixedAssetRepository) CreateFixedAsset(asset *models.FixedAsset) error {
+	return r.db.Omit("AssetAccount").Create(asset).Error
+}
+
+func (r *fixedAssetRepository) FindFixedAssetByID(id uint) (*models.FixedAsset, error) {
+	var asset models.FixedAsset
+	err := r.db.Preload("AssetAccount").
+		Preload("User").
+		First(&asset, id).Error
+	return &asset, err
+}
+
+func (r *fixedAssetRepository) FindFixedAssets(companyID uint, status string, fiscalGroup string) ([]models.FixedAsset, error) {
+	var assets []models.FixedAsset
+	query := r.db.Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if fiscalGroup != "" {
+		query = query.Where("fiscal_group = ?", fiscalGroup)
+	}
+	err := query.Order("acquisition_date ASC, id ASC").
+		Preload("AssetAccount").
+		Find(&assets).Error
+	return assets, err
+}
+
+// FindDepreciableAssets returns the active assets acquired by the end of
+// the period
+func (r *fixedAssetRepository) FindDepreciableAssets(companyID uint, periodEnd time.Time) ([]models.FixedAsset, error) {
+	var assets []models.FixedAsset
+	err := r.db.Where("company_id = ? AND status = ? AND acquisition_date <= ?", companyID, models.FixedAssetStatusActive, periodEnd).
+		Order("acquisition_date ASC, id ASC").
+		Find(&assets).Error
+	return assets, err
+}
+
+func (r *fixedAssetRepository) UpdateFixedAsset(asset *models.FixedAsset) error {
+	return r.db.Omit("AssetAccount", "User").Save(asset).Error
+}
+
+// DeleteFixedAsset removes the asset with its schedule and history
+func (r *fixedAssetRepository) DeleteFixedAsset(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("asset_id = ?", id).Delete(&models.DepreciationScheduleLine{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("asset_id = ?", id).Delete(&models.FixedAssetEvent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.FixedAsset{}, id).Error
+	})
+}
+
+// CountPostedFixedAssetEvents counts the depreciation runs and history
+// entries of the asset that posted a journal
+func (r *fixedAssetRepository) CountPostedFixedAssetEvents(assetID uint) (int64, error) {
+	var runLines, events int64
+	if err := r.db.Model(&models.DepreciationRunLine{}).
+		Where("asset_id = ?", assetID).
+		Count(&runLines).Error; err != nil {
+		return 0, err
+	}
+	err := r.db.Model(&models.FixedAssetEvent{}).
+		Where("asset_id = ? AND journal_id IS NOT NULL", assetID).
+		Count(&events).Error
+	return runLines + events, err
+}
+
+func (r *fixedAssetRepository) GenerateFixedAssetNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "FA/" + date.Format("200601/")
+
+	err := r.db.Unscoped().Model(&models.FixedAsset{}).
+		Where("company_id = ? AND asset_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Depreciation Schedule methods
+func (r *fixedAssetRepository) CreateScheduleLines(lines []models.DepreciationScheduleLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	return r.db.Create(&lines).Error
+}
+
+func (r *fixedAssetRepository) FindScheduleLines(assetID uint) ([]models.DepreciationScheduleLine, error) {
+	var lines []models.DepreciationScheduleLine
+	err := r.db.Where("asset_id = ?", assetID).
+		Order("period ASC").
+		Find(&lines).Error
+	return lines, err
+}
+
+func (r *fixedAssetRepository) FindUnpostedScheduleLines(assetID uint) ([]models.DepreciationScheduleLine, error) {
+	var lines []models.DepreciationScheduleLine
+	err := r.db.Where("asset_id = ? AND is_posted = ?", assetID, false).
+		Order("period ASC").
+		Find(&lines).Error
+	return lines, err
+}
+
+func (r *fixedAssetRepository) MarkScheduleLinesPosted(ids []uint, runID *uint) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Model(&models.DepreciationScheduleLine{}).
+		Where("id IN ?", ids).
+		Updates(map[string]interface{}{"is_posted": true, "run_id": runID}).Error
+}
+
+// ReplaceUnpostedScheduleLines lays out the months still to be posted again
+func (r *fixedAssetRepository) ReplaceUnpostedScheduleLines(assetID uint, lines []models.DepreciationScheduleLine) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("asset_id = ? AND is_posted = ?", assetID, false).Delete(&models.DepreciationScheduleLine{}).Error; err != nil {
+			return err
+		}
+		if len(lines) == 0 {
+			return nil
+		}
+		return tx.Create(&lines).Error
+	})
+}
+
+// Asset History methods
+func (r *fixedAssetRepository) CreateFixedAssetEvent(event *models.FixedAssetEvent) error {
+	return r.db.Create(event).Error
+}
+
+func (r *fixedAssetRepository) FindFixedAssetEvents(assetID uint) ([]models.FixedAssetEvent, error) {
+	var events []models.FixedAssetEvent
+	err := r.db.Where("asset_id = ?", assetID).
+		Order("event_date ASC, id ASC").
+		Preload("User").
+		Find(&events).Error
+	return events, err
+}
+
+// Depreciation Run methods
+func (r *fixedAssetRepository) CreateDepreciationRun(run *models.DepreciationRun) error {
+	return r.db.Omit("Lines.Asset").Create(run).Error
+}
+
+func (r *fixedAssetRepository) FindDepreciationRunByID(id uint) (*models.DepreciationRun, error) {
+	var run models.DepreciationRun
+	err := r.db.Preload("Lines.Asset").
+		Preload("Journal").
+		Preload("User").
+		First(&run, id).Error
+	return &run, err
+}
+
+func (r *fixedAssetRepository) FindDepreciationRuns(companyID uint) ([]models.DepreciationRun, error) {
+	var runs []models.DepreciationRun
+	err := r.db.Where("company_id = ?", companyID).
+		Order("period DESC").
+		Find(&runs).Error
+	return runs, err
+}
+
+func (r *fixedAssetRepository) FindLatestDepreciationRun(companyID uint) (*models.DepreciationRun, error) {
+	var run models.DepreciationRun
+	err := r.db.Where("company_id = ?", companyID).
+		Order("period DESC").
+		First(&run).Error
+	return &run, err
+}
+
+func (r *fixedAssetRepository) UpdateDepreciationRun(run *models.DepreciationRun) error {
+	return r.db.Omit("Lines", "Journal", "User").Save(run).Error
+}
\ No newline at end of file
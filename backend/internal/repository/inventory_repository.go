@@ -16,28 +16,133 @@ type InventoryRepository interface {
 	UpdateProduct(product *models.Product) error
 	DeleteProduct(id uint) error
 
+	// Unit of Measure
+	CreateUnitOfMeasure(unit *models.UnitOfMeasure) error
+	FindUnitOfMeasureByID(id uint) (*models.UnitOfMeasure, error)
+	FindUnitsOfMeasureByCompany(companyID uint) ([]models.UnitOfMeasure, error)
+	UpdateUnitOfMeasure(unit *models.UnitOfMeasure) error
+	DeleteUnitOfMeasure(id uint) error
+	CountProductUnitsByUnit(unitID uint) (int64, error)
+	SaveProductUnit(productUnit *models.ProductUnit) error
+	FindProductUnitByID(id uint) (*models.ProductUnit, error)
+	ClearDefaultProductUnit(productID uint, column string, exceptID uint) error
+	DeleteProductUnit(id uint) error
+
 	// Stock Movement
 	CreateStockMovement(movement *models.StockMovement) error
 	FindStockMovementByID(id uint) (*models.StockMovement, error)
-	FindStockMovementsByProduct(productID uint, startDate, endDate time.Time) ([]models.StockMovement, error)
-	FindStockMovementsByCompany(companyID uint, startDate, endDate time.Time) ([]models.StockMovement, error)
+	FindStockMovementsByProduct(productID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockMovement, error)
+	FindStockMovementsByCompany(companyID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockMovement, error)
 	GenerateMovementNumber(companyID uint, movementType string, date time.Time) (string, error)
+	SetMovementJournal(id uint, journalID uint) error
+	UpdateMovementCost(id uint, unitCost, totalCost float64) error
+	CountMovementsWithoutJournal(companyID uint, endDate time.Time) (int64, error)
+	SumIssuedQuantityByProduct(companyID uint, startDate, endDate time.Time) (map[uint]float64, error)
+	SumReturnedQuantity(sourceMovementID uint) (float64, error)
+
+	// Account Mapping
+	CreateAccountMapping(mapping *models.InventoryAccountMapping) error
+	FindAccountMappingByID(id uint) (*models.InventoryAccountMapping, error)
+	FindAccountMappingsByCompany(companyID uint) ([]models.InventoryAccountMapping, error)
+	UpdateAccountMapping(mapping *models.InventoryAccountMapping) error
+	DeleteAccountMapping(id uint) error
 
 	// Stock Balance
-	GetStockBalance(productID uint) (*models.StockBalance, error)
+	GetStockBalance(productID uint, warehouseID uint) (*models.StockBalance, error)
+	GetProductStockTotal(productID uint) (*models.StockBalance, error)
 	UpdateStockBalance(balance *models.StockBalance) error
-	GetAllStockBalances(companyID uint) ([]models.StockBalance, error)
+	GetAllStockBalances(companyID uint, warehouseID uint) ([]models.StockBalance, error)
+
+	// Cost Layers
+	CreateCostLayer(layer *models.StockCostLayer) error
+	ConsumeCostLayer(id uint, quantity float64) error
+	FindCostLayerByID(id uint) (*models.StockCostLayer, error)
+	FindCostLayerByMovement(movementID uint) (*models.StockCostLayer, error)
+	FindCostLayersByMovement(movementID uint) ([]models.StockCostLayer, error)
+	FindCostLayersBySource(sourceLayerID uint) ([]models.StockCostLayer, error)
+	UpdateCostLayer(layer *models.StockCostLayer) error
+	AddLayerConsumptionCost(layerID uint, perUnit float64) error
+	FindOpenCostLayers(productID uint, warehouseID uint, method models.CostMethod) ([]models.StockCostLayer, error)
+	CreateLayerConsumption(consumption *models.StockLayerConsumption) error
+	FindConsumptionsByMovement(movementID uint) ([]models.StockLayerConsumption, error)
+	GetCostLayerReport(companyID uint, productID uint, warehouseID uint, openOnly bool) ([]models.CostLayerReport, error)
+
+	// Stock Lot
+	CreateLot(lot *models.StockLot) error
+	FindLotByID(id uint) (*models.StockLot, error)
+	FindLotByNumber(productID uint, warehouseID uint, lotNumber string) (*models.StockLot, error)
+	FindAvailableLots(productID uint, warehouseID uint) ([]models.StockLot, error)
+	FindLots(companyID uint, productID uint, warehouseID uint, expiringBefore *time.Time) ([]models.StockLot, error)
+	AdjustLotQuantity(id uint, delta float64) error
+	CreateLotAllocation(allocation *models.StockLotAllocation) error
+	FindLotAllocationsByMovement(movementID uint) ([]models.StockLotAllocation, error)
+
+	// Serial Number
+	CreateSerial(serial *models.SerialNumber) error
+	UpdateSerial(serial *models.SerialNumber) error
+	FindSerial(productID uint, serialNumber string) (*models.SerialNumber, error)
+	FindSerials(companyID uint, productID uint, warehouseID uint, status string) ([]models.SerialNumber, error)
+	FindSerialsByNumber(companyID uint, serialNumber string) ([]models.SerialNumber, error)
+	CreateSerialEvent(event *models.SerialNumberEvent) error
+	FindSerialEvents(serialID uint) ([]models.SerialNumberEvent, error)
+	FindSerialsByMovement(movementID uint) ([]models.SerialNumber, error)
+
+	// Warehouse
+	CreateWarehouse(warehouse *models.Warehouse) error
+	FindWarehouseByID(id uint) (*models.Warehouse, error)
+	FindWarehousesByCompany(companyID uint) ([]models.Warehouse, error)
+	FindDefaultWarehouse(companyID uint) (*models.Warehouse, error)
+	FindTransitWarehouse(companyID uint) (*models.Warehouse, error)
+	UpdateWarehouse(warehouse *models.Warehouse) error
+	DeleteWarehouse(id uint) error
+	AssignUnlocatedStock(companyID uint, warehouseID uint) error
+
+	// Stock Transfer
+	CreateStockTransfer(transfer *models.StockTransfer) error
+	FindStockTransferByID(id uint) (*models.StockTransfer, error)
+	FindStockTransfersByCompany(companyID uint, status string) ([]models.StockTransfer, error)
+	UpdateStockTransfer(transfer *models.StockTransfer) error
+	UpdateStockTransferItem(item *models.StockTransferItem) error
+	GenerateTransferNumber(companyID uint, date time.Time) (string, error)
+
+	// Stock Reservation
+	CreateReservation(reservation *models.StockReservation) error
+	FindReservationByID(id uint) (*models.StockReservation, error)
+	FindReservations(companyID uint, productID uint, warehouseID uint, status string) ([]models.StockReservation, error)
+	UpdateReservation(reservation *models.StockReservation) error
+	SumReservedQuantity(productID uint, warehouseID uint, asOf time.Time) (float64, error)
+	SumReservedQuantities(companyID uint, asOf time.Time) (map[[2]uint]float64, error)
+	ExpireReservations(companyID uint, asOf time.Time) (int64, error)
+	GenerateReservationNumber(companyID uint, date time.Time) (string, error)
+
+	// Landed Cost
+	CreateLandedCost(landedCost *models.LandedCost) error
+	FindLandedCostByID(id uint) (*models.LandedCost, error)
+	FindLandedCostsByCompany(companyID uint, status string) ([]models.LandedCost, error)
+	UpdateLandedCost(landedCost *models.LandedCost) error
+	UpdateLandedCostItem(item *models.LandedCostItem) error
+	GenerateLandedCostNumber(companyID uint, date time.Time) (string, error)
 
 	// Stock Opname
 	CreateStockOpname(opname *models.StockOpname) error
 	FindStockOpnameByID(id uint) (*models.StockOpname, error)
-	FindStockOpnamesByCompany(companyID uint) ([]models.StockOpname, error)
+	FindStockOpnamesByCompany(companyID uint, status string) ([]models.StockOpname, error)
 	UpdateStockOpname(opname *models.StockOpname) error
 	ApproveStockOpname(id uint, approvedBy uint) error
 	GenerateOpnameNumber(companyID uint, date time.Time) (string, error)
+	CreateStockOpnameItem(item *models.StockOpnameItem) error
+	UpdateStockOpnameItem(item *models.StockOpnameItem) error
+	CreateOpnameCounts(counts []models.StockOpnameCount) error
+	FindOpnameCounts(opnameID uint, round int) ([]models.StockOpnameCount, error)
+	FindCountingOpname(productID uint, warehouseID uint) (*models.StockOpname, error)
+
+	// Inventory Reports
+	GetStockPosition(productID uint, warehouseID uint, before time.Time) (float64, float64, error)
+	GetStockCardEntries(productID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockCardEntry, error)
+	FindLastIssueDates(companyID uint, warehouseID uint) (map[uint]time.Time, error)
 
 	// HPP Calculation
-	CalculateHPP(companyID uint, startDate, endDate time.Time) ([]models.HPPCalculation, error)
+	CalculateHPP(companyID uint, warehouseID uint, startDate, endDate time.Time) ([]models.HPPCalculation, error)
 }
 
 type inventoryRepository struct {
@@ -55,7 +160,7 @@ func (r *inventoryRepository) CreateProduct(product *models.Product) error {
 
 func (r *inventoryRepository) FindProductByID(id uint) (*models.Product, error) {
 	var product models.Product
-	err := r.db.Preload("Company").First(&product, id).Error
+	err := r.db.Preload("Company").Preload("Units.Unit").Preload("BOMItems.Component").First(&product, id).Error
 	return &product, err
 }
 
@@ -75,6 +180,63 @@ func (r *inventoryRepository) DeleteProduct(id uint) error {
 	return r.db.Delete(&models.Product{}, id).Error
 }
 
+// Unit of Measure methods
+func (r *inventoryRepository) CreateUnitOfMeasure(unit *models.UnitOfMeasure) error {
+	return r.db.Create(unit).Error
+}
+
+func (r *inventoryRepository) FindUnitOfMeasureByID(id uint) (*models.UnitOfMeasure, error) {
+	var unit models.UnitOfMeasure
+	err := r.db.First(&unit, id).Error
+	return &unit, err
+}
+
+func (r *inventoryRepository) FindUnitsOfMeasureByCompany(companyID uint) ([]models.UnitOfMeasure, error) {
+	var units []models.UnitOfMeasure
+	err := r.db.Where("company_id = ?", companyID).
+		Order("code ASC").
+		Find(&units).Error
+	return units, err
+}
+
+func (r *inventoryRepository) UpdateUnitOfMeasure(unit *models.UnitOfMeasure) error {
+	return r.db.Save(unit).Error
+}
+
+func (r *inventoryRepository) DeleteUnitOfMeasure(id uint) error {
+	return r.db.Delete(&models.UnitOfMeasure{}, id).Error
+}
+
+func (r *inventoryRepository) CountProductUnitsByUnit(unitID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ProductUnit{}).
+		Where("unit_id = ?", unitID).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *inventoryRepository) SaveProductUnit(productUnit *models.ProductUnit) error {
+	return r.db.Omit("Unit").Save(productUnit).Error
+}
+
+func (r *inventoryRepository) FindProductUnitByID(id uint) (*models.ProductUnit, error) {
+	var productUnit models.ProductUnit
+	err := r.db.Preload("Unit").First(&productUnit, id).Error
+	return &productUnit, err
+}
+
+// ClearDefaultProductUnit unsets the purchase or sales default on the
+// product's other units
+func (r *inventoryRepository) ClearDefaultProductUnit(productID uint, column string, exceptID uint) error {
+	return r.db.Model(&models.ProductUnit{}).
+		Where("product_id = ? AND id <> ?", productID, exceptID).
+		Update(column, false).Error
+}
+
+func (r *inventoryRepository) DeleteProductUnit(id uint) error {
+	return r.db.Delete(&models.ProductUnit{}, id).Error
+}
+
 // Stock Movement methods
 func (r *inventoryRepository) CreateStockMovement(movement *models.StockMovement) error {
 	return r.db.Create(movement).Error
@@ -89,20 +251,38 @@ func (r *inventoryRepository) FindStockMovementByID(id uint) (*models.StockMovem
 	return &movement, err
 }
 
-func (r *inventoryRepository) FindStockMovementsByProduct(productID uint, startDate, endDate time.Time) ([]models.StockMovement, error) {
+// SumReturnedQuantity totals the base quantity of the returns made against
+// a stock-in or stock-out
+func (r *inventoryRepository) SumReturnedQuantity(sourceMovementID uint) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.StockMovement{}).
+		Where("source_movement_id = ? AND type IN ?", sourceMovementID, []string{"in", "out"}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+func (r *inventoryRepository) FindStockMovementsByProduct(productID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockMovement, error) {
 	var movements []models.StockMovement
-	err := r.db.Where("product_id = ? AND movement_date BETWEEN ? AND ?", productID, startDate, endDate).
-		Order("movement_date ASC, id ASC").
+	query := r.db.Where("product_id = ? AND movement_date BETWEEN ? AND ?", productID, startDate, endDate)
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	err := query.Order("movement_date ASC, id ASC").
 		Preload("Product").
 		Find(&movements).Error
 	return movements, err
 }
 
-func (r *inventoryRepository) FindStockMovementsByCompany(companyID uint, startDate, endDate time.Time) ([]models.StockMovement, error) {
+func (r *inventoryRepository) FindStockMovementsByCompany(companyID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockMovement, error) {
 	var movements []models.StockMovement
-	err := r.db.Where("company_id = ? AND movement_date BETWEEN ? AND ?", companyID, startDate, endDate).
-		Order("movement_date DESC, id DESC").
+	query := r.db.Where("company_id = ? AND movement_date BETWEEN ? AND ?", companyID, startDate, endDate)
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	err := query.Order("movement_date DESC, id DESC").
 		Preload("Product").
+		Preload("LotAllocations.Lot").
 		Find(&movements).Error
 	return movements, err
 }
@@ -118,6 +298,10 @@ func (r *inventoryRepository) GenerateMovementNumber(companyID uint, movementTyp
 		prefix = "OUT/"
 	case "adjustment":
 		prefix = "ADJ/"
+	case "transfer_out":
+		prefix = "TRO/"
+	case "transfer_in":
+		prefix = "TRI/"
 	default:
 		prefix = "MOV/"
 	}
@@ -135,16 +319,100 @@ func (r *inventoryRepository) GenerateMovementNumber(companyID uint, movementTyp
 	return prefix + fmt.Sprintf("%04d", count+1), nil
 }
 
+func (r *inventoryRepository) SetMovementJournal(id uint, journalID uint) error {
+	return r.db.Model(&models.StockMovement{}).
+		Where("id = ?", id).
+		Update("journal_id", journalID).Error
+}
+
+func (r *inventoryRepository) UpdateMovementCost(id uint, unitCost, totalCost float64) error {
+	return r.db.Model(&models.StockMovement{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"unit_cost":  unitCost,
+			"total_cost": totalCost,
+		}).Error
+}
+
+func (r *inventoryRepository) CountMovementsWithoutJournal(companyID uint, endDate time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.StockMovement{}).
+		Where("company_id = ? AND movement_date <= ? AND journal_id IS NULL AND total_cost != 0", companyID, endDate).
+		Count(&count).Error
+	return count, err
+}
+
+// SumIssuedQuantityByProduct totals stock-out (consumption) per product,
+// transfers between warehouses are not consumption
+func (r *inventoryRepository) SumIssuedQuantityByProduct(companyID uint, startDate, endDate time.Time) (map[uint]float64, error) {
+	var rows []struct {
+		ProductID uint
+		Quantity  float64
+	}
+	err := r.db.Model(&models.StockMovement{}).
+		Select("product_id, SUM(quantity) as quantity").
+		Where("company_id = ? AND type = ? AND movement_date BETWEEN ? AND ?", companyID, "out", startDate, endDate).
+		Group("product_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	issued := make(map[uint]float64)
+	for _, row := range rows {
+		issued[row.ProductID] = row.Quantity
+	}
+	return issued, nil
+}
+
+// Account Mapping methods
+func (r *inventoryRepository) CreateAccountMapping(mapping *models.InventoryAccountMapping) error {
+	return r.db.Create(mapping).Error
+}
+
+func (r *inventoryRepository) FindAccountMappingByID(id uint) (*models.InventoryAccountMapping, error) {
+	var mapping models.InventoryAccountMapping
+	err := r.db.Preload("Product").
+		Preload("InventoryAccount").
+		Preload("COGSAccount").
+		Preload("PurchaseClearingAccount").
+		Preload("VarianceAccount").
+		First(&mapping, id).Error
+	return &mapping, err
+}
+
+func (r *inventoryRepository) FindAccountMappingsByCompany(companyID uint) ([]models.InventoryAccountMapping, error) {
+	var mappings []models.InventoryAccountMapping
+	err := r.db.Where("company_id = ?", companyID).
+		Order("product_id ASC, category ASC").
+		Preload("Product").
+		Preload("InventoryAccount").
+		Preload("COGSAccount").
+		Preload("PurchaseClearingAccount").
+		Preload("VarianceAccount").
+		Find(&mappings).Error
+	return mappings, err
+}
+
+func (r *inventoryRepository) UpdateAccountMapping(mapping *models.InventoryAccountMapping) error {
+	return r.db.Save(mapping).Error
+}
+
+func (r *inventoryRepository) DeleteAccountMapping(id uint) error {
+	return r.db.Delete(&models.InventoryAccountMapping{}, id).Error
+}
+
 // Stock Balance methods
-func (r *inventoryRepository) GetStockBalance(productID uint) (*models.StockBalance, error) {
+func (r *inventoryRepository) GetStockBalance(productID uint, warehouseID uint) (*models.StockBalance, error) {
 	var balance models.StockBalance
-	err := r.db.Where("product_id = ?", productID).
+	err := r.db.Where("product_id = ? AND warehouse_id = ?", productID, warehouseID).
 		Preload("Product").
 		First(&balance).Error
 	
 	if err == gorm.ErrRecordNotFound {
 		return &models.StockBalance{
 			ProductID:   productID,
+			WarehouseID: warehouseID,
 			Quantity:    0,
 			AverageCost: 0,
 			TotalValue:  0,
@@ -154,19 +422,316 @@ func (r *inventoryRepository) GetStockBalance(productID uint) (*models.StockBala
 	return &balance, err
 }
 
+// GetProductStockTotal sums a product's balance over all warehouses
+func (r *inventoryRepository) GetProductStockTotal(productID uint) (*models.StockBalance, error) {
+	var total struct {
+		CompanyID  uint
+		Quantity   float64
+		TotalValue float64
+	}
+	err := r.db.Model(&models.StockBalance{}).
+		Select("MAX(company_id) as company_id, COALESCE(SUM(quantity), 0) as quantity, COALESCE(SUM(total_value), 0) as total_value").
+		Where("product_id = ?", productID).
+		Scan(&total).Error
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &models.StockBalance{
+		CompanyID:  total.CompanyID,
+		ProductID:  productID,
+		Quantity:   total.Quantity,
+		TotalValue: total.TotalValue,
+	}
+	if balance.Quantity > 0 {
+		balance.AverageCost = balance.TotalValue / balance.Quantity
+	}
+	return balance, nil
+}
+
 func (r *inventoryRepository) UpdateStockBalance(balance *models.StockBalance) error {
 	return r.db.Save(balance).Error
 }
 
-func (r *inventoryRepository) GetAllStockBalances(companyID uint) ([]models.StockBalance, error) {
+func (r *inventoryRepository) GetAllStockBalances(companyID uint, warehouseID uint) ([]models.StockBalance, error) {
 	var balances []models.StockBalance
-	err := r.db.Where("company_id = ?", companyID).
-		Preload("Product").
-		Order("product_id ASC").
+	query := r.db.Where("company_id = ?", companyID)
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	err := query.Preload("Product.Units.Unit").
+		Order("product_id ASC, warehouse_id ASC").
 		Find(&balances).Error
 	return balances, err
 }
 
+// Warehouse methods
+func (r *inventoryRepository) CreateWarehouse(warehouse *models.Warehouse) error {
+	return r.db.Create(warehouse).Error
+}
+
+func (r *inventoryRepository) FindWarehouseByID(id uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	err := r.db.First(&warehouse, id).Error
+	return &warehouse, err
+}
+
+func (r *inventoryRepository) FindWarehousesByCompany(companyID uint) ([]models.Warehouse, error) {
+	var warehouses []models.Warehouse
+	err := r.db.Where("company_id = ?", companyID).
+		Order("code ASC").
+		Find(&warehouses).Error
+	return warehouses, err
+}
+
+func (r *inventoryRepository) FindDefaultWarehouse(companyID uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	err := r.db.Where("company_id = ? AND is_default = ?", companyID, true).
+		First(&warehouse).Error
+	return &warehouse, err
+}
+
+func (r *inventoryRepository) FindTransitWarehouse(companyID uint) (*models.Warehouse, error) {
+	var warehouse models.Warehouse
+	err := r.db.Where("company_id = ? AND is_transit = ?", companyID, true).
+		First(&warehouse).Error
+	return &warehouse, err
+}
+
+func (r *inventoryRepository) UpdateWarehouse(warehouse *models.Warehouse) error {
+	return r.db.Save(warehouse).Error
+}
+
+func (r *inventoryRepository) DeleteWarehouse(id uint) error {
+	return r.db.Delete(&models.Warehouse{}, id).Error
+}
+
+// AssignUnlocatedStock moves stock recorded before warehouses existed
+// (warehouse_id = 0) into the given warehouse
+func (r *inventoryRepository) AssignUnlocatedStock(companyID uint, warehouseID uint) error {
+	for _, model := range []interface{}{
+		&models.StockMovement{},
+		&models.StockBalance{},
+		&models.StockCostLayer{},
+		&models.StockOpname{},
+	} {
+		err := r.db.Model(model).
+			Where("company_id = ? AND warehouse_id = 0", companyID).
+			Update("warehouse_id", warehouseID).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Stock Transfer methods
+func (r *inventoryRepository) CreateStockTransfer(transfer *models.StockTransfer) error {
+	return r.db.Create(transfer).Error
+}
+
+func (r *inventoryRepository) FindStockTransferByID(id uint) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	err := r.db.Preload("Items.Product").
+		Preload("FromWarehouse").
+		Preload("ToWarehouse").
+		Preload("User").
+		First(&transfer, id).Error
+	return &transfer, err
+}
+
+func (r *inventoryRepository) FindStockTransfersByCompany(companyID uint, status string) ([]models.StockTransfer, error) {
+	var transfers []models.StockTransfer
+	query := r.db.Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("transfer_date DESC, id DESC").
+		Preload("FromWarehouse").
+		Preload("ToWarehouse").
+		Preload("User").
+		Find(&transfers).Error
+	return transfers, err
+}
+
+func (r *inventoryRepository) UpdateStockTransfer(transfer *models.StockTransfer) error {
+	return r.db.Omit("Items").Save(transfer).Error
+}
+
+func (r *inventoryRepository) UpdateStockTransferItem(item *models.StockTransferItem) error {
+	return r.db.Save(item).Error
+}
+
+func (r *inventoryRepository) GenerateTransferNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "TRF/" + date.Format("200601/")
+
+	err := r.db.Model(&models.StockTransfer{}).
+		Where("company_id = ? AND transfer_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Stock Reservation methods
+func (r *inventoryRepository) CreateReservation(reservation *models.StockReservation) error {
+	return r.db.Create(reservation).Error
+}
+
+func (r *inventoryRepository) FindReservationByID(id uint) (*models.StockReservation, error) {
+	var reservation models.StockReservation
+	err := r.db.Preload("Product").
+		Preload("Warehouse").
+		Preload("User").
+		First(&reservation, id).Error
+	return &reservation, err
+}
+
+func (r *inventoryRepository) FindReservations(companyID uint, productID uint, warehouseID uint, status string) ([]models.StockReservation, error) {
+	var reservations []models.StockReservation
+	query := r.db.Where("company_id = ?", companyID)
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("created_at DESC").
+		Preload("Product").
+		Preload("Warehouse").
+		Find(&reservations).Error
+	return reservations, err
+}
+
+func (r *inventoryRepository) UpdateReservation(reservation *models.StockReservation) error {
+	return r.db.Omit("Product", "Warehouse", "User").Save(reservation).Error
+}
+
+// activeReservations scopes to reservations still holding stock at asOf
+func activeReservations(db *gorm.DB, asOf time.Time) *gorm.DB {
+	return db.Model(&models.StockReservation{}).
+		Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", models.ReservationStatusActive, asOf)
+}
+
+// SumReservedQuantity is the unfulfilled quantity reserved for a product in
+// a warehouse (0 = all warehouses)
+func (r *inventoryRepository) SumReservedQuantity(productID uint, warehouseID uint, asOf time.Time) (float64, error) {
+	var reserved float64
+	query := activeReservations(r.db, asOf).
+		Select("COALESCE(SUM(quantity - fulfilled_quantity), 0)").
+		Where("product_id = ?", productID)
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	err := query.Scan(&reserved).Error
+	return reserved, err
+}
+
+// SumReservedQuantities maps {product ID, warehouse ID} to the unfulfilled
+// quantity reserved there
+func (r *inventoryRepository) SumReservedQuantities(companyID uint, asOf time.Time) (map[[2]uint]float64, error) {
+	var rows []struct {
+		ProductID   uint
+		WarehouseID uint
+		Reserved    float64
+	}
+
+	err := activeReservations(r.db, asOf).
+		Select("product_id, warehouse_id, SUM(quantity - fulfilled_quantity) as reserved").
+		Where("company_id = ?", companyID).
+		Group("product_id, warehouse_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make(map[[2]uint]float64, len(rows))
+	for _, row := range rows {
+		reserved[[2]uint{row.ProductID, row.WarehouseID}] = row.Reserved
+	}
+	return reserved, nil
+}
+
+// ExpireReservations marks active reservations past their expiry as expired
+func (r *inventoryRepository) ExpireReservations(companyID uint, asOf time.Time) (int64, error) {
+	result := r.db.Model(&models.StockReservation{}).
+		Where("company_id = ? AND status = ? AND expires_at IS NOT NULL AND expires_at <= ?", companyID, models.ReservationStatusActive, asOf).
+		Update("status", models.ReservationStatusExpired)
+	return result.RowsAffected, result.Error
+}
+
+func (r *inventoryRepository) GenerateReservationNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "RSV/" + date.Format("200601/")
+
+	err := r.db.Model(&models.StockReservation{}).
+		Where("company_id = ? AND reservation_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Landed Cost methods
+func (r *inventoryRepository) CreateLandedCost(landedCost *models.LandedCost) error {
+	return r.db.Create(landedCost).Error
+}
+
+func (r *inventoryRepository) FindLandedCostByID(id uint) (*models.LandedCost, error) {
+	var landedCost models.LandedCost
+	err := r.db.Preload("Charges.Account").
+		Preload("Items.Movement").
+		Preload("Items.Product").
+		Preload("User").
+		First(&landedCost, id).Error
+	return &landedCost, err
+}
+
+func (r *inventoryRepository) FindLandedCostsByCompany(companyID uint, status string) ([]models.LandedCost, error) {
+	var landedCosts []models.LandedCost
+	query := r.db.Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("document_date DESC, id DESC").
+		Preload("Charges").
+		Find(&landedCosts).Error
+	return landedCosts, err
+}
+
+func (r *inventoryRepository) UpdateLandedCost(landedCost *models.LandedCost) error {
+	return r.db.Omit("Charges", "Items").Save(landedCost).Error
+}
+
+func (r *inventoryRepository) UpdateLandedCostItem(item *models.LandedCostItem) error {
+	return r.db.Omit("Movement", "Product").Save(item).Error
+}
+
+func (r *inventoryRepository) GenerateLandedCostNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "LC/" + date.Format("200601/")
+
+	err := r.db.Model(&models.LandedCost{}).
+		Where("company_id = ? AND document_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
 // Stock Opname methods
 func (r *inventoryRepository) CreateStockOpname(opname *models.StockOpname) error {
 	return r.db.Create(opname).Error
@@ -174,23 +739,67 @@ func (r *inventoryRepository) CreateStockOpname(opname *models.StockOpname) erro
 
 func (r *inventoryRepository) FindStockOpnameByID(id uint) (*models.StockOpname, error) {
 	var opname models.StockOpname
-	err := r.db.Preload("Items.Product").
+	err := r.db.Preload("Items", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).
+		Preload("Items.Product").
+		Preload("Warehouse").
 		Preload("User").
 		First(&opname, id).Error
 	return &opname, err
 }
 
-func (r *inventoryRepository) FindStockOpnamesByCompany(companyID uint) ([]models.StockOpname, error) {
+func (r *inventoryRepository) FindStockOpnamesByCompany(companyID uint, status string) ([]models.StockOpname, error) {
 	var opnames []models.StockOpname
-	err := r.db.Where("company_id = ?", companyID).
-		Order("opname_date DESC").
+	query := r.db.Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("opname_date DESC").
+		Preload("Warehouse").
 		Preload("User").
 		Find(&opnames).Error
 	return opnames, err
 }
 
 func (r *inventoryRepository) UpdateStockOpname(opname *models.StockOpname) error {
-	return r.db.Save(opname).Error
+	return r.db.Omit("Items").Save(opname).Error
+}
+
+func (r *inventoryRepository) CreateStockOpnameItem(item *models.StockOpnameItem) error {
+	return r.db.Omit("Product").Create(item).Error
+}
+
+func (r *inventoryRepository) UpdateStockOpnameItem(item *models.StockOpnameItem) error {
+	return r.db.Omit("Product").Save(item).Error
+}
+
+func (r *inventoryRepository) CreateOpnameCounts(counts []models.StockOpnameCount) error {
+	return r.db.Omit("Product", "User").Create(&counts).Error
+}
+
+// FindOpnameCounts lists the counts of an opname, of one round or all (0)
+func (r *inventoryRepository) FindOpnameCounts(opnameID uint, round int) ([]models.StockOpnameCount, error) {
+	var counts []models.StockOpnameCount
+	query := r.db.Where("opname_id = ?", opnameID)
+	if round > 0 {
+		query = query.Where("count_round = ?", round)
+	}
+	err := query.Order("id ASC").
+		Preload("Product").
+		Preload("User").
+		Find(&counts).Error
+	return counts, err
+}
+
+// FindCountingOpname returns the opname currently counting the product in
+// the warehouse, if any
+func (r *inventoryRepository) FindCountingOpname(productID uint, warehouseID uint) (*models.StockOpname, error) {
+	var opname models.StockOpname
+	err := r.db.Joins("JOIN stock_opname_items i ON i.opname_id = stock_opnames.id AND i.deleted_at IS NULL").
+		Where("stock_opnames.status = ? AND stock_opnames.warehouse_id = ? AND i.product_id = ?", models.OpnameStatusCounting, warehouseID, productID).
+		First(&opname).Error
+	return &opname, err
 }
 
 func (r *inventoryRepository) ApproveStockOpname(id uint, approvedBy uint) error {
@@ -198,7 +807,7 @@ func (r *inventoryRepository) ApproveStockOpname(id uint, approvedBy uint) error
 	return r.db.Model(&models.StockOpname{}).
 		Where("id = ?", id).
 		Updates(map[string]interface{}{
-			"status":      "approved",
+			"status":      models.OpnameStatusApproved,
 			"approved_by": approvedBy,
 			"approved_at": now,
 		}).Error
@@ -219,60 +828,448 @@ func (r *inventoryRepository) GenerateOpnameNumber(companyID uint, date time.Tim
 	return prefix + fmt.Sprintf("%04d", count+1), nil
 }
 
+// Cost Layer methods
+func (r *inventoryRepository) CreateCostLayer(layer *models.StockCostLayer) error {
+	return r.db.Create(layer).Error
+}
+
+func (r *inventoryRepository) ConsumeCostLayer(id uint, quantity float64) error {
+	return r.db.Model(&models.StockCostLayer{}).
+		Where("id = ?", id).
+		Update("remaining_quantity", gorm.Expr("remaining_quantity - ?", quantity)).Error
+}
+
+func (r *inventoryRepository) FindCostLayerByID(id uint) (*models.StockCostLayer, error) {
+	var layer models.StockCostLayer
+	err := r.db.First(&layer, id).Error
+	return &layer, err
+}
+
+func (r *inventoryRepository) FindCostLayerByMovement(movementID uint) (*models.StockCostLayer, error) {
+	var layer models.StockCostLayer
+	err := r.db.Where("movement_id = ?", movementID).First(&layer).Error
+	return &layer, err
+}
+
+// FindCostLayersByMovement returns every layer a receipt created, transfer
+// receipts having one per source layer
+func (r *inventoryRepository) FindCostLayersByMovement(movementID uint) ([]models.StockCostLayer, error) {
+	var layers []models.StockCostLayer
+	err := r.db.Where("movement_id = ?", movementID).
+		Order("id ASC").
+		Find(&layers).Error
+	return layers, err
+}
+
+// FindCostLayersBySource returns the layers transfers built from a layer
+func (r *inventoryRepository) FindCostLayersBySource(sourceLayerID uint) ([]models.StockCostLayer, error) {
+	var layers []models.StockCostLayer
+	err := r.db.Where("source_layer_id = ?", sourceLayerID).
+		Order("id ASC").
+		Find(&layers).Error
+	return layers, err
+}
+
+func (r *inventoryRepository) UpdateCostLayer(layer *models.StockCostLayer) error {
+	return r.db.Omit("Product", "Movement").Save(layer).Error
+}
+
+// AddLayerConsumptionCost re-costs what was already issued from a layer
+func (r *inventoryRepository) AddLayerConsumptionCost(layerID uint, perUnit float64) error {
+	return r.db.Model(&models.StockLayerConsumption{}).
+		Where("layer_id = ?", layerID).
+		Updates(map[string]interface{}{
+			"unit_cost":  gorm.Expr("unit_cost + ?", perUnit),
+			"total_cost": gorm.Expr("total_cost + ROUND(quantity * ?, 2)", perUnit),
+		}).Error
+}
+
+func (r *inventoryRepository) FindOpenCostLayers(productID uint, warehouseID uint, method models.CostMethod) ([]models.StockCostLayer, error) {
+	var layers []models.StockCostLayer
+
+	// LIFO consumes the newest receipt first, FIFO and average the oldest;
+	// transferred stock keeps the date of its original receipt
+	order := "COALESCE(origin_date, received_date) ASC, id ASC"
+	if method == models.CostMethodLIFO {
+		order = "COALESCE(origin_date, received_date) DESC, id DESC"
+	}
+
+	err := r.db.Where("product_id = ? AND warehouse_id = ? AND remaining_quantity > 0", productID, warehouseID).
+		Order(order).
+		Find(&layers).Error
+	return layers, err
+}
+
+// Stock Lot methods
+func (r *inventoryRepository) CreateLot(lot *models.StockLot) error {
+	return r.db.Create(lot).Error
+}
+
+func (r *inventoryRepository) FindLotByID(id uint) (*models.StockLot, error) {
+	var lot models.StockLot
+	err := r.db.First(&lot, id).Error
+	return &lot, err
+}
+
+func (r *inventoryRepository) FindLotByNumber(productID uint, warehouseID uint, lotNumber string) (*models.StockLot, error) {
+	var lot models.StockLot
+	err := r.db.Where("product_id = ? AND warehouse_id = ? AND lot_number = ?", productID, warehouseID, lotNumber).
+		First(&lot).Error
+	return &lot, err
+}
+
+// FindAvailableLots returns lots with stock, first-expiring first (FEFO);
+// lots without expiry date go last
+func (r *inventoryRepository) FindAvailableLots(productID uint, warehouseID uint) ([]models.StockLot, error) {
+	var lots []models.StockLot
+	err := r.db.Where("product_id = ? AND warehouse_id = ? AND quantity > 0", productID, warehouseID).
+		Order("expiry_date IS NULL, expiry_date ASC, id ASC").
+		Find(&lots).Error
+	return lots, err
+}
+
+func (r *inventoryRepository) FindLots(companyID uint, productID uint, warehouseID uint, expiringBefore *time.Time) ([]models.StockLot, error) {
+	var lots []models.StockLot
+	query := r.db.Where("company_id = ? AND quantity > 0", companyID)
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	if expiringBefore != nil {
+		query = query.Where("expiry_date IS NOT NULL AND expiry_date <= ?", *expiringBefore)
+	}
+	err := query.Order("expiry_date IS NULL, expiry_date ASC, id ASC").
+		Preload("Product").
+		Preload("Warehouse").
+		Find(&lots).Error
+	return lots, err
+}
+
+func (r *inventoryRepository) AdjustLotQuantity(id uint, delta float64) error {
+	return r.db.Model(&models.StockLot{}).
+		Where("id = ?", id).
+		Update("quantity", gorm.Expr("quantity + ?", delta)).Error
+}
+
+func (r *inventoryRepository) CreateLotAllocation(allocation *models.StockLotAllocation) error {
+	return r.db.Omit("Lot").Create(allocation).Error
+}
+
+func (r *inventoryRepository) FindLotAllocationsByMovement(movementID uint) ([]models.StockLotAllocation, error) {
+	var allocations []models.StockLotAllocation
+	err := r.db.Where("movement_id = ?", movementID).
+		Preload("Lot").
+		Order("id ASC").
+		Find(&allocations).Error
+	return allocations, err
+}
+
+// Serial Number methods
+func (r *inventoryRepository) CreateSerial(serial *models.SerialNumber) error {
+	return r.db.Create(serial).Error
+}
+
+func (r *inventoryRepository) UpdateSerial(serial *models.SerialNumber) error {
+	return r.db.Omit("Product").Save(serial).Error
+}
+
+func (r *inventoryRepository) FindSerial(productID uint, serialNumber string) (*models.SerialNumber, error) {
+	var serial models.SerialNumber
+	err := r.db.Where("product_id = ? AND serial_number = ?", productID, serialNumber).
+		First(&serial).Error
+	return &serial, err
+}
+
+func (r *inventoryRepository) FindSerials(companyID uint, productID uint, warehouseID uint, status string) ([]models.SerialNumber, error) {
+	var serials []models.SerialNumber
+	query := r.db.Where("company_id = ?", companyID)
+	if productID != 0 {
+		query = query.Where("product_id = ?", productID)
+	}
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("product_id ASC, serial_number ASC").
+		Preload("Product").
+		Find(&serials).Error
+	return serials, err
+}
+
+func (r *inventoryRepository) FindSerialsByNumber(companyID uint, serialNumber string) ([]models.SerialNumber, error) {
+	var serials []models.SerialNumber
+	err := r.db.Where("company_id = ? AND serial_number = ?", companyID, serialNumber).
+		Preload("Product").
+		Find(&serials).Error
+	return serials, err
+}
+
+func (r *inventoryRepository) CreateSerialEvent(event *models.SerialNumberEvent) error {
+	return r.db.Omit("Movement").Create(event).Error
+}
+
+func (r *inventoryRepository) FindSerialEvents(serialID uint) ([]models.SerialNumberEvent, error) {
+	var events []models.SerialNumberEvent
+	err := r.db.Where("serial_id = ?", serialID).
+		Preload("Movement").
+		Order("event_date ASC, id ASC").
+		Find(&events).Error
+	return events, err
+}
+
+// FindSerialsByMovement returns the units a movement moved
+func (r *inventoryRepository) FindSerialsByMovement(movementID uint) ([]models.SerialNumber, error) {
+	var serials []models.SerialNumber
+	err := r.db.Joins("JOIN serial_number_events e ON e.serial_id = serial_numbers.id").
+		Where("e.movement_id = ? AND e.deleted_at IS NULL", movementID).
+		Order("serial_numbers.serial_number ASC").
+		Find(&serials).Error
+	return serials, err
+}
+
+func (r *inventoryRepository) CreateLayerConsumption(consumption *models.StockLayerConsumption) error {
+	return r.db.Create(consumption).Error
+}
+
+func (r *inventoryRepository) FindConsumptionsByMovement(movementID uint) ([]models.StockLayerConsumption, error) {
+	var consumptions []models.StockLayerConsumption
+	err := r.db.Where("movement_id = ?", movementID).
+		Order("id ASC").
+		Find(&consumptions).Error
+	return consumptions, err
+}
+
+func (r *inventoryRepository) GetCostLayerReport(companyID uint, productID uint, warehouseID uint, openOnly bool) ([]models.CostLayerReport, error) {
+	var rows []models.CostLayerReport
+
+	query := r.db.Table("stock_cost_layers l").
+		Select(`l.id as layer_id,
+			p.id as product_id,
+			p.code as product_code,
+			p.name as product_name,
+			l.warehouse_id as warehouse_id,
+			COALESCE(w.code, '') as warehouse_code,
+			p.cost_method as cost_method,
+			COALESCE(m.movement_number, 'OPENING') as movement_number,
+			l.received_date as received_at,
+			COALESCE(l.origin_date, l.received_date) as origin_at,
+			l.quantity as quantity,
+			l.remaining_quantity as remaining_quantity,
+			l.unit_cost as unit_cost,
+			l.remaining_quantity * l.unit_cost as remaining_value`).
+		Joins("JOIN products p ON p.id = l.product_id").
+		Joins("LEFT JOIN stock_movements m ON m.id = l.movement_id").
+		Joins("LEFT JOIN warehouses w ON w.id = l.warehouse_id").
+		Where("l.company_id = ? AND l.deleted_at IS NULL", companyID)
+
+	if productID != 0 {
+		query = query.Where("l.product_id = ?", productID)
+	}
+	if warehouseID != 0 {
+		query = query.Where("l.warehouse_id = ?", warehouseID)
+	}
+	if openOnly {
+		query = query.Where("l.remaining_quantity > 0")
+	}
+
+	var raw []struct {
+		models.CostLayerReport
+		ReceivedAt time.Time
+		OriginAt   time.Time
+	}
+	err := query.Order("p.code ASC, l.received_date ASC, l.id ASC").Scan(&raw).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range raw {
+		row.CostLayerReport.ReceivedDate = row.ReceivedAt.Format("2006-01-02")
+		row.CostLayerReport.OriginDate = row.OriginAt.Format("2006-01-02")
+		rows = append(rows, row.CostLayerReport)
+	}
+
+	return rows, nil
+}
+
+// Inventory Report methods
+
+// GetStockPosition returns quantity and value received minus issued before
+// a date, from the cost layers and their consumptions. Opening layers built
+// from pre-layer balances carry no movement and always count as opening.
+func (r *inventoryRepository) GetStockPosition(productID uint, warehouseID uint, before time.Time) (float64, float64, error) {
+	var received, issued struct {
+		Quantity float64
+		Value    float64
+	}
+
+	layers := r.db.Table("stock_cost_layers").
+		Select("COALESCE(SUM(quantity), 0) as quantity, COALESCE(SUM(quantity * unit_cost), 0) as value").
+		Where("product_id = ? AND deleted_at IS NULL AND (received_date < ? OR movement_id IS NULL)", productID, before)
+	consumptions := r.db.Table("stock_layer_consumptions c").
+		Select("COALESCE(SUM(c.quantity), 0) as quantity, COALESCE(SUM(c.total_cost), 0) as value").
+		Joins("JOIN stock_cost_layers l ON l.id = c.layer_id").
+		Where("c.product_id = ? AND c.deleted_at IS NULL AND c.consumed_date < ?", productID, before)
+	if warehouseID != 0 {
+		layers = layers.Where("warehouse_id = ?", warehouseID)
+		consumptions = consumptions.Where("l.warehouse_id = ?", warehouseID)
+	}
+
+	if err := layers.Scan(&received).Error; err != nil {
+		return 0, 0, err
+	}
+	if err := consumptions.Scan(&issued).Error; err != nil {
+		return 0, 0, err
+	}
+
+	return received.Quantity - issued.Quantity, received.Value - issued.Value, nil
+}
+
+// GetStockCardEntries lists each movement in the period with the quantity
+// and value it added through cost layers or took through consumptions
+func (r *inventoryRepository) GetStockCardEntries(productID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockCardEntry, error) {
+	var raw []struct {
+		models.StockCardEntry
+		MovementDate time.Time
+	}
+
+	query := r.db.Table("stock_movements m").
+		Select(`m.id as movement_id,
+			m.movement_number as movement_number,
+			m.movement_date as movement_date,
+			m.type as type,
+			m.warehouse_id as warehouse_id,
+			m.reference as reference,
+			m.party as party,
+			m.notes as notes,
+			COALESCE(l.quantity, 0) as quantity_in,
+			COALESCE(l.value, 0) as value_in,
+			COALESCE(c.quantity, 0) as quantity_out,
+			COALESCE(c.value, 0) as value_out`).
+		Joins(`LEFT JOIN (SELECT movement_id, SUM(quantity) as quantity, SUM(quantity * unit_cost) as value
+			FROM stock_cost_layers WHERE deleted_at IS NULL GROUP BY movement_id) l ON l.movement_id = m.id`).
+		Joins(`LEFT JOIN (SELECT movement_id, SUM(quantity) as quantity, SUM(total_cost) as value
+			FROM stock_layer_consumptions WHERE deleted_at IS NULL GROUP BY movement_id) c ON c.movement_id = m.id`).
+		Where("m.product_id = ? AND m.deleted_at IS NULL AND m.movement_date BETWEEN ? AND ?", productID, startDate, endDate)
+	if warehouseID != 0 {
+		query = query.Where("m.warehouse_id = ?", warehouseID)
+	}
+
+	if err := query.Order("m.movement_date ASC, m.id ASC").Scan(&raw).Error; err != nil {
+		return nil, err
+	}
+
+	entries := make([]models.StockCardEntry, 0, len(raw))
+	for _, row := range raw {
+		row.StockCardEntry.Date = row.MovementDate.Format("2006-01-02")
+		entries = append(entries, row.StockCardEntry)
+	}
+
+	return entries, nil
+}
+
+// FindLastIssueDates returns the latest stock-out date per product
+func (r *inventoryRepository) FindLastIssueDates(companyID uint, warehouseID uint) (map[uint]time.Time, error) {
+	var rows []struct {
+		ProductID uint
+		LastIssue time.Time
+	}
+
+	query := r.db.Model(&models.StockMovement{}).
+		Select("product_id, MAX(movement_date) as last_issue").
+		Where("company_id = ? AND type = ?", companyID, "out")
+	if warehouseID != 0 {
+		query = query.Where("warehouse_id = ?", warehouseID)
+	}
+
+	if err := query.Group("product_id").Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	dates := make(map[uint]time.Time, len(rows))
+	for _, row := range rows {
+		dates[row.ProductID] = row.LastIssue
+	}
+	return dates, nil
+}
+
 // HPP Calculation
-func (r *inventoryRepository) CalculateHPP(companyID uint, startDate, endDate time.Time) ([]models.HPPCalculation, error) {
+func (r *inventoryRepository) CalculateHPP(companyID uint, warehouseID uint, startDate, endDate time.Time) ([]models.HPPCalculation, error) {
 	var calculations []models.HPPCalculation
 
-	// This is a simplified calculation - you may need to adjust based on cost method
+	// Receipts come from cost layers and issues from layer consumptions, so
+	// COGS follows each product's cost method. Transfers between warehouses
+	// are reported separately from purchases and COGS.
+	layerWhere := "l.company_id = ? AND l.deleted_at IS NULL"
+	consumptionWhere := "c.company_id = ? AND c.deleted_at IS NULL"
+	scope := []interface{}{companyID}
+	if warehouseID != 0 {
+		layerWhere += " AND l.warehouse_id = ?"
+		consumptionWhere += " AND m.warehouse_id = ?"
+		scope = append(scope, warehouseID)
+	}
+
+	layers := func(condition string) string {
+		return `SELECT l.product_id, SUM(l.quantity) as qty, SUM(l.quantity * l.unit_cost) as value
+			FROM stock_cost_layers l
+			LEFT JOIN stock_movements m ON m.id = l.movement_id
+			WHERE ` + layerWhere + ` AND ` + condition + `
+			GROUP BY l.product_id`
+	}
+	consumptions := func(condition string) string {
+		return `SELECT c.product_id, SUM(c.quantity) as qty, SUM(c.total_cost) as value
+			FROM stock_layer_consumptions c
+			JOIN stock_movements m ON m.id = c.movement_id
+			WHERE ` + consumptionWhere + ` AND ` + condition + `
+			GROUP BY c.product_id`
+	}
+
+	var args []interface{}
+	with := func(extra ...interface{}) []interface{} {
+		return append(append([]interface{}{}, scope...), extra...)
+	}
+	args = append(args, with(startDate)...)
+	args = append(args, with(startDate)...)
+	args = append(args, with(startDate, endDate)...)
+	args = append(args, with(startDate, endDate)...)
+	args = append(args, with(startDate, endDate)...)
+	args = append(args, with(startDate, endDate)...)
+	args = append(args, with(endDate)...)
+	args = append(args, with(endDate)...)
+	args = append(args, companyID)
+
 	err := r.db.Raw(`
 		SELECT 
 			p.id as product_id,
 			p.code as product_code,
 			p.name as product_name,
-			COALESCE(beginning.qty, 0) as beginning_stock,
-			COALESCE(beginning.value, 0) as beginning_value,
-			COALESCE(purchases.qty, 0) as purchases,
-			COALESCE(purchases.value, 0) as purchase_value,
-			COALESCE(sales.qty, 0) as sales,
-			COALESCE(sales.value, 0) as cogs,
-			COALESCE(ending.qty, 0) as ending_stock,
-			COALESCE(ending.value, 0) as ending_value
+			COALESCE(rb.qty, 0) - COALESCE(cb.qty, 0) as beginning_stock,
+			COALESCE(rb.value, 0) - COALESCE(cb.value, 0) as beginning_value,
+			COALESCE(rp.qty, 0) as purchases,
+			COALESCE(rp.value, 0) as purchase_value,
+			COALESCE(cp.qty, 0) as sales,
+			COALESCE(cp.value, 0) as cogs,
+			COALESCE(ti.qty, 0) as transfer_in,
+			COALESCE(ti.value, 0) as transfer_in_value,
+			COALESCE(tr.qty, 0) as transfer_out,
+			COALESCE(tr.value, 0) as transfer_out_value,
+			COALESCE(re.qty, 0) - COALESCE(ce.qty, 0) as ending_stock,
+			COALESCE(re.value, 0) - COALESCE(ce.value, 0) as ending_value
 		FROM products p
-		LEFT JOIN (
-			SELECT product_id, 
-				SUM(CASE WHEN type = 'in' THEN quantity ELSE -quantity END) as qty,
-				SUM(CASE WHEN type = 'in' THEN total_cost ELSE -total_cost END) as value
-			FROM stock_movements
-			WHERE company_id = ? AND movement_date < ?
-			GROUP BY product_id
-		) beginning ON p.id = beginning.product_id
-		LEFT JOIN (
-			SELECT product_id,
-				SUM(quantity) as qty,
-				SUM(total_cost) as value
-			FROM stock_movements
-			WHERE company_id = ? AND type = 'in' AND movement_date BETWEEN ? AND ?
-			GROUP BY product_id
-		) purchases ON p.id = purchases.product_id
-		LEFT JOIN (
-			SELECT product_id,
-				SUM(quantity) as qty,
-				SUM(total_cost) as value
-			FROM stock_movements
-			WHERE company_id = ? AND type = 'out' AND movement_date BETWEEN ? AND ?
-			GROUP BY product_id
-		) sales ON p.id = sales.product_id
-		LEFT JOIN (
-			SELECT product_id,
-				SUM(CASE WHEN type = 'in' THEN quantity ELSE -quantity END) as qty,
-				SUM(CASE WHEN type = 'in' THEN total_cost ELSE -total_cost END) as value
-			FROM stock_movements
-			WHERE company_id = ? AND movement_date <= ?
-			GROUP BY product_id
-		) ending ON p.id = ending.product_id
-		WHERE p.company_id = ?
+		LEFT JOIN (`+layers("l.received_date < ?")+`) rb ON p.id = rb.product_id
+		LEFT JOIN (`+consumptions("c.consumed_date < ?")+`) cb ON p.id = cb.product_id
+		LEFT JOIN (`+layers("l.received_date BETWEEN ? AND ? AND COALESCE(m.type, 'in') != 'transfer_in'")+`) rp ON p.id = rp.product_id
+		LEFT JOIN (`+consumptions("c.consumed_date BETWEEN ? AND ? AND m.type != 'transfer_out'")+`) cp ON p.id = cp.product_id
+		LEFT JOIN (`+layers("l.received_date BETWEEN ? AND ? AND m.type = 'transfer_in'")+`) ti ON p.id = ti.product_id
+		LEFT JOIN (`+consumptions("c.consumed_date BETWEEN ? AND ? AND m.type = 'transfer_out'")+`) tr ON p.id = tr.product_id
+		LEFT JOIN (`+layers("l.received_date <= ?")+`) re ON p.id = re.product_id
+		LEFT JOIN (`+consumptions("c.consumed_date <= ?")+`) ce ON p.id = ce.product_id
+		WHERE p.company_id = ? AND p.deleted_at IS NULL
 		ORDER BY p.code ASC
-	`, companyID, startDate, companyID, startDate, endDate, companyID, startDate, endDate, companyID, endDate, companyID).Scan(&calculations).Error
+	`, args...).Scan(&calculations).Error
 
 	return calculations, err
 }
\ No newline at end of file
@@ -0,0 +1,178 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoanRepository interface {
+	// Loans
+	CreateLoan(loan *models.Loan) error
+	FindLoanByID(id uint) (*models.Loan, error)
+	FindLoans(companyID uint, status string) ([]models.Loan, error)
+	FindActiveLoans(companyID uint, startedBy time.Time) ([]models.Loan, error)
+	UpdateLoan(loan *models.Loan) error
+	GenerateLoanNumber(companyID uint, date time.Time) (string, error)
+
+	// Installments
+	UpdateLoanInstallment(installment *models.LoanInstallment) error
+	ReplaceInstallmentsFrom(loanID uint, installmentNumber int, installments []models.LoanInstallment) error
+	FindUnpaidInstallmentsDueBy(companyID uint, dueBy time.Time) ([]models.LoanInstallment, error)
+
+	// Rate Changes
+	CreateLoanRateChange(change *models.LoanRateChange) error
+	FindLoanRateChanges(loanID uint) ([]models.LoanRateChange, error)
+
+	// Reclassifications
+	CreateLoanReclassification(reclassification *models.LoanReclassification) error
+	FindLoanReclassificationByID(id uint) (*models.LoanReclassification, error)
+	FindLoanReclassifications(companyID uint) ([]models.LoanReclassification, error)
+	FindLatestLoanReclassification(companyID uint) (*models.LoanReclassification, error)
+}
+
+type loanRepository struct {
+	db *gorm.DB
+}
+
+func NewLoanRepository(db *gorm.DB) LoanRepository {
+	return &loanRepository{db: db}
+}
+
+// Loan methods
+func (r *loanRepository) CreateLoan(loan *models.Loan) error {
+	return r.db.Omit("Account").Create(loan).Error
+}
+
+func (r *loanRepository) FindLoanByID(id uint) (*models.Loan, error) {
+	var loan models.Loan
+	err := r.db.Preload("Installments", func(db *gorm.DB) *gorm.DB {
+		return db.Order("installment_number ASC")
+	}).
+		Preload("Account").
+		Preload("User").
+		First(&loan, id).Error
+	return &loan, err
+}
+
+func (r *loanRepository) FindLoans(companyID uint, status string) ([]models.Loan, error) {
+	var loans []models.Loan
+	query := r.db.Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("start_date ASC, id ASC").
+		Preload("Account").
+		Find(&loans).Error
+	return loans, err
+}
+
+// FindActiveLoans returns the loans still being repaid that started by the
+// date, with their schedules
+func (r *loanRepository) FindActiveLoans(companyID uint, startedBy time.Time) ([]models.Loan, error) {
+	var loans []models.Loan
+	err := r.db.Where("company_id = ? AND status = ? AND start_date <= ?", companyID, models.LoanStatusActive, startedBy).
+		Order("start_date ASC, id ASC").
+		Preload("Installments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("installment_number ASC")
+		}).
+		Find(&loans).Error
+	return loans, err
+}
+
+func (r *loanRepository) UpdateLoan(loan *models.Loan) error {
+	return r.db.Omit("Installments", "Account", "User").Save(loan).Error
+}
+
+func (r *loanRepository) GenerateLoanNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "LN/" + date.Format("200601/")
+
+	err := r.db.Unscoped().Model(&models.Loan{}).
+		Where("company_id = ? AND loan_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Installment methods
+func (r *loanRepository) UpdateLoanInstallment(installment *models.LoanInstallment) error {
+	return r.db.Omit("Loan").Save(installment).Error
+}
+
+// ReplaceInstallmentsFrom lays out the unpaid installments from the given
+// number on again
+func (r *loanRepository) ReplaceInstallmentsFrom(loanID uint, installmentNumber int, installments []models.LoanInstallment) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("loan_id = ? AND installment_number >= ? AND is_paid = ?", loanID, installmentNumber, false).Delete(&models.LoanInstallment{}).Error; err != nil {
+			return err
+		}
+		if len(installments) == 0 {
+			return nil
+		}
+		return tx.Omit("Loan").Create(&installments).Error
+	})
+}
+
+// FindUnpaidInstallmentsDueBy returns the unpaid installments of active
+// loans falling due by the date, earliest first
+func (r *loanRepository) FindUnpaidInstallmentsDueBy(companyID uint, dueBy time.Time) ([]models.LoanInstallment, error) {
+	var installments []models.LoanInstallment
+	err := r.db.Joins("JOIN loans ON loans.id = loan_installments.loan_id AND loans.deleted_at IS NULL").
+		Where("loans.company_id = ? AND loans.status = ?", companyID, models.LoanStatusActive).
+		Where("loan_installments.is_paid = ? AND loan_installments.due_date <= ?", false, dueBy).
+		Order("loan_installments.due_date ASC, loan_installments.id ASC").
+		Preload("Loan").
+		Find(&installments).Error
+	return installments, err
+}
+
+// Rate Change methods
+func (r *loanRepository) CreateLoanRateChange(change *models.LoanRateChange) error {
+	return r.db.Create(change).Error
+}
+
+func (r *loanRepository) FindLoanRateChanges(loanID uint) ([]models.LoanRateChange, error) {
+	var changes []models.LoanRateChange
+	err := r.db.Where("loan_id = ?", loanID).
+		Order("effective_date ASC, id ASC").
+		Preload("User").
+		Find(&changes).Error
+	return changes, err
+}
+
+// Reclassification methods
+func (r *loanRepository) CreateLoanReclassification(reclassification *models.LoanReclassification) error {
+	return r.db.Omit("Lines.Loan").Create(reclassification).Error
+}
+
+func (r *loanRepository) FindLoanReclassificationByID(id uint) (*models.LoanReclassification, error) {
+	var reclassification models.LoanReclassification
+	err := r.db.Preload("Lines.Loan").
+		Preload("Journal").
+		Preload("User").
+		First(&reclassification, id).Error
+	return &reclassification, err
+}
+
+func (r *loanRepository) FindLoanReclassifications(companyID uint) ([]models.LoanReclassification, error) {
+	var reclassifications []models.LoanReclassification
+	err := r.db.Where("company_id = ?", companyID).
+		Order("period DESC").
+		Find(&reclassifications).Error
+	return reclassifications, err
+}
+
+func (r *loanRepository) FindLatestLoanReclassification(companyID uint) (*models.LoanReclassification, error) {
+	var reclassification models.LoanReclassification
+	err := r.db.Where("company_id = ?", companyID).
+		Order("period DESC").
+		First(&reclassification).Error
+	return &reclassification, err
+}
\ No newline at end of file
@@ -16,6 +16,8 @@ type NotificationRepository interface {
 	MarkAllAsRead(userID uint) error
 	Delete(id uint) error
 	CountUnread(userID uint) (int64, error)
+	ExistsForRelated(userID uint, notificationType models.NotificationType, relatedType string, relatedID uint) (bool, error)
+	ExistsUnreadForRelated(userID uint, notificationType models.NotificationType, relatedType string, relatedID uint) (bool, error)
 }
 
 type notificationRepository struct {
@@ -83,4 +85,20 @@ func (r *notificationRepository) CountUnread(userID uint) (int64, error) {
 		Where("user_id = ? AND status = ?", userID, models.NotificationStatusUnread).
 		Count(&count).Error
 	return count, err
+}
+
+func (r *notificationRepository) ExistsForRelated(userID uint, notificationType models.NotificationType, relatedType string, relatedID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND type = ? AND related_type = ? AND related_id = ?", userID, notificationType, relatedType, relatedID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func (r *notificationRepository) ExistsUnreadForRelated(userID uint, notificationType models.NotificationType, relatedType string, relatedID uint) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Notification{}).
+		Where("user_id = ? AND type = ? AND related_type = ? AND related_id = ? AND status = ?", userID, notificationType, relatedType, relatedID, models.NotificationStatusUnread).
+		Count(&count).Error
+	return count > 0, err
 }
\ No newline at end of file
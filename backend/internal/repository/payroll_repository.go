@@ -0,0 +1,291 @@
+package repository
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PayrollRepository interface {
+	// Salary Components
+	CreateSalaryComponent(component *models.SalaryComponent) error
+	FindSalaryComponentByID(id uint) (*models.SalaryComponent, error)
+	FindSalaryComponents(companyID uint, activeOnly bool) ([]models.SalaryComponent, error)
+	UpdateSalaryComponent(component *models.SalaryComponent) error
+
+	// Employees
+	CreateEmployee(employee *models.Employee) error
+	FindEmployeeByID(id uint) (*models.Employee, error)
+	FindEmployees(companyID uint, department string, activeOnly bool) ([]models.Employee, error)
+	FindPayableEmployees(companyID uint, periodStart, periodEnd time.Time) ([]models.Employee, error)
+	UpdateEmployee(employee *models.Employee) error
+	DeleteEmployee(id uint) error
+	CountEmployeePayslips(employeeID uint) (int64, error)
+
+	// Payroll Settings
+	FindPayrollSetting(companyID uint) (*models.PayrollSetting, error)
+	SavePayrollSetting(setting *models.PayrollSetting) error
+
+	// Payroll Runs
+	CreatePayrollRun(run *models.PayrollRun) error
+	FindPayrollRunByID(id uint) (*models.PayrollRun, error)
+	FindPayrollRunByPeriod(companyID uint, period string) (*models.PayrollRun, error)
+	FindPayrollRuns(companyID uint, year int) ([]models.PayrollRun, error)
+	FindLatestPayrollRun(companyID uint) (*models.PayrollRun, error)
+	UpdatePayrollRun(run *models.PayrollRun) error
+	DeletePayrollRun(id uint) error
+	GeneratePayrollRunNumber(companyID uint, date time.Time) (string, error)
+
+	// Payslips
+	FindPayslipByID(id uint) (*models.Payslip, error)
+	FindEmployeePayslips(employeeID uint, year int) ([]models.Payslip, error)
+	SumEmployeeYearToDate(employeeID uint, period string) (*models.PayrollYearToDate, error)
+}
+
+type payrollRepository struct {
+	db *gorm.DB
+}
+
+func NewPayrollRepository(db *gorm.DB) PayrollRepository {
+	return &payrollRepository{db: db}
+}
+
+// Salary Component methods
+func (r *payrollRepository) CreateSalaryComponent(component *models.SalaryComponent) error {
+	return r.db.Omit("Account").Create(component).Error
+}
+
+func (r *payrollRepository) FindSalaryComponentByID(id uint) (*models.SalaryComponent, error) {
+	var component models.SalaryComponent
+	err := r.db.Preload("Account").First(&component, id).Error
+	return &component, err
+}
+
+func (r *payrollRepository) FindSalaryComponents(companyID uint, activeOnly bool) ([]models.SalaryComponent, error) {
+	var components []models.SalaryComponent
+	query := r.db.Where("company_id = ?", companyID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("type ASC, code ASC").
+		Preload("Account").
+		Find(&components).Error
+	return components, err
+}
+
+func (r *payrollRepository) UpdateSalaryComponent(component *models.SalaryComponent) error {
+	return r.db.Omit("Account").Save(component).Error
+}
+
+// Employee methods
+func (r *payrollRepository) CreateEmployee(employee *models.Employee) error {
+	return r.db.Omit("Components.Component").Create(employee).Error
+}
+
+func (r *payrollRepository) FindEmployeeByID(id uint) (*models.Employee, error) {
+	var employee models.Employee
+	err := r.db.Preload("Components.Component").First(&employee, id).Error
+	return &employee, err
+}
+
+func (r *payrollRepository) FindEmployees(companyID uint, department string, activeOnly bool) ([]models.Employee, error) {
+	var employees []models.Employee
+	query := r.db.Where("company_id = ?", companyID)
+	if department != "" {
+		query = query.Where("department = ?", department)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("code ASC").Find(&employees).Error
+	return employees, err
+}
+
+// FindPayableEmployees returns the active employees employed at some point
+// in the period, with their salary components
+func (r *payrollRepository) FindPayableEmployees(companyID uint, periodStart, periodEnd time.Time) ([]models.Employee, error) {
+	var employees []models.Employee
+	err := r.db.Where("company_id = ? AND is_active = ? AND join_date <= ?", companyID, true, periodEnd).
+		Where("end_date IS NULL OR end_date >= ?", periodStart).
+		Order("code ASC").
+		Preload("Components.Component").
+		Find(&employees).Error
+	return employees, err
+}
+
+// UpdateEmployee saves the employee and replaces its salary components
+func (r *payrollRepository) UpdateEmployee(employee *models.Employee) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("employee_id = ?", employee.ID).Delete(&models.EmployeeSalaryComponent{}).Error; err != nil {
+			return err
+		}
+		for i := range employee.Components {
+			employee.Components[i].ID = 0
+			employee.Components[i].EmployeeID = employee.ID
+		}
+		if len(employee.Components) > 0 {
+			if err := tx.Omit("Component").Create(&employee.Components).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Omit("Components").Save(employee).Error
+	})
+}
+
+func (r *payrollRepository) DeleteEmployee(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("employee_id = ?", id).Delete(&models.EmployeeSalaryComponent{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&models.Employee{}, id).Error
+	})
+}
+
+func (r *payrollRepository) CountEmployeePayslips(employeeID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Payslip{}).
+		Where("employee_id = ?", employeeID).
+		Count(&count).Error
+	return count, err
+}
+
+// Payroll Setting methods
+
+// FindPayrollSetting returns the company's settings; a company that has not
+// set them gets the statutory defaults
+func (r *payrollRepository) FindPayrollSetting(companyID uint) (*models.PayrollSetting, error) {
+	var setting models.PayrollSetting
+	err := r.db.Where("company_id = ?", companyID).First(&setting).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.PayrollSetting{
+			CompanyID:      companyID,
+			JKKRate:        0.24,
+			HealthWageCap:  12000000,
+			PensionWageCap: 10547400,
+		}, nil
+	}
+	return &setting, err
+}
+
+func (r *payrollRepository) SavePayrollSetting(setting *models.PayrollSetting) error {
+	return r.db.Save(setting).Error
+}
+
+// Payroll Run methods
+func (r *payrollRepository) CreatePayrollRun(run *models.PayrollRun) error {
+	return r.db.Omit("Payslips.Employee").Create(run).Error
+}
+
+func (r *payrollRepository) FindPayrollRunByID(id uint) (*models.PayrollRun, error) {
+	var run models.PayrollRun
+	err := r.db.Preload("Payslips", func(db *gorm.DB) *gorm.DB {
+		return db.Order("id ASC")
+	}).
+		Preload("Payslips.Employee").
+		Preload("Payslips.Lines").
+		Preload("Journal").
+		Preload("User").
+		First(&run, id).Error
+	return &run, err
+}
+
+func (r *payrollRepository) FindPayrollRunByPeriod(companyID uint, period string) (*models.PayrollRun, error) {
+	var run models.PayrollRun
+	err := r.db.Where("company_id = ? AND period = ?", companyID, period).
+		First(&run).Error
+	return &run, err
+}
+
+func (r *payrollRepository) FindPayrollRuns(companyID uint, year int) ([]models.PayrollRun, error) {
+	var runs []models.PayrollRun
+	query := r.db.Where("company_id = ?", companyID)
+	if year > 0 {
+		query = query.Where("period LIKE ?", fmt.Sprintf("%04d-%%", year))
+	}
+	err := query.Order("period DESC").Find(&runs).Error
+	return runs, err
+}
+
+func (r *payrollRepository) FindLatestPayrollRun(companyID uint) (*models.PayrollRun, error) {
+	var run models.PayrollRun
+	err := r.db.Where("company_id = ?", companyID).
+		Order("period DESC").
+		First(&run).Error
+	return &run, err
+}
+
+func (r *payrollRepository) UpdatePayrollRun(run *models.PayrollRun) error {
+	return r.db.Omit("Payslips", "Journal", "User").Save(run).Error
+}
+
+// DeletePayrollRun removes a run with its payslips. Runs are deleted for
+// good so the period can be run again.
+func (r *payrollRepository) DeletePayrollRun(id uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var payslipIDs []uint
+		if err := tx.Model(&models.Payslip{}).Where("run_id = ?", id).Pluck("id", &payslipIDs).Error; err != nil {
+			return err
+		}
+		if len(payslipIDs) > 0 {
+			if err := tx.Unscoped().Where("payslip_id IN ?", payslipIDs).Delete(&models.PayslipLine{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("run_id = ?", id).Delete(&models.Payslip{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&models.PayrollRun{}, id).Error
+	})
+}
+
+func (r *payrollRepository) GeneratePayrollRunNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "PYR/" + date.Format("200601/")
+
+	err := r.db.Unscoped().Model(&models.PayrollRun{}).
+		Where("company_id = ? AND run_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Payslip methods
+func (r *payrollRepository) FindPayslipByID(id uint) (*models.Payslip, error) {
+	var payslip models.Payslip
+	err := r.db.Preload("Employee").
+		Preload("Lines").
+		First(&payslip, id).Error
+	return &payslip, err
+}
+
+func (r *payrollRepository) FindEmployeePayslips(employeeID uint, year int) ([]models.Payslip, error) {
+	var payslips []models.Payslip
+	err := r.db.Where("employee_id = ? AND period LIKE ?", employeeID, fmt.Sprintf("%04d-%%", year)).
+		Order("period ASC").
+		Preload("Lines").
+		Find(&payslips).Error
+	return payslips, err
+}
+
+// SumEmployeeYearToDate adds up the employee's posted payslips of the same
+// year as the period, before it
+func (r *payrollRepository) SumEmployeeYearToDate(employeeID uint, period string) (*models.PayrollYearToDate, error) {
+	var ytd models.PayrollYearToDate
+	err := r.db.Table("payslips p").
+		Select(`COUNT(*) AS months, COALESCE(SUM(p.gross_income), 0) AS gross_income,
+			COALESCE(SUM(p.jht_employee + p.jp_employee), 0) AS pension_contributions,
+			COALESCE(SUM(p.pph21), 0) AS pph21`).
+		Joins("JOIN payroll_runs r ON r.id = p.run_id AND r.deleted_at IS NULL").
+		Where("p.employee_id = ? AND p.deleted_at IS NULL", employeeID).
+		Where("p.period >= ? AND p.period < ?", period[:4]+"-01", period).
+		Where("r.status <> ?", models.PayrollRunStatusDraft).
+		Scan(&ytd).Error
+	return &ytd, err
+}
\ No newline at end of file
@@ -0,0 +1,309 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProcurementRepository interface {
+	// Purchase Requisitions
+	CreatePurchaseRequisition(requisition *models.PurchaseRequisition) error
+	FindPurchaseRequisitionByID(id uint) (*models.PurchaseRequisition, error)
+	FindPurchaseRequisitionItemByID(id uint) (*models.PurchaseRequisitionItem, error)
+	FindPurchaseRequisitions(companyID uint, status string) ([]models.PurchaseRequisition, error)
+	UpdatePurchaseRequisition(requisition *models.PurchaseRequisition) error
+	UpdatePurchaseRequisitionItem(item *models.PurchaseRequisitionItem) error
+	GeneratePurchaseRequisitionNumber(companyID uint, date time.Time) (string, error)
+
+	// Purchase Orders
+	CreatePurchaseOrder(order *models.PurchaseOrder) error
+	FindPurchaseOrderByID(id uint) (*models.PurchaseOrder, error)
+	FindPurchaseOrders(companyID uint, vendorID uint, status string) ([]models.PurchaseOrder, error)
+	UpdatePurchaseOrder(order *models.PurchaseOrder) error
+	UpdatePurchaseOrderItem(item *models.PurchaseOrderItem) error
+	GeneratePurchaseOrderNumber(companyID uint, date time.Time) (string, error)
+
+	// Goods Receipts
+	CreateGoodsReceipt(receipt *models.GoodsReceipt) error
+	FindGoodsReceiptByID(id uint) (*models.GoodsReceipt, error)
+	FindGoodsReceipts(companyID uint, vendorID uint, purchaseOrderID uint, status string) ([]models.GoodsReceipt, error)
+	FindGoodsReceiptItemsByIDs(ids []uint) ([]models.GoodsReceiptItem, error)
+	UpdateGoodsReceipt(receipt *models.GoodsReceipt) error
+	UpdateGoodsReceiptItem(item *models.GoodsReceiptItem) error
+	GenerateGoodsReceiptNumber(companyID uint, date time.Time) (string, error)
+
+	// Match Tolerance
+	FindMatchTolerance(companyID uint) (*models.PurchaseMatchTolerance, error)
+	SaveMatchTolerance(tolerance *models.PurchaseMatchTolerance) error
+
+	// Reports
+	FindOpenPurchaseOrderLines(companyID uint, vendorID uint) ([]models.OpenPurchaseOrderLine, error)
+	FindGRNILines(companyID uint, vendorID uint, asOf time.Time) ([]models.GRNILine, error)
+}
+
+type procurementRepository struct {
+	db *gorm.DB
+}
+
+func NewProcurementRepository(db *gorm.DB) ProcurementRepository {
+	return &procurementRepository{db: db}
+}
+
+// Purchase Requisition methods
+func (r *procurementRepository) CreatePurchaseRequisition(requisition *models.PurchaseRequisition) error {
+	return r.db.Create(requisition).Error
+}
+
+func (r *procurementRepository) FindPurchaseRequisitionByID(id uint) (*models.PurchaseRequisition, error) {
+	var requisition models.PurchaseRequisition
+	err := r.db.Preload("Items.Product").
+		Preload("User").
+		First(&requisition, id).Error
+	return &requisition, err
+}
+
+func (r *procurementRepository) FindPurchaseRequisitionItemByID(id uint) (*models.PurchaseRequisitionItem, error) {
+	var item models.PurchaseRequisitionItem
+	err := r.db.First(&item, id).Error
+	return &item, err
+}
+
+func (r *procurementRepository) FindPurchaseRequisitions(companyID uint, status string) ([]models.PurchaseRequisition, error) {
+	var requisitions []models.PurchaseRequisition
+	query := r.db.Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("request_date DESC, id DESC").
+		Preload("User").
+		Find(&requisitions).Error
+	return requisitions, err
+}
+
+func (r *procurementRepository) UpdatePurchaseRequisition(requisition *models.PurchaseRequisition) error {
+	return r.db.Omit("Items", "User").Save(requisition).Error
+}
+
+func (r *procurementRepository) UpdatePurchaseRequisitionItem(item *models.PurchaseRequisitionItem) error {
+	return r.db.Omit("Product").Save(item).Error
+}
+
+func (r *procurementRepository) GeneratePurchaseRequisitionNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "PR/" + date.Format("200601/")
+
+	err := r.db.Model(&models.PurchaseRequisition{}).
+		Where("company_id = ? AND requisition_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Purchase Order methods
+func (r *procurementRepository) CreatePurchaseOrder(order *models.PurchaseOrder) error {
+	return r.db.Create(order).Error
+}
+
+func (r *procurementRepository) FindPurchaseOrderByID(id uint) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	err := r.db.Preload("Items.Product").
+		Preload("Vendor").
+		Preload("User").
+		First(&order, id).Error
+	return &order, err
+}
+
+func (r *procurementRepository) FindPurchaseOrders(companyID uint, vendorID uint, status string) ([]models.PurchaseOrder, error) {
+	var orders []models.PurchaseOrder
+	query := r.db.Where("company_id = ?", companyID)
+	if vendorID > 0 {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("order_date DESC, id DESC").
+		Preload("Vendor").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *procurementRepository) UpdatePurchaseOrder(order *models.PurchaseOrder) error {
+	return r.db.Omit("Items", "Vendor", "User").Save(order).Error
+}
+
+func (r *procurementRepository) UpdatePurchaseOrderItem(item *models.PurchaseOrderItem) error {
+	return r.db.Omit("Product").Save(item).Error
+}
+
+func (r *procurementRepository) GeneratePurchaseOrderNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "PO/" + date.Format("200601/")
+
+	err := r.db.Model(&models.PurchaseOrder{}).
+		Where("company_id = ? AND order_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Goods Receipt methods
+func (r *procurementRepository) CreateGoodsReceipt(receipt *models.GoodsReceipt) error {
+	return r.db.Omit("Items.PurchaseOrderItem").Create(receipt).Error
+}
+
+func (r *procurementRepository) FindGoodsReceiptByID(id uint) (*models.GoodsReceipt, error) {
+	var receipt models.GoodsReceipt
+	err := r.db.Preload("Items.Product").
+		Preload("Items.PurchaseOrderItem").
+		Preload("PurchaseOrder").
+		Preload("Vendor").
+		Preload("User").
+		First(&receipt, id).Error
+	return &receipt, err
+}
+
+func (r *procurementRepository) FindGoodsReceipts(companyID uint, vendorID uint, purchaseOrderID uint, status string) ([]models.GoodsReceipt, error) {
+	var receipts []models.GoodsReceipt
+	query := r.db.Where("company_id = ?", companyID)
+	if vendorID > 0 {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if purchaseOrderID > 0 {
+		query = query.Where("purchase_order_id = ?", purchaseOrderID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("receipt_date DESC, id DESC").
+		Preload("Vendor").
+		Preload("PurchaseOrder").
+		Find(&receipts).Error
+	return receipts, err
+}
+
+// FindGoodsReceiptItemsByIDs loads receipt lines with their receipt and
+// purchase order line, as bills are matched against them
+func (r *procurementRepository) FindGoodsReceiptItemsByIDs(ids []uint) ([]models.GoodsReceiptItem, error) {
+	var items []models.GoodsReceiptItem
+	if len(ids) == 0 {
+		return items, nil
+	}
+	err := r.db.Where("id IN ?", ids).
+		Preload("Receipt").
+		Preload("PurchaseOrderItem").
+		Preload("Product").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *procurementRepository) UpdateGoodsReceipt(receipt *models.GoodsReceipt) error {
+	return r.db.Omit("Items", "PurchaseOrder", "Vendor", "User").Save(receipt).Error
+}
+
+func (r *procurementRepository) UpdateGoodsReceiptItem(item *models.GoodsReceiptItem) error {
+	return r.db.Omit("Receipt", "PurchaseOrderItem", "Product").Save(item).Error
+}
+
+func (r *procurementRepository) GenerateGoodsReceiptNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "GRN/" + date.Format("200601/")
+
+	err := r.db.Model(&models.GoodsReceipt{}).
+		Where("company_id = ? AND receipt_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Match Tolerance methods
+
+// FindMatchTolerance returns the company's tolerances; a company that has
+// not set any matches exactly
+func (r *procurementRepository) FindMatchTolerance(companyID uint) (*models.PurchaseMatchTolerance, error) {
+	var tolerance models.PurchaseMatchTolerance
+	err := r.db.Where("company_id = ?", companyID).First(&tolerance).Error
+
+	if err == gorm.ErrRecordNotFound {
+		return &models.PurchaseMatchTolerance{CompanyID: companyID}, nil
+	}
+
+	return &tolerance, err
+}
+
+func (r *procurementRepository) SaveMatchTolerance(tolerance *models.PurchaseMatchTolerance) error {
+	return r.db.Save(tolerance).Error
+}
+
+// Report methods
+
+// FindOpenPurchaseOrderLines returns the lines of approved purchase orders
+// still to be delivered, oldest order first
+func (r *procurementRepository) FindOpenPurchaseOrderLines(companyID uint, vendorID uint) ([]models.OpenPurchaseOrderLine, error) {
+	var lines []models.OpenPurchaseOrderLine
+	query := r.db.Table("purchase_order_items i").
+		Select(`o.id AS purchase_order_id, o.order_number, o.order_date, o.expected_date,
+			v.id AS vendor_id, v.code AS vendor_code, v.name AS vendor_name,
+			i.id AS item_id, p.code AS product_code, i.description, i.unit,
+			i.quantity AS ordered_quantity, i.received_quantity, i.quantity - i.received_quantity AS remaining_quantity,
+			i.subtotal / i.quantity AS unit_price`).
+		Joins("JOIN purchase_orders o ON o.id = i.purchase_order_id AND o.deleted_at IS NULL").
+		Joins("JOIN vendors v ON v.id = o.vendor_id").
+		Joins("JOIN products p ON p.id = i.product_id").
+		Where("o.company_id = ? AND i.deleted_at IS NULL AND i.quantity > i.received_quantity", companyID).
+		Where("o.status IN ?", []models.PurchaseOrderStatus{
+			models.PurchaseOrderStatusOpen,
+			models.PurchaseOrderStatusPartiallyReceived,
+		})
+	if vendorID > 0 {
+		query = query.Where("o.vendor_id = ?", vendorID)
+	}
+	err := query.Order("o.order_date ASC, o.id ASC, i.id ASC").Scan(&lines).Error
+	return lines, err
+}
+
+// FindGRNILines returns the lines of goods receipts posted on or before asOf
+// with the quantity billed by bills dated on or before asOf
+func (r *procurementRepository) FindGRNILines(companyID uint, vendorID uint, asOf time.Time) ([]models.GRNILine, error) {
+	var lines []models.GRNILine
+	query := r.db.Table("goods_receipt_items gi").
+		Select(`gr.id AS receipt_id, gr.receipt_number, gr.receipt_date, o.order_number,
+			v.id AS vendor_id, v.code AS vendor_code, v.name AS vendor_name,
+			gi.id AS item_id, p.code AS product_code, gi.description, gi.unit,
+			gi.quantity AS received_quantity, gi.cost_amount AS received_cost, COALESCE((
+				SELECT SUM(bi.quantity)
+				FROM purchase_bill_items bi
+				JOIN purchase_bills b ON b.id = bi.bill_id AND b.deleted_at IS NULL
+				WHERE bi.goods_receipt_item_id = gi.id AND bi.deleted_at IS NULL AND b.status IN ? AND b.bill_date <= ?
+			), 0) AS billed_quantity`, []models.PurchaseBillStatus{
+			models.PurchaseBillStatusPosted,
+			models.PurchaseBillStatusPartiallyPaid,
+			models.PurchaseBillStatusPaid,
+		}, asOf).
+		Joins("JOIN goods_receipts gr ON gr.id = gi.receipt_id AND gr.deleted_at IS NULL").
+		Joins("JOIN purchase_orders o ON o.id = gr.purchase_order_id").
+		Joins("JOIN vendors v ON v.id = gr.vendor_id").
+		Joins("JOIN products p ON p.id = gi.product_id").
+		Where("gr.company_id = ? AND gi.deleted_at IS NULL AND gr.status = ? AND gr.receipt_date <= ?",
+			companyID, models.GoodsReceiptStatusPosted, asOf)
+	if vendorID > 0 {
+		query = query.Where("gr.vendor_id = ?", vendorID)
+	}
+	err := query.Order("gr.receipt_date ASC, gr.id ASC, gi.id ASC").Scan(&lines).Error
+	return lines, err
+}
\ No newline at end of file
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProductionRepository interface {
+	// Bill of Materials
+	FindBOMItems(productID uint) ([]models.BOMItem, error)
+	ReplaceBOMItems(productID uint, items []models.BOMItem) error
+
+	// Production Orders
+	CreateProductionOrder(order *models.ProductionOrder) error
+	FindProductionOrderByID(id uint) (*models.ProductionOrder, error)
+	FindProductionOrdersByCompany(companyID uint, status string) ([]models.ProductionOrder, error)
+	UpdateProductionOrder(order *models.ProductionOrder) error
+	UpdateProductionOrderItem(item *models.ProductionOrderItem) error
+	GenerateProductionOrderNumber(companyID uint, date time.Time) (string, error)
+}
+
+type productionRepository struct {
+	db *gorm.DB
+}
+
+func NewProductionRepository(db *gorm.DB) ProductionRepository {
+	return &productionRepository{db: db}
+}
+
+// Bill of Materials methods
+func (r *productionRepository) FindBOMItems(productID uint) ([]models.BOMItem, error) {
+	var items []models.BOMItem
+	err := r.db.Preload("Component").
+		Where("product_id = ?", productID).
+		Order("id ASC").
+		Find(&items).Error
+	return items, err
+}
+
+func (r *productionRepository) ReplaceBOMItems(productID uint, items []models.BOMItem) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("product_id = ?", productID).Delete(&models.BOMItem{}).Error; err != nil {
+			return err
+		}
+		for i := range items {
+			items[i].ProductID = productID
+			if err := tx.Omit("Component").Create(&items[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Production Order methods
+func (r *productionRepository) CreateProductionOrder(order *models.ProductionOrder) error {
+	return r.db.Create(order).Error
+}
+
+func (r *productionRepository) FindProductionOrderByID(id uint) (*models.ProductionOrder, error) {
+	var order models.ProductionOrder
+	err := r.db.Preload("Items.Component").
+		Preload("Product").
+		Preload("Warehouse").
+		Preload("User").
+		First(&order, id).Error
+	return &order, err
+}
+
+func (r *productionRepository) FindProductionOrdersByCompany(companyID uint, status string) ([]models.ProductionOrder, error) {
+	var orders []models.ProductionOrder
+	query := r.db.Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("order_date DESC, id DESC").
+		Preload("Product").
+		Preload("Warehouse").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *productionRepository) UpdateProductionOrder(order *models.ProductionOrder) error {
+	return r.db.Omit("Items").Save(order).Error
+}
+
+func (r *productionRepository) UpdateProductionOrderItem(item *models.ProductionOrderItem) error {
+	return r.db.Omit("Component").Save(item).Error
+}
+
+func (r *productionRepository) GenerateProductionOrderNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "PRD/" + date.Format("200601/")
+
+	err := r.db.Model(&models.ProductionOrder{}).
+		Where("company_id = ? AND order_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
\ No newline at end of file
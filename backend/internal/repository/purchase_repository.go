@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PurchaseRepository interface {
+	// Vendors
+	CreateVendor(vendor *models.Vendor) error
+	FindVendorByID(id uint) (*models.Vendor, error)
+	FindVendorsByCompany(companyID uint, activeOnly bool) ([]models.Vendor, error)
+	UpdateVendor(vendor *models.Vendor) error
+	DeleteVendor(id uint) error
+	CountVendorBills(vendorID uint) (int64, error)
+
+	// Purchase Bills
+	CreatePurchaseBill(bill *models.PurchaseBill) error
+	FindPurchaseBillByID(id uint) (*models.PurchaseBill, error)
+	FindPurchaseBills(companyID uint, vendorID uint, status string) ([]models.PurchaseBill, error)
+	FindOpenPurchaseBills(vendorID uint) ([]models.PurchaseBill, error)
+	FindDuePurchaseBills(companyID uint, dueBefore time.Time, vendorIDs []uint) ([]models.PurchaseBill, error)
+	UpdatePurchaseBill(bill *models.PurchaseBill) error
+	UpdatePurchaseBillItem(item *models.PurchaseBillItem) error
+	FindPayableOpenItems(companyID uint, vendorID uint, asOf time.Time) ([]models.AgingOpenItem, error)
+	GeneratePurchaseBillNumber(companyID uint, date time.Time) (string, error)
+
+	// Vendor Payments
+	CreateVendorPayment(payment *models.VendorPayment) error
+	FindVendorPaymentByID(id uint) (*models.VendorPayment, error)
+	FindVendorPayments(companyID uint, vendorID uint) ([]models.VendorPayment, error)
+	GenerateVendorPaymentNumber(companyID uint, date time.Time) (string, error)
+
+	// Purchase Debit Notes
+	CreatePurchaseDebitNote(debitNote *models.PurchaseDebitNote) error
+	FindPurchaseDebitNoteByID(id uint) (*models.PurchaseDebitNote, error)
+	FindPurchaseDebitNotes(companyID uint, vendorID uint, billID uint, status string) ([]models.PurchaseDebitNote, error)
+	UpdatePurchaseDebitNote(debitNote *models.PurchaseDebitNote) error
+	UpdatePurchaseDebitNoteItem(item *models.PurchaseDebitNoteItem) error
+	SumDebitedByBillItem(billID uint) (map[uint]models.PurchaseDebitNoteItem, error)
+	GeneratePurchaseDebitNoteNumber(companyID uint, date time.Time) (string, error)
+	CreatePurchaseDebitNoteApplication(application *models.PurchaseDebitNoteApplication) error
+
+	// Payment Runs
+	CreatePaymentRun(run *models.PaymentRun) error
+	FindPaymentRunByID(id uint) (*models.PaymentRun, error)
+	FindPaymentRuns(companyID uint, status string) ([]models.PaymentRun, error)
+	FindBillsInDraftRuns(billIDs []uint) ([]uint, error)
+	UpdatePaymentRun(run *models.PaymentRun) error
+	UpdatePaymentRunLine(line *models.PaymentRunLine) error
+	DeletePaymentRunLine(id uint) error
+	GeneratePaymentRunNumber(companyID uint, date time.Time) (string, error)
+}
+
+type purchaseRepository struct {
+	db *gorm.DB
+}
+
+func NewPurchaseRepository(db *gorm.DB) PurchaseRepository {
+	return &purchaseRepository{db: db}
+}
+
+var openBillStatuses = []models.PurchaseBillStatus{
+	models.PurchaseBillStatusPosted,
+	models.PurchaseBillStatusPartiallyPaid,
+}
+
+// Vendor methods
+func (r *purchaseRepository) CreateVendor(vendor *models.Vendor) error {
+	return r.db.Create(vendor).Error
+}
+
+func (r *purchaseRepository) FindVendorByID(id uint) (*models.Vendor, error) {
+	var vendor models.Vendor
+	err := r.db.First(&vendor, id).Error
+	return &vendor, err
+}
+
+func (r *purchaseRepository) FindVendorsByCompany(companyID uint, activeOnly bool) ([]models.Vendor, error) {
+	var vendors []models.Vendor
+	query := r.db.Where("company_id = ?", companyID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("code ASC").Find(&vendors).Error
+	return vendors, err
+}
+
+func (r *purchaseRepository) UpdateVendor(vendor *models.Vendor) error {
+	return r.db.Save(vendor).Error
+}
+
+func (r *purchaseRepository) DeleteVendor(id uint) error {
+	return r.db.Delete(&models.Vendor{}, id).Error
+}
+
+func (r *purchaseRepository) CountVendorBills(vendorID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.PurchaseBill{}).
+		Where("vendor_id = ?", vendorID).
+		Count(&count).Error
+	return count, err
+}
+
+// Purchase Bill methods
+func (r *purchaseRepository) CreatePurchaseBill(bill *models.PurchaseBill) error {
+	return r.db.Create(bill).Error
+}
+
+func (r *purchaseRepository) FindPurchaseBillByID(id uint) (*models.PurchaseBill, error) {
+	var bill models.PurchaseBill
+	err := r.db.Preload("Items.Product").
+		Preload("Items.Account").
+		Preload("Vendor").
+		Preload("User").
+		First(&bill, id).Error
+	return &bill, err
+}
+
+func (r *purchaseRepository) FindPurchaseBills(companyID uint, vendorID uint, status string) ([]models.PurchaseBill, error) {
+	var bills []models.PurchaseBill
+	query := r.db.Where("company_id = ?", companyID)
+	if vendorID > 0 {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("bill_date DESC, id DESC").
+		Preload("Vendor").
+		Find(&bills).Error
+	return bills, err
+}
+
+// FindOpenPurchaseBills returns the vendor's unpaid bills, oldest due first
+func (r *purchaseRepository) FindOpenPurchaseBills(vendorID uint) ([]models.PurchaseBill, error) {
+	var bills []models.PurchaseBill
+	err := r.db.Where("vendor_id = ? AND status IN ?", vendorID, openBillStatuses).
+		Order("due_date ASC, id ASC").
+		Find(&bills).Error
+	return bills, err
+}
+
+// FindDuePurchaseBills returns unpaid bills due on or before dueBefore,
+// optionally limited to some vendors
+func (r *purchaseRepository) FindDuePurchaseBills(companyID uint, dueBefore time.Time, vendorIDs []uint) ([]models.PurchaseBill, error) {
+	var bills []models.PurchaseBill
+	query := r.db.Where("company_id = ? AND status IN ? AND due_date <= ?", companyID, openBillStatuses, dueBefore)
+	if len(vendorIDs) > 0 {
+		query = query.Where("vendor_id IN ?", vendorIDs)
+	}
+	err := query.Order("vendor_id ASC, due_date ASC, id ASC").
+		Preload("Vendor").
+		Find(&bills).Error
+	return bills, err
+}
+
+func (r *purchaseRepository) UpdatePurchaseBill(bill *models.PurchaseBill) error {
+	return r.db.Omit("Items", "Vendor").Save(bill).Error
+}
+
+func (r *purchaseRepository) UpdatePurchaseBillItem(item *models.PurchaseBillItem) error {
+	return r.db.Omit("Product", "Account").Save(item).Error
+}
+
+// FindPayableOpenItems returns posted bills dated on or before asOf with
+// what was still unpaid and not debited at that date
+func (r *purchaseRepository) FindPayableOpenItems(companyID uint, vendorID uint, asOf time.Time) ([]models.AgingOpenItem, error) {
+	var items []models.AgingOpenItem
+	query := r.db.Table("purchase_bills b").
+		Select(`b.id AS document_id, 'bill' AS document_type, b.bill_number AS document_number,
+			b.bill_date AS document_date, b.due_date, v.id AS party_id, v.code AS party_code, v.name AS party_name,
+			b.payable_amount AS total_amount, b.payable_amount - COALESCE((
+				SELECT SUM(a.amount)
+				FROM vendor_payment_allocations a
+				JOIN vendor_payments p ON p.id = a.payment_id AND p.deleted_at IS NULL
+				WHERE a.bill_id = b.id AND a.deleted_at IS NULL AND p.payment_date <= ?
+			), 0) - COALESCE((
+				SELECT SUM(n.payable_amount - n.unapplied_amount)
+				FROM purchase_debit_notes n
+				WHERE n.bill_id = b.id AND n.deleted_at IS NULL AND n.status = ? AND n.debit_note_date <= ?
+			), 0) - COALESCE((
+				SELECT SUM(da.amount)
+				FROM purchase_debit_note_applications da
+				WHERE da.bill_id = b.id AND da.deleted_at IS NULL AND da.application_date <= ?
+			), 0) AS outstanding`, asOf, models.DebitNoteStatusPosted, asOf, asOf).
+		Joins("JOIN vendors v ON v.id = b.vendor_id").
+		Where("b.company_id = ? AND b.deleted_at IS NULL AND b.bill_date <= ?", companyID, asOf).
+		Where("b.status IN ?", []models.PurchaseBillStatus{
+			models.PurchaseBillStatusPosted,
+			models.PurchaseBillStatusPartiallyPaid,
+			models.PurchaseBillStatusPaid,
+		})
+	if vendorID > 0 {
+		query = query.Where("b.vendor_id = ?", vendorID)
+	}
+	err := query.Order("b.due_date ASC, b.id ASC").Scan(&items).Error
+	return items, err
+}
+
+func (r *purchaseRepository) GeneratePurchaseBillNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "BILL/" + date.Format("200601/")
+
+	err := r.db.Model(&models.PurchaseBill{}).
+		Where("company_id = ? AND bill_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Vendor Payment methods
+func (r *purchaseRepository) CreateVendorPayment(payment *models.VendorPayment) error {
+	return r.db.Create(payment).Error
+}
+
+func (r *purchaseRepository) FindVendorPaymentByID(id uint) (*models.VendorPayment, error) {
+	var payment models.VendorPayment
+	err := r.db.Preload("Allocations.Bill").
+		Preload("Vendor").
+		Preload("Account").
+		Preload("User").
+		First(&payment, id).Error
+	return &payment, err
+}
+
+func (r *purchaseRepository) FindVendorPayments(companyID uint, vendorID uint) ([]models.VendorPayment, error) {
+	var payments []models.VendorPayment
+	query := r.db.Where("company_id = ?", companyID)
+	if vendorID > 0 {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	err := query.Order("payment_date DESC, id DESC").
+		Preload("Vendor").
+		Preload("Account").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *purchaseRepository) GenerateVendorPaymentNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "PAY/" + date.Format("200601/")
+
+	err := r.db.Model(&models.VendorPayment{}).
+		Where("company_id = ? AND payment_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Purchase Debit Note methods
+func (r *purchaseRepository) CreatePurchaseDebitNote(debitNote *models.PurchaseDebitNote) error {
+	return r.db.Create(debitNote).Error
+}
+
+func (r *purchaseRepository) FindPurchaseDebitNoteByID(id uint) (*models.PurchaseDebitNote, error) {
+	var debitNote models.PurchaseDebitNote
+	err := r.db.Preload("Items.BillItem").
+		Preload("Bill").
+		Preload("Vendor").
+		Preload("User").
+		Preload("Applications", func(db *gorm.DB) *gorm.DB {
+			return db.Order("application_date ASC, id ASC")
+		}).
+		First(&debitNote, id).Error
+	return &debitNote, err
+}
+
+func (r *purchaseRepository) FindPurchaseDebitNotes(companyID uint, vendorID uint, billID uint, status string) ([]models.PurchaseDebitNote, error) {
+	var debitNotes []models.PurchaseDebitNote
+	query := r.db.Where("company_id = ?", companyID)
+	if vendorID > 0 {
+		query = query.Where("vendor_id = ?", vendorID)
+	}
+	if billID > 0 {
+		query = query.Where("bill_id = ?", billID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("debit_note_date DESC, id DESC").
+		Preload("Vendor").
+		Preload("Bill").
+		Find(&debitNotes).Error
+	return debitNotes, err
+}
+
+func (r *purchaseRepository) UpdatePurchaseDebitNote(debitNote *models.PurchaseDebitNote) error {
+	return r.db.Omit("Items", "Bill", "Vendor", "Applications").Save(debitNote).Error
+}
+
+func (r *purchaseRepository) UpdatePurchaseDebitNoteItem(item *models.PurchaseDebitNoteItem) error {
+	return r.db.Omit("BillItem").Save(item).Error
+}
+
+// SumDebitedByBillItem totals the quantity and amount already debited on
+// each line of the bill by posted debit notes
+func (r *purchaseRepository) SumDebitedByBillItem(billID uint) (map[uint]models.PurchaseDebitNoteItem, error) {
+	var rows []struct {
+		BillItemID uint
+		Quantity   float64
+		Amount     float64
+	}
+	err := r.db.Table("purchase_debit_note_items di").
+		Select("di.bill_item_id, SUM(di.quantity) AS quantity, SUM(di.amount) AS amount").
+		Joins("JOIN purchase_debit_notes n ON n.id = di.debit_note_id AND n.deleted_at IS NULL").
+		Where("n.bill_id = ? AND n.status = ? AND di.deleted_at IS NULL", billID, models.DebitNoteStatusPosted).
+		Group("di.bill_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	debited := make(map[uint]models.PurchaseDebitNoteItem, len(rows))
+	for _, row := range rows {
+		debited[row.BillItemID] = models.PurchaseDebitNoteItem{Quantity: row.Quantity, Amount: row.Amount}
+	}
+	return debited, nil
+}
+
+func (r *purchaseRepository) GeneratePurchaseDebitNoteNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "DN/" + date.Format("200601/")
+
+	err := r.db.Model(&models.PurchaseDebitNote{}).
+		Where("company_id = ? AND debit_note_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+func (r *purchaseRepository) CreatePurchaseDebitNoteApplication(application *models.PurchaseDebitNoteApplication) error {
+	return r.db.Create(application).Error
+}
+
+// Payment Run methods
+func (r *purchaseRepository) CreatePaymentRun(run *models.PaymentRun) error {
+	return r.db.Create(run).Error
+}
+
+func (r *purchaseRepository) FindPaymentRunByID(id uint) (*models.PaymentRun, error) {
+	var run models.PaymentRun
+	err := r.db.Preload("Lines", func(db *gorm.DB) *gorm.DB {
+		return db.Order("vendor_id ASC, id ASC")
+	}).
+		Preload("Lines.Bill.Vendor").
+		Preload("Account").
+		Preload("User").
+		First(&run, id).Error
+	return &run, err
+}
+
+func (r *purchaseRepository) FindPaymentRuns(companyID uint, status string) ([]models.PaymentRun, error) {
+	var runs []models.PaymentRun
+	query := r.db.Where("company_id = ?", companyID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("payment_date DESC, id DESC").
+		Preload("Account").
+		Find(&runs).Error
+	return runs, err
+}
+
+// FindBillsInDraftRuns returns which of the bills are already proposed in a draft run
+func (r *purchaseRepository) FindBillsInDraftRuns(billIDs []uint) ([]uint, error) {
+	var ids []uint
+	if len(billIDs) == 0 {
+		return ids, nil
+	}
+	err := r.db.Model(&models.PaymentRunLine{}).
+		Joins("JOIN payment_runs ON payment_runs.id = payment_run_lines.payment_run_id AND payment_runs.deleted_at IS NULL").
+		Where("payment_runs.status = ? AND payment_run_lines.bill_id IN ?", models.PaymentRunStatusDraft, billIDs).
+		Distinct().
+		Pluck("payment_run_lines.bill_id", &ids).Error
+	return ids, err
+}
+
+func (r *purchaseRepository) UpdatePaymentRun(run *models.PaymentRun) error {
+	return r.db.Omit("Lines", "Account").Save(run).Error
+}
+
+func (r *purchaseRepository) UpdatePaymentRunLine(line *models.PaymentRunLine) error {
+	return r.db.Omit("Bill").Save(line).Error
+}
+
+func (r *purchaseRepository) DeletePaymentRunLine(id uint) error {
+	return r.db.Delete(&models.PaymentRunLine{}, id).Error
+}
+
+func (r *purchaseRepository) GeneratePaymentRunNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "PRN/" + date.Format("200601/")
+
+	err := r.db.Model(&models.PaymentRun{}).
+		Where("company_id = ? AND run_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
\ No newline at end of file
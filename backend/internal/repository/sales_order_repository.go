@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SalesOrderRepository interface {
+	// Sales Quotations
+	CreateSalesQuotation(quotation *models.SalesQuotation) error
+	FindSalesQuotationByID(id uint) (*models.SalesQuotation, error)
+	FindSalesQuotations(companyID uint, customerID uint, status string) ([]models.SalesQuotation, error)
+	UpdateSalesQuotation(quotation *models.SalesQuotation) error
+	GenerateSalesQuotationNumber(companyID uint, date time.Time) (string, error)
+
+	// Sales Orders
+	CreateSalesOrder(order *models.SalesOrder) error
+	FindSalesOrderByID(id uint) (*models.SalesOrder, error)
+	FindSalesOrders(companyID uint, customerID uint, status string) ([]models.SalesOrder, error)
+	UpdateSalesOrder(order *models.SalesOrder) error
+	UpdateSalesOrderItem(item *models.SalesOrderItem) error
+	GenerateSalesOrderNumber(companyID uint, date time.Time) (string, error)
+
+	// Delivery Orders
+	CreateDeliveryOrder(delivery *models.DeliveryOrder) error
+	FindDeliveryOrderByID(id uint) (*models.DeliveryOrder, error)
+	FindDeliveryOrders(companyID uint, customerID uint, salesOrderID uint, status string) ([]models.DeliveryOrder, error)
+	FindDeliveryOrderItemsByIDs(ids []uint) ([]models.DeliveryOrderItem, error)
+	CountDraftInvoiceLinesByDeliveries(deliveryIDs []uint) (int64, error)
+	UpdateDeliveryOrder(delivery *models.DeliveryOrder) error
+	UpdateDeliveryOrderItem(item *models.DeliveryOrderItem) error
+	GenerateDeliveryOrderNumber(companyID uint, date time.Time) (string, error)
+
+	// Reports
+	FindBackorderLines(companyID uint, customerID uint) ([]models.BackorderLine, error)
+}
+
+type salesOrderRepository struct {
+	db *gorm.DB
+}
+
+func NewSalesOrderRepository(db *gorm.DB) SalesOrderRepository {
+	return &salesOrderRepository{db: db}
+}
+
+// Sales Quotation methods
+func (r *salesOrderRepository) CreateSalesQuotation(quotation *models.SalesQuotation) error {
+	return r.db.Create(quotation).Error
+}
+
+func (r *salesOrderRepository) FindSalesQuotationByID(id uint) (*models.SalesQuotation, error) {
+	var quotation models.SalesQuotation
+	err := r.db.Preload("Items.Product").
+		Preload("Customer").
+		Preload("User").
+		First(&quotation, id).Error
+	return &quotation, err
+}
+
+func (r *salesOrderRepository) FindSalesQuotations(companyID uint, customerID uint, status string) ([]models.SalesQuotation, error) {
+	var quotations []models.SalesQuotation
+	query := r.db.Where("company_id = ?", companyID)
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("quotation_date DESC, id DESC").
+		Preload("Customer").
+		Find(&quotations).Error
+	return quotations, err
+}
+
+func (r *salesOrderRepository) UpdateSalesQuotation(quotation *models.SalesQuotation) error {
+	return r.db.Omit("Items", "Customer", "User").Save(quotation).Error
+}
+
+func (r *salesOrderRepository) GenerateSalesQuotationNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "SQ/" + date.Format("200601/")
+
+	err := r.db.Model(&models.SalesQuotation{}).
+		Where("company_id = ? AND quotation_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Sales Order methods
+func (r *salesOrderRepository) CreateSalesOrder(order *models.SalesOrder) error {
+	return r.db.Create(order).Error
+}
+
+func (r *salesOrderRepository) FindSalesOrderByID(id uint) (*models.SalesOrder, error) {
+	var order models.SalesOrder
+	err := r.db.Preload("Items.Product").
+		Preload("Items.Reservation").
+		Preload("Customer").
+		Preload("User").
+		First(&order, id).Error
+	return &order, err
+}
+
+func (r *salesOrderRepository) FindSalesOrders(companyID uint, customerID uint, status string) ([]models.SalesOrder, error) {
+	var orders []models.SalesOrder
+	query := r.db.Where("company_id = ?", companyID)
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("order_date DESC, id DESC").
+		Preload("Customer").
+		Find(&orders).Error
+	return orders, err
+}
+
+func (r *salesOrderRepository) UpdateSalesOrder(order *models.SalesOrder) error {
+	return r.db.Omit("Items", "Customer", "User").Save(order).Error
+}
+
+func (r *salesOrderRepository) UpdateSalesOrderItem(item *models.SalesOrderItem) error {
+	return r.db.Omit("Product", "Reservation").Save(item).Error
+}
+
+func (r *salesOrderRepository) GenerateSalesOrderNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "SO/" + date.Format("200601/")
+
+	err := r.db.Model(&models.SalesOrder{}).
+		Where("company_id = ? AND order_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Delivery Order methods
+func (r *salesOrderRepository) CreateDeliveryOrder(delivery *models.DeliveryOrder) error {
+	return r.db.Omit("Items.SalesOrderItem").Create(delivery).Error
+}
+
+func (r *salesOrderRepository) FindDeliveryOrderByID(id uint) (*models.DeliveryOrder, error) {
+	var delivery models.DeliveryOrder
+	err := r.db.Preload("Items.Product").
+		Preload("Items.SalesOrderItem").
+		Preload("SalesOrder").
+		Preload("Customer").
+		Preload("User").
+		First(&delivery, id).Error
+	return &delivery, err
+}
+
+func (r *salesOrderRepository) FindDeliveryOrders(companyID uint, customerID uint, salesOrderID uint, status string) ([]models.DeliveryOrder, error) {
+	var deliveries []models.DeliveryOrder
+	query := r.db.Where("company_id = ?", companyID)
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if salesOrderID > 0 {
+		query = query.Where("sales_order_id = ?", salesOrderID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("delivery_date DESC, id DESC").
+		Preload("Customer").
+		Preload("SalesOrder").
+		Find(&deliveries).Error
+	return deliveries, err
+}
+
+// FindDeliveryOrderItemsByIDs loads delivery lines with their delivery, as
+// invoices raised from them are posted
+func (r *salesOrderRepository) FindDeliveryOrderItemsByIDs(ids []uint) ([]models.DeliveryOrderItem, error) {
+	var items []models.DeliveryOrderItem
+	if len(ids) == 0 {
+		return items, nil
+	}
+	err := r.db.Where("id IN ?", ids).
+		Preload("Delivery").
+		Find(&items).Error
+	return items, err
+}
+
+// CountDraftInvoiceLinesByDeliveries counts the lines of draft invoices
+// already raised from the deliveries
+func (r *salesOrderRepository) CountDraftInvoiceLinesByDeliveries(deliveryIDs []uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.SalesInvoiceItem{}).
+		Joins("JOIN sales_invoices ON sales_invoices.id = sales_invoice_items.invoice_id AND sales_invoices.deleted_at IS NULL").
+		Joins("JOIN delivery_order_items ON delivery_order_items.id = sales_invoice_items.delivery_item_id").
+		Where("delivery_order_items.delivery_id IN ? AND sales_invoices.status = ?", deliveryIDs, models.SalesInvoiceStatusDraft).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *salesOrderRepository) UpdateDeliveryOrder(delivery *models.DeliveryOrder) error {
+	return r.db.Omit("Items", "SalesOrder", "Customer", "User").Save(delivery).Error
+}
+
+func (r *salesOrderRepository) UpdateDeliveryOrderItem(item *models.DeliveryOrderItem) error {
+	return r.db.Omit("Delivery", "SalesOrderItem", "Product").Save(item).Error
+}
+
+func (r *salesOrderRepository) GenerateDeliveryOrderNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "DO/" + date.Format("200601/")
+
+	err := r.db.Model(&models.DeliveryOrder{}).
+		Where("company_id = ? AND delivery_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Report methods
+
+// FindBackorderLines returns the lines of confirmed sales orders still to be
+// delivered, oldest order first. The reserved quantity is what the line held
+// on confirmation while its reservation is active.
+func (r *salesOrderRepository) FindBackorderLines(companyID uint, customerID uint) ([]models.BackorderLine, error) {
+	var lines []models.BackorderLine
+	query := r.db.Table("sales_order_items i").
+		Select(`o.id AS sales_order_id, o.order_number, o.order_date, o.expected_date,
+			c.id AS customer_id, c.code AS customer_code, c.name AS customer_name,
+			i.id AS item_id, i.product_id, p.code AS product_code, i.description, i.unit,
+			i.quantity AS ordered_quantity, i.delivered_quantity, i.quantity - i.delivered_quantity AS backordered_quantity,
+			CASE WHEN r.status = ? THEN i.reserved_quantity ELSE 0 END AS reserved_quantity,
+			i.subtotal / i.quantity AS unit_price`, models.ReservationStatusActive).
+		Joins("JOIN sales_orders o ON o.id = i.sales_order_id AND o.deleted_at IS NULL").
+		Joins("JOIN customers c ON c.id = o.customer_id").
+		Joins("JOIN products p ON p.id = i.product_id").
+		Joins("LEFT JOIN stock_reservations r ON r.id = i.reservation_id").
+		Where("o.company_id = ? AND i.deleted_at IS NULL AND i.quantity > i.delivered_quantity", companyID).
+		Where("o.status IN ?", []models.SalesOrderStatus{
+			models.SalesOrderStatusConfirmed,
+			models.SalesOrderStatusPartiallyDelivered,
+		})
+	if customerID > 0 {
+		query = query.Where("o.customer_id = ?", customerID)
+	}
+	err := query.Order("o.order_date ASC, o.id ASC, i.id ASC").Scan(&lines).Error
+	return lines, err
+}
\ No newline at end of file
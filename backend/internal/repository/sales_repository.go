@@ -0,0 +1,504 @@
+package repository
+
+import (
+	"finara-backend/internal/models"
+	"fmt"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SalesRepository interface {
+	// Customers
+	CreateCustomer(customer *models.Customer) error
+	FindCustomerByID(id uint) (*models.Customer, error)
+	FindCustomersByCompany(companyID uint, activeOnly bool) ([]models.Customer, error)
+	UpdateCustomer(customer *models.Customer) error
+	DeleteCustomer(id uint) error
+	CountCustomerInvoices(customerID uint) (int64, error)
+	SumCustomerOutstanding(customerID uint) (float64, error)
+
+	// Sales Invoices
+	CreateSalesInvoice(invoice *models.SalesInvoice) error
+	FindSalesInvoiceByID(id uint) (*models.SalesInvoice, error)
+	FindSalesInvoices(companyID uint, customerID uint, status string) ([]models.SalesInvoice, error)
+	FindOpenSalesInvoices(customerID uint) ([]models.SalesInvoice, error)
+	UpdateSalesInvoice(invoice *models.SalesInvoice) error
+	UpdateSalesInvoiceItem(item *models.SalesInvoiceItem) error
+	FindReceivableOpenItems(companyID uint, customerID uint, asOf time.Time) ([]models.AgingOpenItem, error)
+	GenerateSalesInvoiceNumber(companyID uint, date time.Time) (string, error)
+
+	// Customer Payments
+	CreateCustomerPayment(payment *models.CustomerPayment) error
+	FindCustomerPaymentByID(id uint) (*models.CustomerPayment, error)
+	FindCustomerPayments(companyID uint, customerID uint) ([]models.CustomerPayment, error)
+	GenerateCustomerPaymentNumber(companyID uint, date time.Time) (string, error)
+
+	// Sales Credit Notes
+	CreateSalesCreditNote(creditNote *models.SalesCreditNote) error
+	FindSalesCreditNoteByID(id uint) (*models.SalesCreditNote, error)
+	FindSalesCreditNotes(companyID uint, customerID uint, invoiceID uint, status string) ([]models.SalesCreditNote, error)
+	UpdateSalesCreditNote(creditNote *models.SalesCreditNote) error
+	UpdateSalesCreditNoteItem(item *models.SalesCreditNoteItem) error
+	SumCreditedByInvoiceItem(invoiceID uint) (map[uint]models.SalesCreditNoteItem, error)
+	GenerateSalesCreditNoteNumber(companyID uint, date time.Time) (string, error)
+	CreateSalesCreditNoteApplication(application *models.SalesCreditNoteApplication) error
+
+	// Customer Statements
+	GetCustomerBalance(customerID uint, before time.Time) (float64, error)
+	GetCustomerStatementEntries(customerID uint, startDate, endDate time.Time) ([]models.CustomerStatementEntry, error)
+}
+
+type salesRepository struct {
+	db *gorm.DB
+}
+
+func NewSalesRepository(db *gorm.DB) SalesRepository {
+	return &salesRepository{db: db}
+}
+
+// Customer methods
+func (r *salesRepository) CreateCustomer(customer *models.Customer) error {
+	return r.db.Create(customer).Error
+}
+
+func (r *salesRepository) FindCustomerByID(id uint) (*models.Customer, error) {
+	var customer models.Customer
+	err := r.db.First(&customer, id).Error
+	return &customer, err
+}
+
+func (r *salesRepository) FindCustomersByCompany(companyID uint, activeOnly bool) ([]models.Customer, error) {
+	var customers []models.Customer
+	query := r.db.Where("company_id = ?", companyID)
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	err := query.Order("code ASC").Find(&customers).Error
+	return customers, err
+}
+
+func (r *salesRepository) UpdateCustomer(customer *models.Customer) error {
+	return r.db.Save(customer).Error
+}
+
+func (r *salesRepository) DeleteCustomer(id uint) error {
+	return r.db.Delete(&models.Customer{}, id).Error
+}
+
+func (r *salesRepository) CountCustomerInvoices(customerID uint) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.SalesInvoice{}).
+		Where("customer_id = ?", customerID).
+		Count(&count).Error
+	return count, err
+}
+
+// SumCustomerOutstanding totals what the customer still owes on posted invoices
+func (r *salesRepository) SumCustomerOutstanding(customerID uint) (float64, error) {
+	var total float64
+	err := r.db.Model(&models.SalesInvoice{}).
+		Where("customer_id = ? AND status IN ?", customerID, []models.SalesInvoiceStatus{
+			models.SalesInvoiceStatusPosted,
+			models.SalesInvoiceStatusPartiallyPaid,
+		}).
+		Select("COALESCE(SUM(total_amount - paid_amount - credited_amount), 0)").
+		Scan(&total).Error
+	return total, err
+}
+
+// Sales Invoice methods
+func (r *salesRepository) CreateSalesInvoice(invoice *models.SalesInvoice) error {
+	return r.db.Create(invoice).Error
+}
+
+func (r *salesRepository) FindSalesInvoiceByID(id uint) (*models.SalesInvoice, error) {
+	var invoice models.SalesInvoice
+	err := r.db.Preload("Items.Product").
+		Preload("Items.RevenueAccount").
+		Preload("Customer").
+		Preload("User").
+		First(&invoice, id).Error
+	return &invoice, err
+}
+
+func (r *salesRepository) FindSalesInvoices(companyID uint, customerID uint, status string) ([]models.SalesInvoice, error) {
+	var invoices []models.SalesInvoice
+	query := r.db.Where("company_id = ?", companyID)
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("invoice_date DESC, id DESC").
+		Preload("Customer").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+// FindOpenSalesInvoices returns the customer's unpaid invoices, oldest due first
+func (r *salesRepository) FindOpenSalesInvoices(customerID uint) ([]models.SalesInvoice, error) {
+	var invoices []models.SalesInvoice
+	err := r.db.Where("customer_id = ? AND status IN ?", customerID, []models.SalesInvoiceStatus{
+		models.SalesInvoiceStatusPosted,
+		models.SalesInvoiceStatusPartiallyPaid,
+	}).
+		Order("due_date ASC, id ASC").
+		Find(&invoices).Error
+	return invoices, err
+}
+
+func (r *salesRepository) UpdateSalesInvoice(invoice *models.SalesInvoice) error {
+	return r.db.Omit("Items", "Customer").Save(invoice).Error
+}
+
+func (r *salesRepository) UpdateSalesInvoiceItem(item *models.SalesInvoiceItem) error {
+	return r.db.Omit("Product", "RevenueAccount").Save(item).Error
+}
+
+// FindReceivableOpenItems returns posted invoices dated on or before asOf
+// with what was still unpaid and not credited at that date. A credit note
+// counts for the part it applied to its invoice; customer credit applied to
+// an invoice later counts from the date it was applied.
+func (r *salesRepository) FindReceivableOpenItems(companyID uint, customerID uint, asOf time.Time) ([]models.AgingOpenItem, error) {
+	var items []models.AgingOpenItem
+	query := r.db.Table("sales_invoices i").
+		Select(`i.id AS document_id, 'invoice' AS document_type, i.invoice_number AS document_number,
+			i.invoice_date AS document_date, i.due_date, c.id AS party_id, c.code AS party_code, c.name AS party_name,
+			i.total_amount, i.total_amount - COALESCE((
+				SELECT SUM(a.amount)
+				FROM customer_payment_allocations a
+				JOIN customer_payments p ON p.id = a.payment_id AND p.deleted_at IS NULL
+				WHERE a.invoice_id = i.id AND a.deleted_at IS NULL AND p.payment_date <= ?
+			), 0) - COALESCE((
+				SELECT SUM(n.total_amount - n.unapplied_amount)
+				FROM sales_credit_notes n
+				WHERE n.invoice_id = i.id AND n.deleted_at IS NULL AND n.status = ? AND n.credit_note_date <= ?
+			), 0) - COALESCE((
+				SELECT SUM(ca.amount)
+				FROM sales_credit_note_applications ca
+				WHERE ca.invoice_id = i.id AND ca.deleted_at IS NULL AND ca.application_date <= ?
+			), 0) AS outstanding`, asOf, models.CreditNoteStatusPosted, asOf, asOf).
+		Joins("JOIN customers c ON c.id = i.customer_id").
+		Where("i.company_id = ? AND i.deleted_at IS NULL AND i.invoice_date <= ?", companyID, asOf).
+		Where("i.status IN ?", []models.SalesInvoiceStatus{
+			models.SalesInvoiceStatusPosted,
+			models.SalesInvoiceStatusPartiallyPaid,
+			models.SalesInvoiceStatusPaid,
+		})
+	if customerID > 0 {
+		query = query.Where("i.customer_id = ?", customerID)
+	}
+	err := query.Order("i.due_date ASC, i.id ASC").Scan(&items).Error
+	return items, err
+}
+
+func (r *salesRepository) GenerateSalesInvoiceNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "INV/" + date.Format("200601/")
+
+	err := r.db.Model(&models.SalesInvoice{}).
+		Where("company_id = ? AND invoice_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Customer Payment methods
+func (r *salesRepository) CreateCustomerPayment(payment *models.CustomerPayment) error {
+	return r.db.Create(payment).Error
+}
+
+func (r *salesRepository) FindCustomerPaymentByID(id uint) (*models.CustomerPayment, error) {
+	var payment models.CustomerPayment
+	err := r.db.Preload("Allocations.Invoice").
+		Preload("Customer").
+		Preload("Account").
+		Preload("User").
+		First(&payment, id).Error
+	return &payment, err
+}
+
+func (r *salesRepository) FindCustomerPayments(companyID uint, customerID uint) ([]models.CustomerPayment, error) {
+	var payments []models.CustomerPayment
+	query := r.db.Where("company_id = ?", companyID)
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	err := query.Order("payment_date DESC, id DESC").
+		Preload("Customer").
+		Preload("Account").
+		Find(&payments).Error
+	return payments, err
+}
+
+func (r *salesRepository) GenerateCustomerPaymentNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "RCV/" + date.Format("200601/")
+
+	err := r.db.Model(&models.CustomerPayment{}).
+		Where("company_id = ? AND payment_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+// Sales Credit Note methods
+func (r *salesRepository) CreateSalesCreditNote(creditNote *models.SalesCreditNote) error {
+	return r.db.Create(creditNote).Error
+}
+
+func (r *salesRepository) FindSalesCreditNoteByID(id uint) (*models.SalesCreditNote, error) {
+	var creditNote models.SalesCreditNote
+	err := r.db.Preload("Items.Product").
+		Preload("Items.InvoiceItem").
+		Preload("Invoice").
+		Preload("Customer").
+		Preload("User").
+		Preload("Applications", func(db *gorm.DB) *gorm.DB {
+			return db.Order("application_date ASC, id ASC")
+		}).
+		First(&creditNote, id).Error
+	return &creditNote, err
+}
+
+func (r *salesRepository) FindSalesCreditNotes(companyID uint, customerID uint, invoiceID uint, status string) ([]models.SalesCreditNote, error) {
+	var creditNotes []models.SalesCreditNote
+	query := r.db.Where("company_id = ?", companyID)
+	if customerID > 0 {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if invoiceID > 0 {
+		query = query.Where("invoice_id = ?", invoiceID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Order("credit_note_date DESC, id DESC").
+		Preload("Customer").
+		Preload("Invoice").
+		Find(&creditNotes).Error
+	return creditNotes, err
+}
+
+func (r *salesRepository) UpdateSalesCreditNote(creditNote *models.SalesCreditNote) error {
+	return r.db.Omit("Items", "Invoice", "Customer", "Applications").Save(creditNote).Error
+}
+
+func (r *salesRepository) UpdateSalesCreditNoteItem(item *models.SalesCreditNoteItem) error {
+	return r.db.Omit("InvoiceItem", "Product").Save(item).Error
+}
+
+// SumCreditedByInvoiceItem totals the quantity and amount already credited
+// on each line of the invoice by posted credit notes
+func (r *salesRepository) SumCreditedByInvoiceItem(invoiceID uint) (map[uint]models.SalesCreditNoteItem, error) {
+	var rows []struct {
+		InvoiceItemID uint
+		Quantity      float64
+		Amount        float64
+	}
+	err := r.db.Table("sales_credit_note_items ci").
+		Select("ci.invoice_item_id, SUM(ci.quantity) AS quantity, SUM(ci.amount) AS amount").
+		Joins("JOIN sales_credit_notes n ON n.id = ci.credit_note_id AND n.deleted_at IS NULL").
+		Where("n.invoice_id = ? AND n.status = ? AND ci.deleted_at IS NULL", invoiceID, models.CreditNoteStatusPosted).
+		Group("ci.invoice_item_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	credited := make(map[uint]models.SalesCreditNoteItem, len(rows))
+	for _, row := range rows {
+		credited[row.InvoiceItemID] = models.SalesCreditNoteItem{Quantity: row.Quantity, Amount: row.Amount}
+	}
+	return credited, nil
+}
+
+func (r *salesRepository) GenerateSalesCreditNoteNumber(companyID uint, date time.Time) (string, error) {
+	var count int64
+	prefix := "CN/" + date.Format("200601/")
+
+	err := r.db.Model(&models.SalesCreditNote{}).
+		Where("company_id = ? AND credit_note_number LIKE ?", companyID, prefix+"%").
+		Count(&count).Error
+
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + fmt.Sprintf("%04d", count+1), nil
+}
+
+func (r *salesRepository) CreateSalesCreditNoteApplication(application *models.SalesCreditNoteApplication) error {
+	return r.db.Create(application).Error
+}
+
+// Customer Statement methods
+
+// statementInvoiceStatuses are the invoices that count towards what the customer owes
+var statementInvoiceStatuses = []models.SalesInvoiceStatus{
+	models.SalesInvoiceStatusPosted,
+	models.SalesInvoiceStatusPartiallyPaid,
+	models.SalesInvoiceStatusPaid,
+}
+
+// GetCustomerBalance returns what the customer owed before the given time:
+// posted invoices and refunds of customer credit less payments received and
+// credit notes
+func (r *salesRepository) GetCustomerBalance(customerID uint, before time.Time) (float64, error) {
+	var invoiced, refunded, received, credited float64
+	err := r.db.Model(&models.SalesInvoice{}).
+		Where("customer_id = ? AND status IN ? AND invoice_date < ?", customerID, statementInvoiceStatuses, before).
+		Select("COALESCE(SUM(total_amount), 0)").
+		Scan(&invoiced).Error
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.db.Model(&models.CustomerPayment{}).
+		Where("customer_id = ? AND payment_date < ?", customerID, before).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&received).Error
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.db.Model(&models.SalesCreditNote{}).
+		Where("customer_id = ? AND status = ? AND credit_note_date < ?", customerID, models.CreditNoteStatusPosted, before).
+		Select("COALESCE(SUM(total_amount), 0)").
+		Scan(&credited).Error
+	if err != nil {
+		return 0, err
+	}
+
+	err = r.db.Model(&models.SalesCreditNoteApplication{}).
+		Where("customer_id = ? AND invoice_id IS NULL AND application_date < ?", customerID, before).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&refunded).Error
+	if err != nil {
+		return 0, err
+	}
+
+	return invoiced + refunded - received - credited, nil
+}
+
+// GetCustomerStatementEntries returns the customer's invoices and refunds
+// (debit), payments and credit notes (credit) in the period, by date with
+// invoices first
+func (r *salesRepository) GetCustomerStatementEntries(customerID uint, startDate, endDate time.Time) ([]models.CustomerStatementEntry, error) {
+	var invoices []models.SalesInvoice
+	err := r.db.Where("customer_id = ? AND status IN ? AND invoice_date BETWEEN ? AND ?",
+		customerID, statementInvoiceStatuses, startDate, endDate).
+		Order("invoice_date ASC, id ASC").
+		Find(&invoices).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var payments []models.CustomerPayment
+	err = r.db.Where("customer_id = ? AND payment_date BETWEEN ? AND ?", customerID, startDate, endDate).
+		Order("payment_date ASC, id ASC").
+		Find(&payments).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var creditNotes []models.SalesCreditNote
+	err = r.db.Where("customer_id = ? AND status = ? AND credit_note_date BETWEEN ? AND ?",
+		customerID, models.CreditNoteStatusPosted, startDate, endDate).
+		Order("credit_note_date ASC, id ASC").
+		Preload("Invoice").
+		Find(&creditNotes).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// Customer credit applied to another invoice leaves the balance as it
+	// was, only refunds paid out show on the statement
+	var refunds []struct {
+		ID               uint
+		ApplicationDate  time.Time
+		Reference        string
+		Amount           float64
+		CreditNoteNumber string
+	}
+	err = r.db.Table("sales_credit_note_applications ca").
+		Select("ca.id, ca.application_date, ca.reference, ca.amount, n.credit_note_number").
+		Joins("JOIN sales_credit_notes n ON n.id = ca.credit_note_id").
+		Where("ca.customer_id = ? AND ca.invoice_id IS NULL AND ca.deleted_at IS NULL AND ca.application_date BETWEEN ? AND ?",
+			customerID, startDate, endDate).
+		Order("ca.application_date ASC, ca.id ASC").
+		Scan(&refunds).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type datedEntry struct {
+		date  time.Time
+		entry models.CustomerStatementEntry
+	}
+	dated := make([]datedEntry, 0, len(invoices)+len(payments)+len(creditNotes)+len(refunds))
+	for _, invoice := range invoices {
+		dated = append(dated, datedEntry{invoice.InvoiceDate, models.CustomerStatementEntry{
+			Date:           invoice.InvoiceDate.Format("2006-01-02"),
+			Type:           "invoice",
+			DocumentID:     invoice.ID,
+			DocumentNumber: invoice.InvoiceNumber,
+			Reference:      invoice.Reference,
+			DueDate:        invoice.DueDate.Format("2006-01-02"),
+			Debit:          invoice.TotalAmount,
+		}})
+	}
+	for _, payment := range payments {
+		dated = append(dated, datedEntry{payment.PaymentDate, models.CustomerStatementEntry{
+			Date:           payment.PaymentDate.Format("2006-01-02"),
+			Type:           "payment",
+			DocumentID:     payment.ID,
+			DocumentNumber: payment.PaymentNumber,
+			Reference:      payment.Reference,
+			Credit:         payment.Amount,
+		}})
+	}
+	for _, creditNote := range creditNotes {
+		dated = append(dated, datedEntry{creditNote.CreditNoteDate, models.CustomerStatementEntry{
+			Date:           creditNote.CreditNoteDate.Format("2006-01-02"),
+			Type:           "credit_note",
+			DocumentID:     creditNote.ID,
+			DocumentNumber: creditNote.CreditNoteNumber,
+			Reference:      creditNote.Invoice.InvoiceNumber,
+			Credit:         creditNote.TotalAmount,
+		}})
+	}
+	for _, refund := range refunds {
+		dated = append(dated, datedEntry{refund.ApplicationDate, models.CustomerStatementEntry{
+			Date:           refund.ApplicationDate.Format("2006-01-02"),
+			Type:           "refund",
+			DocumentID:     refund.ID,
+			DocumentNumber: refund.CreditNoteNumber,
+			Reference:      refund.Reference,
+			Debit:          refund.Amount,
+		}})
+	}
+
+	// Invoices come first in the slice, so a stable sort by day keeps them
+	// ahead of payments and credit notes of the same day
+	sort.SliceStable(dated, func(i, j int) bool {
+		return dated[i].date.Format("2006-01-02") < dated[j].date.Format("2006-01-02")
+	})
+
+	entries := make([]models.CustomerStatementEntry, 0, len(dated))
+	for _, row := range dated {
+		entries = append(entries, row.entry)
+	}
+	return entries, nil
+}
\ No newline at end of file
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"finara-backend/internal/models"
 	"finara-backend/internal/repository"
+	"fmt"
+	"strings"
 )
 
 type AccountService interface {
@@ -16,6 +18,7 @@ type AccountService interface {
 	UpdateAccount(id uint, updates map[string]interface{}) (*models.Account, error)
 	DeleteAccount(id uint) error
 	InitializeDefaultAccounts(companyID uint) error
+	BackfillDefaultAccounts() error
 }
 
 type accountService struct {
@@ -99,17 +102,31 @@ func (s *accountService) InitializeDefaultAccounts(companyID uint) error {
 		{CompanyID: companyID, Code: "1-1200", Name: "Bank", Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false},
 		{CompanyID: companyID, Code: "1-1300", Name: "Piutang Usaha", Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false},
 		{CompanyID: companyID, Code: "1-1400", Name: "Persediaan Barang", Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "1-1500", Name: "Barang Dalam Proses", Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "1-1600", Name: "PPN Masukan", Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "1-1700", Name: "Uang Muka Karyawan", Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "1-1800", Name: "Biaya Dibayar di Muka", Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "1-1900", Name: "Saldo Kredit Pemasok", Type: models.AccountTypeAsset, Category: models.CategoryCurrentAsset, Level: 3, IsHeader: false},
 		
 		{CompanyID: companyID, Code: "1-2000", Name: "Aset Tetap", Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 2, IsHeader: true},
 		{CompanyID: companyID, Code: "1-2100", Name: "Peralatan", Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 3, IsHeader: false},
 		{CompanyID: companyID, Code: "1-2200", Name: "Kendaraan", Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 3, IsHeader: false},
 		{CompanyID: companyID, Code: "1-2300", Name: "Gedung", Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "1-2900", Name: "Akumulasi Penyusutan", Type: models.AccountTypeAsset, Category: models.CategoryFixedAsset, Level: 3, IsHeader: false},
 
 		// LIABILITAS
 		{CompanyID: companyID, Code: "2-0000", Name: "LIABILITAS", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 1, IsHeader: true},
 		{CompanyID: companyID, Code: "2-1000", Name: "Liabilitas Lancar", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 2, IsHeader: true},
 		{CompanyID: companyID, Code: "2-1100", Name: "Utang Usaha", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
 		{CompanyID: companyID, Code: "2-1200", Name: "Utang Pajak", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "2-1300", Name: "Pembelian Belum Ditagih", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "2-1400", Name: "Utang PPh Dipotong", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "2-1500", Name: "Utang Gaji", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "2-1600", Name: "Utang BPJS", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "2-1700", Name: "Utang PPh 21", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "2-1800", Name: "Bagian Lancar Utang Bank Jangka Panjang", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "2-1900", Name: "Pendapatan Diterima di Muka", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "2-1910", Name: "Saldo Kredit Pelanggan", Type: models.AccountTypeLiability, Category: models.CategoryCurrentLiability, Level: 3, IsHeader: false},
 		
 		{CompanyID: companyID, Code: "2-2000", Name: "Liabilitas Jangka Panjang", Type: models.AccountTypeLiability, Category: models.CategoryLongTermLiability, Level: 2, IsHeader: true},
 		{CompanyID: companyID, Code: "2-2100", Name: "Utang Bank Jangka Panjang", Type: models.AccountTypeLiability, Category: models.CategoryLongTermLiability, Level: 3, IsHeader: false},
@@ -118,6 +135,7 @@ func (s *accountService) InitializeDefaultAccounts(companyID uint) error {
 		{CompanyID: companyID, Code: "3-0000", Name: "EKUITAS", Type: models.AccountTypeEquity, Category: models.CategoryEquity, Level: 1, IsHeader: true},
 		{CompanyID: companyID, Code: "3-1000", Name: "Modal", Type: models.AccountTypeEquity, Category: models.CategoryEquity, Level: 2, IsHeader: false},
 		{CompanyID: companyID, Code: "3-2000", Name: "Laba Ditahan", Type: models.AccountTypeEquity, Category: models.CategoryEquity, Level: 2, IsHeader: false},
+		{CompanyID: companyID, Code: "3-3000", Name: "Surplus Revaluasi Aset Tetap", Type: models.AccountTypeEquity, Category: models.CategoryEquity, Level: 2, IsHeader: false},
 
 		// PENDAPATAN
 		{CompanyID: companyID, Code: "4-0000", Name: "PENDAPATAN", Type: models.AccountTypeRevenue, Category: models.CategoryOperatingRevenue, Level: 1, IsHeader: true},
@@ -131,15 +149,67 @@ func (s *accountService) InitializeDefaultAccounts(companyID uint) error {
 		{CompanyID: companyID, Code: "5-1200", Name: "Beban Sewa", Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 3, IsHeader: false},
 		{CompanyID: companyID, Code: "5-1300", Name: "Beban Listrik & Air", Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 3, IsHeader: false},
 		{CompanyID: companyID, Code: "5-1400", Name: "Beban Telepon & Internet", Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "5-1500", Name: "Beban Penyusutan", Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "5-1600", Name: "Rugi Penurunan Nilai Aset", Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 3, IsHeader: false},
+		{CompanyID: companyID, Code: "5-1700", Name: "Beban BPJS", Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 3, IsHeader: false},
 		
 		{CompanyID: companyID, Code: "5-2000", Name: "Beban Lain-lain", Type: models.AccountTypeExpense, Category: models.CategoryOtherExpense, Level: 2, IsHeader: false},
+		{CompanyID: companyID, Code: "5-3000", Name: "Harga Pokok Penjualan", Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 2, IsHeader: false},
+		{CompanyID: companyID, Code: "5-4000", Name: "Selisih Persediaan", Type: models.AccountTypeExpense, Category: models.CategoryOtherExpense, Level: 2, IsHeader: false},
+		{CompanyID: companyID, Code: "5-5000", Name: "Biaya Overhead Pabrik", Type: models.AccountTypeExpense, Category: models.CategoryOperatingExpense, Level: 2, IsHeader: false},
+		{CompanyID: companyID, Code: "5-6000", Name: "Beban Bunga", Type: models.AccountTypeExpense, Category: models.CategoryOtherExpense, Level: 2, IsHeader: false},
 	}
 
+	// Accounts the company already has, or had and deleted, are left alone,
+	// so running this again only adds defaults introduced since. Postings
+	// look the defaults up by code, so a code taken by a different kind of
+	// account is reported rather than reused.
+	accounts, err := s.accountRepo.FindWithDeletedByCompanyID(companyID)
+	if err != nil {
+		return err
+	}
+	existing := make(map[string]models.Account, len(accounts))
+	for _, account := range accounts {
+		existing[account.Code] = account
+	}
+
+	var conflicts []string
 	for _, account := range defaultAccounts {
+		if current, ok := existing[account.Code]; ok {
+			if current.Type != account.Type || !strings.EqualFold(strings.TrimSpace(current.Name), account.Name) {
+				conflicts = append(conflicts, fmt.Sprintf("%s is %q (%s), expected %q (%s)",
+					account.Code, current.Name, current.Type, account.Name, account.Type))
+			}
+			continue
+		}
 		if err := s.accountRepo.Create(&account); err != nil {
 			return err
 		}
 	}
+	if len(conflicts) > 0 {
+		return fmt.Errorf("default account codes in use by other accounts: %s", strings.Join(conflicts, "; "))
+	}
 
 	return nil
+}
+
+// BackfillDefaultAccounts adds the default accounts missing from every
+// company whose chart is already set up. Stock, tax, asset and payroll
+// postings fall back on these codes, so charts initialized before a code
+// joined the defaults get it at startup. A company whose chart cannot be
+// completed does not stop the others; the errors are returned together.
+func (s *accountService) BackfillDefaultAccounts() error {
+	companyIDs, err := s.accountRepo.FindCompanyIDsWithAccounts()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, companyID := range companyIDs {
+		if err := s.InitializeDefaultAccounts(companyID); err != nil {
+			errs = append(errs, fmt.Errorf("company %d: %w", companyID, err))
+		}
+	}
+
+	return errors.Join(errs...)
 }
\ No newline at end of file
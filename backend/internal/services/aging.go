@@ -0,0 +1,117 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// DefaultAgingLimits are the upper bounds in days overdue of the aging
+// buckets after "Current": 1-30, 31-60, 61-90 and over 90
+var DefaultAgingLimits = []int{30, 60, 90}
+
+// AgingBuckets turns ascending upper bounds into the report buckets
+func AgingBuckets(limits []int) ([]models.AgingBucket, error) {
+	buckets := []models.AgingBucket{{Label: "Current", FromDays: 0, ToDays: 0}}
+	from := 1
+	for _, limit := range limits {
+		if limit < from {
+			return nil, errors.New("aging buckets must be ascending days greater than zero")
+		}
+		buckets = append(buckets, models.AgingBucket{
+			Label:    strconv.Itoa(from) + "-" + strconv.Itoa(limit),
+			FromDays: from,
+			ToDays:   limit,
+		})
+		from = limit + 1
+	}
+	buckets = append(buckets, models.AgingBucket{
+		Label:    "> " + strconv.Itoa(from-1),
+		FromDays: from,
+		ToDays:   -1,
+	})
+	return buckets, nil
+}
+
+// BuildAgingReport spreads the open items over the buckets by days overdue
+// at asOf and totals them per party, ordered by party code
+func BuildAgingReport(items []models.AgingOpenItem, asOf time.Time, limits []int) (*models.AgingReport, error) {
+	buckets, err := AgingBuckets(limits)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.AgingReport{
+		AsOfDate:     asOf.Format("2006-01-02"),
+		Buckets:      buckets,
+		Lines:        []models.AgingPartyLine{},
+		BucketTotals: make([]float64, len(buckets)),
+	}
+
+	lines := make(map[uint]*models.AgingPartyLine)
+	var partyIDs []uint
+	for _, item := range items {
+		outstanding := math.Round(item.Outstanding*100) / 100
+		if outstanding == 0 {
+			continue
+		}
+
+		line, ok := lines[item.PartyID]
+		if !ok {
+			line = &models.AgingPartyLine{
+				PartyID:   item.PartyID,
+				PartyCode: item.PartyCode,
+				PartyName: item.PartyName,
+				Amounts:   make([]float64, len(buckets)),
+			}
+			lines[item.PartyID] = line
+			partyIDs = append(partyIDs, item.PartyID)
+		}
+
+		daysOverdue := int(math.Floor(asOf.Sub(item.DueDate).Hours() / 24))
+		bucket := 0
+		for i := len(buckets) - 1; i > 0; i-- {
+			if daysOverdue >= buckets[i].FromDays {
+				bucket = i
+				break
+			}
+		}
+
+		line.Documents = append(line.Documents, models.AgingDocument{
+			DocumentID:     item.DocumentID,
+			DocumentType:   item.DocumentType,
+			DocumentNumber: item.DocumentNumber,
+			DocumentDate:   item.DocumentDate.Format("2006-01-02"),
+			DueDate:        item.DueDate.Format("2006-01-02"),
+			DaysOverdue:    daysOverdue,
+			TotalAmount:    item.TotalAmount,
+			Outstanding:    outstanding,
+			Bucket:         bucket,
+		})
+		line.Amounts[bucket] += outstanding
+		line.Total += outstanding
+	}
+
+	sort.Slice(partyIDs, func(i, j int) bool {
+		return lines[partyIDs[i]].PartyCode < lines[partyIDs[j]].PartyCode
+	})
+	for _, partyID := range partyIDs {
+		line := lines[partyID]
+		for i := range line.Amounts {
+			line.Amounts[i] = math.Round(line.Amounts[i]*100) / 100
+			report.BucketTotals[i] += line.Amounts[i]
+		}
+		line.Total = math.Round(line.Total*100) / 100
+		report.Total += line.Total
+		report.Lines = append(report.Lines, *line)
+	}
+	for i := range report.BucketTotals {
+		report.BucketTotals[i] = math.Round(report.BucketTotals[i]*100) / 100
+	}
+	report.Total = math.Round(report.Total*100) / 100
+
+	return report, nil
+}
\ No newline at end of file
@@ -5,6 +5,8 @@ import (
 	"finara-backend/internal/models"
 	"finara-backend/internal/repository"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type CashBankService interface {
@@ -40,6 +42,46 @@ func NewCashBankService(
 	}
 }
 
+// cashBankServiceWith returns a cash bank service reading and writing through
+// db, so a receipt or payment can share the transaction of its document
+func cashBankServiceWith(db *gorm.DB) CashBankService {
+	return NewCashBankService(
+		repository.NewCashBankRepository(db),
+		repository.NewJournalRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewAccountRepository(db),
+	)
+}
+
+const (
+	defaultCashAccountCode = "1-1100" // Kas
+	defaultBankAccountCode = "1-1200" // Bank
+)
+
+// findCashBankAccount checks an account money is paid from or received into
+// belongs to the company and is Kas, Bank or an account under either of them
+func findCashBankAccount(accountRepo repository.AccountRepository, companyID uint, accountID uint) (*models.Account, error) {
+	account, err := accountRepo.FindByID(accountID)
+	if err != nil || account.CompanyID != companyID {
+		return nil, errors.New("cash/bank account not found")
+	}
+	if account.IsHeader {
+		return nil, errors.New("cash/bank account cannot be a header account")
+	}
+
+	for parent := account; ; {
+		if parent.Code == defaultCashAccountCode || parent.Code == defaultBankAccountCode {
+			return account, nil
+		}
+		if parent.ParentID == nil {
+			return nil, errors.New("account " + account.Code + " is not a cash or bank account")
+		}
+		if parent, err = accountRepo.FindByID(*parent.ParentID); err != nil {
+			return nil, err
+		}
+	}
+}
+
 func (s *cashBankService) CreateTransaction(transaction *models.CashBankTransaction) error {
 	// Generate transaction number
 	transactionNumber, err := s.cashBankRepo.GenerateTransactionNumber(
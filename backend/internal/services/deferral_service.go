@@ -0,0 +1,456 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type DeferralService interface {
+	// Deferral Schedules
+	CreateDeferralSchedule(schedule *models.DeferralSchedule) error
+	GetDeferralScheduleByID(id uint) (*models.DeferralSchedule, error)
+	GetDeferralSchedules(companyID uint, deferralType string, status string) ([]models.DeferralSchedule, error)
+	DeleteDeferralSchedule(id uint) error
+
+	// Deferral Runs
+	RunAmortization(companyID uint, period string, userID uint) (*models.DeferralRun, error)
+	ProcessAmortization(companyID, userID uint) error
+	GetDeferralRunByID(id uint) (*models.DeferralRun, error)
+	GetDeferralRuns(companyID uint) ([]models.DeferralRun, error)
+
+	// Reports
+	GetRollForwardReport(companyID uint, deferralType string, startDate, endDate time.Time) (*models.DeferralRollForwardReport, error)
+}
+
+const (
+	defaultPrepaidExpenseCode  = "1-1800" // Biaya Dibayar di Muka
+	defaultDeferredRevenueCode = "2-1900" // Pendapatan Diterima di Muka
+)
+
+// ErrNoSchedulesToAmortize is returned by RunAmortization for a period with
+// nothing to amortize
+var ErrNoSchedulesToAmortize = errors.New("no schedules to amortize")
+
+type deferralService struct {
+	db             *gorm.DB
+	deferralRepo   repository.DeferralRepository
+	accountRepo    repository.AccountRepository
+	journalService JournalService
+}
+
+func NewDeferralService(
+	db *gorm.DB,
+	deferralRepo repository.DeferralRepository,
+	accountRepo repository.AccountRepository,
+	journalService JournalService,
+) DeferralService {
+	return &deferralService{
+		db:             db,
+		deferralRepo:   deferralRepo,
+		accountRepo:    accountRepo,
+		journalService: journalService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so a run and
+// the journal it posts commit or roll back together
+func (s *deferralService) withTx(tx *gorm.DB) *deferralService {
+	return &deferralService{
+		db:             tx,
+		deferralRepo:   repository.NewDeferralRepository(tx),
+		accountRepo:    repository.NewAccountRepository(tx),
+		journalService: journalServiceWith(tx),
+	}
+}
+
+// BuildDeferralSchedule spreads the amount over the months of the term,
+// starting in the month of the start date. Daily schedules run to the month
+// of the end date and take an amount in proportion to the days of the term
+// in each month. Monthly ones count whole months, a part month rounded up,
+// so a year from the 15th is twelve equal amounts rather than thirteen. The
+// final month takes whatever is left.
+func BuildDeferralSchedule(schedule *models.DeferralSchedule) []models.DeferralScheduleLine {
+	start := time.Date(schedule.StartDate.Year(), schedule.StartDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	months := monthsBetween(schedule.StartDate, schedule.EndDate) + 1
+	if schedule.Method != models.DeferralMethodDaily {
+		following := schedule.EndDate.AddDate(0, 0, 1)
+		months = monthsBetween(schedule.StartDate, following)
+		if following.Day() > schedule.StartDate.Day() || months == 0 {
+			months++
+		}
+	}
+	totalDays := int(schedule.EndDate.Sub(schedule.StartDate).Hours()/24) + 1
+	if months <= 0 || totalDays <= 0 {
+		return nil
+	}
+
+	lines := make([]models.DeferralScheduleLine, months)
+	var accumulated float64
+	for k := 0; k < months; k++ {
+		line := &lines[k]
+		line.ScheduleID = schedule.ID
+		line.Period = start.AddDate(0, k, 0).Format("2006-01")
+		line.PeriodEnd = start.AddDate(0, k+1, -1)
+
+		from, to := start.AddDate(0, k, 0), line.PeriodEnd
+		if from.Before(schedule.StartDate) {
+			from = schedule.StartDate
+		}
+		if to.After(schedule.EndDate) {
+			to = schedule.EndDate
+		}
+		line.Days = int(to.Sub(from).Hours()/24) + 1
+
+		switch {
+		case k == months-1:
+			line.Amount = math.Round((schedule.Amount-accumulated)*100) / 100
+		case schedule.Method == models.DeferralMethodDaily:
+			line.Amount = math.Round(schedule.Amount*float64(line.Days)/float64(totalDays)*100) / 100
+		default:
+			line.Amount = math.Round(schedule.Amount/float64(months)*100) / 100
+		}
+
+		accumulated = math.Round((accumulated+line.Amount)*100) / 100
+		line.Accumulated = accumulated
+		line.Remaining = math.Round((schedule.Amount-accumulated)*100) / 100
+	}
+	return lines
+}
+
+// CalculateDeferralRollForward moves a schedule's deferred balance across
+// the report period from the months posted. A schedule starting within the
+// period comes in as an addition rather than an opening balance.
+func CalculateDeferralRollForward(schedule *models.DeferralSchedule, posted []models.DeferralScheduleLine, startDate, endDate time.Time) models.DeferralRollForwardLine {
+	line := models.DeferralRollForwardLine{
+		ScheduleID:     schedule.ID,
+		ScheduleNumber: schedule.ScheduleNumber,
+		Description:    schedule.Description,
+		StartDate:      schedule.StartDate,
+		EndDate:        schedule.EndDate,
+	}
+
+	var before float64
+	for _, month := range posted {
+		switch {
+		case month.PeriodEnd.Before(startDate):
+			before += month.Amount
+		case !month.PeriodEnd.After(endDate):
+			line.Amortization += month.Amount
+		}
+	}
+
+	if schedule.StartDate.Before(startDate) {
+		line.OpeningBalance = math.Round((schedule.Amount-before)*100) / 100
+	} else {
+		line.Additions = schedule.Amount
+	}
+	line.Amortization = math.Round(line.Amortization*100) / 100
+	line.ClosingBalance = math.Round((line.OpeningBalance+line.Additions-line.Amortization)*100) / 100
+	return line
+}
+
+// findScheduleAccount checks an account chosen for the schedule belongs to
+// the company, can be posted to and is of the expected type
+func (s *deferralService) findScheduleAccount(companyID uint, accountID uint, accountType models.AccountType, label string) (*models.Account, error) {
+	account, err := s.accountRepo.FindByID(accountID)
+	if err != nil || account.CompanyID != companyID {
+		return nil, errors.New(label + " account not found")
+	}
+	if account.IsHeader {
+		return nil, errors.New(label + " account cannot be a header account")
+	}
+	if account.Type != accountType {
+		return nil, errors.New(label + " account must be of type " + string(accountType))
+	}
+	return account, nil
+}
+
+// Deferral Schedule methods
+func (s *deferralService) CreateDeferralSchedule(schedule *models.DeferralSchedule) error {
+	schedule.Description = strings.TrimSpace(schedule.Description)
+	if schedule.Description == "" {
+		return errors.New("description is required")
+	}
+	schedule.Amount = math.Round(schedule.Amount*100) / 100
+	if schedule.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+	if schedule.EndDate.Before(schedule.StartDate) {
+		return errors.New("end date cannot be before the start date")
+	}
+	if schedule.Method == "" {
+		schedule.Method = models.DeferralMethodMonthly
+	}
+	if schedule.Method != models.DeferralMethodDaily && schedule.Method != models.DeferralMethodMonthly {
+		return errors.New("invalid deferral method")
+	}
+
+	var balanceSheetType, plType models.AccountType
+	var defaultCode string
+	switch schedule.Type {
+	case models.DeferralTypePrepaidExpense:
+		balanceSheetType, plType, defaultCode = models.AccountTypeAsset, models.AccountTypeExpense, defaultPrepaidExpenseCode
+	case models.DeferralTypeDeferredRevenue:
+		balanceSheetType, plType, defaultCode = models.AccountTypeLiability, models.AccountTypeRevenue, defaultDeferredRevenueCode
+	default:
+		return errors.New("invalid deferral type")
+	}
+
+	if schedule.BalanceSheetAccountID == 0 {
+		account, err := s.accountRepo.FindByCode(schedule.CompanyID, defaultCode)
+		if err != nil {
+			return errors.New("balance sheet account " + defaultCode + " not found")
+		}
+		schedule.BalanceSheetAccountID = account.ID
+	} else if _, err := s.findScheduleAccount(schedule.CompanyID, schedule.BalanceSheetAccountID, balanceSheetType, "balance sheet"); err != nil {
+		return err
+	}
+	if _, err := s.findScheduleAccount(schedule.CompanyID, schedule.PLAccountID, plType, "P&L"); err != nil {
+		return err
+	}
+
+	scheduleNumber, err := s.deferralRepo.GenerateDeferralScheduleNumber(schedule.CompanyID, schedule.StartDate)
+	if err != nil {
+		return err
+	}
+	schedule.ScheduleNumber = scheduleNumber
+	schedule.AmortizedAmount = 0
+	schedule.AmortizedThrough = nil
+	schedule.Status = models.DeferralStatusActive
+	schedule.Lines = BuildDeferralSchedule(schedule)
+
+	return s.deferralRepo.CreateDeferralSchedule(schedule)
+}
+
+func (s *deferralService) GetDeferralScheduleByID(id uint) (*models.DeferralSchedule, error) {
+	return s.deferralRepo.FindDeferralScheduleByID(id)
+}
+
+func (s *deferralService) GetDeferralSchedules(companyID uint, deferralType string, status string) ([]models.DeferralSchedule, error) {
+	return s.deferralRepo.FindDeferralSchedules(companyID, deferralType, status)
+}
+
+func (s *deferralService) DeleteDeferralSchedule(id uint) error {
+	schedule, err := s.deferralRepo.FindDeferralScheduleByID(id)
+	if err != nil {
+		return errors.New("deferral schedule not found")
+	}
+
+	if schedule.AmortizedThrough != nil {
+		return errors.New("cannot delete a schedule with posted amortization")
+	}
+
+	return s.deferralRepo.DeleteDeferralSchedule(id)
+}
+
+// Deferral Run methods
+
+// RunAmortization amortizes every active schedule up to the end of the
+// period and posts it as one journal: prepaid expenses are charged to their
+// expense accounts and deferred revenue is recognized in its revenue
+// accounts
+func (s *deferralService) RunAmortization(companyID uint, period string, userID uint) (*models.DeferralRun, error) {
+	_, periodEnd, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.deferralRepo.FindLatestDeferralRun(companyID)
+	if err == nil && latest.Period >= period {
+		return nil, errors.New("amortization has already been run for " + latest.Period)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	schedules, err := s.deferralRepo.FindAmortizableSchedules(companyID, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &models.DeferralRun{
+		CompanyID: companyID,
+		Period:    period,
+		PeriodEnd: periodEnd,
+		CreatedBy: userID,
+	}
+	var amortized []*models.DeferralSchedule
+	var postedIDs []uint
+	for i := range schedules {
+		schedule := &schedules[i]
+		lines, err := s.deferralRepo.FindUnpostedDeferralLines(schedule.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		line := models.DeferralRunLine{ScheduleID: schedule.ID}
+		var through *models.DeferralScheduleLine
+		for k := range lines {
+			month := &lines[k]
+			if month.PeriodEnd.After(periodEnd) {
+				break
+			}
+			line.Months++
+			line.Amount += month.Amount
+			postedIDs = append(postedIDs, month.ID)
+			through = month
+		}
+		if through == nil {
+			continue
+		}
+
+		line.Amount = math.Round(line.Amount*100) / 100
+		line.Remaining = through.Remaining
+		run.Lines = append(run.Lines, line)
+		if schedule.Type == models.DeferralTypePrepaidExpense {
+			run.TotalPrepaidExpense += line.Amount
+		} else {
+			run.TotalDeferredRevenue += line.Amount
+		}
+
+		schedule.AmortizedAmount = through.Accumulated
+		throughDate := through.PeriodEnd
+		schedule.AmortizedThrough = &throughDate
+		if line.Months == len(lines) {
+			schedule.Status = models.DeferralStatusCompleted
+		}
+		amortized = append(amortized, schedule)
+	}
+	if len(run.Lines) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoSchedulesToAmortize, period)
+	}
+	run.TotalPrepaidExpense = math.Round(run.TotalPrepaidExpense*100) / 100
+	run.TotalDeferredRevenue = math.Round(run.TotalDeferredRevenue*100) / 100
+
+	// Debits first: expenses charged and deferred revenue released, then
+	// the prepayments used up and the revenue earned
+	var journalLines []netJournalLine
+	for i, schedule := range amortized {
+		if schedule.Type == models.DeferralTypePrepaidExpense {
+			journalLines = append(journalLines, netJournalLine{schedule.PLAccountID, run.Lines[i].Amount})
+		} else {
+			journalLines = append(journalLines, netJournalLine{schedule.BalanceSheetAccountID, run.Lines[i].Amount})
+		}
+	}
+	for i, schedule := range amortized {
+		if schedule.Type == models.DeferralTypePrepaidExpense {
+			journalLines = append(journalLines, netJournalLine{schedule.BalanceSheetAccountID, -run.Lines[i].Amount})
+		} else {
+			journalLines = append(journalLines, netJournalLine{schedule.PLAccountID, -run.Lines[i].Amount})
+		}
+	}
+	// The journal and the run that marks the months posted are saved
+	// together, so a failed run leaves nothing posted to run again over
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		journalID, err := postNetJournal(txService.journalService, companyID, periodEnd, "Amortization of deferrals "+period, journalLines, userID)
+		if err != nil {
+			return err
+		}
+		run.JournalID = journalID
+
+		if err := txService.deferralRepo.CreateDeferralRun(run); err != nil {
+			return err
+		}
+		if err := txService.deferralRepo.MarkDeferralLinesPosted(postedIDs, run.ID); err != nil {
+			return err
+		}
+		for _, schedule := range amortized {
+			if err := txService.deferralRepo.UpdateDeferralSchedule(schedule); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// ProcessAmortization is the scheduled run, month by month through
+// last month, posted as the given (system) user. It carries on from the
+// runs made by hand, so nothing happens before the first one.
+func (s *deferralService) ProcessAmortization(companyID, userID uint) error {
+	latest, err := s.deferralRepo.FindLatestDeferralRun(companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return runPendingPeriods(latest.Period, ErrNoSchedulesToAmortize, func(period string) error {
+		_, err := s.RunAmortization(companyID, period, userID)
+		return err
+	})
+}
+
+func (s *deferralService) GetDeferralRunByID(id uint) (*models.DeferralRun, error) {
+	return s.deferralRepo.FindDeferralRunByID(id)
+}
+
+func (s *deferralService) GetDeferralRuns(companyID uint) ([]models.DeferralRun, error) {
+	return s.deferralRepo.FindDeferralRuns(companyID)
+}
+
+// Report methods
+
+// GetRollForwardReport moves the deferred balances from the start to the
+// end of the period for each balance sheet account: the opening balance,
+// schedules added, amortization posted and what remains deferred
+func (s *deferralService) GetRollForwardReport(companyID uint, deferralType string, startDate, endDate time.Time) (*models.DeferralRollForwardReport, error) {
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date cannot be before the start date")
+	}
+
+	schedules, err := s.deferralRepo.FindSchedulesStartedBy(companyID, deferralType, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.DeferralRollForwardReport{StartDate: startDate, EndDate: endDate}
+	groups := make(map[uint]int)
+	for i := range schedules {
+		schedule := &schedules[i]
+		posted, err := s.deferralRepo.FindPostedDeferralLines(schedule.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		line := CalculateDeferralRollForward(schedule, posted, startDate, endDate)
+		if line.OpeningBalance == 0 && line.Additions == 0 && line.Amortization == 0 {
+			continue
+		}
+
+		index, ok := groups[schedule.BalanceSheetAccountID]
+		if !ok {
+			index = len(report.Groups)
+			groups[schedule.BalanceSheetAccountID] = index
+			report.Groups = append(report.Groups, models.DeferralRollForwardGroup{
+				Type:        schedule.Type,
+				AccountID:   schedule.BalanceSheetAccountID,
+				AccountCode: schedule.BalanceSheetAccount.Code,
+				AccountName: schedule.BalanceSheetAccount.Name,
+			})
+		}
+
+		group := &report.Groups[index]
+		group.Lines = append(group.Lines, line)
+		group.OpeningBalance = math.Round((group.OpeningBalance+line.OpeningBalance)*100) / 100
+		group.Additions = math.Round((group.Additions+line.Additions)*100) / 100
+		group.Amortization = math.Round((group.Amortization+line.Amortization)*100) / 100
+		group.ClosingBalance = math.Round((group.ClosingBalance+line.ClosingBalance)*100) / 100
+	}
+
+	return report, nil
+}
\ No newline at end of file
@@ -0,0 +1,387 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+type DunningService interface {
+	// Dunning Levels
+	GetDunningLevels(companyID uint) ([]models.DunningLevel, error)
+	SetDunningLevels(companyID uint, levels []models.DunningLevel) ([]models.DunningLevel, error)
+
+	// Dunning Runs
+	RunDunning(companyID uint, asOf time.Time) ([]models.DunningReminder, error)
+	ProcessDunning(companyID uint) error
+
+	// Dunning Reminders
+	GetDunningReminderByID(id uint) (*models.DunningReminder, error)
+	GetDunningReminders(companyID uint, customerID uint) ([]models.DunningReminder, error)
+	ResendDunningReminder(id uint) (*models.DunningReminder, error)
+}
+
+// DefaultDunningLevels apply to companies that have not set up their own
+var DefaultDunningLevels = []models.DunningLevel{
+	{
+		Level:       1,
+		DaysOverdue: 7,
+		Name:        "Payment Reminder",
+		Subject:     "Payment reminder",
+		Message:     "Our records show that the invoices listed below are past due. We would appreciate your payment at your earliest convenience.",
+	},
+	{
+		Level:       2,
+		DaysOverdue: 30,
+		Name:        "Second Reminder",
+		Subject:     "Second reminder: overdue invoices",
+		Message:     "The invoices listed below remain unpaid despite our earlier reminder. Please arrange payment within 7 days.",
+	},
+	{
+		Level:       3,
+		DaysOverdue: 60,
+		Name:        "Final Notice",
+		Subject:     "Final notice: overdue invoices",
+		Message:     "This is our final notice for the invoices listed below. Unless payment is received within 7 days, further deliveries will be put on hold.",
+	},
+}
+
+type dunningService struct {
+	dunningRepo         repository.DunningRepository
+	salesRepo           repository.SalesRepository
+	notificationService NotificationService
+	exportService       ExportService
+	emailSender         EmailSender
+}
+
+func NewDunningService(
+	dunningRepo repository.DunningRepository,
+	salesRepo repository.SalesRepository,
+	notificationService NotificationService,
+	exportService ExportService,
+	emailSender EmailSender,
+) DunningService {
+	return &dunningService{
+		dunningRepo:         dunningRepo,
+		salesRepo:           salesRepo,
+		notificationService: notificationService,
+		exportService:       exportService,
+		emailSender:         emailSender,
+	}
+}
+
+// Dunning Level methods
+func (s *dunningService) GetDunningLevels(companyID uint) ([]models.DunningLevel, error) {
+	levels, err := s.dunningRepo.FindDunningLevels(companyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		levels = make([]models.DunningLevel, len(DefaultDunningLevels))
+		copy(levels, DefaultDunningLevels)
+		for i := range levels {
+			levels[i].CompanyID = companyID
+		}
+	}
+	return levels, nil
+}
+
+func (s *dunningService) SetDunningLevels(companyID uint, levels []models.DunningLevel) ([]models.DunningLevel, error) {
+	sort.SliceStable(levels, func(i, j int) bool {
+		return levels[i].Level < levels[j].Level
+	})
+	if err := ValidateDunningLevels(levels); err != nil {
+		return nil, err
+	}
+
+	if err := s.dunningRepo.ReplaceDunningLevels(companyID, levels); err != nil {
+		return nil, err
+	}
+	return levels, nil
+}
+
+// ValidateDunningLevels checks the levels, sorted by level, are numbered from
+// 1 without gaps and escalate: each level needs more days overdue than the last
+func ValidateDunningLevels(levels []models.DunningLevel) error {
+	if len(levels) == 0 {
+		return errors.New("at least one dunning level is required")
+	}
+	for i, level := range levels {
+		if level.Level != i+1 {
+			return errors.New("dunning levels must be numbered from 1 without gaps")
+		}
+		if level.Name == "" {
+			return fmt.Errorf("dunning level %d needs a name", level.Level)
+		}
+		if level.DaysOverdue <= 0 {
+			return fmt.Errorf("dunning level %d must start at one or more days overdue", level.Level)
+		}
+		if i > 0 && level.DaysOverdue <= levels[i-1].DaysOverdue {
+			return fmt.Errorf("dunning level %d must start later than level %d", level.Level, levels[i-1].Level)
+		}
+	}
+	return nil
+}
+
+// DunningLevelFor returns the highest level reached by an invoice that many
+// days overdue, nil when it is not yet due a reminder
+func DunningLevelFor(levels []models.DunningLevel, daysOverdue int) *models.DunningLevel {
+	var reached *models.DunningLevel
+	for i := range levels {
+		if daysOverdue >= levels[i].DaysOverdue {
+			reached = &levels[i]
+		}
+	}
+	return reached
+}
+
+// RunDunning sends one reminder per customer that has an invoice reaching a
+// level above the one it was last reminded at. The reminder takes the highest
+// level reached and lists all the customer's overdue invoices. A customer
+// that fails does not stop the others.
+func (s *dunningService) RunDunning(companyID uint, asOf time.Time) ([]models.DunningReminder, error) {
+	levels, err := s.GetDunningLevels(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	invoices, err := s.dunningRepo.FindOverdueSalesInvoices(companyID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	// Invoices come ordered by customer
+	var groups [][]models.SalesInvoice
+	for i, invoice := range invoices {
+		if i == 0 || invoice.CustomerID != invoices[i-1].CustomerID {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], invoice)
+	}
+
+	reminders := []models.DunningReminder{}
+	var failures []string
+	for _, group := range groups {
+		reminder, err := s.remindCustomer(companyID, group, levels, asOf)
+		if err != nil {
+			failures = append(failures, group[0].Customer.Name+": "+err.Error())
+			continue
+		}
+		if reminder != nil {
+			reminders = append(reminders, *reminder)
+		}
+	}
+
+	if len(failures) > 0 {
+		return reminders, errors.New("some customers could not be reminded: " + strings.Join(failures, "; "))
+	}
+	return reminders, nil
+}
+
+// ProcessDunning is the scheduled run for today
+func (s *dunningService) ProcessDunning(companyID uint) error {
+	now := time.Now()
+	_, err := s.RunDunning(companyID, time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC))
+	return err
+}
+
+// remindCustomer creates, sends and notifies the reminder for one customer's
+// overdue invoices; nil when no invoice reached a new level
+func (s *dunningService) remindCustomer(companyID uint, invoices []models.SalesInvoice, levels []models.DunningLevel, asOf time.Time) (*models.DunningReminder, error) {
+	customer := invoices[0].Customer
+
+	// Invoices reaching a new level, with that level
+	type escalation struct {
+		invoice *models.SalesInvoice
+		level   int
+	}
+	var level *models.DunningLevel
+	var escalated []escalation
+	var items []models.DunningReminderItem
+	total := 0.0
+	for i := range invoices {
+		invoice := &invoices[i]
+		daysOverdue := int(math.Floor(asOf.Sub(invoice.DueDate).Hours() / 24))
+		reached := DunningLevelFor(levels, daysOverdue)
+		if reached == nil {
+			continue
+		}
+
+		items = append(items, models.DunningReminderItem{
+			InvoiceID:   invoice.ID,
+			Invoice:     *invoice,
+			DueDate:     invoice.DueDate,
+			DaysOverdue: daysOverdue,
+			Level:       reached.Level,
+			Outstanding: invoice.Outstanding(),
+		})
+		total += invoice.Outstanding()
+
+		if reached.Level > invoice.DunningLevel {
+			escalated = append(escalated, escalation{invoice, reached.Level})
+			if level == nil || reached.Level > level.Level {
+				level = reached
+			}
+		}
+	}
+	if level == nil {
+		return nil, nil
+	}
+
+	number, err := s.dunningRepo.GenerateDunningReminderNumber(companyID, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	reminder := &models.DunningReminder{
+		CompanyID:      companyID,
+		ReminderNumber: number,
+		ReminderDate:   asOf,
+		CustomerID:     customer.ID,
+		Level:          level.Level,
+		LevelName:      level.Name,
+		TotalOverdue:   math.Round(total*100) / 100,
+		Subject:        level.Subject,
+		Items:          items,
+	}
+	if reminder.Subject == "" {
+		reminder.Subject = level.Name
+	}
+	reminder.Body = dunningLetter(&customer, level, reminder)
+
+	if err := s.dunningRepo.CreateDunningReminder(reminder); err != nil {
+		return nil, err
+	}
+
+	reminder.Customer = customer
+	if err := s.sendReminder(reminder); err != nil {
+		return nil, err
+	}
+
+	// Invoices move up a level only once the reminder is sent or its
+	// failure recorded, so an interrupted run reminds them again
+	now := time.Now()
+	for _, e := range escalated {
+		e.invoice.DunningLevel = e.level
+		e.invoice.LastDunnedAt = &now
+		if err := s.salesRepo.UpdateSalesInvoice(e.invoice); err != nil {
+			return nil, err
+		}
+	}
+
+	// The account owner follows up; without one, whoever raised the invoice
+	ownerID := escalated[0].invoice.CreatedBy
+	if customer.AccountOwnerID != nil {
+		ownerID = *customer.AccountOwnerID
+	}
+	message := fmt.Sprintf("%s %s sent to %s for %.2f overdue on %d invoice(s)",
+		reminder.LevelName, reminder.ReminderNumber, customer.Name, reminder.TotalOverdue, len(reminder.Items))
+	if reminder.EmailStatus != models.DunningEmailStatusSent {
+		message += " (email " + string(reminder.EmailStatus) + ", please follow up directly)"
+	}
+	err = s.notificationService.CreateNotification(&models.Notification{
+		CompanyID:   companyID,
+		UserID:      ownerID,
+		Type:        models.NotificationTypeDunning,
+		Title:       fmt.Sprintf("Dunning level %d: %s", reminder.Level, customer.Name),
+		Message:     message,
+		RelatedID:   &reminder.ID,
+		RelatedType: "dunning_reminder",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reminder, nil
+}
+
+// dunningLetter is the text of the reminder, addressed to the contact person
+func dunningLetter(customer *models.Customer, level *models.DunningLevel, reminder *models.DunningReminder) string {
+	addressee := customer.ContactPerson
+	if addressee == "" {
+		addressee = customer.Name
+	}
+
+	return fmt.Sprintf("Dear %s,\n\n%s\n\nTotal overdue as of %s: %.2f\n\nIf payment has already been made, please disregard this reminder.\n\nRegards,\nAccounts Receivable",
+		addressee, level.Message, reminder.ReminderDate.Format("2006-01-02"), reminder.TotalOverdue)
+}
+
+// sendReminder emails the reminder with its PDF to the customer and records
+// the outcome; a delivery failure is kept on the reminder, not returned
+func (s *dunningService) sendReminder(reminder *models.DunningReminder) error {
+	reminder.EmailTo = reminder.Customer.Email
+	reminder.EmailError = ""
+
+	if reminder.EmailTo == "" {
+		reminder.EmailStatus = models.DunningEmailStatusSkipped
+		reminder.EmailError = "customer has no email address"
+		return s.dunningRepo.UpdateDunningReminder(reminder)
+	}
+
+	// A letter that cannot be rendered is recorded as a failed send
+	filename := strings.ReplaceAll(reminder.ReminderNumber, "/", "_") + ".pdf"
+	document, err := s.reminderDocument(reminder, filename)
+	if err == nil {
+		lines := []string{reminder.Body, "", "Overdue invoices:"}
+		for _, item := range reminder.Items {
+			lines = append(lines, fmt.Sprintf("- %s due %s, %d days overdue: %.2f",
+				item.Invoice.InvoiceNumber, item.DueDate.Format("2006-01-02"), item.DaysOverdue, item.Outstanding))
+		}
+
+		err = s.emailSender.Send(&EmailMessage{
+			To:      []string{reminder.EmailTo},
+			Subject: reminder.Subject + " - " + reminder.ReminderNumber,
+			Body:    strings.Join(lines, "\n"),
+			Attachments: []EmailAttachment{
+				{Filename: filename, ContentType: "application/pdf", Data: document},
+			},
+		})
+	}
+	if err != nil {
+		reminder.EmailStatus = models.DunningEmailStatusFailed
+		reminder.EmailError = err.Error()
+	} else {
+		now := time.Now()
+		reminder.EmailStatus = models.DunningEmailStatusSent
+		reminder.SentAt = &now
+	}
+
+	return s.dunningRepo.UpdateDunningReminder(reminder)
+}
+
+func (s *dunningService) reminderDocument(reminder *models.DunningReminder, filename string) ([]byte, error) {
+	filepath, err := s.exportService.ExportDunningReminderToPDF(reminder, filename)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath)
+}
+
+// Dunning Reminder methods
+func (s *dunningService) GetDunningReminderByID(id uint) (*models.DunningReminder, error) {
+	return s.dunningRepo.FindDunningReminderByID(id)
+}
+
+func (s *dunningService) GetDunningReminders(companyID uint, customerID uint) ([]models.DunningReminder, error) {
+	return s.dunningRepo.FindDunningReminders(companyID, customerID)
+}
+
+// ResendDunningReminder emails the reminder again, to the customer's current
+// address, e.g. after the address was corrected
+func (s *dunningService) ResendDunningReminder(id uint) (*models.DunningReminder, error) {
+	reminder, err := s.dunningRepo.FindDunningReminderByID(id)
+	if err != nil {
+		return nil, errors.New("dunning reminder not found")
+	}
+
+	if err := s.sendReminder(reminder); err != nil {
+		return nil, err
+	}
+	return reminder, nil
+}
\ No newline at end of file
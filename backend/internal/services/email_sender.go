@@ -0,0 +1,122 @@
+package services
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+type EmailMessage struct {
+	To          []string
+	Subject     string
+	Body        string // plain text
+	Attachments []EmailAttachment
+}
+
+// EmailSender delivers outgoing mail; SMTP in production, a logger when no
+// mail server is configured
+type EmailSender interface {
+	Send(message *EmailMessage) error
+}
+
+type smtpEmailSender struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     string
+}
+
+// NewSMTPEmailSender sends through the given server; without a username no
+// authentication is attempted, which suits a local SMTP stand-in
+func NewSMTPEmailSender(host, port, username, password, from string) EmailSender {
+	return &smtpEmailSender{
+		host:     host,
+		port:     port,
+		username: username,
+		password: password,
+		from:     from,
+	}
+}
+
+func (s *smtpEmailSender) Send(message *EmailMessage) error {
+	if len(message.To) == 0 {
+		return errors.New("email has no recipient")
+	}
+
+	var auth smtp.Auth
+	if s.username != "" {
+		auth = smtp.PlainAuth("", s.username, s.password, s.host)
+	}
+
+	return smtp.SendMail(net.JoinHostPort(s.host, s.port), auth, s.from, message.To, BuildEmail(s.from, message))
+}
+
+type logEmailSender struct{}
+
+// NewLogEmailSender only logs the messages it is given
+func NewLogEmailSender() EmailSender {
+	return &logEmailSender{}
+}
+
+func (s *logEmailSender) Send(message *EmailMessage) error {
+	log.Printf("Email (not sent, SMTP not configured) to %s: %s", strings.Join(message.To, ", "), message.Subject)
+	return nil
+}
+
+// BuildEmail renders the message as MIME: the text body and the attachments
+// base64 encoded in a multipart/mixed message
+func BuildEmail(from string, message *EmailMessage) []byte {
+	var buf bytes.Buffer
+	boundary := fmt.Sprintf("finara-%d", time.Now().UnixNano())
+
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/mixed; boundary=%q\r\n\r\n", boundary)
+
+	fmt.Fprintf(&buf, "--%s\r\n", boundary)
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+	writeBase64Lines(&buf, []byte(message.Body))
+
+	for _, attachment := range message.Attachments {
+		contentType := attachment.ContentType
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		fmt.Fprintf(&buf, "Content-Type: %s; name=%q\r\n", contentType, attachment.Filename)
+		fmt.Fprintf(&buf, "Content-Disposition: attachment; filename=%q\r\n", attachment.Filename)
+		buf.WriteString("Content-Transfer-Encoding: base64\r\n\r\n")
+		writeBase64Lines(&buf, attachment.Data)
+	}
+
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes()
+}
+
+// writeBase64Lines encodes data in lines of 76 characters as MIME requires
+func writeBase64Lines(buf *bytes.Buffer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76] + "\r\n")
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded + "\r\n")
+}
\ No newline at end of file
@@ -0,0 +1,560 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExpenseClaimService interface {
+	// Cash Advances
+	CreateCashAdvance(advance *models.CashAdvance) error
+	GetCashAdvanceByID(id uint) (*models.CashAdvance, error)
+	GetCashAdvances(companyID uint, employeeID uint, status string) ([]models.CashAdvance, error)
+	ApproveCashAdvance(id uint, userID uint) error
+	RejectCashAdvance(id uint) error
+	CancelCashAdvance(id uint) error
+	DisburseCashAdvance(id uint, accountID uint, disbursementDate time.Time, reference string, userID uint) error
+
+	// Expense Claims
+	CreateExpenseClaim(claim *models.ExpenseClaim) error
+	GetExpenseClaimByID(id uint) (*models.ExpenseClaim, error)
+	GetExpenseClaims(companyID uint, employeeID uint, status string) ([]models.ExpenseClaim, error)
+	ApproveExpenseClaim(id uint, userID uint) error
+	RejectExpenseClaim(id uint) error
+	CancelExpenseClaim(id uint) error
+	SettleExpenseClaim(id uint, accountID uint, settlementDate time.Time, reference string, userID uint) error
+
+	// Attachments
+	AddExpenseClaimAttachment(itemID uint, fileName string, contentType string, data []byte, userID uint) (*models.ExpenseClaimAttachment, error)
+	GetExpenseClaimAttachment(id uint) (*models.ExpenseClaimAttachment, error)
+	DeleteExpenseClaimAttachment(id uint) error
+
+	// Reports
+	GetOutstandingAdvances(companyID uint, employeeID uint, asOfDate time.Time) (*models.OutstandingAdvanceReport, error)
+}
+
+const defaultEmployeeAdvanceCode = "1-1700" // Uang Muka Karyawan
+
+type expenseClaimService struct {
+	db               *gorm.DB
+	expenseClaimRepo repository.ExpenseClaimRepository
+	payrollRepo      repository.PayrollRepository
+	accountRepo      repository.AccountRepository
+	journalService   JournalService
+	cashBankService  CashBankService
+}
+
+func NewExpenseClaimService(
+	db *gorm.DB,
+	expenseClaimRepo repository.ExpenseClaimRepository,
+	payrollRepo repository.PayrollRepository,
+	accountRepo repository.AccountRepository,
+	journalService JournalService,
+	cashBankService CashBankService,
+) ExpenseClaimService {
+	return &expenseClaimService{
+		db:               db,
+		expenseClaimRepo: expenseClaimRepo,
+		payrollRepo:      payrollRepo,
+		accountRepo:      accountRepo,
+		journalService:   journalService,
+		cashBankService:  cashBankService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so a payment
+// and the journals it posts commit or roll back together
+func (s *expenseClaimService) withTx(tx *gorm.DB) *expenseClaimService {
+	return &expenseClaimService{
+		db:               tx,
+		expenseClaimRepo: repository.NewExpenseClaimRepository(tx),
+		payrollRepo:      repository.NewPayrollRepository(tx),
+		accountRepo:      repository.NewAccountRepository(tx),
+		journalService:   journalServiceWith(tx),
+		cashBankService:  cashBankServiceWith(tx),
+	}
+}
+
+// CalculateExpenseClaimTotals adds up the claim items and sets off the
+// advance it settles. A positive balance is owed to the employee, a
+// negative one is returned by the employee.
+func CalculateExpenseClaimTotals(claim *models.ExpenseClaim, advanceAmount float64) error {
+	if len(claim.Items) == 0 {
+		return errors.New("expense claim must have at least one item")
+	}
+
+	claim.TotalAmount = 0
+	for i := range claim.Items {
+		item := &claim.Items[i]
+		item.Amount = math.Round(item.Amount*100) / 100
+		if item.Amount <= 0 {
+			return errors.New("item amount must be greater than zero")
+		}
+		claim.TotalAmount += item.Amount
+	}
+	claim.TotalAmount = math.Round(claim.TotalAmount*100) / 100
+	claim.AdvanceAmount = math.Round(advanceAmount*100) / 100
+	claim.Balance = math.Round((claim.TotalAmount-claim.AdvanceAmount)*100) / 100
+	return nil
+}
+
+// findEmployee checks the employee belongs to the company
+func (s *expenseClaimService) findEmployee(companyID uint, employeeID uint) (*models.Employee, error) {
+	employee, err := s.payrollRepo.FindEmployeeByID(employeeID)
+	if err != nil || employee.CompanyID != companyID {
+		return nil, errors.New("employee not found")
+	}
+	return employee, nil
+}
+
+func (s *expenseClaimService) findAdvanceAccount(companyID uint) (*models.Account, error) {
+	account, err := s.accountRepo.FindByCode(companyID, defaultEmployeeAdvanceCode)
+	if err != nil {
+		return nil, errors.New("employee advances account " + defaultEmployeeAdvanceCode + " not found")
+	}
+	return account, nil
+}
+
+// Cash Advance methods
+func (s *expenseClaimService) CreateCashAdvance(advance *models.CashAdvance) error {
+	employee, err := s.findEmployee(advance.CompanyID, advance.EmployeeID)
+	if err != nil {
+		return err
+	}
+	if !employee.IsActive {
+		return errors.New("employee is inactive")
+	}
+
+	advance.Amount = math.Round(advance.Amount*100) / 100
+	if advance.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+
+	advanceNumber, err := s.expenseClaimRepo.GenerateCashAdvanceNumber(advance.CompanyID, advance.RequestDate)
+	if err != nil {
+		return err
+	}
+	advance.AdvanceNumber = advanceNumber
+	advance.Status = models.CashAdvanceStatusDraft
+
+	return s.expenseClaimRepo.CreateCashAdvance(advance)
+}
+
+func (s *expenseClaimService) GetCashAdvanceByID(id uint) (*models.CashAdvance, error) {
+	return s.expenseClaimRepo.FindCashAdvanceByID(id)
+}
+
+func (s *expenseClaimService) GetCashAdvances(companyID uint, employeeID uint, status string) ([]models.CashAdvance, error) {
+	return s.expenseClaimRepo.FindCashAdvances(companyID, employeeID, status)
+}
+
+func (s *expenseClaimService) ApproveCashAdvance(id uint, userID uint) error {
+	advance, err := s.expenseClaimRepo.FindCashAdvanceByID(id)
+	if err != nil {
+		return errors.New("cash advance not found")
+	}
+
+	if advance.Status != models.CashAdvanceStatusDraft {
+		return errors.New("only draft cash advances can be approved")
+	}
+
+	now := time.Now()
+	advance.Status = models.CashAdvanceStatusApproved
+	advance.ApprovedAt = &now
+	advance.ApprovedBy = &userID
+	return s.expenseClaimRepo.UpdateCashAdvance(advance)
+}
+
+func (s *expenseClaimService) RejectCashAdvance(id uint) error {
+	advance, err := s.expenseClaimRepo.FindCashAdvanceByID(id)
+	if err != nil {
+		return errors.New("cash advance not found")
+	}
+
+	if advance.Status != models.CashAdvanceStatusDraft {
+		return errors.New("only draft cash advances can be rejected")
+	}
+
+	advance.Status = models.CashAdvanceStatusRejected
+	return s.expenseClaimRepo.UpdateCashAdvance(advance)
+}
+
+func (s *expenseClaimService) CancelCashAdvance(id uint) error {
+	advance, err := s.expenseClaimRepo.FindCashAdvanceByID(id)
+	if err != nil {
+		return errors.New("cash advance not found")
+	}
+
+	if advance.Status != models.CashAdvanceStatusDraft && advance.Status != models.CashAdvanceStatusApproved {
+		return errors.New("only draft or approved cash advances can be cancelled")
+	}
+
+	advance.Status = models.CashAdvanceStatusCancelled
+	return s.expenseClaimRepo.UpdateCashAdvance(advance)
+}
+
+// DisburseCashAdvance pays an approved advance out of a cash or bank account
+// into the employee advances account
+func (s *expenseClaimService) DisburseCashAdvance(id uint, accountID uint, disbursementDate time.Time, reference string, userID uint) error {
+	advance, err := s.expenseClaimRepo.FindCashAdvanceByID(id)
+	if err != nil {
+		return errors.New("cash advance not found")
+	}
+
+	if advance.Status != models.CashAdvanceStatusApproved {
+		return errors.New("only approved cash advances can be disbursed")
+	}
+
+	if _, err := findCashBankAccount(s.accountRepo, advance.CompanyID, accountID); err != nil {
+		return err
+	}
+	advanceAccount, err := s.findAdvanceAccount(advance.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	// The payment, its posted journal and the disbursed status are saved
+	// together, so a failure leaves the advance approved to disburse again
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		transaction := &models.CashBankTransaction{
+			CompanyID:       advance.CompanyID,
+			AccountID:       accountID,
+			TransactionDate: disbursementDate,
+			Category:        models.CategoryCashAdvance,
+			Amount:          advance.Amount,
+			Description:     "Cash advance " + advance.AdvanceNumber + " to " + advance.Employee.Name,
+			Reference:       reference,
+			CreatedBy:       userID,
+		}
+		if err := txService.cashBankService.CreateCashOutWithJournal(transaction, advanceAccount.ID); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(*transaction.JournalID, userID); err != nil {
+			return err
+		}
+
+		advance.Status = models.CashAdvanceStatusDisbursed
+		advance.DisbursementDate = &disbursementDate
+		advance.CashBankTransactionID = &transaction.ID
+		advance.JournalID = transaction.JournalID
+		return txService.expenseClaimRepo.UpdateCashAdvance(advance)
+	})
+}
+
+// Expense Claim methods
+
+// CreateExpenseClaim records a draft claim. A claim against an advance
+// settles all of it, so the advance must be disbursed to the same employee
+// and not already on another open claim.
+func (s *expenseClaimService) CreateExpenseClaim(claim *models.ExpenseClaim) error {
+	if _, err := s.findEmployee(claim.CompanyID, claim.EmployeeID); err != nil {
+		return err
+	}
+
+	var advanceAmount float64
+	if claim.AdvanceID != nil {
+		advance, err := s.expenseClaimRepo.FindCashAdvanceByID(*claim.AdvanceID)
+		if err != nil || advance.CompanyID != claim.CompanyID {
+			return errors.New("cash advance not found")
+		}
+		if advance.EmployeeID != claim.EmployeeID {
+			return errors.New("cash advance belongs to another employee")
+		}
+		if advance.Status != models.CashAdvanceStatusDisbursed {
+			return errors.New("only disbursed cash advances can be settled")
+		}
+		open, err := s.expenseClaimRepo.FindOpenClaimByAdvance(advance.ID)
+		if err == nil {
+			return errors.New("cash advance is already on claim " + open.ClaimNumber)
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		advanceAmount = advance.Amount
+	}
+
+	for _, item := range claim.Items {
+		account, err := s.accountRepo.FindByID(item.AccountID)
+		if err != nil || account.CompanyID != claim.CompanyID {
+			return errors.New("expense account not found")
+		}
+		if account.IsHeader {
+			return errors.New("expense account cannot be a header account")
+		}
+	}
+	if err := CalculateExpenseClaimTotals(claim, advanceAmount); err != nil {
+		return err
+	}
+
+	claimNumber, err := s.expenseClaimRepo.GenerateExpenseClaimNumber(claim.CompanyID, claim.ClaimDate)
+	if err != nil {
+		return err
+	}
+	claim.ClaimNumber = claimNumber
+	claim.Status = models.ExpenseClaimStatusDraft
+
+	return s.expenseClaimRepo.CreateExpenseClaim(claim)
+}
+
+func (s *expenseClaimService) GetExpenseClaimByID(id uint) (*models.ExpenseClaim, error) {
+	return s.expenseClaimRepo.FindExpenseClaimByID(id)
+}
+
+func (s *expenseClaimService) GetExpenseClaims(companyID uint, employeeID uint, status string) ([]models.ExpenseClaim, error) {
+	return s.expenseClaimRepo.FindExpenseClaims(companyID, employeeID, status)
+}
+
+func (s *expenseClaimService) ApproveExpenseClaim(id uint, userID uint) error {
+	claim, err := s.expenseClaimRepo.FindExpenseClaimByID(id)
+	if err != nil {
+		return errors.New("expense claim not found")
+	}
+
+	if claim.Status != models.ExpenseClaimStatusDraft {
+		return errors.New("only draft expense claims can be approved")
+	}
+
+	now := time.Now()
+	claim.Status = models.ExpenseClaimStatusApproved
+	claim.ApprovedAt = &now
+	claim.ApprovedBy = &userID
+	return s.expenseClaimRepo.UpdateExpenseClaim(claim)
+}
+
+func (s *expenseClaimService) RejectExpenseClaim(id uint) error {
+	claim, err := s.expenseClaimRepo.FindExpenseClaimByID(id)
+	if err != nil {
+		return errors.New("expense claim not found")
+	}
+
+	if claim.Status != models.ExpenseClaimStatusDraft {
+		return errors.New("only draft expense claims can be rejected")
+	}
+
+	claim.Status = models.ExpenseClaimStatusRejected
+	return s.expenseClaimRepo.UpdateExpenseClaim(claim)
+}
+
+func (s *expenseClaimService) CancelExpenseClaim(id uint) error {
+	claim, err := s.expenseClaimRepo.FindExpenseClaimByID(id)
+	if err != nil {
+		return errors.New("expense claim not found")
+	}
+
+	if claim.Status != models.ExpenseClaimStatusDraft && claim.Status != models.ExpenseClaimStatusApproved {
+		return errors.New("only draft or approved expense claims can be cancelled")
+	}
+
+	claim.Status = models.ExpenseClaimStatusCancelled
+	return s.expenseClaimRepo.UpdateExpenseClaim(claim)
+}
+
+// SettleExpenseClaim expenses the items against the employee advances
+// account, then clears what is left on it through the cash or bank account:
+// the shortfall is reimbursed to the employee and the unspent advance is
+// paid back in. The advance the claim settles is closed. Everything is saved
+// in one transaction, so a settlement that fails leaves the claim approved.
+func (s *expenseClaimService) SettleExpenseClaim(id uint, accountID uint, settlementDate time.Time, reference string, userID uint) error {
+	claim, err := s.expenseClaimRepo.FindExpenseClaimByID(id)
+	if err != nil {
+		return errors.New("expense claim not found")
+	}
+
+	if claim.Status != models.ExpenseClaimStatusApproved {
+		return errors.New("only approved expense claims can be settled")
+	}
+
+	if claim.Balance != 0 {
+		if _, err := findCashBankAccount(s.accountRepo, claim.CompanyID, accountID); err != nil {
+			return err
+		}
+	}
+	advanceAccount, err := s.findAdvanceAccount(claim.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		description := "Expense claim " + claim.ClaimNumber + " - " + claim.Employee.Name
+		var lines []netJournalLine
+		for _, item := range claim.Items {
+			lines = append(lines, netJournalLine{item.AccountID, item.Amount})
+		}
+		lines = append(lines, netJournalLine{advanceAccount.ID, -claim.TotalAmount})
+		journalID, err := postNetJournal(txService.journalService, claim.CompanyID, settlementDate, description, lines, userID)
+		if err != nil {
+			return err
+		}
+		claim.JournalID = journalID
+
+		if claim.Balance != 0 {
+			transaction := &models.CashBankTransaction{
+				CompanyID:       claim.CompanyID,
+				AccountID:       accountID,
+				TransactionDate: settlementDate,
+				Reference:       reference,
+				CreatedBy:       userID,
+			}
+			if claim.Balance > 0 {
+				transaction.Category = models.CategoryExpenseClaim
+				transaction.Amount = claim.Balance
+				transaction.Description = "Reimbursement " + claim.ClaimNumber + " to " + claim.Employee.Name
+				err = txService.cashBankService.CreateCashOutWithJournal(transaction, advanceAccount.ID)
+			} else {
+				transaction.Category = models.CategoryCashAdvance
+				transaction.Amount = -claim.Balance
+				transaction.Description = "Unspent advance returned " + claim.ClaimNumber + " by " + claim.Employee.Name
+				err = txService.cashBankService.CreateCashInWithJournal(transaction, advanceAccount.ID)
+			}
+			if err != nil {
+				return err
+			}
+			if err := txService.journalService.PostJournal(*transaction.JournalID, userID); err != nil {
+				return err
+			}
+			claim.CashBankTransactionID = &transaction.ID
+			claim.SettlementJournalID = transaction.JournalID
+		}
+
+		claim.Status = models.ExpenseClaimStatusSettled
+		claim.SettlementDate = &settlementDate
+		if err := txService.expenseClaimRepo.UpdateExpenseClaim(claim); err != nil {
+			return err
+		}
+
+		if claim.Advance != nil {
+			claim.Advance.Status = models.CashAdvanceStatusSettled
+			claim.Advance.SettledDate = &settlementDate
+			return txService.expenseClaimRepo.UpdateCashAdvance(claim.Advance)
+		}
+		return nil
+	})
+}
+
+// Attachment methods
+
+// AddExpenseClaimAttachment stores a receipt for a claim item under
+// attachments/claims until the claim is settled
+func (s *expenseClaimService) AddExpenseClaimAttachment(itemID uint, fileName string, contentType string, data []byte, userID uint) (*models.ExpenseClaimAttachment, error) {
+	item, err := s.expenseClaimRepo.FindExpenseClaimItemByID(itemID)
+	if err != nil {
+		return nil, errors.New("expense claim item not found")
+	}
+	claim, err := s.expenseClaimRepo.FindExpenseClaimByID(item.ClaimID)
+	if err != nil {
+		return nil, errors.New("expense claim not found")
+	}
+	if claim.Status != models.ExpenseClaimStatusDraft && claim.Status != models.ExpenseClaimStatusApproved {
+		return nil, errors.New("attachments can only be added to draft or approved expense claims")
+	}
+
+	fileName = filepath.Base(fileName)
+	dir := fmt.Sprintf("attachments/claims/%d", claim.ID)
+	os.MkdirAll(dir, 0755)
+	path := fmt.Sprintf("%s/%d_%s", dir, time.Now().UnixNano(), fileName)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return nil, err
+	}
+
+	attachment := &models.ExpenseClaimAttachment{
+		ItemID:      item.ID,
+		FileName:    fileName,
+		ContentType: contentType,
+		FileSize:    int64(len(data)),
+		FilePath:    path,
+		UploadedBy:  userID,
+	}
+	if err := s.expenseClaimRepo.CreateExpenseClaimAttachment(attachment); err != nil {
+		os.Remove(path)
+		return nil, err
+	}
+	return attachment, nil
+}
+
+func (s *expenseClaimService) GetExpenseClaimAttachment(id uint) (*models.ExpenseClaimAttachment, error) {
+	return s.expenseClaimRepo.FindExpenseClaimAttachmentByID(id)
+}
+
+func (s *expenseClaimService) DeleteExpenseClaimAttachment(id uint) error {
+	attachment, err := s.expenseClaimRepo.FindExpenseClaimAttachmentByID(id)
+	if err != nil {
+		return errors.New("attachment not found")
+	}
+	item, err := s.expenseClaimRepo.FindExpenseClaimItemByID(attachment.ItemID)
+	if err != nil {
+		return errors.New("expense claim item not found")
+	}
+	claim, err := s.expenseClaimRepo.FindExpenseClaimByID(item.ClaimID)
+	if err != nil {
+		return errors.New("expense claim not found")
+	}
+	if claim.Status != models.ExpenseClaimStatusDraft {
+		return errors.New("attachments can only be removed from draft expense claims")
+	}
+
+	if err := s.expenseClaimRepo.DeleteExpenseClaimAttachment(id); err != nil {
+		return err
+	}
+	os.Remove(attachment.FilePath)
+	return nil
+}
+
+// Report methods
+
+// GetOutstandingAdvances lists the advances each employee had not yet
+// settled on the date, with how long they have been out
+func (s *expenseClaimService) GetOutstandingAdvances(companyID uint, employeeID uint, asOfDate time.Time) (*models.OutstandingAdvanceReport, error) {
+	advances, err := s.expenseClaimRepo.FindOutstandingAdvances(companyID, employeeID, asOfDate)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.OutstandingAdvanceReport{AsOfDate: asOfDate}
+	groups := make(map[uint]int)
+	for _, advance := range advances {
+		index, ok := groups[advance.EmployeeID]
+		if !ok {
+			index = len(report.Employees)
+			groups[advance.EmployeeID] = index
+			report.Employees = append(report.Employees, models.OutstandingAdvanceGroup{
+				EmployeeID:   advance.EmployeeID,
+				EmployeeCode: advance.Employee.Code,
+				EmployeeName: advance.Employee.Name,
+				Department:   advance.Employee.Department,
+			})
+		}
+
+		line := models.OutstandingAdvance{
+			AdvanceID:        advance.ID,
+			AdvanceNumber:    advance.AdvanceNumber,
+			DisbursementDate: *advance.DisbursementDate,
+			Purpose:          advance.Purpose,
+			Amount:           advance.Amount,
+			DaysOutstanding:  int(asOfDate.Sub(*advance.DisbursementDate).Hours() / 24),
+		}
+		if advance.Status == models.CashAdvanceStatusDisbursed {
+			if claim, err := s.expenseClaimRepo.FindOpenClaimByAdvance(advance.ID); err == nil {
+				line.ClaimNumber = claim.ClaimNumber
+			}
+		}
+
+		group := &report.Employees[index]
+		group.Advances = append(group.Advances, line)
+		group.Total = math.Round((group.Total+advance.Amount)*100) / 100
+		report.Total = math.Round((report.Total+advance.Amount)*100) / 100
+	}
+
+	return report, nil
+}
\ No newline at end of file
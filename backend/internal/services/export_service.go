@@ -6,6 +6,7 @@ import (
 	"finara-backend/internal/models"
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/xuri/excelize/v2"
 )
@@ -19,6 +20,20 @@ type ExportService interface {
 	ExportIncomeStatementToExcel(incomeStatement *models.IncomeStatementResponse, filename string) (string, error)
 	ExportBalanceSheetToCSV(balanceSheet *models.BalanceSheetResponse, filename string) (string, error)
 	ExportBalanceSheetToExcel(balanceSheet *models.BalanceSheetResponse, filename string) (string, error)
+	ExportStockCardToCSV(card *models.StockCard, filename string) (string, error)
+	ExportStockCardToExcel(card *models.StockCard, filename string) (string, error)
+	ExportInventoryValuationToCSV(valuation *models.InventoryValuation, filename string) (string, error)
+	ExportInventoryValuationToExcel(valuation *models.InventoryValuation, filename string) (string, error)
+	ExportInventoryAgingToCSV(aging *models.InventoryAgingReport, filename string) (string, error)
+	ExportInventoryAgingToExcel(aging *models.InventoryAgingReport, filename string) (string, error)
+	ExportCountSheetToCSV(opname *models.StockOpname, showSystem bool, filename string) (string, error)
+	ExportCountSheetToExcel(opname *models.StockOpname, showSystem bool, filename string) (string, error)
+	ExportAgingToCSV(aging *models.AgingReport, showDocuments bool, filename string) (string, error)
+	ExportAgingToExcel(aging *models.AgingReport, showDocuments bool, filename string) (string, error)
+	ExportCustomerStatementToCSV(statement *models.CustomerStatement, filename string) (string, error)
+	ExportCustomerStatementToExcel(statement *models.CustomerStatement, filename string) (string, error)
+	ExportCustomerStatementToPDF(statement *models.CustomerStatement, filename string) (string, error)
+	ExportDunningReminderToPDF(reminder *models.DunningReminder, filename string) (string, error)
 }
 
 type exportService struct{}
@@ -299,4 +314,411 @@ func (s *exportService) ExportBalanceSheetToCSV(balanceSheet *models.BalanceShee
 
 func (s *exportService) ExportBalanceSheetToExcel(balanceSheet *models.BalanceSheetResponse, filename string) (string, error) {
 	return "", errors.New("not implemented yet")
+}
+
+// Inventory Report Exports
+func (s *exportService) ExportStockCardToCSV(card *models.StockCard, filename string) (string, error) {
+	return writeTableCSV(filename, stockCardRows(card))
+}
+
+func (s *exportService) ExportStockCardToExcel(card *models.StockCard, filename string) (string, error) {
+	return writeTableExcel(filename, "Stock Card", stockCardRows(card))
+}
+
+func (s *exportService) ExportInventoryValuationToCSV(valuation *models.InventoryValuation, filename string) (string, error) {
+	return writeTableCSV(filename, inventoryValuationRows(valuation))
+}
+
+func (s *exportService) ExportInventoryValuationToExcel(valuation *models.InventoryValuation, filename string) (string, error) {
+	return writeTableExcel(filename, "Inventory Valuation", inventoryValuationRows(valuation))
+}
+
+func (s *exportService) ExportInventoryAgingToCSV(aging *models.InventoryAgingReport, filename string) (string, error) {
+	return writeTableCSV(filename, inventoryAgingRows(aging))
+}
+
+func (s *exportService) ExportInventoryAgingToExcel(aging *models.InventoryAgingReport, filename string) (string, error) {
+	return writeTableExcel(filename, "Inventory Aging", inventoryAgingRows(aging))
+}
+
+func stockCardRows(card *models.StockCard) [][]interface{} {
+	rows := [][]interface{}{
+		{"STOCK CARD"},
+		{fmt.Sprintf("Product: %s - %s (%s)", card.ProductCode, card.ProductName, card.Unit)},
+		{fmt.Sprintf("Period: %s to %s", card.StartDate, card.EndDate)},
+		{},
+		{"Date", "Movement Number", "Type", "Reference", "Party", "Qty In", "Qty Out", "Value In", "Value Out", "Balance Qty", "Balance Value"},
+		{card.StartDate, "OPENING", "", "", "", "", "", "", "", card.OpeningQuantity, card.OpeningValue},
+	}
+	for _, entry := range card.Entries {
+		rows = append(rows, []interface{}{
+			entry.Date, entry.MovementNumber, entry.Type, entry.Reference, entry.Party,
+			entry.QuantityIn, entry.QuantityOut, entry.ValueIn, entry.ValueOut,
+			entry.BalanceQuantity, entry.BalanceValue,
+		})
+	}
+	rows = append(rows, []interface{}{
+		card.EndDate, "TOTAL", "", "", "",
+		card.TotalIn, card.TotalOut, card.TotalValueIn, card.TotalValueOut,
+		card.ClosingQuantity, card.ClosingValue,
+	})
+	return rows
+}
+
+func inventoryValuationRows(valuation *models.InventoryValuation) [][]interface{} {
+	rows := [][]interface{}{
+		{"INVENTORY VALUATION"},
+		{fmt.Sprintf("As of: %s", valuation.AsOfDate)},
+		{},
+		{"Product Code", "Product Name", "Category", "Unit", "Cost Method", "Quantity", "Average Cost", "Value"},
+	}
+	for _, line := range valuation.Lines {
+		rows = append(rows, []interface{}{
+			line.ProductCode, line.ProductName, line.Category, line.Unit, line.CostMethod,
+			line.Quantity, line.AverageCost, line.Value,
+		})
+	}
+	rows = append(rows, []interface{}{"TOTAL", "", "", "", "", "", "", valuation.TotalValue})
+	return rows
+}
+
+func inventoryAgingRows(aging *models.InventoryAgingReport) [][]interface{} {
+	rows := [][]interface{}{
+		{"INVENTORY AGING"},
+		{fmt.Sprintf("As of: %s (slow moving > %d days, dead stock > %d days)", aging.AsOfDate, aging.SlowMovingDays, aging.DeadStockDays)},
+		{},
+		{"Product Code", "Product Name", "Quantity", "Value", "0-30", "31-60", "61-90", "91-180", "> 180", "Oldest Receipt", "Last Issue", "Days Idle", "Status"},
+	}
+	for _, line := range aging.Lines {
+		rows = append(rows, []interface{}{
+			line.ProductCode, line.ProductName, line.Quantity, line.Value,
+			line.Days0To30, line.Days31To60, line.Days61To90, line.Days91To180, line.DaysOver180,
+			line.OldestReceiptDate, line.LastIssueDate, line.DaysSinceLastIssue, line.Status,
+		})
+	}
+	rows = append(rows,
+		[]interface{}{},
+		[]interface{}{"TOTAL", "", "", aging.TotalValue},
+		[]interface{}{"SLOW MOVING", "", "", aging.SlowMovingValue},
+		[]interface{}{"DEAD STOCK", "", "", aging.DeadStockValue},
+	)
+	return rows
+}
+
+// Stock Opname count sheet: blank count column per product, system quantity
+// hidden unless showSystem so counters are not biased
+func (s *exportService) ExportCountSheetToCSV(opname *models.StockOpname, showSystem bool, filename string) (string, error) {
+	return writeTableCSV(filename, countSheetRows(opname, showSystem))
+}
+
+func (s *exportService) ExportCountSheetToExcel(opname *models.StockOpname, showSystem bool, filename string) (string, error) {
+	filepath := fmt.Sprintf("exports/%s", filename)
+
+	os.MkdirAll("exports", 0755)
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	sheetName := "Count Sheet"
+	index, _ := f.NewSheet(sheetName)
+
+	rows := countSheetRows(opname, showSystem)
+	for r, row := range rows {
+		for c, cell := range row {
+			name, err := excelize.CoordinatesToCellName(c+1, r+1)
+			if err != nil {
+				return "", err
+			}
+			f.SetCellValue(sheetName, name, cell)
+		}
+	}
+
+	// Grid and print setup so the sheet can go straight to the counters
+	headerRow := countSheetHeaderRow
+	lastCol, _ := excelize.ColumnNumberToName(len(rows[headerRow-1]))
+	border := []excelize.Border{
+		{Type: "left", Color: "000000", Style: 1},
+		{Type: "top", Color: "000000", Style: 1},
+		{Type: "right", Color: "000000", Style: 1},
+		{Type: "bottom", Color: "000000", Style: 1},
+	}
+	headerStyle, _ := f.NewStyle(&excelize.Style{Border: border, Font: &excelize.Font{Bold: true}})
+	gridStyle, _ := f.NewStyle(&excelize.Style{Border: border})
+	f.SetCellStyle(sheetName, fmt.Sprintf("A%d", headerRow), fmt.Sprintf("%s%d", lastCol, headerRow), headerStyle)
+	if len(rows) > headerRow {
+		f.SetCellStyle(sheetName, fmt.Sprintf("A%d", headerRow+1), fmt.Sprintf("%s%d", lastCol, len(rows)), gridStyle)
+	}
+	f.SetColWidth(sheetName, "A", "A", 5)
+	f.SetColWidth(sheetName, "B", "B", 15)
+	f.SetColWidth(sheetName, "C", "C", 35)
+	f.SetColWidth(sheetName, "D", lastCol, 14)
+
+	orientation := "landscape"
+	fitToPage := true
+	fitToWidth, fitToHeight := 1, 0
+	f.SetSheetProps(sheetName, &excelize.SheetPropsOptions{FitToPage: &fitToPage})
+	f.SetPageLayout(sheetName, &excelize.PageLayoutOptions{Orientation: &orientation, FitToWidth: &fitToWidth, FitToHeight: &fitToHeight})
+	f.SetHeaderFooter(sheetName, &excelize.HeaderFooterOptions{
+		OddFooter: "&L" + opname.OpnameNumber + "&RPage &P of &N",
+	})
+	f.SetDefinedName(&excelize.DefinedName{
+		Name:     "_xlnm.Print_Titles",
+		RefersTo: fmt.Sprintf("'%s'!$%d:$%d", sheetName, headerRow, headerRow),
+		Scope:    sheetName,
+	})
+
+	f.SetActiveSheet(index)
+
+	if err := f.SaveAs(filepath); err != nil {
+		return "", err
+	}
+
+	return filepath, nil
+}
+
+// countSheetHeaderRow is the row of the column headers in countSheetRows
+const countSheetHeaderRow = 6
+
+func countSheetRows(opname *models.StockOpname, showSystem bool) [][]interface{} {
+	rows := [][]interface{}{
+		{"STOCK OPNAME COUNT SHEET"},
+		{fmt.Sprintf("Number: %s", opname.OpnameNumber)},
+		{fmt.Sprintf("Warehouse: %s - %s", opname.Warehouse.Code, opname.Warehouse.Name)},
+		{fmt.Sprintf("Date: %s", opname.OpnameDate.Format("2006-01-02"))},
+		{},
+	}
+
+	header := []interface{}{"No", "Product Code", "Product Name", "Unit", "Round"}
+	if showSystem {
+		header = append(header, "System Qty")
+	}
+	header = append(header, "Lot Number", "Counted Qty", "Counted By", "Notes")
+	rows = append(rows, header)
+
+	for i, item := range opname.Items {
+		row := []interface{}{i + 1, item.Product.Code, item.Product.Name, item.Product.Unit, item.CountRound}
+		if showSystem {
+			row = append(row, item.SystemQuantity)
+		}
+		row = append(row, "", "", "", "")
+		rows = append(rows, row)
+	}
+
+	rows = append(rows,
+		[]interface{}{},
+		[]interface{}{"", "Counted by:", "", "Checked by:"},
+	)
+	return rows
+}
+
+// writeTableCSV writes rows of cells to exports/filename, amounts with two decimals
+func writeTableCSV(filename string, rows [][]interface{}) (string, error) {
+	filepath := fmt.Sprintf("exports/%s", filename)
+
+	os.MkdirAll("exports", 0755)
+
+	file, err := os.Create(filepath)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	writer := csv.NewWriter(file)
+	defer writer.Flush()
+
+	for _, row := range rows {
+		record := make([]string, len(row))
+		for i, cell := range row {
+			if amount, ok := cell.(float64); ok {
+				record[i] = fmt.Sprintf("%.2f", amount)
+			} else {
+				record[i] = fmt.Sprint(cell)
+			}
+		}
+		writer.Write(record)
+	}
+
+	return filepath, nil
+}
+
+// writeTableExcel writes rows of cells to a single sheet in exports/filename
+func writeTableExcel(filename string, sheetName string, rows [][]interface{}) (string, error) {
+	filepath := fmt.Sprintf("exports/%s", filename)
+
+	os.MkdirAll("exports", 0755)
+
+	f := excelize.NewFile()
+	defer f.Close()
+
+	index, _ := f.NewSheet(sheetName)
+
+	for r, row := range rows {
+		for c, cell := range row {
+			name, err := excelize.CoordinatesToCellName(c+1, r+1)
+			if err != nil {
+				return "", err
+			}
+			f.SetCellValue(sheetName, name, cell)
+		}
+	}
+
+	f.SetActiveSheet(index)
+
+	if err := f.SaveAs(filepath); err != nil {
+		return "", err
+	}
+
+	return filepath, nil
+}
+
+// AR / AP Aging: one row per customer or vendor, optionally followed by
+// its open documents, and the reconciliation to the control account
+func (s *exportService) ExportAgingToCSV(aging *models.AgingReport, showDocuments bool, filename string) (string, error) {
+	return writeTableCSV(filename, agingRows(aging, showDocuments))
+}
+
+func (s *exportService) ExportAgingToExcel(aging *models.AgingReport, showDocuments bool, filename string) (string, error) {
+	sheetName := "AR Aging"
+	if aging.Type == "payable" {
+		sheetName = "AP Aging"
+	}
+	return writeTableExcel(filename, sheetName, agingRows(aging, showDocuments))
+}
+
+func agingRows(aging *models.AgingReport, showDocuments bool) [][]interface{} {
+	title, party := "ACCOUNTS RECEIVABLE AGING", "Customer"
+	if aging.Type == "payable" {
+		title, party = "ACCOUNTS PAYABLE AGING", "Vendor"
+	}
+
+	header := []interface{}{party + " Code", party + " Name", "Document", "Date", "Due Date", "Days Overdue"}
+	for _, bucket := range aging.Buckets {
+		header = append(header, bucket.Label)
+	}
+	header = append(header, "Total")
+
+	rows := [][]interface{}{
+		{title},
+		{fmt.Sprintf("As of: %s", aging.AsOfDate)},
+		{},
+		header,
+	}
+	for _, line := range aging.Lines {
+		row := []interface{}{line.PartyCode, line.PartyName, "", "", "", ""}
+		for _, amount := range line.Amounts {
+			row = append(row, amount)
+		}
+		rows = append(rows, append(row, line.Total))
+
+		if !showDocuments {
+			continue
+		}
+		for _, document := range line.Documents {
+			row := []interface{}{"", "", document.DocumentNumber, document.DocumentDate, document.DueDate, document.DaysOverdue}
+			for i := range aging.Buckets {
+				if i == document.Bucket {
+					row = append(row, document.Outstanding)
+				} else {
+					row = append(row, "")
+				}
+			}
+			rows = append(rows, append(row, document.Outstanding))
+		}
+	}
+
+	total := []interface{}{"TOTAL", "", "", "", "", ""}
+	for _, amount := range aging.BucketTotals {
+		total = append(total, amount)
+	}
+	rows = append(rows, append(total, aging.Total),
+		[]interface{}{},
+		[]interface{}{"CONTROL ACCOUNT", aging.ControlAccountCode + " " + aging.ControlAccountName},
+		[]interface{}{"GL BALANCE", aging.GLBalance},
+		[]interface{}{"DIFFERENCE", aging.Difference},
+	)
+	return rows
+}
+
+// Customer Statement: opening balance, invoices and payments with the running
+// balance, and the closing balance with its overdue part
+func (s *exportService) ExportCustomerStatementToCSV(statement *models.CustomerStatement, filename string) (string, error) {
+	return writeTableCSV(filename, customerStatementRows(statement))
+}
+
+func (s *exportService) ExportCustomerStatementToExcel(statement *models.CustomerStatement, filename string) (string, error) {
+	return writeTableExcel(filename, "Statement", customerStatementRows(statement))
+}
+
+func (s *exportService) ExportCustomerStatementToPDF(statement *models.CustomerStatement, filename string) (string, error) {
+	return writeTablePDF(filename, customerStatementRows(statement))
+}
+
+func customerStatementRows(statement *models.CustomerStatement) [][]interface{} {
+	rows := [][]interface{}{
+		{"STATEMENT OF ACCOUNT"},
+		{fmt.Sprintf("Customer: %s - %s", statement.CustomerCode, statement.CustomerName)},
+	}
+	if statement.Address != "" {
+		rows = append(rows, []interface{}{"Address: " + statement.Address})
+	}
+	if statement.NPWP != "" {
+		rows = append(rows, []interface{}{"NPWP: " + statement.NPWP})
+	}
+	rows = append(rows,
+		[]interface{}{fmt.Sprintf("Period: %s to %s", statement.StartDate, statement.EndDate)},
+		[]interface{}{},
+		[]interface{}{"Date", "Type", "Document", "Reference", "Due Date", "Debit", "Credit", "Balance"},
+		[]interface{}{statement.StartDate, "", "Opening Balance", "", "", "", "", statement.OpeningBalance},
+	)
+	for _, entry := range statement.Entries {
+		rows = append(rows, []interface{}{
+			entry.Date,
+			entry.Type,
+			entry.DocumentNumber,
+			entry.Reference,
+			entry.DueDate,
+			entry.Debit,
+			entry.Credit,
+			entry.Balance,
+		})
+	}
+	rows = append(rows,
+		[]interface{}{"TOTAL", "", "", "", "", statement.TotalDebit, statement.TotalCredit, statement.ClosingBalance},
+		[]interface{}{},
+		[]interface{}{"CLOSING BALANCE", statement.ClosingBalance},
+		[]interface{}{"OVERDUE", statement.OverdueAmount},
+	)
+	return rows
+}
+
+// Dunning Reminder document: the letter and the overdue invoices it covers
+func (s *exportService) ExportDunningReminderToPDF(reminder *models.DunningReminder, filename string) (string, error) {
+	return writeTablePDF(filename, dunningReminderRows(reminder))
+}
+
+func dunningReminderRows(reminder *models.DunningReminder) [][]interface{} {
+	rows := [][]interface{}{
+		{strings.ToUpper(reminder.LevelName)},
+		{"Number: " + reminder.ReminderNumber},
+		{"Date: " + reminder.ReminderDate.Format("2006-01-02")},
+		{fmt.Sprintf("To: %s - %s", reminder.Customer.Code, reminder.Customer.Name)},
+		{},
+	}
+	for _, line := range strings.Split(reminder.Body, "\n") {
+		rows = append(rows, []interface{}{line})
+	}
+	rows = append(rows,
+		[]interface{}{},
+		[]interface{}{"Invoice", "Invoice Date", "Due Date", "Days Overdue", "Outstanding"},
+	)
+	for _, item := range reminder.Items {
+		rows = append(rows, []interface{}{
+			item.Invoice.InvoiceNumber,
+			item.Invoice.InvoiceDate.Format("2006-01-02"),
+			item.DueDate.Format("2006-01-02"),
+			item.DaysOverdue,
+			item.Outstanding,
+		})
+	}
+	return append(rows, []interface{}{"TOTAL OVERDUE", "", "", "", reminder.TotalOverdue})
 }
\ No newline at end of file
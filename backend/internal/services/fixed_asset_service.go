@@ -0,0 +1,1160 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type FixedAssetService interface {
+	// Fixed Assets
+	CreateFixedAsset(asset *models.FixedAsset) error
+	GetFixedAssetByID(id uint) (*models.FixedAsset, error)
+	GetFixedAssets(companyID uint, status string, fiscalGroup string) ([]models.FixedAsset, error)
+	UpdateFixedAsset(id uint, asset *models.FixedAsset) error
+	DeleteFixedAsset(id uint) error
+	GetDepreciationSchedule(id uint) (*models.DepreciationSchedule, error)
+	GetFixedAssetHistory(id uint) ([]models.FixedAssetEvent, error)
+
+	// Asset Events
+	TransferFixedAsset(id uint, event *models.FixedAssetEvent) error
+	RevalueFixedAsset(id uint, event *models.FixedAssetEvent, revaluedAmount float64, remainingLifeMonths int, salvageValue *float64) error
+	ImpairFixedAsset(id uint, event *models.FixedAssetEvent) error
+	DisposeFixedAsset(id uint, event *models.FixedAssetEvent, proceedsAccountID uint) error
+
+	// Depreciation Runs
+	RunDepreciation(companyID uint, period string, userID uint) (*models.DepreciationRun, error)
+	ProcessDepreciation(companyID, userID uint) error
+	GetDepreciationRunByID(id uint) (*models.DepreciationRun, error)
+	GetDepreciationRuns(companyID uint) ([]models.DepreciationRun, error)
+
+	// Reports
+	GetDepreciationReport(companyID uint, year int) (*models.FixedAssetDepreciationReport, error)
+}
+
+// ErrNoAssetsToDepreciate is returned by RunDepreciation for a period with
+// nothing to depreciate
+var ErrNoAssetsToDepreciate = errors.New("no assets to depreciate")
+
+const (
+	defaultAccumulatedDepreciationCode = "1-2900" // Akumulasi Penyusutan
+	defaultDepreciationExpenseCode     = "5-1500" // Beban Penyusutan
+	defaultImpairmentLossCode          = "5-1600" // Rugi Penurunan Nilai Aset
+	defaultRevaluationSurplusCode      = "3-3000" // Surplus Revaluasi Aset Tetap
+	defaultRetainedEarningsCode        = "3-2000" // Laba Ditahan
+	defaultDisposalGainCode            = "4-2000" // Pendapatan Lain-lain
+	defaultDisposalLossCode            = "5-2000" // Beban Lain-lain
+)
+
+// FiscalUsefulLifeYears is the useful life PPh Pasal 11 allows each fiscal
+// asset group; declining balance runs at twice the straight-line rate
+var FiscalUsefulLifeYears = map[models.FiscalAssetGroup]int{
+	models.FiscalAssetGroup1:                 4,
+	models.FiscalAssetGroup2:                 8,
+	models.FiscalAssetGroup3:                 16,
+	models.FiscalAssetGroup4:                 20,
+	models.FiscalAssetGroupBuilding:          20,
+	models.FiscalAssetGroupTemporaryBuilding: 10,
+}
+
+// fiscalAssetGroupOrder lists the groups in the order of the reports
+var fiscalAssetGroupOrder = []models.FiscalAssetGroup{
+	models.FiscalAssetGroup1,
+	models.FiscalAssetGroup2,
+	models.FiscalAssetGroup3,
+	models.FiscalAssetGroup4,
+	models.FiscalAssetGroupBuilding,
+	models.FiscalAssetGroupTemporaryBuilding,
+}
+
+type fixedAssetService struct {
+	db             *gorm.DB
+	fixedAssetRepo repository.FixedAssetRepository
+	accountRepo    repository.AccountRepository
+	journalService JournalService
+}
+
+func NewFixedAssetService(
+	db *gorm.DB,
+	fixedAssetRepo repository.FixedAssetRepository,
+	accountRepo repository.AccountRepository,
+	journalService JournalService,
+) FixedAssetService {
+	return &fixedAssetService{
+		db:             db,
+		fixedAssetRepo: fixedAssetRepo,
+		accountRepo:    accountRepo,
+		journalService: journalService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so a run and
+// the journal it posts commit or roll back together
+func (s *fixedAssetService) withTx(tx *gorm.DB) *fixedAssetService {
+	return &fixedAssetService{
+		db:             tx,
+		fixedAssetRepo: repository.NewFixedAssetRepository(tx),
+		accountRepo:    repository.NewAccountRepository(tx),
+		journalService: journalServiceWith(tx),
+	}
+}
+
+// depreciationAmounts spreads the depreciable cost over the months of the
+// useful life left from the start month. Declining balance is charged at
+// the annual rate on the book value at the start of each calendar year and
+// the final month takes whatever is left down to the salvage value.
+func depreciationAmounts(cost, salvage float64, lifeMonths int, annualRate float64, method models.DepreciationMethod, start time.Time) []float64 {
+	if lifeMonths <= 0 {
+		return nil
+	}
+	amounts := make([]float64, lifeMonths)
+	depreciable := cost - salvage
+
+	var accumulated, monthly float64
+	for k := 0; k < lifeMonths; k++ {
+		remaining := math.Round((depreciable-accumulated)*100) / 100
+
+		var amount float64
+		switch {
+		case k == lifeMonths-1:
+			amount = remaining
+		case method == models.DepreciationMethodStraightLine:
+			amount = math.Round(depreciable/float64(lifeMonths)*100) / 100
+		default:
+			if k == 0 || start.AddDate(0, k, 0).Month() == time.January {
+				monthly = math.Round((cost-accumulated)*annualRate/12*100) / 100
+			}
+			amount = monthly
+		}
+		if amount > remaining {
+			amount = remaining
+		}
+		if amount < 0 {
+			amount = 0
+		}
+
+		amounts[k] = amount
+		accumulated = math.Round((accumulated+amount)*100) / 100
+	}
+	return amounts
+}
+
+// BuildDepreciationSchedule lays out an asset's commercial and fiscal
+// depreciation month by month, from the month of acquisition until both
+// are fully depreciated. Fiscal depreciation has no salvage value.
+func BuildDepreciationSchedule(asset *models.FixedAsset) []models.DepreciationScheduleLine {
+	start := time.Date(asset.AcquisitionDate.Year(), asset.AcquisitionDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	fiscalYears := FiscalUsefulLifeYears[asset.FiscalGroup]
+	commercial := depreciationAmounts(asset.AcquisitionCost, asset.SalvageValue, asset.UsefulLifeMonths, 24/float64(asset.UsefulLifeMonths), asset.Method, start)
+	fiscal := depreciationAmounts(asset.AcquisitionCost, 0, fiscalYears*12, 2/float64(fiscalYears), asset.FiscalMethod, start)
+
+	months := len(commercial)
+	if len(fiscal) > months {
+		months = len(fiscal)
+	}
+
+	lines := make([]models.DepreciationScheduleLine, months)
+	var accumulated, fiscalAccumulated float64
+	for k := 0; k < months; k++ {
+		line := &lines[k]
+		line.Period = start.AddDate(0, k, 0).Format("2006-01")
+		line.PeriodEnd = start.AddDate(0, k+1, -1)
+		if k < len(commercial) {
+			line.Depreciation = commercial[k]
+		}
+		if k < len(fiscal) {
+			line.FiscalDepreciation = fiscal[k]
+		}
+
+		accumulated = math.Round((accumulated+line.Depreciation)*100) / 100
+		fiscalAccumulated = math.Round((fiscalAccumulated+line.FiscalDepreciation)*100) / 100
+		line.AccumulatedDepreciation = accumulated
+		line.BookValue = math.Round((asset.AcquisitionCost-accumulated)*100) / 100
+		line.FiscalAccumulatedDepreciation = fiscalAccumulated
+		line.FiscalBookValue = math.Round((asset.AcquisitionCost-fiscalAccumulated)*100) / 100
+		line.IsPosted = asset.DepreciatedThrough != nil && !line.PeriodEnd.After(*asset.DepreciatedThrough)
+	}
+	return lines
+}
+
+// rebuildSchedule lays out the months from start again with the given
+// commercial and fiscal depreciation, the running balances carrying on from
+// the asset's current cost and accumulated depreciation
+func rebuildSchedule(asset *models.FixedAsset, start time.Time, commercial, fiscal []float64) []models.DepreciationScheduleLine {
+	months := len(commercial)
+	if len(fiscal) > months {
+		months = len(fiscal)
+	}
+
+	lines := make([]models.DepreciationScheduleLine, months)
+	accumulated := asset.AccumulatedDepreciation
+	fiscalAccumulated := asset.FiscalAccumulatedDepreciation
+	for k := 0; k < months; k++ {
+		line := &lines[k]
+		line.AssetID = asset.ID
+		line.Period = start.AddDate(0, k, 0).Format("2006-01")
+		line.PeriodEnd = start.AddDate(0, k+1, -1)
+		if k < len(commercial) {
+			line.Depreciation = commercial[k]
+		}
+		if k < len(fiscal) {
+			line.FiscalDepreciation = fiscal[k]
+		}
+
+		accumulated = math.Round((accumulated+line.Depreciation)*100) / 100
+		fiscalAccumulated = math.Round((fiscalAccumulated+line.FiscalDepreciation)*100) / 100
+		line.AccumulatedDepreciation = accumulated
+		line.BookValue = math.Round((asset.Cost-accumulated)*100) / 100
+		line.FiscalAccumulatedDepreciation = fiscalAccumulated
+		line.FiscalBookValue = math.Round((asset.FiscalCost-fiscalAccumulated)*100) / 100
+	}
+	return lines
+}
+
+// scaleAmounts resizes the monthly amounts in proportion to add up to the
+// total, the last month taking the cents lost to rounding
+func scaleAmounts(amounts []float64, total float64) []float64 {
+	var sum float64
+	for _, amount := range amounts {
+		sum += amount
+	}
+
+	scaled := make([]float64, len(amounts))
+	if sum <= 0 || total <= 0 {
+		return scaled
+	}
+
+	last := -1
+	var scaledSum float64
+	for i, amount := range amounts {
+		scaled[i] = math.Round(amount*total/sum*100) / 100
+		scaledSum += scaled[i]
+		if amount > 0 {
+			last = i
+		}
+	}
+	scaled[last] = math.Round((scaled[last]+total-scaledSum)*100) / 100
+	return scaled
+}
+
+// CalculateDisposalGainLoss returns the cost and accumulated depreciation of
+// the percentage of the asset disposed of, and the gain (positive) or loss
+// of the proceeds against their net book value
+func CalculateDisposalGainLoss(asset *models.FixedAsset, percent, proceeds float64) (float64, float64, float64) {
+	cost, accumulated := asset.Cost, asset.AccumulatedDepreciation
+	if percent < 100 {
+		cost = math.Round(asset.Cost*percent) / 100
+		accumulated = math.Round(asset.AccumulatedDepreciation*percent) / 100
+	}
+	return cost, accumulated, math.Round((proceeds-cost+accumulated)*100) / 100
+}
+
+// findCompanyAccount checks an account chosen for the asset belongs to the
+// company and can be posted to
+func (s *fixedAssetService) findCompanyAccount(companyID uint, accountID uint, label string) (*models.Account, error) {
+	account, err := s.accountRepo.FindByID(accountID)
+	if err != nil || account.CompanyID != companyID {
+		return nil, errors.New(label + " account not found")
+	}
+	if account.IsHeader {
+		return nil, errors.New(label + " account cannot be a header account")
+	}
+	return account, nil
+}
+
+// findDefaultAccount looks up one of the accounts asset events post to
+func (s *fixedAssetService) findDefaultAccount(companyID uint, code string, label string) (uint, error) {
+	account, err := s.accountRepo.FindByCode(companyID, code)
+	if err != nil {
+		return 0, errors.New(label + " account " + code + " not found")
+	}
+	return account.ID, nil
+}
+
+// findEventAsset loads an asset for an event dated on eventDate
+func (s *fixedAssetService) findEventAsset(id uint, eventDate time.Time) (*models.FixedAsset, error) {
+	asset, err := s.fixedAssetRepo.FindFixedAssetByID(id)
+	if err != nil {
+		return nil, errors.New("fixed asset not found")
+	}
+	if asset.Status == models.FixedAssetStatusDisposed {
+		return nil, errors.New("fixed asset has been disposed of")
+	}
+	if eventDate.Before(asset.AcquisitionDate) {
+		return nil, errors.New("event date cannot be before the acquisition date")
+	}
+	return asset, nil
+}
+
+// catchUpDepreciation posts the months before the month of the event that
+// no run has posted yet, so that the event starts from the book value at the
+// start of its month. It returns the months still to be posted.
+func (s *fixedAssetService) catchUpDepreciation(asset *models.FixedAsset, eventDate time.Time, userID uint) ([]models.DepreciationScheduleLine, error) {
+	monthStart := time.Date(eventDate.Year(), eventDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if asset.DepreciatedThrough != nil && !asset.DepreciatedThrough.Before(monthStart) {
+		return nil, errors.New("the asset has been depreciated through " + asset.DepreciatedThrough.Format("2006-01") + ", the event must be dated after it")
+	}
+
+	schedule, err := s.fixedAssetRepo.FindUnpostedScheduleLines(asset.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var due []uint
+	var amount float64
+	var through *models.DepreciationScheduleLine
+	for k := range schedule {
+		if !schedule[k].PeriodEnd.Before(monthStart) {
+			break
+		}
+		due = append(due, schedule[k].ID)
+		amount += schedule[k].Depreciation
+		through = &schedule[k]
+	}
+	if through == nil {
+		return schedule, nil
+	}
+
+	amount = math.Round(amount*100) / 100
+	description := "Depreciation " + asset.AssetNumber + " through " + through.Period
+	journalID, err := postNetJournal(s.journalService, asset.CompanyID, through.PeriodEnd, description, []netJournalLine{
+		{asset.ExpenseAccountID, amount},
+		{asset.AccumulatedAccountID, -amount},
+	}, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.fixedAssetRepo.MarkScheduleLinesPosted(due, nil); err != nil {
+		return nil, err
+	}
+
+	bookValueBefore := math.Round((asset.Cost-asset.AccumulatedDepreciation)*100) / 100
+	asset.AccumulatedDepreciation = through.AccumulatedDepreciation
+	asset.FiscalAccumulatedDepreciation = through.FiscalAccumulatedDepreciation
+	throughDate := through.PeriodEnd
+	asset.DepreciatedThrough = &throughDate
+	if len(due) == len(schedule) {
+		asset.Status = models.FixedAssetStatusFullyDepreciated
+	}
+	if err := s.fixedAssetRepo.UpdateFixedAsset(asset); err != nil {
+		return nil, err
+	}
+
+	if err := s.fixedAssetRepo.CreateFixedAssetEvent(&models.FixedAssetEvent{
+		AssetID:         asset.ID,
+		EventType:       models.FixedAssetEventDepreciation,
+		EventDate:       throughDate,
+		Description:     description,
+		CostBefore:      asset.Cost,
+		CostAfter:       asset.Cost,
+		BookValueBefore: bookValueBefore,
+		BookValueAfter:  through.BookValue,
+		Amount:          amount,
+		JournalID:       journalID,
+		CreatedBy:       userID,
+	}); err != nil {
+		return nil, err
+	}
+
+	return schedule[len(due):], nil
+}
+
+// remainingAmounts splits the months still to be posted into their
+// commercial and fiscal depreciation
+func remainingAmounts(schedule []models.DepreciationScheduleLine) ([]float64, []float64) {
+	commercial := make([]float64, len(schedule))
+	fiscal := make([]float64, len(schedule))
+	for k, line := range schedule {
+		commercial[k] = line.Depreciation
+		fiscal[k] = line.FiscalDepreciation
+	}
+	return commercial, fiscal
+}
+
+// replaceSchedule saves the months laid out again from the event's month;
+// an asset with none left is fully depreciated
+func (s *fixedAssetService) replaceSchedule(asset *models.FixedAsset, monthStart time.Time, commercial, fiscal []float64) error {
+	schedule := rebuildSchedule(asset, monthStart, commercial, fiscal)
+	if err := s.fixedAssetRepo.ReplaceUnpostedScheduleLines(asset.ID, schedule); err != nil {
+		return err
+	}
+
+	if len(schedule) == 0 {
+		asset.Status = models.FixedAssetStatusFullyDepreciated
+	} else {
+		asset.Status = models.FixedAssetStatusActive
+	}
+	return nil
+}
+
+// Fixed Asset methods
+func (s *fixedAssetService) CreateFixedAsset(asset *models.FixedAsset) error {
+	asset.Name = strings.TrimSpace(asset.Name)
+	if asset.Name == "" {
+		return errors.New("asset name is required")
+	}
+	if asset.AcquisitionCost <= 0 {
+		return errors.New("acquisition cost must be greater than zero")
+	}
+	if asset.SalvageValue < 0 || asset.SalvageValue >= asset.AcquisitionCost {
+		return errors.New("salvage value must be at least zero and below the acquisition cost")
+	}
+	if asset.UsefulLifeMonths <= 0 {
+		return errors.New("useful life must be greater than zero")
+	}
+	if asset.Method != models.DepreciationMethodStraightLine && asset.Method != models.DepreciationMethodDecliningBalance {
+		return errors.New("invalid depreciation method")
+	}
+	if _, ok := FiscalUsefulLifeYears[asset.FiscalGroup]; !ok {
+		return errors.New("invalid fiscal asset group")
+	}
+	if asset.FiscalMethod == "" {
+		asset.FiscalMethod = models.DepreciationMethodStraightLine
+	}
+	if asset.FiscalMethod != models.DepreciationMethodStraightLine && asset.FiscalMethod != models.DepreciationMethodDecliningBalance {
+		return errors.New("invalid fiscal depreciation method")
+	}
+	if (asset.FiscalGroup == models.FiscalAssetGroupBuilding || asset.FiscalGroup == models.FiscalAssetGroupTemporaryBuilding) &&
+		asset.FiscalMethod != models.DepreciationMethodStraightLine {
+		return errors.New("buildings can only be depreciated straight-line for tax")
+	}
+
+	assetAccount, err := s.findCompanyAccount(asset.CompanyID, asset.AssetAccountID, "asset")
+	if err != nil {
+		return err
+	}
+	if assetAccount.Category != models.CategoryFixedAsset {
+		return errors.New("asset account must be a fixed asset account")
+	}
+
+	if asset.AccumulatedAccountID == 0 {
+		accumulated, err := s.accountRepo.FindByCode(asset.CompanyID, defaultAccumulatedDepreciationCode)
+		if err != nil {
+			return errors.New("accumulated depreciation account " + defaultAccumulatedDepreciationCode + " not found")
+		}
+		asset.AccumulatedAccountID = accumulated.ID
+	} else if _, err := s.findCompanyAccount(asset.CompanyID, asset.AccumulatedAccountID, "accumulated depreciation"); err != nil {
+		return err
+	}
+	if asset.ExpenseAccountID == 0 {
+		expense, err := s.accountRepo.FindByCode(asset.CompanyID, defaultDepreciationExpenseCode)
+		if err != nil {
+			return errors.New("depreciation expense account " + defaultDepreciationExpenseCode + " not found")
+		}
+		asset.ExpenseAccountID = expense.ID
+	} else if _, err := s.findCompanyAccount(asset.CompanyID, asset.ExpenseAccountID, "depreciation expense"); err != nil {
+		return err
+	}
+
+	// An asset taken over from an earlier register comes with the months
+	// already depreciated there, which the runs will not post again
+	asset.Cost = asset.AcquisitionCost
+	asset.FiscalCost = asset.AcquisitionCost
+	asset.AccumulatedDepreciation = 0
+	asset.FiscalAccumulatedDepreciation = 0
+	asset.RevaluationSurplus = 0
+	asset.Status = models.FixedAssetStatusActive
+	asset.DisposalDate = nil
+	if asset.DepreciatedThrough != nil {
+		through := time.Date(asset.DepreciatedThrough.Year(), asset.DepreciatedThrough.Month()+1, 0, 0, 0, 0, 0, time.UTC)
+		if through.Before(asset.AcquisitionDate) {
+			return errors.New("depreciated through cannot be before the acquisition date")
+		}
+		asset.DepreciatedThrough = &through
+	}
+
+	schedule := BuildDepreciationSchedule(asset)
+	for _, line := range schedule {
+		if !line.IsPosted {
+			break
+		}
+		asset.AccumulatedDepreciation = line.AccumulatedDepreciation
+		asset.FiscalAccumulatedDepreciation = line.FiscalAccumulatedDepreciation
+	}
+	if schedule[len(schedule)-1].IsPosted {
+		asset.Status = models.FixedAssetStatusFullyDepreciated
+	}
+
+	assetNumber, err := s.fixedAssetRepo.GenerateFixedAssetNumber(asset.CompanyID, asset.AcquisitionDate)
+	if err != nil {
+		return err
+	}
+	asset.AssetNumber = assetNumber
+
+	if err := s.fixedAssetRepo.CreateFixedAsset(asset); err != nil {
+		return err
+	}
+
+	for i := range schedule {
+		schedule[i].AssetID = asset.ID
+	}
+	if err := s.fixedAssetRepo.CreateScheduleLines(schedule); err != nil {
+		return err
+	}
+
+	return s.fixedAssetRepo.CreateFixedAssetEvent(&models.FixedAssetEvent{
+		AssetID:        asset.ID,
+		EventType:      models.FixedAssetEventAcquisition,
+		EventDate:      asset.AcquisitionDate,
+		Description:    "Acquisition of " + asset.Name,
+		CostAfter:      asset.Cost,
+		BookValueAfter: math.Round((asset.Cost-asset.AccumulatedDepreciation)*100) / 100,
+		Amount:         asset.AcquisitionCost,
+		ToLocation:     asset.Location,
+		ToCostCenter:   asset.CostCenter,
+		Notes:          asset.Notes,
+		CreatedBy:      asset.CreatedBy,
+	})
+}
+
+func (s *fixedAssetService) GetFixedAssetByID(id uint) (*models.FixedAsset, error) {
+	return s.fixedAssetRepo.FindFixedAssetByID(id)
+}
+
+func (s *fixedAssetService) GetFixedAssets(companyID uint, status string, fiscalGroup string) ([]models.FixedAsset, error) {
+	return s.fixedAssetRepo.FindFixedAssets(companyID, status, fiscalGroup)
+}
+
+// UpdateFixedAsset changes the descriptive details of an asset; its cost and
+// depreciation terms are fixed once registered
+func (s *fixedAssetService) UpdateFixedAsset(id uint, updated *models.FixedAsset) error {
+	asset, err := s.fixedAssetRepo.FindFixedAssetByID(id)
+	if err != nil {
+		return errors.New("fixed asset not found")
+	}
+
+	updated.Name = strings.TrimSpace(updated.Name)
+	if updated.Name == "" {
+		return errors.New("asset name is required")
+	}
+
+	asset.Name = updated.Name
+	asset.Description = updated.Description
+	asset.Location = updated.Location
+	asset.Notes = updated.Notes
+	return s.fixedAssetRepo.UpdateFixedAsset(asset)
+}
+
+func (s *fixedAssetService) DeleteFixedAsset(id uint) error {
+	if _, err := s.fixedAssetRepo.FindFixedAssetByID(id); err != nil {
+		return errors.New("fixed asset not found")
+	}
+
+	count, err := s.fixedAssetRepo.CountPostedFixedAssetEvents(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("cannot delete an asset with posted depreciation or events")
+	}
+
+	return s.fixedAssetRepo.DeleteFixedAsset(id)
+}
+
+func (s *fixedAssetService) GetDepreciationSchedule(id uint) (*models.DepreciationSchedule, error) {
+	asset, err := s.fixedAssetRepo.FindFixedAssetByID(id)
+	if err != nil {
+		return nil, errors.New("fixed asset not found")
+	}
+
+	lines, err := s.fixedAssetRepo.FindScheduleLines(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.DepreciationSchedule{
+		Asset: *asset,
+		Lines: lines,
+	}, nil
+}
+
+func (s *fixedAssetService) GetFixedAssetHistory(id uint) ([]models.FixedAssetEvent, error) {
+	if _, err := s.fixedAssetRepo.FindFixedAssetByID(id); err != nil {
+		return nil, errors.New("fixed asset not found")
+	}
+	return s.fixedAssetRepo.FindFixedAssetEvents(id)
+}
+
+// Asset Event methods
+
+// TransferFixedAsset moves the asset to another location or cost center
+func (s *fixedAssetService) TransferFixedAsset(id uint, event *models.FixedAssetEvent) error {
+	asset, err := s.findEventAsset(id, event.EventDate)
+	if err != nil {
+		return err
+	}
+
+	event.ToLocation = strings.TrimSpace(event.ToLocation)
+	event.ToCostCenter = strings.TrimSpace(event.ToCostCenter)
+	if event.ToLocation == "" {
+		event.ToLocation = asset.Location
+	}
+	if event.ToCostCenter == "" {
+		event.ToCostCenter = asset.CostCenter
+	}
+	if event.ToLocation == asset.Location && event.ToCostCenter == asset.CostCenter {
+		return errors.New("transfer must change the location or cost center")
+	}
+
+	bookValue := math.Round((asset.Cost-asset.AccumulatedDepreciation)*100) / 100
+	event.AssetID = asset.ID
+	event.EventType = models.FixedAssetEventTransfer
+	event.Description = "Transfer of " + asset.AssetNumber
+	event.CostBefore = asset.Cost
+	event.CostAfter = asset.Cost
+	event.BookValueBefore = bookValue
+	event.BookValueAfter = bookValue
+	event.FromLocation = asset.Location
+	event.FromCostCenter = asset.CostCenter
+
+	asset.Location = event.ToLocation
+	asset.CostCenter = event.ToCostCenter
+	if err := s.fixedAssetRepo.UpdateFixedAsset(asset); err != nil {
+		return err
+	}
+	return s.fixedAssetRepo.CreateFixedAssetEvent(event)
+}
+
+// RevalueFixedAsset restates the asset at its revalued amount. The
+// accumulated depreciation is eliminated against the cost, an increase goes
+// to the revaluation surplus in equity and a decrease first reverses the
+// asset's surplus, the rest being a loss. Depreciation continues on the
+// revalued amount over the remaining useful life.
+func (s *fixedAssetService) RevalueFixedAsset(id uint, event *models.FixedAssetEvent, revaluedAmount float64, remainingLifeMonths int, salvageValue *float64) error {
+	if revaluedAmount <= 0 {
+		return errors.New("revalued amount must be greater than zero")
+	}
+	if remainingLifeMonths < 0 {
+		return errors.New("remaining useful life cannot be negative")
+	}
+
+	asset, err := s.findEventAsset(id, event.EventDate)
+	if err != nil {
+		return err
+	}
+
+	salvage := asset.SalvageValue
+	if salvageValue != nil {
+		salvage = *salvageValue
+	}
+	if salvage < 0 || salvage >= revaluedAmount {
+		return errors.New("salvage value must be at least zero and below the revalued amount")
+	}
+
+	monthStart := time.Date(event.EventDate.Year(), event.EventDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	elapsed := monthsBetween(asset.AcquisitionDate, monthStart)
+	if remainingLifeMonths == 0 {
+		remainingLifeMonths = asset.UsefulLifeMonths - elapsed
+		if remainingLifeMonths <= 0 {
+			return errors.New("the useful life of the asset has ended, give its remaining useful life")
+		}
+	}
+
+	schedule, err := s.catchUpDepreciation(asset, event.EventDate, event.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	bookValue := math.Round((asset.Cost-asset.AccumulatedDepreciation)*100) / 100
+	difference := math.Round((revaluedAmount-bookValue)*100) / 100
+
+	lines := []netJournalLine{
+		{asset.AccumulatedAccountID, asset.AccumulatedDepreciation},
+		{asset.AssetAccountID, revaluedAmount - asset.Cost},
+	}
+	var surplusChange float64
+	if difference > 0 {
+		surplusChange = difference
+	} else if difference < 0 {
+		surplusChange = -math.Min(-difference, asset.RevaluationSurplus)
+		lossID, err := s.findDefaultAccount(asset.CompanyID, defaultImpairmentLossCode, "impairment loss")
+		if err != nil {
+			return err
+		}
+		lines = append(lines, netJournalLine{lossID, -difference + surplusChange})
+	}
+	if surplusChange != 0 {
+		surplusID, err := s.findDefaultAccount(asset.CompanyID, defaultRevaluationSurplusCode, "revaluation surplus")
+		if err != nil {
+			return err
+		}
+		lines = append(lines, netJournalLine{surplusID, -surplusChange})
+	}
+
+	description := "Revaluation of " + asset.AssetNumber
+	journalID, err := postNetJournal(s.journalService, asset.CompanyID, event.EventDate, description, lines, event.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	event.AssetID = asset.ID
+	event.EventType = models.FixedAssetEventRevaluation
+	event.Description = description
+	event.CostBefore = asset.Cost
+	event.CostAfter = revaluedAmount
+	event.BookValueBefore = bookValue
+	event.BookValueAfter = revaluedAmount
+	event.Amount = difference
+	event.JournalID = journalID
+
+	asset.Cost = revaluedAmount
+	asset.AccumulatedDepreciation = 0
+	asset.SalvageValue = salvage
+	asset.RevaluationSurplus = math.Round((asset.RevaluationSurplus+surplusChange)*100) / 100
+	asset.UsefulLifeMonths = elapsed + remainingLifeMonths
+
+	// Tax keeps depreciating the original cost
+	_, fiscal := remainingAmounts(schedule)
+	commercial := depreciationAmounts(revaluedAmount, salvage, remainingLifeMonths, 24/float64(asset.UsefulLifeMonths), asset.Method, monthStart)
+	if err := s.replaceSchedule(asset, monthStart, commercial, fiscal); err != nil {
+		return err
+	}
+	if err := s.fixedAssetRepo.UpdateFixedAsset(asset); err != nil {
+		return err
+	}
+	return s.fixedAssetRepo.CreateFixedAssetEvent(event)
+}
+
+// ImpairFixedAsset writes the asset down by the event amount. The write-down
+// first reverses the asset's revaluation surplus, the rest being a loss, and
+// depreciation continues on the lower book value.
+func (s *fixedAssetService) ImpairFixedAsset(id uint, event *models.FixedAssetEvent) error {
+	if event.Amount <= 0 {
+		return errors.New("write-down must be greater than zero")
+	}
+
+	asset, err := s.findEventAsset(id, event.EventDate)
+	if err != nil {
+		return err
+	}
+
+	schedule, err := s.catchUpDepreciation(asset, event.EventDate, event.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	bookValue := math.Round((asset.Cost-asset.AccumulatedDepreciation)*100) / 100
+	if event.Amount > bookValue {
+		return errors.New("write-down cannot exceed the book value of " + strconv.FormatFloat(bookValue, 'f', 2, 64))
+	}
+
+	fromSurplus := math.Min(event.Amount, asset.RevaluationSurplus)
+	lines := []netJournalLine{{asset.AccumulatedAccountID, -event.Amount}}
+	if fromSurplus > 0 {
+		surplusID, err := s.findDefaultAccount(asset.CompanyID, defaultRevaluationSurplusCode, "revaluation surplus")
+		if err != nil {
+			return err
+		}
+		lines = append(lines, netJournalLine{surplusID, fromSurplus})
+	}
+	if loss := event.Amount - fromSurplus; loss > 0 {
+		lossID, err := s.findDefaultAccount(asset.CompanyID, defaultImpairmentLossCode, "impairment loss")
+		if err != nil {
+			return err
+		}
+		lines = append(lines, netJournalLine{lossID, loss})
+	}
+
+	description := "Impairment of " + asset.AssetNumber
+	journalID, err := postNetJournal(s.journalService, asset.CompanyID, event.EventDate, description, lines, event.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	asset.AccumulatedDepreciation = math.Round((asset.AccumulatedDepreciation+event.Amount)*100) / 100
+	asset.RevaluationSurplus = math.Round((asset.RevaluationSurplus-fromSurplus)*100) / 100
+	newBookValue := math.Round((bookValue-event.Amount)*100) / 100
+	if asset.SalvageValue > newBookValue {
+		asset.SalvageValue = newBookValue
+	}
+
+	event.AssetID = asset.ID
+	event.EventType = models.FixedAssetEventImpairment
+	event.Description = description
+	event.CostBefore = asset.Cost
+	event.CostAfter = asset.Cost
+	event.BookValueBefore = bookValue
+	event.BookValueAfter = newBookValue
+	event.JournalID = journalID
+
+	// Write-downs are not deductible, so tax keeps its own schedule
+	monthStart := time.Date(event.EventDate.Year(), event.EventDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	remaining := asset.UsefulLifeMonths - monthsBetween(asset.AcquisitionDate, monthStart)
+	_, fiscal := remainingAmounts(schedule)
+	commercial := depreciationAmounts(newBookValue, asset.SalvageValue, remaining, 24/float64(asset.UsefulLifeMonths), asset.Method, monthStart)
+	if err := s.replaceSchedule(asset, monthStart, commercial, fiscal); err != nil {
+		return err
+	}
+	if err := s.fixedAssetRepo.UpdateFixedAsset(asset); err != nil {
+		return err
+	}
+	return s.fixedAssetRepo.CreateFixedAssetEvent(event)
+}
+
+// DisposeFixedAsset sells or scraps the event's percentage of the asset,
+// all of it when no percentage is given. The share of cost and accumulated
+// depreciation leaves the books, the proceeds against the net book value
+// are the gain or loss, and the share of the revaluation surplus is
+// released to retained earnings. What is left of a partly disposed asset
+// keeps depreciating in proportion.
+func (s *fixedAssetService) DisposeFixedAsset(id uint, event *models.FixedAssetEvent, proceedsAccountID uint) error {
+	if event.Percent == 0 {
+		event.Percent = 100
+	}
+	if event.Percent < 0 || event.Percent > 100 {
+		return errors.New("percent disposed must be between 0 and 100")
+	}
+	if event.Amount < 0 {
+		return errors.New("proceeds cannot be negative")
+	}
+
+	asset, err := s.findEventAsset(id, event.EventDate)
+	if err != nil {
+		return err
+	}
+	if event.Amount > 0 {
+		if proceedsAccountID == 0 {
+			return errors.New("proceeds account is required when the asset is sold")
+		}
+		if _, err := s.findCompanyAccount(asset.CompanyID, proceedsAccountID, "proceeds"); err != nil {
+			return err
+		}
+	}
+
+	schedule, err := s.catchUpDepreciation(asset, event.EventDate, event.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	full := event.Percent == 100
+	share := event.Percent / 100
+	cost, accumulated, gainLoss := CalculateDisposalGainLoss(asset, event.Percent, event.Amount)
+	surplus := asset.RevaluationSurplus
+	if !full {
+		surplus = math.Round(asset.RevaluationSurplus*share*100) / 100
+	}
+	bookValue := math.Round((asset.Cost-asset.AccumulatedDepreciation)*100) / 100
+
+	lines := []netJournalLine{
+		{proceedsAccountID, event.Amount},
+		{asset.AccumulatedAccountID, accumulated},
+		{asset.AssetAccountID, -cost},
+	}
+	if gainLoss > 0 {
+		gainID, err := s.findDefaultAccount(asset.CompanyID, defaultDisposalGainCode, "disposal gain")
+		if err != nil {
+			return err
+		}
+		lines = append(lines, netJournalLine{gainID, -gainLoss})
+	} else if gainLoss < 0 {
+		lossID, err := s.findDefaultAccount(asset.CompanyID, defaultDisposalLossCode, "disposal loss")
+		if err != nil {
+			return err
+		}
+		lines = append(lines, netJournalLine{lossID, -gainLoss})
+	}
+	if surplus > 0 {
+		surplusID, err := s.findDefaultAccount(asset.CompanyID, defaultRevaluationSurplusCode, "revaluation surplus")
+		if err != nil {
+			return err
+		}
+		retainedID, err := s.findDefaultAccount(asset.CompanyID, defaultRetainedEarningsCode, "retained earnings")
+		if err != nil {
+			return err
+		}
+		lines = append(lines, netJournalLine{surplusID, surplus}, netJournalLine{retainedID, -surplus})
+	}
+
+	description := "Disposal of " + asset.AssetNumber
+	if !full {
+		description = "Partial disposal of " + asset.AssetNumber
+	}
+	journalID, err := postNetJournal(s.journalService, asset.CompanyID, event.EventDate, description, lines, event.CreatedBy)
+	if err != nil {
+		return err
+	}
+
+	event.AssetID = asset.ID
+	event.Description = description
+	event.CostBefore = asset.Cost
+	event.BookValueBefore = bookValue
+	event.GainLoss = gainLoss
+	event.JournalID = journalID
+
+	monthStart := time.Date(event.EventDate.Year(), event.EventDate.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if full {
+		event.EventType = models.FixedAssetEventDisposal
+		disposalDate := event.EventDate
+		asset.Status = models.FixedAssetStatusDisposed
+		asset.DisposalDate = &disposalDate
+		if err := s.fixedAssetRepo.ReplaceUnpostedScheduleLines(asset.ID, nil); err != nil {
+			return err
+		}
+	} else {
+		event.EventType = models.FixedAssetEventPartialDisposal
+		asset.Cost = math.Round((asset.Cost-cost)*100) / 100
+		asset.AccumulatedDepreciation = math.Round((asset.AccumulatedDepreciation-accumulated)*100) / 100
+		asset.RevaluationSurplus = math.Round((asset.RevaluationSurplus-surplus)*100) / 100
+		asset.SalvageValue = math.Round(asset.SalvageValue*(1-share)*100) / 100
+		asset.FiscalCost = math.Round(asset.FiscalCost*(1-share)*100) / 100
+		asset.FiscalAccumulatedDepreciation = math.Round(asset.FiscalAccumulatedDepreciation*(1-share)*100) / 100
+		event.CostAfter = asset.Cost
+		event.BookValueAfter = math.Round((asset.Cost-asset.AccumulatedDepreciation)*100) / 100
+
+		commercial, fiscal := remainingAmounts(schedule)
+		commercial = scaleAmounts(commercial, asset.Cost-asset.AccumulatedDepreciation-asset.SalvageValue)
+		fiscal = scaleAmounts(fiscal, asset.FiscalCost-asset.FiscalAccumulatedDepreciation)
+		if err := s.replaceSchedule(asset, monthStart, commercial, fiscal); err != nil {
+			return err
+		}
+	}
+
+	if err := s.fixedAssetRepo.UpdateFixedAsset(asset); err != nil {
+		return err
+	}
+	return s.fixedAssetRepo.CreateFixedAssetEvent(event)
+}
+
+// Depreciation Run methods
+
+// RunDepreciation depreciates every active asset up to the end of the
+// period and posts the commercial depreciation as one journal, debiting
+// each expense account and crediting each accumulated depreciation account
+func (s *fixedAssetService) RunDepreciation(companyID uint, period string, userID uint) (*models.DepreciationRun, error) {
+	_, periodEnd, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.fixedAssetRepo.FindLatestDepreciationRun(companyID)
+	if err == nil && latest.Period >= period {
+		return nil, errors.New("depreciation has already been run for " + latest.Period)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	assets, err := s.fixedAssetRepo.FindDepreciableAssets(companyID, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &models.DepreciationRun{
+		CompanyID: companyID,
+		Period:    period,
+		PeriodEnd: periodEnd,
+		CreatedBy: userID,
+	}
+	var depreciated []*models.FixedAsset
+	var postedIDs []uint
+	for i := range assets {
+		asset := &assets[i]
+		schedule, err := s.fixedAssetRepo.FindUnpostedScheduleLines(asset.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		line := models.DepreciationRunLine{AssetID: asset.ID}
+		var through *models.DepreciationScheduleLine
+		for k := range schedule {
+			month := &schedule[k]
+			if month.PeriodEnd.After(periodEnd) {
+				break
+			}
+			line.Months++
+			line.Depreciation += month.Depreciation
+			line.FiscalDepreciation += month.FiscalDepreciation
+			postedIDs = append(postedIDs, month.ID)
+			through = month
+		}
+		if through == nil {
+			continue
+		}
+
+		line.Depreciation = math.Round(line.Depreciation*100) / 100
+		line.FiscalDepreciation = math.Round(line.FiscalDepreciation*100) / 100
+		line.AccumulatedDepreciation = through.AccumulatedDepreciation
+		line.FiscalAccumulatedDepreciation = through.FiscalAccumulatedDepreciation
+		line.BookValue = through.BookValue
+		run.Lines = append(run.Lines, line)
+		run.TotalDepreciation += line.Depreciation
+		run.TotalFiscalDepreciation += line.FiscalDepreciation
+
+		asset.AccumulatedDepreciation = through.AccumulatedDepreciation
+		asset.FiscalAccumulatedDepreciation = through.FiscalAccumulatedDepreciation
+		throughDate := through.PeriodEnd
+		asset.DepreciatedThrough = &throughDate
+		if line.Months == len(schedule) {
+			asset.Status = models.FixedAssetStatusFullyDepreciated
+		}
+		depreciated = append(depreciated, asset)
+	}
+	if len(run.Lines) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoAssetsToDepreciate, period)
+	}
+	run.TotalDepreciation = math.Round(run.TotalDepreciation*100) / 100
+	run.TotalFiscalDepreciation = math.Round(run.TotalFiscalDepreciation*100) / 100
+
+	// Expenses first, then the accumulated depreciation they build up;
+	// assets still depreciating for tax only leave nothing to post
+	var journalLines []netJournalLine
+	for i, asset := range depreciated {
+		journalLines = append(journalLines, netJournalLine{asset.ExpenseAccountID, run.Lines[i].Depreciation})
+	}
+	for i, asset := range depreciated {
+		journalLines = append(journalLines, netJournalLine{asset.AccumulatedAccountID, -run.Lines[i].Depreciation})
+	}
+	// The journal and the run that marks the months posted are saved
+	// together, so a failed run leaves nothing posted to run again over
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		journalID, err := postNetJournal(txService.journalService, companyID, periodEnd, "Depreciation "+period, journalLines, userID)
+		if err != nil {
+			return err
+		}
+		run.JournalID = journalID
+
+		if err := txService.fixedAssetRepo.CreateDepreciationRun(run); err != nil {
+			return err
+		}
+		if err := txService.fixedAssetRepo.MarkScheduleLinesPosted(postedIDs, &run.ID); err != nil {
+			return err
+		}
+		for _, asset := range depreciated {
+			if err := txService.fixedAssetRepo.UpdateFixedAsset(asset); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return run, nil
+}
+
+// ProcessDepreciation is the scheduled run, month by month through
+// last month, posted as the given (system) user. It carries on from the
+// runs made by hand, so nothing happens before the first one.
+func (s *fixedAssetService) ProcessDepreciation(companyID, userID uint) error {
+	latest, err := s.fixedAssetRepo.FindLatestDepreciationRun(companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return runPendingPeriods(latest.Period, ErrNoAssetsToDepreciate, func(period string) error {
+		_, err := s.RunDepreciation(companyID, period, userID)
+		return err
+	})
+}
+
+func (s *fixedAssetService) GetDepreciationRunByID(id uint) (*models.DepreciationRun, error) {
+	return s.fixedAssetRepo.FindDepreciationRunByID(id)
+}
+
+func (s *fixedAssetService) GetDepreciationRuns(companyID uint) ([]models.DepreciationRun, error) {
+	return s.fixedAssetRepo.FindDepreciationRuns(companyID)
+}
+
+// Report methods
+
+// GetDepreciationReport compares each asset's commercial depreciation for
+// the year with what PPh Pasal 11 allows, the difference being the fiscal
+// correction of the annual return
+func (s *fixedAssetService) GetDepreciationReport(companyID uint, year int) (*models.FixedAssetDepreciationReport, error) {
+	assets, err := s.fixedAssetRepo.FindFixedAssets(companyID, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	yearEnd := time.Date(year, time.December, 31, 0, 0, 0, 0, time.UTC)
+
+	report := &models.FixedAssetDepreciationReport{Year: year}
+	groups := make(map[models.FiscalAssetGroup]*models.FixedAssetDepreciationGroup)
+	for i := range assets {
+		asset := &assets[i]
+		if asset.AcquisitionDate.After(yearEnd) || (asset.DisposalDate != nil && asset.DisposalDate.Before(yearStart)) {
+			continue
+		}
+		schedule, err := s.fixedAssetRepo.FindScheduleLines(asset.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		line := models.FixedAssetDepreciationLine{
+			AssetID:             asset.ID,
+			AssetNumber:         asset.AssetNumber,
+			Name:                asset.Name,
+			FiscalGroup:         asset.FiscalGroup,
+			AcquisitionDate:     asset.AcquisitionDate,
+			AcquisitionCost:     asset.AcquisitionCost,
+			CommercialBookValue: asset.AcquisitionCost,
+			FiscalBookValue:     asset.AcquisitionCost,
+		}
+		for _, month := range schedule {
+			if month.PeriodEnd.After(yearEnd) {
+				break
+			}
+			if !month.PeriodEnd.Before(yearStart) {
+				line.CommercialDepreciation += month.Depreciation
+				line.FiscalDepreciation += month.FiscalDepreciation
+			}
+			line.CommercialBookValue = month.BookValue
+			line.FiscalBookValue = month.FiscalBookValue
+		}
+		if asset.DisposalDate != nil && !asset.DisposalDate.After(yearEnd) {
+			line.CommercialBookValue = 0
+			line.FiscalBookValue = 0
+		}
+		line.CommercialDepreciation = math.Round(line.CommercialDepreciation*100) / 100
+		line.FiscalDepreciation = math.Round(line.FiscalDepreciation*100) / 100
+		line.FiscalCorrection = math.Round((line.CommercialDepreciation-line.FiscalDepreciation)*100) / 100
+
+		group, ok := groups[asset.FiscalGroup]
+		if !ok {
+			group = &models.FixedAssetDepreciationGroup{FiscalGroup: asset.FiscalGroup}
+			groups[asset.FiscalGroup] = group
+		}
+		group.Lines = append(group.Lines, line)
+		group.AcquisitionCost += line.AcquisitionCost
+		group.CommercialDepreciation += line.CommercialDepreciation
+		group.FiscalDepreciation += line.FiscalDepreciation
+		group.FiscalCorrection += line.FiscalCorrection
+	}
+
+	for _, fiscalGroup := range fiscalAssetGroupOrder {
+		group, ok := groups[fiscalGroup]
+		if !ok {
+			continue
+		}
+		group.AcquisitionCost = math.Round(group.AcquisitionCost*100) / 100
+		group.CommercialDepreciation = math.Round(group.CommercialDepreciation*100) / 100
+		group.FiscalDepreciation = math.Round(group.FiscalDepreciation*100) / 100
+		group.FiscalCorrection = math.Round(group.FiscalCorrection*100) / 100
+		report.Groups = append(report.Groups, *group)
+
+		report.AcquisitionCost += group.AcquisitionCost
+		report.CommercialDepreciation += group.CommercialDepreciation
+		report.FiscalDepreciation += group.FiscalDepreciation
+		report.FiscalCorrection += group.FiscalCorrection
+	}
+	report.AcquisitionCost = math.Round(report.AcquisitionCost*100) / 100
+	report.CommercialDepreciation = math.Round(report.CommercialDepreciation*100) / 100
+	report.FiscalDepreciation = math.Round(report.FiscalDepreciation*100) / 100
+	report.FiscalCorrection = math.Round(report.FiscalCorrection*100) / 100
+
+	return report, nil
+}
\ No newline at end of file
@@ -4,7 +4,13 @@ import (
 	"errors"
 	"finara-backend/internal/models"
 	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type InventoryService interface {
@@ -19,44 +25,159 @@ type InventoryService interface {
 	CreateStockMovement(movement *models.StockMovement) error
 	CreateStockIn(movement *models.StockMovement) error
 	CreateStockOut(movement *models.StockMovement) error
+	CreateStockReturn(movement *models.StockMovement, sourceMovementID uint) error
 	GetStockMovementByID(id uint) (*models.StockMovement, error)
-	GetStockMovementsByProduct(productID uint, startDate, endDate time.Time) ([]models.StockMovement, error)
-	GetStockMovementsByCompany(companyID uint, startDate, endDate time.Time) ([]models.StockMovement, error)
+	GetStockMovementsByProduct(productID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockMovement, error)
+	GetStockMovementsByCompany(companyID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockMovement, error)
+
+	// Stock Balance (warehouseID 0 means all warehouses; unit is an alternate
+	// unit code, "purchase" or "sales" to also show quantities in that unit)
+	GetStockBalance(productID uint, warehouseID uint, unit string) (*models.StockBalance, error)
+	GetAllStockBalances(companyID uint, warehouseID uint, unit string) ([]models.StockBalance, error)
+
+	// Unit of Measure
+	CreateUnitOfMeasure(unit *models.UnitOfMeasure) error
+	GetUnitsOfMeasure(companyID uint) ([]models.UnitOfMeasure, error)
+	UpdateUnitOfMeasure(id uint, unit *models.UnitOfMeasure) error
+	DeleteUnitOfMeasure(id uint) error
+	SetProductUnit(productUnit *models.ProductUnit) error
+	DeleteProductUnit(productID uint, id uint) error
+
+	// Cost Layers
+	GetCostLayerReport(companyID uint, productID uint, warehouseID uint, openOnly bool) ([]models.CostLayerReport, error)
+
+	// Serial Number
+	GetSerialNumbers(companyID uint, productID uint, warehouseID uint, status string) ([]models.SerialNumber, error)
+	GetSerialHistory(companyID uint, serialNumber string) ([]models.SerialHistory, error)
+
+	// Stock Lot (expiringBefore nil means all lots with stock)
+	GetStockLots(companyID uint, productID uint, warehouseID uint, expiringBefore *time.Time) ([]models.StockLot, error)
+
+	// Warehouse
+	CreateWarehouse(warehouse *models.Warehouse) error
+	GetWarehousesByCompany(companyID uint) ([]models.Warehouse, error)
+	UpdateWarehouse(id uint, warehouse *models.Warehouse) error
+	DeleteWarehouse(id uint) error
+
+	// Stock Transfer
+	CreateStockTransfer(transfer *models.StockTransfer) error
+	GetStockTransferByID(id uint) (*models.StockTransfer, error)
+	GetStockTransfersByCompany(companyID uint, status string) ([]models.StockTransfer, error)
+	DispatchStockTransfer(id uint, userID uint) error
+	ReceiveStockTransfer(id uint, userID uint) error
+	CancelStockTransfer(id uint) error
 
-	// Stock Balance
-	GetStockBalance(productID uint) (*models.StockBalance, error)
-	GetAllStockBalances(companyID uint) ([]models.StockBalance, error)
+	// Stock Reservation
+	CreateReservation(reservation *models.StockReservation) error
+	GetReservationByID(id uint) (*models.StockReservation, error)
+	GetReservations(companyID uint, productID uint, warehouseID uint, status string) ([]models.StockReservation, error)
+	ReleaseReservation(id uint) error
+	ExpireReservations(companyID uint) error
+	GetAvailableToPromise(companyID uint, productIDs []uint, warehouseID uint) ([]models.AvailableToPromise, error)
+
+	// Landed Cost
+	CreateLandedCost(landedCost *models.LandedCost) error
+	GetLandedCostByID(id uint) (*models.LandedCost, error)
+	GetLandedCostsByCompany(companyID uint, status string) ([]models.LandedCost, error)
+	PostLandedCost(id uint, userID uint) error
+	CancelLandedCost(id uint) error
+
+	// Account Mapping & GL
+	CreateAccountMapping(mapping *models.InventoryAccountMapping) error
+	GetAccountMappingsByCompany(companyID uint) ([]models.InventoryAccountMapping, error)
+	UpdateAccountMapping(id uint, mapping *models.InventoryAccountMapping) error
+	DeleteAccountMapping(id uint) error
+	GetGLReconciliation(companyID uint, asOfDate time.Time) (*models.InventoryReconciliation, error)
 
 	// Stock Opname
-	CreateStockOpname(opname *models.StockOpname) error
+	CreateStockOpname(opname *models.StockOpname, productIDs []uint) error
 	GetStockOpnameByID(id uint) (*models.StockOpname, error)
-	GetStockOpnamesByCompany(companyID uint) ([]models.StockOpname, error)
+	GetStockOpnamesByCompany(companyID uint, status string) ([]models.StockOpname, error)
+	GetOpnameCounts(opnameID uint, round int) ([]models.StockOpnameCount, error)
+	SubmitOpnameCounts(opnameID uint, batch string, counts []models.StockOpnameCount, countedBy uint) error
+	ReviewStockOpname(id uint) (*models.StockOpname, error)
 	ApproveStockOpname(id uint, approvedBy uint) error
+	CancelStockOpname(id uint) error
+
+	// Inventory Reports
+	GetStockCard(productID uint, warehouseID uint, startDate, endDate time.Time) (*models.StockCard, error)
+	GetInventoryValuation(companyID uint, warehouseID uint, asOfDate time.Time) (*models.InventoryValuation, error)
+	GetInventoryAging(companyID uint, warehouseID uint, slowDays, deadDays int) (*models.InventoryAgingReport, error)
+
+	// Reorder (lookbackDays of stock-out history give the average daily usage)
+	GetReorderSuggestions(companyID uint, lookbackDays int, onlyNeeded bool) ([]models.ReorderSuggestion, error)
 
 	// HPP
-	CalculateHPP(companyID uint, startDate, endDate time.Time) ([]models.HPPCalculation, error)
+	CalculateHPP(companyID uint, warehouseID uint, startDate, endDate time.Time) ([]models.HPPCalculation, error)
 }
 
+// Default chart of accounts codes used when no account mapping applies
+const (
+	defaultInventoryAccountCode        = "1-1400" // Persediaan Barang
+	defaultPurchaseClearingAccountCode = "2-1300" // Pembelian Belum Ditagih
+	defaultCOGSAccountCode             = "5-3000" // Harga Pokok Penjualan
+	defaultInventoryVarianceCode       = "5-4000" // Selisih Persediaan
+)
+
 type inventoryService struct {
-	inventoryRepo repository.InventoryRepository
-	journalRepo   repository.JournalRepository
-	accountRepo   repository.AccountRepository
+	db                  *gorm.DB
+	inventoryRepo       repository.InventoryRepository
+	accountRepo         repository.AccountRepository
+	ledgerRepo          repository.LedgerRepository
+	journalService      JournalService
+	notificationService NotificationService
 }
 
 func NewInventoryService(
+	db *gorm.DB,
 	inventoryRepo repository.InventoryRepository,
-	journalRepo repository.JournalRepository,
 	accountRepo repository.AccountRepository,
+	ledgerRepo repository.LedgerRepository,
+	journalService JournalService,
+	notificationService NotificationService,
 ) InventoryService {
 	return &inventoryService{
-		inventoryRepo: inventoryRepo,
-		journalRepo:   journalRepo,
-		accountRepo:   accountRepo,
+		db:                  db,
+		inventoryRepo:       inventoryRepo,
+		accountRepo:         accountRepo,
+		ledgerRepo:          ledgerRepo,
+		journalService:      journalService,
+		notificationService: notificationService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so everything
+// a movement records commits or rolls back together
+func (s *inventoryService) withTx(tx *gorm.DB) *inventoryService {
+	return &inventoryService{
+		db:                  tx,
+		inventoryRepo:       repository.NewInventoryRepository(tx),
+		accountRepo:         repository.NewAccountRepository(tx),
+		ledgerRepo:          repository.NewLedgerRepository(tx),
+		journalService:      journalServiceWith(tx),
+		notificationService: s.notificationService,
 	}
 }
 
+// inventoryServiceWith returns the inventory service reading and writing
+// through tx, for documents that move stock as part of their own transaction
+func inventoryServiceWith(service InventoryService, tx *gorm.DB) InventoryService {
+	return service.(*inventoryService).withTx(tx)
+}
+
+// inventoryAccounts are the GL accounts a product's movements post to
+type inventoryAccounts struct {
+	Inventory        uint
+	COGS             uint
+	PurchaseClearing uint
+	Variance         uint
+}
+
 // Product methods
 func (s *inventoryService) CreateProduct(product *models.Product) error {
+	if product.IsLotTracked && product.IsSerialTracked {
+		return errors.New("product cannot be both lot and serial tracked")
+	}
 	return s.inventoryRepo.CreateProduct(product)
 }
 
@@ -74,13 +195,31 @@ func (s *inventoryService) UpdateProduct(id uint, updatedProduct *models.Product
 		return errors.New("product not found")
 	}
 
+	if updatedProduct.IsLotTracked && updatedProduct.IsSerialTracked {
+		return errors.New("product cannot be both lot and serial tracked")
+	}
+
+	// Existing stock has no lot or serial numbers to allocate from and is
+	// counted in the current base unit
+	if (updatedProduct.IsLotTracked && !product.IsLotTracked) ||
+		(updatedProduct.IsSerialTracked && !product.IsSerialTracked) ||
+		updatedProduct.Unit != product.Unit {
+		balance, err := s.inventoryRepo.GetProductStockTotal(id)
+		if err != nil {
+			return err
+		}
+		if balance.Quantity != 0 {
+			return errors.New("cannot change base unit or enable lot/serial tracking for product with existing stock")
+		}
+	}
+
 	updatedProduct.ID = product.ID
 	return s.inventoryRepo.UpdateProduct(updatedProduct)
 }
 
 func (s *inventoryService) DeleteProduct(id uint) error {
 	// Check if product has stock balance
-	balance, err := s.inventoryRepo.GetStockBalance(id)
+	balance, err := s.inventoryRepo.GetProductStockTotal(id)
 	if err != nil && err.Error() != "record not found" {
 		return err
 	}
@@ -93,7 +232,137 @@ func (s *inventoryService) DeleteProduct(id uint) error {
 }
 
 // Stock Movement methods
+
+// CreateStockMovement records the movement with its lots, serials, cost
+// layers, balance, reservation and journal in one database transaction
 func (s *inventoryService) CreateStockMovement(movement *models.StockMovement) error {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		return s.withTx(tx).createStockMovement(movement)
+	})
+	if err != nil {
+		return err
+	}
+
+	// Low stock alerts must not fail the movement itself
+	if movement.Type == "out" || movement.Type == "adjustment" {
+		_ = s.notificationService.CheckProductStockLevel(movement.ProductID)
+	}
+
+	return nil
+}
+
+func (s *inventoryService) createStockMovement(movement *models.StockMovement) error {
+	if movement.Type != "adjustment" && movement.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+
+	product, err := s.inventoryRepo.FindProductByID(movement.ProductID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+
+	if err := ConvertToBaseUnit(product, movement); err != nil {
+		return err
+	}
+
+	accounts, err := s.resolveAccounts(product)
+	if err != nil {
+		return err
+	}
+
+	// Movements without a location go to the company's default warehouse
+	if movement.WarehouseID == 0 {
+		warehouse, err := s.ensureDefaultWarehouse(movement.CompanyID)
+		if err != nil {
+			return err
+		}
+		movement.WarehouseID = warehouse.ID
+	} else {
+		warehouse, err := s.inventoryRepo.FindWarehouseByID(movement.WarehouseID)
+		if err != nil || warehouse.CompanyID != movement.CompanyID {
+			return errors.New("warehouse not found")
+		}
+		if !warehouse.IsActive {
+			return errors.New("warehouse is inactive")
+		}
+	}
+
+	// Products being counted are frozen until their opname is approved or cancelled
+	if counting, err := s.inventoryRepo.FindCountingOpname(movement.ProductID, movement.WarehouseID); err == nil {
+		if movement.OpnameID == nil || *movement.OpnameID != counting.ID {
+			return fmt.Errorf("product is frozen for stock opname %s", counting.OpnameNumber)
+		}
+	}
+
+	balance, err := s.inventoryRepo.GetStockBalance(movement.ProductID, movement.WarehouseID)
+	if err != nil {
+		return err
+	}
+
+	// Plan which cost layers this movement draws from before saving it,
+	// so stock-out and shrinking adjustments are costed by the product's method
+	var consumptions []models.StockLayerConsumption
+	var reservation *models.StockReservation
+	var reservedQuantity float64
+	switch movement.Type {
+	case "in", "transfer_in":
+		movement.TotalCost = movement.Quantity * movement.UnitCost
+	case "out", "transfer_out":
+		reservation, reservedQuantity, err = s.checkAvailableStock(movement, balance, movement.Quantity)
+		if err != nil {
+			return err
+		}
+		if movement.SourceMovementID != nil {
+			// Goods returned to the supplier leave from the layer they came
+			// in on, transfers leave transit from the layers they came in on
+			consumptions, err = s.planReturnConsumption(product, balance, movement)
+		} else {
+			consumptions, err = s.planLayerConsumption(product, balance, movement.Quantity, movement.MovementDate)
+		}
+		if err != nil {
+			return err
+		}
+		movement.TotalCost = sumConsumptionCost(consumptions)
+		movement.UnitCost = movement.TotalCost / movement.Quantity
+	case "adjustment":
+		if movement.Quantity < balance.Quantity {
+			// Counted stock below what is reserved needs those
+			// reservations released first
+			if _, _, err = s.checkAvailableStock(movement, balance, balance.Quantity-movement.Quantity); err != nil {
+				return err
+			}
+			consumptions, err = s.planLayerConsumption(product, balance, balance.Quantity-movement.Quantity, movement.MovementDate)
+			if err != nil {
+				return err
+			}
+		}
+		if movement.UnitCost == 0 {
+			movement.UnitCost = balance.AverageCost
+		}
+		movement.TotalCost = movement.Quantity * movement.UnitCost
+	default:
+		return errors.New("invalid movement type")
+	}
+
+	// Lot-tracked products also move stock lot by lot
+	var lotAllocations []models.StockLotAllocation
+	if product.IsLotTracked {
+		lotAllocations, err = s.planLotAllocation(movement, balance)
+		if err != nil {
+			return err
+		}
+	}
+	movement.LotAllocations = nil
+
+	// Serial-tracked products name every unit that moves
+	var serialChanges []serialChange
+	if product.IsSerialTracked {
+		serialChanges, err = s.planSerialChanges(movement)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Generate movement number
 	movementNumber, err := s.inventoryRepo.GenerateMovementNumber(
 		movement.CompanyID,
@@ -105,16 +374,38 @@ func (s *inventoryService) CreateStockMovement(movement *models.StockMovement) e
 	}
 	movement.MovementNumber = movementNumber
 
-	// Calculate total cost
-	movement.TotalCost = movement.Quantity * movement.UnitCost
-
 	// Create movement
 	if err := s.inventoryRepo.CreateStockMovement(movement); err != nil {
 		return err
 	}
 
+	if err := s.applyLotAllocations(movement, balance, lotAllocations); err != nil {
+		return err
+	}
+
+	if err := s.applySerialChanges(movement, serialChanges); err != nil {
+		return err
+	}
+
+	// Record new cost layers or consume existing ones
+	valueChange, err := s.applyCostLayers(movement, balance, consumptions)
+	if err != nil {
+		return err
+	}
+
 	// Update stock balance
-	return s.updateStockBalance(movement)
+	if err := s.updateStockBalance(movement, balance, valueChange); err != nil {
+		return err
+	}
+
+	if reservation != nil {
+		if err := s.fulfillReservation(reservation, reservedQuantity); err != nil {
+			return err
+		}
+	}
+
+	// Post the value change to the general ledger
+	return s.postMovementJournal(movement, product, accounts, valueChange)
 }
 
 func (s *inventoryService) CreateStockIn(movement *models.StockMovement) error {
@@ -123,155 +414,2769 @@ func (s *inventoryService) CreateStockIn(movement *models.StockMovement) error {
 }
 
 func (s *inventoryService) CreateStockOut(movement *models.StockMovement) error {
-	// Check if stock is sufficient
-	balance, err := s.inventoryRepo.GetStockBalance(movement.ProductID)
+	// Stock sufficiency in the warehouse is checked while planning the
+	// cost layers; unit cost is derived from the consumed layers
+	movement.Type = "out"
+	return s.CreateStockMovement(movement)
+}
+
+// CreateStockReturn reverses part of a stock-out or stock-in at its original
+// cost: goods sold come back in at the cost they were issued at and goods
+// received go back out of the layer they created. The movement's quantity is
+// in the unit of the source movement; lots and serial numbers default to
+// those of the source, and the journal is posted against the account the
+// source movement was posted to (COGS or purchase clearing).
+func (s *inventoryService) CreateStockReturn(movement *models.StockMovement, sourceMovementID uint) error {
+	source, err := s.inventoryRepo.FindStockMovementByID(sourceMovementID)
+	if err != nil || source.CompanyID != movement.CompanyID {
+		return errors.New("source movement not found")
+	}
+	if source.Type != "in" && source.Type != "out" {
+		return errors.New("only stock-in and stock-out movements can be returned")
+	}
+	if movement.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+
+	returned, err := s.inventoryRepo.SumReturnedQuantity(source.ID)
 	if err != nil {
 		return err
 	}
+	factor := source.ConversionFactor
+	if factor == 0 {
+		factor = 1
+	}
+	if movement.Quantity*factor > source.Quantity-returned+0.001 {
+		return fmt.Errorf("only %.2f %s of %s can still be returned", (source.Quantity-returned)/factor, source.Unit, source.MovementNumber)
+	}
 
-	if balance.Quantity < movement.Quantity {
-		return errors.New("insufficient stock")
+	movement.ProductID = source.ProductID
+	movement.Unit = source.Unit
+	movement.SourceMovementID = &source.ID
+	if movement.WarehouseID == 0 {
+		movement.WarehouseID = source.WarehouseID
 	}
 
-	movement.Type = "out"
-	// For stock out, use average cost from balance
-	movement.UnitCost = balance.AverageCost
-	movement.TotalCost = movement.Quantity * movement.UnitCost
+	if source.Type == "out" {
+		movement.Type = "in"
+		movement.UnitCost = source.UnitCost * factor
+	} else {
+		movement.Type = "out"
+	}
+
+	// Post against the account the source was posted to: the debit of a
+	// stock-out or the credit of a stock-in
+	if source.JournalID != nil {
+		journal, err := s.journalService.GetJournalByID(*source.JournalID)
+		if err != nil {
+			return err
+		}
+		for _, entry := range journal.Entries {
+			if (source.Type == "out" && entry.Debit > 0) || (source.Type == "in" && entry.Credit > 0) {
+				accountID := entry.AccountID
+				movement.OffsetAccountID = &accountID
+				break
+			}
+		}
+	}
+
+	if source.Product.IsLotTracked && movement.LotNumber == "" && len(movement.LotAllocations) == 0 {
+		lots, err := s.inventoryRepo.FindLotAllocationsByMovement(source.ID)
+		if err != nil {
+			return err
+		}
+		if len(lots) == 1 {
+			if movement.Type == "in" {
+				movement.LotNumber = lots[0].Lot.LotNumber
+				movement.ExpiryDate = lots[0].Lot.ExpiryDate
+			} else {
+				movement.LotAllocations = []models.StockLotAllocation{{LotID: lots[0].LotID, Quantity: movement.Quantity * factor}}
+			}
+		}
+	}
+
+	if source.Product.IsSerialTracked {
+		serials, err := s.inventoryRepo.FindSerialsByMovement(source.ID)
+		if err != nil {
+			return err
+		}
+		moved := make(map[string]bool)
+		for _, serial := range serials {
+			moved[serial.SerialNumber] = true
+		}
+		for _, number := range movement.SerialNumbers {
+			if !moved[strings.TrimSpace(number)] {
+				return errors.New("serial number " + number + " was not moved by " + source.MovementNumber)
+			}
+		}
+	}
 
 	return s.CreateStockMovement(movement)
 }
 
-func (s *inventoryService) updateStockBalance(movement *models.StockMovement) error {
-	balance, err := s.inventoryRepo.GetStockBalance(movement.ProductID)
+// planReturnConsumption takes a stock-out from the layers its source
+// movement created: goods returned to the supplier leave from the layer they
+// came in on, and a transfer leaves transit from the layers its dispatch
+// brought in, not from stock other transfers have in transit.
+func (s *inventoryService) planReturnConsumption(product *models.Product, balance *models.StockBalance, movement *models.StockMovement) ([]models.StockLayerConsumption, error) {
+	if balance.Quantity < movement.Quantity {
+		return nil, errors.New("insufficient stock")
+	}
+
+	layers, err := s.inventoryRepo.FindCostLayersByMovement(*movement.SourceMovementID)
+	if err != nil {
+		return nil, err
+	}
+	if len(layers) == 0 {
+		return nil, errors.New("cost layer of the source movement not found")
+	}
+
+	var available float64
+	for _, layer := range layers {
+		if layer.WarehouseID != movement.WarehouseID {
+			return nil, errors.New("goods must leave from the warehouse they were received in")
+		}
+		available += layer.RemainingQuantity
+	}
+	if available < movement.Quantity-0.001 {
+		return nil, fmt.Errorf("only %.2f %s of the received goods are still in stock", available, product.Unit)
+	}
+
+	var consumptions []models.StockLayerConsumption
+	remaining := movement.Quantity
+	for _, layer := range layers {
+		if remaining <= 0.001 {
+			break
+		}
+		quantity := math.Min(remaining, layer.RemainingQuantity)
+		if quantity <= 0 {
+			continue
+		}
+		consumptions = append(consumptions, models.StockLayerConsumption{
+			CompanyID:    product.CompanyID,
+			ProductID:    product.ID,
+			LayerID:      layer.ID,
+			ConsumedDate: movement.MovementDate,
+			Quantity:     quantity,
+			UnitCost:     layer.UnitCost,
+			TotalCost:    quantity * layer.UnitCost,
+		})
+		remaining -= quantity
+	}
+	return consumptions, nil
+}
+
+// planLayerConsumption picks the open cost layers a stock-out of quantity
+// draws from, ordered by the product's cost method. The only thing saved is
+// the opening layer covering stock received before cost layers existed; it
+// stands for stock already on hand, so it is kept if the movement fails and
+// found again on the next attempt.
+func (s *inventoryService) planLayerConsumption(product *models.Product, balance *models.StockBalance, quantity float64, date time.Time) ([]models.StockLayerConsumption, error) {
+	if balance.Quantity < quantity {
+		return nil, errors.New("insufficient stock")
+	}
+
+	method := product.CostMethod
+	if method == "" {
+		method = models.CostMethodFIFO
+	}
+
+	layers, err := s.inventoryRepo.FindOpenCostLayers(product.ID, balance.WarehouseID, method)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// If balance doesn't exist, create new
-	if balance.ID == 0 {
-		balance.CompanyID = movement.CompanyID
-		balance.ProductID = movement.ProductID
-		balance.Quantity = 0
-		balance.AverageCost = 0
-		balance.TotalValue = 0
+	// Stock received before cost layers existed has no layer yet;
+	// cover the gap with an opening layer at the current average cost
+	var layered float64
+	for _, layer := range layers {
+		layered += layer.RemainingQuantity
+	}
+	if gap := balance.Quantity - layered; gap > 0 {
+		opening := models.StockCostLayer{
+			CompanyID:         product.CompanyID,
+			ProductID:         product.ID,
+			WarehouseID:       balance.WarehouseID,
+			ReceivedDate:      balance.CreatedAt,
+			Quantity:          gap,
+			RemainingQuantity: gap,
+			UnitCost:          balance.AverageCost,
+		}
+		if err := s.inventoryRepo.CreateCostLayer(&opening); err != nil {
+			return nil, err
+		}
+		layers = append(layers, opening)
 	}
 
-	// Update balance based on movement type
-	if movement.Type == "in" {
-		// Weighted average cost method
-		totalValue := balance.TotalValue + movement.TotalCost
-		totalQty := balance.Quantity + movement.Quantity
-		balance.Quantity = totalQty
-		balance.TotalValue = totalValue
-		if totalQty > 0 {
-			balance.AverageCost = totalValue / totalQty
-		}
-	} else if movement.Type == "out" {
-		balance.Quantity -= movement.Quantity
-		balance.TotalValue -= movement.TotalCost
-		if balance.Quantity < 0 {
-			return errors.New("negative stock not allowed")
+	return ConsumeCostLayers(layers, method, balance.AverageCost, quantity, date)
+}
+
+// ConsumeCostLayers takes quantity from the open layers in the order of the
+// cost method: FIFO and average from the oldest receipt, LIFO from the
+// newest, with opening layers the oldest of all. Average cost issues every
+// unit at the warehouse's average cost rather than the layer's.
+func ConsumeCostLayers(layers []models.StockCostLayer, method models.CostMethod, averageCost float64, quantity float64, date time.Time) ([]models.StockLayerConsumption, error) {
+	ordered := append([]models.StockCostLayer(nil), layers...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if method == models.CostMethodLIFO {
+			return layerBefore(&ordered[j], &ordered[i])
 		}
-	} else if movement.Type == "adjustment" {
-		// For adjustment, recalculate everything
-		balance.Quantity = movement.Quantity
-		balance.TotalValue = movement.TotalCost
-		if balance.Quantity > 0 {
-			balance.AverageCost = balance.TotalValue / balance.Quantity
+		return layerBefore(&ordered[i], &ordered[j])
+	})
+
+	var consumptions []models.StockLayerConsumption
+	remaining := quantity
+	for _, layer := range ordered {
+		if remaining <= 0 {
+			break
 		}
+		if layer.RemainingQuantity <= 0 {
+			continue
+		}
+
+		take := math.Min(layer.RemainingQuantity, remaining)
+		unitCost := layer.UnitCost
+		if method == models.CostMethodAverage {
+			unitCost = averageCost
+		}
+
+		consumptions = append(consumptions, models.StockLayerConsumption{
+			CompanyID:    layer.CompanyID,
+			ProductID:    layer.ProductID,
+			LayerID:      layer.ID,
+			ConsumedDate: date,
+			Quantity:     take,
+			UnitCost:     unitCost,
+			TotalCost:    take * unitCost,
+		})
+		remaining -= take
 	}
 
-	return s.inventoryRepo.UpdateStockBalance(balance)
+	if remaining > 0.001 {
+		return nil, errors.New("insufficient cost layers")
+	}
+
+	return consumptions, nil
 }
 
-func (s *inventoryService) GetStockMovementByID(id uint) (*models.StockMovement, error) {
-	return s.inventoryRepo.FindStockMovementByID(id)
+// layerBefore orders cost layers by their original receipt, opening layers
+// first
+func layerBefore(a, b *models.StockCostLayer) bool {
+	if (a.MovementID == nil) != (b.MovementID == nil) {
+		return a.MovementID == nil
+	}
+	if originA, originB := layerOrigin(a), layerOrigin(b); !originA.Equal(originB) {
+		return originA.Before(originB)
+	}
+	return a.ID < b.ID
 }
 
-func (s *inventoryService) GetStockMovementsByProduct(productID uint, startDate, endDate time.Time) ([]models.StockMovement, error) {
-	return s.inventoryRepo.FindStockMovementsByProduct(productID, startDate, endDate)
+// layerOrigin is when the layer's stock first came in, before any transfers
+func layerOrigin(layer *models.StockCostLayer) time.Time {
+	if layer.OriginDate != nil {
+		return *layer.OriginDate
+	}
+	return layer.ReceivedDate
 }
 
-func (s *inventoryService) GetStockMovementsByCompany(companyID uint, startDate, endDate time.Time) ([]models.StockMovement, error) {
-	return s.inventoryRepo.FindStockMovementsByCompany(companyID, startDate, endDate)
+// planLotAllocation decides which lots a movement adds to or draws from.
+// Receipts need a lot number; issues use the lots selected on the movement
+// or fall back to first-expired-first-out.
+func (s *inventoryService) planLotAllocation(movement *models.StockMovement, balance *models.StockBalance) ([]models.StockLotAllocation, error) {
+	switch movement.Type {
+	case "in":
+		return s.receiveIntoLot(movement, movement.Quantity)
+	case "transfer_in":
+		if movement.SourceMovementID == nil {
+			return nil, errors.New("transfer receipt requires a source movement")
+		}
+		sources, err := s.inventoryRepo.FindLotAllocationsByMovement(*movement.SourceMovementID)
+		if err != nil {
+			return nil, err
+		}
+		var allocations []models.StockLotAllocation
+		for _, source := range sources {
+			lot, err := s.findOrCreateLot(movement, source.Lot.LotNumber, source.Lot.ExpiryDate)
+			if err != nil {
+				return nil, err
+			}
+			allocations = append(allocations, models.StockLotAllocation{LotID: lot.ID, Quantity: source.Quantity})
+		}
+		return allocations, nil
+	case "out", "transfer_out":
+		return s.allocateLots(movement, movement.Quantity, movement.Type == "out")
+	case "adjustment":
+		if movement.Quantity > balance.Quantity {
+			return s.receiveIntoLot(movement, movement.Quantity-balance.Quantity)
+		}
+		if movement.Quantity < balance.Quantity {
+			return s.allocateLots(movement, balance.Quantity-movement.Quantity, false)
+		}
+	}
+	return nil, nil
 }
 
-// Stock Balance methods
-func (s *inventoryService) GetStockBalance(productID uint) (*models.StockBalance, error) {
-	return s.inventoryRepo.GetStockBalance(productID)
+func (s *inventoryService) receiveIntoLot(movement *models.StockMovement, quantity float64) ([]models.StockLotAllocation, error) {
+	if movement.LotNumber == "" {
+		return nil, errors.New("lot number is required for lot-tracked products")
+	}
+
+	lot, err := s.findOrCreateLot(movement, movement.LotNumber, movement.ExpiryDate)
+	if err != nil {
+		return nil, err
+	}
+
+	return []models.StockLotAllocation{{LotID: lot.ID, Quantity: quantity}}, nil
 }
 
-func (s *inventoryService) GetAllStockBalances(companyID uint) ([]models.StockBalance, error) {
-	return s.inventoryRepo.GetAllStockBalances(companyID)
+func (s *inventoryService) findOrCreateLot(movement *models.StockMovement, lotNumber string, expiryDate *time.Time) (*models.StockLot, error) {
+	lot, err := s.inventoryRepo.FindLotByNumber(movement.ProductID, movement.WarehouseID, lotNumber)
+	if err == nil {
+		if expiryDate != nil && (lot.ExpiryDate == nil || !lot.ExpiryDate.Equal(*expiryDate)) {
+			return nil, errors.New("expiry date does not match existing lot " + lotNumber)
+		}
+		return lot, nil
+	}
+
+	lot = &models.StockLot{
+		CompanyID:   movement.CompanyID,
+		ProductID:   movement.ProductID,
+		WarehouseID: movement.WarehouseID,
+		LotNumber:   lotNumber,
+		ExpiryDate:  expiryDate,
+	}
+	return lot, s.inventoryRepo.CreateLot(lot)
 }
 
-// Stock Opname methods
-func (s *inventoryService) CreateStockOpname(opname *models.StockOpname) error {
-	// Generate opname number
-	opnameNumber, err := s.inventoryRepo.GenerateOpnameNumber(opname.CompanyID, opname.OpnameDate)
+// allocateLots draws quantity from the lots selected on the movement, or
+// FEFO from the warehouse's lots. Sales skip lots already expired.
+func (s *inventoryService) allocateLots(movement *models.StockMovement, quantity float64, skipExpired bool) ([]models.StockLotAllocation, error) {
+	if len(movement.LotAllocations) > 0 {
+		var selected float64
+		for _, allocation := range movement.LotAllocations {
+			lot, err := s.inventoryRepo.FindLotByID(allocation.LotID)
+			if err != nil || lot.ProductID != movement.ProductID || lot.WarehouseID != movement.WarehouseID {
+				return nil, errors.New("lot not found in warehouse")
+			}
+			if allocation.Quantity <= 0 || lot.Quantity < allocation.Quantity {
+				return nil, errors.New("insufficient stock in lot " + lot.LotNumber)
+			}
+			selected += allocation.Quantity
+		}
+		if math.Abs(selected-quantity) > 0.001 {
+			return nil, errors.New("selected lot quantities must equal movement quantity")
+		}
+		return movement.LotAllocations, nil
+	}
+
+	lots, err := s.inventoryRepo.FindAvailableLots(movement.ProductID, movement.WarehouseID)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	opname.OpnameNumber = opnameNumber
 
-	// Calculate differences for each item
-	for i := range opname.Items {
-		opname.Items[i].Difference = opname.Items[i].PhysicalQuantity - opname.Items[i].SystemQuantity
+	return AllocateLotsFEFO(lots, quantity, movement.MovementDate, skipExpired)
+}
+
+// AllocateLotsFEFO draws quantity from the lots that expire first, lots
+// without an expiry date last. With skipExpired, lots expired before the
+// date are passed over.
+func AllocateLotsFEFO(lots []models.StockLot, quantity float64, date time.Time, skipExpired bool) ([]models.StockLotAllocation, error) {
+	ordered := append([]models.StockLot(nil), lots...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		a, b := ordered[i].ExpiryDate, ordered[j].ExpiryDate
+		switch {
+		case a == nil || b == nil:
+			return a != nil && b == nil
+		case !a.Equal(*b):
+			return a.Before(*b)
+		}
+		return ordered[i].ID < ordered[j].ID
+	})
+
+	var allocations []models.StockLotAllocation
+	remaining := quantity
+	for _, lot := range ordered {
+		if remaining <= 0 {
+			break
+		}
+		if lot.Quantity <= 0 {
+			continue
+		}
+		if skipExpired && lot.ExpiryDate != nil && lot.ExpiryDate.Before(date) {
+			continue
+		}
+
+		take := math.Min(lot.Quantity, remaining)
+		allocations = append(allocations, models.StockLotAllocation{LotID: lot.ID, Quantity: take})
+		remaining -= take
 	}
 
-	return s.inventoryRepo.CreateStockOpname(opname)
+	if remaining > 0.001 {
+		return nil, errors.New("insufficient unexpired lot stock")
+	}
+
+	return allocations, nil
 }
 
-func (s *inventoryService) GetStockOpnameByID(id uint) (*models.StockOpname, error) {
-	return s.inventoryRepo.FindStockOpnameByID(id)
+// applyLotAllocations saves the planned allocations of a saved movement
+// and updates the lot quantities
+func (s *inventoryService) applyLotAllocations(movement *models.StockMovement, balance *models.StockBalance, allocations []models.StockLotAllocation) error {
+	inbound := movement.Type == "in" || movement.Type == "transfer_in" ||
+		(movement.Type == "adjustment" && movement.Quantity > balance.Quantity)
+
+	for i := range allocations {
+		allocation := &allocations[i]
+		allocation.ID = 0
+		allocation.MovementID = movement.ID
+		if err := s.inventoryRepo.CreateLotAllocation(allocation); err != nil {
+			return err
+		}
+
+		delta := allocation.Quantity
+		if !inbound {
+			delta = -delta
+		}
+		if err := s.inventoryRepo.AdjustLotQuantity(allocation.LotID, delta); err != nil {
+			return err
+		}
+	}
+
+	movement.LotAllocations = allocations
+	return nil
 }
 
-func (s *inventoryService) GetStockOpnamesByCompany(companyID uint) ([]models.StockOpname, error) {
-	return s.inventoryRepo.FindStockOpnamesByCompany(companyID)
+// serialChange is the planned status change of one serial-tracked unit
+type serialChange struct {
+	serial *models.SerialNumber
+	event  string
 }
 
-func (s *inventoryService) ApproveStockOpname(id uint, approvedBy uint) error {
-	opname, err := s.inventoryRepo.FindStockOpnameByID(id)
-	if err != nil {
-		return errors.New("stock opname not found")
+// planSerialChanges validates the serial numbers of a movement against the
+// units on hand. Receipts must be new or previously issued units, issues
+// must be in stock in the warehouse, and an adjustment lists every unit
+// counted so missing ones are written off and unknown ones found.
+func (s *inventoryService) planSerialChanges(movement *models.StockMovement) ([]serialChange, error) {
+	// Transfer receipts take the units of the source movement
+	if movement.Type == "transfer_in" {
+		if movement.SourceMovementID == nil {
+			return nil, errors.New("transfer receipt requires a source movement")
+		}
+		serials, err := s.inventoryRepo.FindSerialsByMovement(*movement.SourceMovementID)
+		if err != nil {
+			return nil, err
+		}
+		var changes []serialChange
+		movement.SerialNumbers = nil
+		for i := range serials {
+			changes = append(changes, serialChange{serial: &serials[i], event: "moved_in"})
+			movement.SerialNumbers = append(movement.SerialNumbers, serials[i].SerialNumber)
+		}
+		return changes, nil
 	}
 
-	if opname.Status != "draft" {
-		return errors.New("only draft stock opname can be approved")
+	if float64(len(movement.SerialNumbers)) != movement.Quantity {
+		return nil, errors.New("number of serial numbers must equal quantity")
 	}
 
-	// Approve opname
-	if err := s.inventoryRepo.ApproveStockOpname(id, approvedBy); err != nil {
-		return err
+	seen := make(map[string]bool)
+	for i, number := range movement.SerialNumbers {
+		number = strings.TrimSpace(number)
+		if number == "" {
+			return nil, errors.New("serial number cannot be empty")
+		}
+		if seen[number] {
+			return nil, errors.New("duplicate serial number " + number)
+		}
+		seen[number] = true
+		movement.SerialNumbers[i] = number
 	}
 
-	// Create adjustment movements for differences
-	for _, item := range opname.Items {
-		if item.Difference != 0 {
-			movement := &models.StockMovement{
-				CompanyID:      opname.CompanyID,
-				ProductID:      item.ProductID,
-				MovementDate:   opname.OpnameDate,
-				Type:           "adjustment",
-				Quantity:       item.PhysicalQuantity,
-				Reference:      opname.OpnameNumber,
-				Notes:          "Stock opname adjustment: " + item.Notes,
-				CreatedBy:      approvedBy,
-			}
-
-			// Get current balance for unit cost
-			balance, _ := s.inventoryRepo.GetStockBalance(item.ProductID)
-			movement.UnitCost = balance.AverageCost
-			movement.TotalCost = item.PhysicalQuantity * balance.AverageCost
+	var changes []serialChange
+	switch movement.Type {
+	case "in":
+		for _, number := range movement.SerialNumbers {
+			serial, err := s.inventoryRepo.FindSerial(movement.ProductID, number)
+			if err != nil {
+				changes = append(changes, serialChange{serial: s.newSerial(movement, number), event: "received"})
+				continue
+			}
+			if serial.Status == models.SerialStatusInStock {
+				return nil, errors.New("serial number " + number + " is already in stock")
+			}
+			changes = append(changes, serialChange{serial: serial, event: "returned"})
+		}
+	case "out", "transfer_out":
+		event := "sold"
+		if movement.Type == "transfer_out" {
+			event = "moved_out"
+		}
+		for _, number := range movement.SerialNumbers {
+			serial, err := s.inventoryRepo.FindSerial(movement.ProductID, number)
+			if err != nil || serial.Status != models.SerialStatusInStock || serial.WarehouseID != movement.WarehouseID {
+				return nil, errors.New("serial number " + number + " is not in stock in this warehouse")
+			}
+			changes = append(changes, serialChange{serial: serial, event: event})
+		}
+	case "adjustment":
+		onHand, err := s.inventoryRepo.FindSerials(movement.CompanyID, movement.ProductID, movement.WarehouseID, models.SerialStatusInStock)
+		if err != nil {
+			return nil, err
+		}
 
-			s.CreateStockMovement(movement)
+		counted := make(map[string]bool)
+		for _, number := range movement.SerialNumbers {
+			counted[number] = true
 		}
-	}
 
-	return nil
+		onHandNumbers := make(map[string]bool)
+		for i := range onHand {
+			onHandNumbers[onHand[i].SerialNumber] = true
+			if !counted[onHand[i].SerialNumber] {
+				changes = append(changes, serialChange{serial: &onHand[i], event: "written_off"})
+			}
+		}
+
+		for _, number := range movement.SerialNumbers {
+			if onHandNumbers[number] {
+				continue
+			}
+			serial, err := s.inventoryRepo.FindSerial(movement.ProductID, number)
+			if err != nil {
+				changes = append(changes, serialChange{serial: s.newSerial(movement, number), event: "found"})
+				continue
+			}
+			if serial.Status == models.SerialStatusInStock {
+				return nil, errors.New("serial number " + number + " is in stock in another warehouse")
+			}
+			changes = append(changes, serialChange{serial: serial, event: "found"})
+		}
+	}
+
+	return changes, nil
+}
+
+func (s *inventoryService) newSerial(movement *models.StockMovement, number string) *models.SerialNumber {
+	return &models.SerialNumber{
+		CompanyID:    movement.CompanyID,
+		ProductID:    movement.ProductID,
+		SerialNumber: number,
+	}
+}
+
+// applySerialChanges moves the units of a saved movement and records
+// their history
+func (s *inventoryService) applySerialChanges(movement *models.StockMovement, changes []serialChange) error {
+	for _, change := range changes {
+		serial := change.serial
+		serial.WarehouseID = movement.WarehouseID
+		serial.LastMovementID = movement.ID
+
+		switch change.event {
+		case "received", "returned", "moved_in", "found":
+			serial.Status = models.SerialStatusInStock
+		default:
+			serial.Status = models.SerialStatusOut
+		}
+
+		var err error
+		if serial.ID == 0 {
+			err = s.inventoryRepo.CreateSerial(serial)
+		} else {
+			err = s.inventoryRepo.UpdateSerial(serial)
+		}
+		if err != nil {
+			return err
+		}
+
+		event := &models.SerialNumberEvent{
+			SerialID:    serial.ID,
+			MovementID:  movement.ID,
+			WarehouseID: movement.WarehouseID,
+			Event:       change.event,
+			Party:       movement.Party,
+			EventDate:   movement.MovementDate,
+		}
+		if err := s.inventoryRepo.CreateSerialEvent(event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// applyCostLayers persists the layer changes of a saved movement and
+// returns the resulting change in inventory value
+func (s *inventoryService) applyCostLayers(movement *models.StockMovement, balance *models.StockBalance, consumptions []models.StockLayerConsumption) (float64, error) {
+	var valueChange float64
+
+	// Transfer receipts keep the cost and age of the layers that left the source
+	if movement.Type == "transfer_in" {
+		return s.receiveTransferLayers(movement)
+	}
+
+	receipt := movement.Quantity
+	if movement.Type == "adjustment" {
+		receipt = movement.Quantity - balance.Quantity
+	}
+
+	if (movement.Type == "in" || movement.Type == "adjustment") && receipt > 0 {
+		layer := &models.StockCostLayer{
+			CompanyID:         movement.CompanyID,
+			ProductID:         movement.ProductID,
+			WarehouseID:       movement.WarehouseID,
+			MovementID:        &movement.ID,
+			ReceivedDate:      movement.MovementDate,
+			Quantity:          receipt,
+			RemainingQuantity: receipt,
+			UnitCost:          movement.UnitCost,
+		}
+		if err := s.inventoryRepo.CreateCostLayer(layer); err != nil {
+			return 0, err
+		}
+		valueChange += receipt * movement.UnitCost
+	}
+
+	for _, consumption := range consumptions {
+		consumption.MovementID = movement.ID
+		if err := s.inventoryRepo.CreateLayerConsumption(&consumption); err != nil {
+			return 0, err
+		}
+
+		if err := s.inventoryRepo.ConsumeCostLayer(consumption.LayerID, consumption.Quantity); err != nil {
+			return 0, err
+		}
+		valueChange -= consumption.TotalCost
+	}
+
+	return valueChange, nil
+}
+
+// postMovementJournal creates and posts the journal for a movement:
+// stock-in Dr inventory / Cr purchase clearing, stock-out Dr COGS / Cr inventory,
+// adjustments against the inventory variance account. A movement's offset
+// account replaces purchase clearing or COGS.
+func (s *inventoryService) postMovementJournal(movement *models.StockMovement, product *models.Product, accounts *inventoryAccounts, valueChange float64) error {
+	// Transfers stay within the same inventory account
+	if movement.Type == "transfer_out" || movement.Type == "transfer_in" {
+		return nil
+	}
+
+	amount := math.Round(math.Abs(valueChange)*100) / 100
+	if amount == 0 {
+		return nil
+	}
+
+	var debitAccountID, creditAccountID uint
+	var description string
+	switch {
+	case movement.Type == "in":
+		debitAccountID, creditAccountID = accounts.Inventory, accounts.PurchaseClearing
+		if movement.OffsetAccountID != nil {
+			creditAccountID = *movement.OffsetAccountID
+		}
+		description = "Stock in " + movement.MovementNumber + " - " + product.Name
+	case movement.Type == "out":
+		debitAccountID, creditAccountID = accounts.COGS, accounts.Inventory
+		if movement.OffsetAccountID != nil {
+			debitAccountID = *movement.OffsetAccountID
+		}
+		description = "Stock out " + movement.MovementNumber + " - " + product.Name
+	case valueChange > 0:
+		debitAccountID, creditAccountID = accounts.Inventory, accounts.Variance
+		description = "Stock adjustment " + movement.MovementNumber + " - " + product.Name
+	default:
+		debitAccountID, creditAccountID = accounts.Variance, accounts.Inventory
+		description = "Stock adjustment " + movement.MovementNumber + " - " + product.Name
+	}
+
+	journal := &models.Journal{
+		CompanyID:       movement.CompanyID,
+		TransactionDate: movement.MovementDate,
+		Description:     description,
+		CreatedBy:       movement.CreatedBy,
+		Entries: []models.JournalEntry{
+			{
+				AccountID:   debitAccountID,
+				Description: description,
+				Debit:       amount,
+				Position:    1,
+			},
+			{
+				AccountID:   creditAccountID,
+				Description: description,
+				Credit:      amount,
+				Position:    2,
+			},
+		},
+	}
+
+	if err := s.journalService.CreateJournal(journal); err != nil {
+		return err
+	}
+	if err := s.journalService.PostJournal(journal.ID, movement.CreatedBy); err != nil {
+		return err
+	}
+
+	// Link movement to journal
+	movement.JournalID = &journal.ID
+	return s.inventoryRepo.SetMovementJournal(movement.ID, journal.ID)
+}
+
+// resolveAccounts picks each account from the product mapping, then the
+// category mapping, then the company default mapping, then the default chart
+func (s *inventoryService) resolveAccounts(product *models.Product) (*inventoryAccounts, error) {
+	mappings, err := s.inventoryRepo.FindAccountMappingsByCompany(product.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var productMapping, categoryMapping, defaultMapping *models.InventoryAccountMapping
+	for i := range mappings {
+		mapping := &mappings[i]
+		switch {
+		case mapping.ProductID != nil:
+			if *mapping.ProductID == product.ID {
+				productMapping = mapping
+			}
+		case mapping.Category != "":
+			if product.Category != "" && mapping.Category == product.Category {
+				categoryMapping = mapping
+			}
+		default:
+			defaultMapping = mapping
+		}
+	}
+	candidates := []*models.InventoryAccountMapping{productMapping, categoryMapping, defaultMapping}
+
+	pick := func(field func(*models.InventoryAccountMapping) *uint, code string) (uint, error) {
+		for _, mapping := range candidates {
+			if mapping != nil && field(mapping) != nil {
+				return *field(mapping), nil
+			}
+		}
+		account, err := s.accountRepo.FindByCode(product.CompanyID, code)
+		if err != nil {
+			return 0, errors.New("no account mapped for product " + product.Code + " and default account " + code + " not found")
+		}
+		return account.ID, nil
+	}
+
+	accounts := &inventoryAccounts{}
+	if accounts.Inventory, err = pick(func(m *models.InventoryAccountMapping) *uint { return m.InventoryAccountID }, defaultInventoryAccountCode); err != nil {
+		return nil, err
+	}
+	if accounts.COGS, err = pick(func(m *models.InventoryAccountMapping) *uint { return m.COGSAccountID }, defaultCOGSAccountCode); err != nil {
+		return nil, err
+	}
+	if accounts.PurchaseClearing, err = pick(func(m *models.InventoryAccountMapping) *uint { return m.PurchaseClearingAccountID }, defaultPurchaseClearingAccountCode); err != nil {
+		return nil, err
+	}
+	if accounts.Variance, err = pick(func(m *models.InventoryAccountMapping) *uint { return m.VarianceAccountID }, defaultInventoryVarianceCode); err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// receiveTransferLayers recreates the layers consumed by the source
+// transfer_out movement in the receiving warehouse, at their cost
+func (s *inventoryService) receiveTransferLayers(movement *models.StockMovement) (float64, error) {
+	if movement.SourceMovementID == nil {
+		return 0, errors.New("transfer receipt requires a source movement")
+	}
+
+	consumptions, err := s.inventoryRepo.FindConsumptionsByMovement(*movement.SourceMovementID)
+	if err != nil {
+		return 0, err
+	}
+
+	var valueChange float64
+	for _, consumption := range consumptions {
+		source, err := s.inventoryRepo.FindCostLayerByID(consumption.LayerID)
+		if err != nil {
+			return 0, err
+		}
+
+		// Received on the transfer date, matching the transfer_out that took
+		// it from the source, but keeping the age of the original receipt
+		origin := layerOrigin(source)
+		layer := &models.StockCostLayer{
+			CompanyID:         movement.CompanyID,
+			ProductID:         movement.ProductID,
+			WarehouseID:       movement.WarehouseID,
+			MovementID:        &movement.ID,
+			ReceivedDate:      movement.MovementDate,
+			OriginDate:        &origin,
+			SourceLayerID:     &source.ID,
+			Quantity:          consumption.Quantity,
+			RemainingQuantity: consumption.Quantity,
+			UnitCost:          consumption.UnitCost,
+		}
+		if err := s.inventoryRepo.CreateCostLayer(layer); err != nil {
+			return 0, err
+		}
+		valueChange += consumption.TotalCost
+	}
+
+	return valueChange, nil
+}
+
+func sumConsumptionCost(consumptions []models.StockLayerConsumption) float64 {
+	var total float64
+	for _, consumption := range consumptions {
+		total += consumption.TotalCost
+	}
+	return total
+}
+
+func (s *inventoryService) updateStockBalance(movement *models.StockMovement, balance *models.StockBalance, valueChange float64) error {
+	// If balance doesn't exist, create new
+	if balance.ID == 0 {
+		balance.CompanyID = movement.CompanyID
+		balance.ProductID = movement.ProductID
+		balance.WarehouseID = movement.WarehouseID
+		balance.Quantity = 0
+		balance.AverageCost = 0
+		balance.TotalValue = 0
+	}
+
+	// Update balance based on movement type
+	if movement.Type == "in" || movement.Type == "transfer_in" {
+		balance.Quantity += movement.Quantity
+	} else if movement.Type == "out" || movement.Type == "transfer_out" {
+		balance.Quantity -= movement.Quantity
+		if balance.Quantity < 0 {
+			return errors.New("negative stock not allowed")
+		}
+	} else if movement.Type == "adjustment" {
+		// Adjustment sets the counted quantity
+		balance.Quantity = movement.Quantity
+	}
+
+	// Value follows the cost layers added or consumed
+	balance.TotalValue += valueChange
+	if balance.Quantity > 0 {
+		balance.AverageCost = balance.TotalValue / balance.Quantity
+	} else {
+		balance.TotalValue = 0
+	}
+
+	return s.inventoryRepo.UpdateStockBalance(balance)
+}
+
+func (s *inventoryService) GetStockMovementByID(id uint) (*models.StockMovement, error) {
+	return s.inventoryRepo.FindStockMovementByID(id)
+}
+
+func (s *inventoryService) GetStockMovementsByProduct(productID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockMovement, error) {
+	return s.inventoryRepo.FindStockMovementsByProduct(productID, warehouseID, startDate, endDate)
+}
+
+func (s *inventoryService) GetStockMovementsByCompany(companyID uint, warehouseID uint, startDate, endDate time.Time) ([]models.StockMovement, error) {
+	return s.inventoryRepo.FindStockMovementsByCompany(companyID, warehouseID, startDate, endDate)
+}
+
+// Stock Balance methods
+func (s *inventoryService) GetStockBalance(productID uint, warehouseID uint, unit string) (*models.StockBalance, error) {
+	var balance *models.StockBalance
+	var err error
+	if warehouseID == 0 {
+		balance, err = s.inventoryRepo.GetProductStockTotal(productID)
+	} else {
+		balance, err = s.inventoryRepo.GetStockBalance(productID, warehouseID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := s.inventoryRepo.SumReservedQuantity(productID, warehouseID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	setReservedQuantity(balance, reserved)
+
+	if unit == "" {
+		return balance, nil
+	}
+
+	product, err := s.inventoryRepo.FindProductByID(productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+	setDisplayUnit(balance, product, unit)
+	return balance, nil
+}
+
+func (s *inventoryService) GetAllStockBalances(companyID uint, warehouseID uint, unit string) ([]models.StockBalance, error) {
+	balances, err := s.inventoryRepo.GetAllStockBalances(companyID, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved, err := s.inventoryRepo.SumReservedQuantities(companyID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range balances {
+		balance := &balances[i]
+		setReservedQuantity(balance, reserved[[2]uint{balance.ProductID, balance.WarehouseID}])
+		if unit != "" {
+			setDisplayUnit(balance, &balance.Product, unit)
+		}
+	}
+	return balances, nil
+}
+
+// setReservedQuantity splits the on-hand quantity into reserved and available
+func setReservedQuantity(balance *models.StockBalance, reserved float64) {
+	balance.ReservedQuantity = reserved
+	balance.AvailableQuantity = math.Round((balance.Quantity-reserved)*100) / 100
+}
+
+// Unit of Measure methods
+func (s *inventoryService) CreateUnitOfMeasure(unit *models.UnitOfMeasure) error {
+	return s.inventoryRepo.CreateUnitOfMeasure(unit)
+}
+
+func (s *inventoryService) GetUnitsOfMeasure(companyID uint) ([]models.UnitOfMeasure, error) {
+	return s.inventoryRepo.FindUnitsOfMeasureByCompany(companyID)
+}
+
+func (s *inventoryService) UpdateUnitOfMeasure(id uint, updatedUnit *models.UnitOfMeasure) error {
+	unit, err := s.inventoryRepo.FindUnitOfMeasureByID(id)
+	if err != nil {
+		return errors.New("unit of measure not found")
+	}
+
+	unit.Code = updatedUnit.Code
+	unit.Name = updatedUnit.Name
+
+	*updatedUnit = *unit
+	return s.inventoryRepo.UpdateUnitOfMeasure(unit)
+}
+
+func (s *inventoryService) DeleteUnitOfMeasure(id uint) error {
+	count, err := s.inventoryRepo.CountProductUnitsByUnit(id)
+	if err != nil {
+		return err
+	}
+
+	if count > 0 {
+		return errors.New("unit of measure is used by product conversions")
+	}
+
+	return s.inventoryRepo.DeleteUnitOfMeasure(id)
+}
+
+// SetProductUnit adds a conversion to a product or updates the existing
+// one for the same unit
+func (s *inventoryService) SetProductUnit(productUnit *models.ProductUnit) error {
+	product, err := s.inventoryRepo.FindProductByID(productUnit.ProductID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+
+	unit, err := s.inventoryRepo.FindUnitOfMeasureByID(productUnit.UnitID)
+	if err != nil || unit.CompanyID != product.CompanyID {
+		return errors.New("unit of measure not found")
+	}
+
+	if unit.Code == product.Unit {
+		return errors.New("base unit does not need a conversion")
+	}
+
+	if productUnit.Factor <= 0 {
+		return errors.New("conversion factor must be greater than zero")
+	}
+
+	for _, existing := range product.Units {
+		if existing.UnitID == productUnit.UnitID {
+			productUnit.ID = existing.ID
+			productUnit.CreatedAt = existing.CreatedAt
+		}
+	}
+
+	if err := s.inventoryRepo.SaveProductUnit(productUnit); err != nil {
+		return err
+	}
+
+	// A product has one default purchase and one default sales unit
+	if productUnit.IsPurchaseUnit {
+		if err := s.inventoryRepo.ClearDefaultProductUnit(product.ID, "is_purchase_unit", productUnit.ID); err != nil {
+			return err
+		}
+	}
+	if productUnit.IsSalesUnit {
+		if err := s.inventoryRepo.ClearDefaultProductUnit(product.ID, "is_sales_unit", productUnit.ID); err != nil {
+			return err
+		}
+	}
+
+	productUnit.Unit = *unit
+	return nil
+}
+
+func (s *inventoryService) DeleteProductUnit(productID uint, id uint) error {
+	productUnit, err := s.inventoryRepo.FindProductUnitByID(id)
+	if err != nil || productUnit.ProductID != productID {
+		return errors.New("product unit not found")
+	}
+
+	return s.inventoryRepo.DeleteProductUnit(id)
+}
+
+// findProductUnit resolves a unit code, or "purchase" / "sales" for the
+// product's default units. The base unit is not a product unit.
+func findProductUnit(product *models.Product, unit string) *models.ProductUnit {
+	for i := range product.Units {
+		productUnit := &product.Units[i]
+		if (unit == "purchase" && productUnit.IsPurchaseUnit) ||
+			(unit == "sales" && productUnit.IsSalesUnit) ||
+			productUnit.Unit.Code == unit {
+			return productUnit
+		}
+	}
+	return nil
+}
+
+// ConvertToBaseUnit restates a movement entered in an alternate unit in the
+// product's base unit; the entered unit and quantity are kept for reference
+func ConvertToBaseUnit(product *models.Product, movement *models.StockMovement) error {
+	if movement.Unit == "" || movement.Unit == product.Unit {
+		movement.Unit = product.Unit
+		movement.UnitQuantity = movement.Quantity
+		movement.ConversionFactor = 1
+		return nil
+	}
+
+	productUnit := findProductUnit(product, movement.Unit)
+	if productUnit == nil {
+		return errors.New("unit " + movement.Unit + " is not defined for this product")
+	}
+
+	movement.Unit = productUnit.Unit.Code
+	movement.UnitQuantity = movement.Quantity
+	movement.ConversionFactor = productUnit.Factor
+	movement.Quantity = movement.Quantity * productUnit.Factor
+	movement.UnitCost = roundUnitCost(movement.UnitCost / productUnit.Factor)
+	return nil
+}
+
+// roundUnitCost rounds a unit cost to the six decimals it is stored with,
+// fine enough that layer values add up to the posted amounts
+func roundUnitCost(unitCost float64) float64 {
+	return math.Round(unitCost*1e6) / 1e6
+}
+
+// setDisplayUnit adds the balance quantity in an alternate unit; products
+// without that unit show their base unit
+func setDisplayUnit(balance *models.StockBalance, product *models.Product, unit string) {
+	balance.DisplayUnit = product.Unit
+	balance.DisplayQuantity = balance.Quantity
+
+	if productUnit := findProductUnit(product, unit); productUnit != nil {
+		balance.DisplayUnit = productUnit.Unit.Code
+		balance.DisplayQuantity = balance.Quantity / productUnit.Factor
+	}
+}
+
+// Cost Layer methods
+func (s *inventoryService) GetCostLayerReport(companyID uint, productID uint, warehouseID uint, openOnly bool) ([]models.CostLayerReport, error) {
+	return s.inventoryRepo.GetCostLayerReport(companyID, productID, warehouseID, openOnly)
+}
+
+// Serial Number methods
+func (s *inventoryService) GetSerialNumbers(companyID uint, productID uint, warehouseID uint, status string) ([]models.SerialNumber, error) {
+	return s.inventoryRepo.FindSerials(companyID, productID, warehouseID, status)
+}
+
+// GetSerialHistory looks a serial number up across products, it is only
+// unique per product
+func (s *inventoryService) GetSerialHistory(companyID uint, serialNumber string) ([]models.SerialHistory, error) {
+	serials, err := s.inventoryRepo.FindSerialsByNumber(companyID, serialNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(serials) == 0 {
+		return nil, errors.New("serial number not found")
+	}
+
+	history := make([]models.SerialHistory, 0, len(serials))
+	for _, serial := range serials {
+		events, err := s.inventoryRepo.FindSerialEvents(serial.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		entry := models.SerialHistory{Serial: serial, Events: events}
+		if serial.Status == models.SerialStatusOut {
+			for i := len(events) - 1; i >= 0; i-- {
+				if events[i].Event == "sold" {
+					entry.Customer = events[i].Party
+					break
+				}
+			}
+		}
+		history = append(history, entry)
+	}
+
+	return history, nil
+}
+
+// Stock Lot methods
+func (s *inventoryService) GetStockLots(companyID uint, productID uint, warehouseID uint, expiringBefore *time.Time) ([]models.StockLot, error) {
+	return s.inventoryRepo.FindLots(companyID, productID, warehouseID, expiringBefore)
+}
+
+// Warehouse methods
+func (s *inventoryService) CreateWarehouse(warehouse *models.Warehouse) error {
+	if warehouse.IsTransit {
+		return errors.New("transit warehouse is managed by the system")
+	}
+
+	// The first warehouse of a company becomes its default
+	if _, err := s.inventoryRepo.FindDefaultWarehouse(warehouse.CompanyID); err != nil {
+		warehouse.IsDefault = true
+	} else if warehouse.IsDefault {
+		return errors.New("company already has a default warehouse")
+	}
+
+	if err := s.inventoryRepo.CreateWarehouse(warehouse); err != nil {
+		return err
+	}
+
+	if warehouse.IsDefault {
+		return s.inventoryRepo.AssignUnlocatedStock(warehouse.CompanyID, warehouse.ID)
+	}
+	return nil
+}
+
+func (s *inventoryService) GetWarehousesByCompany(companyID uint) ([]models.Warehouse, error) {
+	return s.inventoryRepo.FindWarehousesByCompany(companyID)
+}
+
+func (s *inventoryService) UpdateWarehouse(id uint, updatedWarehouse *models.Warehouse) error {
+	warehouse, err := s.inventoryRepo.FindWarehouseByID(id)
+	if err != nil {
+		return errors.New("warehouse not found")
+	}
+
+	// Default and transit flags are fixed after creation
+	warehouse.Code = updatedWarehouse.Code
+	warehouse.Name = updatedWarehouse.Name
+	warehouse.Address = updatedWarehouse.Address
+	warehouse.IsActive = updatedWarehouse.IsActive
+	if warehouse.IsDefault && !warehouse.IsActive {
+		return errors.New("default warehouse cannot be deactivated")
+	}
+
+	*updatedWarehouse = *warehouse
+	return s.inventoryRepo.UpdateWarehouse(warehouse)
+}
+
+func (s *inventoryService) DeleteWarehouse(id uint) error {
+	warehouse, err := s.inventoryRepo.FindWarehouseByID(id)
+	if err != nil {
+		return errors.New("warehouse not found")
+	}
+
+	if warehouse.IsDefault || warehouse.IsTransit {
+		return errors.New("default and transit warehouses cannot be deleted")
+	}
+
+	balances, err := s.inventoryRepo.GetAllStockBalances(warehouse.CompanyID, warehouse.ID)
+	if err != nil {
+		return err
+	}
+	for _, balance := range balances {
+		if balance.Quantity != 0 {
+			return errors.New("cannot delete warehouse with existing stock")
+		}
+	}
+
+	return s.inventoryRepo.DeleteWarehouse(id)
+}
+
+// ensureDefaultWarehouse returns the company's default warehouse, creating
+// it (and assigning stock recorded before warehouses existed) when missing
+func (s *inventoryService) ensureDefaultWarehouse(companyID uint) (*models.Warehouse, error) {
+	warehouse, err := s.inventoryRepo.FindDefaultWarehouse(companyID)
+	if err == nil {
+		return warehouse, nil
+	}
+
+	warehouse = &models.Warehouse{
+		CompanyID: companyID,
+		Code:      "MAIN",
+		Name:      "Gudang Utama",
+		IsDefault: true,
+		IsActive:  true,
+	}
+	if err := s.inventoryRepo.CreateWarehouse(warehouse); err != nil {
+		return nil, err
+	}
+
+	return warehouse, s.inventoryRepo.AssignUnlocatedStock(companyID, warehouse.ID)
+}
+
+func (s *inventoryService) ensureTransitWarehouse(companyID uint) (*models.Warehouse, error) {
+	warehouse, err := s.inventoryRepo.FindTransitWarehouse(companyID)
+	if err == nil {
+		return warehouse, nil
+	}
+
+	warehouse = &models.Warehouse{
+		CompanyID: companyID,
+		Code:      "TRANSIT",
+		Name:      "Barang Dalam Perjalanan",
+		IsTransit: true,
+		IsActive:  true,
+	}
+	return warehouse, s.inventoryRepo.CreateWarehouse(warehouse)
+}
+
+// Stock Transfer methods
+func (s *inventoryService) CreateStockTransfer(transfer *models.StockTransfer) error {
+	if transfer.FromWarehouseID == transfer.ToWarehouseID {
+		return errors.New("source and destination warehouse must differ")
+	}
+
+	for _, warehouseID := range []uint{transfer.FromWarehouseID, transfer.ToWarehouseID} {
+		warehouse, err := s.inventoryRepo.FindWarehouseByID(warehouseID)
+		if err != nil || warehouse.CompanyID != transfer.CompanyID {
+			return errors.New("warehouse not found")
+		}
+		if warehouse.IsTransit || !warehouse.IsActive {
+			return errors.New("transfers must be between active stock warehouses")
+		}
+	}
+
+	transferNumber, err := s.inventoryRepo.GenerateTransferNumber(transfer.CompanyID, transfer.TransferDate)
+	if err != nil {
+		return err
+	}
+	transfer.TransferNumber = transferNumber
+	transfer.Status = models.TransferStatusDraft
+
+	return s.inventoryRepo.CreateStockTransfer(transfer)
+}
+
+func (s *inventoryService) GetStockTransferByID(id uint) (*models.StockTransfer, error) {
+	return s.inventoryRepo.FindStockTransferByID(id)
+}
+
+func (s *inventoryService) GetStockTransfersByCompany(companyID uint, status string) ([]models.StockTransfer, error) {
+	return s.inventoryRepo.FindStockTransfersByCompany(companyID, status)
+}
+
+// DispatchStockTransfer moves the items from the source into transit
+func (s *inventoryService) DispatchStockTransfer(id uint, userID uint) error {
+	transfer, err := s.inventoryRepo.FindStockTransferByID(id)
+	if err != nil {
+		return errors.New("stock transfer not found")
+	}
+
+	if transfer.Status != models.TransferStatusDraft {
+		return errors.New("only draft transfers can be dispatched")
+	}
+
+	transit, err := s.ensureTransitWarehouse(transfer.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	// Items moved by an earlier attempt that failed part way keep their movements
+	now := time.Now()
+	for i := range transfer.Items {
+		item := &transfer.Items[i]
+
+		in, err := s.moveStock(transfer, item, &item.OutMovementID, &item.TransitInMovementID, nil, transfer.FromWarehouseID, transit.ID, now, userID)
+		if err != nil {
+			return errors.New("failed to dispatch " + item.Product.Code + ": " + err.Error())
+		}
+
+		item.TotalCost = in.TotalCost
+		if err := s.inventoryRepo.UpdateStockTransferItem(item); err != nil {
+			return err
+		}
+	}
+
+	transfer.Status = models.TransferStatusInTransit
+	transfer.DispatchedAt = &now
+	transfer.DispatchedBy = &userID
+	return s.inventoryRepo.UpdateStockTransfer(transfer)
+}
+
+// ReceiveStockTransfer moves the items from transit into the destination
+func (s *inventoryService) ReceiveStockTransfer(id uint, userID uint) error {
+	transfer, err := s.inventoryRepo.FindStockTransferByID(id)
+	if err != nil {
+		return errors.New("stock transfer not found")
+	}
+
+	if transfer.Status != models.TransferStatusInTransit {
+		return errors.New("only in-transit transfers can be received")
+	}
+
+	transit, err := s.ensureTransitWarehouse(transfer.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	// Items moved by an earlier attempt that failed part way keep their movements
+	now := time.Now()
+	for i := range transfer.Items {
+		item := &transfer.Items[i]
+
+		// Transit is shared by every open transfer, so the receipt takes the
+		// layers and lots this item's dispatch brought in
+		if _, err := s.moveStock(transfer, item, &item.TransitOutMovementID, &item.InMovementID, item.TransitInMovementID, transit.ID, transfer.ToWarehouseID, now, userID); err != nil {
+			return errors.New("failed to receive " + item.Product.Code + ": " + err.Error())
+		}
+	}
+
+	transfer.Status = models.TransferStatusReceived
+	transfer.ReceivedAt = &now
+	transfer.ReceivedBy = &userID
+	return s.inventoryRepo.UpdateStockTransfer(transfer)
+}
+
+func (s *inventoryService) CancelStockTransfer(id uint) error {
+	transfer, err := s.inventoryRepo.FindStockTransferByID(id)
+	if err != nil {
+		return errors.New("stock transfer not found")
+	}
+
+	if transfer.Status != models.TransferStatusDraft {
+		return errors.New("only draft transfers can be cancelled")
+	}
+
+	transfer.Status = models.TransferStatusCancelled
+	return s.inventoryRepo.UpdateStockTransfer(transfer)
+}
+
+// moveStock issues a transfer item from one warehouse and receives it in
+// another, carrying the consumed cost layers across, and returns the
+// receipt. An issue with a sourceMovementID draws only on the layers and lots
+// that movement received. Each movement is recorded on the item as soon as it
+// is made, so a retry skips the movements already in outMovementID and
+// inMovementID.
+func (s *inventoryService) moveStock(transfer *models.StockTransfer, item *models.StockTransferItem, outMovementID, inMovementID **uint, sourceMovementID *uint, fromWarehouseID, toWarehouseID uint, date time.Time, userID uint) (*models.StockMovement, error) {
+	if *inMovementID != nil {
+		return s.inventoryRepo.FindStockMovementByID(**inMovementID)
+	}
+
+	var out *models.StockMovement
+	if *outMovementID != nil {
+		var err error
+		if out, err = s.inventoryRepo.FindStockMovementByID(**outMovementID); err != nil {
+			return nil, err
+		}
+	} else {
+		out = &models.StockMovement{
+			CompanyID:     transfer.CompanyID,
+			ProductID:     item.ProductID,
+			WarehouseID:   fromWarehouseID,
+			MovementDate:  date,
+			Type:          "transfer_out",
+			Quantity:      item.Quantity,
+			SerialNumbers: append([]string(nil), item.SerialNumbers...),
+			Reference:     transfer.TransferNumber,
+			Notes:         item.Notes,
+			CreatedBy:     userID,
+		}
+		if sourceMovementID != nil {
+			out.SourceMovementID = sourceMovementID
+			lots, err := s.inventoryRepo.FindLotAllocationsByMovement(*sourceMovementID)
+			if err != nil {
+				return nil, err
+			}
+			for _, lot := range lots {
+				out.LotAllocations = append(out.LotAllocations, models.StockLotAllocation{LotID: lot.LotID, Quantity: lot.Quantity})
+			}
+		}
+		if err := s.CreateStockMovement(out); err != nil {
+			return nil, err
+		}
+		*outMovementID = &out.ID
+		if err := s.inventoryRepo.UpdateStockTransferItem(item); err != nil {
+			return nil, err
+		}
+	}
+
+	in := &models.StockMovement{
+		CompanyID:        transfer.CompanyID,
+		ProductID:        item.ProductID,
+		WarehouseID:      toWarehouseID,
+		MovementDate:     date,
+		Type:             "transfer_in",
+		Quantity:         item.Quantity,
+		UnitCost:         out.UnitCost,
+		Reference:        transfer.TransferNumber,
+		Notes:            item.Notes,
+		SourceMovementID: &out.ID,
+		CreatedBy:        userID,
+	}
+	if err := s.CreateStockMovement(in); err != nil {
+		return nil, err
+	}
+	*inMovementID = &in.ID
+	if err := s.inventoryRepo.UpdateStockTransferItem(item); err != nil {
+		return nil, err
+	}
+
+	return in, nil
+}
+
+// Account Mapping methods
+func (s *inventoryService) CreateAccountMapping(mapping *models.InventoryAccountMapping) error {
+	if mapping.ProductID != nil && mapping.Category != "" {
+		return errors.New("mapping must target either a product or a category, not both")
+	}
+	return s.inventoryRepo.CreateAccountMapping(mapping)
+}
+
+func (s *inventoryService) GetAccountMappingsByCompany(companyID uint) ([]models.InventoryAccountMapping, error) {
+	return s.inventoryRepo.FindAccountMappingsByCompany(companyID)
+}
+
+func (s *inventoryService) UpdateAccountMapping(id uint, updatedMapping *models.InventoryAccountMapping) error {
+	mapping, err := s.inventoryRepo.FindAccountMappingByID(id)
+	if err != nil {
+		return errors.New("account mapping not found")
+	}
+
+	if updatedMapping.ProductID != nil && updatedMapping.Category != "" {
+		return errors.New("mapping must target either a product or a category, not both")
+	}
+
+	updatedMapping.ID = mapping.ID
+	updatedMapping.CreatedAt = mapping.CreatedAt
+	return s.inventoryRepo.UpdateAccountMapping(updatedMapping)
+}
+
+func (s *inventoryService) DeleteAccountMapping(id uint) error {
+	return s.inventoryRepo.DeleteAccountMapping(id)
+}
+
+// GetGLReconciliation compares the inventory subledger value per inventory
+// account with the posted GL balance of that account as of a date
+func (s *inventoryService) GetGLReconciliation(companyID uint, asOfDate time.Time) (*models.InventoryReconciliation, error) {
+	valuations, err := s.inventoryRepo.CalculateHPP(companyID, 0, asOfDate, asOfDate)
+	if err != nil {
+		return nil, err
+	}
+
+	subledger := make(map[uint]float64)
+	for _, valuation := range valuations {
+		product, err := s.inventoryRepo.FindProductByID(valuation.ProductID)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts, err := s.resolveAccounts(product)
+		if err != nil {
+			return nil, err
+		}
+		subledger[accounts.Inventory] += valuation.EndingValue
+	}
+
+	report := &models.InventoryReconciliation{
+		AsOfDate: asOfDate.Format("2006-01-02"),
+		Accounts: []models.InventoryReconciliationLine{},
+	}
+
+	for accountID, value := range subledger {
+		account, err := s.accountRepo.FindByID(accountID)
+		if err != nil {
+			return nil, err
+		}
+
+		glBalance, err := s.ledgerRepo.GetAccountBalance(accountID, asOfDate)
+		if err != nil {
+			return nil, err
+		}
+
+		line := models.InventoryReconciliationLine{
+			AccountID:      accountID,
+			AccountCode:    account.Code,
+			AccountName:    account.Name,
+			SubledgerValue: math.Round(value*100) / 100,
+			GLBalance:      glBalance,
+		}
+		line.Difference = math.Round((line.SubledgerValue-line.GLBalance)*100) / 100
+
+		report.Accounts = append(report.Accounts, line)
+		report.TotalSubledgerValue += line.SubledgerValue
+		report.TotalGLBalance += line.GLBalance
+		report.TotalDifference += line.Difference
+	}
+
+	sort.Slice(report.Accounts, func(i, j int) bool {
+		return report.Accounts[i].AccountCode < report.Accounts[j].AccountCode
+	})
+
+	report.MovementsWithoutJournal, err = s.inventoryRepo.CountMovementsWithoutJournal(companyID, asOfDate)
+	if err != nil {
+		return nil, err
+	}
+	report.IsReconciled = math.Abs(report.TotalDifference) < 0.01
+
+	return report, nil
+}
+
+// Stock Reservation methods
+func (s *inventoryService) CreateReservation(reservation *models.StockReservation) error {
+	if reservation.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+
+	product, err := s.inventoryRepo.FindProductByID(reservation.ProductID)
+	if err != nil || product.CompanyID != reservation.CompanyID {
+		return errors.New("product not found")
+	}
+
+	warehouseID, err := s.resolveWarehouse(reservation.CompanyID, reservation.WarehouseID)
+	if err != nil {
+		return err
+	}
+	reservation.WarehouseID = warehouseID
+
+	now := time.Now()
+	if reservation.ExpiresAt != nil && !reservation.ExpiresAt.After(now) {
+		return errors.New("expiry must be in the future")
+	}
+
+	balance, err := s.GetStockBalance(product.ID, warehouseID, "")
+	if err != nil {
+		return err
+	}
+	if math.Round((balance.AvailableQuantity-reservation.Quantity)*100) < 0 {
+		return fmt.Errorf("insufficient available stock: %.2f available", balance.AvailableQuantity)
+	}
+
+	reservationNumber, err := s.inventoryRepo.GenerateReservationNumber(reservation.CompanyID, now)
+	if err != nil {
+		return err
+	}
+	reservation.ReservationNumber = reservationNumber
+	reservation.FulfilledQuantity = 0
+	reservation.Status = models.ReservationStatusActive
+
+	return s.inventoryRepo.CreateReservation(reservation)
+}
+
+func (s *inventoryService) GetReservationByID(id uint) (*models.StockReservation, error) {
+	return s.inventoryRepo.FindReservationByID(id)
+}
+
+func (s *inventoryService) GetReservations(companyID uint, productID uint, warehouseID uint, status string) ([]models.StockReservation, error) {
+	return s.inventoryRepo.FindReservations(companyID, productID, warehouseID, status)
+}
+
+// ReleaseReservation frees whatever the reservation still holds
+func (s *inventoryService) ReleaseReservation(id uint) error {
+	reservation, err := s.inventoryRepo.FindReservationByID(id)
+	if err != nil {
+		return errors.New("reservation not found")
+	}
+
+	if reservation.Status != models.ReservationStatusActive {
+		return errors.New("only active reservation can be released")
+	}
+
+	now := time.Now()
+	reservation.Status = models.ReservationStatusReleased
+	reservation.ReleasedAt = &now
+	return s.inventoryRepo.UpdateReservation(reservation)
+}
+
+// ExpireReservations closes reservations past their expiry. Balances already
+// ignore them, this keeps the status in line.
+func (s *inventoryService) ExpireReservations(companyID uint) error {
+	_, err := s.inventoryRepo.ExpireReservations(companyID, time.Now())
+	return err
+}
+
+func (s *inventoryService) GetAvailableToPromise(companyID uint, productIDs []uint, warehouseID uint) ([]models.AvailableToPromise, error) {
+	if len(productIDs) == 0 {
+		return nil, errors.New("no products requested")
+	}
+
+	result := make([]models.AvailableToPromise, 0, len(productIDs))
+	for _, productID := range productIDs {
+		product, err := s.inventoryRepo.FindProductByID(productID)
+		if err != nil || product.CompanyID != companyID {
+			return nil, errors.New("product not found")
+		}
+
+		balance, err := s.GetStockBalance(productID, warehouseID, "")
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, models.AvailableToPromise{
+			ProductID:   product.ID,
+			ProductCode: product.Code,
+			ProductName: product.Name,
+			Unit:        product.Unit,
+			WarehouseID: warehouseID,
+			OnHand:      balance.Quantity,
+			Reserved:    balance.ReservedQuantity,
+			Available:   balance.AvailableQuantity,
+		})
+	}
+	return result, nil
+}
+
+// checkAvailableStock keeps stock-outs, transfers and shrinking adjustments
+// from taking quantity reserved for others. A stock-out fulfilling a
+// reservation may take what that reservation holds; the quantity it covers
+// is returned with it.
+func (s *inventoryService) checkAvailableStock(movement *models.StockMovement, balance *models.StockBalance, quantity float64) (*models.StockReservation, float64, error) {
+	if movement.ReservationID != nil && movement.Type != "out" {
+		return nil, 0, errors.New("only stock-outs can fulfill a reservation")
+	}
+
+	now := time.Now()
+	reserved, err := s.inventoryRepo.SumReservedQuantity(movement.ProductID, movement.WarehouseID, now)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var reservation *models.StockReservation
+	held := 0.0
+	if movement.ReservationID != nil {
+		reservation, err = s.inventoryRepo.FindReservationByID(*movement.ReservationID)
+		if err != nil || reservation.CompanyID != movement.CompanyID {
+			return nil, 0, errors.New("reservation not found")
+		}
+		if reservation.ProductID != movement.ProductID || reservation.WarehouseID != movement.WarehouseID {
+			return nil, 0, errors.New("reservation is for another product or warehouse")
+		}
+		if reservation.Status != models.ReservationStatusActive || (reservation.ExpiresAt != nil && !reservation.ExpiresAt.After(now)) {
+			return nil, 0, errors.New("reservation is no longer active")
+		}
+
+		held = reservation.Quantity - reservation.FulfilledQuantity
+	}
+
+	covered, err := CheckReservedStock(balance.Quantity, reserved, held, quantity)
+	if err != nil {
+		return nil, 0, err
+	}
+	return reservation, covered, nil
+}
+
+// CheckReservedStock checks that taking quantity from onHand leaves what is
+// reserved. held is what the reservation being fulfilled still holds, out of
+// reserved; it may be taken and the part of quantity it covers is returned.
+// Plain shortages are left to the cost layer check.
+func CheckReservedStock(onHand, reserved, held, quantity float64) (float64, error) {
+	others := reserved - held
+	if others > 0 && math.Round((onHand-others-quantity)*100) < 0 {
+		return 0, fmt.Errorf("insufficient available stock: %.2f on hand, %.2f reserved", onHand, others)
+	}
+	return math.Min(quantity, held), nil
+}
+
+func (s *inventoryService) fulfillReservation(reservation *models.StockReservation, quantity float64) error {
+	reservation.FulfilledQuantity = math.Round((reservation.FulfilledQuantity+quantity)*100) / 100
+	if reservation.FulfilledQuantity >= reservation.Quantity {
+		reservation.Status = models.ReservationStatusFulfilled
+	}
+	return s.inventoryRepo.UpdateReservation(reservation)
+}
+
+// resolveWarehouse checks the warehouse belongs to the company and is
+// active; 0 means the company's default warehouse
+func (s *inventoryService) resolveWarehouse(companyID uint, warehouseID uint) (uint, error) {
+	if warehouseID == 0 {
+		warehouse, err := s.ensureDefaultWarehouse(companyID)
+		if err != nil {
+			return 0, err
+		}
+		return warehouse.ID, nil
+	}
+
+	warehouse, err := s.inventoryRepo.FindWarehouseByID(warehouseID)
+	if err != nil || warehouse.CompanyID != companyID {
+		return 0, errors.New("warehouse not found")
+	}
+	if !warehouse.IsActive {
+		return 0, errors.New("warehouse is inactive")
+	}
+	return warehouse.ID, nil
+}
+
+// Landed Cost methods
+func (s *inventoryService) CreateLandedCost(landedCost *models.LandedCost) error {
+	if landedCost.Method == "" {
+		landedCost.Method = models.LandedCostByValue
+	}
+
+	if len(landedCost.Charges) == 0 {
+		return errors.New("landed cost requires at least one charge")
+	}
+	if len(landedCost.Items) == 0 {
+		return errors.New("landed cost requires at least one stock receipt")
+	}
+
+	landedCost.TotalAmount = 0
+	for _, charge := range landedCost.Charges {
+		if charge.Amount <= 0 {
+			return errors.New("charge amount must be greater than zero")
+		}
+		if charge.AccountID != nil {
+			account, err := s.accountRepo.FindByID(*charge.AccountID)
+			if err != nil || account.CompanyID != landedCost.CompanyID {
+				return errors.New("charge account not found")
+			}
+		}
+		landedCost.TotalAmount += charge.Amount
+	}
+	landedCost.TotalAmount = math.Round(landedCost.TotalAmount*100) / 100
+
+	seen := make(map[uint]bool)
+	for i := range landedCost.Items {
+		item := &landedCost.Items[i]
+		if seen[item.MovementID] {
+			return errors.New("stock receipt listed more than once")
+		}
+		seen[item.MovementID] = true
+
+		movement, err := s.inventoryRepo.FindStockMovementByID(item.MovementID)
+		if err != nil || movement.CompanyID != landedCost.CompanyID {
+			return errors.New("stock movement not found")
+		}
+		if movement.Type != "in" {
+			return errors.New("landed cost can only be added to stock-in movement " + movement.MovementNumber)
+		}
+
+		item.ProductID = movement.ProductID
+		item.Quantity = movement.Quantity
+		item.Value = movement.TotalCost
+		if item.Weight == 0 {
+			item.Weight = movement.Product.Weight * movement.Quantity
+		}
+	}
+
+	if err := AllocateLandedCost(landedCost.Method, landedCost.TotalAmount, landedCost.Items); err != nil {
+		return err
+	}
+
+	documentNumber, err := s.inventoryRepo.GenerateLandedCostNumber(landedCost.CompanyID, landedCost.DocumentDate)
+	if err != nil {
+		return err
+	}
+	landedCost.DocumentNumber = documentNumber
+	landedCost.Status = models.LandedCostStatusDraft
+
+	return s.inventoryRepo.CreateLandedCost(landedCost)
+}
+
+// AllocateLandedCost spreads amount over the items in proportion to their
+// quantity, value or weight; the last item takes the rounding difference
+func AllocateLandedCost(method models.LandedCostMethod, amount float64, items []models.LandedCostItem) error {
+	basis := func(item *models.LandedCostItem) float64 {
+		switch method {
+		case models.LandedCostByQuantity:
+			return item.Quantity
+		case models.LandedCostByWeight:
+			return item.Weight
+		default:
+			return item.Value
+		}
+	}
+
+	switch method {
+	case models.LandedCostByQuantity, models.LandedCostByValue, models.LandedCostByWeight:
+	default:
+		return errors.New("invalid allocation method")
+	}
+
+	var total float64
+	for i := range items {
+		total += basis(&items[i])
+	}
+	if total <= 0 {
+		return errors.New("nothing to allocate by " + string(method))
+	}
+
+	var allocated float64
+	for i := range items {
+		if i == len(items)-1 {
+			items[i].AllocatedAmount = math.Round((amount-allocated)*100) / 100
+			break
+		}
+		items[i].AllocatedAmount = math.Round(amount*basis(&items[i])/total*100) / 100
+		allocated += items[i].AllocatedAmount
+	}
+
+	return nil
+}
+
+func (s *inventoryService) GetLandedCostByID(id uint) (*models.LandedCost, error) {
+	return s.inventoryRepo.FindLandedCostByID(id)
+}
+
+func (s *inventoryService) GetLandedCostsByCompany(companyID uint, status string) ([]models.LandedCost, error) {
+	return s.inventoryRepo.FindLandedCostsByCompany(companyID, status)
+}
+
+// PostLandedCost raises the unit cost of each receipt's cost layer and the
+// stock value of what is still on hand, following units transferred to
+// other warehouses to the layers they were received into. The share of
+// units already issued goes to COGS. Dr inventory / Dr COGS / Cr charge
+// accounts.
+func (s *inventoryService) PostLandedCost(id uint, userID uint) error {
+	landedCost, err := s.inventoryRepo.FindLandedCostByID(id)
+	if err != nil {
+		return errors.New("landed cost not found")
+	}
+
+	if landedCost.Status != models.LandedCostStatusDraft {
+		return errors.New("only draft landed costs can be posted")
+	}
+
+	type layerUpdate struct {
+		layer       *models.StockCostLayer
+		newUnitCost float64
+		capitalized float64
+	}
+
+	// Work out every change before touching stock so a missing account or
+	// layer leaves nothing half applied
+	var updates []layerUpdate
+	perUnits := make([]float64, len(landedCost.Items))
+	var accountIDs []uint
+	debits := make(map[uint]float64)
+	addDebit := func(accountID uint, amount float64) {
+		if _, ok := debits[accountID]; !ok {
+			accountIDs = append(accountIDs, accountID)
+		}
+		debits[accountID] += amount
+	}
+
+	for i := range landedCost.Items {
+		item := &landedCost.Items[i]
+
+		product, err := s.inventoryRepo.FindProductByID(item.ProductID)
+		if err != nil {
+			return errors.New("product not found")
+		}
+		accounts, err := s.resolveAccounts(product)
+		if err != nil {
+			return err
+		}
+
+		layer, err := s.inventoryRepo.FindCostLayerByMovement(item.MovementID)
+		if err != nil {
+			return errors.New("cost layer for " + item.Movement.MovementNumber + " not found")
+		}
+
+		layers, err := s.transferredCostLayers(layer)
+		if err != nil {
+			return err
+		}
+
+		// Every unit of the receipt carries the same increase, wherever it
+		// has been transferred to; what is still on hand is capitalized
+		perUnit := item.AllocatedAmount / layer.Quantity
+		item.CapitalizedAmount = 0
+		for _, l := range layers {
+			newUnitCost := roundUnitCost(l.UnitCost + perUnit)
+			capitalized := math.Round((newUnitCost-l.UnitCost)*l.RemainingQuantity*100) / 100
+			item.CapitalizedAmount += capitalized
+			updates = append(updates, layerUpdate{layer: l, newUnitCost: newUnitCost, capitalized: capitalized})
+		}
+		item.CapitalizedAmount = math.Round(item.CapitalizedAmount*100) / 100
+		item.ExpensedAmount = math.Round((item.AllocatedAmount-item.CapitalizedAmount)*100) / 100
+		perUnits[i] = perUnit
+
+		addDebit(accounts.Inventory, item.CapitalizedAmount)
+		addDebit(accounts.COGS, item.ExpensedAmount)
+	}
+
+	description := "Landed cost " + landedCost.DocumentNumber
+	var entries []models.JournalEntry
+	addEntry := func(accountID uint, amount float64) {
+		amount = math.Round(amount*100) / 100
+		if amount == 0 {
+			return
+		}
+		entry := models.JournalEntry{
+			AccountID:   accountID,
+			Description: description,
+			Position:    len(entries) + 1,
+		}
+		if amount > 0 {
+			entry.Debit = amount
+		} else {
+			entry.Credit = -amount
+		}
+		entries = append(entries, entry)
+	}
+
+	for _, accountID := range accountIDs {
+		addEntry(accountID, debits[accountID])
+	}
+	for _, charge := range landedCost.Charges {
+		accountID := uint(0)
+		if charge.AccountID != nil {
+			accountID = *charge.AccountID
+		} else {
+			account, err := s.accountRepo.FindByCode(landedCost.CompanyID, defaultPurchaseClearingAccountCode)
+			if err != nil {
+				return errors.New("purchase clearing account " + defaultPurchaseClearingAccountCode + " not found")
+			}
+			accountID = account.ID
+		}
+		addEntry(accountID, -charge.Amount)
+	}
+
+	journal := &models.Journal{
+		CompanyID:       landedCost.CompanyID,
+		TransactionDate: landedCost.DocumentDate,
+		Description:     description,
+		CreatedBy:       userID,
+		Entries:         entries,
+	}
+	if err := s.journalService.CreateJournal(journal); err != nil {
+		return err
+	}
+	if err := s.journalService.PostJournal(journal.ID, userID); err != nil {
+		return err
+	}
+
+	for _, update := range updates {
+		layer := update.layer
+
+		// Units already issued or transferred from the layer carry the
+		// same increase
+		if err := s.inventoryRepo.AddLayerConsumptionCost(layer.ID, update.newUnitCost-layer.UnitCost); err != nil {
+			return err
+		}
+		layer.UnitCost = update.newUnitCost
+		if err := s.inventoryRepo.UpdateCostLayer(layer); err != nil {
+			return err
+		}
+
+		balance, err := s.inventoryRepo.GetStockBalance(layer.ProductID, layer.WarehouseID)
+		if err != nil {
+			return err
+		}
+		balance.TotalValue += update.capitalized
+		if balance.Quantity > 0 {
+			balance.AverageCost = balance.TotalValue / balance.Quantity
+		}
+		if err := s.inventoryRepo.UpdateStockBalance(balance); err != nil {
+			return err
+		}
+	}
+
+	for i := range landedCost.Items {
+		item := &landedCost.Items[i]
+
+		movement := item.Movement
+		unitCost := roundUnitCost(movement.UnitCost + perUnits[i])
+		totalCost := math.Round((movement.TotalCost+item.AllocatedAmount)*100) / 100
+		if err := s.inventoryRepo.UpdateMovementCost(movement.ID, unitCost, totalCost); err != nil {
+			return err
+		}
+
+		if err := s.inventoryRepo.UpdateLandedCostItem(item); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	landedCost.Status = models.LandedCostStatusPosted
+	landedCost.JournalID = &journal.ID
+	landedCost.PostedAt = &now
+	landedCost.PostedBy = &userID
+	return s.inventoryRepo.UpdateLandedCost(landedCost)
+}
+
+// transferredCostLayers returns a receipt layer together with the layers
+// transfers built from it, in this and every later warehouse it moved to
+func (s *inventoryService) transferredCostLayers(layer *models.StockCostLayer) ([]*models.StockCostLayer, error) {
+	layers := []*models.StockCostLayer{layer}
+	for i := 0; i < len(layers); i++ {
+		received, err := s.inventoryRepo.FindCostLayersBySource(layers[i].ID)
+		if err != nil {
+			return nil, err
+		}
+		for j := range received {
+			layers = append(layers, &received[j])
+		}
+	}
+	return layers, nil
+}
+
+func (s *inventoryService) CancelLandedCost(id uint) error {
+	landedCost, err := s.inventoryRepo.FindLandedCostByID(id)
+	if err != nil {
+		return errors.New("landed cost not found")
+	}
+
+	if landedCost.Status != models.LandedCostStatusDraft {
+		return errors.New("only draft landed costs can be cancelled")
+	}
+
+	landedCost.Status = models.LandedCostStatusCancelled
+	return s.inventoryRepo.UpdateLandedCost(landedCost)
+}
+
+// Stock Opname methods
+
+// CreateStockOpname starts a count session: system quantities of the products
+// in scope are snapshotted server-side and their movements in the warehouse
+// are frozen until the opname is approved or cancelled. Without productIDs
+// every active product with stock in the warehouse (of the category, if set)
+// is counted.
+func (s *inventoryService) CreateStockOpname(opname *models.StockOpname, productIDs []uint) error {
+	warehouseID, err := s.resolveWarehouse(opname.CompanyID, opname.WarehouseID)
+	if err != nil {
+		return err
+	}
+	opname.WarehouseID = warehouseID
+
+	if opname.TolerancePercent < 0 || opname.ToleranceQuantity < 0 {
+		return errors.New("tolerance cannot be negative")
+	}
+
+	var products []models.Product
+	if len(productIDs) > 0 {
+		for _, productID := range productIDs {
+			product, err := s.inventoryRepo.FindProductByID(productID)
+			if err != nil || product.CompanyID != opname.CompanyID {
+				return errors.New("product not found")
+			}
+			products = append(products, *product)
+		}
+	} else {
+		all, err := s.inventoryRepo.FindProductsByCompanyID(opname.CompanyID)
+		if err != nil {
+			return err
+		}
+		balances, err := s.inventoryRepo.GetAllStockBalances(opname.CompanyID, opname.WarehouseID)
+		if err != nil {
+			return err
+		}
+		onHand := make(map[uint]float64)
+		for _, balance := range balances {
+			onHand[balance.ProductID] += balance.Quantity
+		}
+
+		for _, product := range all {
+			if !product.IsActive || onHand[product.ID] == 0 {
+				continue
+			}
+			if opname.Category != "" && product.Category != opname.Category {
+				continue
+			}
+			products = append(products, product)
+		}
+	}
+
+	if len(products) == 0 {
+		return errors.New("no products to count")
+	}
+
+	opname.Items = make([]models.StockOpnameItem, 0, len(products))
+	for i := range products {
+		item, err := s.snapshotOpnameItem(opname.CompanyID, opname.WarehouseID, &products[i])
+		if err != nil {
+			return err
+		}
+		opname.Items = append(opname.Items, *item)
+	}
+
+	// Generate opname number
+	opnameNumber, err := s.inventoryRepo.GenerateOpnameNumber(opname.CompanyID, opname.OpnameDate)
+	if err != nil {
+		return err
+	}
+	opname.OpnameNumber = opnameNumber
+	opname.Status = models.OpnameStatusCounting
+
+	return s.inventoryRepo.CreateStockOpname(opname)
+}
+
+// snapshotOpnameItem records the system quantity of a product about to be
+// counted; serial-tracked products are counted by unit
+func (s *inventoryService) snapshotOpnameItem(companyID, warehouseID uint, product *models.Product) (*models.StockOpnameItem, error) {
+	if counting, err := s.inventoryRepo.FindCountingOpname(product.ID, warehouseID); err == nil {
+		return nil, fmt.Errorf("product %s is already being counted in %s", product.Code, counting.OpnameNumber)
+	}
+
+	item := &models.StockOpnameItem{
+		ProductID:  product.ID,
+		Status:     models.OpnameItemPending,
+		CountRound: 1,
+	}
+
+	if product.IsSerialTracked {
+		onHand, err := s.inventoryRepo.FindSerials(companyID, product.ID, warehouseID, models.SerialStatusInStock)
+		if err != nil {
+			return nil, err
+		}
+		item.SystemQuantity = float64(len(onHand))
+	} else {
+		balance, err := s.inventoryRepo.GetStockBalance(product.ID, warehouseID)
+		if err != nil {
+			return nil, err
+		}
+		item.SystemQuantity = balance.Quantity
+	}
+	item.Difference = -item.SystemQuantity
+
+	return item, nil
+}
+
+func (s *inventoryService) GetStockOpnameByID(id uint) (*models.StockOpname, error) {
+	return s.inventoryRepo.FindStockOpnameByID(id)
+}
+
+func (s *inventoryService) GetStockOpnamesByCompany(companyID uint, status string) ([]models.StockOpname, error) {
+	return s.inventoryRepo.FindStockOpnamesByCompany(companyID, status)
+}
+
+func (s *inventoryService) GetOpnameCounts(opnameID uint, round int) ([]models.StockOpnameCount, error) {
+	return s.inventoryRepo.FindOpnameCounts(opnameID, round)
+}
+
+// SubmitOpnameCounts records a batch of counts from one counter. Counts of
+// the same product in the same round add up, so counters can split the
+// warehouse by zone. Products found but not in the snapshot are added to
+// the opname with their system quantity at that moment.
+func (s *inventoryService) SubmitOpnameCounts(opnameID uint, batch string, counts []models.StockOpnameCount, countedBy uint) error {
+	opname, err := s.inventoryRepo.FindStockOpnameByID(opnameID)
+	if err != nil {
+		return errors.New("stock opname not found")
+	}
+
+	if opname.Status != models.OpnameStatusCounting {
+		return errors.New("only stock opname in counting can receive counts")
+	}
+
+	if len(counts) == 0 {
+		return errors.New("no counts submitted")
+	}
+
+	itemIndex := make(map[uint]int)
+	for i, item := range opname.Items {
+		itemIndex[item.ProductID] = i
+	}
+
+	// Validate the whole batch before saving anything
+	products := make(map[uint]*models.Product)
+	for i := range counts {
+		count := &counts[i]
+
+		var product *models.Product
+		if index, ok := itemIndex[count.ProductID]; ok {
+			product = &opname.Items[index].Product
+		} else if product, ok = products[count.ProductID]; !ok {
+			product, err = s.inventoryRepo.FindProductByID(count.ProductID)
+			if err != nil || product.CompanyID != opname.CompanyID {
+				return errors.New("product not found")
+			}
+		}
+		products[count.ProductID] = product
+
+		if product.IsSerialTracked {
+			count.Quantity = float64(len(count.ScannedSerials))
+		} else if len(count.ScannedSerials) > 0 {
+			return errors.New("serial scan is only for serial-tracked products")
+		}
+
+		if count.Quantity < 0 {
+			return errors.New("counted quantity cannot be negative")
+		}
+	}
+
+	touched := make(map[uint]bool)
+	for i := range counts {
+		count := &counts[i]
+
+		index, ok := itemIndex[count.ProductID]
+		if !ok {
+			item, err := s.snapshotOpnameItem(opname.CompanyID, opname.WarehouseID, products[count.ProductID])
+			if err != nil {
+				return err
+			}
+			item.OpnameID = opname.ID
+			if err := s.inventoryRepo.CreateStockOpnameItem(item); err != nil {
+				return err
+			}
+			item.Product = *products[count.ProductID]
+			opname.Items = append(opname.Items, *item)
+			index = len(opname.Items) - 1
+			itemIndex[count.ProductID] = index
+		}
+		item := &opname.Items[index]
+
+		count.OpnameID = opname.ID
+		count.ItemID = item.ID
+		count.CountRound = item.CountRound
+		count.CountedBy = countedBy
+		if count.Batch == "" {
+			count.Batch = batch
+		}
+		touched[item.ID] = true
+	}
+
+	if err := s.inventoryRepo.CreateOpnameCounts(counts); err != nil {
+		return err
+	}
+
+	// Recalculate the counted products from every count of their current round
+	allCounts, err := s.inventoryRepo.FindOpnameCounts(opname.ID, 0)
+	if err != nil {
+		return err
+	}
+	for i := range opname.Items {
+		item := &opname.Items[i]
+		if !touched[item.ID] {
+			continue
+		}
+
+		item.PhysicalQuantity = 0
+		item.ScannedSerials = nil
+		seen := make(map[string]bool)
+		for _, count := range allCounts {
+			if count.ItemID != item.ID || count.CountRound != item.CountRound {
+				continue
+			}
+			item.PhysicalQuantity += count.Quantity
+			for _, number := range count.ScannedSerials {
+				number = strings.TrimSpace(number)
+				if number != "" && !seen[number] {
+					seen[number] = true
+					item.ScannedSerials = append(item.ScannedSerials, number)
+				}
+			}
+			if count.LotNumber != "" {
+				item.LotNumber = count.LotNumber
+				item.ExpiryDate = count.ExpiryDate
+			}
+		}
+		if item.Product.IsSerialTracked {
+			item.PhysicalQuantity = float64(len(item.ScannedSerials))
+		}
+
+		item.Difference = math.Round((item.PhysicalQuantity-item.SystemQuantity)*100) / 100
+		item.Status = models.OpnameItemCounted
+		if err := s.inventoryRepo.UpdateStockOpnameItem(item); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ReviewStockOpname sends first counts with a variance over the tolerance
+// back for a recount
+func (s *inventoryService) ReviewStockOpname(id uint) (*models.StockOpname, error) {
+	opname, err := s.inventoryRepo.FindStockOpnameByID(id)
+	if err != nil {
+		return nil, errors.New("stock opname not found")
+	}
+
+	if opname.Status != models.OpnameStatusCounting {
+		return nil, errors.New("only stock opname in counting can be reviewed")
+	}
+
+	if _, err := s.flagRecounts(opname); err != nil {
+		return nil, err
+	}
+
+	return opname, nil
+}
+
+// flagRecounts moves counted first-round items over the tolerance (or with
+// other serials than in stock) to a recount round. A recount is accepted
+// as counted.
+func (s *inventoryService) flagRecounts(opname *models.StockOpname) (int, error) {
+	flagged := 0
+	for i := range opname.Items {
+		item := &opname.Items[i]
+		if item.Status != models.OpnameItemCounted || item.CountRound > 1 {
+			continue
+		}
+
+		serialMismatch := false
+		if item.Product.IsSerialTracked {
+			serialMismatch = s.serialsDiffer(opname.CompanyID, item.ProductID, opname.WarehouseID, item.ScannedSerials)
+		}
+
+		if !serialMismatch && !OpnameVarianceExceedsTolerance(item.SystemQuantity, item.PhysicalQuantity, opname.TolerancePercent, opname.ToleranceQuantity) {
+			continue
+		}
+
+		item.Status = models.OpnameItemRecount
+		item.CountRound++
+		if err := s.inventoryRepo.UpdateStockOpnameItem(item); err != nil {
+			return flagged, err
+		}
+		flagged++
+	}
+	return flagged, nil
+}
+
+// OpnameVarianceExceedsTolerance reports whether the counted quantity is
+// off by more than the larger of the quantity and percentage tolerance
+func OpnameVarianceExceedsTolerance(systemQuantity, physicalQuantity, tolerancePercent, toleranceQuantity float64) bool {
+	allowed := math.Max(toleranceQuantity, math.Abs(systemQuantity)*tolerancePercent/100)
+	variance := math.Abs(math.Round((physicalQuantity-systemQuantity)*100) / 100)
+	return variance > allowed
+}
+
+// ApproveStockOpname posts an adjustment for every counted difference. A
+// failed adjustment is recorded on its item and reported; the opname stays
+// open (and frozen) so the cause can be fixed and the approval retried,
+// which only posts the adjustments still missing.
+func (s *inventoryService) ApproveStockOpname(id uint, approvedBy uint) error {
+	opname, err := s.inventoryRepo.FindStockOpnameByID(id)
+	if err != nil {
+		return errors.New("stock opname not found")
+	}
+
+	if opname.Status != models.OpnameStatusDraft && opname.Status != models.OpnameStatusCounting {
+		return errors.New("only draft or counting stock opname can be approved")
+	}
+
+	if opname.Status == models.OpnameStatusCounting {
+		uncounted := 0
+		for _, item := range opname.Items {
+			if item.Status != models.OpnameItemCounted {
+				uncounted++
+			}
+		}
+		if uncounted > 0 {
+			return fmt.Errorf("%d item(s) not counted yet", uncounted)
+		}
+
+		flagged, err := s.flagRecounts(opname)
+		if err != nil {
+			return err
+		}
+		if flagged > 0 {
+			return fmt.Errorf("%d item(s) over tolerance flagged for recount", flagged)
+		}
+	}
+
+	// Create adjustment movements for differences
+	var failures []string
+	for i := range opname.Items {
+		item := &opname.Items[i]
+		if item.MovementID != nil {
+			continue // posted by an earlier approval attempt
+		}
+
+		serialMismatch := false
+		if item.Product.IsSerialTracked {
+			serialMismatch = s.serialsDiffer(opname.CompanyID, item.ProductID, opname.WarehouseID, item.ScannedSerials)
+		}
+
+		if item.Difference == 0 && !serialMismatch {
+			continue
+		}
+
+		movement := &models.StockMovement{
+			CompanyID:     opname.CompanyID,
+			ProductID:     item.ProductID,
+			WarehouseID:   opname.WarehouseID,
+			MovementDate:  opname.OpnameDate,
+			Type:          "adjustment",
+			Quantity:      item.PhysicalQuantity,
+			Reference:     opname.OpnameNumber,
+			Notes:         "Stock opname adjustment: " + item.Notes,
+			LotNumber:     item.LotNumber,
+			ExpiryDate:    item.ExpiryDate,
+			SerialNumbers: item.ScannedSerials,
+			OpnameID:      &opname.ID,
+			CreatedBy:     approvedBy,
+		}
+
+		// Get current balance for unit cost; a failed lookup is recorded on
+		// the item like a failed adjustment
+		balance, err := s.inventoryRepo.GetStockBalance(item.ProductID, opname.WarehouseID)
+		if err == nil {
+			movement.UnitCost = balance.AverageCost
+			movement.TotalCost = item.PhysicalQuantity * balance.AverageCost
+			err = s.CreateStockMovement(movement)
+		}
+
+		if err != nil {
+			item.AdjustmentError = err.Error()
+			failures = append(failures, item.Product.Code+": "+err.Error())
+		} else {
+			item.MovementID = &movement.ID
+			item.AdjustmentError = ""
+		}
+
+		if err := s.inventoryRepo.UpdateStockOpnameItem(item); err != nil {
+			return err
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d adjustment(s) failed: %s", len(failures), strings.Join(failures, "; "))
+	}
+
+	// Approve opname
+	return s.inventoryRepo.ApproveStockOpname(id, approvedBy)
+}
+
+func (s *inventoryService) CancelStockOpname(id uint) error {
+	opname, err := s.inventoryRepo.FindStockOpnameByID(id)
+	if err != nil {
+		return errors.New("stock opname not found")
+	}
+
+	if opname.Status != models.OpnameStatusDraft && opname.Status != models.OpnameStatusCounting {
+		return errors.New("only draft or counting stock opname can be cancelled")
+	}
+
+	for _, item := range opname.Items {
+		if item.MovementID != nil {
+			return errors.New("stock opname with posted adjustments cannot be cancelled")
+		}
+	}
+
+	opname.Status = models.OpnameStatusCancelled
+	return s.inventoryRepo.UpdateStockOpname(opname)
+}
+
+// serialsDiffer reports whether scanned units differ from the units in stock
+func (s *inventoryService) serialsDiffer(companyID, productID, warehouseID uint, scanned []string) bool {
+	onHand, err := s.inventoryRepo.FindSerials(companyID, productID, warehouseID, models.SerialStatusInStock)
+	if err != nil || len(onHand) != len(scanned) {
+		return true
+	}
+
+	scannedSet := make(map[string]bool)
+	for _, number := range scanned {
+		scannedSet[strings.TrimSpace(number)] = true
+	}
+	for _, serial := range onHand {
+		if !scannedSet[serial.SerialNumber] {
+			return true
+		}
+	}
+	return false
+}
+
+// Inventory Report methods
+
+// GetStockCard lists every movement of a product in the period with the
+// running quantity and value, starting from the balance before the period
+func (s *inventoryService) GetStockCard(productID uint, warehouseID uint, startDate, endDate time.Time) (*models.StockCard, error) {
+	product, err := s.inventoryRepo.FindProductByID(productID)
+	if err != nil {
+		return nil, errors.New("product not found")
+	}
+
+	openingQuantity, openingValue, err := s.inventoryRepo.GetStockPosition(productID, warehouseID, startDate)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.inventoryRepo.GetStockCardEntries(productID, warehouseID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	card := &models.StockCard{
+		ProductID:       product.ID,
+		ProductCode:     product.Code,
+		ProductName:     product.Name,
+		Unit:            product.Unit,
+		WarehouseID:     warehouseID,
+		StartDate:       startDate.Format("2006-01-02"),
+		EndDate:         endDate.Format("2006-01-02"),
+		OpeningQuantity: openingQuantity,
+		OpeningValue:    math.Round(openingValue*100) / 100,
+		Entries:         entries,
+	}
+
+	quantity, value := openingQuantity, openingValue
+	for i := range card.Entries {
+		entry := &card.Entries[i]
+		quantity += entry.QuantityIn - entry.QuantityOut
+		value += entry.ValueIn - entry.ValueOut
+		entry.BalanceQuantity = quantity
+		entry.BalanceValue = math.Round(value*100) / 100
+
+		card.TotalIn += entry.QuantityIn
+		card.TotalOut += entry.QuantityOut
+		card.TotalValueIn += entry.ValueIn
+		card.TotalValueOut += entry.ValueOut
+	}
+	card.TotalValueIn = math.Round(card.TotalValueIn*100) / 100
+	card.TotalValueOut = math.Round(card.TotalValueOut*100) / 100
+	card.ClosingQuantity = quantity
+	card.ClosingValue = math.Round(value*100) / 100
+
+	return card, nil
+}
+
+func (s *inventoryService) GetInventoryValuation(companyID uint, warehouseID uint, asOfDate time.Time) (*models.InventoryValuation, error) {
+	positions, err := s.inventoryRepo.CalculateHPP(companyID, warehouseID, asOfDate, asOfDate)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.inventoryRepo.FindProductsByCompanyID(companyID)
+	if err != nil {
+		return nil, err
+	}
+	productsByID := make(map[uint]*models.Product, len(products))
+	for i := range products {
+		productsByID[products[i].ID] = &products[i]
+	}
+
+	valuation := &models.InventoryValuation{
+		AsOfDate:    asOfDate.Format("2006-01-02"),
+		WarehouseID: warehouseID,
+		Lines:       []models.InventoryValuationLine{},
+	}
+	for _, position := range positions {
+		if position.EndingStock == 0 && position.EndingValue == 0 {
+			continue
+		}
+
+		line := models.InventoryValuationLine{
+			ProductID:   position.ProductID,
+			ProductCode: position.ProductCode,
+			ProductName: position.ProductName,
+			Quantity:    position.EndingStock,
+			Value:       math.Round(position.EndingValue*100) / 100,
+		}
+		if product, ok := productsByID[position.ProductID]; ok {
+			line.Category = product.Category
+			line.Unit = product.Unit
+			line.CostMethod = string(product.CostMethod)
+		}
+		if line.Quantity > 0 {
+			line.AverageCost = math.Round(line.Value/line.Quantity*100) / 100
+		}
+
+		valuation.Lines = append(valuation.Lines, line)
+		valuation.TotalValue += line.Value
+	}
+	valuation.TotalValue = math.Round(valuation.TotalValue*100) / 100
+
+	return valuation, nil
+}
+
+func (s *inventoryService) GetInventoryAging(companyID uint, warehouseID uint, slowDays, deadDays int) (*models.InventoryAgingReport, error) {
+	layers, err := s.inventoryRepo.GetCostLayerReport(companyID, 0, warehouseID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	lastIssues, err := s.inventoryRepo.FindLastIssueDates(companyID, warehouseID)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildInventoryAging(layers, lastIssues, time.Now(), slowDays, deadDays), nil
+}
+
+// BuildInventoryAging buckets the on-hand value of open cost layers by the
+// age of their receipt and flags products not issued for slowDays (slow
+// moving) or deadDays (dead stock). Products never issued are aged from
+// their oldest open receipt.
+func BuildInventoryAging(layers []models.CostLayerReport, lastIssues map[uint]time.Time, asOf time.Time, slowDays, deadDays int) *models.InventoryAgingReport {
+	if slowDays <= 0 {
+		slowDays = 90
+	}
+	if deadDays <= slowDays {
+		deadDays = slowDays * 2
+	}
+
+	daysSince := func(date time.Time) int {
+		return int(asOf.Sub(date).Hours() / 24)
+	}
+
+	report := &models.InventoryAgingReport{
+		AsOfDate:       asOf.Format("2006-01-02"),
+		SlowMovingDays: slowDays,
+		DeadStockDays:  deadDays,
+		Lines:          []models.InventoryAgingLine{},
+	}
+
+	lineIndex := make(map[uint]int)
+	oldest := make(map[uint]time.Time)
+	for _, layer := range layers {
+		index, ok := lineIndex[layer.ProductID]
+		if !ok {
+			report.Lines = append(report.Lines, models.InventoryAgingLine{
+				ProductID:   layer.ProductID,
+				ProductCode: layer.ProductCode,
+				ProductName: layer.ProductName,
+			})
+			index = len(report.Lines) - 1
+			lineIndex[layer.ProductID] = index
+		}
+		line := &report.Lines[index]
+
+		// Stock transferred between warehouses ages from its first receipt
+		receivedDate := layer.OriginDate
+		if receivedDate == "" {
+			receivedDate = layer.ReceivedDate
+		}
+		received, _ := time.Parse("2006-01-02", receivedDate)
+		if first, ok := oldest[layer.ProductID]; !ok || received.Before(first) {
+			oldest[layer.ProductID] = received
+		}
+
+		line.Quantity += layer.RemainingQuantity
+		line.Value += layer.RemainingValue
+		switch age := daysSince(received); {
+		case age <= 30:
+			line.Days0To30 += layer.RemainingValue
+		case age <= 60:
+			line.Days31To60 += layer.RemainingValue
+		case age <= 90:
+			line.Days61To90 += layer.RemainingValue
+		case age <= 180:
+			line.Days91To180 += layer.RemainingValue
+		default:
+			line.DaysOver180 += layer.RemainingValue
+		}
+	}
+
+	for i := range report.Lines {
+		line := &report.Lines[i]
+		line.OldestReceiptDate = oldest[line.ProductID].Format("2006-01-02")
+
+		idleSince := oldest[line.ProductID]
+		if lastIssue, ok := lastIssues[line.ProductID]; ok {
+			line.LastIssueDate = lastIssue.Format("2006-01-02")
+			idleSince = lastIssue
+		}
+		line.DaysSinceLastIssue = daysSince(idleSince)
+
+		line.Value = math.Round(line.Value*100) / 100
+		line.Days0To30 = math.Round(line.Days0To30*100) / 100
+		line.Days31To60 = math.Round(line.Days31To60*100) / 100
+		line.Days61To90 = math.Round(line.Days61To90*100) / 100
+		line.Days91To180 = math.Round(line.Days91To180*100) / 100
+		line.DaysOver180 = math.Round(line.DaysOver180*100) / 100
+		report.TotalValue += line.Value
+		switch {
+		case line.DaysSinceLastIssue > deadDays:
+			line.Status = models.StockAgeDead
+			report.DeadStockValue += line.Value
+		case line.DaysSinceLastIssue > slowDays:
+			line.Status = models.StockAgeSlowMoving
+			report.SlowMovingValue += line.Value
+		default:
+			line.Status = models.StockAgeActive
+		}
+	}
+	report.TotalValue = math.Round(report.TotalValue*100) / 100
+	report.SlowMovingValue = math.Round(report.SlowMovingValue*100) / 100
+	report.DeadStockValue = math.Round(report.DeadStockValue*100) / 100
+
+	return report
+}
+
+// Reorder methods
+func (s *inventoryService) GetReorderSuggestions(companyID uint, lookbackDays int, onlyNeeded bool) ([]models.ReorderSuggestion, error) {
+	if lookbackDays <= 0 {
+		lookbackDays = 90
+	}
+
+	endDate := time.Now()
+	startDate := endDate.AddDate(0, 0, -lookbackDays)
+
+	issued, err := s.inventoryRepo.SumIssuedQuantityByProduct(companyID, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+
+	products, err := s.inventoryRepo.FindProductsByCompanyID(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	suggestions := []models.ReorderSuggestion{}
+	for i := range products {
+		product := &products[i]
+		if !product.IsActive {
+			continue
+		}
+
+		balance, err := s.inventoryRepo.GetProductStockTotal(product.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		suggestion := CalculateReorderSuggestion(product, balance.Quantity, issued[product.ID], lookbackDays)
+		if onlyNeeded && !suggestion.NeedsReorder {
+			continue
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, nil
+}
+
+// CalculateReorderSuggestion sets the reorder point at the usage expected
+// during the lead time plus safety stock, unless the product's own reorder
+// point or min stock is higher. Once on hand reaches it, the suggestion
+// refills to the reorder point plus another lead time of usage, and at
+// least the product's reorder quantity.
+func CalculateReorderSuggestion(product *models.Product, onHand, issued float64, lookbackDays int) models.ReorderSuggestion {
+	averageDailyUsage := 0.0
+	if lookbackDays > 0 {
+		averageDailyUsage = issued / float64(lookbackDays)
+	}
+
+	leadTimeUsage := averageDailyUsage * float64(product.LeadTimeDays)
+	reorderPoint := math.Max(leadTimeUsage+product.SafetyStock, math.Max(product.ReorderPoint, product.MinStock))
+
+	suggestion := models.ReorderSuggestion{
+		ProductID:         product.ID,
+		ProductCode:       product.Code,
+		ProductName:       product.Name,
+		Unit:              product.Unit,
+		OnHand:            onHand,
+		MinStock:          product.MinStock,
+		AverageDailyUsage: math.Round(averageDailyUsage*100) / 100,
+		LeadTimeDays:      product.LeadTimeDays,
+		SafetyStock:       product.SafetyStock,
+		ReorderPoint:      math.Round(reorderPoint*100) / 100,
+		PurchaseUnit:      product.Unit,
+	}
+
+	if reorderPoint <= 0 || onHand > reorderPoint {
+		return suggestion
+	}
+
+	suggestion.NeedsReorder = true
+	quantity := math.Max(reorderPoint-onHand+leadTimeUsage, product.ReorderQuantity)
+	suggestion.SuggestedQuantity = math.Round(quantity*100) / 100
+	suggestion.SuggestedPurchaseQuantity = suggestion.SuggestedQuantity
+
+	// Orders are placed in whole purchase units
+	if productUnit := findProductUnit(product, "purchase"); productUnit != nil {
+		suggestion.PurchaseUnit = productUnit.Unit.Code
+		suggestion.SuggestedPurchaseQuantity = math.Ceil(quantity / productUnit.Factor)
+	}
+
+	return suggestion
 }
 
 // HPP Calculation
-func (s *inventoryService) CalculateHPP(companyID uint, startDate, endDate time.Time) ([]models.HPPCalculation, error) {
-	return s.inventoryRepo.CalculateHPP(companyID, startDate, endDate)
+func (s *inventoryService) CalculateHPP(companyID uint, warehouseID uint, startDate, endDate time.Time) ([]models.HPPCalculation, error) {
+	return s.inventoryRepo.CalculateHPP(companyID, warehouseID, startDate, endDate)
 }
\ No newline at end of file
@@ -4,7 +4,10 @@ import (
 	"errors"
 	"finara-backend/internal/models"
 	"finara-backend/internal/repository"
+	"math"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type JournalService interface {
@@ -36,6 +39,16 @@ func NewJournalService(
 	}
 }
 
+// journalServiceWith returns a journal service reading and writing through
+// db, so a posting can share the transaction of the records it belongs to
+func journalServiceWith(db *gorm.DB) JournalService {
+	return NewJournalService(
+		repository.NewJournalRepository(db),
+		repository.NewLedgerRepository(db),
+		repository.NewAccountRepository(db),
+	)
+}
+
 func (s *journalService) CreateJournal(journal *models.Journal) error {
 	// Validasi: entries harus ada minimal 2
 	if len(journal.Entries) < 2 {
@@ -221,4 +234,97 @@ func (s *journalService) VoidJournal(id uint) error {
 	// Update status
 	journal.Status = models.JournalStatusVoided
 	return s.journalRepo.Update(journal)
+}
+
+// netJournalLine is an amount for an account, a debit when positive and a
+// credit when negative
+type netJournalLine struct {
+	accountID uint
+	amount    float64
+}
+
+// postNetJournal posts a journal of the lines, netting those on the same
+// account and leaving out what comes to nothing. It returns nil when there
+// is nothing to post.
+func postNetJournal(journalService JournalService, companyID uint, date time.Time, description string, lines []netJournalLine, userID uint) (*uint, error) {
+	var accountIDs []uint
+	amounts := make(map[uint]float64)
+	for _, line := range lines {
+		if _, ok := amounts[line.accountID]; !ok {
+			accountIDs = append(accountIDs, line.accountID)
+		}
+		amounts[line.accountID] += line.amount
+	}
+
+	var entries []models.JournalEntry
+	for _, accountID := range accountIDs {
+		amount := math.Round(amounts[accountID]*100) / 100
+		if amount == 0 {
+			continue
+		}
+		entry := models.JournalEntry{
+			AccountID:   accountID,
+			Description: description,
+			Position:    len(entries) + 1,
+		}
+		if amount > 0 {
+			entry.Debit = amount
+		} else {
+			entry.Credit = -amount
+		}
+		entries = append(entries, entry)
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	journal := &models.Journal{
+		CompanyID:       companyID,
+		TransactionDate: date,
+		Description:     description,
+		CreatedBy:       userID,
+		Entries:         entries,
+	}
+	if err := journalService.CreateJournal(journal); err != nil {
+		return nil, err
+	}
+	if err := journalService.PostJournal(journal.ID, userID); err != nil {
+		return nil, err
+	}
+	return &journal.ID, nil
+}
+
+// parsePeriod returns the first and last day of a YYYY-MM period
+func parsePeriod(period string) (time.Time, time.Time, error) {
+	start, err := time.Parse("2006-01", period)
+	if err != nil {
+		return time.Time{}, time.Time{}, errors.New("period must be in YYYY-MM format")
+	}
+	return start, start.AddDate(0, 1, -1), nil
+}
+
+// monthsBetween counts the months from the month of one date to the month
+// of another
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
+
+// runPendingPeriods makes the scheduled month-end runs of a posting: every
+// period after the latest one run through last month. It carries on from the
+// runs made by hand, so nothing happens before the first one. Periods with
+// nothing to post (errNothing) are passed over.
+func runPendingPeriods(latest string, errNothing error, run func(period string) error) error {
+	start, _, err := parsePeriod(latest)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	last := time.Date(now.Year(), now.Month()-1, 1, 0, 0, 0, 0, time.UTC)
+	for next := start.AddDate(0, 1, 0); !next.After(last); next = next.AddDate(0, 1, 0) {
+		if err := run(next.Format("2006-01")); err != nil && !errors.Is(err, errNothing) {
+			return err
+		}
+	}
+	return nil
 }
\ No newline at end of file
@@ -0,0 +1,585 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type LoanService interface {
+	// Loans
+	CreateLoan(loan *models.Loan, paidInstallments int, disbursementAccountID uint) error
+	GetLoanByID(id uint) (*models.Loan, error)
+	GetLoans(companyID uint, status string) ([]models.Loan, error)
+	PayLoanInstallment(id uint, accountID uint, paymentDate time.Time, reference string, userID uint) (*models.LoanInstallment, error)
+
+	// Rate Changes
+	ChangeLoanRate(id uint, change *models.LoanRateChange) error
+	GetLoanRateChanges(id uint) ([]models.LoanRateChange, error)
+
+	// Reclassifications
+	RunReclassification(companyID uint, period string, userID uint) (*models.LoanReclassification, error)
+	ProcessReclassification(companyID, userID uint) error
+	GetReclassificationByID(id uint) (*models.LoanReclassification, error)
+	GetReclassifications(companyID uint) ([]models.LoanReclassification, error)
+}
+
+// ErrNoLoansToReclassify is returned by RunReclassification for a period
+// without active loans
+var ErrNoLoansToReclassify = errors.New("no loans to reclassify")
+
+const (
+	defaultLongTermLoanCode    = "2-2100" // Utang Bank Jangka Panjang
+	defaultCurrentLoanCode     = "2-1800" // Bagian Lancar Utang Bank Jangka Panjang
+	defaultInterestExpenseCode = "5-6000" // Beban Bunga
+
+	// Installments are notified this many days before they fall due unless
+	// the loan sets its own window
+	defaultLoanReminderDays = 7
+	maxLoanReminderDays     = 90
+)
+
+type loanService struct {
+	db              *gorm.DB
+	loanRepo        repository.LoanRepository
+	accountRepo     repository.AccountRepository
+	journalService  JournalService
+	cashBankService CashBankService
+}
+
+func NewLoanService(
+	db *gorm.DB,
+	loanRepo repository.LoanRepository,
+	accountRepo repository.AccountRepository,
+	journalService JournalService,
+	cashBankService CashBankService,
+) LoanService {
+	return &loanService{
+		db:              db,
+		loanRepo:        loanRepo,
+		accountRepo:     accountRepo,
+		journalService:  journalService,
+		cashBankService: cashBankService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so a loan event
+// and the journal it posts commit or roll back together
+func (s *loanService) withTx(tx *gorm.DB) *loanService {
+	return &loanService{
+		db:              tx,
+		loanRepo:        repository.NewLoanRepository(tx),
+		accountRepo:     repository.NewAccountRepository(tx),
+		journalService:  journalServiceWith(tx),
+		cashBankService: cashBankServiceWith(tx),
+	}
+}
+
+// loanDueDate is the due date k months after the first one, on the same day
+// of the month or the last day of shorter months
+func loanDueDate(firstDueDate time.Time, k int) time.Time {
+	month := time.Date(firstDueDate.Year(), firstDueDate.Month()+time.Month(k), 1, 0, 0, 0, 0, time.UTC)
+	day := firstDueDate.Day()
+	if lastDay := month.AddDate(0, 1, -1).Day(); day > lastDay {
+		day = lastDay
+	}
+	return time.Date(month.Year(), month.Month(), day, 0, 0, 0, 0, time.UTC)
+}
+
+// loanInstallments repays the balance over the months left from the given
+// installment number at the annual rate. Annuity installments are equal;
+// flat and effective installments repay equal principal, with interest on
+// the original principal and on the balance respectively. The last
+// installment takes whatever principal is left.
+func loanInstallments(balance, principal, annualRate float64, method models.LoanMethod, firstDueDate time.Time, firstNumber, months int) []models.LoanInstallment {
+	if months <= 0 {
+		return nil
+	}
+	rate := annualRate / 100 / 12
+
+	var payment float64
+	if method == models.LoanMethodAnnuity {
+		if rate == 0 {
+			payment = balance / float64(months)
+		} else {
+			payment = balance * rate / (1 - math.Pow(1+rate, -float64(months)))
+		}
+		payment = math.Round(payment*100) / 100
+	}
+	equalPrincipal := math.Round(balance/float64(months)*100) / 100
+
+	installments := make([]models.LoanInstallment, months)
+	for k := 0; k < months; k++ {
+		installment := &installments[k]
+		installment.InstallmentNumber = firstNumber + k
+		installment.DueDate = loanDueDate(firstDueDate, firstNumber+k-1)
+		installment.InterestRate = annualRate
+		installment.OpeningBalance = balance
+
+		if method == models.LoanMethodFlat {
+			installment.Interest = math.Round(principal*rate*100) / 100
+		} else {
+			installment.Interest = math.Round(balance*rate*100) / 100
+		}
+		if method == models.LoanMethodAnnuity {
+			installment.Principal = math.Round((payment-installment.Interest)*100) / 100
+		} else {
+			installment.Principal = equalPrincipal
+		}
+		if k == months-1 || installment.Principal > balance {
+			installment.Principal = balance
+		}
+		if installment.Principal < 0 {
+			installment.Principal = 0
+		}
+
+		installment.Amount = math.Round((installment.Principal+installment.Interest)*100) / 100
+		balance = math.Round((balance-installment.Principal)*100) / 100
+		installment.ClosingBalance = balance
+	}
+	return installments
+}
+
+// BuildLoanSchedule lays out the amortization schedule of a loan over its
+// tenor, one installment a month from the first due date
+func BuildLoanSchedule(loan *models.Loan) []models.LoanInstallment {
+	return loanInstallments(loan.Principal, loan.Principal, loan.InterestRate, loan.Method, loan.FirstDueDate, 1, loan.TenorMonths)
+}
+
+// CalculateCurrentPortion is the principal of the unpaid installments
+// falling due within twelve months of the date
+func CalculateCurrentPortion(installments []models.LoanInstallment, asOfDate time.Time) float64 {
+	horizon := asOfDate.AddDate(0, 12, 0)
+
+	var portion float64
+	for _, installment := range installments {
+		if installment.IsPaid || installment.DueDate.After(horizon) {
+			continue
+		}
+		portion += installment.Principal
+	}
+	return math.Round(portion*100) / 100
+}
+
+// findLoanAccount checks an account chosen for the loan belongs to the
+// company and can be posted to, falling back to the default when none is
+func (s *loanService) findLoanAccount(companyID uint, accountID uint, code string, label string) (*models.Account, error) {
+	if accountID == 0 {
+		account, err := s.accountRepo.FindByCode(companyID, code)
+		if err != nil {
+			return nil, errors.New(label + " account " + code + " not found")
+		}
+		return account, nil
+	}
+
+	account, err := s.accountRepo.FindByID(accountID)
+	if err != nil || account.CompanyID != companyID {
+		return nil, errors.New(label + " account not found")
+	}
+	if account.IsHeader {
+		return nil, errors.New(label + " account cannot be a header account")
+	}
+	return account, nil
+}
+
+// Loan methods
+
+// CreateLoan registers a loan and lays out its schedule. A loan taken over
+// from an earlier register comes with the installments already paid there,
+// which are not posted again; a new one can be disbursed straight into a
+// cash or bank account.
+func (s *loanService) CreateLoan(loan *models.Loan, paidInstallments int, disbursementAccountID uint) error {
+	loan.Lender = strings.TrimSpace(loan.Lender)
+	if loan.Lender == "" {
+		return errors.New("lender is required")
+	}
+	loan.Principal = math.Round(loan.Principal*100) / 100
+	if loan.Principal <= 0 {
+		return errors.New("principal must be greater than zero")
+	}
+	if loan.InterestRate < 0 {
+		return errors.New("interest rate cannot be negative")
+	}
+	if loan.TenorMonths <= 0 {
+		return errors.New("tenor must be greater than zero")
+	}
+	if loan.RateType == "" {
+		loan.RateType = models.LoanRateTypeFixed
+	}
+	if loan.RateType != models.LoanRateTypeFixed && loan.RateType != models.LoanRateTypeFloating {
+		return errors.New("invalid rate type")
+	}
+	if loan.Method != models.LoanMethodAnnuity && loan.Method != models.LoanMethodFlat && loan.Method != models.LoanMethodEffective {
+		return errors.New("invalid loan method")
+	}
+	if loan.FirstDueDate.IsZero() {
+		loan.FirstDueDate = loanDueDate(loan.StartDate, 1)
+	}
+	if !loan.FirstDueDate.After(loan.StartDate) {
+		return errors.New("first due date must be after the start date")
+	}
+	if loan.ReminderDays == 0 {
+		loan.ReminderDays = defaultLoanReminderDays
+	}
+	if loan.ReminderDays < 0 || loan.ReminderDays > maxLoanReminderDays {
+		return fmt.Errorf("reminder days must be between 1 and %d", maxLoanReminderDays)
+	}
+	if paidInstallments < 0 || paidInstallments >= loan.TenorMonths {
+		return errors.New("paid installments must be at least zero and below the tenor")
+	}
+	if paidInstallments > 0 && disbursementAccountID != 0 {
+		return errors.New("a loan taken over with paid installments cannot be disbursed again")
+	}
+	if disbursementAccountID != 0 {
+		if _, err := findCashBankAccount(s.accountRepo, loan.CompanyID, disbursementAccountID); err != nil {
+			return err
+		}
+	}
+
+	account, err := s.findLoanAccount(loan.CompanyID, loan.AccountID, defaultLongTermLoanCode, "loan")
+	if err != nil {
+		return err
+	}
+	if account.Type != models.AccountTypeLiability {
+		return errors.New("loan account must be a liability account")
+	}
+	loan.AccountID = account.ID
+	current, err := s.findLoanAccount(loan.CompanyID, loan.CurrentAccountID, defaultCurrentLoanCode, "current portion")
+	if err != nil {
+		return err
+	}
+	if current.Type != models.AccountTypeLiability {
+		return errors.New("current portion account must be a liability account")
+	}
+	loan.CurrentAccountID = current.ID
+	interest, err := s.findLoanAccount(loan.CompanyID, loan.InterestAccountID, defaultInterestExpenseCode, "interest expense")
+	if err != nil {
+		return err
+	}
+	loan.InterestAccountID = interest.ID
+
+	loan.Installments = BuildLoanSchedule(loan)
+	loan.Outstanding = loan.Principal
+	for i := 0; i < paidInstallments; i++ {
+		installment := &loan.Installments[i]
+		paidDate := installment.DueDate
+		installment.IsPaid = true
+		installment.PaidDate = &paidDate
+		loan.Outstanding = installment.ClosingBalance
+	}
+	loan.CurrentPortion = 0
+	loan.Status = models.LoanStatusActive
+	loan.CashBankTransactionID = nil
+	loan.JournalID = nil
+
+	// The loan and its disbursement are saved together, so a disbursement
+	// that fails leaves no loan behind to be entered again
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		loanNumber, err := txService.loanRepo.GenerateLoanNumber(loan.CompanyID, loan.StartDate)
+		if err != nil {
+			return err
+		}
+		loan.LoanNumber = loanNumber
+
+		if err := txService.loanRepo.CreateLoan(loan); err != nil {
+			return err
+		}
+		if disbursementAccountID == 0 {
+			return nil
+		}
+
+		transaction := &models.CashBankTransaction{
+			CompanyID:       loan.CompanyID,
+			AccountID:       disbursementAccountID,
+			TransactionDate: loan.StartDate,
+			Category:        models.CategoryLoanDisbursement,
+			Amount:          loan.Principal,
+			Description:     "Loan " + loan.LoanNumber + " from " + loan.Lender,
+			Reference:       loan.AgreementNumber,
+			CreatedBy:       loan.CreatedBy,
+		}
+		if err := txService.cashBankService.CreateCashInWithJournal(transaction, loan.AccountID); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(*transaction.JournalID, loan.CreatedBy); err != nil {
+			return err
+		}
+
+		loan.CashBankTransactionID = &transaction.ID
+		loan.JournalID = transaction.JournalID
+		return txService.loanRepo.UpdateLoan(loan)
+	})
+}
+
+func (s *loanService) GetLoanByID(id uint) (*models.Loan, error) {
+	return s.loanRepo.FindLoanByID(id)
+}
+
+func (s *loanService) GetLoans(companyID uint, status string) ([]models.Loan, error) {
+	return s.loanRepo.FindLoans(companyID, status)
+}
+
+// PayLoanInstallment pays the next installment out of a cash or bank
+// account. The principal comes off the current portion first and the long
+// term account for the rest, and the interest is expensed. The journal, the
+// cash transaction and the paid installment are saved together.
+func (s *loanService) PayLoanInstallment(id uint, accountID uint, paymentDate time.Time, reference string, userID uint) (*models.LoanInstallment, error) {
+	loan, err := s.loanRepo.FindLoanByID(id)
+	if err != nil {
+		return nil, errors.New("loan not found")
+	}
+
+	if loan.Status != models.LoanStatusActive {
+		return nil, errors.New("loan has been paid off")
+	}
+
+	var installment *models.LoanInstallment
+	for i := range loan.Installments {
+		if !loan.Installments[i].IsPaid {
+			installment = &loan.Installments[i]
+			break
+		}
+	}
+	if installment == nil {
+		return nil, errors.New("loan has no unpaid installments")
+	}
+
+	cashAccount, err := findCashBankAccount(s.accountRepo, loan.CompanyID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromCurrent := math.Min(installment.Principal, loan.CurrentPortion)
+	description := fmt.Sprintf("Installment %d of loan %s - %s", installment.InstallmentNumber, loan.LoanNumber, loan.Lender)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		journalID, err := postNetJournal(txService.journalService, loan.CompanyID, paymentDate, description, []netJournalLine{
+			{loan.CurrentAccountID, fromCurrent},
+			{loan.AccountID, installment.Principal - fromCurrent},
+			{loan.InterestAccountID, installment.Interest},
+			{cashAccount.ID, -installment.Amount},
+		}, userID)
+		if err != nil {
+			return err
+		}
+
+		transaction := &models.CashBankTransaction{
+			CompanyID:       loan.CompanyID,
+			AccountID:       cashAccount.ID,
+			TransactionDate: paymentDate,
+			Type:            models.TransactionTypeOut,
+			Category:        models.CategoryLoanInstallment,
+			Amount:          installment.Amount,
+			Description:     description,
+			Reference:       reference,
+			JournalID:       journalID,
+			CreatedBy:       userID,
+		}
+		if err := txService.cashBankService.CreateTransaction(transaction); err != nil {
+			return err
+		}
+
+		installment.IsPaid = true
+		installment.PaidDate = &paymentDate
+		installment.JournalID = journalID
+		installment.CashBankTransactionID = &transaction.ID
+		if err := txService.loanRepo.UpdateLoanInstallment(installment); err != nil {
+			return err
+		}
+
+		loan.Outstanding = installment.ClosingBalance
+		loan.CurrentPortion = math.Round((loan.CurrentPortion-fromCurrent)*100) / 100
+		if installment.InstallmentNumber == loan.Installments[len(loan.Installments)-1].InstallmentNumber {
+			loan.Status = models.LoanStatusPaidOff
+		}
+		return txService.loanRepo.UpdateLoan(loan)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return installment, nil
+}
+
+// Rate Change methods
+
+// ChangeLoanRate applies a new rate to a floating loan. The installments
+// falling due from the effective date are laid out again at the new rate
+// over the rest of the tenor.
+func (s *loanService) ChangeLoanRate(id uint, change *models.LoanRateChange) error {
+	loan, err := s.loanRepo.FindLoanByID(id)
+	if err != nil {
+		return errors.New("loan not found")
+	}
+
+	if loan.Status != models.LoanStatusActive {
+		return errors.New("loan has been paid off")
+	}
+	if loan.RateType != models.LoanRateTypeFloating {
+		return errors.New("only floating rate loans can change rate")
+	}
+	if change.NewRate < 0 {
+		return errors.New("interest rate cannot be negative")
+	}
+
+	from := -1
+	for i, installment := range loan.Installments {
+		if installment.DueDate.Before(change.EffectiveDate) {
+			continue
+		}
+		if installment.IsPaid {
+			return errors.New("installments due after the effective date have already been paid")
+		}
+		if from < 0 {
+			from = i
+		}
+	}
+	if from < 0 {
+		return errors.New("no installments fall due after the effective date")
+	}
+
+	balance := loan.Principal
+	if from > 0 {
+		balance = loan.Installments[from-1].ClosingBalance
+	}
+	first := loan.Installments[from].InstallmentNumber
+	installments := loanInstallments(balance, loan.Principal, change.NewRate, loan.Method, loan.FirstDueDate, first, loan.TenorMonths-first+1)
+	for i := range installments {
+		installments[i].LoanID = loan.ID
+	}
+	if err := s.loanRepo.ReplaceInstallmentsFrom(loan.ID, first, installments); err != nil {
+		return err
+	}
+
+	change.LoanID = loan.ID
+	change.PreviousRate = loan.InterestRate
+	if err := s.loanRepo.CreateLoanRateChange(change); err != nil {
+		return err
+	}
+
+	loan.InterestRate = change.NewRate
+	return s.loanRepo.UpdateLoan(loan)
+}
+
+func (s *loanService) GetLoanRateChanges(id uint) ([]models.LoanRateChange, error) {
+	return s.loanRepo.FindLoanRateChanges(id)
+}
+
+// Reclassification methods
+
+// RunReclassification moves the principal falling due within twelve months
+// of the period end to current liabilities and posts the change in each
+// loan's current portion as one journal
+func (s *loanService) RunReclassification(companyID uint, period string, userID uint) (*models.LoanReclassification, error) {
+	_, periodEnd, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.loanRepo.FindLatestLoanReclassification(companyID)
+	if err == nil && latest.Period >= period {
+		return nil, errors.New("current portion has already been reclassified for " + latest.Period)
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	loans, err := s.loanRepo.FindActiveLoans(companyID, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	if len(loans) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoLoansToReclassify, period)
+	}
+
+	reclassification := &models.LoanReclassification{
+		CompanyID: companyID,
+		Period:    period,
+		PeriodEnd: periodEnd,
+		CreatedBy: userID,
+	}
+	var journalLines []netJournalLine
+	for i := range loans {
+		loan := &loans[i]
+		line := models.LoanReclassificationLine{
+			LoanID:                 loan.ID,
+			Outstanding:            loan.Outstanding,
+			PreviousCurrentPortion: loan.CurrentPortion,
+			CurrentPortion:         CalculateCurrentPortion(loan.Installments, periodEnd),
+		}
+		line.Amount = math.Round((line.CurrentPortion-line.PreviousCurrentPortion)*100) / 100
+		reclassification.Lines = append(reclassification.Lines, line)
+		reclassification.TotalCurrentPortion += line.CurrentPortion
+		reclassification.TotalAdjustment += line.Amount
+
+		journalLines = append(journalLines,
+			netJournalLine{loan.AccountID, line.Amount},
+			netJournalLine{loan.CurrentAccountID, -line.Amount},
+		)
+		loan.CurrentPortion = line.CurrentPortion
+	}
+	reclassification.TotalCurrentPortion = math.Round(reclassification.TotalCurrentPortion*100) / 100
+	reclassification.TotalAdjustment = math.Round(reclassification.TotalAdjustment*100) / 100
+
+	// The journal and the run that records the new current portions are
+	// saved together, so a failed run leaves nothing posted to run again over
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		journalID, err := postNetJournal(txService.journalService, companyID, periodEnd, "Current portion of long-term loans "+period, journalLines, userID)
+		if err != nil {
+			return err
+		}
+		reclassification.JournalID = journalID
+
+		if err := txService.loanRepo.CreateLoanReclassification(reclassification); err != nil {
+			return err
+		}
+		for i := range loans {
+			if err := txService.loanRepo.UpdateLoan(&loans[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return reclassification, nil
+}
+
+// ProcessReclassification is the scheduled run, month by month through
+// last month, posted as the given (system) user. It carries on from the
+// runs made by hand, so nothing happens before the first one.
+func (s *loanService) ProcessReclassification(companyID, userID uint) error {
+	latest, err := s.loanRepo.FindLatestLoanReclassification(companyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return runPendingPeriods(latest.Period, ErrNoLoansToReclassify, func(period string) error {
+		_, err := s.RunReclassification(companyID, period, userID)
+		return err
+	})
+}
+
+func (s *loanService) GetReclassificationByID(id uint) (*models.LoanReclassification, error) {
+	return s.loanRepo.FindLoanReclassificationByID(id)
+}
+
+func (s *loanService) GetReclassifications(companyID uint) ([]models.LoanReclassification, error) {
+	return s.loanRepo.FindLoanReclassifications(companyID)
+}
\ No newline at end of file
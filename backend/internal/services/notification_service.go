@@ -3,6 +3,7 @@ package services
 import (
 	"finara-backend/internal/models"
 	"finara-backend/internal/repository"
+	"strconv"
 	"time"
 )
 
@@ -16,23 +17,36 @@ type NotificationService interface {
 	DeleteNotification(id uint) error
 	GetUnreadCount(userID uint) (int64, error)
 	CheckAndCreateTaxDueNotifications(companyID uint) error
+	CheckAndCreateLotExpiryNotifications(companyID uint) error
+	CheckAndCreateLowStockNotifications(companyID uint) error
+	CheckProductStockLevel(productID uint) error
+	CheckAndCreateLoanDueNotifications(companyID uint) error
 }
 
+// Lots are reported this many days before expiry unless the product sets its own window
+const defaultExpiryWarningDays = 30
+
 type notificationService struct {
 	notificationRepo repository.NotificationRepository
 	taxRepo          repository.TaxRepository
 	userRepo         repository.UserRepository
+	inventoryRepo    repository.InventoryRepository
+	loanRepo         repository.LoanRepository
 }
 
 func NewNotificationService(
 	notificationRepo repository.NotificationRepository,
 	taxRepo repository.TaxRepository,
 	userRepo repository.UserRepository,
+	inventoryRepo repository.InventoryRepository,
+	loanRepo repository.LoanRepository,
 ) NotificationService {
 	return &notificationService{
 		notificationRepo: notificationRepo,
 		taxRepo:          taxRepo,
 		userRepo:         userRepo,
+		inventoryRepo:    inventoryRepo,
+		loanRepo:         loanRepo,
 	}
 }
 
@@ -112,4 +126,232 @@ func (s *notificationService) CheckAndCreateTaxDueNotifications(companyID uint)
 	}
 
 	return nil
+}
+
+func (s *notificationService) CheckAndCreateLotExpiryNotifications(companyID uint) error {
+	lots, err := s.inventoryRepo.FindLots(companyID, 0, 0, nil)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := s.companyRecipients(companyID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, lot := range lots {
+		if lot.ExpiryDate == nil {
+			continue
+		}
+
+		warningDays := lot.Product.ExpiryWarningDays
+		if warningDays <= 0 {
+			warningDays = defaultExpiryWarningDays
+		}
+		if lot.ExpiryDate.After(now.AddDate(0, 0, warningDays)) {
+			continue
+		}
+
+		// Expiring soon and expired are separate alerts, so the first does not
+		// hold back the second
+		notificationType := models.NotificationTypeLotExpiry
+		title := "Lot Mendekati Kedaluwarsa"
+		status := "akan kedaluwarsa pada "
+		if lot.ExpiryDate.Before(now) {
+			notificationType = models.NotificationTypeLotExpired
+			title = "Lot Telah Kedaluwarsa"
+			status = "telah kedaluwarsa sejak "
+		}
+
+		for _, user := range recipients {
+			// One notification per lot, user and alert, the check runs repeatedly
+			exists, err := s.notificationRepo.ExistsForRelated(user.ID, notificationType, "stock_lot", lot.ID)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+
+			notification := &models.Notification{
+				CompanyID:   companyID,
+				UserID:      user.ID,
+				Type:        notificationType,
+				Title:       title,
+				Message:     "Lot " + lot.LotNumber + " produk " + lot.Product.Name + " di gudang " + lot.Warehouse.Name + " (" + strconv.FormatFloat(lot.Quantity, 'f', -1, 64) + " " + lot.Product.Unit + ") " + status + lot.ExpiryDate.Format("2006-01-02"),
+				Status:      models.NotificationStatusUnread,
+				RelatedID:   &lot.ID,
+				RelatedType: "stock_lot",
+			}
+
+			if err := s.notificationRepo.Create(notification); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *notificationService) CheckAndCreateLowStockNotifications(companyID uint) error {
+	products, err := s.inventoryRepo.FindProductsByCompanyID(companyID)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := s.companyRecipients(companyID)
+	if err != nil {
+		return err
+	}
+
+	for i := range products {
+		if !products[i].IsActive {
+			continue
+		}
+		if err := s.notifyLowStock(&products[i], recipients); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CheckProductStockLevel runs the low stock check for one product, used
+// right after a movement reduces its stock
+func (s *notificationService) CheckProductStockLevel(productID uint) error {
+	product, err := s.inventoryRepo.FindProductByID(productID)
+	if err != nil {
+		return err
+	}
+
+	recipients, err := s.companyRecipients(product.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	return s.notifyLowStock(product, recipients)
+}
+
+func (s *notificationService) notifyLowStock(product *models.Product, recipients []models.User) error {
+	if product.MinStock <= 0 && product.ReorderPoint <= 0 {
+		return nil
+	}
+
+	balance, err := s.inventoryRepo.GetProductStockTotal(product.ID)
+	if err != nil {
+		return err
+	}
+
+	var title, condition string
+	switch {
+	case product.MinStock > 0 && balance.Quantity < product.MinStock:
+		title = "Stok Di Bawah Minimum"
+		condition = "di bawah stok minimum " + strconv.FormatFloat(product.MinStock, 'f', -1, 64)
+	case product.ReorderPoint > 0 && balance.Quantity <= product.ReorderPoint:
+		title = "Stok Mencapai Titik Pemesanan Ulang"
+		condition = "mencapai titik pemesanan ulang " + strconv.FormatFloat(product.ReorderPoint, 'f', -1, 64)
+	default:
+		return nil
+	}
+
+	for _, user := range recipients {
+		// Remind again only after the previous alert was read
+		exists, err := s.notificationRepo.ExistsUnreadForRelated(user.ID, models.NotificationTypeLowStock, "product", product.ID)
+		if err != nil {
+			return err
+		}
+		if exists {
+			continue
+		}
+
+		notification := &models.Notification{
+			CompanyID:   product.CompanyID,
+			UserID:      user.ID,
+			Type:        models.NotificationTypeLowStock,
+			Title:       title,
+			Message:     "Stok " + product.Code + " - " + product.Name + " tinggal " + strconv.FormatFloat(balance.Quantity, 'f', -1, 64) + " " + product.Unit + ", " + condition,
+			Status:      models.NotificationStatusUnread,
+			RelatedID:   &product.ID,
+			RelatedType: "product",
+		}
+
+		if err := s.notificationRepo.Create(notification); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *notificationService) CheckAndCreateLoanDueNotifications(companyID uint) error {
+	now := time.Now()
+	installments, err := s.loanRepo.FindUnpaidInstallmentsDueBy(companyID, now.AddDate(0, 0, maxLoanReminderDays))
+	if err != nil {
+		return err
+	}
+
+	recipients, err := s.companyRecipients(companyID)
+	if err != nil {
+		return err
+	}
+
+	for _, installment := range installments {
+		reminderDays := installment.Loan.ReminderDays
+		if reminderDays <= 0 {
+			reminderDays = defaultLoanReminderDays
+		}
+		if installment.DueDate.After(now.AddDate(0, 0, reminderDays)) {
+			continue
+		}
+
+		status := "akan jatuh tempo pada "
+		if installment.DueDate.Before(now) {
+			status = "telah jatuh tempo sejak "
+		}
+
+		for _, user := range recipients {
+			// One notification per installment and user, the check runs repeatedly
+			exists, err := s.notificationRepo.ExistsForRelated(user.ID, models.NotificationTypeLoanDue, "loan_installment", installment.ID)
+			if err != nil {
+				return err
+			}
+			if exists {
+				continue
+			}
+
+			notification := &models.Notification{
+				CompanyID:   companyID,
+				UserID:      user.ID,
+				Type:        models.NotificationTypeLoanDue,
+				Title:       "Angsuran Pinjaman Jatuh Tempo",
+				Message:     "Angsuran ke-" + strconv.Itoa(installment.InstallmentNumber) + " pinjaman " + installment.Loan.LoanNumber + " (" + installment.Loan.Lender + ") sebesar " + strconv.FormatFloat(installment.Amount, 'f', 2, 64) + " " + status + installment.DueDate.Format("2006-01-02"),
+				Status:      models.NotificationStatusUnread,
+				RelatedID:   &installment.ID,
+				RelatedType: "loan_installment",
+			}
+
+			if err := s.notificationRepo.Create(notification); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// companyRecipients returns the admin and accountant users of a company
+func (s *notificationService) companyRecipients(companyID uint) ([]models.User, error) {
+	users, err := s.userRepo.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var recipients []models.User
+	for _, user := range users {
+		if user.CompanyID == companyID && (user.Role == models.RoleAdmin || user.Role == models.RoleAccountant) {
+			recipients = append(recipients, user)
+		}
+	}
+	return recipients, nil
 }
\ No newline at end of file
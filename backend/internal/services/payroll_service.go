@@ -0,0 +1,712 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PayrollService interface {
+	// Salary Components
+	CreateSalaryComponent(component *models.SalaryComponent) error
+	GetSalaryComponents(companyID uint, activeOnly bool) ([]models.SalaryComponent, error)
+	UpdateSalaryComponent(id uint, component *models.SalaryComponent) error
+
+	// Employees
+	CreateEmployee(employee *models.Employee) error
+	GetEmployeeByID(id uint) (*models.Employee, error)
+	GetEmployees(companyID uint, department string, activeOnly bool) ([]models.Employee, error)
+	UpdateEmployee(id uint, employee *models.Employee) error
+	DeleteEmployee(id uint) error
+	GetEmployeePayslips(employeeID uint, year int) ([]models.Payslip, error)
+
+	// Payroll Settings
+	GetPayrollSetting(companyID uint) (*models.PayrollSetting, error)
+	SetPayrollSetting(setting *models.PayrollSetting) (*models.PayrollSetting, error)
+
+	// Payroll Runs
+	CreatePayrollRun(run *models.PayrollRun) error
+	GetPayrollRunByID(id uint) (*models.PayrollRun, error)
+	GetPayrollRuns(companyID uint, year int) ([]models.PayrollRun, error)
+	DeletePayrollRun(id uint) error
+	PostPayrollRun(id uint, userID uint) error
+	PayPayrollRun(id uint, accountID uint, paymentDate time.Time, reference string, userID uint) error
+	GetPayslipByID(id uint) (*models.Payslip, error)
+}
+
+const (
+	defaultSalaryExpenseCode   = "5-1100" // Beban Gaji
+	defaultBPJSExpenseCode     = "5-1700" // Beban BPJS
+	defaultSalariesPayableCode = "2-1500" // Utang Gaji
+	defaultBPJSPayableCode     = "2-1600" // Utang BPJS
+	defaultPPh21PayableCode    = "2-1700" // Utang PPh 21
+)
+
+// BPJS contribution rates, as percentages of the wage. The JKK rate depends
+// on the work risk and is in the payroll settings.
+const (
+	bpjsHealthEmployerRate = 4
+	bpjsHealthEmployeeRate = 1
+	bpjsJHTEmployerRate    = 3.7
+	bpjsJHTEmployeeRate    = 2
+	bpjsJKMEmployerRate    = 0.3
+	bpjsJPEmployerRate     = 2
+	bpjsJPEmployeeRate     = 1
+)
+
+// Biaya jabatan is 5% of gross income, up to Rp 500.000 a month
+const (
+	occupationalAllowanceRate       = 5
+	occupationalAllowanceMonthlyCap = 500000
+)
+
+// PTKPAmounts is the annual penghasilan tidak kena pajak of each status
+var PTKPAmounts = map[models.PTKPStatus]float64{
+	models.PTKPStatusTK0: 54000000,
+	models.PTKPStatusTK1: 58500000,
+	models.PTKPStatusTK2: 63000000,
+	models.PTKPStatusTK3: 67500000,
+	models.PTKPStatusK0:  58500000,
+	models.PTKPStatusK1:  63000000,
+	models.PTKPStatusK2:  67500000,
+	models.PTKPStatusK3:  72000000,
+}
+
+// taxBracket is a rate applied up to an amount
+type taxBracket struct {
+	upTo float64
+	rate float64
+}
+
+// Tarif efektif rata-rata of PP 58/2023 for monthly PPh 21, by category, on
+// the month's gross income
+var terBrackets = map[string][]taxBracket{
+	"A": {
+		{5400000, 0}, {5650000, 0.25}, {5950000, 0.5}, {6300000, 0.75}, {6750000, 1},
+		{7500000, 1.25}, {8550000, 1.5}, {9650000, 1.75}, {10050000, 2}, {10350000, 2.25},
+		{10700000, 2.5}, {11050000, 3}, {11600000, 3.5}, {12500000, 4}, {13750000, 5},
+		{15100000, 6}, {16950000, 7}, {19750000, 8}, {24150000, 9}, {26450000, 10},
+		{28000000, 11}, {30050000, 12}, {32400000, 13}, {35400000, 14}, {39100000, 15},
+		{43850000, 16}, {47800000, 17}, {51400000, 18}, {56300000, 19}, {62200000, 20},
+		{68600000, 21}, {77500000, 22}, {89000000, 23}, {103000000, 24}, {125000000, 25},
+		{157000000, 26}, {206000000, 27}, {337000000, 28}, {454000000, 29}, {550000000, 30},
+		{695000000, 31}, {910000000, 32}, {1400000000, 33}, {math.Inf(1), 34},
+	},
+	"B": {
+		{6200000, 0}, {6500000, 0.25}, {6850000, 0.5}, {7300000, 0.75}, {9200000, 1},
+		{10750000, 1.5}, {11250000, 2}, {11600000, 2.5}, {12600000, 3}, {13600000, 4},
+		{14950000, 5}, {16400000, 6}, {18450000, 7}, {21850000, 8}, {26000000, 9},
+		{27700000, 10}, {29350000, 11}, {31450000, 12}, {33950000, 13}, {37100000, 14},
+		{41100000, 15}, {45800000, 16}, {49500000, 17}, {53800000, 18}, {58500000, 19},
+		{64000000, 20}, {71000000, 21}, {80000000, 22}, {93000000, 23}, {109000000, 24},
+		{129000000, 25}, {163000000, 26}, {211000000, 27}, {374000000, 28}, {459000000, 29},
+		{555000000, 30}, {704000000, 31}, {957000000, 32}, {1405000000, 33}, {math.Inf(1), 34},
+	},
+	"C": {
+		{6600000, 0}, {6950000, 0.25}, {7350000, 0.5}, {7800000, 0.75}, {8850000, 1},
+		{9800000, 1.25}, {10950000, 1.5}, {11200000, 1.75}, {12050000, 2}, {12950000, 3},
+		{14150000, 4}, {15550000, 5}, {17050000, 6}, {19500000, 7}, {22700000, 8},
+		{26600000, 9}, {28100000, 10}, {30100000, 11}, {32600000, 12}, {35400000, 13},
+		{38900000, 14}, {43000000, 15}, {47400000, 16}, {51200000, 17}, {55800000, 18},
+		{60400000, 19}, {66700000, 20}, {74500000, 21}, {83200000, 22}, {95600000, 23},
+		{110000000, 24}, {134000000, 25}, {169000000, 26}, {221000000, 27}, {390000000, 28},
+		{463000000, 29}, {561000000, 30}, {709000000, 31}, {965000000, 32}, {1419000000, 33},
+		{math.Inf(1), 34},
+	},
+}
+
+// Progressive rates of PPh Pasal 17 on annual taxable income
+var pasal17Brackets = []taxBracket{
+	{60000000, 5}, {250000000, 15}, {500000000, 25}, {5000000000, 30}, {math.Inf(1), 35},
+}
+
+type payrollService struct {
+	db              *gorm.DB
+	payrollRepo     repository.PayrollRepository
+	accountRepo     repository.AccountRepository
+	journalService  JournalService
+	cashBankService CashBankService
+	taxService      TaxService
+}
+
+func NewPayrollService(
+	db *gorm.DB,
+	payrollRepo repository.PayrollRepository,
+	accountRepo repository.AccountRepository,
+	journalService JournalService,
+	cashBankService CashBankService,
+	taxService TaxService,
+) PayrollService {
+	return &payrollService{
+		db:              db,
+		payrollRepo:     payrollRepo,
+		accountRepo:     accountRepo,
+		journalService:  journalService,
+		cashBankService: cashBankService,
+		taxService:      taxService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so a payment
+// and the journal it posts commit or roll back together
+func (s *payrollService) withTx(tx *gorm.DB) *payrollService {
+	return &payrollService{
+		db:              tx,
+		payrollRepo:     repository.NewPayrollRepository(tx),
+		accountRepo:     repository.NewAccountRepository(tx),
+		journalService:  journalServiceWith(tx),
+		cashBankService: cashBankServiceWith(tx),
+		taxService:      s.taxService,
+	}
+}
+
+// TERCategory returns the TER category of a PTKP status
+func TERCategory(status models.PTKPStatus) string {
+	switch status {
+	case models.PTKPStatusTK0, models.PTKPStatusTK1, models.PTKPStatusK0:
+		return "A"
+	case models.PTKPStatusTK2, models.PTKPStatusTK3, models.PTKPStatusK1, models.PTKPStatusK2:
+		return "B"
+	default:
+		return "C"
+	}
+}
+
+// TERRate returns the monthly PPh 21 rate of the category for a gross income
+func TERRate(category string, grossIncome float64) float64 {
+	for _, bracket := range terBrackets[category] {
+		if grossIncome <= bracket.upTo {
+			return bracket.rate
+		}
+	}
+	return 0
+}
+
+// CalculateAnnualPPh21 is the Pasal 17 tax on a year's gross income, after
+// biaya jabatan for the months worked, the employee's JHT and JP
+// contributions and PTKP. Taxable income is rounded down to the thousand.
+func CalculateAnnualPPh21(status models.PTKPStatus, grossIncome, pensionContributions float64, months int) float64 {
+	occupational := math.Min(grossIncome*occupationalAllowanceRate/100, float64(months)*occupationalAllowanceMonthlyCap)
+	taxable := math.Floor((grossIncome-occupational-pensionContributions-PTKPAmounts[status])/1000) * 1000
+	if taxable <= 0 {
+		return 0
+	}
+
+	var tax, lower float64
+	for _, bracket := range pasal17Brackets {
+		if taxable <= lower {
+			break
+		}
+		tax += (math.Min(taxable, bracket.upTo) - lower) * bracket.rate / 100
+		lower = bracket.upTo
+	}
+	return math.Floor(tax)
+}
+
+// bpjsContribution is the rate of a wage, capped at the ceiling when above zero
+func bpjsContribution(wage, ceiling, rate float64) float64 {
+	if ceiling > 0 && wage > ceiling {
+		wage = ceiling
+	}
+	return math.Round(wage*rate) / 100
+}
+
+// CalculatePayslip works out an employee's pay for the month starting on
+// periodStart from the salary components and the year to date. Allowances
+// and deductions post to their component's account or salaryAccountID.
+func CalculatePayslip(employee *models.Employee, setting *models.PayrollSetting, periodStart time.Time, ytd *models.PayrollYearToDate, salaryAccountID uint) *models.Payslip {
+	payslip := &models.Payslip{
+		EmployeeID:  employee.ID,
+		Period:      periodStart.Format("2006-01"),
+		PTKPStatus:  employee.PTKPStatus,
+		BaseSalary:  employee.BaseSalary,
+		TERCategory: TERCategory(employee.PTKPStatus),
+	}
+
+	taxableEarnings := employee.BaseSalary
+	for _, esc := range employee.Components {
+		component := esc.Component
+		line := models.PayslipLine{
+			ComponentID: component.ID,
+			Code:        component.Code,
+			Name:        component.Name,
+			Type:        component.Type,
+			IsTaxable:   component.IsTaxable,
+			AccountID:   salaryAccountID,
+			Amount:      esc.Amount,
+		}
+		if component.AccountID != nil {
+			line.AccountID = *component.AccountID
+		}
+		if component.Type == models.SalaryComponentAllowance {
+			payslip.Allowances += esc.Amount
+			if component.IsTaxable {
+				taxableEarnings += esc.Amount
+			}
+		} else {
+			payslip.Deductions += esc.Amount
+		}
+		payslip.Lines = append(payslip.Lines, line)
+	}
+
+	// Contributions are on the fixed monthly pay
+	wage := employee.BaseSalary + payslip.Allowances
+	if employee.HealthInsured {
+		payslip.HealthEmployer = bpjsContribution(wage, setting.HealthWageCap, bpjsHealthEmployerRate)
+		payslip.HealthEmployee = bpjsContribution(wage, setting.HealthWageCap, bpjsHealthEmployeeRate)
+	}
+	if employee.EmploymentInsured {
+		payslip.JHTEmployer = bpjsContribution(wage, 0, bpjsJHTEmployerRate)
+		payslip.JHTEmployee = bpjsContribution(wage, 0, bpjsJHTEmployeeRate)
+		payslip.JKKEmployer = bpjsContribution(wage, 0, setting.JKKRate)
+		payslip.JKMEmployer = bpjsContribution(wage, 0, bpjsJKMEmployerRate)
+	}
+	if employee.PensionInsured {
+		payslip.JPEmployer = bpjsContribution(wage, setting.PensionWageCap, bpjsJPEmployerRate)
+		payslip.JPEmployee = bpjsContribution(wage, setting.PensionWageCap, bpjsJPEmployeeRate)
+	}
+
+	payslip.GrossIncome = math.Round((taxableEarnings+payslip.HealthEmployer+payslip.JKKEmployer+payslip.JKMEmployer)*100) / 100
+
+	// December and the last month of employment settle the year's tax
+	periodEnd := periodStart.AddDate(0, 1, -1)
+	leaving := employee.EndDate != nil && !employee.EndDate.After(periodEnd)
+	if periodStart.Month() == time.December || leaving {
+		payslip.IsAnnualCalculation = true
+		annual := CalculateAnnualPPh21(
+			employee.PTKPStatus,
+			ytd.GrossIncome+payslip.GrossIncome,
+			ytd.PensionContributions+payslip.JHTEmployee+payslip.JPEmployee,
+			ytd.Months+1,
+		)
+		payslip.PPh21 = math.Round((annual-ytd.PPh21)*100) / 100
+	} else {
+		payslip.TERRate = TERRate(payslip.TERCategory, payslip.GrossIncome)
+		payslip.PPh21 = math.Floor(payslip.GrossIncome * payslip.TERRate / 100)
+	}
+
+	payslip.NetPay = math.Round((payslip.BaseSalary+payslip.Allowances-payslip.Deductions-payslip.EmployeeContributions()-payslip.PPh21)*100) / 100
+	return payslip
+}
+
+// findPayrollAccount looks up one of the accounts payroll posts to
+func (s *payrollService) findPayrollAccount(companyID uint, code string, label string) (uint, error) {
+	account, err := s.accountRepo.FindByCode(companyID, code)
+	if err != nil {
+		return 0, errors.New(label + " account " + code + " not found")
+	}
+	return account.ID, nil
+}
+
+func (s *payrollService) validateSalaryComponent(component *models.SalaryComponent) error {
+	switch component.Type {
+	case models.SalaryComponentAllowance:
+	case models.SalaryComponentDeduction:
+		// Deductions come out of net pay and leave gross income as it is
+		component.IsTaxable = false
+	default:
+		return errors.New("component type must be allowance or deduction")
+	}
+
+	if component.AccountID != nil {
+		account, err := s.accountRepo.FindByID(*component.AccountID)
+		if err != nil || account.CompanyID != component.CompanyID {
+			return errors.New("component account not found")
+		}
+		if account.IsHeader {
+			return errors.New("component account cannot be a header account")
+		}
+	}
+	return nil
+}
+
+// Salary Component methods
+func (s *payrollService) CreateSalaryComponent(component *models.SalaryComponent) error {
+	if err := s.validateSalaryComponent(component); err != nil {
+		return err
+	}
+	return s.payrollRepo.CreateSalaryComponent(component)
+}
+
+func (s *payrollService) GetSalaryComponents(companyID uint, activeOnly bool) ([]models.SalaryComponent, error) {
+	return s.payrollRepo.FindSalaryComponents(companyID, activeOnly)
+}
+
+// UpdateSalaryComponent changes a component for the runs ahead; payslips
+// keep what they were paid
+func (s *payrollService) UpdateSalaryComponent(id uint, updated *models.SalaryComponent) error {
+	component, err := s.payrollRepo.FindSalaryComponentByID(id)
+	if err != nil {
+		return errors.New("salary component not found")
+	}
+
+	component.Code = updated.Code
+	component.Name = updated.Name
+	component.Type = updated.Type
+	component.IsTaxable = updated.IsTaxable
+	component.AccountID = updated.AccountID
+	component.IsActive = updated.IsActive
+	if err := s.validateSalaryComponent(component); err != nil {
+		return err
+	}
+
+	return s.payrollRepo.UpdateSalaryComponent(component)
+}
+
+// Employee methods
+
+// validateEmployee checks the employee and its salary components, which must
+// be the company's active components, each listed once
+func (s *payrollService) validateEmployee(employee *models.Employee) error {
+	if _, ok := PTKPAmounts[employee.PTKPStatus]; !ok {
+		return errors.New("invalid PTKP status")
+	}
+	if employee.BaseSalary <= 0 {
+		return errors.New("base salary must be greater than zero")
+	}
+	if employee.EndDate != nil && employee.EndDate.Before(employee.JoinDate) {
+		return errors.New("end date cannot be before the join date")
+	}
+
+	seen := make(map[uint]bool)
+	for i := range employee.Components {
+		esc := &employee.Components[i]
+		if seen[esc.ComponentID] {
+			return errors.New("salary component is listed more than once")
+		}
+		seen[esc.ComponentID] = true
+
+		component, err := s.payrollRepo.FindSalaryComponentByID(esc.ComponentID)
+		if err != nil || component.CompanyID != employee.CompanyID {
+			return errors.New("salary component not found")
+		}
+		if !component.IsActive {
+			return errors.New("salary component " + component.Code + " is inactive")
+		}
+		if esc.Amount <= 0 {
+			return errors.New("component amount must be greater than zero")
+		}
+		esc.Amount = math.Round(esc.Amount*100) / 100
+	}
+	return nil
+}
+
+func (s *payrollService) CreateEmployee(employee *models.Employee) error {
+	if err := s.validateEmployee(employee); err != nil {
+		return err
+	}
+	return s.payrollRepo.CreateEmployee(employee)
+}
+
+func (s *payrollService) GetEmployeeByID(id uint) (*models.Employee, error) {
+	return s.payrollRepo.FindEmployeeByID(id)
+}
+
+func (s *payrollService) GetEmployees(companyID uint, department string, activeOnly bool) ([]models.Employee, error) {
+	return s.payrollRepo.FindEmployees(companyID, department, activeOnly)
+}
+
+func (s *payrollService) UpdateEmployee(id uint, updated *models.Employee) error {
+	employee, err := s.payrollRepo.FindEmployeeByID(id)
+	if err != nil {
+		return errors.New("employee not found")
+	}
+
+	employee.Code = updated.Code
+	employee.Name = updated.Name
+	employee.NIK = updated.NIK
+	employee.NPWP = updated.NPWP
+	employee.Position = updated.Position
+	employee.Department = updated.Department
+	employee.JoinDate = updated.JoinDate
+	employee.EndDate = updated.EndDate
+	employee.PTKPStatus = updated.PTKPStatus
+	employee.BaseSalary = updated.BaseSalary
+	employee.HealthInsured = updated.HealthInsured
+	employee.EmploymentInsured = updated.EmploymentInsured
+	employee.PensionInsured = updated.PensionInsured
+	employee.BankName = updated.BankName
+	employee.BankAccountNumber = updated.BankAccountNumber
+	employee.BankAccountName = updated.BankAccountName
+	employee.IsActive = updated.IsActive
+	employee.Notes = updated.Notes
+	employee.Components = updated.Components
+	if err := s.validateEmployee(employee); err != nil {
+		return err
+	}
+
+	return s.payrollRepo.UpdateEmployee(employee)
+}
+
+func (s *payrollService) DeleteEmployee(id uint) error {
+	count, err := s.payrollRepo.CountEmployeePayslips(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("employee has payslips, deactivate it instead")
+	}
+	return s.payrollRepo.DeleteEmployee(id)
+}
+
+func (s *payrollService) GetEmployeePayslips(employeeID uint, year int) ([]models.Payslip, error) {
+	return s.payrollRepo.FindEmployeePayslips(employeeID, year)
+}
+
+// Payroll Setting methods
+func (s *payrollService) GetPayrollSetting(companyID uint) (*models.PayrollSetting, error) {
+	return s.payrollRepo.FindPayrollSetting(companyID)
+}
+
+func (s *payrollService) SetPayrollSetting(updated *models.PayrollSetting) (*models.PayrollSetting, error) {
+	if updated.JKKRate < 0.24 || updated.JKKRate > 1.74 {
+		return nil, errors.New("JKK rate must be between 0.24 and 1.74")
+	}
+	if updated.HealthWageCap <= 0 || updated.PensionWageCap <= 0 {
+		return nil, errors.New("wage caps must be greater than zero")
+	}
+
+	setting, err := s.payrollRepo.FindPayrollSetting(updated.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+	setting.JKKRate = updated.JKKRate
+	setting.HealthWageCap = updated.HealthWageCap
+	setting.PensionWageCap = updated.PensionWageCap
+
+	if err := s.payrollRepo.SavePayrollSetting(setting); err != nil {
+		return nil, err
+	}
+	return setting, nil
+}
+
+// Payroll Run methods
+
+// CreatePayrollRun computes the period's payslips as a draft. Runs follow
+// each other month by month, since December settles the year on the
+// payslips posted before it.
+func (s *payrollService) CreatePayrollRun(run *models.PayrollRun) error {
+	periodStart, periodEnd, err := parsePeriod(run.Period)
+	if err != nil {
+		return err
+	}
+
+	latest, err := s.payrollRepo.FindLatestPayrollRun(run.CompanyID)
+	if err == nil {
+		if latest.Status == models.PayrollRunStatusDraft {
+			return errors.New("payroll run " + latest.RunNumber + " is still a draft")
+		}
+		if latest.Period >= run.Period {
+			return errors.New("payroll has already been run for " + latest.Period)
+		}
+	}
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	setting, err := s.payrollRepo.FindPayrollSetting(run.CompanyID)
+	if err != nil {
+		return err
+	}
+	salaryAccountID, err := s.findPayrollAccount(run.CompanyID, defaultSalaryExpenseCode, "salary expense")
+	if err != nil {
+		return err
+	}
+
+	employees, err := s.payrollRepo.FindPayableEmployees(run.CompanyID, periodStart, periodEnd)
+	if err != nil {
+		return err
+	}
+	if len(employees) == 0 {
+		return errors.New("no employees to pay for " + run.Period)
+	}
+
+	for i := range employees {
+		ytd, err := s.payrollRepo.SumEmployeeYearToDate(employees[i].ID, run.Period)
+		if err != nil {
+			return err
+		}
+		payslip := CalculatePayslip(&employees[i], setting, periodStart, ytd, salaryAccountID)
+		run.Payslips = append(run.Payslips, *payslip)
+
+		run.TotalEarnings += payslip.BaseSalary + payslip.Allowances
+		run.TotalDeductions += payslip.Deductions
+		run.TotalEmployerContributions += payslip.EmployerContributions()
+		run.TotalEmployeeContributions += payslip.EmployeeContributions()
+		run.TotalGrossIncome += payslip.GrossIncome
+		run.TotalPPh21 += payslip.PPh21
+		run.TotalNetPay += payslip.NetPay
+	}
+	run.TotalEarnings = math.Round(run.TotalEarnings*100) / 100
+	run.TotalDeductions = math.Round(run.TotalDeductions*100) / 100
+	run.TotalEmployerContributions = math.Round(run.TotalEmployerContributions*100) / 100
+	run.TotalEmployeeContributions = math.Round(run.TotalEmployeeContributions*100) / 100
+	run.TotalGrossIncome = math.Round(run.TotalGrossIncome*100) / 100
+	run.TotalPPh21 = math.Round(run.TotalPPh21*100) / 100
+	run.TotalNetPay = math.Round(run.TotalNetPay*100) / 100
+
+	runNumber, err := s.payrollRepo.GeneratePayrollRunNumber(run.CompanyID, run.PayDate)
+	if err != nil {
+		return err
+	}
+	run.RunNumber = runNumber
+	run.Status = models.PayrollRunStatusDraft
+
+	return s.payrollRepo.CreatePayrollRun(run)
+}
+
+func (s *payrollService) GetPayrollRunByID(id uint) (*models.PayrollRun, error) {
+	return s.payrollRepo.FindPayrollRunByID(id)
+}
+
+func (s *payrollService) GetPayrollRuns(companyID uint, year int) ([]models.PayrollRun, error) {
+	return s.payrollRepo.FindPayrollRuns(companyID, year)
+}
+
+// DeletePayrollRun discards a draft run, to run the period again after
+// employees or components change
+func (s *payrollService) DeletePayrollRun(id uint) error {
+	run, err := s.payrollRepo.FindPayrollRunByID(id)
+	if err != nil {
+		return errors.New("payroll run not found")
+	}
+	if run.Status != models.PayrollRunStatusDraft {
+		return errors.New("only draft payroll runs can be deleted")
+	}
+	return s.payrollRepo.DeletePayrollRun(id)
+}
+
+// PostPayrollRun accrues the run at the end of the period, or on the pay
+// date when salaries are paid before then. Salaries and allowances are
+// expensed with the employer's BPJS; BPJS, PPh 21, deductions and net pay
+// are credited to what is owed. The PPh 21 withheld is then recorded as the
+// period's tax.
+func (s *payrollService) PostPayrollRun(id uint, userID uint) error {
+	run, err := s.payrollRepo.FindPayrollRunByID(id)
+	if err != nil {
+		return errors.New("payroll run not found")
+	}
+	if run.Status != models.PayrollRunStatusDraft {
+		return errors.New("only draft payroll runs can be posted")
+	}
+
+	_, periodEnd, err := parsePeriod(run.Period)
+	if err != nil {
+		return err
+	}
+	journalDate := periodEnd
+	if run.PayDate.Before(periodEnd) {
+		journalDate = run.PayDate
+	}
+
+	salaryAccountID, err := s.findPayrollAccount(run.CompanyID, defaultSalaryExpenseCode, "salary expense")
+	if err != nil {
+		return err
+	}
+	bpjsExpenseID, err := s.findPayrollAccount(run.CompanyID, defaultBPJSExpenseCode, "BPJS expense")
+	if err != nil {
+		return err
+	}
+	salariesPayableID, err := s.findPayrollAccount(run.CompanyID, defaultSalariesPayableCode, "salaries payable")
+	if err != nil {
+		return err
+	}
+	bpjsPayableID, err := s.findPayrollAccount(run.CompanyID, defaultBPJSPayableCode, "BPJS payable")
+	if err != nil {
+		return err
+	}
+	pph21PayableID, err := s.findPayrollAccount(run.CompanyID, defaultPPh21PayableCode, "PPh 21 payable")
+	if err != nil {
+		return err
+	}
+
+	// Expenses first, then what they leave owing
+	var expenses, owed []netJournalLine
+	for _, payslip := range run.Payslips {
+		expenses = append(expenses, netJournalLine{salaryAccountID, payslip.BaseSalary})
+		for _, line := range payslip.Lines {
+			if line.Type == models.SalaryComponentAllowance {
+				expenses = append(expenses, netJournalLine{line.AccountID, line.Amount})
+			} else {
+				owed = append(owed, netJournalLine{line.AccountID, -line.Amount})
+			}
+		}
+		expenses = append(expenses, netJournalLine{bpjsExpenseID, payslip.EmployerContributions()})
+		owed = append(owed,
+			netJournalLine{bpjsPayableID, -(payslip.EmployerContributions() + payslip.EmployeeContributions())},
+			netJournalLine{pph21PayableID, -payslip.PPh21},
+			netJournalLine{salariesPayableID, -payslip.NetPay},
+		)
+	}
+	run.JournalID, err = postNetJournal(s.journalService, run.CompanyID, journalDate, "Payroll "+run.Period+" "+run.RunNumber, append(expenses, owed...), userID)
+	if err != nil {
+		return err
+	}
+
+	run.Status = models.PayrollRunStatusPosted
+	if err := s.payrollRepo.UpdatePayrollRun(run); err != nil {
+		return err
+	}
+
+	_, err = s.taxService.CalculatePPh21(run.CompanyID, run.Period)
+	return err
+}
+
+// PayPayrollRun pays the run's net pay from a cash or bank account, settling
+// the salaries payable
+func (s *payrollService) PayPayrollRun(id uint, accountID uint, paymentDate time.Time, reference string, userID uint) error {
+	run, err := s.payrollRepo.FindPayrollRunByID(id)
+	if err != nil {
+		return errors.New("payroll run not found")
+	}
+	if run.Status != models.PayrollRunStatusPosted {
+		return errors.New("only posted payroll runs can be paid")
+	}
+	if run.TotalNetPay <= 0 {
+		return errors.New("payroll run has no net pay to pay")
+	}
+
+	if _, err := findCashBankAccount(s.accountRepo, run.CompanyID, accountID); err != nil {
+		return err
+	}
+	salariesPayableID, err := s.findPayrollAccount(run.CompanyID, defaultSalariesPayableCode, "salaries payable")
+	if err != nil {
+		return err
+	}
+
+	// The payment, its posted journal and the paid status are saved together,
+	// so a failure leaves the run posted and ready to pay again
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		transaction := &models.CashBankTransaction{
+			CompanyID:       run.CompanyID,
+			AccountID:       accountID,
+			TransactionDate: paymentDate,
+			Category:        models.CategorySalaryPayment,
+			Amount:          run.TotalNetPay,
+			Description:     "Salaries " + run.Period + " " + run.RunNumber,
+			Reference:       reference,
+			CreatedBy:       userID,
+		}
+		if err := txService.cashBankService.CreateCashOutWithJournal(transaction, salariesPayableID); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(*transaction.JournalID, userID); err != nil {
+			return err
+		}
+
+		run.CashBankTransactionID = &transaction.ID
+		run.PaymentJournalID = transaction.JournalID
+		run.Status = models.PayrollRunStatusPaid
+		return txService.payrollRepo.UpdatePayrollRun(run)
+	})
+}
+
+func (s *payrollService) GetPayslipByID(id uint) (*models.Payslip, error) {
+	return s.payrollRepo.FindPayslipByID(id)
+}
\ No newline at end of file
@@ -0,0 +1,176 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// Page layout of the exported PDFs: A4 landscape in points
+const (
+	pdfPageWidth   = 842.0
+	pdfPageHeight  = 595.0
+	pdfMargin      = 36.0
+	pdfMaxFontSize = 9.0
+	pdfMinFontSize = 5.0
+	pdfColumnWidth = 40 // characters, longer cells are cut
+)
+
+// writeTablePDF writes rows of cells to exports/filename as a plain PDF.
+// Single-cell rows are printed as text lines and the others as columns in
+// Courier, amounts right aligned with two decimals. The font shrinks to fit
+// the widest row and the rows are split over as many pages as needed.
+func writeTablePDF(filename string, rows [][]interface{}) (string, error) {
+	filepath := fmt.Sprintf("exports/%s", filename)
+
+	os.MkdirAll("exports", 0755)
+
+	lines := pdfTextLines(rows)
+
+	longest := 1
+	for _, line := range lines {
+		if n := len([]rune(line)); n > longest {
+			longest = n
+		}
+	}
+
+	// Courier glyphs are 0.6 em wide
+	fontSize := math.Min(pdfMaxFontSize, (pdfPageWidth-2*pdfMargin)/(0.6*float64(longest)))
+	fontSize = math.Max(pdfMinFontSize, math.Floor(fontSize*10)/10)
+	leading := fontSize * 1.3
+	perPage := int((pdfPageHeight-2*pdfMargin)/leading) - 2 // room for the page footer
+
+	pages := [][]string{}
+	for start := 0; start < len(lines); start += perPage {
+		end := start + perPage
+		if end > len(lines) {
+			end = len(lines)
+		}
+		pages = append(pages, lines[start:end])
+	}
+	if len(pages) == 0 {
+		pages = append(pages, nil)
+	}
+
+	if err := os.WriteFile(filepath, buildPDF(pages, fontSize, leading), 0644); err != nil {
+		return "", err
+	}
+
+	return filepath, nil
+}
+
+// pdfTextLines lays the rows out as fixed-width text lines
+func pdfTextLines(rows [][]interface{}) []string {
+	var widths []int
+	cells := make([][]string, len(rows))
+	for r, row := range rows {
+		cells[r] = make([]string, len(row))
+		for i, cell := range row {
+			if amount, ok := cell.(float64); ok {
+				cells[r][i] = fmt.Sprintf("%.2f", amount)
+			} else {
+				cells[r][i] = fmt.Sprint(cell)
+			}
+		}
+		if len(row) < 2 {
+			continue
+		}
+		for i, text := range cells[r] {
+			if i >= len(widths) {
+				widths = append(widths, 0)
+			}
+			if n := len([]rune(text)); n > widths[i] {
+				widths[i] = int(math.Min(float64(n), pdfColumnWidth))
+			}
+		}
+	}
+
+	lines := make([]string, 0, len(rows))
+	for r, row := range rows {
+		if len(row) < 2 {
+			lines = append(lines, strings.Join(cells[r], ""))
+			continue
+		}
+
+		parts := make([]string, len(row))
+		for i, text := range cells[r] {
+			if runes := []rune(text); len(runes) > widths[i] {
+				text = string(runes[:widths[i]])
+			}
+			if _, ok := row[i].(float64); ok {
+				parts[i] = fmt.Sprintf("%*s", widths[i], text)
+			} else {
+				parts[i] = fmt.Sprintf("%-*s", widths[i], text)
+			}
+		}
+		lines = append(lines, strings.TrimRight(strings.Join(parts, "  "), " "))
+	}
+	return lines
+}
+
+// buildPDF assembles a PDF 1.4 document with one text page per slice of lines
+func buildPDF(pages [][]string, fontSize, leading float64) []byte {
+	var buf bytes.Buffer
+	var offsets []int
+
+	// Objects: 1 catalog, 2 page tree, 3 font, then a page and its content per page
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 4+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+
+	for i, lines := range pages {
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n/F1 %.1f Tf\n%.1f TL\n%.1f %.1f Td\n", fontSize, leading, pdfMargin, pdfPageHeight-pdfMargin-fontSize)
+		for _, line := range lines {
+			fmt.Fprintf(&content, "(%s) Tj T*\n", pdfEscape(line))
+		}
+		fmt.Fprintf(&content, "ET\nBT\n/F1 %.1f Tf\n%.1f %.1f Td\n(%s) Tj\nET\n",
+			fontSize, pdfMargin, pdfMargin-fontSize, pdfEscape(fmt.Sprintf("Page %d of %d", i+1, len(pages))))
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.0f %.0f] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 5+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	return buf.Bytes()
+}
+
+// pdfEscape turns text into a PDF string literal body in WinAnsi; characters
+// outside Latin-1 are replaced by '?'
+func pdfEscape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r == '\t':
+			b.WriteString("    ")
+		case r < 0x20 || (r >= 0x7f && r < 0xa0) || r > 0xff:
+			b.WriteByte('?')
+		default:
+			b.WriteByte(byte(r))
+		}
+	}
+	return b.String()
+}
\ No newline at end of file
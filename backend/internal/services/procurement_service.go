@@ -0,0 +1,681 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"time"
+)
+
+type ProcurementService interface {
+	// Purchase Requisitions
+	CreatePurchaseRequisition(requisition *models.PurchaseRequisition) error
+	GetPurchaseRequisitionByID(id uint) (*models.PurchaseRequisition, error)
+	GetPurchaseRequisitions(companyID uint, status string) ([]models.PurchaseRequisition, error)
+	ApprovePurchaseRequisition(id uint, userID uint) error
+	RejectPurchaseRequisition(id uint) error
+	CancelPurchaseRequisition(id uint) error
+
+	// Purchase Orders
+	CreatePurchaseOrder(order *models.PurchaseOrder) error
+	GetPurchaseOrderByID(id uint) (*models.PurchaseOrder, error)
+	GetPurchaseOrders(companyID uint, vendorID uint, status string) ([]models.PurchaseOrder, error)
+	ApprovePurchaseOrder(id uint, userID uint) error
+	ClosePurchaseOrder(id uint) error
+	CancelPurchaseOrder(id uint) error
+
+	// Goods Receipts
+	CreateGoodsReceipt(receipt *models.GoodsReceipt) error
+	GetGoodsReceiptByID(id uint) (*models.GoodsReceipt, error)
+	GetGoodsReceipts(companyID uint, vendorID uint, purchaseOrderID uint, status string) ([]models.GoodsReceipt, error)
+	PostGoodsReceipt(id uint, userID uint) error
+	CancelGoodsReceipt(id uint) error
+
+	// Match Tolerance
+	GetMatchTolerance(companyID uint) (*models.PurchaseMatchTolerance, error)
+	SetMatchTolerance(companyID uint, quantityPercent, pricePercent float64) (*models.PurchaseMatchTolerance, error)
+
+	// Reports
+	GetOpenPurchaseOrders(companyID uint, vendorID uint) (*models.OpenPurchaseOrderReport, error)
+	GetGRNIReport(companyID uint, vendorID uint, asOf time.Time) (*models.GRNIReport, error)
+}
+
+type procurementService struct {
+	procurementRepo  repository.ProcurementRepository
+	purchaseRepo     repository.PurchaseRepository
+	inventoryRepo    repository.InventoryRepository
+	accountRepo      repository.AccountRepository
+	ledgerRepo       repository.LedgerRepository
+	inventoryService InventoryService
+}
+
+func NewProcurementService(
+	procurementRepo repository.ProcurementRepository,
+	purchaseRepo repository.PurchaseRepository,
+	inventoryRepo repository.InventoryRepository,
+	accountRepo repository.AccountRepository,
+	ledgerRepo repository.LedgerRepository,
+	inventoryService InventoryService,
+) ProcurementService {
+	return &procurementService{
+		procurementRepo:  procurementRepo,
+		purchaseRepo:     purchaseRepo,
+		inventoryRepo:    inventoryRepo,
+		accountRepo:      accountRepo,
+		ledgerRepo:       ledgerRepo,
+		inventoryService: inventoryService,
+	}
+}
+
+// resolveProductLine checks the product belongs to the company and fills the
+// line's description and unit from it
+func resolveProductLine(inventoryRepo repository.InventoryRepository, companyID uint, productID uint, description, unit *string) error {
+	product, err := inventoryRepo.FindProductByID(productID)
+	if err != nil || product.CompanyID != companyID {
+		return errors.New("product not found")
+	}
+	if *description == "" {
+		*description = product.Name
+	}
+	if *unit == "" {
+		*unit = product.Unit
+	} else if *unit != product.Unit && findProductUnit(product, *unit) == nil {
+		return errors.New("unit " + *unit + " is not defined for " + product.Code)
+	}
+	return nil
+}
+
+// Purchase Requisition methods
+func (s *procurementService) CreatePurchaseRequisition(requisition *models.PurchaseRequisition) error {
+	if len(requisition.Items) == 0 {
+		return errors.New("requisition must have at least one item")
+	}
+	if requisition.RequiredDate != nil && requisition.RequiredDate.Before(requisition.RequestDate) {
+		return errors.New("required date cannot be before the request date")
+	}
+
+	for i := range requisition.Items {
+		item := &requisition.Items[i]
+		if item.Quantity <= 0 {
+			return errors.New("quantity must be greater than zero")
+		}
+		if err := resolveProductLine(s.inventoryRepo, requisition.CompanyID, item.ProductID, &item.Description, &item.Unit); err != nil {
+			return err
+		}
+		item.OrderedQuantity = 0
+	}
+
+	requisitionNumber, err := s.procurementRepo.GeneratePurchaseRequisitionNumber(requisition.CompanyID, requisition.RequestDate)
+	if err != nil {
+		return err
+	}
+	requisition.RequisitionNumber = requisitionNumber
+	requisition.Status = models.PurchaseRequisitionStatusDraft
+
+	return s.procurementRepo.CreatePurchaseRequisition(requisition)
+}
+
+func (s *procurementService) GetPurchaseRequisitionByID(id uint) (*models.PurchaseRequisition, error) {
+	return s.procurementRepo.FindPurchaseRequisitionByID(id)
+}
+
+func (s *procurementService) GetPurchaseRequisitions(companyID uint, status string) ([]models.PurchaseRequisition, error) {
+	return s.procurementRepo.FindPurchaseRequisitions(companyID, status)
+}
+
+func (s *procurementService) ApprovePurchaseRequisition(id uint, userID uint) error {
+	requisition, err := s.procurementRepo.FindPurchaseRequisitionByID(id)
+	if err != nil {
+		return errors.New("purchase requisition not found")
+	}
+
+	if requisition.Status != models.PurchaseRequisitionStatusDraft {
+		return errors.New("only draft requisitions can be approved")
+	}
+
+	now := time.Now()
+	requisition.Status = models.PurchaseRequisitionStatusApproved
+	requisition.ApprovedAt = &now
+	requisition.ApprovedBy = &userID
+	return s.procurementRepo.UpdatePurchaseRequisition(requisition)
+}
+
+func (s *procurementService) RejectPurchaseRequisition(id uint) error {
+	requisition, err := s.procurementRepo.FindPurchaseRequisitionByID(id)
+	if err != nil {
+		return errors.New("purchase requisition not found")
+	}
+
+	if requisition.Status != models.PurchaseRequisitionStatusDraft {
+		return errors.New("only draft requisitions can be rejected")
+	}
+
+	requisition.Status = models.PurchaseRequisitionStatusRejected
+	return s.procurementRepo.UpdatePurchaseRequisition(requisition)
+}
+
+func (s *procurementService) CancelPurchaseRequisition(id uint) error {
+	requisition, err := s.procurementRepo.FindPurchaseRequisitionByID(id)
+	if err != nil {
+		return errors.New("purchase requisition not found")
+	}
+
+	if requisition.Status != models.PurchaseRequisitionStatusDraft && requisition.Status != models.PurchaseRequisitionStatusApproved {
+		return errors.New("only draft or approved requisitions can be cancelled")
+	}
+	for _, item := range requisition.Items {
+		if item.OrderedQuantity > 0 {
+			return errors.New("requisition is already on a purchase order")
+		}
+	}
+
+	requisition.Status = models.PurchaseRequisitionStatusCancelled
+	return s.procurementRepo.UpdatePurchaseRequisition(requisition)
+}
+
+// orderRequisitionItems adds the order's quantities to the requisition lines
+// they come from (a negative sign releases them) and moves the requisitions
+// between approved and ordered
+func (s *procurementService) orderRequisitionItems(order *models.PurchaseOrder, sign float64) error {
+	requisitionIDs := make(map[uint]bool)
+	var ordered []uint
+	for _, item := range order.Items {
+		if item.RequisitionItemID == nil {
+			continue
+		}
+		requisitionItem, err := s.procurementRepo.FindPurchaseRequisitionItemByID(*item.RequisitionItemID)
+		if err != nil {
+			return errors.New("requisition line not found")
+		}
+		requisitionItem.OrderedQuantity = math.Max(0, math.Round((requisitionItem.OrderedQuantity+sign*item.Quantity)*100)/100)
+		if err := s.procurementRepo.UpdatePurchaseRequisitionItem(requisitionItem); err != nil {
+			return err
+		}
+		if !requisitionIDs[requisitionItem.RequisitionID] {
+			requisitionIDs[requisitionItem.RequisitionID] = true
+			ordered = append(ordered, requisitionItem.RequisitionID)
+		}
+	}
+
+	for _, requisitionID := range ordered {
+		requisition, err := s.procurementRepo.FindPurchaseRequisitionByID(requisitionID)
+		if err != nil {
+			return err
+		}
+		status := models.PurchaseRequisitionStatusOrdered
+		for _, item := range requisition.Items {
+			if item.OrderedQuantity < item.Quantity {
+				status = models.PurchaseRequisitionStatusApproved
+				break
+			}
+		}
+		if requisition.Status != status {
+			requisition.Status = status
+			if err := s.procurementRepo.UpdatePurchaseRequisition(requisition); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Purchase Order methods
+
+// CalculatePurchaseOrderTotals fills the line discounts and subtotals and the
+// order PPN and total
+func CalculatePurchaseOrderTotals(order *models.PurchaseOrder) error {
+	if order.TaxRate < 0 || order.TaxRate > 100 {
+		return errors.New("tax rate must be between 0 and 100")
+	}
+
+	order.Subtotal = 0
+	for i := range order.Items {
+		item := &order.Items[i]
+		if item.Quantity <= 0 {
+			return errors.New("quantity must be greater than zero")
+		}
+		if item.UnitPrice < 0 {
+			return errors.New("unit price cannot be negative")
+		}
+		if item.DiscountPercent < 0 || item.DiscountPercent > 100 {
+			return errors.New("discount percent must be between 0 and 100")
+		}
+
+		gross := item.Quantity * item.UnitPrice
+		item.DiscountAmount = math.Round(gross*item.DiscountPercent) / 100
+		item.Subtotal = math.Round((gross-item.DiscountAmount)*100) / 100
+		order.Subtotal += item.Subtotal
+	}
+	order.Subtotal = math.Round(order.Subtotal*100) / 100
+
+	order.TaxAmount = math.Round(order.Subtotal*order.TaxRate) / 100
+	order.TotalAmount = math.Round((order.Subtotal+order.TaxAmount)*100) / 100
+	return nil
+}
+
+// CreatePurchaseOrder orders stock items from a vendor, optionally for the
+// lines of approved requisitions
+func (s *procurementService) CreatePurchaseOrder(order *models.PurchaseOrder) error {
+	if len(order.Items) == 0 {
+		return errors.New("purchase order must have at least one item")
+	}
+	if order.ExpectedDate != nil && order.ExpectedDate.Before(order.OrderDate) {
+		return errors.New("expected date cannot be before the order date")
+	}
+
+	vendor, err := s.purchaseRepo.FindVendorByID(order.VendorID)
+	if err != nil || vendor.CompanyID != order.CompanyID {
+		return errors.New("vendor not found")
+	}
+	if !vendor.IsActive {
+		return errors.New("vendor is inactive")
+	}
+
+	requested := make(map[uint]float64)
+	for i := range order.Items {
+		item := &order.Items[i]
+		if err := resolveProductLine(s.inventoryRepo, order.CompanyID, item.ProductID, &item.Description, &item.Unit); err != nil {
+			return err
+		}
+		item.ReceivedQuantity = 0
+		item.BilledQuantity = 0
+
+		if item.RequisitionItemID == nil {
+			continue
+		}
+		requisitionItem, err := s.procurementRepo.FindPurchaseRequisitionItemByID(*item.RequisitionItemID)
+		if err != nil {
+			return errors.New("requisition line not found")
+		}
+		requisition, err := s.procurementRepo.FindPurchaseRequisitionByID(requisitionItem.RequisitionID)
+		if err != nil || requisition.CompanyID != order.CompanyID {
+			return errors.New("requisition line not found")
+		}
+		if requisition.Status != models.PurchaseRequisitionStatusApproved {
+			return errors.New("requisition " + requisition.RequisitionNumber + " is not approved or already fully ordered")
+		}
+		if requisitionItem.ProductID != item.ProductID || requisitionItem.Unit != item.Unit {
+			return errors.New("order line does not match requisition line of " + requisitionItem.Description)
+		}
+		requested[requisitionItem.ID] += item.Quantity
+		if requested[requisitionItem.ID] > requisitionItem.Quantity-requisitionItem.OrderedQuantity+0.001 {
+			return fmt.Errorf("only %.2f %s of %s is still to be ordered on %s",
+				requisitionItem.Quantity-requisitionItem.OrderedQuantity, requisitionItem.Unit, requisitionItem.Description, requisition.RequisitionNumber)
+		}
+	}
+
+	if err := CalculatePurchaseOrderTotals(order); err != nil {
+		return err
+	}
+
+	orderNumber, err := s.procurementRepo.GeneratePurchaseOrderNumber(order.CompanyID, order.OrderDate)
+	if err != nil {
+		return err
+	}
+	order.OrderNumber = orderNumber
+	order.Status = models.PurchaseOrderStatusDraft
+
+	if err := s.procurementRepo.CreatePurchaseOrder(order); err != nil {
+		return err
+	}
+	return s.orderRequisitionItems(order, 1)
+}
+
+func (s *procurementService) GetPurchaseOrderByID(id uint) (*models.PurchaseOrder, error) {
+	return s.procurementRepo.FindPurchaseOrderByID(id)
+}
+
+func (s *procurementService) GetPurchaseOrders(companyID uint, vendorID uint, status string) ([]models.PurchaseOrder, error) {
+	return s.procurementRepo.FindPurchaseOrders(companyID, vendorID, status)
+}
+
+// ApprovePurchaseOrder releases the order to the vendor; goods can then be
+// received against it
+func (s *procurementService) ApprovePurchaseOrder(id uint, userID uint) error {
+	order, err := s.procurementRepo.FindPurchaseOrderByID(id)
+	if err != nil {
+		return errors.New("purchase order not found")
+	}
+
+	if order.Status != models.PurchaseOrderStatusDraft {
+		return errors.New("only draft purchase orders can be approved")
+	}
+
+	now := time.Now()
+	order.Status = models.PurchaseOrderStatusOpen
+	order.ApprovedAt = &now
+	order.ApprovedBy = &userID
+	return s.procurementRepo.UpdatePurchaseOrder(order)
+}
+
+// ClosePurchaseOrder short-closes an order: what was not delivered is no
+// longer expected and drops off the open purchase order report
+func (s *procurementService) ClosePurchaseOrder(id uint) error {
+	order, err := s.procurementRepo.FindPurchaseOrderByID(id)
+	if err != nil {
+		return errors.New("purchase order not found")
+	}
+
+	if order.Status != models.PurchaseOrderStatusOpen && order.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return errors.New("only open purchase orders can be closed")
+	}
+
+	order.Status = models.PurchaseOrderStatusClosed
+	return s.procurementRepo.UpdatePurchaseOrder(order)
+}
+
+func (s *procurementService) CancelPurchaseOrder(id uint) error {
+	order, err := s.procurementRepo.FindPurchaseOrderByID(id)
+	if err != nil {
+		return errors.New("purchase order not found")
+	}
+
+	if order.Status != models.PurchaseOrderStatusDraft && order.Status != models.PurchaseOrderStatusOpen {
+		return errors.New("only draft or open purchase orders can be cancelled")
+	}
+	receipts, err := s.procurementRepo.FindGoodsReceipts(order.CompanyID, 0, order.ID, string(models.GoodsReceiptStatusDraft))
+	if err != nil {
+		return err
+	}
+	if len(receipts) > 0 {
+		return errors.New("purchase order has draft goods receipts")
+	}
+
+	order.Status = models.PurchaseOrderStatusCancelled
+	if err := s.procurementRepo.UpdatePurchaseOrder(order); err != nil {
+		return err
+	}
+	return s.orderRequisitionItems(order, -1)
+}
+
+// Goods Receipt methods
+
+// prepareGoodsReceipt checks the purchase order can still be received and
+// fills the receipt lines from the order lines
+func (s *procurementService) prepareGoodsReceipt(receipt *models.GoodsReceipt) (*models.PurchaseOrder, error) {
+	order, err := s.procurementRepo.FindPurchaseOrderByID(receipt.PurchaseOrderID)
+	if err != nil || order.CompanyID != receipt.CompanyID {
+		return nil, errors.New("purchase order not found")
+	}
+	if order.Status != models.PurchaseOrderStatusOpen && order.Status != models.PurchaseOrderStatusPartiallyReceived {
+		return nil, errors.New("only open purchase orders can be received")
+	}
+	if receipt.ReceiptDate.Before(order.OrderDate) {
+		return nil, errors.New("receipt date cannot be before the order date")
+	}
+
+	orderItems := make(map[uint]*models.PurchaseOrderItem, len(order.Items))
+	for i := range order.Items {
+		orderItems[order.Items[i].ID] = &order.Items[i]
+	}
+
+	seen := make(map[uint]bool)
+	for i := range receipt.Items {
+		item := &receipt.Items[i]
+		orderItem, ok := orderItems[item.PurchaseOrderItemID]
+		if !ok {
+			return nil, errors.New("purchase order line not found")
+		}
+		if seen[item.PurchaseOrderItemID] {
+			return nil, errors.New("an order line can only be received once per receipt")
+		}
+		seen[item.PurchaseOrderItemID] = true
+
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than zero")
+		}
+		if item.Quantity > orderItem.Quantity-orderItem.ReceivedQuantity+0.001 {
+			return nil, fmt.Errorf("only %.2f %s of %s is still to be received", orderItem.Quantity-orderItem.ReceivedQuantity, orderItem.Unit, orderItem.Description)
+		}
+
+		item.ProductID = orderItem.ProductID
+		item.Unit = orderItem.Unit
+		item.UnitPrice = math.Round(orderItem.NetUnitPrice()*100) / 100
+		if item.Description == "" {
+			item.Description = orderItem.Description
+		}
+	}
+
+	receipt.VendorID = order.VendorID
+	if receipt.WarehouseID == 0 {
+		receipt.WarehouseID = order.WarehouseID
+	}
+	return order, nil
+}
+
+func (s *procurementService) CreateGoodsReceipt(receipt *models.GoodsReceipt) error {
+	if len(receipt.Items) == 0 {
+		return errors.New("goods receipt must have at least one item")
+	}
+	if _, err := s.prepareGoodsReceipt(receipt); err != nil {
+		return err
+	}
+
+	receiptNumber, err := s.procurementRepo.GenerateGoodsReceiptNumber(receipt.CompanyID, receipt.ReceiptDate)
+	if err != nil {
+		return err
+	}
+	receipt.ReceiptNumber = receiptNumber
+	receipt.Status = models.GoodsReceiptStatusDraft
+
+	return s.procurementRepo.CreateGoodsReceipt(receipt)
+}
+
+func (s *procurementService) GetGoodsReceiptByID(id uint) (*models.GoodsReceipt, error) {
+	return s.procurementRepo.FindGoodsReceiptByID(id)
+}
+
+func (s *procurementService) GetGoodsReceipts(companyID uint, vendorID uint, purchaseOrderID uint, status string) ([]models.GoodsReceipt, error) {
+	return s.procurementRepo.FindGoodsReceipts(companyID, vendorID, purchaseOrderID, status)
+}
+
+// PostGoodsReceipt receives the goods into stock at the ordered price
+// (Dr inventory / Cr purchase clearing through the stock-in) and updates the
+// quantities received on the purchase order
+func (s *procurementService) PostGoodsReceipt(id uint, userID uint) error {
+	receipt, err := s.procurementRepo.FindGoodsReceiptByID(id)
+	if err != nil {
+		return errors.New("goods receipt not found")
+	}
+
+	if receipt.Status != models.GoodsReceiptStatusDraft {
+		return errors.New("only draft goods receipts can be posted")
+	}
+
+	clearing, err := s.accountRepo.FindByCode(receipt.CompanyID, defaultPurchaseClearingAccountCode)
+	if err != nil {
+		return errors.New("purchase clearing account " + defaultPurchaseClearingAccountCode + " not found")
+	}
+
+	// Lines received by an earlier attempt that failed part way keep their
+	// movement; the order was not updated for them yet
+	order, err := s.procurementRepo.FindPurchaseOrderByID(receipt.PurchaseOrderID)
+	if err != nil {
+		return errors.New("purchase order not found")
+	}
+	orderItems := make(map[uint]*models.PurchaseOrderItem, len(order.Items))
+	for i := range order.Items {
+		orderItems[order.Items[i].ID] = &order.Items[i]
+	}
+	for _, item := range receipt.Items {
+		orderItem := orderItems[item.PurchaseOrderItemID]
+		if item.MovementID == nil && item.Quantity > orderItem.Quantity-orderItem.ReceivedQuantity+0.001 {
+			return fmt.Errorf("only %.2f %s of %s is still to be received", orderItem.Quantity-orderItem.ReceivedQuantity, orderItem.Unit, orderItem.Description)
+		}
+	}
+
+	for i := range receipt.Items {
+		item := &receipt.Items[i]
+		if item.MovementID != nil {
+			continue
+		}
+
+		orderItem := orderItems[item.PurchaseOrderItemID]
+		movement := &models.StockMovement{
+			CompanyID:       receipt.CompanyID,
+			ProductID:       item.ProductID,
+			WarehouseID:     receipt.WarehouseID,
+			MovementDate:    receipt.ReceiptDate,
+			Quantity:        item.Quantity,
+			Unit:            item.Unit,
+			UnitCost:        orderItem.NetUnitPrice(),
+			LotNumber:       item.LotNumber,
+			ExpiryDate:      item.ExpiryDate,
+			SerialNumbers:   append([]string(nil), item.SerialNumbers...),
+			Reference:       receipt.ReceiptNumber,
+			Party:           receipt.Vendor.Name,
+			Notes:           "Goods receipt " + receipt.ReceiptNumber + " for " + order.OrderNumber + " - " + item.Description,
+			OffsetAccountID: &clearing.ID,
+			CreatedBy:       userID,
+		}
+		if err := s.inventoryService.CreateStockIn(movement); err != nil {
+			return errors.New("failed to receive " + item.Description + ": " + err.Error())
+		}
+
+		item.MovementID = &movement.ID
+		item.CostAmount = math.Round(movement.TotalCost*100) / 100
+		if err := s.procurementRepo.UpdateGoodsReceiptItem(item); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range receipt.Items {
+		orderItem := orderItems[item.PurchaseOrderItemID]
+		orderItem.ReceivedQuantity = math.Round((orderItem.ReceivedQuantity+item.Quantity)*100) / 100
+		if err := s.procurementRepo.UpdatePurchaseOrderItem(orderItem); err != nil {
+			return err
+		}
+	}
+
+	order.Status = models.PurchaseOrderStatusReceived
+	for _, orderItem := range order.Items {
+		if orderItem.ReceivedQuantity < orderItem.Quantity {
+			order.Status = models.PurchaseOrderStatusPartiallyReceived
+			break
+		}
+	}
+	if err := s.procurementRepo.UpdatePurchaseOrder(order); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	receipt.Status = models.GoodsReceiptStatusPosted
+	receipt.PostedAt = &now
+	receipt.PostedBy = &userID
+	return s.procurementRepo.UpdateGoodsReceipt(receipt)
+}
+
+func (s *procurementService) CancelGoodsReceipt(id uint) error {
+	receipt, err := s.procurementRepo.FindGoodsReceiptByID(id)
+	if err != nil {
+		return errors.New("goods receipt not found")
+	}
+
+	if receipt.Status != models.GoodsReceiptStatusDraft {
+		return errors.New("only draft goods receipts can be cancelled")
+	}
+	for _, item := range receipt.Items {
+		if item.MovementID != nil {
+			return errors.New("goods have already been received for this receipt")
+		}
+	}
+
+	receipt.Status = models.GoodsReceiptStatusCancelled
+	return s.procurementRepo.UpdateGoodsReceipt(receipt)
+}
+
+// Match Tolerance methods
+func (s *procurementService) GetMatchTolerance(companyID uint) (*models.PurchaseMatchTolerance, error) {
+	return s.procurementRepo.FindMatchTolerance(companyID)
+}
+
+func (s *procurementService) SetMatchTolerance(companyID uint, quantityPercent, pricePercent float64) (*models.PurchaseMatchTolerance, error) {
+	if quantityPercent < 0 || quantityPercent > 100 || pricePercent < 0 || pricePercent > 100 {
+		return nil, errors.New("tolerances must be between 0 and 100 percent")
+	}
+
+	tolerance, err := s.procurementRepo.FindMatchTolerance(companyID)
+	if err != nil {
+		return nil, err
+	}
+	tolerance.QuantityPercent = quantityPercent
+	tolerance.PricePercent = pricePercent
+	if err := s.procurementRepo.SaveMatchTolerance(tolerance); err != nil {
+		return nil, err
+	}
+	return tolerance, nil
+}
+
+// Report methods
+
+// GetOpenPurchaseOrders lists what approved purchase orders still expect to
+// be delivered, valued at the ordered price
+func (s *procurementService) GetOpenPurchaseOrders(companyID uint, vendorID uint) (*models.OpenPurchaseOrderReport, error) {
+	lines, err := s.procurementRepo.FindOpenPurchaseOrderLines(companyID, vendorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	report := &models.OpenPurchaseOrderReport{
+		AsOfDate: today.Format("2006-01-02"),
+		Lines:    []models.OpenPurchaseOrderLine{},
+	}
+	for _, line := range lines {
+		line.RemainingAmount = math.Round(line.RemainingQuantity*line.UnitPrice*100) / 100
+		line.UnitPrice = math.Round(line.UnitPrice*100) / 100
+		line.IsOverdue = line.ExpectedDate != nil && line.ExpectedDate.Before(today)
+		report.Lines = append(report.Lines, line)
+		report.TotalAmount += line.RemainingAmount
+	}
+	report.TotalAmount = math.Round(report.TotalAmount*100) / 100
+	return report, nil
+}
+
+// GetGRNIReport values the goods received but not yet billed at asOf at the
+// cost they were received at, and reconciles the accrual to the purchase
+// clearing account
+func (s *procurementService) GetGRNIReport(companyID uint, vendorID uint, asOf time.Time) (*models.GRNIReport, error) {
+	clearing, err := s.accountRepo.FindByCode(companyID, defaultPurchaseClearingAccountCode)
+	if err != nil {
+		return nil, errors.New("purchase clearing account " + defaultPurchaseClearingAccountCode + " not found")
+	}
+
+	// Receipts and bills dated on asOf count towards it
+	endOfDay := asOf.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	lines, err := s.procurementRepo.FindGRNILines(companyID, vendorID, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.GRNIReport{
+		AsOfDate: asOf.Format("2006-01-02"),
+		Lines:    []models.GRNILine{},
+	}
+	for _, line := range lines {
+		line.UnbilledQuantity = math.Round((line.ReceivedQuantity-line.BilledQuantity)*100) / 100
+		if line.UnbilledQuantity <= 0 || line.ReceivedQuantity == 0 {
+			continue
+		}
+		line.UnitCost = math.Round(line.ReceivedCost/line.ReceivedQuantity*100) / 100
+		line.AccruedAmount = math.Round(line.ReceivedCost*line.UnbilledQuantity/line.ReceivedQuantity*100) / 100
+		report.Lines = append(report.Lines, line)
+		report.Total += line.AccruedAmount
+	}
+	report.Total = math.Round(report.Total*100) / 100
+
+	// Ledger balances are debit less credit; the accrual is a credit balance
+	balance, err := s.ledgerRepo.GetAccountBalance(clearing.ID, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	report.ControlAccountCode = clearing.Code
+	report.ControlAccountName = clearing.Name
+	report.GLBalance = math.Round(-balance*100) / 100
+	report.Difference = math.Round((report.Total-report.GLBalance)*100) / 100
+	return report, nil
+}
\ No newline at end of file
@@ -0,0 +1,521 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ProductionService interface {
+	// Bill of Materials
+	GetBOM(productID uint) ([]models.BOMItem, error)
+	SetBOM(productID uint, items []models.BOMItem) error
+	GetBOMCost(productID uint, quantity float64) (*models.BOMCost, error)
+
+	// Production Orders
+	CreateProductionOrder(order *models.ProductionOrder) error
+	GetProductionOrderByID(id uint) (*models.ProductionOrder, error)
+	GetProductionOrdersByCompany(companyID uint, status string) ([]models.ProductionOrder, error)
+	StartProductionOrder(id uint, userID uint) error
+	CompleteProductionOrder(id uint, laborCost, overheadCost *float64, userID uint) error
+	CancelProductionOrder(id uint, userID uint) error
+}
+
+// Default chart of accounts codes used by production
+const (
+	defaultWIPAccountCode      = "1-1500" // Barang Dalam Proses
+	defaultLaborAccountCode    = "5-1100" // Beban Gaji
+	defaultOverheadAccountCode = "5-5000" // Biaya Overhead Pabrik
+)
+
+type productionService struct {
+	db               *gorm.DB
+	productionRepo   repository.ProductionRepository
+	inventoryRepo    repository.InventoryRepository
+	accountRepo      repository.AccountRepository
+	inventoryService InventoryService
+	journalService   JournalService
+}
+
+func NewProductionService(
+	db *gorm.DB,
+	productionRepo repository.ProductionRepository,
+	inventoryRepo repository.InventoryRepository,
+	accountRepo repository.AccountRepository,
+	inventoryService InventoryService,
+	journalService JournalService,
+) ProductionService {
+	return &productionService{
+		db:               db,
+		productionRepo:   productionRepo,
+		inventoryRepo:    inventoryRepo,
+		accountRepo:      accountRepo,
+		inventoryService: inventoryService,
+		journalService:   journalService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so the stock,
+// journal and order changes of a step commit or roll back together
+func (s *productionService) withTx(tx *gorm.DB) *productionService {
+	return &productionService{
+		db:               tx,
+		productionRepo:   repository.NewProductionRepository(tx),
+		inventoryRepo:    repository.NewInventoryRepository(tx),
+		accountRepo:      repository.NewAccountRepository(tx),
+		inventoryService: inventoryServiceWith(s.inventoryService, tx),
+		journalService:   journalServiceWith(tx),
+	}
+}
+
+// Bill of Materials methods
+func (s *productionService) GetBOM(productID uint) ([]models.BOMItem, error) {
+	return s.productionRepo.FindBOMItems(productID)
+}
+
+// SetBOM replaces the product's components
+func (s *productionService) SetBOM(productID uint, items []models.BOMItem) error {
+	product, err := s.inventoryRepo.FindProductByID(productID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+
+	seen := make(map[uint]bool)
+	for _, item := range items {
+		if item.Quantity <= 0 {
+			return errors.New("component quantity must be greater than zero")
+		}
+		if item.ComponentID == productID {
+			return errors.New("product cannot be its own component")
+		}
+		if seen[item.ComponentID] {
+			return errors.New("component listed more than once")
+		}
+		seen[item.ComponentID] = true
+
+		component, err := s.inventoryRepo.FindProductByID(item.ComponentID)
+		if err != nil || component.CompanyID != product.CompanyID {
+			return errors.New("component not found")
+		}
+
+		// A component assembled from this product would never finish
+		uses, err := s.usesComponent(item.ComponentID, productID, map[uint]bool{})
+		if err != nil {
+			return err
+		}
+		if uses {
+			return errors.New("component " + component.Code + " is itself assembled from " + product.Code)
+		}
+	}
+
+	return s.productionRepo.ReplaceBOMItems(productID, items)
+}
+
+// usesComponent reports whether productID's BOM tree contains componentID
+func (s *productionService) usesComponent(productID, componentID uint, visited map[uint]bool) (bool, error) {
+	if visited[productID] {
+		return false, nil
+	}
+	visited[productID] = true
+
+	items, err := s.productionRepo.FindBOMItems(productID)
+	if err != nil {
+		return false, err
+	}
+	for _, item := range items {
+		if item.ComponentID == componentID {
+			return true, nil
+		}
+		uses, err := s.usesComponent(item.ComponentID, componentID, visited)
+		if err != nil || uses {
+			return uses, err
+		}
+	}
+
+	return false, nil
+}
+
+// GetBOMCost estimates material cost from the components' current average cost
+func (s *productionService) GetBOMCost(productID uint, quantity float64) (*models.BOMCost, error) {
+	if quantity <= 0 {
+		quantity = 1
+	}
+
+	items, err := s.productionRepo.FindBOMItems(productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(items) == 0 {
+		return nil, errors.New("product has no bill of materials")
+	}
+
+	cost := &models.BOMCost{
+		ProductID: productID,
+		Quantity:  quantity,
+	}
+	for _, item := range items {
+		balance, err := s.inventoryRepo.GetProductStockTotal(item.ComponentID)
+		if err != nil {
+			return nil, err
+		}
+
+		required := item.Quantity * quantity
+		line := models.BOMCostLine{
+			ComponentID:   item.ComponentID,
+			ComponentCode: item.Component.Code,
+			ComponentName: item.Component.Name,
+			Quantity:      required,
+			UnitCost:      math.Round(balance.AverageCost*100) / 100,
+			TotalCost:     math.Round(required*balance.AverageCost*100) / 100,
+			Available:     balance.Quantity,
+		}
+		cost.Lines = append(cost.Lines, line)
+		cost.MaterialCost += line.TotalCost
+	}
+	cost.MaterialCost = math.Round(cost.MaterialCost*100) / 100
+	cost.UnitCost = math.Round(cost.MaterialCost/quantity*100) / 100
+
+	return cost, nil
+}
+
+// Production Order methods
+func (s *productionService) CreateProductionOrder(order *models.ProductionOrder) error {
+	if order.Quantity <= 0 {
+		return errors.New("quantity must be greater than zero")
+	}
+	if order.LaborCost < 0 || order.OverheadCost < 0 {
+		return errors.New("labor and overhead cost cannot be negative")
+	}
+
+	product, err := s.inventoryRepo.FindProductByID(order.ProductID)
+	if err != nil || product.CompanyID != order.CompanyID {
+		return errors.New("product not found")
+	}
+
+	warehouse, err := s.inventoryRepo.FindWarehouseByID(order.WarehouseID)
+	if err != nil || warehouse.CompanyID != order.CompanyID {
+		return errors.New("warehouse not found")
+	}
+	if warehouse.IsTransit || !warehouse.IsActive {
+		return errors.New("production requires an active stock warehouse")
+	}
+
+	// Components come from the BOM unless the order lists what it actually uses
+	if len(order.Items) == 0 {
+		if len(product.BOMItems) == 0 {
+			return errors.New("product has no bill of materials")
+		}
+		for _, bom := range product.BOMItems {
+			order.Items = append(order.Items, models.ProductionOrderItem{
+				ComponentID: bom.ComponentID,
+				Quantity:    math.Round(bom.Quantity*order.Quantity*100) / 100,
+			})
+		}
+	}
+	for _, item := range order.Items {
+		if item.Quantity <= 0 {
+			return errors.New("component quantity must be greater than zero")
+		}
+		if item.ComponentID == order.ProductID {
+			return errors.New("product cannot be its own component")
+		}
+	}
+
+	orderNumber, err := s.productionRepo.GenerateProductionOrderNumber(order.CompanyID, order.OrderDate)
+	if err != nil {
+		return err
+	}
+	order.OrderNumber = orderNumber
+	order.Status = models.ProductionStatusDraft
+
+	return s.productionRepo.CreateProductionOrder(order)
+}
+
+func (s *productionService) GetProductionOrderByID(id uint) (*models.ProductionOrder, error) {
+	return s.productionRepo.FindProductionOrderByID(id)
+}
+
+func (s *productionService) GetProductionOrdersByCompany(companyID uint, status string) ([]models.ProductionOrder, error) {
+	return s.productionRepo.FindProductionOrdersByCompany(companyID, status)
+}
+
+// StartProductionOrder issues the components into work in process:
+// Dr WIP / Cr inventory per component at its consumed cost. Components
+// issued by an earlier attempt that failed part way keep their movement.
+func (s *productionService) StartProductionOrder(id uint, userID uint) error {
+	order, err := s.productionRepo.FindProductionOrderByID(id)
+	if err != nil {
+		return errors.New("production order not found")
+	}
+
+	if order.Status != models.ProductionStatusDraft {
+		return errors.New("only draft production orders can be started")
+	}
+
+	wip, err := s.accountRepo.FindByCode(order.CompanyID, defaultWIPAccountCode)
+	if err != nil {
+		return errors.New("work in process account " + defaultWIPAccountCode + " not found")
+	}
+
+	now := time.Now()
+	order.MaterialCost = 0
+	for i := range order.Items {
+		item := &order.Items[i]
+		if item.MovementID != nil {
+			order.MaterialCost += item.TotalCost
+			continue
+		}
+
+		movement := &models.StockMovement{
+			CompanyID:       order.CompanyID,
+			ProductID:       item.ComponentID,
+			WarehouseID:     order.WarehouseID,
+			MovementDate:    now,
+			Quantity:        item.Quantity,
+			SerialNumbers:   append([]string(nil), item.SerialNumbers...),
+			Reference:       order.OrderNumber,
+			Notes:           "Production " + order.OrderNumber + " - " + order.Product.Name,
+			OffsetAccountID: &wip.ID,
+			CreatedBy:       userID,
+		}
+		if err := s.inventoryService.CreateStockOut(movement); err != nil {
+			return errors.New("failed to issue " + item.Component.Code + ": " + err.Error())
+		}
+
+		item.MovementID = &movement.ID
+		item.TotalCost = math.Round(movement.TotalCost*100) / 100
+		if err := s.productionRepo.UpdateProductionOrderItem(item); err != nil {
+			return err
+		}
+		order.MaterialCost += item.TotalCost
+	}
+
+	order.MaterialCost = math.Round(order.MaterialCost*100) / 100
+	order.Status = models.ProductionStatusInProgress
+	order.StartedAt = &now
+	return s.productionRepo.UpdateProductionOrder(order)
+}
+
+// CompleteProductionOrder receives the finished good at the rolled-up cost
+// (Dr inventory / Cr WIP) and then applies labor and overhead to WIP.
+// Nil labor or overhead keeps the amount planned on the order. Each step is
+// recorded on the order as it is done, so a retry after a failure picks up
+// where it stopped; once the finished good is received its cost is fixed.
+func (s *productionService) CompleteProductionOrder(id uint, laborCost, overheadCost *float64, userID uint) error {
+	order, err := s.productionRepo.FindProductionOrderByID(id)
+	if err != nil {
+		return errors.New("production order not found")
+	}
+
+	if order.Status != models.ProductionStatusInProgress {
+		return errors.New("only in-progress production orders can be completed")
+	}
+
+	if order.OutputMovementID == nil {
+		if laborCost != nil {
+			order.LaborCost = *laborCost
+		}
+		if overheadCost != nil {
+			order.OverheadCost = *overheadCost
+		}
+	}
+	if order.LaborCost < 0 || order.OverheadCost < 0 {
+		return errors.New("labor and overhead cost cannot be negative")
+	}
+
+	wip, err := s.accountRepo.FindByCode(order.CompanyID, defaultWIPAccountCode)
+	if err != nil {
+		return errors.New("work in process account " + defaultWIPAccountCode + " not found")
+	}
+
+	order.LaborCost = math.Round(order.LaborCost*100) / 100
+	order.OverheadCost = math.Round(order.OverheadCost*100) / 100
+	order.TotalCost = math.Round((order.MaterialCost+order.LaborCost+order.OverheadCost)*100) / 100
+	order.UnitCost = math.Round(order.TotalCost/order.Quantity*100) / 100
+
+	now := time.Now()
+	if order.OutputMovementID == nil {
+		movement := &models.StockMovement{
+			CompanyID:       order.CompanyID,
+			ProductID:       order.ProductID,
+			WarehouseID:     order.WarehouseID,
+			MovementDate:    now,
+			Quantity:        order.Quantity,
+			UnitCost:        order.UnitCost,
+			LotNumber:       order.LotNumber,
+			ExpiryDate:      order.ExpiryDate,
+			SerialNumbers:   append([]string(nil), order.SerialNumbers...),
+			Notes:           "Production " + order.OrderNumber + " - " + order.Product.Name,
+			OffsetAccountID: &wip.ID,
+			CreatedBy:       userID,
+		}
+		if err := s.inventoryService.CreateStockIn(movement); err != nil {
+			return errors.New("failed to receive " + order.Product.Code + ": " + err.Error())
+		}
+
+		order.OutputMovementID = &movement.ID
+		if err := s.productionRepo.UpdateProductionOrder(order); err != nil {
+			return err
+		}
+	}
+
+	// The finished good is received at a rounded unit cost; whatever that
+	// leaves in WIP goes to the inventory variance account. The journal is
+	// saved with the order, so a failed posting leaves neither behind.
+	if order.CostJournalID == nil {
+		rounding := math.Round((order.TotalCost-order.UnitCost*order.Quantity)*100) / 100
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			txService := s.withTx(tx)
+			if err := txService.postConversionCosts(order, wip.ID, rounding, now, userID); err != nil {
+				return err
+			}
+			return txService.productionRepo.UpdateProductionOrder(order)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	order.Status = models.ProductionStatusCompleted
+	order.CompletedAt = &now
+	order.CompletedBy = &userID
+	return s.productionRepo.UpdateProductionOrder(order)
+}
+
+// postConversionCosts posts labor and overhead into WIP (Dr WIP / Cr labor,
+// Cr overhead) together with the unit cost rounding taken out of WIP
+func (s *productionService) postConversionCosts(order *models.ProductionOrder, wipAccountID uint, rounding float64, date time.Time, userID uint) error {
+	if order.LaborCost == 0 && order.OverheadCost == 0 && rounding == 0 {
+		return nil
+	}
+
+	description := "Production cost " + order.OrderNumber + " - " + order.Product.Name
+	var entries []models.JournalEntry
+	addEntry := func(accountID uint, debit, credit float64) {
+		entries = append(entries, models.JournalEntry{
+			AccountID:   accountID,
+			Description: description,
+			Debit:       debit,
+			Credit:      credit,
+			Position:    len(entries) + 1,
+		})
+	}
+
+	if wipDebit := math.Round((order.LaborCost+order.OverheadCost-rounding)*100) / 100; wipDebit > 0 {
+		addEntry(wipAccountID, wipDebit, 0)
+	} else if wipDebit < 0 {
+		addEntry(wipAccountID, 0, -wipDebit)
+	}
+	if order.LaborCost > 0 {
+		labor, err := s.accountRepo.FindByCode(order.CompanyID, defaultLaborAccountCode)
+		if err != nil {
+			return errors.New("labor account " + defaultLaborAccountCode + " not found")
+		}
+		addEntry(labor.ID, 0, order.LaborCost)
+	}
+	if order.OverheadCost > 0 {
+		overhead, err := s.accountRepo.FindByCode(order.CompanyID, defaultOverheadAccountCode)
+		if err != nil {
+			return errors.New("overhead account " + defaultOverheadAccountCode + " not found")
+		}
+		addEntry(overhead.ID, 0, order.OverheadCost)
+	}
+	if rounding != 0 {
+		variance, err := s.accountRepo.FindByCode(order.CompanyID, defaultInventoryVarianceCode)
+		if err != nil {
+			return errors.New("inventory variance account " + defaultInventoryVarianceCode + " not found")
+		}
+		if rounding > 0 {
+			addEntry(variance.ID, rounding, 0)
+		} else {
+			addEntry(variance.ID, 0, -rounding)
+		}
+	}
+
+	journal := &models.Journal{
+		CompanyID:       order.CompanyID,
+		TransactionDate: date,
+		Description:     description,
+		CreatedBy:       userID,
+		Entries:         entries,
+	}
+
+	if err := s.journalService.CreateJournal(journal); err != nil {
+		return err
+	}
+	if err := s.journalService.PostJournal(journal.ID, userID); err != nil {
+		return err
+	}
+
+	order.CostJournalID = &journal.ID
+	return nil
+}
+
+// CancelProductionOrder cancels a draft order, or an in-progress one by
+// returning its issued components from WIP to stock at the cost they were
+// issued at (Dr inventory / Cr WIP). The returns and the order are saved in
+// one database transaction.
+func (s *productionService) CancelProductionOrder(id uint, userID uint) error {
+	order, err := s.productionRepo.FindProductionOrderByID(id)
+	if err != nil {
+		return errors.New("production order not found")
+	}
+
+	if order.Status != models.ProductionStatusDraft && order.Status != models.ProductionStatusInProgress {
+		return errors.New("only draft or in-progress production orders can be cancelled")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+		if order.Status == models.ProductionStatusInProgress {
+			if err := txService.returnComponents(order, userID); err != nil {
+				return err
+			}
+		}
+
+		order.Status = models.ProductionStatusCancelled
+		return txService.productionRepo.UpdateProductionOrder(order)
+	})
+}
+
+// returnComponents reverses the component issues of a started order. Lots
+// are returned one by one since a receipt goes into a single lot.
+func (s *productionService) returnComponents(order *models.ProductionOrder, userID uint) error {
+	now := time.Now()
+	for _, item := range order.Items {
+		if item.MovementID == nil {
+			continue
+		}
+
+		lots, err := s.inventoryRepo.FindLotAllocationsByMovement(*item.MovementID)
+		if err != nil {
+			return err
+		}
+
+		returns := []models.StockMovement{{Quantity: item.Quantity, SerialNumbers: append([]string(nil), item.SerialNumbers...)}}
+		if len(lots) > 1 {
+			returns = nil
+			for _, lot := range lots {
+				returns = append(returns, models.StockMovement{Quantity: lot.Quantity, LotNumber: lot.Lot.LotNumber, ExpiryDate: lot.Lot.ExpiryDate})
+			}
+		}
+
+		for i := range returns {
+			movement := &returns[i]
+			movement.CompanyID = order.CompanyID
+			movement.WarehouseID = order.WarehouseID
+			movement.MovementDate = now
+			movement.Reference = order.OrderNumber
+			movement.Notes = "Cancelled production " + order.OrderNumber + " - " + order.Product.Name
+			movement.CreatedBy = userID
+			if err := s.inventoryService.CreateStockReturn(movement, *item.MovementID); err != nil {
+				return errors.New("failed to return " + item.Component.Code + ": " + err.Error())
+			}
+		}
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,1538 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PurchaseService interface {
+	// Vendors
+	CreateVendor(vendor *models.Vendor) error
+	GetVendorByID(id uint) (*models.Vendor, error)
+	GetVendorsByCompany(companyID uint, activeOnly bool) ([]models.Vendor, error)
+	UpdateVendor(id uint, vendor *models.Vendor) error
+	DeleteVendor(id uint) error
+
+	// Purchase Bills
+	CreatePurchaseBill(bill *models.PurchaseBill) error
+	GetPurchaseBillByID(id uint) (*models.PurchaseBill, error)
+	GetPurchaseBills(companyID uint, vendorID uint, status string) ([]models.PurchaseBill, error)
+	PostPurchaseBill(id uint, userID uint) error
+	ApprovePurchaseBillMatch(id uint, userID uint) error
+	CancelPurchaseBill(id uint) error
+
+	// Vendor Payments
+	CreateVendorPayment(payment *models.VendorPayment) error
+	GetVendorPaymentByID(id uint) (*models.VendorPayment, error)
+	GetVendorPayments(companyID uint, vendorID uint) ([]models.VendorPayment, error)
+
+	// Purchase Debit Notes
+	CreatePurchaseDebitNote(debitNote *models.PurchaseDebitNote) error
+	GetPurchaseDebitNoteByID(id uint) (*models.PurchaseDebitNote, error)
+	GetPurchaseDebitNotes(companyID uint, vendorID uint, billID uint, status string) ([]models.PurchaseDebitNote, error)
+	PostPurchaseDebitNote(id uint, userID uint) error
+	CancelPurchaseDebitNote(id uint) error
+	ApplyPurchaseDebitNote(application *models.PurchaseDebitNoteApplication) error
+	RefundPurchaseDebitNote(application *models.PurchaseDebitNoteApplication) error
+
+	// Payment Runs
+	CreatePaymentRun(run *models.PaymentRun, vendorIDs []uint) error
+	GetPaymentRunByID(id uint) (*models.PaymentRun, error)
+	GetPaymentRuns(companyID uint, status string) ([]models.PaymentRun, error)
+	UpdatePaymentRunLine(runID uint, lineID uint, amount float64) error
+	ProcessPaymentRun(id uint, userID uint) error
+	CancelPaymentRun(id uint) error
+
+	// Payable Aging
+	GetPayableAging(companyID uint, vendorID uint, asOf time.Time, limits []int) (*models.AgingReport, error)
+}
+
+// Default chart of accounts codes used by purchasing
+const (
+	defaultPayableAccountCode     = "2-1100" // Utang Usaha
+	defaultInputTaxAccountCode    = "1-1600" // PPN Masukan
+	defaultWithholdingAccountCode = "2-1400" // Utang PPh Dipotong
+	defaultVendorCreditCode       = "1-1900" // Saldo Kredit Pemasok
+)
+
+type purchaseService struct {
+	db               *gorm.DB
+	purchaseRepo     repository.PurchaseRepository
+	procurementRepo  repository.ProcurementRepository
+	inventoryRepo    repository.InventoryRepository
+	accountRepo      repository.AccountRepository
+	ledgerRepo       repository.LedgerRepository
+	inventoryService InventoryService
+	journalService   JournalService
+	cashBankService  CashBankService
+}
+
+func NewPurchaseService(
+	db *gorm.DB,
+	purchaseRepo repository.PurchaseRepository,
+	procurementRepo repository.ProcurementRepository,
+	inventoryRepo repository.InventoryRepository,
+	accountRepo repository.AccountRepository,
+	ledgerRepo repository.LedgerRepository,
+	inventoryService InventoryService,
+	journalService JournalService,
+	cashBankService CashBankService,
+) PurchaseService {
+	return &purchaseService{
+		db:               db,
+		purchaseRepo:     purchaseRepo,
+		procurementRepo:  procurementRepo,
+		inventoryRepo:    inventoryRepo,
+		accountRepo:      accountRepo,
+		ledgerRepo:       ledgerRepo,
+		inventoryService: inventoryService,
+		journalService:   journalService,
+		cashBankService:  cashBankService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so a document
+// and the postings it makes commit or roll back together
+func (s *purchaseService) withTx(tx *gorm.DB) *purchaseService {
+	return &purchaseService{
+		db:               tx,
+		purchaseRepo:     repository.NewPurchaseRepository(tx),
+		procurementRepo:  repository.NewProcurementRepository(tx),
+		inventoryRepo:    repository.NewInventoryRepository(tx),
+		accountRepo:      repository.NewAccountRepository(tx),
+		ledgerRepo:       repository.NewLedgerRepository(tx),
+		inventoryService: inventoryServiceWith(s.inventoryService, tx),
+		journalService:   journalServiceWith(tx),
+		cashBankService:  cashBankServiceWith(tx),
+	}
+}
+
+// Vendor methods
+func (s *purchaseService) CreateVendor(vendor *models.Vendor) error {
+	if vendor.PaymentTermDays < 0 {
+		return errors.New("payment term days cannot be negative")
+	}
+	return s.purchaseRepo.CreateVendor(vendor)
+}
+
+func (s *purchaseService) GetVendorByID(id uint) (*models.Vendor, error) {
+	return s.purchaseRepo.FindVendorByID(id)
+}
+
+func (s *purchaseService) GetVendorsByCompany(companyID uint, activeOnly bool) ([]models.Vendor, error) {
+	return s.purchaseRepo.FindVendorsByCompany(companyID, activeOnly)
+}
+
+func (s *purchaseService) UpdateVendor(id uint, updated *models.Vendor) error {
+	vendor, err := s.purchaseRepo.FindVendorByID(id)
+	if err != nil {
+		return errors.New("vendor not found")
+	}
+
+	if updated.PaymentTermDays < 0 {
+		return errors.New("payment term days cannot be negative")
+	}
+
+	vendor.Code = updated.Code
+	vendor.Name = updated.Name
+	vendor.NPWP = updated.NPWP
+	vendor.Address = updated.Address
+	vendor.Phone = updated.Phone
+	vendor.Email = updated.Email
+	vendor.ContactPerson = updated.ContactPerson
+	vendor.PaymentTermDays = updated.PaymentTermDays
+	vendor.BankName = updated.BankName
+	vendor.BankAccountNumber = updated.BankAccountNumber
+	vendor.BankAccountName = updated.BankAccountName
+	vendor.IsActive = updated.IsActive
+	vendor.Notes = updated.Notes
+
+	return s.purchaseRepo.UpdateVendor(vendor)
+}
+
+func (s *purchaseService) DeleteVendor(id uint) error {
+	count, err := s.purchaseRepo.CountVendorBills(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("vendor has bills, deactivate it instead")
+	}
+	return s.purchaseRepo.DeleteVendor(id)
+}
+
+// CalculatePurchaseBillTotals fills the line discounts and subtotals and the
+// bill PPN, total, withholding and payable. Withholding is computed on the
+// withholdable lines before PPN and is deducted from what the vendor is paid.
+func CalculatePurchaseBillTotals(bill *models.PurchaseBill) error {
+	if bill.TaxRate < 0 || bill.TaxRate > 100 {
+		return errors.New("tax rate must be between 0 and 100")
+	}
+	switch bill.WithholdingType {
+	case "":
+		bill.WithholdingRate = 0
+	case models.TaxTypePPh23, models.TaxTypePPh4Ayat2:
+		if bill.WithholdingRate <= 0 || bill.WithholdingRate > 100 {
+			return errors.New("withholding rate must be between 0 and 100")
+		}
+	default:
+		return errors.New("withholding type must be pph23 or pph4ayat2")
+	}
+
+	var withholdingBase float64
+	bill.Subtotal = 0
+	for i := range bill.Items {
+		item := &bill.Items[i]
+		if item.Quantity <= 0 {
+			return errors.New("quantity must be greater than zero")
+		}
+		if item.UnitPrice < 0 {
+			return errors.New("unit price cannot be negative")
+		}
+		if item.DiscountPercent < 0 || item.DiscountPercent > 100 {
+			return errors.New("discount percent must be between 0 and 100")
+		}
+
+		gross := item.Quantity * item.UnitPrice
+		item.DiscountAmount = math.Round(gross*item.DiscountPercent) / 100
+		item.Subtotal = math.Round((gross-item.DiscountAmount)*100) / 100
+		bill.Subtotal += item.Subtotal
+		if item.IsWithholdable {
+			withholdingBase += item.Subtotal
+		}
+	}
+	bill.Subtotal = math.Round(bill.Subtotal*100) / 100
+
+	if bill.WithholdingType != "" && withholdingBase == 0 {
+		return errors.New("withholding requires at least one withholdable line")
+	}
+
+	bill.TaxAmount = math.Round(bill.Subtotal*bill.TaxRate) / 100
+	bill.TotalAmount = math.Round((bill.Subtotal+bill.TaxAmount)*100) / 100
+	bill.WithholdingAmount = math.Round(withholdingBase*bill.WithholdingRate) / 100
+	bill.PayableAmount = math.Round((bill.TotalAmount-bill.WithholdingAmount)*100) / 100
+	return nil
+}
+
+// Purchase Bill methods
+func (s *purchaseService) CreatePurchaseBill(bill *models.PurchaseBill) error {
+	if len(bill.Items) == 0 {
+		return errors.New("bill must have at least one item")
+	}
+
+	vendor, err := s.purchaseRepo.FindVendorByID(bill.VendorID)
+	if err != nil || vendor.CompanyID != bill.CompanyID {
+		return errors.New("vendor not found")
+	}
+	if !vendor.IsActive {
+		return errors.New("vendor is inactive")
+	}
+
+	receiptItems, err := s.resolveReceiptLines(bill)
+	if err != nil {
+		return err
+	}
+
+	for i := range bill.Items {
+		item := &bill.Items[i]
+		if (item.ProductID == nil) == (item.AccountID == nil) {
+			return errors.New("each line needs either a product or an expense account")
+		}
+
+		if item.ProductID != nil {
+			product, err := s.inventoryRepo.FindProductByID(*item.ProductID)
+			if err != nil || product.CompanyID != bill.CompanyID {
+				return errors.New("product not found")
+			}
+			if item.IsWithholdable {
+				return errors.New("withholding applies to service lines, not stock items")
+			}
+			if item.Description == "" {
+				item.Description = product.Name
+			}
+			if item.Unit == "" {
+				item.Unit = product.Unit
+			} else if item.Unit != product.Unit && findProductUnit(product, item.Unit) == nil {
+				return errors.New("unit " + item.Unit + " is not defined for " + product.Code)
+			}
+			continue
+		}
+
+		account, err := s.accountRepo.FindByID(*item.AccountID)
+		if err != nil || account.CompanyID != bill.CompanyID {
+			return errors.New("expense account not found")
+		}
+		if account.IsHeader {
+			return errors.New("cannot post to header account " + account.Code)
+		}
+		if item.Description == "" {
+			item.Description = account.Name
+		}
+	}
+
+	if err := CalculatePurchaseBillTotals(bill); err != nil {
+		return err
+	}
+	bill.MatchStatus = ""
+	if err := s.matchPurchaseBill(bill, receiptItems); err != nil {
+		return err
+	}
+
+	if bill.DueDate.IsZero() {
+		bill.DueDate = bill.BillDate.AddDate(0, 0, vendor.PaymentTermDays)
+	}
+	if bill.DueDate.Before(bill.BillDate) {
+		return errors.New("due date cannot be before the bill date")
+	}
+
+	billNumber, err := s.purchaseRepo.GeneratePurchaseBillNumber(bill.CompanyID, bill.BillDate)
+	if err != nil {
+		return err
+	}
+	bill.BillNumber = billNumber
+	bill.Status = models.PurchaseBillStatusDraft
+	bill.PaidAmount = 0
+
+	return s.purchaseRepo.CreatePurchaseBill(bill)
+}
+
+func (s *purchaseService) GetPurchaseBillByID(id uint) (*models.PurchaseBill, error) {
+	return s.purchaseRepo.FindPurchaseBillByID(id)
+}
+
+func (s *purchaseService) GetPurchaseBills(companyID uint, vendorID uint, status string) ([]models.PurchaseBill, error) {
+	return s.purchaseRepo.FindPurchaseBills(companyID, vendorID, status)
+}
+
+// PostPurchaseBill receives the stock lines (Dr inventory / Cr purchase
+// clearing through the stock-in) and records the payable: Dr purchase
+// clearing, Dr expenses, Dr PPN Masukan / Cr AP, Cr withholding
+func (s *purchaseService) PostPurchaseBill(id uint, userID uint) error {
+	bill, err := s.purchaseRepo.FindPurchaseBillByID(id)
+	if err != nil {
+		return errors.New("purchase bill not found")
+	}
+
+	if bill.Status != models.PurchaseBillStatusDraft {
+		return errors.New("only draft bills can be posted")
+	}
+
+	// Rematch: other bills may have billed the same receipts since
+	receiptItems, err := s.resolveReceiptLines(bill)
+	if err != nil {
+		return err
+	}
+	if err := s.matchPurchaseBill(bill, receiptItems); err != nil {
+		return err
+	}
+	if bill.MatchStatus == models.PurchaseMatchStatusMismatch {
+		if err := s.purchaseRepo.UpdatePurchaseBill(bill); err != nil {
+			return err
+		}
+		return errors.New("bill does not match its purchase orders and goods receipts and needs approval: " + bill.MatchNotes)
+	}
+
+	payable, err := s.accountRepo.FindByCode(bill.CompanyID, defaultPayableAccountCode)
+	if err != nil {
+		return errors.New("payable account " + defaultPayableAccountCode + " not found")
+	}
+	clearing, err := s.accountRepo.FindByCode(bill.CompanyID, defaultPurchaseClearingAccountCode)
+	if err != nil {
+		return errors.New("purchase clearing account " + defaultPurchaseClearingAccountCode + " not found")
+	}
+
+	description := "Purchase bill " + bill.BillNumber + " - " + bill.Vendor.Name
+	var entries []models.JournalEntry
+	addEntry := func(accountID uint, debit, credit float64) {
+		entries = append(entries, models.JournalEntry{
+			AccountID:   accountID,
+			Description: description,
+			Debit:       debit,
+			Credit:      credit,
+			Position:    len(entries) + 1,
+		})
+	}
+
+	// Lines received by an earlier attempt that failed part way keep their movement
+	var stockCost, stockBilled float64
+	var accountIDs []uint
+	expenses := make(map[uint]float64)
+	for i := range bill.Items {
+		item := &bill.Items[i]
+		if item.AccountID != nil {
+			if _, ok := expenses[*item.AccountID]; !ok {
+				accountIDs = append(accountIDs, *item.AccountID)
+			}
+			expenses[*item.AccountID] += item.Subtotal
+			continue
+		}
+
+		// Goods received by a receipt are cleared at the cost they were
+		// received at, up to what is left unbilled on the receipt line
+		if item.GoodsReceiptItemID != nil {
+			receiptItem := receiptItems[*item.GoodsReceiptItemID]
+			quantity := math.Max(0, math.Min(item.Quantity, receiptItem.Quantity-receiptItem.BilledQuantity))
+			item.MovementID = receiptItem.MovementID
+			item.CostAmount = math.Round(receiptItem.CostAmount*quantity/receiptItem.Quantity*100) / 100
+			stockCost += item.CostAmount
+			stockBilled += item.Subtotal
+			continue
+		}
+
+		if item.MovementID == nil {
+			movement := &models.StockMovement{
+				CompanyID:       bill.CompanyID,
+				ProductID:       *item.ProductID,
+				WarehouseID:     bill.WarehouseID,
+				MovementDate:    bill.BillDate,
+				Quantity:        item.Quantity,
+				Unit:            item.Unit,
+				UnitCost:        item.Subtotal / item.Quantity,
+				LotNumber:       item.LotNumber,
+				ExpiryDate:      item.ExpiryDate,
+				SerialNumbers:   append([]string(nil), item.SerialNumbers...),
+				Reference:       bill.BillNumber,
+				Party:           bill.Vendor.Name,
+				Notes:           "Purchase " + bill.BillNumber + " - " + item.Description,
+				OffsetAccountID: &clearing.ID,
+				CreatedBy:       userID,
+			}
+			if err := s.inventoryService.CreateStockIn(movement); err != nil {
+				return errors.New("failed to receive " + item.Product.Code + ": " + err.Error())
+			}
+
+			item.MovementID = &movement.ID
+			item.CostAmount = math.Round(movement.TotalCost*100) / 100
+			if err := s.purchaseRepo.UpdatePurchaseBillItem(item); err != nil {
+				return err
+			}
+		}
+		stockCost += item.CostAmount
+		stockBilled += item.Subtotal
+	}
+
+	// The stock-in cleared the goods at their unit cost; cents lost to the
+	// per-unit rounding and differences with the price received at go to the
+	// inventory variance account
+	if stockCost = math.Round(stockCost*100) / 100; stockCost > 0 {
+		addEntry(clearing.ID, stockCost, 0)
+	}
+	if rounding := math.Round((stockBilled-stockCost)*100) / 100; rounding != 0 {
+		variance, err := s.accountRepo.FindByCode(bill.CompanyID, defaultInventoryVarianceCode)
+		if err != nil {
+			return errors.New("inventory variance account " + defaultInventoryVarianceCode + " not found")
+		}
+		if rounding > 0 {
+			addEntry(variance.ID, rounding, 0)
+		} else {
+			addEntry(variance.ID, 0, -rounding)
+		}
+	}
+	for _, accountID := range accountIDs {
+		if amount := math.Round(expenses[accountID]*100) / 100; amount > 0 {
+			addEntry(accountID, amount, 0)
+		}
+	}
+	if bill.TaxAmount > 0 {
+		inputTax, err := s.accountRepo.FindByCode(bill.CompanyID, defaultInputTaxAccountCode)
+		if err != nil {
+			return errors.New("input tax account " + defaultInputTaxAccountCode + " not found")
+		}
+		addEntry(inputTax.ID, bill.TaxAmount, 0)
+	}
+	if bill.PayableAmount > 0 {
+		addEntry(payable.ID, 0, bill.PayableAmount)
+	}
+	if bill.WithholdingAmount > 0 {
+		withholding, err := s.accountRepo.FindByCode(bill.CompanyID, defaultWithholdingAccountCode)
+		if err != nil {
+			return errors.New("withholding account " + defaultWithholdingAccountCode + " not found")
+		}
+		addEntry(withholding.ID, 0, bill.WithholdingAmount)
+	}
+
+	journal := &models.Journal{
+		CompanyID:       bill.CompanyID,
+		TransactionDate: bill.BillDate,
+		Description:     description,
+		CreatedBy:       userID,
+		Entries:         entries,
+	}
+
+	if err := s.journalService.CreateJournal(journal); err != nil {
+		return err
+	}
+	if err := s.journalService.PostJournal(journal.ID, userID); err != nil {
+		return err
+	}
+
+	// Receipts of the same order line each carry their own copy of it
+	orderItems := make(map[uint]*models.PurchaseOrderItem)
+	for i := range bill.Items {
+		item := &bill.Items[i]
+		if item.GoodsReceiptItemID == nil {
+			continue
+		}
+		if err := s.purchaseRepo.UpdatePurchaseBillItem(item); err != nil {
+			return err
+		}
+
+		receiptItem := receiptItems[*item.GoodsReceiptItemID]
+		receiptItem.BilledQuantity = math.Round((receiptItem.BilledQuantity+item.Quantity)*100) / 100
+		if err := s.procurementRepo.UpdateGoodsReceiptItem(receiptItem); err != nil {
+			return err
+		}
+		orderItem, ok := orderItems[receiptItem.PurchaseOrderItemID]
+		if !ok {
+			orderItem = &receiptItem.PurchaseOrderItem
+			orderItems[orderItem.ID] = orderItem
+		}
+		orderItem.BilledQuantity = math.Round((orderItem.BilledQuantity+item.Quantity)*100) / 100
+		if err := s.procurementRepo.UpdatePurchaseOrderItem(orderItem); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	bill.JournalID = &journal.ID
+	bill.Status = models.PurchaseBillStatusPosted
+	bill.PostedAt = &now
+	bill.PostedBy = &userID
+	if bill.PayableAmount == 0 {
+		bill.Status = models.PurchaseBillStatusPaid
+	}
+	return s.purchaseRepo.UpdatePurchaseBill(bill)
+}
+
+// ApprovePurchaseBillMatch accepts a bill that is outside the matching
+// tolerances so it can be posted
+func (s *purchaseService) ApprovePurchaseBillMatch(id uint, userID uint) error {
+	bill, err := s.purchaseRepo.FindPurchaseBillByID(id)
+	if err != nil {
+		return errors.New("purchase bill not found")
+	}
+
+	if bill.Status != models.PurchaseBillStatusDraft {
+		return errors.New("only draft bills can be approved")
+	}
+	if bill.MatchStatus != models.PurchaseMatchStatusMismatch {
+		return errors.New("bill has no mismatch to approve")
+	}
+
+	now := time.Now()
+	bill.MatchStatus = models.PurchaseMatchStatusApproved
+	bill.MatchApprovedAt = &now
+	bill.MatchApprovedBy = &userID
+	return s.purchaseRepo.UpdatePurchaseBill(bill)
+}
+
+// resolveReceiptLines loads the goods receipt lines the bill's lines bill,
+// checks they were received from the bill's vendor and fills the bill lines'
+// product and unit from them
+func (s *purchaseService) resolveReceiptLines(bill *models.PurchaseBill) (map[uint]*models.GoodsReceiptItem, error) {
+	var ids []uint
+	seen := make(map[uint]bool)
+	for _, item := range bill.Items {
+		if item.GoodsReceiptItemID == nil {
+			continue
+		}
+		if seen[*item.GoodsReceiptItemID] {
+			return nil, errors.New("a receipt line can only be billed once per bill")
+		}
+		seen[*item.GoodsReceiptItemID] = true
+		ids = append(ids, *item.GoodsReceiptItemID)
+	}
+
+	items, err := s.procurementRepo.FindGoodsReceiptItemsByIDs(ids)
+	if err != nil {
+		return nil, err
+	}
+	receiptItems := make(map[uint]*models.GoodsReceiptItem, len(items))
+	for i := range items {
+		receiptItems[items[i].ID] = &items[i]
+	}
+
+	for i := range bill.Items {
+		item := &bill.Items[i]
+		if item.GoodsReceiptItemID == nil {
+			continue
+		}
+		receiptItem, ok := receiptItems[*item.GoodsReceiptItemID]
+		if !ok || receiptItem.Receipt == nil || receiptItem.Receipt.CompanyID != bill.CompanyID {
+			return nil, errors.New("goods receipt line not found")
+		}
+		receipt := receiptItem.Receipt
+		if receipt.Status != models.GoodsReceiptStatusPosted {
+			return nil, errors.New("goods receipt " + receipt.ReceiptNumber + " is not posted")
+		}
+		if receipt.VendorID != bill.VendorID {
+			return nil, errors.New("goods receipt " + receipt.ReceiptNumber + " is from another vendor")
+		}
+		if item.AccountID != nil {
+			return nil, errors.New("expense lines cannot bill a goods receipt")
+		}
+
+		if item.ProductID == nil {
+			productID := receiptItem.ProductID
+			item.ProductID = &productID
+		} else if *item.ProductID != receiptItem.ProductID {
+			return nil, errors.New("bill line product differs from goods receipt " + receipt.ReceiptNumber)
+		}
+		if item.Unit == "" {
+			item.Unit = receiptItem.Unit
+		} else if item.Unit != receiptItem.Unit {
+			return nil, errors.New("bill line must be in " + receiptItem.Unit + " as received on " + receipt.ReceiptNumber)
+		}
+		if item.Description == "" {
+			item.Description = receiptItem.Description
+		}
+	}
+	return receiptItems, nil
+}
+
+// matchPurchaseBill sets the bill's match status from its lines that bill
+// goods receipts; an approved mismatch stays approved
+func (s *purchaseService) matchPurchaseBill(bill *models.PurchaseBill, receiptItems map[uint]*models.GoodsReceiptItem) error {
+	if len(receiptItems) == 0 {
+		bill.MatchStatus = ""
+		bill.MatchNotes = ""
+		return nil
+	}
+
+	tolerance, err := s.procurementRepo.FindMatchTolerance(bill.CompanyID)
+	if err != nil {
+		return err
+	}
+
+	var discrepancies []string
+	for i := range bill.Items {
+		item := &bill.Items[i]
+		if item.GoodsReceiptItemID == nil {
+			continue
+		}
+		discrepancies = append(discrepancies, MatchPurchaseBillLine(item, receiptItems[*item.GoodsReceiptItemID], tolerance)...)
+	}
+
+	bill.MatchNotes = strings.Join(discrepancies, "; ")
+	switch {
+	case len(discrepancies) == 0:
+		bill.MatchStatus = models.PurchaseMatchStatusMatched
+	case bill.MatchStatus != models.PurchaseMatchStatusApproved:
+		bill.MatchStatus = models.PurchaseMatchStatusMismatch
+	}
+	return nil
+}
+
+// MatchPurchaseBillLine compares a bill line with the goods receipt line it
+// bills and that line's purchase order line: the quantity billed against
+// what was received and not billed yet, and the billed net price against the
+// ordered net price. It returns the discrepancies outside the tolerances.
+func MatchPurchaseBillLine(item *models.PurchaseBillItem, receiptItem *models.GoodsReceiptItem, tolerance *models.PurchaseMatchTolerance) []string {
+	var discrepancies []string
+
+	unbilled := receiptItem.Quantity - receiptItem.BilledQuantity
+	if item.Quantity > unbilled*(1+tolerance.QuantityPercent/100)+0.001 {
+		discrepancies = append(discrepancies, fmt.Sprintf("%s: billed %.2f %s, %.2f received and not billed",
+			item.Description, item.Quantity, item.Unit, math.Max(0, unbilled)))
+	}
+
+	if item.Quantity > 0 {
+		ordered := receiptItem.PurchaseOrderItem.NetUnitPrice()
+		billed := item.Subtotal / item.Quantity
+		if math.Abs(billed-ordered) > ordered*tolerance.PricePercent/100+0.005 {
+			discrepancies = append(discrepancies, fmt.Sprintf("%s: billed at %.2f, ordered at %.2f",
+				item.Description, billed, ordered))
+		}
+	}
+	return discrepancies
+}
+
+func (s *purchaseService) CancelPurchaseBill(id uint) error {
+	bill, err := s.purchaseRepo.FindPurchaseBillByID(id)
+	if err != nil {
+		return errors.New("purchase bill not found")
+	}
+
+	if bill.Status != models.PurchaseBillStatusDraft {
+		return errors.New("only draft bills can be cancelled")
+	}
+	for _, item := range bill.Items {
+		if item.MovementID != nil {
+			return errors.New("goods have already been received for this bill")
+		}
+	}
+
+	bill.Status = models.PurchaseBillStatusCancelled
+	return s.purchaseRepo.UpdatePurchaseBill(bill)
+}
+
+// Vendor Payment methods
+func (s *purchaseService) CreateVendorPayment(payment *models.VendorPayment) error {
+	if payment.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+
+	vendor, err := s.purchaseRepo.FindVendorByID(payment.VendorID)
+	if err != nil || vendor.CompanyID != payment.CompanyID {
+		return errors.New("vendor not found")
+	}
+
+	if _, err := findCashBankAccount(s.accountRepo, payment.CompanyID, payment.AccountID); err != nil {
+		return err
+	}
+
+	return s.payVendor(payment, vendor)
+}
+
+// payVendor pays out of cash/bank (Dr AP / Cr cash/bank) and settles the
+// bills the payment is allocated to. Without allocations the amount is
+// applied to the vendor's bills oldest due first.
+func (s *purchaseService) payVendor(payment *models.VendorPayment, vendor *models.Vendor) error {
+	payment.Amount = math.Round(payment.Amount*100) / 100
+
+	payable, err := s.accountRepo.FindByCode(payment.CompanyID, defaultPayableAccountCode)
+	if err != nil {
+		return errors.New("payable account " + defaultPayableAccountCode + " not found")
+	}
+
+	openBills, err := s.purchaseRepo.FindOpenPurchaseBills(payment.VendorID)
+	if err != nil {
+		return err
+	}
+	bills := make(map[uint]*models.PurchaseBill)
+	for i := range openBills {
+		bills[openBills[i].ID] = &openBills[i]
+	}
+
+	if len(payment.Allocations) == 0 {
+		remaining := payment.Amount
+		for i := range openBills {
+			if remaining <= 0 {
+				break
+			}
+			amount := math.Min(remaining, math.Round(openBills[i].Outstanding()*100)/100)
+			payment.Allocations = append(payment.Allocations, models.VendorPaymentAllocation{
+				BillID: openBills[i].ID,
+				Amount: amount,
+			})
+			remaining = math.Round((remaining-amount)*100) / 100
+		}
+		if remaining > 0 {
+			return fmt.Errorf("payment exceeds the vendor's outstanding bills by %.2f", remaining)
+		}
+	}
+
+	var allocated float64
+	for i := range payment.Allocations {
+		allocation := &payment.Allocations[i]
+		bill, ok := bills[allocation.BillID]
+		if !ok {
+			return errors.New("bill is not open for this vendor")
+		}
+		allocation.Amount = math.Round(allocation.Amount*100) / 100
+		if allocation.Amount <= 0 {
+			return errors.New("allocated amount must be greater than zero")
+		}
+		if allocation.Amount > math.Round(bill.Outstanding()*100)/100 {
+			return errors.New("allocation exceeds the outstanding amount of " + bill.BillNumber)
+		}
+
+		// The same bill listed twice draws on what is left of it
+		bill.PaidAmount = math.Round((bill.PaidAmount+allocation.Amount)*100) / 100
+		allocated += allocation.Amount
+	}
+	if math.Round(allocated*100)/100 != payment.Amount {
+		return errors.New("allocations must add up to the payment amount")
+	}
+
+	// The payment, its posted journal and the bills it settles are saved
+	// together so a failure leaves nothing to retry around
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		paymentNumber, err := txService.purchaseRepo.GenerateVendorPaymentNumber(payment.CompanyID, payment.PaymentDate)
+		if err != nil {
+			return err
+		}
+		payment.PaymentNumber = paymentNumber
+
+		transaction := &models.CashBankTransaction{
+			CompanyID:       payment.CompanyID,
+			AccountID:       payment.AccountID,
+			TransactionDate: payment.PaymentDate,
+			Category:        models.CategoryPayablePayment,
+			Amount:          payment.Amount,
+			Description:     "Payment " + paymentNumber + " to " + vendor.Name,
+			Reference:       payment.Reference,
+			CreatedBy:       payment.CreatedBy,
+		}
+		if err := txService.cashBankService.CreateCashOutWithJournal(transaction, payable.ID); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(*transaction.JournalID, payment.CreatedBy); err != nil {
+			return err
+		}
+
+		payment.CashBankTransactionID = &transaction.ID
+		payment.JournalID = transaction.JournalID
+		if err := txService.purchaseRepo.CreateVendorPayment(payment); err != nil {
+			return err
+		}
+
+		updated := make(map[uint]bool)
+		for _, allocation := range payment.Allocations {
+			if updated[allocation.BillID] {
+				continue
+			}
+			updated[allocation.BillID] = true
+
+			bill := bills[allocation.BillID]
+			if math.Round(bill.Outstanding()*100) <= 0 {
+				bill.Status = models.PurchaseBillStatusPaid
+			} else {
+				bill.Status = models.PurchaseBillStatusPartiallyPaid
+			}
+			if err := txService.purchaseRepo.UpdatePurchaseBill(bill); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *purchaseService) GetVendorPaymentByID(id uint) (*models.VendorPayment, error) {
+	return s.purchaseRepo.FindVendorPaymentByID(id)
+}
+
+func (s *purchaseService) GetVendorPayments(companyID uint, vendorID uint) ([]models.VendorPayment, error) {
+	return s.purchaseRepo.FindVendorPayments(companyID, vendorID)
+}
+
+// Purchase Debit Note methods
+
+// CalculatePurchaseDebitNoteTotals fills the debit note lines from the bill
+// lines they reference and totals them. A line returning goods without an
+// amount is debited at the billed price of the line. Withholding is reversed
+// on the withholdable lines at the bill's rate. debited holds what earlier
+// debit notes took off each bill line; a line cannot be debited more than
+// was billed.
+func CalculatePurchaseDebitNoteTotals(debitNote *models.PurchaseDebitNote, bill *models.PurchaseBill, debited map[uint]models.PurchaseDebitNoteItem) error {
+	if len(debitNote.Items) == 0 {
+		return errors.New("debit note must have at least one item")
+	}
+
+	billItems := make(map[uint]*models.PurchaseBillItem, len(bill.Items))
+	for i := range bill.Items {
+		billItems[bill.Items[i].ID] = &bill.Items[i]
+	}
+
+	var withholdingBase float64
+	debitNote.Subtotal = 0
+	seen := make(map[uint]bool)
+	for i := range debitNote.Items {
+		item := &debitNote.Items[i]
+		billItem, ok := billItems[item.BillItemID]
+		if !ok {
+			return errors.New("bill line not found")
+		}
+		if seen[item.BillItemID] {
+			return errors.New("a bill line can only be debited once per debit note")
+		}
+		seen[item.BillItemID] = true
+
+		item.Unit = billItem.Unit
+		if item.Description == "" {
+			item.Description = billItem.Description
+		}
+
+		previous := debited[item.BillItemID]
+		if item.Quantity < 0 {
+			return errors.New("quantity cannot be negative")
+		}
+		if item.Quantity > 0 && billItem.AccountID != nil {
+			return errors.New("expense lines can only be debited by amount")
+		}
+		if item.Quantity > billItem.Quantity-previous.Quantity+0.001 {
+			return fmt.Errorf("only %.2f %s of %s can still be returned", billItem.Quantity-previous.Quantity, billItem.Unit, item.Description)
+		}
+		if item.Amount == 0 && item.Quantity > 0 {
+			item.Amount = math.Round(billItem.Subtotal*item.Quantity/billItem.Quantity*100) / 100
+		}
+		if item.Amount <= 0 {
+			return errors.New("debit amount must be greater than zero")
+		}
+		if math.Round((previous.Amount+item.Amount)*100) > math.Round(billItem.Subtotal*100) {
+			return fmt.Errorf("only %.2f of %s can still be debited", billItem.Subtotal-previous.Amount, item.Description)
+		}
+
+		debitNote.Subtotal += item.Amount
+		if billItem.IsWithholdable {
+			withholdingBase += item.Amount
+		}
+	}
+
+	debitNote.Subtotal = math.Round(debitNote.Subtotal*100) / 100
+	debitNote.TaxRate = bill.TaxRate
+	debitNote.TaxAmount = math.Round(debitNote.Subtotal*debitNote.TaxRate) / 100
+	debitNote.TotalAmount = math.Round((debitNote.Subtotal+debitNote.TaxAmount)*100) / 100
+	debitNote.WithholdingAmount = math.Round(withholdingBase*bill.WithholdingRate) / 100
+	debitNote.PayableAmount = math.Round((debitNote.TotalAmount-debitNote.WithholdingAmount)*100) / 100
+	return nil
+}
+
+// preparePurchaseDebitNote checks the bill can still be debited and
+// (re)calculates the debit note against it
+func (s *purchaseService) preparePurchaseDebitNote(debitNote *models.PurchaseDebitNote) (*models.PurchaseBill, error) {
+	bill, err := s.purchaseRepo.FindPurchaseBillByID(debitNote.BillID)
+	if err != nil || bill.CompanyID != debitNote.CompanyID {
+		return nil, errors.New("purchase bill not found")
+	}
+	if bill.Status == models.PurchaseBillStatusDraft || bill.Status == models.PurchaseBillStatusCancelled {
+		return nil, errors.New("only posted bills can be debited")
+	}
+	if debitNote.DebitNoteDate.Before(bill.BillDate) {
+		return nil, errors.New("debit note date cannot be before the bill date")
+	}
+
+	debited, err := s.purchaseRepo.SumDebitedByBillItem(bill.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := CalculatePurchaseDebitNoteTotals(debitNote, bill, debited); err != nil {
+		return nil, err
+	}
+
+	// Whatever is no longer owed on the bill is held as vendor credit to be
+	// refunded or applied later
+	debitNote.UnappliedAmount = UnappliedNoteAmount(debitNote.PayableAmount, bill.Outstanding())
+
+	debitNote.VendorID = bill.VendorID
+	return bill, nil
+}
+
+func (s *purchaseService) CreatePurchaseDebitNote(debitNote *models.PurchaseDebitNote) error {
+	if _, err := s.preparePurchaseDebitNote(debitNote); err != nil {
+		return err
+	}
+
+	debitNoteNumber, err := s.purchaseRepo.GeneratePurchaseDebitNoteNumber(debitNote.CompanyID, debitNote.DebitNoteDate)
+	if err != nil {
+		return err
+	}
+	debitNote.DebitNoteNumber = debitNoteNumber
+	debitNote.Status = models.DebitNoteStatusDraft
+
+	return s.purchaseRepo.CreatePurchaseDebitNote(debitNote)
+}
+
+func (s *purchaseService) GetPurchaseDebitNoteByID(id uint) (*models.PurchaseDebitNote, error) {
+	return s.purchaseRepo.FindPurchaseDebitNoteByID(id)
+}
+
+func (s *purchaseService) GetPurchaseDebitNotes(companyID uint, vendorID uint, billID uint, status string) ([]models.PurchaseDebitNote, error) {
+	return s.purchaseRepo.FindPurchaseDebitNotes(companyID, vendorID, billID, status)
+}
+
+// PostPurchaseDebitNote sends returned goods back out of stock at the cost
+// they were received at (Dr purchase clearing / Cr inventory through the
+// stock return) and reverses the bill: Dr AP, Dr withholding / Cr purchase
+// clearing, Cr expenses, Cr PPN Masukan. Price corrections on stock lines and
+// the difference between the debited amount and the returned cost go to the
+// inventory variance account. The part beyond the bill's open balance is
+// debited to vendor credit instead of AP.
+func (s *purchaseService) PostPurchaseDebitNote(id uint, userID uint) error {
+	debitNote, err := s.purchaseRepo.FindPurchaseDebitNoteByID(id)
+	if err != nil {
+		return errors.New("debit note not found")
+	}
+
+	if debitNote.Status != models.DebitNoteStatusDraft {
+		return errors.New("only draft debit notes can be posted")
+	}
+
+	bill, err := s.preparePurchaseDebitNote(debitNote)
+	if err != nil {
+		return err
+	}
+
+	payable, err := s.accountRepo.FindByCode(debitNote.CompanyID, defaultPayableAccountCode)
+	if err != nil {
+		return errors.New("payable account " + defaultPayableAccountCode + " not found")
+	}
+	clearing, err := s.accountRepo.FindByCode(debitNote.CompanyID, defaultPurchaseClearingAccountCode)
+	if err != nil {
+		return errors.New("purchase clearing account " + defaultPurchaseClearingAccountCode + " not found")
+	}
+
+	billItems := make(map[uint]*models.PurchaseBillItem, len(bill.Items))
+	for i := range bill.Items {
+		billItems[bill.Items[i].ID] = &bill.Items[i]
+	}
+
+	description := "Debit note " + debitNote.DebitNoteNumber + " for " + bill.BillNumber + " - " + bill.Vendor.Name
+	var entries []models.JournalEntry
+	addEntry := func(accountID uint, debit, credit float64) {
+		entries = append(entries, models.JournalEntry{
+			AccountID:   accountID,
+			Description: description,
+			Debit:       debit,
+			Credit:      credit,
+			Position:    len(entries) + 1,
+		})
+	}
+
+	// Lines returned by an earlier attempt that failed part way keep their movement
+	var stockCost, stockDebited float64
+	var accountIDs []uint
+	expenses := make(map[uint]float64)
+	for i := range debitNote.Items {
+		item := &debitNote.Items[i]
+		billItem := billItems[item.BillItemID]
+		if billItem.AccountID != nil {
+			if _, ok := expenses[*billItem.AccountID]; !ok {
+				accountIDs = append(accountIDs, *billItem.AccountID)
+			}
+			expenses[*billItem.AccountID] += item.Amount
+			continue
+		}
+
+		if item.Quantity > 0 && item.MovementID == nil {
+			if billItem.MovementID == nil {
+				return errors.New("goods of " + item.Description + " were never received")
+			}
+
+			movement := &models.StockMovement{
+				CompanyID:     debitNote.CompanyID,
+				MovementDate:  debitNote.DebitNoteDate,
+				Quantity:      item.Quantity,
+				SerialNumbers: append([]string(nil), item.SerialNumbers...),
+				Reference:     debitNote.DebitNoteNumber,
+				Party:         bill.Vendor.Name,
+				Notes:         "Purchase return " + debitNote.DebitNoteNumber + " of " + bill.BillNumber + " - " + item.Description,
+				CreatedBy:     userID,
+			}
+			if err := s.inventoryService.CreateStockReturn(movement, *billItem.MovementID); err != nil {
+				return errors.New("failed to return " + item.Description + ": " + err.Error())
+			}
+
+			item.MovementID = &movement.ID
+			item.CostAmount = math.Round(movement.TotalCost*100) / 100
+			if err := s.purchaseRepo.UpdatePurchaseDebitNoteItem(item); err != nil {
+				return err
+			}
+		}
+		stockCost += item.CostAmount
+		stockDebited += item.Amount
+	}
+
+	applied := math.Round(debitNote.AppliedAmount()*100) / 100
+	if applied > 0 {
+		addEntry(payable.ID, applied, 0)
+	}
+	if debitNote.UnappliedAmount > 0 {
+		vendorCredit, err := s.accountRepo.FindByCode(debitNote.CompanyID, defaultVendorCreditCode)
+		if err != nil {
+			return errors.New("vendor credit account " + defaultVendorCreditCode + " not found")
+		}
+		addEntry(vendorCredit.ID, debitNote.UnappliedAmount, 0)
+	}
+	if debitNote.WithholdingAmount > 0 {
+		withholding, err := s.accountRepo.FindByCode(debitNote.CompanyID, defaultWithholdingAccountCode)
+		if err != nil {
+			return errors.New("withholding account " + defaultWithholdingAccountCode + " not found")
+		}
+		addEntry(withholding.ID, debitNote.WithholdingAmount, 0)
+	}
+
+	// The stock return cleared the goods at their original cost
+	if stockCost = math.Round(stockCost*100) / 100; stockCost > 0 {
+		addEntry(clearing.ID, 0, stockCost)
+	}
+	if difference := math.Round((stockDebited-stockCost)*100) / 100; difference != 0 {
+		variance, err := s.accountRepo.FindByCode(debitNote.CompanyID, defaultInventoryVarianceCode)
+		if err != nil {
+			return errors.New("inventory variance account " + defaultInventoryVarianceCode + " not found")
+		}
+		if difference > 0 {
+			addEntry(variance.ID, 0, difference)
+		} else {
+			addEntry(variance.ID, -difference, 0)
+		}
+	}
+	for _, accountID := range accountIDs {
+		if amount := math.Round(expenses[accountID]*100) / 100; amount > 0 {
+			addEntry(accountID, 0, amount)
+		}
+	}
+	if debitNote.TaxAmount > 0 {
+		inputTax, err := s.accountRepo.FindByCode(debitNote.CompanyID, defaultInputTaxAccountCode)
+		if err != nil {
+			return errors.New("input tax account " + defaultInputTaxAccountCode + " not found")
+		}
+		addEntry(inputTax.ID, 0, debitNote.TaxAmount)
+	}
+
+	journal := &models.Journal{
+		CompanyID:       debitNote.CompanyID,
+		TransactionDate: debitNote.DebitNoteDate,
+		Description:     description,
+		CreatedBy:       userID,
+		Entries:         entries,
+	}
+
+	if err := s.journalService.CreateJournal(journal); err != nil {
+		return err
+	}
+	if err := s.journalService.PostJournal(journal.ID, userID); err != nil {
+		return err
+	}
+
+	bill.DebitedAmount = math.Round((bill.DebitedAmount+applied)*100) / 100
+	if math.Round(bill.Outstanding()*100) <= 0 {
+		bill.Status = models.PurchaseBillStatusPaid
+	}
+	if err := s.purchaseRepo.UpdatePurchaseBill(bill); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	debitNote.JournalID = &journal.ID
+	debitNote.Status = models.DebitNoteStatusPosted
+	debitNote.PostedAt = &now
+	debitNote.PostedBy = &userID
+	return s.purchaseRepo.UpdatePurchaseDebitNote(debitNote)
+}
+
+func (s *purchaseService) CancelPurchaseDebitNote(id uint) error {
+	debitNote, err := s.purchaseRepo.FindPurchaseDebitNoteByID(id)
+	if err != nil {
+		return errors.New("debit note not found")
+	}
+
+	if debitNote.Status != models.DebitNoteStatusDraft {
+		return errors.New("only draft debit notes can be cancelled")
+	}
+	for _, item := range debitNote.Items {
+		if item.MovementID != nil {
+			return errors.New("goods have already been returned for this debit note")
+		}
+	}
+
+	debitNote.Status = models.DebitNoteStatusCancelled
+	return s.purchaseRepo.UpdatePurchaseDebitNote(debitNote)
+}
+
+// preparePurchaseDebitNoteApplication checks the debit note still holds the
+// vendor credit the application draws on
+func (s *purchaseService) preparePurchaseDebitNoteApplication(application *models.PurchaseDebitNoteApplication) (*models.PurchaseDebitNote, error) {
+	debitNote, err := s.purchaseRepo.FindPurchaseDebitNoteByID(application.DebitNoteID)
+	if err != nil || debitNote.CompanyID != application.CompanyID {
+		return nil, errors.New("debit note not found")
+	}
+	if debitNote.Status != models.DebitNoteStatusPosted {
+		return nil, errors.New("only posted debit notes hold vendor credit")
+	}
+	if application.ApplicationDate.Before(debitNote.DebitNoteDate) {
+		return nil, errors.New("date cannot be before the debit note date")
+	}
+
+	application.Amount = math.Round(application.Amount*100) / 100
+	if application.Amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if application.Amount > math.Round(debitNote.AvailableCredit()*100)/100 {
+		return nil, fmt.Errorf("only %.2f of vendor credit is left on %s", debitNote.AvailableCredit(), debitNote.DebitNoteNumber)
+	}
+
+	application.VendorID = debitNote.VendorID
+	return debitNote, nil
+}
+
+// ApplyPurchaseDebitNote settles another open bill of the vendor with
+// credit held on a debit note: Dr AP / Cr vendor credit
+func (s *purchaseService) ApplyPurchaseDebitNote(application *models.PurchaseDebitNoteApplication) error {
+	debitNote, err := s.preparePurchaseDebitNoteApplication(application)
+	if err != nil {
+		return err
+	}
+	if application.BillID == nil {
+		return errors.New("bill is required")
+	}
+	application.AccountID = nil
+
+	bill, err := s.purchaseRepo.FindPurchaseBillByID(*application.BillID)
+	if err != nil || bill.CompanyID != application.CompanyID || bill.VendorID != debitNote.VendorID {
+		return errors.New("bill is not open for this vendor")
+	}
+	if bill.Status != models.PurchaseBillStatusPosted && bill.Status != models.PurchaseBillStatusPartiallyPaid {
+		return errors.New("bill is not open for this vendor")
+	}
+	if application.ApplicationDate.Before(bill.BillDate) {
+		return errors.New("date cannot be before the bill date")
+	}
+	if application.Amount > math.Round(bill.Outstanding()*100)/100 {
+		return errors.New("amount exceeds the outstanding amount of " + bill.BillNumber)
+	}
+
+	payable, err := s.accountRepo.FindByCode(application.CompanyID, defaultPayableAccountCode)
+	if err != nil {
+		return errors.New("payable account " + defaultPayableAccountCode + " not found")
+	}
+	vendorCredit, err := s.accountRepo.FindByCode(application.CompanyID, defaultVendorCreditCode)
+	if err != nil {
+		return errors.New("vendor credit account " + defaultVendorCreditCode + " not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		description := "Debit note " + debitNote.DebitNoteNumber + " applied to " + bill.BillNumber + " - " + debitNote.Vendor.Name
+		journal := &models.Journal{
+			CompanyID:       application.CompanyID,
+			TransactionDate: application.ApplicationDate,
+			Description:     description,
+			CreatedBy:       application.CreatedBy,
+			Entries: []models.JournalEntry{
+				{AccountID: payable.ID, Description: description, Debit: application.Amount, Position: 1},
+				{AccountID: vendorCredit.ID, Description: description, Credit: application.Amount, Position: 2},
+			},
+		}
+		if err := txService.journalService.CreateJournal(journal); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(journal.ID, application.CreatedBy); err != nil {
+			return err
+		}
+
+		bill.DebitedAmount = math.Round((bill.DebitedAmount+application.Amount)*100) / 100
+		if math.Round(bill.Outstanding()*100) <= 0 {
+			bill.Status = models.PurchaseBillStatusPaid
+		} else {
+			bill.Status = models.PurchaseBillStatusPartiallyPaid
+		}
+		if err := txService.purchaseRepo.UpdatePurchaseBill(bill); err != nil {
+			return err
+		}
+
+		debitNote.CreditUsedAmount = math.Round((debitNote.CreditUsedAmount+application.Amount)*100) / 100
+		if err := txService.purchaseRepo.UpdatePurchaseDebitNote(debitNote); err != nil {
+			return err
+		}
+
+		application.JournalID = &journal.ID
+		return txService.purchaseRepo.CreatePurchaseDebitNoteApplication(application)
+	})
+}
+
+// RefundPurchaseDebitNote records the vendor paying back credit held on a
+// debit note: Dr cash/bank / Cr vendor credit
+func (s *purchaseService) RefundPurchaseDebitNote(application *models.PurchaseDebitNoteApplication) error {
+	debitNote, err := s.preparePurchaseDebitNoteApplication(application)
+	if err != nil {
+		return err
+	}
+	if application.AccountID == nil {
+		return errors.New("cash/bank account is required")
+	}
+	application.BillID = nil
+
+	account, err := findCashBankAccount(s.accountRepo, application.CompanyID, *application.AccountID)
+	if err != nil {
+		return err
+	}
+
+	vendorCredit, err := s.accountRepo.FindByCode(application.CompanyID, defaultVendorCreditCode)
+	if err != nil {
+		return errors.New("vendor credit account " + defaultVendorCreditCode + " not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		transaction := &models.CashBankTransaction{
+			CompanyID:       application.CompanyID,
+			AccountID:       account.ID,
+			TransactionDate: application.ApplicationDate,
+			Category:        models.CategoryVendorRefund,
+			Amount:          application.Amount,
+			Description:     "Refund of debit note " + debitNote.DebitNoteNumber + " from " + debitNote.Vendor.Name,
+			Reference:       application.Reference,
+			CreatedBy:       application.CreatedBy,
+		}
+		if err := txService.cashBankService.CreateCashInWithJournal(transaction, vendorCredit.ID); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(*transaction.JournalID, application.CreatedBy); err != nil {
+			return err
+		}
+
+		debitNote.CreditUsedAmount = math.Round((debitNote.CreditUsedAmount+application.Amount)*100) / 100
+		if err := txService.purchaseRepo.UpdatePurchaseDebitNote(debitNote); err != nil {
+			return err
+		}
+
+		application.CashBankTransactionID = &transaction.ID
+		application.JournalID = transaction.JournalID
+		return txService.purchaseRepo.CreatePurchaseDebitNoteApplication(application)
+	})
+}
+
+// Payment Run methods
+
+// CreatePaymentRun proposes the full outstanding amount of every bill due
+// on or before run.DueBefore that is not already in another draft run
+func (s *purchaseService) CreatePaymentRun(run *models.PaymentRun, vendorIDs []uint) error {
+	if _, err := findCashBankAccount(s.accountRepo, run.CompanyID, run.AccountID); err != nil {
+		return err
+	}
+
+	bills, err := s.purchaseRepo.FindDuePurchaseBills(run.CompanyID, run.DueBefore, vendorIDs)
+	if err != nil {
+		return err
+	}
+
+	billIDs := make([]uint, len(bills))
+	for i, bill := range bills {
+		billIDs[i] = bill.ID
+	}
+	proposed, err := s.purchaseRepo.FindBillsInDraftRuns(billIDs)
+	if err != nil {
+		return err
+	}
+	inOtherRun := make(map[uint]bool)
+	for _, id := range proposed {
+		inOtherRun[id] = true
+	}
+
+	run.Lines = nil
+	run.TotalAmount = 0
+	for _, bill := range bills {
+		if inOtherRun[bill.ID] || !bill.Vendor.IsActive {
+			continue
+		}
+		amount := math.Round(bill.Outstanding()*100) / 100
+		if amount <= 0 {
+			continue
+		}
+		run.Lines = append(run.Lines, models.PaymentRunLine{
+			BillID:   bill.ID,
+			VendorID: bill.VendorID,
+			Amount:   amount,
+		})
+		run.TotalAmount += amount
+	}
+	if len(run.Lines) == 0 {
+		return errors.New("no unpaid bills are due by the selected date")
+	}
+	run.TotalAmount = math.Round(run.TotalAmount*100) / 100
+
+	runNumber, err := s.purchaseRepo.GeneratePaymentRunNumber(run.CompanyID, run.PaymentDate)
+	if err != nil {
+		return err
+	}
+	run.RunNumber = runNumber
+	run.Status = models.PaymentRunStatusDraft
+
+	return s.purchaseRepo.CreatePaymentRun(run)
+}
+
+func (s *purchaseService) GetPaymentRunByID(id uint) (*models.PaymentRun, error) {
+	return s.purchaseRepo.FindPaymentRunByID(id)
+}
+
+func (s *purchaseService) GetPaymentRuns(companyID uint, status string) ([]models.PaymentRun, error) {
+	return s.purchaseRepo.FindPaymentRuns(companyID, status)
+}
+
+// UpdatePaymentRunLine changes how much of a bill the run pays; zero drops
+// the bill from the run
+func (s *purchaseService) UpdatePaymentRunLine(runID uint, lineID uint, amount float64) error {
+	run, err := s.purchaseRepo.FindPaymentRunByID(runID)
+	if err != nil {
+		return errors.New("payment run not found")
+	}
+
+	if run.Status != models.PaymentRunStatusDraft {
+		return errors.New("only draft payment runs can be changed")
+	}
+
+	var line *models.PaymentRunLine
+	for i := range run.Lines {
+		if run.Lines[i].ID == lineID {
+			line = &run.Lines[i]
+		}
+	}
+	if line == nil {
+		return errors.New("payment run line not found")
+	}
+	if line.PaymentID != nil {
+		return errors.New("bill has already been paid by this run")
+	}
+
+	amount = math.Round(amount*100) / 100
+	if amount < 0 {
+		return errors.New("amount cannot be negative")
+	}
+	if amount > math.Round(line.Bill.Outstanding()*100)/100 {
+		return errors.New("amount exceeds the outstanding amount of " + line.Bill.BillNumber)
+	}
+
+	run.TotalAmount = math.Round((run.TotalAmount-line.Amount+amount)*100) / 100
+	if amount == 0 {
+		if err := s.purchaseRepo.DeletePaymentRunLine(line.ID); err != nil {
+			return err
+		}
+	} else {
+		line.Amount = amount
+		if err := s.purchaseRepo.UpdatePaymentRunLine(line); err != nil {
+			return err
+		}
+	}
+
+	return s.purchaseRepo.UpdatePaymentRun(run)
+}
+
+// ProcessPaymentRun makes one payment per vendor covering its bills in the
+// run. A vendor that fails is reported and left unpaid; processing again
+// retries only the vendors still unpaid.
+func (s *purchaseService) ProcessPaymentRun(id uint, userID uint) error {
+	run, err := s.purchaseRepo.FindPaymentRunByID(id)
+	if err != nil {
+		return errors.New("payment run not found")
+	}
+
+	if run.Status != models.PaymentRunStatusDraft {
+		return errors.New("only draft payment runs can be processed")
+	}
+	if len(run.Lines) == 0 {
+		return errors.New("payment run has no bills")
+	}
+
+	var vendorIDs []uint
+	linesByVendor := make(map[uint][]*models.PaymentRunLine)
+	for i := range run.Lines {
+		line := &run.Lines[i]
+		if line.PaymentID != nil {
+			continue
+		}
+		if _, ok := linesByVendor[line.VendorID]; !ok {
+			vendorIDs = append(vendorIDs, line.VendorID)
+		}
+		linesByVendor[line.VendorID] = append(linesByVendor[line.VendorID], line)
+	}
+
+	var failures []string
+	for _, vendorID := range vendorIDs {
+		lines := linesByVendor[vendorID]
+		vendor := &lines[0].Bill.Vendor
+
+		payment := &models.VendorPayment{
+			CompanyID:    run.CompanyID,
+			PaymentDate:  run.PaymentDate,
+			VendorID:     vendorID,
+			AccountID:    run.AccountID,
+			Reference:    run.RunNumber,
+			PaymentRunID: &run.ID,
+			CreatedBy:    userID,
+		}
+		for _, line := range lines {
+			payment.Amount += line.Amount
+			payment.Allocations = append(payment.Allocations, models.VendorPaymentAllocation{
+				BillID: line.BillID,
+				Amount: line.Amount,
+			})
+		}
+
+		// A vendor whose payment fails keeps its lines unpaid, so processing
+		// the run again picks up only what is still owed
+		if err := s.db.Transaction(func(tx *gorm.DB) error {
+			txService := s.withTx(tx)
+			if err := txService.payVendor(payment, vendor); err != nil {
+				return err
+			}
+			for _, line := range lines {
+				line.PaymentID = &payment.ID
+				if err := txService.purchaseRepo.UpdatePaymentRunLine(line); err != nil {
+					return err
+				}
+			}
+			return nil
+		}); err != nil {
+			failures = append(failures, vendor.Name+": "+err.Error())
+		}
+	}
+
+	if len(failures) > 0 {
+		return errors.New("some vendors could not be paid: " + strings.Join(failures, "; "))
+	}
+
+	now := time.Now()
+	run.Status = models.PaymentRunStatusProcessed
+	run.ProcessedAt = &now
+	run.ProcessedBy = &userID
+	return s.purchaseRepo.UpdatePaymentRun(run)
+}
+
+func (s *purchaseService) CancelPaymentRun(id uint) error {
+	run, err := s.purchaseRepo.FindPaymentRunByID(id)
+	if err != nil {
+		return errors.New("payment run not found")
+	}
+
+	if run.Status != models.PaymentRunStatusDraft {
+		return errors.New("only draft payment runs can be cancelled")
+	}
+	for _, line := range run.Lines {
+		if line.PaymentID != nil {
+			return errors.New("payment run has already paid some vendors")
+		}
+	}
+
+	run.Status = models.PaymentRunStatusCancelled
+	return s.purchaseRepo.UpdatePaymentRun(run)
+}
+
+// Payable Aging methods
+
+// GetPayableAging ages unpaid bills by vendor and reconciles the total to
+// the payable control account
+func (s *purchaseService) GetPayableAging(companyID uint, vendorID uint, asOf time.Time, limits []int) (*models.AgingReport, error) {
+	payable, err := s.accountRepo.FindByCode(companyID, defaultPayableAccountCode)
+	if err != nil {
+		return nil, errors.New("payable account " + defaultPayableAccountCode + " not found")
+	}
+
+	// Documents and payments dated on asOf count towards it
+	endOfDay := asOf.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	items, err := s.purchaseRepo.FindPayableOpenItems(companyID, vendorID, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := BuildAgingReport(items, asOf, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	// Ledger balances are debit less credit; the payable is a credit balance
+	balance, err := s.ledgerRepo.GetAccountBalance(payable.ID, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Type = "payable"
+	report.ControlAccountCode = payable.Code
+	report.ControlAccountName = payable.Name
+	report.GLBalance = math.Round(-balance*100) / 100
+	report.Difference = math.Round((report.Total-report.GLBalance)*100) / 100
+	report.IsReconciled = vendorID == 0 && report.Difference == 0
+	return report, nil
+}
\ No newline at end of file
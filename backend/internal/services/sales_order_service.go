@@ -0,0 +1,835 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+type SalesOrderService interface {
+	// Sales Quotations
+	CreateSalesQuotation(quotation *models.SalesQuotation) error
+	GetSalesQuotationByID(id uint) (*models.SalesQuotation, error)
+	GetSalesQuotations(companyID uint, customerID uint, status string) ([]models.SalesQuotation, error)
+	SendSalesQuotation(id uint) error
+	AcceptSalesQuotation(id uint) error
+	RejectSalesQuotation(id uint) error
+	CancelSalesQuotation(id uint) error
+	ConvertSalesQuotation(id uint, orderDate time.Time, userID uint) (*models.SalesOrder, error)
+
+	// Sales Orders
+	CreateSalesOrder(order *models.SalesOrder) error
+	GetSalesOrderByID(id uint) (*models.SalesOrder, error)
+	GetSalesOrders(companyID uint, customerID uint, status string) ([]models.SalesOrder, error)
+	ConfirmSalesOrder(id uint, userID uint) error
+	CloseSalesOrder(id uint) error
+	CancelSalesOrder(id uint) error
+
+	// Delivery Orders
+	CreateDeliveryOrder(delivery *models.DeliveryOrder) error
+	GetDeliveryOrderByID(id uint) (*models.DeliveryOrder, error)
+	GetDeliveryOrders(companyID uint, customerID uint, salesOrderID uint, status string) ([]models.DeliveryOrder, error)
+	PostDeliveryOrder(id uint, userID uint) error
+	CancelDeliveryOrder(id uint) error
+
+	// Invoicing
+	CreateSalesInvoiceFromDeliveries(companyID uint, deliveryIDs []uint, invoiceDate time.Time, userID uint) (*models.SalesInvoice, error)
+
+	// Reports
+	GetBackorderReport(companyID uint, customerID uint) (*models.BackorderReport, error)
+}
+
+type salesOrderService struct {
+	salesOrderRepo   repository.SalesOrderRepository
+	salesRepo        repository.SalesRepository
+	inventoryRepo    repository.InventoryRepository
+	inventoryService InventoryService
+	salesService     SalesService
+}
+
+func NewSalesOrderService(
+	salesOrderRepo repository.SalesOrderRepository,
+	salesRepo repository.SalesRepository,
+	inventoryRepo repository.InventoryRepository,
+	inventoryService InventoryService,
+	salesService SalesService,
+) SalesOrderService {
+	return &salesOrderService{
+		salesOrderRepo:   salesOrderRepo,
+		salesRepo:        salesRepo,
+		inventoryRepo:    inventoryRepo,
+		inventoryService: inventoryService,
+		salesService:     salesService,
+	}
+}
+
+// findActiveCustomer checks the customer belongs to the company and can
+// still be sold to
+func (s *salesOrderService) findActiveCustomer(companyID uint, customerID uint) (*models.Customer, error) {
+	customer, err := s.salesRepo.FindCustomerByID(customerID)
+	if err != nil || customer.CompanyID != companyID {
+		return nil, errors.New("customer not found")
+	}
+	if !customer.IsActive {
+		return nil, errors.New("customer is inactive")
+	}
+	return customer, nil
+}
+
+// calculateSalesLine fills the discount and subtotal of a quotation or order line
+func calculateSalesLine(quantity, unitPrice, discountPercent float64) (float64, float64, error) {
+	if quantity <= 0 {
+		return 0, 0, errors.New("quantity must be greater than zero")
+	}
+	if unitPrice < 0 {
+		return 0, 0, errors.New("unit price cannot be negative")
+	}
+	if discountPercent < 0 || discountPercent > 100 {
+		return 0, 0, errors.New("discount percent must be between 0 and 100")
+	}
+
+	gross := quantity * unitPrice
+	discount := math.Round(gross*discountPercent) / 100
+	return discount, math.Round((gross-discount)*100) / 100, nil
+}
+
+// CalculateSalesQuotationTotals fills the line discounts and subtotals and
+// the quotation subtotal, PPN and total
+func CalculateSalesQuotationTotals(quotation *models.SalesQuotation) error {
+	if quotation.TaxRate < 0 || quotation.TaxRate > 100 {
+		return errors.New("tax rate must be between 0 and 100")
+	}
+
+	quotation.Subtotal = 0
+	for i := range quotation.Items {
+		item := &quotation.Items[i]
+		discount, subtotal, err := calculateSalesLine(item.Quantity, item.UnitPrice, item.DiscountPercent)
+		if err != nil {
+			return err
+		}
+		item.DiscountAmount = discount
+		item.Subtotal = subtotal
+		quotation.Subtotal += item.Subtotal
+	}
+	quotation.Subtotal = math.Round(quotation.Subtotal*100) / 100
+
+	quotation.TaxAmount = math.Round(quotation.Subtotal*quotation.TaxRate) / 100
+	quotation.TotalAmount = math.Round((quotation.Subtotal+quotation.TaxAmount)*100) / 100
+	return nil
+}
+
+// CalculateSalesOrderTotals fills the line discounts and subtotals and the
+// order subtotal, PPN and total
+func CalculateSalesOrderTotals(order *models.SalesOrder) error {
+	if order.TaxRate < 0 || order.TaxRate > 100 {
+		return errors.New("tax rate must be between 0 and 100")
+	}
+
+	order.Subtotal = 0
+	for i := range order.Items {
+		item := &order.Items[i]
+		discount, subtotal, err := calculateSalesLine(item.Quantity, item.UnitPrice, item.DiscountPercent)
+		if err != nil {
+			return err
+		}
+		item.DiscountAmount = discount
+		item.Subtotal = subtotal
+		order.Subtotal += item.Subtotal
+	}
+	order.Subtotal = math.Round(order.Subtotal*100) / 100
+
+	order.TaxAmount = math.Round(order.Subtotal*order.TaxRate) / 100
+	order.TotalAmount = math.Round((order.Subtotal+order.TaxAmount)*100) / 100
+	return nil
+}
+
+// Sales Quotation methods
+func (s *salesOrderService) CreateSalesQuotation(quotation *models.SalesQuotation) error {
+	if len(quotation.Items) == 0 {
+		return errors.New("quotation must have at least one item")
+	}
+	if quotation.ValidUntil != nil && quotation.ValidUntil.Before(quotation.QuotationDate) {
+		return errors.New("valid until cannot be before the quotation date")
+	}
+
+	if _, err := s.findActiveCustomer(quotation.CompanyID, quotation.CustomerID); err != nil {
+		return err
+	}
+
+	for i := range quotation.Items {
+		item := &quotation.Items[i]
+		if err := resolveProductLine(s.inventoryRepo, quotation.CompanyID, item.ProductID, &item.Description, &item.Unit); err != nil {
+			return err
+		}
+	}
+
+	if err := CalculateSalesQuotationTotals(quotation); err != nil {
+		return err
+	}
+
+	quotationNumber, err := s.salesOrderRepo.GenerateSalesQuotationNumber(quotation.CompanyID, quotation.QuotationDate)
+	if err != nil {
+		return err
+	}
+	quotation.QuotationNumber = quotationNumber
+	quotation.Status = models.SalesQuotationStatusDraft
+	quotation.SalesOrderID = nil
+
+	return s.salesOrderRepo.CreateSalesQuotation(quotation)
+}
+
+func (s *salesOrderService) GetSalesQuotationByID(id uint) (*models.SalesQuotation, error) {
+	return s.salesOrderRepo.FindSalesQuotationByID(id)
+}
+
+func (s *salesOrderService) GetSalesQuotations(companyID uint, customerID uint, status string) ([]models.SalesQuotation, error) {
+	return s.salesOrderRepo.FindSalesQuotations(companyID, customerID, status)
+}
+
+// setSalesQuotationStatus moves a quotation on from one of the given statuses
+func (s *salesOrderService) setSalesQuotationStatus(id uint, status models.SalesQuotationStatus, from ...models.SalesQuotationStatus) error {
+	quotation, err := s.salesOrderRepo.FindSalesQuotationByID(id)
+	if err != nil {
+		return errors.New("sales quotation not found")
+	}
+
+	allowed := false
+	for _, current := range from {
+		if quotation.Status == current {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("a %s quotation cannot be marked %s", quotation.Status, status)
+	}
+
+	quotation.Status = status
+	return s.salesOrderRepo.UpdateSalesQuotation(quotation)
+}
+
+func (s *salesOrderService) SendSalesQuotation(id uint) error {
+	return s.setSalesQuotationStatus(id, models.SalesQuotationStatusSent, models.SalesQuotationStatusDraft)
+}
+
+func (s *salesOrderService) AcceptSalesQuotation(id uint) error {
+	return s.setSalesQuotationStatus(id, models.SalesQuotationStatusAccepted,
+		models.SalesQuotationStatusDraft, models.SalesQuotationStatusSent)
+}
+
+func (s *salesOrderService) RejectSalesQuotation(id uint) error {
+	return s.setSalesQuotationStatus(id, models.SalesQuotationStatusRejected,
+		models.SalesQuotationStatusDraft, models.SalesQuotationStatusSent)
+}
+
+func (s *salesOrderService) CancelSalesQuotation(id uint) error {
+	return s.setSalesQuotationStatus(id, models.SalesQuotationStatusCancelled,
+		models.SalesQuotationStatusDraft, models.SalesQuotationStatusSent, models.SalesQuotationStatusAccepted)
+}
+
+// ConvertSalesQuotation carries a quotation still within its validity
+// forward to a draft sales order at the quoted prices
+func (s *salesOrderService) ConvertSalesQuotation(id uint, orderDate time.Time, userID uint) (*models.SalesOrder, error) {
+	quotation, err := s.salesOrderRepo.FindSalesQuotationByID(id)
+	if err != nil {
+		return nil, errors.New("sales quotation not found")
+	}
+
+	switch quotation.Status {
+	case models.SalesQuotationStatusDraft, models.SalesQuotationStatusSent, models.SalesQuotationStatusAccepted:
+	default:
+		return nil, fmt.Errorf("a %s quotation cannot be converted to a sales order", quotation.Status)
+	}
+	if quotation.ValidUntil != nil && quotation.ValidUntil.Before(orderDate) {
+		return nil, errors.New("quotation expired on " + quotation.ValidUntil.Format("2006-01-02"))
+	}
+	if orderDate.Before(quotation.QuotationDate) {
+		return nil, errors.New("order date cannot be before the quotation date")
+	}
+
+	items := make([]models.SalesOrderItem, len(quotation.Items))
+	for i, item := range quotation.Items {
+		quotationItemID := item.ID
+		items[i] = models.SalesOrderItem{
+			QuotationItemID: &quotationItemID,
+			ProductID:       item.ProductID,
+			Description:     item.Description,
+			Quantity:        item.Quantity,
+			Unit:            item.Unit,
+			UnitPrice:       item.UnitPrice,
+			DiscountPercent: item.DiscountPercent,
+		}
+	}
+
+	order := &models.SalesOrder{
+		CompanyID:   quotation.CompanyID,
+		OrderDate:   orderDate,
+		CustomerID:  quotation.CustomerID,
+		QuotationID: &quotation.ID,
+		TaxRate:     quotation.TaxRate,
+		Notes:       quotation.Notes,
+		CreatedBy:   userID,
+		Items:       items,
+	}
+	if err := s.CreateSalesOrder(order); err != nil {
+		return nil, err
+	}
+
+	quotation.Status = models.SalesQuotationStatusOrdered
+	quotation.SalesOrderID = &order.ID
+	if err := s.salesOrderRepo.UpdateSalesQuotation(quotation); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Sales Order methods
+func (s *salesOrderService) CreateSalesOrder(order *models.SalesOrder) error {
+	if len(order.Items) == 0 {
+		return errors.New("sales order must have at least one item")
+	}
+	if order.ExpectedDate != nil && order.ExpectedDate.Before(order.OrderDate) {
+		return errors.New("expected date cannot be before the order date")
+	}
+
+	if _, err := s.findActiveCustomer(order.CompanyID, order.CustomerID); err != nil {
+		return err
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		if err := resolveProductLine(s.inventoryRepo, order.CompanyID, item.ProductID, &item.Description, &item.Unit); err != nil {
+			return err
+		}
+		item.ReservationID = nil
+		item.ReservedQuantity = 0
+		item.DeliveredQuantity = 0
+	}
+
+	if err := CalculateSalesOrderTotals(order); err != nil {
+		return err
+	}
+
+	orderNumber, err := s.salesOrderRepo.GenerateSalesOrderNumber(order.CompanyID, order.OrderDate)
+	if err != nil {
+		return err
+	}
+	order.OrderNumber = orderNumber
+	order.Status = models.SalesOrderStatusDraft
+
+	return s.salesOrderRepo.CreateSalesOrder(order)
+}
+
+func (s *salesOrderService) GetSalesOrderByID(id uint) (*models.SalesOrder, error) {
+	return s.salesOrderRepo.FindSalesOrderByID(id)
+}
+
+func (s *salesOrderService) GetSalesOrders(companyID uint, customerID uint, status string) ([]models.SalesOrder, error) {
+	return s.salesOrderRepo.FindSalesOrders(companyID, customerID, status)
+}
+
+// ConfirmSalesOrder accepts the order and reserves the stock it needs. Lines
+// are reserved as far as stock is available; the rest is backordered.
+func (s *salesOrderService) ConfirmSalesOrder(id uint, userID uint) error {
+	order, err := s.salesOrderRepo.FindSalesOrderByID(id)
+	if err != nil {
+		return errors.New("sales order not found")
+	}
+
+	if order.Status != models.SalesOrderStatusDraft {
+		return errors.New("only draft sales orders can be confirmed")
+	}
+	if !order.Customer.IsActive {
+		return errors.New("customer is inactive")
+	}
+
+	// Reservations are held in a warehouse; without one nothing is in stock yet
+	if order.WarehouseID == 0 {
+		if warehouse, err := s.inventoryRepo.FindDefaultWarehouse(order.CompanyID); err == nil {
+			order.WarehouseID = warehouse.ID
+		}
+	}
+
+	if order.WarehouseID > 0 {
+		for i := range order.Items {
+			if err := s.reserveSalesOrderItem(order, &order.Items[i], userID); err != nil {
+				return err
+			}
+		}
+	}
+
+	now := time.Now()
+	order.Status = models.SalesOrderStatusConfirmed
+	order.ConfirmedAt = &now
+	order.ConfirmedBy = &userID
+	return s.salesOrderRepo.UpdateSalesOrder(order)
+}
+
+// reserveSalesOrderItem holds what is available of the line in the order's
+// warehouse. Reservations are in the product's base unit.
+func (s *salesOrderService) reserveSalesOrderItem(order *models.SalesOrder, item *models.SalesOrderItem, userID uint) error {
+	if item.ReservationID != nil {
+		return nil
+	}
+
+	product, err := s.inventoryRepo.FindProductByID(item.ProductID)
+	if err != nil {
+		return errors.New("product not found")
+	}
+	factor := 1.0
+	if item.Unit != product.Unit {
+		if productUnit := findProductUnit(product, item.Unit); productUnit != nil {
+			factor = productUnit.Factor
+		}
+	}
+
+	balance, err := s.inventoryService.GetStockBalance(item.ProductID, order.WarehouseID, "")
+	if err != nil {
+		return err
+	}
+	quantity := math.Floor(math.Min(item.Quantity*factor, balance.AvailableQuantity)*100) / 100
+	if quantity <= 0 {
+		return nil
+	}
+
+	reservation := &models.StockReservation{
+		CompanyID:     order.CompanyID,
+		ProductID:     item.ProductID,
+		WarehouseID:   order.WarehouseID,
+		Quantity:      quantity,
+		ReferenceType: "sales_order",
+		Reference:     order.OrderNumber,
+		Notes:         item.Description,
+		CreatedBy:     userID,
+	}
+	if err := s.inventoryService.CreateReservation(reservation); err != nil {
+		return errors.New("failed to reserve " + item.Description + ": " + err.Error())
+	}
+
+	item.ReservationID = &reservation.ID
+	item.ReservedQuantity = math.Round(quantity/factor*100) / 100
+	return s.salesOrderRepo.UpdateSalesOrderItem(item)
+}
+
+// releaseSalesOrderReservations frees the stock still held for the order
+func (s *salesOrderService) releaseSalesOrderReservations(order *models.SalesOrder) error {
+	for _, item := range order.Items {
+		if item.ReservationID == nil {
+			continue
+		}
+		reservation, err := s.inventoryService.GetReservationByID(*item.ReservationID)
+		if err != nil {
+			return err
+		}
+		if reservation.Status != models.ReservationStatusActive {
+			continue
+		}
+		if err := s.inventoryService.ReleaseReservation(reservation.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkNoDraftDeliveries keeps an order from being closed or cancelled while
+// goods are being prepared against it
+func (s *salesOrderService) checkNoDraftDeliveries(order *models.SalesOrder) error {
+	deliveries, err := s.salesOrderRepo.FindDeliveryOrders(order.CompanyID, 0, order.ID, string(models.DeliveryOrderStatusDraft))
+	if err != nil {
+		return err
+	}
+	if len(deliveries) > 0 {
+		return errors.New("sales order has draft delivery orders")
+	}
+	return nil
+}
+
+// CloseSalesOrder short-closes an order: what was not delivered is no longer
+// expected, its reserved stock is released and it drops off the backorder report
+func (s *salesOrderService) CloseSalesOrder(id uint) error {
+	order, err := s.salesOrderRepo.FindSalesOrderByID(id)
+	if err != nil {
+		return errors.New("sales order not found")
+	}
+
+	if order.Status != models.SalesOrderStatusConfirmed && order.Status != models.SalesOrderStatusPartiallyDelivered {
+		return errors.New("only confirmed sales orders can be closed")
+	}
+	if err := s.checkNoDraftDeliveries(order); err != nil {
+		return err
+	}
+
+	if err := s.releaseSalesOrderReservations(order); err != nil {
+		return err
+	}
+	order.Status = models.SalesOrderStatusClosed
+	return s.salesOrderRepo.UpdateSalesOrder(order)
+}
+
+// CancelSalesOrder drops an order nothing has been delivered on. A quotation
+// it was converted from can be ordered again.
+func (s *salesOrderService) CancelSalesOrder(id uint) error {
+	order, err := s.salesOrderRepo.FindSalesOrderByID(id)
+	if err != nil {
+		return errors.New("sales order not found")
+	}
+
+	if order.Status != models.SalesOrderStatusDraft && order.Status != models.SalesOrderStatusConfirmed {
+		return errors.New("only draft or confirmed sales orders can be cancelled")
+	}
+	if err := s.checkNoDraftDeliveries(order); err != nil {
+		return err
+	}
+
+	if err := s.releaseSalesOrderReservations(order); err != nil {
+		return err
+	}
+	order.Status = models.SalesOrderStatusCancelled
+	if err := s.salesOrderRepo.UpdateSalesOrder(order); err != nil {
+		return err
+	}
+
+	if order.QuotationID == nil {
+		return nil
+	}
+	quotation, err := s.salesOrderRepo.FindSalesQuotationByID(*order.QuotationID)
+	if err != nil {
+		return err
+	}
+	quotation.Status = models.SalesQuotationStatusAccepted
+	quotation.SalesOrderID = nil
+	return s.salesOrderRepo.UpdateSalesQuotation(quotation)
+}
+
+// Delivery Order methods
+
+// prepareDeliveryOrder checks the sales order can still be delivered and
+// fills the delivery lines from the order lines
+func (s *salesOrderService) prepareDeliveryOrder(delivery *models.DeliveryOrder) (*models.SalesOrder, error) {
+	order, err := s.salesOrderRepo.FindSalesOrderByID(delivery.SalesOrderID)
+	if err != nil || order.CompanyID != delivery.CompanyID {
+		return nil, errors.New("sales order not found")
+	}
+	if order.Status != models.SalesOrderStatusConfirmed && order.Status != models.SalesOrderStatusPartiallyDelivered {
+		return nil, errors.New("only confirmed sales orders can be delivered")
+	}
+	if delivery.DeliveryDate.Before(order.OrderDate) {
+		return nil, errors.New("delivery date cannot be before the order date")
+	}
+
+	orderItems := make(map[uint]*models.SalesOrderItem, len(order.Items))
+	for i := range order.Items {
+		orderItems[order.Items[i].ID] = &order.Items[i]
+	}
+
+	seen := make(map[uint]bool)
+	for i := range delivery.Items {
+		item := &delivery.Items[i]
+		orderItem, ok := orderItems[item.SalesOrderItemID]
+		if !ok {
+			return nil, errors.New("sales order line not found")
+		}
+		if seen[item.SalesOrderItemID] {
+			return nil, errors.New("an order line can only be delivered once per delivery order")
+		}
+		seen[item.SalesOrderItemID] = true
+
+		if item.Quantity <= 0 {
+			return nil, errors.New("quantity must be greater than zero")
+		}
+		if item.Quantity > orderItem.Quantity-orderItem.DeliveredQuantity+0.001 {
+			return nil, fmt.Errorf("only %.2f %s of %s is still to be delivered", orderItem.Quantity-orderItem.DeliveredQuantity, orderItem.Unit, orderItem.Description)
+		}
+
+		item.ProductID = orderItem.ProductID
+		item.Unit = orderItem.Unit
+		if item.Description == "" {
+			item.Description = orderItem.Description
+		}
+	}
+
+	delivery.CustomerID = order.CustomerID
+	if delivery.WarehouseID == 0 {
+		delivery.WarehouseID = order.WarehouseID
+	}
+	if delivery.ShipTo == "" {
+		delivery.ShipTo = order.Customer.Address
+	}
+	return order, nil
+}
+
+func (s *salesOrderService) CreateDeliveryOrder(delivery *models.DeliveryOrder) error {
+	if len(delivery.Items) == 0 {
+		return errors.New("delivery order must have at least one item")
+	}
+	if _, err := s.prepareDeliveryOrder(delivery); err != nil {
+		return err
+	}
+
+	deliveryNumber, err := s.salesOrderRepo.GenerateDeliveryOrderNumber(delivery.CompanyID, delivery.DeliveryDate)
+	if err != nil {
+		return err
+	}
+	delivery.DeliveryNumber = deliveryNumber
+	delivery.Status = models.DeliveryOrderStatusDraft
+	delivery.InvoiceID = nil
+
+	return s.salesOrderRepo.CreateDeliveryOrder(delivery)
+}
+
+func (s *salesOrderService) GetDeliveryOrderByID(id uint) (*models.DeliveryOrder, error) {
+	return s.salesOrderRepo.FindDeliveryOrderByID(id)
+}
+
+func (s *salesOrderService) GetDeliveryOrders(companyID uint, customerID uint, salesOrderID uint, status string) ([]models.DeliveryOrder, error) {
+	return s.salesOrderRepo.FindDeliveryOrders(companyID, customerID, salesOrderID, status)
+}
+
+// PostDeliveryOrder issues the goods out of the stock reserved for the order
+// (Dr COGS / Cr inventory through the stock-out) and updates the quantities
+// delivered on the sales order
+func (s *salesOrderService) PostDeliveryOrder(id uint, userID uint) error {
+	delivery, err := s.salesOrderRepo.FindDeliveryOrderByID(id)
+	if err != nil {
+		return errors.New("delivery order not found")
+	}
+
+	if delivery.Status != models.DeliveryOrderStatusDraft {
+		return errors.New("only draft delivery orders can be posted")
+	}
+
+	// Lines issued by an earlier attempt that failed part way keep their
+	// movement; the order was not updated for them yet
+	order, err := s.salesOrderRepo.FindSalesOrderByID(delivery.SalesOrderID)
+	if err != nil {
+		return errors.New("sales order not found")
+	}
+	orderItems := make(map[uint]*models.SalesOrderItem, len(order.Items))
+	for i := range order.Items {
+		orderItems[order.Items[i].ID] = &order.Items[i]
+	}
+	for _, item := range delivery.Items {
+		orderItem := orderItems[item.SalesOrderItemID]
+		if item.MovementID == nil && item.Quantity > orderItem.Quantity-orderItem.DeliveredQuantity+0.001 {
+			return fmt.Errorf("only %.2f %s of %s is still to be delivered", orderItem.Quantity-orderItem.DeliveredQuantity, orderItem.Unit, orderItem.Description)
+		}
+	}
+
+	for i := range delivery.Items {
+		item := &delivery.Items[i]
+		if item.MovementID != nil {
+			continue
+		}
+
+		movement := &models.StockMovement{
+			CompanyID:     delivery.CompanyID,
+			ProductID:     item.ProductID,
+			WarehouseID:   delivery.WarehouseID,
+			MovementDate:  delivery.DeliveryDate,
+			Quantity:      item.Quantity,
+			Unit:          item.Unit,
+			SerialNumbers: append([]string(nil), item.SerialNumbers...),
+			Reference:     delivery.DeliveryNumber,
+			Party:         delivery.Customer.Name,
+			Notes:         "Delivery " + delivery.DeliveryNumber + " for " + order.OrderNumber + " - " + item.Description,
+			CreatedBy:     userID,
+		}
+
+		// Goods shipped from the warehouse they were reserved in take the reserved stock
+		reservation := orderItems[item.SalesOrderItemID].Reservation
+		if reservation != nil && reservation.Status == models.ReservationStatusActive && reservation.WarehouseID == delivery.WarehouseID {
+			movement.ReservationID = &reservation.ID
+		}
+
+		if err := s.inventoryService.CreateStockOut(movement); err != nil {
+			return errors.New("failed to deliver " + item.Description + ": " + err.Error())
+		}
+
+		item.MovementID = &movement.ID
+		item.CostAmount = math.Round(movement.TotalCost*100) / 100
+		if err := s.salesOrderRepo.UpdateDeliveryOrderItem(item); err != nil {
+			return err
+		}
+	}
+
+	for _, item := range delivery.Items {
+		orderItem := orderItems[item.SalesOrderItemID]
+		orderItem.DeliveredQuantity = math.Round((orderItem.DeliveredQuantity+item.Quantity)*100) / 100
+		if err := s.salesOrderRepo.UpdateSalesOrderItem(orderItem); err != nil {
+			return err
+		}
+	}
+
+	order.Status = models.SalesOrderStatusDelivered
+	for _, orderItem := range order.Items {
+		if orderItem.DeliveredQuantity < orderItem.Quantity {
+			order.Status = models.SalesOrderStatusPartiallyDelivered
+			break
+		}
+	}
+	if order.Status == models.SalesOrderStatusDelivered {
+		// Whatever rounding left on the reservations is no longer needed
+		if err := s.releaseSalesOrderReservations(order); err != nil {
+			return err
+		}
+	}
+	if err := s.salesOrderRepo.UpdateSalesOrder(order); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	delivery.Status = models.DeliveryOrderStatusPosted
+	delivery.PostedAt = &now
+	delivery.PostedBy = &userID
+	return s.salesOrderRepo.UpdateDeliveryOrder(delivery)
+}
+
+func (s *salesOrderService) CancelDeliveryOrder(id uint) error {
+	delivery, err := s.salesOrderRepo.FindDeliveryOrderByID(id)
+	if err != nil {
+		return errors.New("delivery order not found")
+	}
+
+	if delivery.Status != models.DeliveryOrderStatusDraft {
+		return errors.New("only draft delivery orders can be cancelled")
+	}
+	for _, item := range delivery.Items {
+		if item.MovementID != nil {
+			return errors.New("goods have already been issued for this delivery order")
+		}
+	}
+
+	delivery.Status = models.DeliveryOrderStatusCancelled
+	return s.salesOrderRepo.UpdateDeliveryOrder(delivery)
+}
+
+// Invoicing methods
+
+// CreateSalesInvoiceFromDeliveries raises one draft invoice for posted
+// deliveries of a customer at their sales order prices. The goods were
+// issued by the deliveries, so posting the invoice only records the revenue.
+func (s *salesOrderService) CreateSalesInvoiceFromDeliveries(companyID uint, deliveryIDs []uint, invoiceDate time.Time, userID uint) (*models.SalesInvoice, error) {
+	if len(deliveryIDs) == 0 {
+		return nil, errors.New("select at least one delivery order to invoice")
+	}
+
+	invoice := &models.SalesInvoice{
+		CompanyID:   companyID,
+		InvoiceDate: invoiceDate,
+		CreatedBy:   userID,
+	}
+
+	seen := make(map[uint]bool)
+	var orderIDs []uint
+	var references, deliveryNumbers []string
+	for i, deliveryID := range deliveryIDs {
+		if seen[deliveryID] {
+			return nil, errors.New("a delivery order can only be invoiced once per invoice")
+		}
+		seen[deliveryID] = true
+
+		delivery, err := s.salesOrderRepo.FindDeliveryOrderByID(deliveryID)
+		if err != nil || delivery.CompanyID != companyID {
+			return nil, errors.New("delivery order not found")
+		}
+		if delivery.Status != models.DeliveryOrderStatusPosted {
+			return nil, errors.New("delivery order " + delivery.DeliveryNumber + " is not posted or already invoiced")
+		}
+		if invoiceDate.Before(delivery.DeliveryDate) {
+			return nil, errors.New("invoice date cannot be before delivery " + delivery.DeliveryNumber)
+		}
+
+		if i == 0 {
+			invoice.CustomerID = delivery.CustomerID
+			invoice.WarehouseID = delivery.WarehouseID
+			invoice.TaxRate = delivery.SalesOrder.TaxRate
+		} else if delivery.CustomerID != invoice.CustomerID {
+			return nil, errors.New("delivery orders to invoice together must be for the same customer")
+		} else if delivery.SalesOrder.TaxRate != invoice.TaxRate {
+			return nil, errors.New("delivery orders to invoice together must have the same tax rate")
+		}
+
+		if !containsUint(orderIDs, delivery.SalesOrderID) {
+			orderIDs = append(orderIDs, delivery.SalesOrderID)
+			reference := delivery.SalesOrder.Reference
+			if reference == "" {
+				reference = delivery.SalesOrder.OrderNumber
+			}
+			references = append(references, reference)
+		}
+		deliveryNumbers = append(deliveryNumbers, delivery.DeliveryNumber)
+
+		for _, item := range delivery.Items {
+			deliveryItemID := item.ID
+			invoice.Items = append(invoice.Items, models.SalesInvoiceItem{
+				ProductID:       item.ProductID,
+				Description:     item.Description,
+				Quantity:        item.Quantity,
+				Unit:            item.Unit,
+				UnitPrice:       item.SalesOrderItem.UnitPrice,
+				DiscountPercent: item.SalesOrderItem.DiscountPercent,
+				DeliveryItemID:  &deliveryItemID,
+			})
+		}
+	}
+
+	drafts, err := s.salesOrderRepo.CountDraftInvoiceLinesByDeliveries(deliveryIDs)
+	if err != nil {
+		return nil, err
+	}
+	if drafts > 0 {
+		return nil, errors.New("delivery orders are already on a draft invoice")
+	}
+
+	invoice.Reference = strings.Join(references, ", ")
+	invoice.Notes = "Delivery " + strings.Join(deliveryNumbers, ", ")
+
+	if err := s.salesService.CreateSalesInvoice(invoice); err != nil {
+		return nil, err
+	}
+	return invoice, nil
+}
+
+func containsUint(values []uint, value uint) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Report methods
+
+// GetBackorderReport lists what confirmed sales orders still have to deliver,
+// split into what is held in stock for them and what is short
+func (s *salesOrderService) GetBackorderReport(companyID uint, customerID uint) (*models.BackorderReport, error) {
+	lines, err := s.salesOrderRepo.FindBackorderLines(companyID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	report := &models.BackorderReport{
+		AsOfDate: today.Format("2006-01-02"),
+		Lines:    []models.BackorderLine{},
+	}
+	for _, line := range lines {
+		// Deliveries draw on the reservation first
+		reserved := math.Max(0, line.ReservedQuantity-line.DeliveredQuantity)
+		line.ReservedQuantity = math.Round(math.Min(reserved, line.BackorderedQuantity)*100) / 100
+		line.ShortQuantity = math.Round((line.BackorderedQuantity-line.ReservedQuantity)*100) / 100
+		line.BackorderAmount = math.Round(line.BackorderedQuantity*line.UnitPrice*100) / 100
+		line.UnitPrice = math.Round(line.UnitPrice*100) / 100
+		line.IsOverdue = line.ExpectedDate != nil && line.ExpectedDate.Before(today)
+		report.Lines = append(report.Lines, line)
+		report.TotalAmount += line.BackorderAmount
+	}
+	report.TotalAmount = math.Round(report.TotalAmount*100) / 100
+	return report, nil
+}
\ No newline at end of file
@@ -0,0 +1,1187 @@
+package services
+
+import (
+	"errors"
+	"finara-backend/internal/models"
+	"finara-backend/internal/repository"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SalesService interface {
+	// Customers
+	CreateCustomer(customer *models.Customer) error
+	GetCustomerByID(id uint) (*models.Customer, error)
+	GetCustomersByCompany(companyID uint, activeOnly bool) ([]models.Customer, error)
+	UpdateCustomer(id uint, customer *models.Customer) error
+	DeleteCustomer(id uint) error
+
+	// Sales Invoices
+	CreateSalesInvoice(invoice *models.SalesInvoice) error
+	GetSalesInvoiceByID(id uint) (*models.SalesInvoice, error)
+	GetSalesInvoices(companyID uint, customerID uint, status string) ([]models.SalesInvoice, error)
+	PostSalesInvoice(id uint, userID uint) error
+	CancelSalesInvoice(id uint) error
+
+	// Customer Payments
+	CreateCustomerPayment(payment *models.CustomerPayment) error
+	GetCustomerPaymentByID(id uint) (*models.CustomerPayment, error)
+	GetCustomerPayments(companyID uint, customerID uint) ([]models.CustomerPayment, error)
+
+	// Sales Credit Notes
+	CreateSalesCreditNote(creditNote *models.SalesCreditNote) error
+	GetSalesCreditNoteByID(id uint) (*models.SalesCreditNote, error)
+	GetSalesCreditNotes(companyID uint, customerID uint, invoiceID uint, status string) ([]models.SalesCreditNote, error)
+	PostSalesCreditNote(id uint, userID uint) error
+	CancelSalesCreditNote(id uint) error
+	ApplySalesCreditNote(application *models.SalesCreditNoteApplication) error
+	RefundSalesCreditNote(application *models.SalesCreditNoteApplication) error
+
+	// Receivable Aging
+	GetReceivableAging(companyID uint, customerID uint, asOf time.Time, limits []int) (*models.AgingReport, error)
+
+	// Customer Statements
+	GetCustomerStatement(customerID uint, startDate, endDate time.Time) (*models.CustomerStatement, error)
+}
+
+// DefaultPPNRate is the PPN percentage applied when an invoice does not set one
+const DefaultPPNRate = 11
+
+// Default chart of accounts codes used by sales
+const (
+	defaultReceivableAccountCode   = "1-1300" // Piutang Usaha
+	defaultSalesRevenueAccountCode = "4-1000" // Pendapatan Usaha
+	defaultOutputTaxAccountCode    = "2-1200" // Utang Pajak (PPN Keluaran)
+	defaultCustomerCreditCode      = "2-1910" // Saldo Kredit Pelanggan
+)
+
+type salesService struct {
+	db               *gorm.DB
+	salesRepo        repository.SalesRepository
+	salesOrderRepo   repository.SalesOrderRepository
+	inventoryRepo    repository.InventoryRepository
+	accountRepo      repository.AccountRepository
+	ledgerRepo       repository.LedgerRepository
+	inventoryService InventoryService
+	journalService   JournalService
+	cashBankService  CashBankService
+}
+
+func NewSalesService(
+	db *gorm.DB,
+	salesRepo repository.SalesRepository,
+	salesOrderRepo repository.SalesOrderRepository,
+	inventoryRepo repository.InventoryRepository,
+	accountRepo repository.AccountRepository,
+	ledgerRepo repository.LedgerRepository,
+	inventoryService InventoryService,
+	journalService JournalService,
+	cashBankService CashBankService,
+) SalesService {
+	return &salesService{
+		db:               db,
+		salesRepo:        salesRepo,
+		salesOrderRepo:   salesOrderRepo,
+		inventoryRepo:    inventoryRepo,
+		accountRepo:      accountRepo,
+		ledgerRepo:       ledgerRepo,
+		inventoryService: inventoryService,
+		journalService:   journalService,
+		cashBankService:  cashBankService,
+	}
+}
+
+// withTx returns the service reading and writing through tx, so a document
+// and the postings it makes commit or roll back together
+func (s *salesService) withTx(tx *gorm.DB) *salesService {
+	return &salesService{
+		db:               tx,
+		salesRepo:        repository.NewSalesRepository(tx),
+		salesOrderRepo:   repository.NewSalesOrderRepository(tx),
+		inventoryRepo:    repository.NewInventoryRepository(tx),
+		accountRepo:      repository.NewAccountRepository(tx),
+		ledgerRepo:       repository.NewLedgerRepository(tx),
+		inventoryService: inventoryServiceWith(s.inventoryService, tx),
+		journalService:   journalServiceWith(tx),
+		cashBankService:  cashBankServiceWith(tx),
+	}
+}
+
+// Customer methods
+func (s *salesService) CreateCustomer(customer *models.Customer) error {
+	if customer.PaymentTermDays < 0 {
+		return errors.New("payment term days cannot be negative")
+	}
+	if customer.CreditLimit < 0 {
+		return errors.New("credit limit cannot be negative")
+	}
+	return s.salesRepo.CreateCustomer(customer)
+}
+
+func (s *salesService) GetCustomerByID(id uint) (*models.Customer, error) {
+	return s.salesRepo.FindCustomerByID(id)
+}
+
+func (s *salesService) GetCustomersByCompany(companyID uint, activeOnly bool) ([]models.Customer, error) {
+	return s.salesRepo.FindCustomersByCompany(companyID, activeOnly)
+}
+
+func (s *salesService) UpdateCustomer(id uint, updated *models.Customer) error {
+	customer, err := s.salesRepo.FindCustomerByID(id)
+	if err != nil {
+		return errors.New("customer not found")
+	}
+
+	if updated.PaymentTermDays < 0 {
+		return errors.New("payment term days cannot be negative")
+	}
+	if updated.CreditLimit < 0 {
+		return errors.New("credit limit cannot be negative")
+	}
+
+	customer.Code = updated.Code
+	customer.Name = updated.Name
+	customer.NPWP = updated.NPWP
+	customer.Address = updated.Address
+	customer.Phone = updated.Phone
+	customer.Email = updated.Email
+	customer.ContactPerson = updated.ContactPerson
+	customer.PaymentTermDays = updated.PaymentTermDays
+	customer.CreditLimit = updated.CreditLimit
+	customer.IsActive = updated.IsActive
+	customer.Notes = updated.Notes
+	customer.AccountOwnerID = updated.AccountOwnerID
+
+	return s.salesRepo.UpdateCustomer(customer)
+}
+
+func (s *salesService) DeleteCustomer(id uint) error {
+	count, err := s.salesRepo.CountCustomerInvoices(id)
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("customer has invoices, deactivate it instead")
+	}
+	return s.salesRepo.DeleteCustomer(id)
+}
+
+// CalculateSalesInvoiceTotals fills the line discounts and subtotals and the
+// invoice subtotal, PPN and total. The header discount is taken off the
+// subtotal before PPN.
+func CalculateSalesInvoiceTotals(invoice *models.SalesInvoice) error {
+	if invoice.TaxRate < 0 || invoice.TaxRate > 100 {
+		return errors.New("tax rate must be between 0 and 100")
+	}
+	if invoice.DiscountAmount < 0 {
+		return errors.New("discount amount cannot be negative")
+	}
+
+	invoice.Subtotal = 0
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+		if item.Quantity <= 0 {
+			return errors.New("quantity must be greater than zero")
+		}
+		if item.UnitPrice < 0 {
+			return errors.New("unit price cannot be negative")
+		}
+		if item.DiscountPercent < 0 || item.DiscountPercent > 100 {
+			return errors.New("discount percent must be between 0 and 100")
+		}
+
+		gross := item.Quantity * item.UnitPrice
+		item.DiscountAmount = math.Round(gross*item.DiscountPercent) / 100
+		item.Subtotal = math.Round((gross-item.DiscountAmount)*100) / 100
+		invoice.Subtotal += item.Subtotal
+	}
+	invoice.Subtotal = math.Round(invoice.Subtotal*100) / 100
+
+	if invoice.DiscountAmount > invoice.Subtotal {
+		return errors.New("discount amount exceeds the invoice subtotal")
+	}
+
+	taxBase := invoice.Subtotal - invoice.DiscountAmount
+	invoice.TaxAmount = math.Round(taxBase*invoice.TaxRate) / 100
+	invoice.TotalAmount = math.Round((taxBase+invoice.TaxAmount)*100) / 100
+	return nil
+}
+
+// Sales Invoice methods
+func (s *salesService) CreateSalesInvoice(invoice *models.SalesInvoice) error {
+	if len(invoice.Items) == 0 {
+		return errors.New("invoice must have at least one item")
+	}
+
+	customer, err := s.salesRepo.FindCustomerByID(invoice.CustomerID)
+	if err != nil || customer.CompanyID != invoice.CompanyID {
+		return errors.New("customer not found")
+	}
+	if !customer.IsActive {
+		return errors.New("customer is inactive")
+	}
+
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+		product, err := s.inventoryRepo.FindProductByID(item.ProductID)
+		if err != nil || product.CompanyID != invoice.CompanyID {
+			return errors.New("product not found")
+		}
+		if item.Description == "" {
+			item.Description = product.Name
+		}
+		if item.Unit == "" {
+			item.Unit = product.Unit
+		} else if item.Unit != product.Unit && findProductUnit(product, item.Unit) == nil {
+			return errors.New("unit " + item.Unit + " is not defined for " + product.Code)
+		}
+		if item.RevenueAccountID != nil {
+			account, err := s.accountRepo.FindByID(*item.RevenueAccountID)
+			if err != nil || account.CompanyID != invoice.CompanyID || account.Type != models.AccountTypeRevenue {
+				return errors.New("revenue account not found")
+			}
+		}
+	}
+
+	if err := CalculateSalesInvoiceTotals(invoice); err != nil {
+		return err
+	}
+
+	if invoice.DueDate.IsZero() {
+		invoice.DueDate = invoice.InvoiceDate.AddDate(0, 0, customer.PaymentTermDays)
+	}
+	if invoice.DueDate.Before(invoice.InvoiceDate) {
+		return errors.New("due date cannot be before the invoice date")
+	}
+
+	invoiceNumber, err := s.salesRepo.GenerateSalesInvoiceNumber(invoice.CompanyID, invoice.InvoiceDate)
+	if err != nil {
+		return err
+	}
+	invoice.InvoiceNumber = invoiceNumber
+	invoice.Status = models.SalesInvoiceStatusDraft
+	invoice.PaidAmount = 0
+
+	return s.salesRepo.CreateSalesInvoice(invoice)
+}
+
+func (s *salesService) GetSalesInvoiceByID(id uint) (*models.SalesInvoice, error) {
+	return s.salesRepo.FindSalesInvoiceByID(id)
+}
+
+func (s *salesService) GetSalesInvoices(companyID uint, customerID uint, status string) ([]models.SalesInvoice, error) {
+	return s.salesRepo.FindSalesInvoices(companyID, customerID, status)
+}
+
+// PostSalesInvoice issues the goods (Dr COGS / Cr inventory through the
+// stock-out) and records the receivable: Dr AR / Cr revenue, Cr PPN. Lines
+// raised from a delivery order take its stock-out instead of issuing again.
+func (s *salesService) PostSalesInvoice(id uint, userID uint) error {
+	invoice, err := s.salesRepo.FindSalesInvoiceByID(id)
+	if err != nil {
+		return errors.New("sales invoice not found")
+	}
+
+	if invoice.Status != models.SalesInvoiceStatusDraft {
+		return errors.New("only draft invoices can be posted")
+	}
+
+	if invoice.Customer.CreditLimit > 0 {
+		outstanding, err := s.salesRepo.SumCustomerOutstanding(invoice.CustomerID)
+		if err != nil {
+			return err
+		}
+		if outstanding+invoice.TotalAmount > invoice.Customer.CreditLimit {
+			return fmt.Errorf("credit limit exceeded: outstanding %.2f plus invoice %.2f is over the limit of %.2f",
+				outstanding, invoice.TotalAmount, invoice.Customer.CreditLimit)
+		}
+	}
+
+	receivable, err := s.accountRepo.FindByCode(invoice.CompanyID, defaultReceivableAccountCode)
+	if err != nil {
+		return errors.New("receivable account " + defaultReceivableAccountCode + " not found")
+	}
+
+	var deliveryItemIDs []uint
+	for _, item := range invoice.Items {
+		if item.DeliveryItemID != nil {
+			deliveryItemIDs = append(deliveryItemIDs, *item.DeliveryItemID)
+		}
+	}
+	deliveryItems, err := s.salesOrderRepo.FindDeliveryOrderItemsByIDs(deliveryItemIDs)
+	if err != nil {
+		return err
+	}
+	deliveryItemByID := make(map[uint]*models.DeliveryOrderItem, len(deliveryItems))
+	deliveries := make(map[uint]*models.DeliveryOrder)
+	for i := range deliveryItems {
+		deliveryItem := &deliveryItems[i]
+		if deliveryItem.Delivery.Status != models.DeliveryOrderStatusPosted {
+			return errors.New("delivery order " + deliveryItem.Delivery.DeliveryNumber + " is not posted or already invoiced")
+		}
+		deliveryItemByID[deliveryItem.ID] = deliveryItem
+		deliveries[deliveryItem.DeliveryID] = deliveryItem.Delivery
+	}
+
+	// Lines issued by an earlier attempt that failed part way keep their movement
+	for i := range invoice.Items {
+		item := &invoice.Items[i]
+		if item.MovementID != nil {
+			continue
+		}
+
+		if item.DeliveryItemID != nil {
+			deliveryItem, ok := deliveryItemByID[*item.DeliveryItemID]
+			if !ok {
+				return errors.New("delivery order line of " + item.Description + " not found")
+			}
+			item.MovementID = deliveryItem.MovementID
+			item.CostAmount = deliveryItem.CostAmount
+			if err := s.salesRepo.UpdateSalesInvoiceItem(item); err != nil {
+				return err
+			}
+			continue
+		}
+
+		movement := &models.StockMovement{
+			CompanyID:     invoice.CompanyID,
+			ProductID:     item.ProductID,
+			WarehouseID:   invoice.WarehouseID,
+			MovementDate:  invoice.InvoiceDate,
+			Quantity:      item.Quantity,
+			Unit:          item.Unit,
+			SerialNumbers: append([]string(nil), item.SerialNumbers...),
+			Reference:     invoice.InvoiceNumber,
+			Party:         invoice.Customer.Name,
+			Notes:         "Sales " + invoice.InvoiceNumber + " - " + item.Description,
+			CreatedBy:     userID,
+		}
+		if err := s.inventoryService.CreateStockOut(movement); err != nil {
+			return errors.New("failed to issue " + item.Product.Code + ": " + err.Error())
+		}
+
+		item.MovementID = &movement.ID
+		item.CostAmount = math.Round(movement.TotalCost*100) / 100
+		if err := s.salesRepo.UpdateSalesInvoiceItem(item); err != nil {
+			return err
+		}
+	}
+
+	entries, err := s.revenueEntries(invoice, receivable.ID)
+	if err != nil {
+		return err
+	}
+
+	journal := &models.Journal{
+		CompanyID:       invoice.CompanyID,
+		TransactionDate: invoice.InvoiceDate,
+		Description:     "Sales invoice " + invoice.InvoiceNumber + " - " + invoice.Customer.Name,
+		CreatedBy:       userID,
+		Entries:         entries,
+	}
+
+	if err := s.journalService.CreateJournal(journal); err != nil {
+		return err
+	}
+	if err := s.journalService.PostJournal(journal.ID, userID); err != nil {
+		return err
+	}
+
+	for _, delivery := range deliveries {
+		delivery.Status = models.DeliveryOrderStatusInvoiced
+		delivery.InvoiceID = &invoice.ID
+		if err := s.salesOrderRepo.UpdateDeliveryOrder(delivery); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	invoice.JournalID = &journal.ID
+	invoice.Status = models.SalesInvoiceStatusPosted
+	invoice.PostedAt = &now
+	invoice.PostedBy = &userID
+	return s.salesRepo.UpdateSalesInvoice(invoice)
+}
+
+// revenueEntries builds the invoice journal lines. Revenue is credited per
+// revenue account net of its share of the header discount; the rounding of
+// that share goes to the last account.
+func (s *salesService) revenueEntries(invoice *models.SalesInvoice, receivableAccountID uint) ([]models.JournalEntry, error) {
+	description := "Sales invoice " + invoice.InvoiceNumber + " - " + invoice.Customer.Name
+	var entries []models.JournalEntry
+	addEntry := func(accountID uint, debit, credit float64) {
+		entries = append(entries, models.JournalEntry{
+			AccountID:   accountID,
+			Description: description,
+			Debit:       debit,
+			Credit:      credit,
+			Position:    len(entries) + 1,
+		})
+	}
+
+	addEntry(receivableAccountID, invoice.TotalAmount, 0)
+
+	var accountIDs []uint
+	revenue := make(map[uint]float64)
+	for _, item := range invoice.Items {
+		var accountID uint
+		if item.RevenueAccountID != nil {
+			accountID = *item.RevenueAccountID
+		} else {
+			account, err := s.accountRepo.FindByCode(invoice.CompanyID, defaultSalesRevenueAccountCode)
+			if err != nil {
+				return nil, errors.New("revenue account " + defaultSalesRevenueAccountCode + " not found")
+			}
+			accountID = account.ID
+		}
+		if _, ok := revenue[accountID]; !ok {
+			accountIDs = append(accountIDs, accountID)
+		}
+		revenue[accountID] += item.Subtotal
+	}
+
+	remainingDiscount := invoice.DiscountAmount
+	for i, accountID := range accountIDs {
+		amount := revenue[accountID]
+		if invoice.DiscountAmount > 0 {
+			share := remainingDiscount
+			if i < len(accountIDs)-1 {
+				share = math.Round(invoice.DiscountAmount*amount/invoice.Subtotal*100) / 100
+			}
+			remainingDiscount -= share
+			amount -= share
+		}
+		if amount = math.Round(amount*100) / 100; amount > 0 {
+			addEntry(accountID, 0, amount)
+		}
+	}
+
+	if invoice.TaxAmount > 0 {
+		outputTax, err := s.accountRepo.FindByCode(invoice.CompanyID, defaultOutputTaxAccountCode)
+		if err != nil {
+			return nil, errors.New("output tax account " + defaultOutputTaxAccountCode + " not found")
+		}
+		addEntry(outputTax.ID, 0, invoice.TaxAmount)
+	}
+
+	return entries, nil
+}
+
+func (s *salesService) CancelSalesInvoice(id uint) error {
+	invoice, err := s.salesRepo.FindSalesInvoiceByID(id)
+	if err != nil {
+		return errors.New("sales invoice not found")
+	}
+
+	if invoice.Status != models.SalesInvoiceStatusDraft {
+		return errors.New("only draft invoices can be cancelled")
+	}
+	for _, item := range invoice.Items {
+		if item.MovementID != nil && item.DeliveryItemID == nil {
+			return errors.New("goods have already been issued for this invoice")
+		}
+	}
+
+	invoice.Status = models.SalesInvoiceStatusCancelled
+	return s.salesRepo.UpdateSalesInvoice(invoice)
+}
+
+// Customer Payment methods
+
+// CreateCustomerPayment receives the payment into cash/bank (Dr cash/bank /
+// Cr AR) and settles the invoices it is allocated to. Without allocations
+// the amount is applied to the customer's invoices oldest due first.
+func (s *salesService) CreateCustomerPayment(payment *models.CustomerPayment) error {
+	if payment.Amount <= 0 {
+		return errors.New("amount must be greater than zero")
+	}
+	payment.Amount = math.Round(payment.Amount*100) / 100
+
+	customer, err := s.salesRepo.FindCustomerByID(payment.CustomerID)
+	if err != nil || customer.CompanyID != payment.CompanyID {
+		return errors.New("customer not found")
+	}
+
+	if _, err := findCashBankAccount(s.accountRepo, payment.CompanyID, payment.AccountID); err != nil {
+		return err
+	}
+
+	receivable, err := s.accountRepo.FindByCode(payment.CompanyID, defaultReceivableAccountCode)
+	if err != nil {
+		return errors.New("receivable account " + defaultReceivableAccountCode + " not found")
+	}
+
+	openInvoices, err := s.salesRepo.FindOpenSalesInvoices(payment.CustomerID)
+	if err != nil {
+		return err
+	}
+	invoices := make(map[uint]*models.SalesInvoice)
+	for i := range openInvoices {
+		invoices[openInvoices[i].ID] = &openInvoices[i]
+	}
+
+	if len(payment.Allocations) == 0 {
+		remaining := payment.Amount
+		for i := range openInvoices {
+			if remaining <= 0 {
+				break
+			}
+			amount := math.Min(remaining, math.Round(openInvoices[i].Outstanding()*100)/100)
+			payment.Allocations = append(payment.Allocations, models.CustomerPaymentAllocation{
+				InvoiceID: openInvoices[i].ID,
+				Amount:    amount,
+			})
+			remaining = math.Round((remaining-amount)*100) / 100
+		}
+		if remaining > 0 {
+			return fmt.Errorf("payment exceeds the customer's outstanding invoices by %.2f", remaining)
+		}
+	}
+
+	var allocated float64
+	for i := range payment.Allocations {
+		allocation := &payment.Allocations[i]
+		invoice, ok := invoices[allocation.InvoiceID]
+		if !ok {
+			return errors.New("invoice is not open for this customer")
+		}
+		allocation.Amount = math.Round(allocation.Amount*100) / 100
+		if allocation.Amount <= 0 {
+			return errors.New("allocated amount must be greater than zero")
+		}
+		if allocation.Amount > math.Round(invoice.Outstanding()*100)/100 {
+			return errors.New("allocation exceeds the outstanding amount of " + invoice.InvoiceNumber)
+		}
+
+		// The same invoice listed twice draws on what is left of it
+		invoice.PaidAmount = math.Round((invoice.PaidAmount+allocation.Amount)*100) / 100
+		allocated += allocation.Amount
+	}
+	if math.Round(allocated*100)/100 != payment.Amount {
+		return errors.New("allocations must add up to the payment amount")
+	}
+
+	// The receipt, its posted journal, the payment and the invoices it
+	// settles are saved together so a failure leaves nothing to retry around
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		paymentNumber, err := txService.salesRepo.GenerateCustomerPaymentNumber(payment.CompanyID, payment.PaymentDate)
+		if err != nil {
+			return err
+		}
+		payment.PaymentNumber = paymentNumber
+
+		transaction := &models.CashBankTransaction{
+			CompanyID:       payment.CompanyID,
+			AccountID:       payment.AccountID,
+			TransactionDate: payment.PaymentDate,
+			Category:        models.CategoryReceivablePayment,
+			Amount:          payment.Amount,
+			Description:     "Payment " + paymentNumber + " from " + customer.Name,
+			Reference:       payment.Reference,
+			CreatedBy:       payment.CreatedBy,
+		}
+		if err := txService.cashBankService.CreateCashInWithJournal(transaction, receivable.ID); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(*transaction.JournalID, payment.CreatedBy); err != nil {
+			return err
+		}
+
+		payment.CashBankTransactionID = &transaction.ID
+		payment.JournalID = transaction.JournalID
+		if err := txService.salesRepo.CreateCustomerPayment(payment); err != nil {
+			return err
+		}
+
+		updated := make(map[uint]bool)
+		for _, allocation := range payment.Allocations {
+			if updated[allocation.InvoiceID] {
+				continue
+			}
+			updated[allocation.InvoiceID] = true
+
+			invoice := invoices[allocation.InvoiceID]
+			if math.Round(invoice.Outstanding()*100) <= 0 {
+				invoice.Status = models.SalesInvoiceStatusPaid
+			} else {
+				invoice.Status = models.SalesInvoiceStatusPartiallyPaid
+			}
+			if err := txService.salesRepo.UpdateSalesInvoice(invoice); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s *salesService) GetCustomerPaymentByID(id uint) (*models.CustomerPayment, error) {
+	return s.salesRepo.FindCustomerPaymentByID(id)
+}
+
+func (s *salesService) GetCustomerPayments(companyID uint, customerID uint) ([]models.CustomerPayment, error) {
+	return s.salesRepo.FindCustomerPayments(companyID, customerID)
+}
+
+// Sales Credit Note methods
+
+// CalculateSalesCreditNoteTotals fills the credit note lines from the invoice
+// lines they reference and totals them. A line returning goods without an
+// amount is credited at the net price of the invoice line, after its share
+// of the header discount. credited holds what earlier credit notes took off
+// each invoice line; a line cannot be credited more than was invoiced.
+func CalculateSalesCreditNoteTotals(creditNote *models.SalesCreditNote, invoice *models.SalesInvoice, credited map[uint]models.SalesCreditNoteItem) error {
+	if len(creditNote.Items) == 0 {
+		return errors.New("credit note must have at least one item")
+	}
+
+	invoiceItems := make(map[uint]*models.SalesInvoiceItem, len(invoice.Items))
+	for i := range invoice.Items {
+		invoiceItems[invoice.Items[i].ID] = &invoice.Items[i]
+	}
+
+	creditNote.Subtotal = 0
+	seen := make(map[uint]bool)
+	for i := range creditNote.Items {
+		item := &creditNote.Items[i]
+		invoiceItem, ok := invoiceItems[item.InvoiceItemID]
+		if !ok {
+			return errors.New("invoice line not found")
+		}
+		if seen[item.InvoiceItemID] {
+			return errors.New("an invoice line can only be credited once per credit note")
+		}
+		seen[item.InvoiceItemID] = true
+
+		item.ProductID = invoiceItem.ProductID
+		item.Unit = invoiceItem.Unit
+		if item.Description == "" {
+			item.Description = invoiceItem.Description
+		}
+
+		// Net value of the invoice line after its share of the header discount
+		net := invoiceItem.Subtotal
+		if invoice.DiscountAmount > 0 && invoice.Subtotal > 0 {
+			net -= math.Round(invoice.DiscountAmount*invoiceItem.Subtotal/invoice.Subtotal*100) / 100
+		}
+
+		previous := credited[item.InvoiceItemID]
+		if item.Quantity < 0 {
+			return errors.New("quantity cannot be negative")
+		}
+		if item.Quantity > invoiceItem.Quantity-previous.Quantity+0.001 {
+			return fmt.Errorf("only %.2f %s of %s can still be returned", invoiceItem.Quantity-previous.Quantity, invoiceItem.Unit, item.Description)
+		}
+		if item.Amount == 0 && item.Quantity > 0 {
+			item.Amount = math.Round(net*item.Quantity/invoiceItem.Quantity*100) / 100
+		}
+		if item.Amount <= 0 {
+			return errors.New("credit amount must be greater than zero")
+		}
+		if math.Round((previous.Amount+item.Amount)*100) > math.Round(net*100) {
+			return fmt.Errorf("only %.2f of %s can still be credited", net-previous.Amount, item.Description)
+		}
+
+		creditNote.Subtotal += item.Amount
+	}
+
+	creditNote.Subtotal = math.Round(creditNote.Subtotal*100) / 100
+	creditNote.TaxRate = invoice.TaxRate
+	creditNote.TaxAmount = math.Round(creditNote.Subtotal*creditNote.TaxRate) / 100
+	creditNote.TotalAmount = math.Round((creditNote.Subtotal+creditNote.TaxAmount)*100) / 100
+	return nil
+}
+
+// UnappliedNoteAmount is the part of a credit or debit note beyond the open
+// balance of the document it corrects, e.g. after that was paid, which is
+// held as customer or vendor credit instead
+func UnappliedNoteAmount(amount float64, outstanding float64) float64 {
+	return math.Round(math.Max(amount-math.Max(outstanding, 0), 0)*100) / 100
+}
+
+// prepareSalesCreditNote checks the invoice can still be credited and
+// (re)calculates the credit note against it
+func (s *salesService) prepareSalesCreditNote(creditNote *models.SalesCreditNote) (*models.SalesInvoice, error) {
+	invoice, err := s.salesRepo.FindSalesInvoiceByID(creditNote.InvoiceID)
+	if err != nil || invoice.CompanyID != creditNote.CompanyID {
+		return nil, errors.New("sales invoice not found")
+	}
+	if invoice.Status == models.SalesInvoiceStatusDraft || invoice.Status == models.SalesInvoiceStatusCancelled {
+		return nil, errors.New("only posted invoices can be credited")
+	}
+	if creditNote.CreditNoteDate.Before(invoice.InvoiceDate) {
+		return nil, errors.New("credit note date cannot be before the invoice date")
+	}
+
+	credited, err := s.salesRepo.SumCreditedByInvoiceItem(invoice.ID)
+	if err != nil {
+		return nil, err
+	}
+	if err := CalculateSalesCreditNoteTotals(creditNote, invoice, credited); err != nil {
+		return nil, err
+	}
+
+	// Whatever the invoice no longer owes is held as customer credit to
+	// refund or apply later
+	creditNote.UnappliedAmount = UnappliedNoteAmount(creditNote.TotalAmount, invoice.Outstanding())
+
+	creditNote.CustomerID = invoice.CustomerID
+	return invoice, nil
+}
+
+func (s *salesService) CreateSalesCreditNote(creditNote *models.SalesCreditNote) error {
+	if _, err := s.prepareSalesCreditNote(creditNote); err != nil {
+		return err
+	}
+
+	creditNoteNumber, err := s.salesRepo.GenerateSalesCreditNoteNumber(creditNote.CompanyID, creditNote.CreditNoteDate)
+	if err != nil {
+		return err
+	}
+	creditNote.CreditNoteNumber = creditNoteNumber
+	creditNote.Status = models.CreditNoteStatusDraft
+
+	return s.salesRepo.CreateSalesCreditNote(creditNote)
+}
+
+func (s *salesService) GetSalesCreditNoteByID(id uint) (*models.SalesCreditNote, error) {
+	return s.salesRepo.FindSalesCreditNoteByID(id)
+}
+
+func (s *salesService) GetSalesCreditNotes(companyID uint, customerID uint, invoiceID uint, status string) ([]models.SalesCreditNote, error) {
+	return s.salesRepo.FindSalesCreditNotes(companyID, customerID, invoiceID, status)
+}
+
+// PostSalesCreditNote takes returned goods back into stock at the cost they
+// were issued at (Dr inventory / Cr COGS through the stock return), reverses
+// the sale: Dr revenue, Dr PPN / Cr AR, and reduces the invoice's open balance.
+// The part beyond the open balance is credited to customer credit instead.
+func (s *salesService) PostSalesCreditNote(id uint, userID uint) error {
+	creditNote, err := s.salesRepo.FindSalesCreditNoteByID(id)
+	if err != nil {
+		return errors.New("credit note not found")
+	}
+
+	if creditNote.Status != models.CreditNoteStatusDraft {
+		return errors.New("only draft credit notes can be posted")
+	}
+
+	invoice, err := s.prepareSalesCreditNote(creditNote)
+	if err != nil {
+		return err
+	}
+
+	receivable, err := s.accountRepo.FindByCode(creditNote.CompanyID, defaultReceivableAccountCode)
+	if err != nil {
+		return errors.New("receivable account " + defaultReceivableAccountCode + " not found")
+	}
+
+	invoiceItems := make(map[uint]*models.SalesInvoiceItem, len(invoice.Items))
+	for i := range invoice.Items {
+		invoiceItems[invoice.Items[i].ID] = &invoice.Items[i]
+	}
+
+	// Lines returned by an earlier attempt that failed part way keep their movement
+	for i := range creditNote.Items {
+		item := &creditNote.Items[i]
+		if item.Quantity == 0 || item.MovementID != nil {
+			continue
+		}
+
+		invoiceItem := invoiceItems[item.InvoiceItemID]
+		if invoiceItem.MovementID == nil {
+			return errors.New("goods of " + item.Description + " were never issued")
+		}
+
+		movement := &models.StockMovement{
+			CompanyID:     creditNote.CompanyID,
+			WarehouseID:   creditNote.WarehouseID,
+			MovementDate:  creditNote.CreditNoteDate,
+			Quantity:      item.Quantity,
+			SerialNumbers: append([]string(nil), item.SerialNumbers...),
+			Reference:     creditNote.CreditNoteNumber,
+			Party:         invoice.Customer.Name,
+			Notes:         "Sales return " + creditNote.CreditNoteNumber + " of " + invoice.InvoiceNumber + " - " + item.Description,
+			CreatedBy:     userID,
+		}
+		if err := s.inventoryService.CreateStockReturn(movement, *invoiceItem.MovementID); err != nil {
+			return errors.New("failed to return " + item.Description + ": " + err.Error())
+		}
+
+		item.MovementID = &movement.ID
+		item.CostAmount = math.Round(movement.TotalCost*100) / 100
+		if err := s.salesRepo.UpdateSalesCreditNoteItem(item); err != nil {
+			return err
+		}
+	}
+
+	description := "Credit note " + creditNote.CreditNoteNumber + " for " + invoice.InvoiceNumber + " - " + invoice.Customer.Name
+	var entries []models.JournalEntry
+	addEntry := func(accountID uint, debit, credit float64) {
+		entries = append(entries, models.JournalEntry{
+			AccountID:   accountID,
+			Description: description,
+			Debit:       debit,
+			Credit:      credit,
+			Position:    len(entries) + 1,
+		})
+	}
+
+	var accountIDs []uint
+	revenue := make(map[uint]float64)
+	for _, item := range creditNote.Items {
+		var accountID uint
+		if revenueAccountID := invoiceItems[item.InvoiceItemID].RevenueAccountID; revenueAccountID != nil {
+			accountID = *revenueAccountID
+		} else {
+			account, err := s.accountRepo.FindByCode(creditNote.CompanyID, defaultSalesRevenueAccountCode)
+			if err != nil {
+				return errors.New("revenue account " + defaultSalesRevenueAccountCode + " not found")
+			}
+			accountID = account.ID
+		}
+		if _, ok := revenue[accountID]; !ok {
+			accountIDs = append(accountIDs, accountID)
+		}
+		revenue[accountID] += item.Amount
+	}
+	for _, accountID := range accountIDs {
+		addEntry(accountID, math.Round(revenue[accountID]*100)/100, 0)
+	}
+
+	if creditNote.TaxAmount > 0 {
+		outputTax, err := s.accountRepo.FindByCode(creditNote.CompanyID, defaultOutputTaxAccountCode)
+		if err != nil {
+			return errors.New("output tax account " + defaultOutputTaxAccountCode + " not found")
+		}
+		addEntry(outputTax.ID, creditNote.TaxAmount, 0)
+	}
+	applied := math.Round(creditNote.AppliedAmount()*100) / 100
+	if applied > 0 {
+		addEntry(receivable.ID, 0, applied)
+	}
+	if creditNote.UnappliedAmount > 0 {
+		customerCredit, err := s.accountRepo.FindByCode(creditNote.CompanyID, defaultCustomerCreditCode)
+		if err != nil {
+			return errors.New("customer credit account " + defaultCustomerCreditCode + " not found")
+		}
+		addEntry(customerCredit.ID, 0, creditNote.UnappliedAmount)
+	}
+
+	journal := &models.Journal{
+		CompanyID:       creditNote.CompanyID,
+		TransactionDate: creditNote.CreditNoteDate,
+		Description:     description,
+		CreatedBy:       userID,
+		Entries:         entries,
+	}
+
+	if err := s.journalService.CreateJournal(journal); err != nil {
+		return err
+	}
+	if err := s.journalService.PostJournal(journal.ID, userID); err != nil {
+		return err
+	}
+
+	invoice.CreditedAmount = math.Round((invoice.CreditedAmount+applied)*100) / 100
+	if math.Round(invoice.Outstanding()*100) <= 0 {
+		invoice.Status = models.SalesInvoiceStatusPaid
+	}
+	if err := s.salesRepo.UpdateSalesInvoice(invoice); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	creditNote.JournalID = &journal.ID
+	creditNote.Status = models.CreditNoteStatusPosted
+	creditNote.PostedAt = &now
+	creditNote.PostedBy = &userID
+	return s.salesRepo.UpdateSalesCreditNote(creditNote)
+}
+
+func (s *salesService) CancelSalesCreditNote(id uint) error {
+	creditNote, err := s.salesRepo.FindSalesCreditNoteByID(id)
+	if err != nil {
+		return errors.New("credit note not found")
+	}
+
+	if creditNote.Status != models.CreditNoteStatusDraft {
+		return errors.New("only draft credit notes can be cancelled")
+	}
+	for _, item := range creditNote.Items {
+		if item.MovementID != nil {
+			return errors.New("goods have already been returned for this credit note")
+		}
+	}
+
+	creditNote.Status = models.CreditNoteStatusCancelled
+	return s.salesRepo.UpdateSalesCreditNote(creditNote)
+}
+
+// prepareSalesCreditNoteApplication checks the credit note still holds the
+// customer credit the application draws on
+func (s *salesService) prepareSalesCreditNoteApplication(application *models.SalesCreditNoteApplication) (*models.SalesCreditNote, error) {
+	creditNote, err := s.salesRepo.FindSalesCreditNoteByID(application.CreditNoteID)
+	if err != nil || creditNote.CompanyID != application.CompanyID {
+		return nil, errors.New("credit note not found")
+	}
+	if creditNote.Status != models.CreditNoteStatusPosted {
+		return nil, errors.New("only posted credit notes hold customer credit")
+	}
+	if application.ApplicationDate.Before(creditNote.CreditNoteDate) {
+		return nil, errors.New("date cannot be before the credit note date")
+	}
+
+	application.Amount = math.Round(application.Amount*100) / 100
+	if application.Amount <= 0 {
+		return nil, errors.New("amount must be greater than zero")
+	}
+	if application.Amount > math.Round(creditNote.AvailableCredit()*100)/100 {
+		return nil, fmt.Errorf("only %.2f of customer credit is left on %s", creditNote.AvailableCredit(), creditNote.CreditNoteNumber)
+	}
+
+	application.CustomerID = creditNote.CustomerID
+	return creditNote, nil
+}
+
+// ApplySalesCreditNote settles another open invoice of the customer with
+// credit held on a credit note: Dr customer credit / Cr AR
+func (s *salesService) ApplySalesCreditNote(application *models.SalesCreditNoteApplication) error {
+	creditNote, err := s.prepareSalesCreditNoteApplication(application)
+	if err != nil {
+		return err
+	}
+	if application.InvoiceID == nil {
+		return errors.New("invoice is required")
+	}
+	application.AccountID = nil
+
+	invoice, err := s.salesRepo.FindSalesInvoiceByID(*application.InvoiceID)
+	if err != nil || invoice.CompanyID != application.CompanyID || invoice.CustomerID != creditNote.CustomerID {
+		return errors.New("invoice is not open for this customer")
+	}
+	if invoice.Status != models.SalesInvoiceStatusPosted && invoice.Status != models.SalesInvoiceStatusPartiallyPaid {
+		return errors.New("invoice is not open for this customer")
+	}
+	if application.ApplicationDate.Before(invoice.InvoiceDate) {
+		return errors.New("date cannot be before the invoice date")
+	}
+	if application.Amount > math.Round(invoice.Outstanding()*100)/100 {
+		return errors.New("amount exceeds the outstanding amount of " + invoice.InvoiceNumber)
+	}
+
+	receivable, err := s.accountRepo.FindByCode(application.CompanyID, defaultReceivableAccountCode)
+	if err != nil {
+		return errors.New("receivable account " + defaultReceivableAccountCode + " not found")
+	}
+	customerCredit, err := s.accountRepo.FindByCode(application.CompanyID, defaultCustomerCreditCode)
+	if err != nil {
+		return errors.New("customer credit account " + defaultCustomerCreditCode + " not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		description := "Credit note " + creditNote.CreditNoteNumber + " applied to " + invoice.InvoiceNumber + " - " + creditNote.Customer.Name
+		journal := &models.Journal{
+			CompanyID:       application.CompanyID,
+			TransactionDate: application.ApplicationDate,
+			Description:     description,
+			CreatedBy:       application.CreatedBy,
+			Entries: []models.JournalEntry{
+				{AccountID: customerCredit.ID, Description: description, Debit: application.Amount, Position: 1},
+				{AccountID: receivable.ID, Description: description, Credit: application.Amount, Position: 2},
+			},
+		}
+		if err := txService.journalService.CreateJournal(journal); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(journal.ID, application.CreatedBy); err != nil {
+			return err
+		}
+
+		invoice.CreditedAmount = math.Round((invoice.CreditedAmount+application.Amount)*100) / 100
+		if math.Round(invoice.Outstanding()*100) <= 0 {
+			invoice.Status = models.SalesInvoiceStatusPaid
+		} else {
+			invoice.Status = models.SalesInvoiceStatusPartiallyPaid
+		}
+		if err := txService.salesRepo.UpdateSalesInvoice(invoice); err != nil {
+			return err
+		}
+
+		creditNote.CreditUsedAmount = math.Round((creditNote.CreditUsedAmount+application.Amount)*100) / 100
+		if err := txService.salesRepo.UpdateSalesCreditNote(creditNote); err != nil {
+			return err
+		}
+
+		application.JournalID = &journal.ID
+		return txService.salesRepo.CreateSalesCreditNoteApplication(application)
+	})
+}
+
+// RefundSalesCreditNote pays credit held on a credit note back to the
+// customer: Dr customer credit / Cr cash/bank
+func (s *salesService) RefundSalesCreditNote(application *models.SalesCreditNoteApplication) error {
+	creditNote, err := s.prepareSalesCreditNoteApplication(application)
+	if err != nil {
+		return err
+	}
+	if application.AccountID == nil {
+		return errors.New("cash/bank account is required")
+	}
+	application.InvoiceID = nil
+
+	account, err := findCashBankAccount(s.accountRepo, application.CompanyID, *application.AccountID)
+	if err != nil {
+		return err
+	}
+
+	customerCredit, err := s.accountRepo.FindByCode(application.CompanyID, defaultCustomerCreditCode)
+	if err != nil {
+		return errors.New("customer credit account " + defaultCustomerCreditCode + " not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		txService := s.withTx(tx)
+
+		transaction := &models.CashBankTransaction{
+			CompanyID:       application.CompanyID,
+			AccountID:       account.ID,
+			TransactionDate: application.ApplicationDate,
+			Category:        models.CategoryCustomerRefund,
+			Amount:          application.Amount,
+			Description:     "Refund of credit note " + creditNote.CreditNoteNumber + " to " + creditNote.Customer.Name,
+			Reference:       application.Reference,
+			CreatedBy:       application.CreatedBy,
+		}
+		if err := txService.cashBankService.CreateCashOutWithJournal(transaction, customerCredit.ID); err != nil {
+			return err
+		}
+		if err := txService.journalService.PostJournal(*transaction.JournalID, application.CreatedBy); err != nil {
+			return err
+		}
+
+		creditNote.CreditUsedAmount = math.Round((creditNote.CreditUsedAmount+application.Amount)*100) / 100
+		if err := txService.salesRepo.UpdateSalesCreditNote(creditNote); err != nil {
+			return err
+		}
+
+		application.CashBankTransactionID = &transaction.ID
+		application.JournalID = transaction.JournalID
+		return txService.salesRepo.CreateSalesCreditNoteApplication(application)
+	})
+}
+
+// Receivable Aging methods
+
+// GetReceivableAging ages unpaid invoices by customer and reconciles the
+// total to the receivable control account
+func (s *salesService) GetReceivableAging(companyID uint, customerID uint, asOf time.Time, limits []int) (*models.AgingReport, error) {
+	receivable, err := s.accountRepo.FindByCode(companyID, defaultReceivableAccountCode)
+	if err != nil {
+		return nil, errors.New("receivable account " + defaultReceivableAccountCode + " not found")
+	}
+
+	// Documents and payments dated on asOf count towards it
+	endOfDay := asOf.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	items, err := s.salesRepo.FindReceivableOpenItems(companyID, customerID, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := BuildAgingReport(items, asOf, limits)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, err := s.ledgerRepo.GetAccountBalance(receivable.ID, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	report.Type = "receivable"
+	report.ControlAccountCode = receivable.Code
+	report.ControlAccountName = receivable.Name
+	report.GLBalance = math.Round(balance*100) / 100
+	report.Difference = math.Round((report.Total-report.GLBalance)*100) / 100
+	report.IsReconciled = customerID == 0 && report.Difference == 0
+	return report, nil
+}
+
+// GetCustomerStatement lists the customer's invoices and payments in the
+// period with a running balance from what was owed at the start date
+func (s *salesService) GetCustomerStatement(customerID uint, startDate, endDate time.Time) (*models.CustomerStatement, error) {
+	customer, err := s.salesRepo.FindCustomerByID(customerID)
+	if err != nil {
+		return nil, errors.New("customer not found")
+	}
+	if endDate.Before(startDate) {
+		return nil, errors.New("end date is before start date")
+	}
+
+	// Documents dated on the end date are part of the statement
+	endOfDay := endDate.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	opening, err := s.salesRepo.GetCustomerBalance(customer.ID, startDate)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := s.salesRepo.GetCustomerStatementEntries(customer.ID, startDate, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := BuildCustomerStatement(opening, entries)
+	statement.CustomerID = customer.ID
+	statement.CustomerCode = customer.Code
+	statement.CustomerName = customer.Name
+	statement.Address = customer.Address
+	statement.NPWP = customer.NPWP
+	statement.StartDate = startDate.Format("2006-01-02")
+	statement.EndDate = endDate.Format("2006-01-02")
+
+	// Overdue part of the closing balance, from the invoices still open at the end date
+	items, err := s.salesRepo.FindReceivableOpenItems(customer.CompanyID, customer.ID, endOfDay)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.DueDate.Before(endDate) && item.Outstanding > 0 {
+			statement.OverdueAmount += item.Outstanding
+		}
+	}
+	statement.OverdueAmount = math.Round(statement.OverdueAmount*100) / 100
+
+	return statement, nil
+}
+
+// BuildCustomerStatement runs the balance through the entries, invoices
+// adding to what the customer owes and payments reducing it
+func BuildCustomerStatement(opening float64, entries []models.CustomerStatementEntry) *models.CustomerStatement {
+	statement := &models.CustomerStatement{
+		OpeningBalance: math.Round(opening*100) / 100,
+		Entries:        entries,
+	}
+
+	balance := opening
+	for i := range statement.Entries {
+		entry := &statement.Entries[i]
+		balance += entry.Debit - entry.Credit
+		entry.Balance = math.Round(balance*100) / 100
+
+		statement.TotalDebit += entry.Debit
+		statement.TotalCredit += entry.Credit
+	}
+
+	statement.TotalDebit = math.Round(statement.TotalDebit*100) / 100
+	statement.TotalCredit = math.Round(statement.TotalCredit*100) / 100
+	statement.ClosingBalance = math.Round(balance*100) / 100
+	return statement
+}
\ No newline at end of file
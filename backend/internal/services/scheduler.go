@@ -0,0 +1,64 @@
+package services
+
+import (
+	"finara-backend/internal/repository"
+	"log"
+	"time"
+)
+
+// CompanyJob is a periodic check run once per company
+type CompanyJob func(companyID uint) error
+
+type scheduledJob struct {
+	name string
+	run  CompanyJob
+}
+
+// Scheduler runs registered company jobs in the background at a fixed interval
+type Scheduler struct {
+	companyRepo repository.CompanyRepository
+	interval    time.Duration
+	jobs        []scheduledJob
+}
+
+func NewScheduler(companyRepo repository.CompanyRepository, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		companyRepo: companyRepo,
+		interval:    interval,
+	}
+}
+
+func (s *Scheduler) Register(name string, job CompanyJob) {
+	s.jobs = append(s.jobs, scheduledJob{name: name, run: job})
+}
+
+// Start runs all jobs immediately and then on every tick
+func (s *Scheduler) Start() {
+	go func() {
+		s.RunOnce()
+
+		ticker := time.NewTicker(s.interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.RunOnce()
+		}
+	}()
+}
+
+// RunOnce runs every job for every company; a failing job is logged and
+// does not stop the others
+func (s *Scheduler) RunOnce() {
+	companies, err := s.companyRepo.FindAll()
+	if err != nil {
+		log.Printf("Scheduler: failed to load companies: %v", err)
+		return
+	}
+
+	for _, job := range s.jobs {
+		for _, company := range companies {
+			if err := job.run(company.ID); err != nil {
+				log.Printf("Scheduler: %s failed for company %d: %v", job.name, company.ID, err)
+			}
+		}
+	}
+}
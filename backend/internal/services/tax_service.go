@@ -4,6 +4,7 @@ import (
 	"errors"
 	"finara-backend/internal/models"
 	"finara-backend/internal/repository"
+	"math"
 	"time"
 )
 
@@ -20,15 +21,19 @@ type TaxService interface {
 	MarkAsPaid(id uint) error
 	GetTaxSummary(companyID uint, period string) ([]models.TaxSummary, error)
 	CalculatePPN(companyID uint, period string) error
-	CalculatePPh21(companyID uint, period string) error
+	CalculatePPh21(companyID uint, period string) (*models.Tax, error)
 }
 
 type taxService struct {
-	taxRepo repository.TaxRepository
+	taxRepo     repository.TaxRepository
+	payrollRepo repository.PayrollRepository
 }
 
-func NewTaxService(taxRepo repository.TaxRepository) TaxService {
-	return &taxService{taxRepo: taxRepo}
+func NewTaxService(taxRepo repository.TaxRepository, payrollRepo repository.PayrollRepository) TaxService {
+	return &taxService{
+		taxRepo:     taxRepo,
+		payrollRepo: payrollRepo,
+	}
 }
 
 func (s *taxService) CreateTax(tax *models.Tax) error {
@@ -81,8 +86,17 @@ func (s *taxService) UpdateTax(id uint, updatedTax *models.Tax) error {
 		return errors.New("only draft taxes can be updated")
 	}
 
-	// Recalculate tax amount
-	updatedTax.TaxAmount = updatedTax.TaxableAmount * (updatedTax.TaxRate / 100)
+	// PPh 21 of a payroll run is the sum of what each payslip withheld, not
+	// the effective rate times the gross, so only the payroll can change it
+	updatedTax.PayrollRunID = tax.PayrollRunID
+	if tax.PayrollRunID != nil {
+		updatedTax.TaxableAmount = tax.TaxableAmount
+		updatedTax.TaxRate = tax.TaxRate
+		updatedTax.TaxAmount = tax.TaxAmount
+	} else {
+		// Recalculate tax amount
+		updatedTax.TaxAmount = updatedTax.TaxableAmount * (updatedTax.TaxRate / 100)
+	}
 	updatedTax.ID = id
 
 	return s.taxRepo.Update(updatedTax)
@@ -140,10 +154,71 @@ func (s *taxService) CalculatePPN(companyID uint, period string) error {
 	return errors.New("PPN calculation not yet implemented")
 }
 
-func (s *taxService) CalculatePPh21(companyID uint, period string) error {
-	// This is a simplified calculation
-	// In real implementation, you would calculate from payroll data
-	
-	// For now, return not implemented
-	return errors.New("PPh21 calculation not yet implemented")
+// CalculatePPh21 records the PPh 21 the period's posted payroll withheld as
+// a draft tax, due on the 15th of the following month. Calculating again
+// brings the draft up to date with the payroll.
+func (s *taxService) CalculatePPh21(companyID uint, period string) (*models.Tax, error) {
+	periodStart, _, err := parsePeriod(period)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := s.payrollRepo.FindPayrollRunByPeriod(companyID, period)
+	if err != nil {
+		return nil, errors.New("no payroll run for " + period)
+	}
+	if run.Status == models.PayrollRunStatusDraft {
+		return nil, errors.New("payroll run " + run.RunNumber + " has not been posted")
+	}
+
+	taxes, err := s.taxRepo.FindByPeriod(companyID, period)
+	if err != nil {
+		return nil, err
+	}
+	var tax *models.Tax
+	for i := range taxes {
+		if taxes[i].TaxType == models.TaxTypePPh21 {
+			tax = &taxes[i]
+			break
+		}
+	}
+	if tax != nil && tax.Status != models.TaxStatusDraft {
+		return nil, errors.New("PPh 21 for " + period + " has already been reported")
+	}
+	if tax == nil {
+		taxNumber, err := s.taxRepo.GenerateTaxNumber(companyID, models.TaxTypePPh21, period)
+		if err != nil {
+			return nil, err
+		}
+		tax = &models.Tax{
+			CompanyID: companyID,
+			TaxNumber: taxNumber,
+			TaxType:   models.TaxTypePPh21,
+			TaxPeriod: period,
+			Status:    models.TaxStatusDraft,
+			CreatedBy: run.CreatedBy,
+		}
+	}
+
+	// The amount is exactly what the payslips withheld; the rate is only the
+	// effective rate over the payroll's gross income, shown for reference
+	tax.TaxableAmount = run.TotalGrossIncome
+	tax.TaxAmount = run.TotalPPh21
+	tax.TaxRate = 0
+	if run.TotalGrossIncome > 0 {
+		tax.TaxRate = math.Round(run.TotalPPh21/run.TotalGrossIncome*10000) / 100
+	}
+	tax.DueDate = periodStart.AddDate(0, 1, 14)
+	tax.Description = "PPh 21 withheld by payroll " + run.RunNumber
+	tax.PayrollRunID = &run.ID
+
+	if tax.ID == 0 {
+		err = s.taxRepo.Create(tax)
+	} else {
+		err = s.taxRepo.Update(tax)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tax, nil
 }
\ No newline at end of file
@@ -4,6 +4,8 @@ import (
 	"errors"
 	"finara-backend/internal/models"
 	"finara-backend/internal/repository"
+
+	"gorm.io/gorm"
 )
 
 type UserService interface {
@@ -11,6 +13,7 @@ type UserService interface {
 	GetAllUsers() ([]models.User, error)
 	UpdateUser(id uint, updates map[string]interface{}) (*models.User, error)
 	DeleteUser(id uint) error
+	GetSystemUser() (*models.User, error)
 }
 
 type userService struct {
@@ -34,6 +37,9 @@ func (s *userService) UpdateUser(id uint, updates map[string]interface{}) (*mode
 	if err != nil {
 		return nil, errors.New("user not found")
 	}
+	if user.Email == models.SystemUserEmail {
+		return nil, errors.New("the system user cannot be changed")
+	}
 
 	// Update fields
 	if fullName, ok := updates["full_name"].(string); ok {
@@ -65,5 +71,33 @@ func (s *userService) UpdateUser(id uint, updates map[string]interface{}) (*mode
 }
 
 func (s *userService) DeleteUser(id uint) error {
+	user, err := s.userRepo.FindByID(id)
+	if err == nil && user.Email == models.SystemUserEmail {
+		return errors.New("the system user cannot be deleted")
+	}
 	return s.userRepo.Delete(id)
+}
+
+// GetSystemUser returns the user scheduled jobs post as, creating it on
+// first use. It has no usable password, so nobody can log in as it.
+func (s *userService) GetSystemUser() (*models.User, error) {
+	user, err := s.userRepo.FindByEmail(models.SystemUserEmail)
+	if err == nil {
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	user = &models.User{
+		Email:    models.SystemUserEmail,
+		Password: "-",
+		FullName: "System",
+		Role:     models.RoleViewer,
+		IsActive: true,
+	}
+	if err := s.userRepo.Create(user); err != nil {
+		return nil, err
+	}
+	return user, nil
 }
\ No newline at end of file
@@ -93,6 +93,13 @@ func setupTestRouter() *gin.Engine {
 	dashboardRepo := repository.NewDashboardRepository(db)
 	notificationRepo := repository.NewNotificationRepository(db)
 	inventoryRepo := repository.NewInventoryRepository(db)
+	salesRepo := repository.NewSalesRepository(db)
+	salesOrderRepo := repository.NewSalesOrderRepository(db)
+	purchaseRepo := repository.NewPurchaseRepository(db)
+	procurementRepo := repository.NewProcurementRepository(db)
+	dunningRepo := repository.NewDunningRepository(db)
+	payrollRepo := repository.NewPayrollRepository(db)
+	loanRepo := repository.NewLoanRepository(db)
 	auditLogRepo := repository.NewAuditLogRepository(db)
 	backupRepo := repository.NewBackupRepository(db)
 
@@ -114,12 +121,15 @@ func setupTestRouter() *gin.Engine {
 	ledgerService := services.NewLedgerService(ledgerRepo)
 	reportService := services.NewReportService(reportRepo)
 	cashBankService := services.NewCashBankService(cashBankRepo, journalRepo, ledgerRepo, accountRepo)
-	taxService := services.NewTaxService(taxRepo)
+	taxService := services.NewTaxService(taxRepo, payrollRepo)
 	dashboardService := services.NewDashboardService(dashboardRepo)
-	notificationService := services.NewNotificationService(notificationRepo, taxRepo, userRepo)
-	inventoryService := services.NewInventoryService(inventoryRepo, journalRepo, accountRepo)
+	notificationService := services.NewNotificationService(notificationRepo, taxRepo, userRepo, inventoryRepo, loanRepo)
+	inventoryService := services.NewInventoryService(db, inventoryRepo, accountRepo, ledgerRepo, journalService, notificationService)
+	salesService := services.NewSalesService(db, salesRepo, salesOrderRepo, inventoryRepo, accountRepo, ledgerRepo, inventoryService, journalService, cashBankService)
+	purchaseService := services.NewPurchaseService(db, purchaseRepo, procurementRepo, inventoryRepo, accountRepo, ledgerRepo, inventoryService, journalService, cashBankService)
 	auditLogService := services.NewAuditLogService(auditLogRepo)
 	exportService := services.NewExportService()
+	dunningService := services.NewDunningService(dunningRepo, salesRepo, notificationService, exportService, services.NewLogEmailSender())
 	backupService := services.NewBackupService(backupRepo, dbConfig)
 
 	// Initialize handlers
@@ -136,7 +146,7 @@ func setupTestRouter() *gin.Engine {
 	notificationHandler := handlers.NewNotificationHandler(notificationService)
 	inventoryHandler := handlers.NewInventoryHandler(inventoryService)
 	auditLogHandler := handlers.NewAuditLogHandler(auditLogService)
-	exportHandler := handlers.NewExportHandler(exportService, journalService, ledgerService, reportService)
+	exportHandler := handlers.NewExportHandler(exportService, journalService, ledgerService, reportService, inventoryService, salesService, purchaseService, dunningService)
 	backupHandler := handlers.NewBackupHandler(backupService)
 
 	// Setup router
@@ -297,11 +307,88 @@ func cleanupDatabase(db *gorm.DB) {
 	tables := []string{
 		"audit_logs",
 		"backups",
+		"deferral_run_lines",
+		"deferral_runs",
+		"deferral_schedule_lines",
+		"deferral_schedules",
+		"loan_reclassification_lines",
+		"loan_reclassifications",
+		"loan_rate_changes",
+		"loan_installments",
+		"loans",
+		"expense_claim_attachments",
+		"expense_claim_items",
+		"expense_claims",
+		"cash_advances",
+		"payslip_lines",
+		"payslips",
+		"payroll_runs",
+		"payroll_settings",
+		"employee_salary_components",
+		"employees",
+		"salary_components",
+		"depreciation_run_lines",
+		"depreciation_runs",
+		"fixed_asset_events",
+		"depreciation_schedule_lines",
+		"fixed_assets",
+		"payment_run_lines",
+		"payment_runs",
+		"purchase_debit_note_items",
+		"purchase_debit_notes",
+		"vendor_payment_allocations",
+		"vendor_payments",
+		"purchase_bill_items",
+		"purchase_bills",
+		"purchase_match_tolerances",
+		"goods_receipt_items",
+		"goods_receipts",
+		"purchase_order_items",
+		"purchase_orders",
+		"purchase_requisition_items",
+		"purchase_requisitions",
+		"vendors",
+		"dunning_reminder_items",
+		"dunning_reminders",
+		"dunning_levels",
+		"sales_credit_note_items",
+		"sales_credit_notes",
+		"customer_payment_allocations",
+		"customer_payments",
+		"sales_invoice_items",
+		"sales_invoices",
+		"delivery_order_items",
+		"delivery_orders",
+		"sales_order_items",
+		"sales_orders",
+		"sales_quotation_items",
+		"sales_quotations",
+		"customers",
+		"production_order_items",
+		"production_orders",
+		"stock_opname_counts",
 		"stock_opname_items",
 		"stock_opnames",
+		"stock_reservations",
+		"landed_cost_items",
+		"landed_cost_charges",
+		"landed_costs",
+		"stock_transfer_items",
+		"stock_transfers",
+		"serial_number_events",
+		"serial_numbers",
+		"stock_lot_allocations",
+		"stock_lots",
+		"stock_layer_consumptions",
+		"stock_cost_layers",
 		"stock_movements",
 		"stock_balances",
+		"inventory_account_mappings",
+		"warehouses",
+		"product_units",
+		"bom_items",
 		"products",
+		"unit_of_measures",
 		"notifications",
 		"taxes",
 		"bank_reconciliations",
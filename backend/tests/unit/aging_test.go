@@ -0,0 +1,69 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+	"time"
+)
+
+// Test AR / AP Aging
+func TestBuildAgingReport_Buckets(t *testing.T) {
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	items := []models.AgingOpenItem{
+		{DocumentID: 1, PartyID: 2, PartyCode: "C-002", DueDate: asOf.AddDate(0, 0, 10), Outstanding: 1000},
+		{DocumentID: 2, PartyID: 1, PartyCode: "C-001", DueDate: asOf.AddDate(0, 0, -30), Outstanding: 2000},
+		{DocumentID: 3, PartyID: 1, PartyCode: "C-001", DueDate: asOf.AddDate(0, 0, -31), Outstanding: 3000},
+		{DocumentID: 4, PartyID: 1, PartyCode: "C-001", DueDate: asOf.AddDate(0, 0, -120), Outstanding: 4000},
+		{DocumentID: 5, PartyID: 1, PartyCode: "C-001", DueDate: asOf.AddDate(0, 0, -5), Outstanding: 0},
+	}
+
+	report, err := services.BuildAgingReport(items, asOf, services.DefaultAgingLimits)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(report.Buckets) != 5 || report.Buckets[4].Label != "> 90" {
+		t.Fatalf("Expected 5 buckets ending with > 90, got %v", report.Buckets)
+	}
+
+	if len(report.Lines) != 2 || report.Lines[0].PartyCode != "C-001" {
+		t.Fatalf("Expected 2 lines starting with C-001, got %v", report.Lines)
+	}
+
+	// Paid documents are left out
+	line := report.Lines[0]
+	if len(line.Documents) != 3 {
+		t.Errorf("Expected 3 open documents, got %d", len(line.Documents))
+	}
+
+	if line.Amounts[1] != 2000 || line.Amounts[2] != 3000 || line.Amounts[4] != 4000 || line.Total != 9000 {
+		t.Errorf("Expected 2000 in 1-30, 3000 in 31-60, 4000 over 90, got %v", line.Amounts)
+	}
+
+	if report.BucketTotals[0] != 1000 || report.Total != 10000 {
+		t.Errorf("Expected 1000 current and 10000 total, got %v and %v", report.BucketTotals[0], report.Total)
+	}
+}
+
+func TestBuildAgingReport_CustomBuckets(t *testing.T) {
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	items := []models.AgingOpenItem{
+		{PartyID: 1, DueDate: asOf.AddDate(0, 0, -20), Outstanding: 500},
+	}
+
+	report, err := services.BuildAgingReport(items, asOf, []int{15, 45})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if report.Buckets[2].Label != "16-45" || report.Lines[0].Amounts[2] != 500 {
+		t.Errorf("Expected 500 in 16-45, got %v in %v", report.Lines[0].Amounts, report.Buckets)
+	}
+}
+
+func TestAgingBuckets_NotAscending(t *testing.T) {
+	if _, err := services.AgingBuckets([]int{60, 30}); err == nil {
+		t.Errorf("Expected error for buckets that are not ascending")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,89 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+	"time"
+)
+
+func testDeferral(method models.DeferralMethod, amount float64, start, end time.Time) *models.DeferralSchedule {
+	return &models.DeferralSchedule{
+		Type:      models.DeferralTypePrepaidExpense,
+		Amount:    amount,
+		StartDate: start,
+		EndDate:   end,
+		Method:    method,
+	}
+}
+
+// Test Deferral Schedules
+func TestBuildDeferralSchedule_Monthly(t *testing.T) {
+	// A year's insurance from the 15th is twelve whole months
+	lines := services.BuildDeferralSchedule(testDeferral(models.DeferralMethodMonthly, 12000000,
+		time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2027, time.January, 14, 0, 0, 0, 0, time.UTC)))
+	if len(lines) != 12 {
+		t.Fatalf("Expected 12 months, got %d", len(lines))
+	}
+	if lines[0].Period != "2026-01" || lines[11].Period != "2026-12" {
+		t.Errorf("Expected 2026-01 to 2026-12, got %s to %s", lines[0].Period, lines[11].Period)
+	}
+	if lines[0].Amount != 1000000 || lines[11].Amount != 1000000 || lines[11].Remaining != 0 {
+		t.Errorf("Expected 1000000 a month down to 0, got %v .. %v remaining %v", lines[0].Amount, lines[11].Amount, lines[11].Remaining)
+	}
+	if !lines[1].PeriodEnd.Equal(time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected period end 2026-02-28, got %v", lines[1].PeriodEnd)
+	}
+
+	// The last month takes the cents left by rounding
+	lines = services.BuildDeferralSchedule(testDeferral(models.DeferralMethodMonthly, 1000000,
+		time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)))
+	if len(lines) != 3 || lines[0].Amount != 333333.33 || lines[2].Amount != 333333.34 || lines[2].Accumulated != 1000000 {
+		t.Errorf("Expected 333333.33, 333333.33, 333333.34, got %+v", lines)
+	}
+}
+
+func TestBuildDeferralSchedule_Daily(t *testing.T) {
+	// 365 days at 10000 a day, with part months at either end
+	lines := services.BuildDeferralSchedule(testDeferral(models.DeferralMethodDaily, 3650000,
+		time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2027, time.January, 14, 0, 0, 0, 0, time.UTC)))
+	if len(lines) != 13 {
+		t.Fatalf("Expected 13 months, got %d", len(lines))
+	}
+	if lines[0].Days != 17 || lines[0].Amount != 170000 {
+		t.Errorf("Expected 17 days of 170000 in January, got %d days of %v", lines[0].Days, lines[0].Amount)
+	}
+	if lines[1].Days != 28 || lines[1].Amount != 280000 {
+		t.Errorf("Expected 28 days of 280000 in February, got %d days of %v", lines[1].Days, lines[1].Amount)
+	}
+	if lines[12].Days != 14 || lines[12].Amount != 140000 || lines[12].Remaining != 0 {
+		t.Errorf("Expected 14 days of 140000 ending at 0, got %d days of %v remaining %v", lines[12].Days, lines[12].Amount, lines[12].Remaining)
+	}
+}
+
+// Test Roll-Forward
+func TestCalculateDeferralRollForward(t *testing.T) {
+	schedule := testDeferral(models.DeferralMethodMonthly, 12000000,
+		time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC),
+		time.Date(2027, time.January, 14, 0, 0, 0, 0, time.UTC))
+	posted := services.BuildDeferralSchedule(schedule)[:6] // January to June
+
+	// Added in the first quarter
+	line := services.CalculateDeferralRollForward(schedule, posted,
+		time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC))
+	if line.OpeningBalance != 0 || line.Additions != 12000000 || line.Amortization != 3000000 || line.ClosingBalance != 9000000 {
+		t.Errorf("Expected 0 + 12000000 - 3000000 = 9000000, got %v + %v - %v = %v", line.OpeningBalance, line.Additions, line.Amortization, line.ClosingBalance)
+	}
+
+	// Brought forward into the second quarter
+	line = services.CalculateDeferralRollForward(schedule, posted,
+		time.Date(2026, time.April, 1, 0, 0, 0, 0, time.UTC),
+		time.Date(2026, time.June, 30, 0, 0, 0, 0, time.UTC))
+	if line.OpeningBalance != 9000000 || line.Additions != 0 || line.Amortization != 3000000 || line.ClosingBalance != 6000000 {
+		t.Errorf("Expected 9000000 + 0 - 3000000 = 6000000, got %v + %v - %v = %v", line.OpeningBalance, line.Additions, line.Amortization, line.ClosingBalance)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,154 @@
+package unit
+
+import (
+	"bufio"
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"net"
+	"strings"
+	"testing"
+)
+
+// Test Customer Statements
+func TestBuildCustomerStatement_RunningBalance(t *testing.T) {
+	entries := []models.CustomerStatementEntry{
+		{Type: "invoice", Debit: 1110},
+		{Type: "payment", Credit: 500},
+		{Type: "invoice", Debit: 222.2},
+	}
+
+	statement := services.BuildCustomerStatement(1000, entries)
+
+	if statement.Entries[0].Balance != 2110 || statement.Entries[1].Balance != 1610 || statement.Entries[2].Balance != 1832.2 {
+		t.Errorf("Expected balances 2110, 1610, 1832.2, got %v", statement.Entries)
+	}
+
+	if statement.TotalDebit != 1332.2 || statement.TotalCredit != 500 || statement.ClosingBalance != 1832.2 {
+		t.Errorf("Expected debit 1332.2, credit 500, closing 1832.2, got %v, %v, %v",
+			statement.TotalDebit, statement.TotalCredit, statement.ClosingBalance)
+	}
+}
+
+// Test Dunning Levels
+func TestDunningLevelFor(t *testing.T) {
+	levels := services.DefaultDunningLevels
+
+	if level := services.DunningLevelFor(levels, 6); level != nil {
+		t.Errorf("Expected no level at 6 days, got %d", level.Level)
+	}
+
+	if level := services.DunningLevelFor(levels, 7); level == nil || level.Level != 1 {
+		t.Errorf("Expected level 1 at 7 days, got %v", level)
+	}
+
+	if level := services.DunningLevelFor(levels, 45); level == nil || level.Level != 2 {
+		t.Errorf("Expected level 2 at 45 days, got %v", level)
+	}
+
+	if level := services.DunningLevelFor(levels, 400); level == nil || level.Level != 3 {
+		t.Errorf("Expected level 3 at 400 days, got %v", level)
+	}
+}
+
+func TestValidateDunningLevels(t *testing.T) {
+	if err := services.ValidateDunningLevels(services.DefaultDunningLevels); err != nil {
+		t.Errorf("Expected default levels to be valid, got %v", err)
+	}
+
+	gap := []models.DunningLevel{
+		{Level: 1, DaysOverdue: 7, Name: "First"},
+		{Level: 3, DaysOverdue: 30, Name: "Third"},
+	}
+	if err := services.ValidateDunningLevels(gap); err == nil {
+		t.Error("Expected error for a gap in level numbers")
+	}
+
+	notEscalating := []models.DunningLevel{
+		{Level: 1, DaysOverdue: 30, Name: "First"},
+		{Level: 2, DaysOverdue: 30, Name: "Second"},
+	}
+	if err := services.ValidateDunningLevels(notEscalating); err == nil {
+		t.Error("Expected error for a level not later than the previous one")
+	}
+}
+
+// Test Email Sender against a local SMTP stand-in
+func TestSMTPEmailSender_Send(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Expected a local listener, got %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go serveSMTPOnce(listener, received)
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	sender := services.NewSMTPEmailSender(host, port, "", "", "billing@example.com")
+
+	err = sender.Send(&services.EmailMessage{
+		To:      []string{"customer@example.com"},
+		Subject: "Payment reminder - DUN/202412/0001",
+		Body:    "Dear customer,",
+		Attachments: []services.EmailAttachment{
+			{Filename: "DUN_202412_0001.pdf", ContentType: "application/pdf", Data: []byte("%PDF-1.4")},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Expected email to be sent, got %v", err)
+	}
+
+	data := <-received
+	if !strings.Contains(data, "To: customer@example.com") || !strings.Contains(data, "Subject: Payment reminder - DUN/202412/0001") {
+		t.Errorf("Expected recipient and subject headers, got %q", data)
+	}
+
+	if !strings.Contains(data, `filename="DUN_202412_0001.pdf"`) {
+		t.Errorf("Expected the PDF attachment, got %q", data)
+	}
+}
+
+// serveSMTPOnce accepts one connection, answers just enough SMTP for a
+// message to be delivered and passes on the message data
+func serveSMTPOnce(listener net.Listener, received chan<- string) {
+	conn, err := listener.Accept()
+	if err != nil {
+		received <- ""
+		return
+	}
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ready")
+	var data strings.Builder
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			received <- data.String()
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case command == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil || line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+			reply("250 queued")
+		case command == "QUIT":
+			reply("221 bye")
+			received <- data.String()
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
\ No newline at end of file
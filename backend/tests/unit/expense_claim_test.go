@@ -0,0 +1,49 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+)
+
+// Test Expense Claim Totals
+func TestCalculateExpenseClaimTotals_AgainstAdvance(t *testing.T) {
+	claim := &models.ExpenseClaim{
+		Items: []models.ExpenseClaimItem{
+			{AccountID: 1, Amount: 1250000},
+			{AccountID: 2, Amount: 375000.5},
+		},
+	}
+
+	// Spent less than the 2.000.000 advance, the rest comes back
+	if err := services.CalculateExpenseClaimTotals(claim, 2000000); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if claim.TotalAmount != 1625000.5 || claim.AdvanceAmount != 2000000 {
+		t.Errorf("Expected total 1625000.5 against 2000000, got %v against %v", claim.TotalAmount, claim.AdvanceAmount)
+	}
+	if claim.Balance != -374999.5 {
+		t.Errorf("Expected -374999.5 to be returned, got %v", claim.Balance)
+	}
+
+	// Spent more than a 1.000.000 advance, the shortfall is reimbursed
+	if err := services.CalculateExpenseClaimTotals(claim, 1000000); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if claim.Balance != 625000.5 {
+		t.Errorf("Expected 625000.5 to be reimbursed, got %v", claim.Balance)
+	}
+}
+
+func TestCalculateExpenseClaimTotals_Invalid(t *testing.T) {
+	if err := services.CalculateExpenseClaimTotals(&models.ExpenseClaim{}, 0); err == nil {
+		t.Error("Expected an error for a claim without items")
+	}
+
+	claim := &models.ExpenseClaim{
+		Items: []models.ExpenseClaimItem{{AccountID: 1, Amount: 0}},
+	}
+	if err := services.CalculateExpenseClaimTotals(claim, 0); err == nil {
+		t.Error("Expected an error for a zero amount item")
+	}
+}
\ No newline at end of file
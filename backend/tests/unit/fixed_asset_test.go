@@ -0,0 +1,95 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+	"time"
+)
+
+// Test Depreciation Schedule
+func TestBuildDepreciationSchedule_StraightLine(t *testing.T) {
+	asset := &models.FixedAsset{
+		AcquisitionDate:  time.Date(2026, time.March, 15, 0, 0, 0, 0, time.UTC),
+		AcquisitionCost:  48000000,
+		UsefulLifeMonths: 48,
+		Method:           models.DepreciationMethodStraightLine,
+		FiscalGroup:      models.FiscalAssetGroup1,
+		FiscalMethod:     models.DepreciationMethodStraightLine,
+	}
+
+	lines := services.BuildDepreciationSchedule(asset)
+	if len(lines) != 48 {
+		t.Fatalf("Expected 48 months, got %d", len(lines))
+	}
+
+	// Depreciation starts in the month of acquisition
+	if lines[0].Period != "2026-03" || lines[0].Depreciation != 1000000 || lines[0].FiscalDepreciation != 1000000 {
+		t.Errorf("Expected 1000000 for 2026-03, got %v on %v", lines[0].Depreciation, lines[0].Period)
+	}
+
+	last := lines[len(lines)-1]
+	if last.Period != "2030-02" || last.BookValue != 0 || last.FiscalBookValue != 0 {
+		t.Errorf("Expected fully depreciated by 2030-02, got %v book value on %v", last.BookValue, last.Period)
+	}
+}
+
+func TestBuildDepreciationSchedule_FiscalDecliningBalance(t *testing.T) {
+	asset := &models.FixedAsset{
+		AcquisitionDate:  time.Date(2026, time.July, 1, 0, 0, 0, 0, time.UTC),
+		AcquisitionCost:  100000000,
+		SalvageValue:     10000000,
+		UsefulLifeMonths: 60,
+		Method:           models.DepreciationMethodStraightLine,
+		FiscalGroup:      models.FiscalAssetGroup1,
+		FiscalMethod:     models.DepreciationMethodDecliningBalance,
+	}
+
+	lines := services.BuildDepreciationSchedule(asset)
+	if len(lines) != 60 {
+		t.Fatalf("Expected the longer commercial life of 60 months, got %d", len(lines))
+	}
+
+	// Kelompok 1 declines at 50% a year on the book value at the start of
+	// each year, the first year only for the months held
+	if lines[0].FiscalDepreciation != 4166666.67 {
+		t.Errorf("Expected 4166666.67 fiscal depreciation in the first month, got %v", lines[0].FiscalDepreciation)
+	}
+	if lines[6].Period != "2027-01" || lines[6].FiscalDepreciation != 3125000 {
+		t.Errorf("Expected 3125000 fiscal depreciation from 2027-01, got %v on %v", lines[6].FiscalDepreciation, lines[6].Period)
+	}
+
+	// The rest of the fiscal book value goes in the last month of the 4 years
+	if lines[47].FiscalBookValue != 0 || lines[46].FiscalBookValue == 0 {
+		t.Errorf("Expected the fiscal book value to reach zero in month 48, got %v", lines[47].FiscalBookValue)
+	}
+
+	// Commercial depreciation stops at the salvage value
+	if lines[0].Depreciation != 1500000 || lines[59].BookValue != 10000000 {
+		t.Errorf("Expected 1500000 a month down to 10000000, got %v and %v", lines[0].Depreciation, lines[59].BookValue)
+	}
+}
+
+// Test Disposal Gain or Loss
+func TestCalculateDisposalGainLoss_Partial(t *testing.T) {
+	asset := &models.FixedAsset{Cost: 100000000, AccumulatedDepreciation: 40000000}
+
+	cost, accumulated, gainLoss := services.CalculateDisposalGainLoss(asset, 25, 20000000)
+	if cost != 25000000 || accumulated != 10000000 {
+		t.Errorf("Expected a quarter of the cost and depreciation, got %v and %v", cost, accumulated)
+	}
+
+	// Net book value of the quarter is 15000000
+	if gainLoss != 5000000 {
+		t.Errorf("Expected a gain of 5000000, got %v", gainLoss)
+	}
+}
+
+func TestCalculateDisposalGainLoss_ScrappedAtLoss(t *testing.T) {
+	asset := &models.FixedAsset{Cost: 100000000, AccumulatedDepreciation: 40000000}
+
+	cost, accumulated, gainLoss := services.CalculateDisposalGainLoss(asset, 100, 0)
+	if cost != 100000000 || accumulated != 40000000 || gainLoss != -60000000 {
+		t.Errorf("Expected the full book value of 60000000 lost, got %v, %v, %v", cost, accumulated, gainLoss)
+	}
+}
\ No newline at end of file
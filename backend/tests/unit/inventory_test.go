@@ -0,0 +1,429 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+	"time"
+)
+
+// Test Cost Layer Consumption
+func testCostLayers() []models.StockCostLayer {
+	movement := uint(1)
+	return []models.StockCostLayer{
+		{BaseModel: models.BaseModel{ID: 3}, MovementID: &movement, ReceivedDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), RemainingQuantity: 10, UnitCost: 3000},
+		{BaseModel: models.BaseModel{ID: 1}, MovementID: &movement, ReceivedDate: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), RemainingQuantity: 10, UnitCost: 1000},
+		{BaseModel: models.BaseModel{ID: 2}, MovementID: &movement, ReceivedDate: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC), RemainingQuantity: 5, UnitCost: 2000},
+	}
+}
+
+func TestConsumeCostLayers(t *testing.T) {
+	tests := []struct {
+		name      string
+		method    models.CostMethod
+		quantity  float64
+		layerIDs  []uint
+		taken     []float64
+		totalCost float64
+	}{
+		{"FIFO takes the oldest layer first", models.CostMethodFIFO, 8, []uint{1}, []float64{8}, 8000},
+		{"FIFO finishes a partial layer and moves on", models.CostMethodFIFO, 12, []uint{1, 2}, []float64{10, 2}, 14000},
+		{"LIFO takes the newest layer first", models.CostMethodLIFO, 12, []uint{3, 2}, []float64{10, 2}, 34000},
+		{"average costs every unit at the average", models.CostMethodAverage, 12, []uint{1, 2}, []float64{10, 2}, 24000},
+		{"all layers", models.CostMethodFIFO, 25, []uint{1, 2, 3}, []float64{10, 5, 10}, 50000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			consumptions, err := services.ConsumeCostLayers(testCostLayers(), tt.method, 2000, tt.quantity, time.Now())
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(consumptions) != len(tt.layerIDs) {
+				t.Fatalf("Expected %d layers consumed, got %d", len(tt.layerIDs), len(consumptions))
+			}
+
+			var total float64
+			for i, consumption := range consumptions {
+				if consumption.LayerID != tt.layerIDs[i] || consumption.Quantity != tt.taken[i] {
+					t.Errorf("Expected %v from layer %d, got %v from layer %d", tt.taken[i], tt.layerIDs[i], consumption.Quantity, consumption.LayerID)
+				}
+				total += consumption.TotalCost
+			}
+			if total != tt.totalCost {
+				t.Errorf("Expected total cost %v, got %v", tt.totalCost, total)
+			}
+		})
+	}
+}
+
+func TestConsumeCostLayers_OpeningLayerIsOldest(t *testing.T) {
+	layers := append(testCostLayers(), models.StockCostLayer{
+		BaseModel:         models.BaseModel{ID: 9},
+		ReceivedDate:      time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC),
+		RemainingQuantity: 4,
+		UnitCost:          500,
+	})
+
+	fifo, _ := services.ConsumeCostLayers(layers, models.CostMethodFIFO, 0, 1, time.Now())
+	lifo, _ := services.ConsumeCostLayers(layers, models.CostMethodLIFO, 0, 1, time.Now())
+	if len(fifo) != 1 || fifo[0].LayerID != 9 || len(lifo) != 1 || lifo[0].LayerID != 3 {
+		t.Errorf("Expected FIFO from the opening layer and LIFO from the newest receipt, got %+v and %+v", fifo, lifo)
+	}
+}
+
+func TestConsumeCostLayers_Insufficient(t *testing.T) {
+	if _, err := services.ConsumeCostLayers(testCostLayers(), models.CostMethodFIFO, 0, 26, time.Now()); err == nil {
+		t.Errorf("Expected error when the layers hold less than the quantity")
+	}
+}
+
+// Test FEFO Lot Allocation
+func testLots() []models.StockLot {
+	date := func(month time.Month) *time.Time {
+		d := time.Date(2024, month, 1, 0, 0, 0, 0, time.UTC)
+		return &d
+	}
+	return []models.StockLot{
+		{BaseModel: models.BaseModel{ID: 1}, LotNumber: "NO-EXPIRY", Quantity: 10},
+		{BaseModel: models.BaseModel{ID: 2}, LotNumber: "JUN", ExpiryDate: date(6), Quantity: 5},
+		{BaseModel: models.BaseModel{ID: 3}, LotNumber: "FEB", ExpiryDate: date(2), Quantity: 4},
+		{BaseModel: models.BaseModel{ID: 4}, LotNumber: "APR", ExpiryDate: date(4), Quantity: 3},
+		{BaseModel: models.BaseModel{ID: 5}, LotNumber: "EMPTY", ExpiryDate: date(1), Quantity: 0},
+	}
+}
+
+func TestAllocateLotsFEFO(t *testing.T) {
+	tests := []struct {
+		name        string
+		quantity    float64
+		skipExpired bool
+		lotIDs      []uint
+		taken       []float64
+	}{
+		{"earliest expiry first", 3, false, []uint{3}, []float64{3}},
+		{"partial lot then the next expiry", 6, false, []uint{3, 4}, []float64{4, 2}},
+		{"lots without expiry last", 15, false, []uint{3, 4, 2, 1}, []float64{4, 3, 5, 3}},
+		{"expired lots skipped", 6, true, []uint{4, 2}, []float64{3, 3}},
+	}
+
+	asOf := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allocations, err := services.AllocateLotsFEFO(testLots(), tt.quantity, asOf, tt.skipExpired)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if len(allocations) != len(tt.lotIDs) {
+				t.Fatalf("Expected %d lots allocated, got %d", len(tt.lotIDs), len(allocations))
+			}
+			for i, allocation := range allocations {
+				if allocation.LotID != tt.lotIDs[i] || allocation.Quantity != tt.taken[i] {
+					t.Errorf("Expected %v from lot %d, got %v from lot %d", tt.taken[i], tt.lotIDs[i], allocation.Quantity, allocation.LotID)
+				}
+			}
+		})
+	}
+}
+
+func TestAllocateLotsFEFO_Insufficient(t *testing.T) {
+	asOf := time.Date(2024, 3, 15, 0, 0, 0, 0, time.UTC)
+	if _, err := services.AllocateLotsFEFO(testLots(), 20, asOf, true); err == nil {
+		t.Errorf("Expected error when unexpired lots hold less than the quantity")
+	}
+}
+
+// Test Unit of Measure Conversion
+func testUnitProduct() *models.Product {
+	return &models.Product{
+		Unit: "pcs",
+		Units: []models.ProductUnit{
+			{Unit: models.UnitOfMeasure{Code: "pack"}, Factor: 12, IsSalesUnit: true},
+			{Unit: models.UnitOfMeasure{Code: "ctn"}, Factor: 144, IsPurchaseUnit: true},
+		},
+	}
+}
+
+func TestConvertToBaseUnit(t *testing.T) {
+	tests := []struct {
+		name     string
+		unit     string
+		quantity float64
+		unitCost float64
+		wantUnit string
+		baseQty  float64
+		baseCost float64
+		factor   float64
+	}{
+		{"empty unit is the base unit", "", 5, 1000, "pcs", 5, 1000, 1},
+		{"base unit", "pcs", 5, 1000, "pcs", 5, 1000, 1},
+		{"alternate unit", "pack", 2, 12000, "pack", 24, 1000, 12},
+		{"purchase unit alias", "purchase", 1, 144000, "ctn", 144, 1000, 144},
+		{"sales unit alias", "sales", 3, 6000, "pack", 36, 500, 12},
+		{"unit cost kept to six decimals", "pack", 1, 10000, "pack", 12, 833.333333, 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			movement := &models.StockMovement{Unit: tt.unit, Quantity: tt.quantity, UnitCost: tt.unitCost}
+			if err := services.ConvertToBaseUnit(testUnitProduct(), movement); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if movement.Unit != tt.wantUnit || movement.UnitQuantity != tt.quantity || movement.ConversionFactor != tt.factor {
+				t.Errorf("Expected %v %s at factor %v, got %v %s at factor %v", tt.quantity, tt.wantUnit, tt.factor, movement.UnitQuantity, movement.Unit, movement.ConversionFactor)
+			}
+			if movement.Quantity != tt.baseQty || movement.UnitCost != tt.baseCost {
+				t.Errorf("Expected %v base units at %v, got %v at %v", tt.baseQty, tt.baseCost, movement.Quantity, movement.UnitCost)
+			}
+		})
+	}
+}
+
+func TestConvertToBaseUnit_UnknownUnit(t *testing.T) {
+	movement := &models.StockMovement{Unit: "box", Quantity: 1}
+	if err := services.ConvertToBaseUnit(testUnitProduct(), movement); err == nil {
+		t.Errorf("Expected error for a unit the product does not define")
+	}
+}
+
+// Test Stock Reservations
+func TestCheckReservedStock(t *testing.T) {
+	tests := []struct {
+		name     string
+		onHand   float64
+		reserved float64
+		held     float64
+		quantity float64
+		covered  float64
+		wantErr  bool
+	}{
+		{"nothing reserved", 10, 0, 0, 10, 0, false},
+		{"takes only the unreserved part", 10, 4, 0, 6, 0, false},
+		{"would take reserved stock", 10, 4, 0, 7, 0, true},
+		{"fulfills its own reservation", 10, 4, 4, 10, 4, false},
+		{"partly covered by its reservation", 10, 6, 2, 5, 2, false},
+		{"its reservation does not free others", 10, 6, 2, 7, 0, true},
+		{"plain shortage left to cost layers", 5, 0, 0, 8, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			covered, err := services.CheckReservedStock(tt.onHand, tt.reserved, tt.held, tt.quantity)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Expected error, got covered %v", covered)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if covered != tt.covered {
+				t.Errorf("Expected %v covered by the reservation, got %v", tt.covered, covered)
+			}
+		})
+	}
+}
+
+// Test Reorder Suggestions
+func TestCalculateReorderSuggestion_BelowReorderPoint(t *testing.T) {
+	product := &models.Product{
+		Code:            "BRG-001",
+		Unit:            "pcs",
+		LeadTimeDays:    7,
+		SafetyStock:     10,
+		ReorderQuantity: 20,
+	}
+
+	// 90 pcs issued over 30 days is 3 pcs a day, 21 pcs over the lead time
+	suggestion := services.CalculateReorderSuggestion(product, 25, 90, 30)
+
+	if suggestion.ReorderPoint != 31 {
+		t.Errorf("Expected reorder point 31, got %v", suggestion.ReorderPoint)
+	}
+
+	if !suggestion.NeedsReorder {
+		t.Errorf("Expected product to need reorder")
+	}
+
+	if suggestion.SuggestedQuantity != 27 {
+		t.Errorf("Expected suggested quantity 27, got %v", suggestion.SuggestedQuantity)
+	}
+}
+
+func TestCalculateReorderSuggestion_MinimumOrderQuantity(t *testing.T) {
+	product := &models.Product{
+		Unit:            "pcs",
+		MinStock:        50,
+		ReorderQuantity: 100,
+	}
+
+	suggestion := services.CalculateReorderSuggestion(product, 40, 0, 90)
+
+	if suggestion.SuggestedQuantity != 100 {
+		t.Errorf("Expected suggested quantity 100, got %v", suggestion.SuggestedQuantity)
+	}
+}
+
+func TestCalculateReorderSuggestion_PurchaseUnit(t *testing.T) {
+	product := &models.Product{
+		Unit:     "pcs",
+		MinStock: 30,
+		Units: []models.ProductUnit{
+			{Unit: models.UnitOfMeasure{Code: "box"}, Factor: 12, IsPurchaseUnit: true},
+		},
+	}
+
+	suggestion := services.CalculateReorderSuggestion(product, 5, 0, 90)
+
+	if suggestion.PurchaseUnit != "box" {
+		t.Errorf("Expected purchase unit box, got %s", suggestion.PurchaseUnit)
+	}
+
+	// 25 pcs rounds up to 3 boxes
+	if suggestion.SuggestedPurchaseQuantity != 3 {
+		t.Errorf("Expected 3 boxes, got %v", suggestion.SuggestedPurchaseQuantity)
+	}
+}
+
+func TestCalculateReorderSuggestion_SufficientStock(t *testing.T) {
+	product := &models.Product{
+		Unit:         "pcs",
+		ReorderPoint: 20,
+	}
+
+	suggestion := services.CalculateReorderSuggestion(product, 50, 0, 90)
+
+	if suggestion.NeedsReorder {
+		t.Errorf("Expected product not to need reorder")
+	}
+
+	if suggestion.SuggestedQuantity != 0 {
+		t.Errorf("Expected no suggested quantity, got %v", suggestion.SuggestedQuantity)
+	}
+}
+
+// Test Landed Cost Allocation
+func TestAllocateLandedCost_ByValue(t *testing.T) {
+	items := []models.LandedCostItem{
+		{Quantity: 10, Value: 1000000},
+		{Quantity: 30, Value: 3000000},
+	}
+
+	if err := services.AllocateLandedCost(models.LandedCostByValue, 400000, items); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if items[0].AllocatedAmount != 100000 || items[1].AllocatedAmount != 300000 {
+		t.Errorf("Expected 100000 and 300000, got %v and %v", items[0].AllocatedAmount, items[1].AllocatedAmount)
+	}
+}
+
+func TestAllocateLandedCost_RoundingToLastItem(t *testing.T) {
+	items := []models.LandedCostItem{
+		{Quantity: 1},
+		{Quantity: 1},
+		{Quantity: 1},
+	}
+
+	if err := services.AllocateLandedCost(models.LandedCostByQuantity, 100, items); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	total := items[0].AllocatedAmount + items[1].AllocatedAmount + items[2].AllocatedAmount
+	if items[2].AllocatedAmount != 33.34 || total != 100 {
+		t.Errorf("Expected last item 33.34 and total 100, got %v and %v", items[2].AllocatedAmount, total)
+	}
+}
+
+func TestAllocateLandedCost_NoWeight(t *testing.T) {
+	items := []models.LandedCostItem{
+		{Quantity: 5, Value: 500},
+	}
+
+	if err := services.AllocateLandedCost(models.LandedCostByWeight, 100, items); err == nil {
+		t.Errorf("Expected error when no item has a weight")
+	}
+}
+
+// Test Inventory Aging
+func TestBuildInventoryAging_Buckets(t *testing.T) {
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	layers := []models.CostLayerReport{
+		{ProductID: 1, ProductCode: "BRG-001", ReceivedDate: "2024-12-15", RemainingQuantity: 10, RemainingValue: 100000},
+		{ProductID: 1, ProductCode: "BRG-001", ReceivedDate: "2024-05-01", RemainingQuantity: 5, RemainingValue: 50000},
+	}
+	lastIssues := map[uint]time.Time{1: time.Date(2024, 12, 20, 0, 0, 0, 0, time.UTC)}
+
+	report := services.BuildInventoryAging(layers, lastIssues, asOf, 90, 180)
+
+	if len(report.Lines) != 1 {
+		t.Fatalf("Expected 1 line, got %d", len(report.Lines))
+	}
+
+	line := report.Lines[0]
+	if line.Days0To30 != 100000 || line.DaysOver180 != 50000 {
+		t.Errorf("Expected 100000 in 0-30 and 50000 over 180, got %v and %v", line.Days0To30, line.DaysOver180)
+	}
+
+	if line.OldestReceiptDate != "2024-05-01" {
+		t.Errorf("Expected oldest receipt 2024-05-01, got %s", line.OldestReceiptDate)
+	}
+
+	if line.Status != models.StockAgeActive {
+		t.Errorf("Expected status active, got %s", line.Status)
+	}
+}
+
+func TestBuildInventoryAging_SlowAndDeadStock(t *testing.T) {
+	asOf := time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC)
+	layers := []models.CostLayerReport{
+		{ProductID: 1, ReceivedDate: "2024-06-01", RemainingQuantity: 1, RemainingValue: 1000},
+		{ProductID: 2, ReceivedDate: "2024-01-01", RemainingQuantity: 1, RemainingValue: 2000},
+	}
+	// product 1 last issued 120 days ago, product 2 never issued
+	lastIssues := map[uint]time.Time{1: asOf.AddDate(0, 0, -120)}
+
+	report := services.BuildInventoryAging(layers, lastIssues, asOf, 90, 180)
+
+	if report.Lines[0].Status != models.StockAgeSlowMoving {
+		t.Errorf("Expected product 1 slow moving, got %s", report.Lines[0].Status)
+	}
+
+	if report.Lines[1].Status != models.StockAgeDead {
+		t.Errorf("Expected product 2 dead stock, got %s", report.Lines[1].Status)
+	}
+
+	if report.SlowMovingValue != 1000 || report.DeadStockValue != 2000 || report.TotalValue != 3000 {
+		t.Errorf("Expected 1000 slow, 2000 dead, 3000 total, got %v, %v, %v", report.SlowMovingValue, report.DeadStockValue, report.TotalValue)
+	}
+}
+
+// Test Stock Opname Tolerance
+func TestOpnameVarianceExceedsTolerance_Percent(t *testing.T) {
+	// 2% of 200 allows a variance of 4
+	if services.OpnameVarianceExceedsTolerance(200, 197, 2, 0) {
+		t.Errorf("Expected variance of 3 to be within tolerance")
+	}
+
+	if !services.OpnameVarianceExceedsTolerance(200, 195, 2, 0) {
+		t.Errorf("Expected variance of 5 to exceed tolerance")
+	}
+}
+
+func TestOpnameVarianceExceedsTolerance_QuantityFloor(t *testing.T) {
+	// 2% of 10 is 0.2, the quantity tolerance of 1 is larger
+	if services.OpnameVarianceExceedsTolerance(10, 11, 2, 1) {
+		t.Errorf("Expected variance of 1 to be within tolerance")
+	}
+}
+
+func TestOpnameVarianceExceedsTolerance_NoTolerance(t *testing.T) {
+	if !services.OpnameVarianceExceedsTolerance(0, 1, 0, 0) {
+		t.Errorf("Expected any variance to exceed a zero tolerance")
+	}
+
+	if services.OpnameVarianceExceedsTolerance(50, 50, 0, 0) {
+		t.Errorf("Expected no variance to be within tolerance")
+	}
+}
\ No newline at end of file
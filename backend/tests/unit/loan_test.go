@@ -0,0 +1,74 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+	"time"
+)
+
+func testLoan(method models.LoanMethod, tenor int) *models.Loan {
+	return &models.Loan{
+		Principal:    12000000,
+		InterestRate: 12,
+		Method:       method,
+		TenorMonths:  tenor,
+		StartDate:    time.Date(2026, time.January, 5, 0, 0, 0, 0, time.UTC),
+		FirstDueDate: time.Date(2026, time.January, 31, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// Test Loan Schedules
+func TestBuildLoanSchedule_Annuity(t *testing.T) {
+	schedule := services.BuildLoanSchedule(testLoan(models.LoanMethodAnnuity, 12))
+	if len(schedule) != 12 {
+		t.Fatalf("Expected 12 installments, got %d", len(schedule))
+	}
+
+	first := schedule[0]
+	if first.Amount != 1066185.46 || first.Interest != 120000 || first.Principal != 946185.46 {
+		t.Errorf("Expected first installment 1066185.46 = 946185.46 + 120000, got %v = %v + %v", first.Amount, first.Principal, first.Interest)
+	}
+
+	// The last installment clears the cents left by rounding
+	last := schedule[11]
+	if last.Principal != 1055629.23 || last.Amount != 1066185.52 || last.ClosingBalance != 0 {
+		t.Errorf("Expected last installment 1066185.52 closing at 0, got %v closing at %v", last.Amount, last.ClosingBalance)
+	}
+
+	// Due on the same day each month, or the last day of shorter months
+	if !schedule[1].DueDate.Equal(time.Date(2026, time.February, 28, 0, 0, 0, 0, time.UTC)) ||
+		!schedule[2].DueDate.Equal(time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected due dates 2026-02-28 and 2026-03-31, got %v and %v", schedule[1].DueDate, schedule[2].DueDate)
+	}
+}
+
+func TestBuildLoanSchedule_FlatAndEffective(t *testing.T) {
+	flat := services.BuildLoanSchedule(testLoan(models.LoanMethodFlat, 12))
+	for _, installment := range []models.LoanInstallment{flat[0], flat[11]} {
+		if installment.Principal != 1000000 || installment.Interest != 120000 {
+			t.Errorf("Expected flat installments of 1000000 + 120000, got %v + %v", installment.Principal, installment.Interest)
+		}
+	}
+
+	effective := services.BuildLoanSchedule(testLoan(models.LoanMethodEffective, 12))
+	if effective[0].Interest != 120000 || effective[1].Interest != 110000 || effective[11].Interest != 10000 {
+		t.Errorf("Expected effective interest of 120000, 110000 .. 10000, got %v, %v .. %v", effective[0].Interest, effective[1].Interest, effective[11].Interest)
+	}
+	if effective[11].Principal != 1000000 || effective[11].ClosingBalance != 0 {
+		t.Errorf("Expected equal principal repaid to 0, got %v closing at %v", effective[11].Principal, effective[11].ClosingBalance)
+	}
+}
+
+func TestCalculateCurrentPortion(t *testing.T) {
+	schedule := services.BuildLoanSchedule(testLoan(models.LoanMethodEffective, 24))
+	for i := 0; i < 3; i++ {
+		schedule[i].IsPaid = true
+	}
+
+	// Installments 4 to 15 fall due by the end of March 2027
+	portion := services.CalculateCurrentPortion(schedule, time.Date(2026, time.March, 31, 0, 0, 0, 0, time.UTC))
+	if portion != 6000000 {
+		t.Errorf("Expected a current portion of 6000000, got %v", portion)
+	}
+}
\ No newline at end of file
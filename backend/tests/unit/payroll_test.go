@@ -0,0 +1,108 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+	"time"
+)
+
+func defaultPayrollSetting() *models.PayrollSetting {
+	return &models.PayrollSetting{
+		JKKRate:        0.24,
+		HealthWageCap:  12000000,
+		PensionWageCap: 10547400,
+	}
+}
+
+// Test Payslip Calculation
+func TestCalculatePayslip_BPJSAndTER(t *testing.T) {
+	accountID := uint(7)
+	employee := &models.Employee{
+		PTKPStatus:        models.PTKPStatusTK0,
+		BaseSalary:        10000000,
+		HealthInsured:     true,
+		EmploymentInsured: true,
+		PensionInsured:    true,
+		Components: []models.EmployeeSalaryComponent{
+			{
+				ComponentID: 1,
+				Amount:      1000000,
+				Component:   models.SalaryComponent{Code: "TRANS", Type: models.SalaryComponentAllowance, IsTaxable: true},
+			},
+			{
+				ComponentID: 2,
+				Amount:      200000,
+				Component:   models.SalaryComponent{Code: "KOP", Type: models.SalaryComponentDeduction, AccountID: &accountID},
+			},
+		},
+	}
+
+	payslip := services.CalculatePayslip(employee, defaultPayrollSetting(), time.Date(2026, time.March, 1, 0, 0, 0, 0, time.UTC), &models.PayrollYearToDate{}, 1)
+
+	// BPJS on a wage of 11.000.000, JP capped at its ceiling
+	if payslip.HealthEmployer != 440000 || payslip.HealthEmployee != 110000 {
+		t.Errorf("Expected BPJS Kesehatan 440000/110000, got %v/%v", payslip.HealthEmployer, payslip.HealthEmployee)
+	}
+	if payslip.JHTEmployer != 407000 || payslip.JHTEmployee != 220000 || payslip.JKKEmployer != 26400 || payslip.JKMEmployer != 33000 {
+		t.Errorf("Unexpected BPJS Ketenagakerjaan: JHT %v/%v, JKK %v, JKM %v", payslip.JHTEmployer, payslip.JHTEmployee, payslip.JKKEmployer, payslip.JKMEmployer)
+	}
+	if payslip.JPEmployer != 210948 || payslip.JPEmployee != 105474 {
+		t.Errorf("Expected JP 210948/105474, got %v/%v", payslip.JPEmployer, payslip.JPEmployee)
+	}
+
+	// Gross income takes in the employer's health, JKK and JKM premiums
+	if payslip.GrossIncome != 11499400 {
+		t.Errorf("Expected gross income 11499400, got %v", payslip.GrossIncome)
+	}
+	if payslip.TERCategory != "A" || payslip.TERRate != 3.5 || payslip.PPh21 != 402479 {
+		t.Errorf("Expected TER A 3.5%% = 402479, got %v %v%% = %v", payslip.TERCategory, payslip.TERRate, payslip.PPh21)
+	}
+
+	if payslip.NetPay != 9962047 {
+		t.Errorf("Expected net pay 9962047, got %v", payslip.NetPay)
+	}
+	if len(payslip.Lines) != 2 || payslip.Lines[0].AccountID != 1 || payslip.Lines[1].AccountID != accountID {
+		t.Errorf("Expected the allowance on the salary account and the deduction on its own, got %+v", payslip.Lines)
+	}
+}
+
+func TestCalculatePayslip_DecemberSettlesYear(t *testing.T) {
+	employee := &models.Employee{
+		PTKPStatus: models.PTKPStatusTK0,
+		BaseSalary: 10000000,
+	}
+
+	// Eleven months at TER A 2% withheld 2.200.000
+	ytd := &models.PayrollYearToDate{
+		Months:      11,
+		GrossIncome: 110000000,
+		PPh21:       2200000,
+	}
+
+	payslip := services.CalculatePayslip(employee, defaultPayrollSetting(), time.Date(2026, time.December, 1, 0, 0, 0, 0, time.UTC), ytd, 1)
+
+	// 120.000.000 - 6.000.000 biaya jabatan - 54.000.000 PTKP at 5%
+	if !payslip.IsAnnualCalculation || payslip.PPh21 != 800000 {
+		t.Errorf("Expected December PPh 21 of 800000, got %v", payslip.PPh21)
+	}
+	if payslip.NetPay != 9200000 {
+		t.Errorf("Expected net pay 9200000, got %v", payslip.NetPay)
+	}
+}
+
+func TestCalculateAnnualPPh21_Progressive(t *testing.T) {
+	// 300.000.000 - 6.000.000 - 3.600.000 - 63.000.000 = 227.400.000
+	tax := services.CalculateAnnualPPh21(models.PTKPStatusK1, 300000000, 3600000, 12)
+	if tax != 28110000 {
+		t.Errorf("Expected 28110000, got %v", tax)
+	}
+
+	if tax := services.CalculateAnnualPPh21(models.PTKPStatusK3, 60000000, 0, 12); tax != 0 {
+		t.Errorf("Expected no tax below PTKP, got %v", tax)
+	}
+
+	if services.TERCategory(models.PTKPStatusK1) != "B" || services.TERRate("B", 9200000) != 1 {
+		t.Errorf("Expected K/1 in category B at 1%% for 9200000")
+	}
+}
\ No newline at end of file
@@ -0,0 +1,203 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+)
+
+// Test Purchase Bill Totals
+func TestCalculatePurchaseBillTotals_WithholdingOnServices(t *testing.T) {
+	productID := uint(1)
+	accountID := uint(2)
+	bill := &models.PurchaseBill{
+		TaxRate:         11,
+		WithholdingType: models.TaxTypePPh23,
+		WithholdingRate: 2,
+		Items: []models.PurchaseBillItem{
+			{ProductID: &productID, Quantity: 10, UnitPrice: 50000},
+			{AccountID: &accountID, Quantity: 1, UnitPrice: 1000000, IsWithholdable: true},
+		},
+	}
+
+	if err := services.CalculatePurchaseBillTotals(bill); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if bill.Subtotal != 1500000 || bill.TaxAmount != 165000 || bill.TotalAmount != 1665000 {
+		t.Errorf("Expected 1500000 subtotal, 165000 PPN, 1665000 total, got %v, %v, %v", bill.Subtotal, bill.TaxAmount, bill.TotalAmount)
+	}
+
+	// PPh 23 on the service line only
+	if bill.WithholdingAmount != 20000 || bill.PayableAmount != 1645000 {
+		t.Errorf("Expected 20000 withheld and 1645000 payable, got %v and %v", bill.WithholdingAmount, bill.PayableAmount)
+	}
+}
+
+func TestCalculatePurchaseBillTotals_NoWithholding(t *testing.T) {
+	bill := &models.PurchaseBill{
+		WithholdingRate: 2,
+		Items: []models.PurchaseBillItem{
+			{Quantity: 3, UnitPrice: 1000, DiscountPercent: 10, IsWithholdable: true},
+		},
+	}
+
+	if err := services.CalculatePurchaseBillTotals(bill); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if bill.WithholdingAmount != 0 || bill.PayableAmount != 2700 {
+		t.Errorf("Expected nothing withheld and 2700 payable, got %v and %v", bill.WithholdingAmount, bill.PayableAmount)
+	}
+}
+
+func TestCalculatePurchaseBillTotals_InvalidWithholding(t *testing.T) {
+	bill := &models.PurchaseBill{
+		WithholdingType: models.TaxTypePPh21,
+		WithholdingRate: 5,
+		Items: []models.PurchaseBillItem{
+			{Quantity: 1, UnitPrice: 100, IsWithholdable: true},
+		},
+	}
+
+	if err := services.CalculatePurchaseBillTotals(bill); err == nil {
+		t.Errorf("Expected error for an unsupported withholding type")
+	}
+
+	bill = &models.PurchaseBill{
+		WithholdingType: models.TaxTypePPh23,
+		WithholdingRate: 2,
+		Items: []models.PurchaseBillItem{
+			{Quantity: 1, UnitPrice: 100},
+		},
+	}
+
+	if err := services.CalculatePurchaseBillTotals(bill); err == nil {
+		t.Errorf("Expected error when no line is withholdable")
+	}
+}
+// Test Purchase Debit Note Totals
+func TestCalculatePurchaseDebitNoteTotals_ReversesWithholding(t *testing.T) {
+	productID := uint(1)
+	accountID := uint(2)
+	bill := &models.PurchaseBill{
+		TaxRate:         11,
+		WithholdingType: models.TaxTypePPh23,
+		WithholdingRate: 2,
+		Items: []models.PurchaseBillItem{
+			{BaseModel: models.BaseModel{ID: 1}, ProductID: &productID, Quantity: 10, Subtotal: 500000},
+			{BaseModel: models.BaseModel{ID: 2}, AccountID: &accountID, Quantity: 1, Subtotal: 1000000, IsWithholdable: true},
+		},
+	}
+	debitNote := &models.PurchaseDebitNote{
+		Items: []models.PurchaseDebitNoteItem{
+			{BillItemID: 1, Quantity: 4},
+			{BillItemID: 2, Amount: 100000},
+		},
+	}
+
+	if err := services.CalculatePurchaseDebitNoteTotals(debitNote, bill, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if debitNote.Items[0].Amount != 200000 {
+		t.Errorf("Expected 200000 debited for the returned goods, got %v", debitNote.Items[0].Amount)
+	}
+
+	if debitNote.Subtotal != 300000 || debitNote.TaxAmount != 33000 || debitNote.TotalAmount != 333000 {
+		t.Errorf("Expected 300000 subtotal, 33000 PPN, 333000 total, got %v, %v, %v", debitNote.Subtotal, debitNote.TaxAmount, debitNote.TotalAmount)
+	}
+
+	// PPh 23 reversed on the service correction only
+	if debitNote.WithholdingAmount != 2000 || debitNote.PayableAmount != 331000 {
+		t.Errorf("Expected 2000 withholding reversed and 331000 payable, got %v and %v", debitNote.WithholdingAmount, debitNote.PayableAmount)
+	}
+}
+
+func TestCalculatePurchaseDebitNoteTotals_Invalid(t *testing.T) {
+	accountID := uint(2)
+	bill := &models.PurchaseBill{
+		Items: []models.PurchaseBillItem{
+			{BaseModel: models.BaseModel{ID: 2}, AccountID: &accountID, Quantity: 1, Subtotal: 1000000},
+		},
+	}
+
+	debitNote := &models.PurchaseDebitNote{
+		Items: []models.PurchaseDebitNoteItem{{BillItemID: 2, Quantity: 1}},
+	}
+	if err := services.CalculatePurchaseDebitNoteTotals(debitNote, bill, nil); err == nil {
+		t.Errorf("Expected error when returning goods on an expense line")
+	}
+
+	debited := map[uint]models.PurchaseDebitNoteItem{2: {Amount: 950000}}
+	debitNote = &models.PurchaseDebitNote{
+		Items: []models.PurchaseDebitNoteItem{{BillItemID: 2, Amount: 100000}},
+	}
+	if err := services.CalculatePurchaseDebitNoteTotals(debitNote, bill, debited); err == nil {
+		t.Errorf("Expected error when debiting more than was billed")
+	}
+}
+
+// Test Purchase Order Totals
+func TestCalculatePurchaseOrderTotals(t *testing.T) {
+	order := &models.PurchaseOrder{
+		TaxRate: 11,
+		Items: []models.PurchaseOrderItem{
+			{Quantity: 10, UnitPrice: 50000, DiscountPercent: 10},
+			{Quantity: 4, UnitPrice: 25000},
+		},
+	}
+
+	if err := services.CalculatePurchaseOrderTotals(order); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if order.Items[0].DiscountAmount != 50000 || order.Items[0].Subtotal != 450000 {
+		t.Errorf("Expected 50000 discount and 450000 subtotal, got %v and %v", order.Items[0].DiscountAmount, order.Items[0].Subtotal)
+	}
+	if order.Subtotal != 550000 || order.TaxAmount != 60500 || order.TotalAmount != 610500 {
+		t.Errorf("Expected 550000 subtotal, 60500 PPN, 610500 total, got %v, %v, %v", order.Subtotal, order.TaxAmount, order.TotalAmount)
+	}
+
+	// Net unit price is what the goods are received at
+	if order.Items[0].NetUnitPrice() != 45000 {
+		t.Errorf("Expected net unit price 45000, got %v", order.Items[0].NetUnitPrice())
+	}
+}
+
+// Test Three-way Match
+func TestMatchPurchaseBillLine_WithinTolerance(t *testing.T) {
+	receiptItem := &models.GoodsReceiptItem{
+		Quantity:          10,
+		BilledQuantity:    4,
+		PurchaseOrderItem: models.PurchaseOrderItem{Quantity: 10, Subtotal: 450000},
+	}
+	tolerance := &models.PurchaseMatchTolerance{QuantityPercent: 0, PricePercent: 2}
+
+	// 6 left to bill at 45000, billed at 45500 is within 2%
+	item := &models.PurchaseBillItem{Description: "Widget", Quantity: 6, Subtotal: 273000}
+	if discrepancies := services.MatchPurchaseBillLine(item, receiptItem, tolerance); len(discrepancies) != 0 {
+		t.Errorf("Expected no discrepancies, got %v", discrepancies)
+	}
+}
+
+func TestMatchPurchaseBillLine_Mismatch(t *testing.T) {
+	receiptItem := &models.GoodsReceiptItem{
+		Quantity:          10,
+		BilledQuantity:    4,
+		PurchaseOrderItem: models.PurchaseOrderItem{Quantity: 10, Subtotal: 450000},
+	}
+	tolerance := &models.PurchaseMatchTolerance{QuantityPercent: 10, PricePercent: 2}
+
+	// 7 exceeds 6 unbilled plus 10%
+	item := &models.PurchaseBillItem{Description: "Widget", Quantity: 7, Subtotal: 315000}
+	if discrepancies := services.MatchPurchaseBillLine(item, receiptItem, tolerance); len(discrepancies) != 1 {
+		t.Errorf("Expected a quantity discrepancy, got %v", discrepancies)
+	}
+
+	// 48000 is more than 2% over the ordered 45000
+	item = &models.PurchaseBillItem{Description: "Widget", Quantity: 6, Subtotal: 288000}
+	if discrepancies := services.MatchPurchaseBillLine(item, receiptItem, tolerance); len(discrepancies) != 1 {
+		t.Errorf("Expected a price discrepancy, got %v", discrepancies)
+	}
+}
\ No newline at end of file
@@ -0,0 +1,211 @@
+package unit
+
+import (
+	"finara-backend/internal/models"
+	"finara-backend/internal/services"
+	"testing"
+)
+
+// Test Sales Invoice Totals
+func TestCalculateSalesInvoiceTotals_WithPPN(t *testing.T) {
+	invoice := &models.SalesInvoice{
+		TaxRate: 11,
+		Items: []models.SalesInvoiceItem{
+			{Quantity: 10, UnitPrice: 100000},
+			{Quantity: 4, UnitPrice: 250000, DiscountPercent: 10},
+		},
+	}
+
+	if err := services.CalculateSalesInvoiceTotals(invoice); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if invoice.Items[1].DiscountAmount != 100000 || invoice.Items[1].Subtotal != 900000 {
+		t.Errorf("Expected line discount 100000 and subtotal 900000, got %v and %v", invoice.Items[1].DiscountAmount, invoice.Items[1].Subtotal)
+	}
+
+	if invoice.Subtotal != 1900000 || invoice.TaxAmount != 209000 || invoice.TotalAmount != 2109000 {
+		t.Errorf("Expected 1900000 subtotal, 209000 PPN, 2109000 total, got %v, %v, %v", invoice.Subtotal, invoice.TaxAmount, invoice.TotalAmount)
+	}
+}
+
+func TestCalculateSalesInvoiceTotals_HeaderDiscountBeforePPN(t *testing.T) {
+	invoice := &models.SalesInvoice{
+		TaxRate:        11,
+		DiscountAmount: 100000,
+		Items: []models.SalesInvoiceItem{
+			{Quantity: 1, UnitPrice: 1000000},
+		},
+	}
+
+	if err := services.CalculateSalesInvoiceTotals(invoice); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if invoice.TaxAmount != 99000 || invoice.TotalAmount != 999000 {
+		t.Errorf("Expected 99000 PPN and 999000 total, got %v and %v", invoice.TaxAmount, invoice.TotalAmount)
+	}
+}
+
+func TestCalculateSalesInvoiceTotals_Invalid(t *testing.T) {
+	invoice := &models.SalesInvoice{
+		DiscountAmount: 500,
+		Items: []models.SalesInvoiceItem{
+			{Quantity: 1, UnitPrice: 100},
+		},
+	}
+
+	if err := services.CalculateSalesInvoiceTotals(invoice); err == nil {
+		t.Errorf("Expected error when the discount exceeds the subtotal")
+	}
+
+	invoice = &models.SalesInvoice{
+		Items: []models.SalesInvoiceItem{
+			{Quantity: 1, UnitPrice: 100, DiscountPercent: 120},
+		},
+	}
+
+	if err := services.CalculateSalesInvoiceTotals(invoice); err == nil {
+		t.Errorf("Expected error for a discount over 100 percent")
+	}
+}
+// Test Sales Credit Note Totals
+func TestCalculateSalesCreditNoteTotals_ReturnAfterHeaderDiscount(t *testing.T) {
+	invoice := &models.SalesInvoice{
+		TaxRate:        11,
+		Subtotal:       1000000,
+		DiscountAmount: 100000,
+		Items: []models.SalesInvoiceItem{
+			{BaseModel: models.BaseModel{ID: 1}, Quantity: 10, Subtotal: 600000},
+			{BaseModel: models.BaseModel{ID: 2}, Quantity: 4, Subtotal: 400000},
+		},
+	}
+	creditNote := &models.SalesCreditNote{
+		Items: []models.SalesCreditNoteItem{
+			{InvoiceItemID: 1, Quantity: 5},
+			{InvoiceItemID: 2, Amount: 50000},
+		},
+	}
+
+	if err := services.CalculateSalesCreditNoteTotals(creditNote, invoice, nil); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	// Half of the line after its 60000 share of the header discount
+	if creditNote.Items[0].Amount != 270000 {
+		t.Errorf("Expected 270000 credited for the returned goods, got %v", creditNote.Items[0].Amount)
+	}
+
+	if creditNote.Subtotal != 320000 || creditNote.TaxAmount != 35200 || creditNote.TotalAmount != 355200 {
+		t.Errorf("Expected 320000 subtotal, 35200 PPN, 355200 total, got %v, %v, %v", creditNote.Subtotal, creditNote.TaxAmount, creditNote.TotalAmount)
+	}
+}
+
+func TestCalculateSalesCreditNoteTotals_Invalid(t *testing.T) {
+	invoice := &models.SalesInvoice{
+		Subtotal: 600000,
+		Items: []models.SalesInvoiceItem{
+			{BaseModel: models.BaseModel{ID: 1}, Quantity: 10, Subtotal: 600000},
+		},
+	}
+	credited := map[uint]models.SalesCreditNoteItem{1: {Quantity: 6, Amount: 360000}}
+
+	creditNote := &models.SalesCreditNote{
+		Items: []models.SalesCreditNoteItem{{InvoiceItemID: 1, Quantity: 5}},
+	}
+	if err := services.CalculateSalesCreditNoteTotals(creditNote, invoice, credited); err == nil {
+		t.Errorf("Expected error when returning more than is left on the invoice line")
+	}
+
+	creditNote = &models.SalesCreditNote{
+		Items: []models.SalesCreditNoteItem{{InvoiceItemID: 1, Amount: 250000}},
+	}
+	if err := services.CalculateSalesCreditNoteTotals(creditNote, invoice, credited); err == nil {
+		t.Errorf("Expected error when crediting more than was invoiced")
+	}
+
+	creditNote = &models.SalesCreditNote{
+		Items: []models.SalesCreditNoteItem{{InvoiceItemID: 9, Amount: 100}},
+	}
+	if err := services.CalculateSalesCreditNoteTotals(creditNote, invoice, nil); err == nil {
+		t.Errorf("Expected error for a line not on the invoice")
+	}
+}
+
+func TestUnappliedNoteAmount(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      float64
+		outstanding float64
+		expected    float64
+	}{
+		{"within the open balance", 300000, 500000, 0},
+		{"partly paid invoice", 300000, 100000, 200000},
+		{"fully paid invoice", 300000, 0, 300000},
+		{"overpaid invoice", 300000, -50000, 300000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := services.UnappliedNoteAmount(tt.amount, tt.outstanding); got != tt.expected {
+				t.Errorf("Expected %v held as credit, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestSalesCreditNote_AppliedAndAvailableCredit(t *testing.T) {
+	invoice := &models.SalesInvoice{TotalAmount: 555000, PaidAmount: 455000}
+	creditNote := &models.SalesCreditNote{TotalAmount: 333000}
+	creditNote.UnappliedAmount = services.UnappliedNoteAmount(creditNote.TotalAmount, invoice.Outstanding())
+
+	// Aging and the invoice only count the part applied to the invoice
+	if creditNote.AppliedAmount() != 100000 || creditNote.UnappliedAmount != 233000 {
+		t.Errorf("Expected 100000 applied and 233000 unapplied, got %v and %v", creditNote.AppliedAmount(), creditNote.UnappliedAmount)
+	}
+
+	creditNote.CreditUsedAmount = 200000
+	if creditNote.AvailableCredit() != 33000 {
+		t.Errorf("Expected 33000 customer credit left, got %v", creditNote.AvailableCredit())
+	}
+}
+
+// Test Sales Order Totals
+func TestCalculateSalesOrderTotals(t *testing.T) {
+	order := &models.SalesOrder{
+		TaxRate: 11,
+		Items: []models.SalesOrderItem{
+			{Quantity: 3, UnitPrice: 150000, DiscountPercent: 5},
+			{Quantity: 2, UnitPrice: 75000},
+		},
+	}
+
+	if err := services.CalculateSalesOrderTotals(order); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if order.Items[0].DiscountAmount != 22500 || order.Items[0].Subtotal != 427500 {
+		t.Errorf("Expected 22500 discount and 427500 subtotal, got %v and %v", order.Items[0].DiscountAmount, order.Items[0].Subtotal)
+	}
+	if order.Subtotal != 577500 || order.TaxAmount != 63525 || order.TotalAmount != 641025 {
+		t.Errorf("Expected 577500 subtotal, 63525 PPN, 641025 total, got %v, %v, %v", order.Subtotal, order.TaxAmount, order.TotalAmount)
+	}
+}
+
+func TestCalculateSalesQuotationTotals_Invalid(t *testing.T) {
+	quotation := &models.SalesQuotation{
+		TaxRate: 11,
+		Items:   []models.SalesQuotationItem{{Quantity: 1, UnitPrice: 100000, DiscountPercent: 120}},
+	}
+	if err := services.CalculateSalesQuotationTotals(quotation); err == nil {
+		t.Errorf("Expected error for a discount over 100 percent")
+	}
+
+	quotation = &models.SalesQuotation{
+		TaxRate: 11,
+		Items:   []models.SalesQuotationItem{{Quantity: 0, UnitPrice: 100000}},
+	}
+	if err := services.CalculateSalesQuotationTotals(quotation); err == nil {
+		t.Errorf("Expected error for a zero quantity")
+	}
+}
\ No newline at end of file